@@ -43,6 +43,9 @@ type StringNode struct {
 	*Base
 	Key   string
 	Value string
+	// Mode defines how the value is matched, like "phonetic" for name~phonetic:jonson,
+	// it is empty for the default match.
+	Mode string
 }
 
 // BooleanNode represents a bool value
@@ -72,6 +75,8 @@ type GroupNode struct {
 	*Base
 	Key   string
 	Nodes []Node
+	// MinimumMatch is the number of OR-connected nodes which have to match, at least one if 0
+	MinimumMatch int
 }
 
 // NodeKey tries to return the node key
@@ -2,6 +2,9 @@ package kql
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/now"
@@ -128,6 +131,11 @@ func toTimeRange(in interface{}) (*time.Time, *time.Time, error) {
 		lastYear := n.With(n.AddDate(-1, 0, 0))
 		from = lastYear.BeginningOfYear()
 		to = lastYear.EndOfYear()
+	default:
+		if d, ok := toRelativeDuration(value); ok {
+			from = n.Add(-d)
+			to = n.Time
+		}
 	}
 
 	if from.IsZero() || to.IsZero() {
@@ -136,3 +144,33 @@ func toTimeRange(in interface{}) (*time.Time, *time.Time, error) {
 
 	return &from, &to, nil
 }
+
+// toRelativeDuration returns the duration of relative time ranges like "last-7d",
+// the supported units are d (days), h (hours), m (minutes) and w (weeks).
+func toRelativeDuration(value string) (time.Duration, bool) {
+	amount, ok := strings.CutPrefix(value, "last-")
+	if !ok || len(amount) < 2 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch amount[len(amount)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(amount[:len(amount)-1])
+	if err != nil || n <= 0 || time.Duration(n) > math.MaxInt64/unit {
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
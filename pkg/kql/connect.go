@@ -1,11 +1,16 @@
 package kql
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
 )
 
+// implicitOperatorSource is the source of the operators which are added between nodes without an explicit operator
+const implicitOperatorSource = "implicitly operator"
+
 // connectNodes connects given nodes
 func connectNodes(c Connector, nodes ...ast.Node) []ast.Node {
 	var connectedNodes []ast.Node
@@ -69,7 +74,7 @@ func (c DefaultConnector) Connect(head ast.Node, neighbor ast.Node, connections
 	neighborKey := strings.ToLower(ast.NodeKey(neighbor))
 
 	connection := &ast.OperatorNode{
-		Base:  &ast.Base{Loc: &ast.Location{Source: &[]string{"implicitly operator"}[0]}},
+		Base:  &ast.Base{Loc: &ast.Location{Source: &[]string{implicitOperatorSource}[0]}},
 		Value: BoolAND,
 	}
 
@@ -127,3 +132,80 @@ func (c DefaultConnector) Connect(head ast.Node, neighbor ast.Node, connections
 
 	return []ast.Node{connection}
 }
+
+// connectFreeText groups the free-text terms which are implicitly connected with each other
+// and connects them using the operator returned by connect for the number of terms instead,
+// at least minimumMatch of the terms have to match if it is greater than 0.
+// The group is connected with its neighbors as before.
+//
+// default operator OR:
+//
+//	cat dog author:"John Smith"
+//	(cat OR dog) AND author:"John Smith"
+func connectFreeText(nodes []ast.Node, connect func(terms int) (operator string, minimumMatch int)) []ast.Node {
+	var connectedNodes []ast.Node
+
+	for i := 0; i < len(nodes); i++ {
+		if group, ok := nodes[i].(*ast.GroupNode); ok && group.Key == "" {
+			group.Nodes = connectFreeText(group.Nodes, connect)
+		}
+
+		if !isFreeText(nodes[i]) {
+			connectedNodes = append(connectedNodes, nodes[i])
+			continue
+		}
+
+		first := i
+		for i+2 < len(nodes) && isImplicitOperator(nodes[i+1]) && isFreeText(nodes[i+2]) {
+			i += 2
+		}
+
+		if i == first {
+			connectedNodes = append(connectedNodes, nodes[i])
+			continue
+		}
+
+		operator, minimumMatch := connect((i-first)/2 + 1)
+		terms := []ast.Node{nodes[first]}
+		for j := first + 2; j <= i; j += 2 {
+			terms = append(terms, &ast.OperatorNode{Base: nodes[j-1].(*ast.OperatorNode).Base, Value: operator}, nodes[j])
+		}
+
+		connectedNodes = append(connectedNodes, &ast.GroupNode{Base: terms[0].(*ast.StringNode).Base, Nodes: terms, MinimumMatch: minimumMatch})
+	}
+
+	return connectedNodes
+}
+
+// MinimumMatch returns how many of the given number of terms have to match according to the spec,
+// which is either a number like "3", a percentage like "75%" rounded down,
+// or a negative number or percentage like "-1" or "-25%" of the terms which may be missing.
+// The result is at least 1 and at most the number of terms.
+func MinimumMatch(spec string, terms int) (int, error) {
+	value, percentage := strings.CutSuffix(strings.TrimSpace(spec), "%")
+	n, err := strconv.Atoi(value)
+	if err != nil || (percentage && (n < -100 || n > 100)) {
+		return 0, fmt.Errorf("invalid minimum should match '%s', expected a number like '3' or a percentage like '75%%'", spec)
+	}
+
+	if percentage {
+		n = terms * n / 100
+	}
+	if n < 0 || (n == 0 && strings.HasPrefix(value, "-")) {
+		n += terms
+	}
+
+	return max(1, min(n, terms)), nil
+}
+
+// isFreeText reports whether the node is a term without a property restriction
+func isFreeText(node ast.Node) bool {
+	n, ok := node.(*ast.StringNode)
+	return ok && n.Key == ""
+}
+
+// isImplicitOperator reports whether the node is an AND operator added by the DefaultConnector
+func isImplicitOperator(node ast.Node) bool {
+	n, ok := node.(*ast.OperatorNode)
+	return ok && n.Value == BoolAND && n.Base != nil && n.Loc != nil && n.Loc.Source != nil && *n.Loc.Source == implicitOperatorSource
+}
@@ -43,12 +43,12 @@ var g = &grammar{
 					pos: position{line: 19, col: 6, offset: 351},
 					exprs: []any{
 						&actionExpr{
-							pos: position{line: 233, col: 5, offset: 4804},
+							pos: position{line: 250, col: 5, offset: 5226},
 							run: (*parser).callonNodes3,
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 233, col: 5, offset: 4804},
+								pos: position{line: 250, col: 5, offset: 5226},
 								expr: &charClassMatcher{
-									pos:        position{line: 233, col: 5, offset: 4804},
+									pos:        position{line: 250, col: 5, offset: 5226},
 									val:        "[ \\t]",
 									chars:      []rune{' ', '\t'},
 									ignoreCase: false,
@@ -75,21 +75,21 @@ var g = &grammar{
 						name: "GroupNode",
 					},
 					&actionExpr{
-						pos: position{line: 46, col: 5, offset: 1042},
+						pos: position{line: 47, col: 5, offset: 1081},
 						run: (*parser).callonNode3,
 						expr: &seqExpr{
-							pos: position{line: 46, col: 5, offset: 1042},
+							pos: position{line: 47, col: 5, offset: 1081},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 46, col: 5, offset: 1042},
+									pos:   position{line: 47, col: 5, offset: 1081},
 									label: "k",
 									expr: &oneOrMoreExpr{
-										pos: position{line: 46, col: 7, offset: 1044},
+										pos: position{line: 47, col: 7, offset: 1083},
 										expr: &actionExpr{
-											pos: position{line: 218, col: 5, offset: 4634},
+											pos: position{line: 235, col: 5, offset: 5056},
 											run: (*parser).callonNode7,
 											expr: &charClassMatcher{
-												pos:        position{line: 218, col: 5, offset: 4634},
+												pos:        position{line: 235, col: 5, offset: 5056},
 												val:        "[A-Za-z]",
 												ranges:     []rune{'A', 'Z', 'a', 'z'},
 												ignoreCase: false,
@@ -99,23 +99,23 @@ var g = &grammar{
 									},
 								},
 								&choiceExpr{
-									pos: position{line: 46, col: 14, offset: 1051},
+									pos: position{line: 47, col: 14, offset: 1090},
 									alternatives: []any{
 										&actionExpr{
-											pos: position{line: 120, col: 5, offset: 2911},
+											pos: position{line: 131, col: 5, offset: 3211},
 											run: (*parser).callonNode10,
 											expr: &litMatcher{
-												pos:        position{line: 120, col: 5, offset: 2911},
+												pos:        position{line: 131, col: 5, offset: 3211},
 												val:        ":",
 												ignoreCase: false,
 												want:       "\":\"",
 											},
 										},
 										&actionExpr{
-											pos: position{line: 125, col: 5, offset: 2997},
+											pos: position{line: 136, col: 5, offset: 3297},
 											run: (*parser).callonNode12,
 											expr: &litMatcher{
-												pos:        position{line: 125, col: 5, offset: 2997},
+												pos:        position{line: 136, col: 5, offset: 3297},
 												val:        "=",
 												ignoreCase: false,
 												want:       "\"=\"",
@@ -124,19 +124,19 @@ var g = &grammar{
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 46, col: 53, offset: 1090},
+									pos:   position{line: 47, col: 53, offset: 1129},
 									label: "v",
 									expr: &choiceExpr{
-										pos: position{line: 46, col: 56, offset: 1093},
+										pos: position{line: 47, col: 56, offset: 1132},
 										alternatives: []any{
 											&litMatcher{
-												pos:        position{line: 46, col: 56, offset: 1093},
+												pos:        position{line: 47, col: 56, offset: 1132},
 												val:        "true",
 												ignoreCase: false,
 												want:       "\"true\"",
 											},
 											&litMatcher{
-												pos:        position{line: 46, col: 65, offset: 1102},
+												pos:        position{line: 47, col: 65, offset: 1141},
 												val:        "false",
 												ignoreCase: false,
 												want:       "\"false\"",
@@ -148,21 +148,21 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 51, col: 5, offset: 1203},
+						pos: position{line: 52, col: 5, offset: 1242},
 						run: (*parser).callonNode18,
 						expr: &seqExpr{
-							pos: position{line: 51, col: 5, offset: 1203},
+							pos: position{line: 52, col: 5, offset: 1242},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 51, col: 5, offset: 1203},
+									pos:   position{line: 52, col: 5, offset: 1242},
 									label: "k",
 									expr: &oneOrMoreExpr{
-										pos: position{line: 51, col: 7, offset: 1205},
+										pos: position{line: 52, col: 7, offset: 1244},
 										expr: &actionExpr{
-											pos: position{line: 218, col: 5, offset: 4634},
+											pos: position{line: 235, col: 5, offset: 5056},
 											run: (*parser).callonNode22,
 											expr: &charClassMatcher{
-												pos:        position{line: 218, col: 5, offset: 4634},
+												pos:        position{line: 235, col: 5, offset: 5056},
 												val:        "[A-Za-z]",
 												ranges:     []rune{'A', 'Z', 'a', 'z'},
 												ignoreCase: false,
@@ -172,66 +172,66 @@ var g = &grammar{
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 51, col: 13, offset: 1211},
+									pos:   position{line: 52, col: 13, offset: 1250},
 									label: "o",
 									expr: &choiceExpr{
-										pos: position{line: 52, col: 9, offset: 1223},
+										pos: position{line: 53, col: 9, offset: 1262},
 										alternatives: []any{
 											&actionExpr{
-												pos: position{line: 145, col: 5, offset: 3358},
+												pos: position{line: 156, col: 5, offset: 3658},
 												run: (*parser).callonNode26,
 												expr: &litMatcher{
-													pos:        position{line: 145, col: 5, offset: 3358},
+													pos:        position{line: 156, col: 5, offset: 3658},
 													val:        ">=",
 													ignoreCase: false,
 													want:       "\">=\"",
 												},
 											},
 											&actionExpr{
-												pos: position{line: 135, col: 5, offset: 3174},
+												pos: position{line: 146, col: 5, offset: 3474},
 												run: (*parser).callonNode28,
 												expr: &litMatcher{
-													pos:        position{line: 135, col: 5, offset: 3174},
+													pos:        position{line: 146, col: 5, offset: 3474},
 													val:        "<=",
 													ignoreCase: false,
 													want:       "\"<=\"",
 												},
 											},
 											&actionExpr{
-												pos: position{line: 140, col: 5, offset: 3263},
+												pos: position{line: 151, col: 5, offset: 3563},
 												run: (*parser).callonNode30,
 												expr: &litMatcher{
-													pos:        position{line: 140, col: 5, offset: 3263},
+													pos:        position{line: 151, col: 5, offset: 3563},
 													val:        ">",
 													ignoreCase: false,
 													want:       "\">\"",
 												},
 											},
 											&actionExpr{
-												pos: position{line: 130, col: 5, offset: 3082},
+												pos: position{line: 141, col: 5, offset: 3382},
 												run: (*parser).callonNode32,
 												expr: &litMatcher{
-													pos:        position{line: 130, col: 5, offset: 3082},
+													pos:        position{line: 141, col: 5, offset: 3382},
 													val:        "<",
 													ignoreCase: false,
 													want:       "\"<\"",
 												},
 											},
 											&actionExpr{
-												pos: position{line: 125, col: 5, offset: 2997},
+												pos: position{line: 136, col: 5, offset: 3297},
 												run: (*parser).callonNode34,
 												expr: &litMatcher{
-													pos:        position{line: 125, col: 5, offset: 2997},
+													pos:        position{line: 136, col: 5, offset: 3297},
 													val:        "=",
 													ignoreCase: false,
 													want:       "\"=\"",
 												},
 											},
 											&actionExpr{
-												pos: position{line: 120, col: 5, offset: 2911},
+												pos: position{line: 131, col: 5, offset: 3211},
 												run: (*parser).callonNode36,
 												expr: &litMatcher{
-													pos:        position{line: 120, col: 5, offset: 2911},
+													pos:        position{line: 131, col: 5, offset: 3211},
 													val:        ":",
 													ignoreCase: false,
 													want:       "\":\"",
@@ -241,43 +241,43 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 58, col: 7, offset: 1403},
+									pos: position{line: 59, col: 7, offset: 1442},
 									expr: &litMatcher{
-										pos:        position{line: 58, col: 7, offset: 1403},
+										pos:        position{line: 59, col: 7, offset: 1442},
 										val:        "\"",
 										ignoreCase: false,
 										want:       "\"\\\"\"",
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 58, col: 12, offset: 1408},
+									pos:   position{line: 59, col: 12, offset: 1447},
 									label: "v",
 									expr: &choiceExpr{
-										pos: position{line: 59, col: 9, offset: 1420},
+										pos: position{line: 60, col: 9, offset: 1459},
 										alternatives: []any{
 											&actionExpr{
-												pos: position{line: 195, col: 5, offset: 4197},
+												pos: position{line: 206, col: 5, offset: 4497},
 												run: (*parser).callonNode42,
 												expr: &seqExpr{
-													pos: position{line: 195, col: 5, offset: 4197},
+													pos: position{line: 206, col: 5, offset: 4497},
 													exprs: []any{
 														&actionExpr{
-															pos: position{line: 185, col: 5, offset: 3960},
+															pos: position{line: 196, col: 5, offset: 4260},
 															run: (*parser).callonNode44,
 															expr: &seqExpr{
-																pos: position{line: 185, col: 5, offset: 3960},
+																pos: position{line: 196, col: 5, offset: 4260},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 155, col: 5, offset: 3560},
+																		pos: position{line: 166, col: 5, offset: 3860},
 																		run: (*parser).callonNode46,
 																		expr: &seqExpr{
-																			pos: position{line: 155, col: 5, offset: 3560},
+																			pos: position{line: 166, col: 5, offset: 3860},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode48,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -285,10 +285,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode50,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -296,10 +296,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode52,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -307,10 +307,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode54,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -321,22 +321,22 @@ var g = &grammar{
 																		},
 																	},
 																	&litMatcher{
-																		pos:        position{line: 185, col: 14, offset: 3969},
+																		pos:        position{line: 196, col: 14, offset: 4269},
 																		val:        "-",
 																		ignoreCase: false,
 																		want:       "\"-\"",
 																	},
 																	&actionExpr{
-																		pos: position{line: 160, col: 5, offset: 3637},
+																		pos: position{line: 171, col: 5, offset: 3937},
 																		run: (*parser).callonNode57,
 																		expr: &seqExpr{
-																			pos: position{line: 160, col: 5, offset: 3637},
+																			pos: position{line: 171, col: 5, offset: 3937},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode59,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -344,10 +344,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode61,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -358,22 +358,22 @@ var g = &grammar{
 																		},
 																	},
 																	&litMatcher{
-																		pos:        position{line: 185, col: 28, offset: 3983},
+																		pos:        position{line: 196, col: 28, offset: 4283},
 																		val:        "-",
 																		ignoreCase: false,
 																		want:       "\"-\"",
 																	},
 																	&actionExpr{
-																		pos: position{line: 165, col: 5, offset: 3700},
+																		pos: position{line: 176, col: 5, offset: 4000},
 																		run: (*parser).callonNode64,
 																		expr: &seqExpr{
-																			pos: position{line: 165, col: 5, offset: 3700},
+																			pos: position{line: 176, col: 5, offset: 4000},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode66,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -381,10 +381,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode68,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -398,28 +398,28 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 195, col: 14, offset: 4206},
+															pos:        position{line: 206, col: 14, offset: 4506},
 															val:        "T",
 															ignoreCase: false,
 															want:       "\"T\"",
 														},
 														&actionExpr{
-															pos: position{line: 190, col: 5, offset: 4047},
+															pos: position{line: 201, col: 5, offset: 4347},
 															run: (*parser).callonNode71,
 															expr: &seqExpr{
-																pos: position{line: 190, col: 5, offset: 4047},
+																pos: position{line: 201, col: 5, offset: 4347},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 170, col: 5, offset: 3764},
+																		pos: position{line: 181, col: 5, offset: 4064},
 																		run: (*parser).callonNode73,
 																		expr: &seqExpr{
-																			pos: position{line: 170, col: 5, offset: 3764},
+																			pos: position{line: 181, col: 5, offset: 4064},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode75,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -427,10 +427,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode77,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -441,22 +441,22 @@ var g = &grammar{
 																		},
 																	},
 																	&litMatcher{
-																		pos:        position{line: 190, col: 14, offset: 4056},
+																		pos:        position{line: 201, col: 14, offset: 4356},
 																		val:        ":",
 																		ignoreCase: false,
 																		want:       "\":\"",
 																	},
 																	&actionExpr{
-																		pos: position{line: 175, col: 5, offset: 3830},
+																		pos: position{line: 186, col: 5, offset: 4130},
 																		run: (*parser).callonNode80,
 																		expr: &seqExpr{
-																			pos: position{line: 175, col: 5, offset: 3830},
+																			pos: position{line: 186, col: 5, offset: 4130},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode82,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -464,10 +464,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode84,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -478,22 +478,22 @@ var g = &grammar{
 																		},
 																	},
 																	&litMatcher{
-																		pos:        position{line: 190, col: 29, offset: 4071},
+																		pos:        position{line: 201, col: 29, offset: 4371},
 																		val:        ":",
 																		ignoreCase: false,
 																		want:       "\":\"",
 																	},
 																	&actionExpr{
-																		pos: position{line: 180, col: 5, offset: 3896},
+																		pos: position{line: 191, col: 5, offset: 4196},
 																		run: (*parser).callonNode87,
 																		expr: &seqExpr{
-																			pos: position{line: 180, col: 5, offset: 3896},
+																			pos: position{line: 191, col: 5, offset: 4196},
 																			exprs: []any{
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode89,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -501,10 +501,10 @@ var g = &grammar{
 																					},
 																				},
 																				&actionExpr{
-																					pos: position{line: 228, col: 5, offset: 4753},
+																					pos: position{line: 245, col: 5, offset: 5175},
 																					run: (*parser).callonNode91,
 																					expr: &charClassMatcher{
-																						pos:        position{line: 228, col: 5, offset: 4753},
+																						pos:        position{line: 245, col: 5, offset: 5175},
 																						val:        "[0-9]",
 																						ranges:     []rune{'0', '9'},
 																						ignoreCase: false,
@@ -515,23 +515,23 @@ var g = &grammar{
 																		},
 																	},
 																	&zeroOrOneExpr{
-																		pos: position{line: 190, col: 44, offset: 4086},
+																		pos: position{line: 201, col: 44, offset: 4386},
 																		expr: &seqExpr{
-																			pos: position{line: 190, col: 45, offset: 4087},
+																			pos: position{line: 201, col: 45, offset: 4387},
 																			exprs: []any{
 																				&litMatcher{
-																					pos:        position{line: 190, col: 45, offset: 4087},
+																					pos:        position{line: 201, col: 45, offset: 4387},
 																					val:        ".",
 																					ignoreCase: false,
 																					want:       "\".\"",
 																				},
 																				&oneOrMoreExpr{
-																					pos: position{line: 190, col: 49, offset: 4091},
+																					pos: position{line: 201, col: 49, offset: 4391},
 																					expr: &actionExpr{
-																						pos: position{line: 228, col: 5, offset: 4753},
+																						pos: position{line: 245, col: 5, offset: 5175},
 																						run: (*parser).callonNode97,
 																						expr: &charClassMatcher{
-																							pos:        position{line: 228, col: 5, offset: 4753},
+																							pos:        position{line: 245, col: 5, offset: 5175},
 																							val:        "[0-9]",
 																							ranges:     []rune{'0', '9'},
 																							ignoreCase: false,
@@ -543,35 +543,35 @@ var g = &grammar{
 																		},
 																	},
 																	&choiceExpr{
-																		pos: position{line: 190, col: 59, offset: 4101},
+																		pos: position{line: 201, col: 59, offset: 4401},
 																		alternatives: []any{
 																			&litMatcher{
-																				pos:        position{line: 190, col: 59, offset: 4101},
+																				pos:        position{line: 201, col: 59, offset: 4401},
 																				val:        "Z",
 																				ignoreCase: false,
 																				want:       "\"Z\"",
 																			},
 																			&seqExpr{
-																				pos: position{line: 190, col: 65, offset: 4107},
+																				pos: position{line: 201, col: 65, offset: 4407},
 																				exprs: []any{
 																					&charClassMatcher{
-																						pos:        position{line: 190, col: 66, offset: 4108},
+																						pos:        position{line: 201, col: 66, offset: 4408},
 																						val:        "[+-]",
 																						chars:      []rune{'+', '-'},
 																						ignoreCase: false,
 																						inverted:   false,
 																					},
 																					&actionExpr{
-																						pos: position{line: 170, col: 5, offset: 3764},
+																						pos: position{line: 181, col: 5, offset: 4064},
 																						run: (*parser).callonNode103,
 																						expr: &seqExpr{
-																							pos: position{line: 170, col: 5, offset: 3764},
+																							pos: position{line: 181, col: 5, offset: 4064},
 																							exprs: []any{
 																								&actionExpr{
-																									pos: position{line: 228, col: 5, offset: 4753},
+																									pos: position{line: 245, col: 5, offset: 5175},
 																									run: (*parser).callonNode105,
 																									expr: &charClassMatcher{
-																										pos:        position{line: 228, col: 5, offset: 4753},
+																										pos:        position{line: 245, col: 5, offset: 5175},
 																										val:        "[0-9]",
 																										ranges:     []rune{'0', '9'},
 																										ignoreCase: false,
@@ -579,10 +579,10 @@ var g = &grammar{
 																									},
 																								},
 																								&actionExpr{
-																									pos: position{line: 228, col: 5, offset: 4753},
+																									pos: position{line: 245, col: 5, offset: 5175},
 																									run: (*parser).callonNode107,
 																									expr: &charClassMatcher{
-																										pos:        position{line: 228, col: 5, offset: 4753},
+																										pos:        position{line: 245, col: 5, offset: 5175},
 																										val:        "[0-9]",
 																										ranges:     []rune{'0', '9'},
 																										ignoreCase: false,
@@ -593,22 +593,22 @@ var g = &grammar{
 																						},
 																					},
 																					&litMatcher{
-																						pos:        position{line: 190, col: 86, offset: 4128},
+																						pos:        position{line: 201, col: 86, offset: 4428},
 																						val:        ":",
 																						ignoreCase: false,
 																						want:       "\":\"",
 																					},
 																					&actionExpr{
-																						pos: position{line: 175, col: 5, offset: 3830},
+																						pos: position{line: 186, col: 5, offset: 4130},
 																						run: (*parser).callonNode110,
 																						expr: &seqExpr{
-																							pos: position{line: 175, col: 5, offset: 3830},
+																							pos: position{line: 186, col: 5, offset: 4130},
 																							exprs: []any{
 																								&actionExpr{
-																									pos: position{line: 228, col: 5, offset: 4753},
+																									pos: position{line: 245, col: 5, offset: 5175},
 																									run: (*parser).callonNode112,
 																									expr: &charClassMatcher{
-																										pos:        position{line: 228, col: 5, offset: 4753},
+																										pos:        position{line: 245, col: 5, offset: 5175},
 																										val:        "[0-9]",
 																										ranges:     []rune{'0', '9'},
 																										ignoreCase: false,
@@ -616,10 +616,10 @@ var g = &grammar{
 																									},
 																								},
 																								&actionExpr{
-																									pos: position{line: 228, col: 5, offset: 4753},
+																									pos: position{line: 245, col: 5, offset: 5175},
 																									run: (*parser).callonNode114,
 																									expr: &charClassMatcher{
-																										pos:        position{line: 228, col: 5, offset: 4753},
+																										pos:        position{line: 245, col: 5, offset: 5175},
 																										val:        "[0-9]",
 																										ranges:     []rune{'0', '9'},
 																										ignoreCase: false,
@@ -640,22 +640,22 @@ var g = &grammar{
 												},
 											},
 											&actionExpr{
-												pos: position{line: 185, col: 5, offset: 3960},
+												pos: position{line: 196, col: 5, offset: 4260},
 												run: (*parser).callonNode116,
 												expr: &seqExpr{
-													pos: position{line: 185, col: 5, offset: 3960},
+													pos: position{line: 196, col: 5, offset: 4260},
 													exprs: []any{
 														&actionExpr{
-															pos: position{line: 155, col: 5, offset: 3560},
+															pos: position{line: 166, col: 5, offset: 3860},
 															run: (*parser).callonNode118,
 															expr: &seqExpr{
-																pos: position{line: 155, col: 5, offset: 3560},
+																pos: position{line: 166, col: 5, offset: 3860},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode120,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -663,10 +663,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode122,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -674,10 +674,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode124,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -685,10 +685,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode126,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -699,22 +699,22 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 185, col: 14, offset: 3969},
+															pos:        position{line: 196, col: 14, offset: 4269},
 															val:        "-",
 															ignoreCase: false,
 															want:       "\"-\"",
 														},
 														&actionExpr{
-															pos: position{line: 160, col: 5, offset: 3637},
+															pos: position{line: 171, col: 5, offset: 3937},
 															run: (*parser).callonNode129,
 															expr: &seqExpr{
-																pos: position{line: 160, col: 5, offset: 3637},
+																pos: position{line: 171, col: 5, offset: 3937},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode131,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -722,10 +722,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode133,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -736,22 +736,22 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 185, col: 28, offset: 3983},
+															pos:        position{line: 196, col: 28, offset: 4283},
 															val:        "-",
 															ignoreCase: false,
 															want:       "\"-\"",
 														},
 														&actionExpr{
-															pos: position{line: 165, col: 5, offset: 3700},
+															pos: position{line: 176, col: 5, offset: 4000},
 															run: (*parser).callonNode136,
 															expr: &seqExpr{
-																pos: position{line: 165, col: 5, offset: 3700},
+																pos: position{line: 176, col: 5, offset: 4000},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode138,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -759,10 +759,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode140,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -776,22 +776,22 @@ var g = &grammar{
 												},
 											},
 											&actionExpr{
-												pos: position{line: 190, col: 5, offset: 4047},
+												pos: position{line: 201, col: 5, offset: 4347},
 												run: (*parser).callonNode142,
 												expr: &seqExpr{
-													pos: position{line: 190, col: 5, offset: 4047},
+													pos: position{line: 201, col: 5, offset: 4347},
 													exprs: []any{
 														&actionExpr{
-															pos: position{line: 170, col: 5, offset: 3764},
+															pos: position{line: 181, col: 5, offset: 4064},
 															run: (*parser).callonNode144,
 															expr: &seqExpr{
-																pos: position{line: 170, col: 5, offset: 3764},
+																pos: position{line: 181, col: 5, offset: 4064},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode146,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -799,10 +799,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode148,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -813,22 +813,22 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 190, col: 14, offset: 4056},
+															pos:        position{line: 201, col: 14, offset: 4356},
 															val:        ":",
 															ignoreCase: false,
 															want:       "\":\"",
 														},
 														&actionExpr{
-															pos: position{line: 175, col: 5, offset: 3830},
+															pos: position{line: 186, col: 5, offset: 4130},
 															run: (*parser).callonNode151,
 															expr: &seqExpr{
-																pos: position{line: 175, col: 5, offset: 3830},
+																pos: position{line: 186, col: 5, offset: 4130},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode153,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -836,10 +836,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode155,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -850,22 +850,22 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 190, col: 29, offset: 4071},
+															pos:        position{line: 201, col: 29, offset: 4371},
 															val:        ":",
 															ignoreCase: false,
 															want:       "\":\"",
 														},
 														&actionExpr{
-															pos: position{line: 180, col: 5, offset: 3896},
+															pos: position{line: 191, col: 5, offset: 4196},
 															run: (*parser).callonNode158,
 															expr: &seqExpr{
-																pos: position{line: 180, col: 5, offset: 3896},
+																pos: position{line: 191, col: 5, offset: 4196},
 																exprs: []any{
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode160,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -873,10 +873,10 @@ var g = &grammar{
 																		},
 																	},
 																	&actionExpr{
-																		pos: position{line: 228, col: 5, offset: 4753},
+																		pos: position{line: 245, col: 5, offset: 5175},
 																		run: (*parser).callonNode162,
 																		expr: &charClassMatcher{
-																			pos:        position{line: 228, col: 5, offset: 4753},
+																			pos:        position{line: 245, col: 5, offset: 5175},
 																			val:        "[0-9]",
 																			ranges:     []rune{'0', '9'},
 																			ignoreCase: false,
@@ -887,23 +887,23 @@ var g = &grammar{
 															},
 														},
 														&zeroOrOneExpr{
-															pos: position{line: 190, col: 44, offset: 4086},
+															pos: position{line: 201, col: 44, offset: 4386},
 															expr: &seqExpr{
-																pos: position{line: 190, col: 45, offset: 4087},
+																pos: position{line: 201, col: 45, offset: 4387},
 																exprs: []any{
 																	&litMatcher{
-																		pos:        position{line: 190, col: 45, offset: 4087},
+																		pos:        position{line: 201, col: 45, offset: 4387},
 																		val:        ".",
 																		ignoreCase: false,
 																		want:       "\".\"",
 																	},
 																	&oneOrMoreExpr{
-																		pos: position{line: 190, col: 49, offset: 4091},
+																		pos: position{line: 201, col: 49, offset: 4391},
 																		expr: &actionExpr{
-																			pos: position{line: 228, col: 5, offset: 4753},
+																			pos: position{line: 245, col: 5, offset: 5175},
 																			run: (*parser).callonNode168,
 																			expr: &charClassMatcher{
-																				pos:        position{line: 228, col: 5, offset: 4753},
+																				pos:        position{line: 245, col: 5, offset: 5175},
 																				val:        "[0-9]",
 																				ranges:     []rune{'0', '9'},
 																				ignoreCase: false,
@@ -915,35 +915,35 @@ var g = &grammar{
 															},
 														},
 														&choiceExpr{
-															pos: position{line: 190, col: 59, offset: 4101},
+															pos: position{line: 201, col: 59, offset: 4401},
 															alternatives: []any{
 																&litMatcher{
-																	pos:        position{line: 190, col: 59, offset: 4101},
+																	pos:        position{line: 201, col: 59, offset: 4401},
 																	val:        "Z",
 																	ignoreCase: false,
 																	want:       "\"Z\"",
 																},
 																&seqExpr{
-																	pos: position{line: 190, col: 65, offset: 4107},
+																	pos: position{line: 201, col: 65, offset: 4407},
 																	exprs: []any{
 																		&charClassMatcher{
-																			pos:        position{line: 190, col: 66, offset: 4108},
+																			pos:        position{line: 201, col: 66, offset: 4408},
 																			val:        "[+-]",
 																			chars:      []rune{'+', '-'},
 																			ignoreCase: false,
 																			inverted:   false,
 																		},
 																		&actionExpr{
-																			pos: position{line: 170, col: 5, offset: 3764},
+																			pos: position{line: 181, col: 5, offset: 4064},
 																			run: (*parser).callonNode174,
 																			expr: &seqExpr{
-																				pos: position{line: 170, col: 5, offset: 3764},
+																				pos: position{line: 181, col: 5, offset: 4064},
 																				exprs: []any{
 																					&actionExpr{
-																						pos: position{line: 228, col: 5, offset: 4753},
+																						pos: position{line: 245, col: 5, offset: 5175},
 																						run: (*parser).callonNode176,
 																						expr: &charClassMatcher{
-																							pos:        position{line: 228, col: 5, offset: 4753},
+																							pos:        position{line: 245, col: 5, offset: 5175},
 																							val:        "[0-9]",
 																							ranges:     []rune{'0', '9'},
 																							ignoreCase: false,
@@ -951,10 +951,10 @@ var g = &grammar{
 																						},
 																					},
 																					&actionExpr{
-																						pos: position{line: 228, col: 5, offset: 4753},
+																						pos: position{line: 245, col: 5, offset: 5175},
 																						run: (*parser).callonNode178,
 																						expr: &charClassMatcher{
-																							pos:        position{line: 228, col: 5, offset: 4753},
+																							pos:        position{line: 245, col: 5, offset: 5175},
 																							val:        "[0-9]",
 																							ranges:     []rune{'0', '9'},
 																							ignoreCase: false,
@@ -965,22 +965,22 @@ var g = &grammar{
 																			},
 																		},
 																		&litMatcher{
-																			pos:        position{line: 190, col: 86, offset: 4128},
+																			pos:        position{line: 201, col: 86, offset: 4428},
 																			val:        ":",
 																			ignoreCase: false,
 																			want:       "\":\"",
 																		},
 																		&actionExpr{
-																			pos: position{line: 175, col: 5, offset: 3830},
+																			pos: position{line: 186, col: 5, offset: 4130},
 																			run: (*parser).callonNode181,
 																			expr: &seqExpr{
-																				pos: position{line: 175, col: 5, offset: 3830},
+																				pos: position{line: 186, col: 5, offset: 4130},
 																				exprs: []any{
 																					&actionExpr{
-																						pos: position{line: 228, col: 5, offset: 4753},
+																						pos: position{line: 245, col: 5, offset: 5175},
 																						run: (*parser).callonNode183,
 																						expr: &charClassMatcher{
-																							pos:        position{line: 228, col: 5, offset: 4753},
+																							pos:        position{line: 245, col: 5, offset: 5175},
 																							val:        "[0-9]",
 																							ranges:     []rune{'0', '9'},
 																							ignoreCase: false,
@@ -988,10 +988,10 @@ var g = &grammar{
 																						},
 																					},
 																					&actionExpr{
-																						pos: position{line: 228, col: 5, offset: 4753},
+																						pos: position{line: 245, col: 5, offset: 5175},
 																						run: (*parser).callonNode185,
 																						expr: &charClassMatcher{
-																							pos:        position{line: 228, col: 5, offset: 4753},
+																							pos:        position{line: 245, col: 5, offset: 5175},
 																							val:        "[0-9]",
 																							ranges:     []rune{'0', '9'},
 																							ignoreCase: false,
@@ -1012,9 +1012,9 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 62, col: 7, offset: 1473},
+									pos: position{line: 63, col: 7, offset: 1512},
 									expr: &litMatcher{
-										pos:        position{line: 62, col: 7, offset: 1473},
+										pos:        position{line: 63, col: 7, offset: 1512},
 										val:        "\"",
 										ignoreCase: false,
 										want:       "\"\\\"\"",
@@ -1024,21 +1024,21 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 65, col: 5, offset: 1549},
+						pos: position{line: 66, col: 5, offset: 1588},
 						run: (*parser).callonNode189,
 						expr: &seqExpr{
-							pos: position{line: 65, col: 5, offset: 1549},
+							pos: position{line: 66, col: 5, offset: 1588},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 65, col: 5, offset: 1549},
+									pos:   position{line: 66, col: 5, offset: 1588},
 									label: "k",
 									expr: &oneOrMoreExpr{
-										pos: position{line: 65, col: 7, offset: 1551},
+										pos: position{line: 66, col: 7, offset: 1590},
 										expr: &actionExpr{
-											pos: position{line: 218, col: 5, offset: 4634},
+											pos: position{line: 235, col: 5, offset: 5056},
 											run: (*parser).callonNode193,
 											expr: &charClassMatcher{
-												pos:        position{line: 218, col: 5, offset: 4634},
+												pos:        position{line: 235, col: 5, offset: 5056},
 												val:        "[A-Za-z]",
 												ranges:     []rune{'A', 'Z', 'a', 'z'},
 												ignoreCase: false,
@@ -1048,23 +1048,23 @@ var g = &grammar{
 									},
 								},
 								&choiceExpr{
-									pos: position{line: 66, col: 9, offset: 1567},
+									pos: position{line: 67, col: 9, offset: 1606},
 									alternatives: []any{
 										&actionExpr{
-											pos: position{line: 125, col: 5, offset: 2997},
+											pos: position{line: 136, col: 5, offset: 3297},
 											run: (*parser).callonNode196,
 											expr: &litMatcher{
-												pos:        position{line: 125, col: 5, offset: 2997},
+												pos:        position{line: 136, col: 5, offset: 3297},
 												val:        "=",
 												ignoreCase: false,
 												want:       "\"=\"",
 											},
 										},
 										&actionExpr{
-											pos: position{line: 120, col: 5, offset: 2911},
+											pos: position{line: 131, col: 5, offset: 3211},
 											run: (*parser).callonNode198,
 											expr: &litMatcher{
-												pos:        position{line: 120, col: 5, offset: 2911},
+												pos:        position{line: 131, col: 5, offset: 3211},
 												val:        ":",
 												ignoreCase: false,
 												want:       "\":\"",
@@ -1073,79 +1073,115 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 68, col: 7, offset: 1619},
+									pos: position{line: 69, col: 7, offset: 1658},
 									expr: &litMatcher{
-										pos:        position{line: 68, col: 7, offset: 1619},
+										pos:        position{line: 69, col: 7, offset: 1658},
 										val:        "\"",
 										ignoreCase: false,
 										want:       "\"\\\"\"",
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 68, col: 12, offset: 1624},
+									pos:   position{line: 69, col: 12, offset: 1663},
 									label: "v",
 									expr: &choiceExpr{
-										pos: position{line: 200, col: 5, offset: 4285},
+										pos: position{line: 211, col: 5, offset: 4585},
 										alternatives: []any{
+											&actionExpr{
+												pos: position{line: 226, col: 5, offset: 4846},
+												run: (*parser).callonNode204,
+												expr: &seqExpr{
+													pos: position{line: 226, col: 5, offset: 4846},
+													exprs: []any{
+														&litMatcher{
+															pos:        position{line: 226, col: 5, offset: 4846},
+															val:        "last-",
+															ignoreCase: false,
+															want:       "\"last-\"",
+														},
+														&oneOrMoreExpr{
+															pos: position{line: 226, col: 13, offset: 4854},
+															expr: &actionExpr{
+																pos: position{line: 245, col: 5, offset: 5175},
+																run: (*parser).callonNode208,
+																expr: &charClassMatcher{
+																	pos:        position{line: 245, col: 5, offset: 5175},
+																	val:        "[0-9]",
+																	ranges:     []rune{'0', '9'},
+																	ignoreCase: false,
+																	inverted:   false,
+																},
+															},
+														},
+														&charClassMatcher{
+															pos:        position{line: 226, col: 21, offset: 4862},
+															val:        "[dhmw]",
+															chars:      []rune{'d', 'h', 'm', 'w'},
+															ignoreCase: false,
+															inverted:   false,
+														},
+													},
+												},
+											},
 											&litMatcher{
-												pos:        position{line: 200, col: 5, offset: 4285},
+												pos:        position{line: 212, col: 5, offset: 4608},
 												val:        "today",
 												ignoreCase: false,
 												want:       "\"today\"",
 											},
 											&litMatcher{
-												pos:        position{line: 201, col: 5, offset: 4299},
+												pos:        position{line: 213, col: 5, offset: 4622},
 												val:        "yesterday",
 												ignoreCase: false,
 												want:       "\"yesterday\"",
 											},
 											&litMatcher{
-												pos:        position{line: 202, col: 5, offset: 4317},
+												pos:        position{line: 214, col: 5, offset: 4640},
 												val:        "this week",
 												ignoreCase: false,
 												want:       "\"this week\"",
 											},
 											&litMatcher{
-												pos:        position{line: 203, col: 5, offset: 4335},
+												pos:        position{line: 215, col: 5, offset: 4658},
 												val:        "last week",
 												ignoreCase: false,
 												want:       "\"last week\"",
 											},
 											&litMatcher{
-												pos:        position{line: 204, col: 5, offset: 4353},
+												pos:        position{line: 216, col: 5, offset: 4676},
 												val:        "last 7 days",
 												ignoreCase: false,
 												want:       "\"last 7 days\"",
 											},
 											&litMatcher{
-												pos:        position{line: 205, col: 5, offset: 4373},
+												pos:        position{line: 217, col: 5, offset: 4696},
 												val:        "this month",
 												ignoreCase: false,
 												want:       "\"this month\"",
 											},
 											&litMatcher{
-												pos:        position{line: 206, col: 5, offset: 4392},
+												pos:        position{line: 218, col: 5, offset: 4715},
 												val:        "last month",
 												ignoreCase: false,
 												want:       "\"last month\"",
 											},
 											&litMatcher{
-												pos:        position{line: 207, col: 5, offset: 4411},
+												pos:        position{line: 219, col: 5, offset: 4734},
 												val:        "last 30 days",
 												ignoreCase: false,
 												want:       "\"last 30 days\"",
 											},
 											&litMatcher{
-												pos:        position{line: 208, col: 5, offset: 4432},
+												pos:        position{line: 220, col: 5, offset: 4755},
 												val:        "this year",
 												ignoreCase: false,
 												want:       "\"this year\"",
 											},
 											&actionExpr{
-												pos: position{line: 209, col: 5, offset: 4450},
-												run: (*parser).callonNode213,
+												pos: position{line: 221, col: 5, offset: 4773},
+												run: (*parser).callonNode220,
 												expr: &litMatcher{
-													pos:        position{line: 209, col: 5, offset: 4450},
+													pos:        position{line: 221, col: 5, offset: 4773},
 													val:        "last year",
 													ignoreCase: false,
 													want:       "\"last year\"",
@@ -1155,9 +1191,9 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 68, col: 38, offset: 1650},
+									pos: position{line: 69, col: 38, offset: 1689},
 									expr: &litMatcher{
-										pos:        position{line: 68, col: 38, offset: 1650},
+										pos:        position{line: 69, col: 38, offset: 1689},
 										val:        "\"",
 										ignoreCase: false,
 										want:       "\"\\\"\"",
@@ -1167,21 +1203,147 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 73, col: 5, offset: 1769},
-						run: (*parser).callonNode217,
+						pos: position{line: 74, col: 5, offset: 1813},
+						run: (*parser).callonNode224,
+						expr: &seqExpr{
+							pos: position{line: 74, col: 5, offset: 1813},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 74, col: 5, offset: 1813},
+									label: "k",
+									expr: &oneOrMoreExpr{
+										pos: position{line: 74, col: 7, offset: 1815},
+										expr: &actionExpr{
+											pos: position{line: 235, col: 5, offset: 5056},
+											run: (*parser).callonNode228,
+											expr: &charClassMatcher{
+												pos:        position{line: 235, col: 5, offset: 5056},
+												val:        "[A-Za-z]",
+												ranges:     []rune{'A', 'Z', 'a', 'z'},
+												ignoreCase: false,
+												inverted:   false,
+											},
+										},
+									},
+								},
+								&litMatcher{
+									pos:        position{line: 74, col: 13, offset: 1821},
+									val:        "~",
+									ignoreCase: false,
+									want:       "\"~\"",
+								},
+								&labeledExpr{
+									pos:   position{line: 74, col: 17, offset: 1825},
+									label: "m",
+									expr: &actionExpr{
+										pos: position{line: 102, col: 5, offset: 2607},
+										run: (*parser).callonNode232,
+										expr: &litMatcher{
+											pos:        position{line: 102, col: 5, offset: 2607},
+											val:        "phonetic",
+											ignoreCase: false,
+											want:       "\"phonetic\"",
+										},
+									},
+								},
+								&choiceExpr{
+									pos: position{line: 74, col: 30, offset: 1838},
+									alternatives: []any{
+										&actionExpr{
+											pos: position{line: 131, col: 5, offset: 3211},
+											run: (*parser).callonNode235,
+											expr: &litMatcher{
+												pos:        position{line: 131, col: 5, offset: 3211},
+												val:        ":",
+												ignoreCase: false,
+												want:       "\":\"",
+											},
+										},
+										&actionExpr{
+											pos: position{line: 136, col: 5, offset: 3297},
+											run: (*parser).callonNode237,
+											expr: &litMatcher{
+												pos:        position{line: 136, col: 5, offset: 3297},
+												val:        "=",
+												ignoreCase: false,
+												want:       "\"=\"",
+											},
+										},
+									},
+								},
+								&labeledExpr{
+									pos:   position{line: 74, col: 69, offset: 1877},
+									label: "v",
+									expr: &choiceExpr{
+										pos: position{line: 74, col: 72, offset: 1880},
+										alternatives: []any{
+											&actionExpr{
+												pos: position{line: 240, col: 5, offset: 5115},
+												run: (*parser).callonNode241,
+												expr: &seqExpr{
+													pos: position{line: 240, col: 5, offset: 5115},
+													exprs: []any{
+														&litMatcher{
+															pos:        position{line: 240, col: 5, offset: 5115},
+															val:        "\"",
+															ignoreCase: false,
+															want:       "\"\\\"\"",
+														},
+														&labeledExpr{
+															pos:   position{line: 240, col: 9, offset: 5119},
+															label: "v",
+															expr: &zeroOrMoreExpr{
+																pos: position{line: 240, col: 11, offset: 5121},
+																expr: &charClassMatcher{
+																	pos:        position{line: 240, col: 11, offset: 5121},
+																	val:        "[^\"]",
+																	chars:      []rune{'"'},
+																	ignoreCase: false,
+																	inverted:   true,
+																},
+															},
+														},
+														&litMatcher{
+															pos:        position{line: 240, col: 17, offset: 5127},
+															val:        "\"",
+															ignoreCase: false,
+															want:       "\"\\\"\"",
+														},
+													},
+												},
+											},
+											&oneOrMoreExpr{
+												pos: position{line: 74, col: 81, offset: 1889},
+												expr: &charClassMatcher{
+													pos:        position{line: 74, col: 81, offset: 1889},
+													val:        "[^ ()]",
+													chars:      []rune{' ', '(', ')'},
+													ignoreCase: false,
+													inverted:   true,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 79, col: 5, offset: 2005},
+						run: (*parser).callonNode250,
 						expr: &seqExpr{
-							pos: position{line: 73, col: 5, offset: 1769},
+							pos: position{line: 79, col: 5, offset: 2005},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 73, col: 5, offset: 1769},
+									pos:   position{line: 79, col: 5, offset: 2005},
 									label: "k",
 									expr: &oneOrMoreExpr{
-										pos: position{line: 73, col: 7, offset: 1771},
+										pos: position{line: 79, col: 7, offset: 2007},
 										expr: &actionExpr{
-											pos: position{line: 218, col: 5, offset: 4634},
-											run: (*parser).callonNode221,
+											pos: position{line: 235, col: 5, offset: 5056},
+											run: (*parser).callonNode254,
 											expr: &charClassMatcher{
-												pos:        position{line: 218, col: 5, offset: 4634},
+												pos:        position{line: 235, col: 5, offset: 5056},
 												val:        "[A-Za-z]",
 												ranges:     []rune{'A', 'Z', 'a', 'z'},
 												ignoreCase: false,
@@ -1191,23 +1353,23 @@ var g = &grammar{
 									},
 								},
 								&choiceExpr{
-									pos: position{line: 73, col: 14, offset: 1778},
+									pos: position{line: 79, col: 14, offset: 2014},
 									alternatives: []any{
 										&actionExpr{
-											pos: position{line: 120, col: 5, offset: 2911},
-											run: (*parser).callonNode224,
+											pos: position{line: 131, col: 5, offset: 3211},
+											run: (*parser).callonNode257,
 											expr: &litMatcher{
-												pos:        position{line: 120, col: 5, offset: 2911},
+												pos:        position{line: 131, col: 5, offset: 3211},
 												val:        ":",
 												ignoreCase: false,
 												want:       "\":\"",
 											},
 										},
 										&actionExpr{
-											pos: position{line: 125, col: 5, offset: 2997},
-											run: (*parser).callonNode226,
+											pos: position{line: 136, col: 5, offset: 3297},
+											run: (*parser).callonNode259,
 											expr: &litMatcher{
-												pos:        position{line: 125, col: 5, offset: 2997},
+												pos:        position{line: 136, col: 5, offset: 3297},
 												val:        "=",
 												ignoreCase: false,
 												want:       "\"=\"",
@@ -1216,30 +1378,30 @@ var g = &grammar{
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 73, col: 53, offset: 1817},
+									pos:   position{line: 79, col: 53, offset: 2053},
 									label: "v",
 									expr: &choiceExpr{
-										pos: position{line: 73, col: 56, offset: 1820},
+										pos: position{line: 79, col: 56, offset: 2056},
 										alternatives: []any{
 											&actionExpr{
-												pos: position{line: 223, col: 5, offset: 4693},
-												run: (*parser).callonNode230,
+												pos: position{line: 240, col: 5, offset: 5115},
+												run: (*parser).callonNode263,
 												expr: &seqExpr{
-													pos: position{line: 223, col: 5, offset: 4693},
+													pos: position{line: 240, col: 5, offset: 5115},
 													exprs: []any{
 														&litMatcher{
-															pos:        position{line: 223, col: 5, offset: 4693},
+															pos:        position{line: 240, col: 5, offset: 5115},
 															val:        "\"",
 															ignoreCase: false,
 															want:       "\"\\\"\"",
 														},
 														&labeledExpr{
-															pos:   position{line: 223, col: 9, offset: 4697},
+															pos:   position{line: 240, col: 9, offset: 5119},
 															label: "v",
 															expr: &zeroOrMoreExpr{
-																pos: position{line: 223, col: 11, offset: 4699},
+																pos: position{line: 240, col: 11, offset: 5121},
 																expr: &charClassMatcher{
-																	pos:        position{line: 223, col: 11, offset: 4699},
+																	pos:        position{line: 240, col: 11, offset: 5121},
 																	val:        "[^\"]",
 																	chars:      []rune{'"'},
 																	ignoreCase: false,
@@ -1248,7 +1410,7 @@ var g = &grammar{
 															},
 														},
 														&litMatcher{
-															pos:        position{line: 223, col: 17, offset: 4705},
+															pos:        position{line: 240, col: 17, offset: 5127},
 															val:        "\"",
 															ignoreCase: false,
 															want:       "\"\\\"\"",
@@ -1257,9 +1419,9 @@ var g = &grammar{
 												},
 											},
 											&oneOrMoreExpr{
-												pos: position{line: 73, col: 65, offset: 1829},
+												pos: position{line: 79, col: 65, offset: 2065},
 												expr: &charClassMatcher{
-													pos:        position{line: 73, col: 65, offset: 1829},
+													pos:        position{line: 79, col: 65, offset: 2065},
 													val:        "[^ ()]",
 													chars:      []rune{' ', '(', ')'},
 													ignoreCase: false,
@@ -1273,19 +1435,19 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 105, col: 5, offset: 2621},
-						run: (*parser).callonNode239,
+						pos: position{line: 116, col: 5, offset: 2921},
+						run: (*parser).callonNode272,
 						expr: &choiceExpr{
-							pos: position{line: 105, col: 6, offset: 2622},
+							pos: position{line: 116, col: 6, offset: 2922},
 							alternatives: []any{
 								&litMatcher{
-									pos:        position{line: 105, col: 6, offset: 2622},
+									pos:        position{line: 116, col: 6, offset: 2922},
 									val:        "AND",
 									ignoreCase: false,
 									want:       "\"AND\"",
 								},
 								&litMatcher{
-									pos:        position{line: 105, col: 14, offset: 2630},
+									pos:        position{line: 116, col: 14, offset: 2930},
 									val:        "+",
 									ignoreCase: false,
 									want:       "\"+\"",
@@ -1294,19 +1456,19 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 110, col: 5, offset: 2722},
-						run: (*parser).callonNode243,
+						pos: position{line: 121, col: 5, offset: 3022},
+						run: (*parser).callonNode276,
 						expr: &choiceExpr{
-							pos: position{line: 110, col: 6, offset: 2723},
+							pos: position{line: 121, col: 6, offset: 3023},
 							alternatives: []any{
 								&litMatcher{
-									pos:        position{line: 110, col: 6, offset: 2723},
+									pos:        position{line: 121, col: 6, offset: 3023},
 									val:        "NOT",
 									ignoreCase: false,
 									want:       "\"NOT\"",
 								},
 								&litMatcher{
-									pos:        position{line: 110, col: 14, offset: 2731},
+									pos:        position{line: 121, col: 14, offset: 3031},
 									val:        "-",
 									ignoreCase: false,
 									want:       "\"-\"",
@@ -1315,28 +1477,28 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 115, col: 5, offset: 2822},
-						run: (*parser).callonNode247,
+						pos: position{line: 126, col: 5, offset: 3122},
+						run: (*parser).callonNode280,
 						expr: &litMatcher{
-							pos:        position{line: 115, col: 6, offset: 2823},
+							pos:        position{line: 126, col: 6, offset: 3123},
 							val:        "OR",
 							ignoreCase: false,
 							want:       "\"OR\"",
 						},
 					},
 					&actionExpr{
-						pos: position{line: 86, col: 6, offset: 2109},
-						run: (*parser).callonNode249,
+						pos: position{line: 92, col: 6, offset: 2345},
+						run: (*parser).callonNode282,
 						expr: &seqExpr{
-							pos: position{line: 86, col: 6, offset: 2109},
+							pos: position{line: 92, col: 6, offset: 2345},
 							exprs: []any{
 								&zeroOrOneExpr{
-									pos: position{line: 86, col: 6, offset: 2109},
+									pos: position{line: 92, col: 6, offset: 2345},
 									expr: &actionExpr{
-										pos: position{line: 120, col: 5, offset: 2911},
-										run: (*parser).callonNode252,
+										pos: position{line: 131, col: 5, offset: 3211},
+										run: (*parser).callonNode285,
 										expr: &litMatcher{
-											pos:        position{line: 120, col: 5, offset: 2911},
+											pos:        position{line: 131, col: 5, offset: 3211},
 											val:        ":",
 											ignoreCase: false,
 											want:       "\":\"",
@@ -1344,12 +1506,12 @@ var g = &grammar{
 									},
 								},
 								&actionExpr{
-									pos: position{line: 233, col: 5, offset: 4804},
-									run: (*parser).callonNode254,
+									pos: position{line: 250, col: 5, offset: 5226},
+									run: (*parser).callonNode287,
 									expr: &zeroOrMoreExpr{
-										pos: position{line: 233, col: 5, offset: 4804},
+										pos: position{line: 250, col: 5, offset: 5226},
 										expr: &charClassMatcher{
-											pos:        position{line: 233, col: 5, offset: 4804},
+											pos:        position{line: 250, col: 5, offset: 5226},
 											val:        "[ \\t]",
 											chars:      []rune{' ', '\t'},
 											ignoreCase: false,
@@ -1358,27 +1520,27 @@ var g = &grammar{
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 86, col: 27, offset: 2130},
+									pos:   position{line: 92, col: 27, offset: 2366},
 									label: "v",
 									expr: &actionExpr{
-										pos: position{line: 223, col: 5, offset: 4693},
-										run: (*parser).callonNode258,
+										pos: position{line: 240, col: 5, offset: 5115},
+										run: (*parser).callonNode291,
 										expr: &seqExpr{
-											pos: position{line: 223, col: 5, offset: 4693},
+											pos: position{line: 240, col: 5, offset: 5115},
 											exprs: []any{
 												&litMatcher{
-													pos:        position{line: 223, col: 5, offset: 4693},
+													pos:        position{line: 240, col: 5, offset: 5115},
 													val:        "\"",
 													ignoreCase: false,
 													want:       "\"\\\"\"",
 												},
 												&labeledExpr{
-													pos:   position{line: 223, col: 9, offset: 4697},
+													pos:   position{line: 240, col: 9, offset: 5119},
 													label: "v",
 													expr: &zeroOrMoreExpr{
-														pos: position{line: 223, col: 11, offset: 4699},
+														pos: position{line: 240, col: 11, offset: 5121},
 														expr: &charClassMatcher{
-															pos:        position{line: 223, col: 11, offset: 4699},
+															pos:        position{line: 240, col: 11, offset: 5121},
 															val:        "[^\"]",
 															chars:      []rune{'"'},
 															ignoreCase: false,
@@ -1387,7 +1549,7 @@ var g = &grammar{
 													},
 												},
 												&litMatcher{
-													pos:        position{line: 223, col: 17, offset: 4705},
+													pos:        position{line: 240, col: 17, offset: 5127},
 													val:        "\"",
 													ignoreCase: false,
 													want:       "\"\\\"\"",
@@ -1397,12 +1559,12 @@ var g = &grammar{
 									},
 								},
 								&actionExpr{
-									pos: position{line: 233, col: 5, offset: 4804},
-									run: (*parser).callonNode265,
+									pos: position{line: 250, col: 5, offset: 5226},
+									run: (*parser).callonNode298,
 									expr: &zeroOrMoreExpr{
-										pos: position{line: 233, col: 5, offset: 4804},
+										pos: position{line: 250, col: 5, offset: 5226},
 										expr: &charClassMatcher{
-											pos:        position{line: 233, col: 5, offset: 4804},
+											pos:        position{line: 250, col: 5, offset: 5226},
 											val:        "[ \\t]",
 											chars:      []rune{' ', '\t'},
 											ignoreCase: false,
@@ -1411,12 +1573,12 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 86, col: 38, offset: 2141},
+									pos: position{line: 92, col: 38, offset: 2377},
 									expr: &actionExpr{
-										pos: position{line: 120, col: 5, offset: 2911},
-										run: (*parser).callonNode269,
+										pos: position{line: 131, col: 5, offset: 3211},
+										run: (*parser).callonNode302,
 										expr: &litMatcher{
-											pos:        position{line: 120, col: 5, offset: 2911},
+											pos:        position{line: 131, col: 5, offset: 3211},
 											val:        ":",
 											ignoreCase: false,
 											want:       "\":\"",
@@ -1427,18 +1589,18 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 91, col: 6, offset: 2239},
-						run: (*parser).callonNode271,
+						pos: position{line: 97, col: 6, offset: 2475},
+						run: (*parser).callonNode304,
 						expr: &seqExpr{
-							pos: position{line: 91, col: 6, offset: 2239},
+							pos: position{line: 97, col: 6, offset: 2475},
 							exprs: []any{
 								&zeroOrOneExpr{
-									pos: position{line: 91, col: 6, offset: 2239},
+									pos: position{line: 97, col: 6, offset: 2475},
 									expr: &actionExpr{
-										pos: position{line: 120, col: 5, offset: 2911},
-										run: (*parser).callonNode274,
+										pos: position{line: 131, col: 5, offset: 3211},
+										run: (*parser).callonNode307,
 										expr: &litMatcher{
-											pos:        position{line: 120, col: 5, offset: 2911},
+											pos:        position{line: 131, col: 5, offset: 3211},
 											val:        ":",
 											ignoreCase: false,
 											want:       "\":\"",
@@ -1446,12 +1608,12 @@ var g = &grammar{
 									},
 								},
 								&actionExpr{
-									pos: position{line: 233, col: 5, offset: 4804},
-									run: (*parser).callonNode276,
+									pos: position{line: 250, col: 5, offset: 5226},
+									run: (*parser).callonNode309,
 									expr: &zeroOrMoreExpr{
-										pos: position{line: 233, col: 5, offset: 4804},
+										pos: position{line: 250, col: 5, offset: 5226},
 										expr: &charClassMatcher{
-											pos:        position{line: 233, col: 5, offset: 4804},
+											pos:        position{line: 250, col: 5, offset: 5226},
 											val:        "[ \\t]",
 											chars:      []rune{' ', '\t'},
 											ignoreCase: false,
@@ -1460,12 +1622,12 @@ var g = &grammar{
 									},
 								},
 								&labeledExpr{
-									pos:   position{line: 91, col: 27, offset: 2260},
+									pos:   position{line: 97, col: 27, offset: 2496},
 									label: "v",
 									expr: &oneOrMoreExpr{
-										pos: position{line: 91, col: 29, offset: 2262},
+										pos: position{line: 97, col: 29, offset: 2498},
 										expr: &charClassMatcher{
-											pos:        position{line: 91, col: 29, offset: 2262},
+											pos:        position{line: 97, col: 29, offset: 2498},
 											val:        "[^ :()]",
 											chars:      []rune{' ', ':', '(', ')'},
 											ignoreCase: false,
@@ -1474,12 +1636,12 @@ var g = &grammar{
 									},
 								},
 								&actionExpr{
-									pos: position{line: 233, col: 5, offset: 4804},
-									run: (*parser).callonNode282,
+									pos: position{line: 250, col: 5, offset: 5226},
+									run: (*parser).callonNode315,
 									expr: &zeroOrMoreExpr{
-										pos: position{line: 233, col: 5, offset: 4804},
+										pos: position{line: 250, col: 5, offset: 5226},
 										expr: &charClassMatcher{
-											pos:        position{line: 233, col: 5, offset: 4804},
+											pos:        position{line: 250, col: 5, offset: 5226},
 											val:        "[ \\t]",
 											chars:      []rune{' ', '\t'},
 											ignoreCase: false,
@@ -1488,12 +1650,12 @@ var g = &grammar{
 									},
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 91, col: 40, offset: 2273},
+									pos: position{line: 97, col: 40, offset: 2509},
 									expr: &actionExpr{
-										pos: position{line: 120, col: 5, offset: 2911},
-										run: (*parser).callonNode286,
+										pos: position{line: 131, col: 5, offset: 3211},
+										run: (*parser).callonNode319,
 										expr: &litMatcher{
-											pos:        position{line: 120, col: 5, offset: 2911},
+											pos:        position{line: 131, col: 5, offset: 3211},
 											val:        ":",
 											ignoreCase: false,
 											want:       "\":\"",
@@ -1523,10 +1685,10 @@ var g = &grammar{
 								expr: &oneOrMoreExpr{
 									pos: position{line: 32, col: 8, offset: 615},
 									expr: &actionExpr{
-										pos: position{line: 218, col: 5, offset: 4634},
+										pos: position{line: 235, col: 5, offset: 5056},
 										run: (*parser).callonGroupNode6,
 										expr: &charClassMatcher{
-											pos:        position{line: 218, col: 5, offset: 4634},
+											pos:        position{line: 235, col: 5, offset: 5056},
 											val:        "[A-Za-z]",
 											ranges:     []rune{'A', 'Z', 'a', 'z'},
 											ignoreCase: false,
@@ -1542,20 +1704,20 @@ var g = &grammar{
 								pos: position{line: 32, col: 17, offset: 624},
 								alternatives: []any{
 									&actionExpr{
-										pos: position{line: 120, col: 5, offset: 2911},
+										pos: position{line: 131, col: 5, offset: 3211},
 										run: (*parser).callonGroupNode10,
 										expr: &litMatcher{
-											pos:        position{line: 120, col: 5, offset: 2911},
+											pos:        position{line: 131, col: 5, offset: 3211},
 											val:        ":",
 											ignoreCase: false,
 											want:       "\":\"",
 										},
 									},
 									&actionExpr{
-										pos: position{line: 125, col: 5, offset: 2997},
+										pos: position{line: 136, col: 5, offset: 3297},
 										run: (*parser).callonGroupNode12,
 										expr: &litMatcher{
-											pos:        position{line: 125, col: 5, offset: 2997},
+											pos:        position{line: 136, col: 5, offset: 3297},
 											val:        "=",
 											ignoreCase: false,
 											want:       "\"=\"",
@@ -2427,15 +2589,37 @@ func (p *parser) callonNode198() (any, error) {
 	return p.cur.onNode198()
 }
 
-func (c *current) onNode213() (any, error) {
+func (c *current) onNode208() (any, error) {
 	return c.text, nil
 
 }
 
-func (p *parser) callonNode213() (any, error) {
+func (p *parser) callonNode208() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode213()
+	return p.cur.onNode208()
+}
+
+func (c *current) onNode204() (any, error) {
+	return c.text, nil
+
+}
+
+func (p *parser) callonNode204() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode204()
+}
+
+func (c *current) onNode220() (any, error) {
+	return c.text, nil
+
+}
+
+func (p *parser) callonNode220() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode220()
 }
 
 func (c *current) onNode189(k, v any) (any, error) {
@@ -2449,213 +2633,279 @@ func (p *parser) callonNode189() (any, error) {
 	return p.cur.onNode189(stack["k"], stack["v"])
 }
 
-func (c *current) onNode221() (any, error) {
+func (c *current) onNode228() (any, error) {
+	return c.text, nil
+
+}
+
+func (p *parser) callonNode228() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode228()
+}
+
+func (c *current) onNode232() (any, error) {
 	return c.text, nil
 
 }
 
-func (p *parser) callonNode221() (any, error) {
+func (p *parser) callonNode232() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode221()
+	return p.cur.onNode232()
 }
 
-func (c *current) onNode224() (any, error) {
+func (c *current) onNode235() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
+func (p *parser) callonNode235() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode235()
+}
+
+func (c *current) onNode237() (any, error) {
+	return buildOperatorNode(c.text, c.pos)
+
+}
+
+func (p *parser) callonNode237() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode237()
+}
+
+func (c *current) onNode241(v any) (any, error) {
+	return v, nil
+
+}
+
+func (p *parser) callonNode241() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode241(stack["v"])
+}
+
+func (c *current) onNode224(k, m, v any) (any, error) {
+	return buildMatchModeStringNode(k, m, v, c.text, c.pos)
+
+}
+
 func (p *parser) callonNode224() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode224()
+	return p.cur.onNode224(stack["k"], stack["m"], stack["v"])
+}
+
+func (c *current) onNode254() (any, error) {
+	return c.text, nil
+
+}
+
+func (p *parser) callonNode254() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode254()
+}
+
+func (c *current) onNode257() (any, error) {
+	return buildOperatorNode(c.text, c.pos)
+
+}
+
+func (p *parser) callonNode257() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNode257()
 }
 
-func (c *current) onNode226() (any, error) {
+func (c *current) onNode259() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode226() (any, error) {
+func (p *parser) callonNode259() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode226()
+	return p.cur.onNode259()
 }
 
-func (c *current) onNode230(v any) (any, error) {
+func (c *current) onNode263(v any) (any, error) {
 	return v, nil
 
 }
 
-func (p *parser) callonNode230() (any, error) {
+func (p *parser) callonNode263() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode230(stack["v"])
+	return p.cur.onNode263(stack["v"])
 }
 
-func (c *current) onNode217(k, v any) (any, error) {
+func (c *current) onNode250(k, v any) (any, error) {
 	return buildStringNode(k, v, c.text, c.pos)
 
 }
 
-func (p *parser) callonNode217() (any, error) {
+func (p *parser) callonNode250() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode217(stack["k"], stack["v"])
+	return p.cur.onNode250(stack["k"], stack["v"])
 }
 
-func (c *current) onNode239() (any, error) {
+func (c *current) onNode272() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode239() (any, error) {
+func (p *parser) callonNode272() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode239()
+	return p.cur.onNode272()
 }
 
-func (c *current) onNode243() (any, error) {
+func (c *current) onNode276() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode243() (any, error) {
+func (p *parser) callonNode276() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode243()
+	return p.cur.onNode276()
 }
 
-func (c *current) onNode247() (any, error) {
+func (c *current) onNode280() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode247() (any, error) {
+func (p *parser) callonNode280() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode247()
+	return p.cur.onNode280()
 }
 
-func (c *current) onNode252() (any, error) {
+func (c *current) onNode285() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode252() (any, error) {
+func (p *parser) callonNode285() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode252()
+	return p.cur.onNode285()
 }
 
-func (c *current) onNode254() (any, error) {
+func (c *current) onNode287() (any, error) {
 	return nil, nil
 
 }
 
-func (p *parser) callonNode254() (any, error) {
+func (p *parser) callonNode287() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode254()
+	return p.cur.onNode287()
 }
 
-func (c *current) onNode258(v any) (any, error) {
+func (c *current) onNode291(v any) (any, error) {
 	return v, nil
 
 }
 
-func (p *parser) callonNode258() (any, error) {
+func (p *parser) callonNode291() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode258(stack["v"])
+	return p.cur.onNode291(stack["v"])
 }
 
-func (c *current) onNode265(v any) (any, error) {
+func (c *current) onNode298(v any) (any, error) {
 	return nil, nil
 
 }
 
-func (p *parser) callonNode265() (any, error) {
+func (p *parser) callonNode298() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode265(stack["v"])
+	return p.cur.onNode298(stack["v"])
 }
 
-func (c *current) onNode269() (any, error) {
+func (c *current) onNode302() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode269() (any, error) {
+func (p *parser) callonNode302() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode269()
+	return p.cur.onNode302()
 }
 
-func (c *current) onNode249(v any) (any, error) {
+func (c *current) onNode282(v any) (any, error) {
 	return buildStringNode("", v, c.text, c.pos)
 
 }
 
-func (p *parser) callonNode249() (any, error) {
+func (p *parser) callonNode282() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode249(stack["v"])
+	return p.cur.onNode282(stack["v"])
 }
 
-func (c *current) onNode274() (any, error) {
+func (c *current) onNode307() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode274() (any, error) {
+func (p *parser) callonNode307() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode274()
+	return p.cur.onNode307()
 }
 
-func (c *current) onNode276() (any, error) {
+func (c *current) onNode309() (any, error) {
 	return nil, nil
 
 }
 
-func (p *parser) callonNode276() (any, error) {
+func (p *parser) callonNode309() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode276()
+	return p.cur.onNode309()
 }
 
-func (c *current) onNode282(v any) (any, error) {
+func (c *current) onNode315(v any) (any, error) {
 	return nil, nil
 
 }
 
-func (p *parser) callonNode282() (any, error) {
+func (p *parser) callonNode315() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode282(stack["v"])
+	return p.cur.onNode315(stack["v"])
 }
 
-func (c *current) onNode286() (any, error) {
+func (c *current) onNode319() (any, error) {
 	return buildOperatorNode(c.text, c.pos)
 
 }
 
-func (p *parser) callonNode286() (any, error) {
+func (p *parser) callonNode319() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode286()
+	return p.cur.onNode319()
 }
 
-func (c *current) onNode271(v any) (any, error) {
+func (c *current) onNode304(v any) (any, error) {
 	return buildStringNode("", v, c.text, c.pos)
 
 }
 
-func (p *parser) callonNode271() (any, error) {
+func (p *parser) callonNode304() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNode271(stack["v"])
+	return p.cur.onNode304(stack["v"])
 }
 
 func (c *current) onGroupNode6() (any, error) {
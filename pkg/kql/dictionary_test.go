@@ -414,6 +414,24 @@ func TestParse_Spec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: `name~phonetic:Jonson`,
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "name", Value: "Jonson", Mode: kql.MatchPhonetic},
+				},
+			},
+		},
+		{
+			name: `name~phonetic:"Jon Smith" name:report`,
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "name", Value: "Jon Smith", Mode: kql.MatchPhonetic},
+					&ast.OperatorNode{Value: kql.BoolOR},
+					&ast.StringNode{Key: "name", Value: "report"},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -848,6 +866,99 @@ func TestParse_DateTimeRestrictionNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "Relative DateTimeNode - last-7d",
+			patch: setWorldClock(t, "2023-09-06 14:30:00"),
+			query: join([]string{
+				`Mtime:last-7d`,
+			}),
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: ">="},
+						Value:    mustParseTime(t, "2023-08-30 14:30:00"),
+					},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: "<="},
+						Value:    mustParseTime(t, "2023-09-06 14:30:00"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Relative DateTimeNode - last-24h",
+			patch: setWorldClock(t, "2023-09-06 14:30:00"),
+			query: join([]string{
+				`Mtime:last-24h`,
+			}),
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: ">="},
+						Value:    mustParseTime(t, "2023-09-05 14:30:00"),
+					},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: "<="},
+						Value:    mustParseTime(t, "2023-09-06 14:30:00"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Relative DateTimeNode - last-30m",
+			patch: setWorldClock(t, "2023-09-06 00:10:00"),
+			query: join([]string{
+				`Mtime=last-30m`,
+			}),
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: ">="},
+						Value:    mustParseTime(t, "2023-09-05 23:40:00"),
+					},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: "<="},
+						Value:    mustParseTime(t, "2023-09-06 00:10:00"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Relative DateTimeNode - last-2w",
+			patch: setWorldClock(t, "2023-09-06 14:30:00"),
+			query: join([]string{
+				`Mtime:"last-2w"`,
+			}),
+			ast: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: ">="},
+						Value:    mustParseTime(t, "2023-08-23 14:30:00"),
+					},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.DateTimeNode{
+						Key:      "Mtime",
+						Operator: &ast.OperatorNode{Value: "<="},
+						Value:    mustParseTime(t, "2023-09-06 14:30:00"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Relative DateTimeNode - empty range",
+			query: `Mtime:last-0d`,
+			error: query.UnsupportedTimeRangeError{},
+		},
 	}
 
 	for _, tc := range tests {
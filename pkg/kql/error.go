@@ -0,0 +1,17 @@
+package kql
+
+import "github.com/opencloud-eu/opencloud/pkg/ast"
+
+// SyntaxError records an error of the query and the position in the query at which it occurred.
+type SyntaxError struct {
+	Err      error
+	Position ast.Position
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
@@ -73,6 +73,21 @@ func buildStringNode(k, v interface{}, text []byte, pos position) (*ast.StringNo
 	}, nil
 }
 
+func buildMatchModeStringNode(k, m, v interface{}, text []byte, pos position) (*ast.StringNode, error) {
+	node, err := buildStringNode(k, v, text, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := toString(m)
+	if err != nil {
+		return nil, err
+	}
+
+	node.Mode = mode
+	return node, nil
+}
+
 func buildDateTimeNode(k, o, v interface{}, text []byte, pos position) (*ast.DateTimeNode, error) {
 	b, err := base(text, pos)
 	if err != nil {
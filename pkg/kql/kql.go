@@ -18,8 +18,21 @@ const (
 	BoolNOT = "NOT"
 )
 
+// The match modes of the property restrictions
+const (
+	// MatchPhonetic matches the values which sound like the given value, name~phonetic:jonson
+	MatchPhonetic = "phonetic"
+)
+
 // Builder implements kql Builder interface
-type Builder struct{}
+type Builder struct {
+	// DefaultOperator connects free-text terms which have no operator in between, BoolAND if empty.
+	// Property restrictions are always connected with BoolAND.
+	DefaultOperator string
+	// MinimumShouldMatch is the number of the implicitly connected free-text terms which have to match,
+	// see MinimumMatch for the format. It takes precedence over the DefaultOperator if set.
+	MinimumShouldMatch string
+}
 
 // Build creates an ast.Ast based on a kql query
 func (b Builder) Build(q string) (*ast.Ast, error) {
@@ -32,16 +45,38 @@ func (b Builder) Build(q string) (*ast.Ast, error) {
 			var parserError *parserError
 			switch {
 			case errors.As(listError, &parserError):
+				position := ast.Position{Line: parserError.pos.line, Column: parserError.pos.col}
 				if parserError.Inner != nil {
-					return nil, parserError.Inner
+					return nil, &SyntaxError{Err: parserError.Inner, Position: position}
 				}
 
-				return nil, listError
+				return nil, &SyntaxError{Err: listError, Position: position}
 			}
 		}
 	}
 
-	return f.(*ast.Ast), nil
+	a := f.(*ast.Ast)
+	switch {
+	case b.MinimumShouldMatch != "":
+		if _, err := MinimumMatch(b.MinimumShouldMatch, 1); err != nil {
+			return nil, err
+		}
+
+		a.Nodes = connectFreeText(a.Nodes, func(terms int) (string, int) {
+			minimum, _ := MinimumMatch(b.MinimumShouldMatch, terms)
+			switch {
+			case minimum >= terms:
+				return BoolAND, 0
+			case minimum <= 1:
+				return BoolOR, 0
+			}
+			return BoolOR, minimum
+		})
+	case b.DefaultOperator == BoolOR:
+		a.Nodes = connectFreeText(a.Nodes, func(int) (string, int) { return BoolOR, 0 })
+	}
+
+	return a, nil
 }
 
 // timeNow mirrors time.Now by default, the only reason why this exists
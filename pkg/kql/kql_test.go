@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/pkg/ast/test"
 	"github.com/opencloud-eu/opencloud/pkg/kql"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
 	tAssert "github.com/stretchr/testify/assert"
@@ -52,3 +53,184 @@ func TestNewAST(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilder_SyntaxError(t *testing.T) {
+	for _, givenQuery := range []string{"", kql.BoolAND + " foo"} {
+		_, err := kql.Builder{}.Build(givenQuery)
+
+		var syntaxErr *kql.SyntaxError
+		tAssert.ErrorAs(t, err, &syntaxErr, givenQuery)
+		tAssert.Equal(t, ast.Position{Line: 1, Column: 1}, syntaxErr.Position, givenQuery)
+		tAssert.Equal(t, syntaxErr.Err.Error(), err.Error(), givenQuery)
+	}
+
+	// the errors of the query are still found in the chain
+	_, err := kql.Builder{}.Build(kql.BoolAND)
+	var startsWithBinaryOperator *query.StartsWithBinaryOperatorError
+	tAssert.ErrorAs(t, err, &startsWithBinaryOperator)
+}
+
+func TestBuilder_DefaultOperator(t *testing.T) {
+	tests := []struct {
+		name            string
+		givenQuery      string
+		defaultOperator string
+		expectedAst     *ast.Ast
+	}{
+		{
+			name:       "AND by default",
+			givenQuery: "report quarterly",
+			expectedAst: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Value: "report"},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.StringNode{Value: "quarterly"},
+				},
+			},
+		},
+		{
+			name:            "OR between free-text terms",
+			givenQuery:      "report quarterly",
+			defaultOperator: kql.BoolOR,
+			expectedAst: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.GroupNode{Nodes: []ast.Node{
+						&ast.StringNode{Value: "report"},
+						&ast.OperatorNode{Value: kql.BoolOR},
+						&ast.StringNode{Value: "quarterly"},
+					}},
+				},
+			},
+		},
+		{
+			name:            "property restrictions stay AND-ed",
+			givenQuery:      "report quarterly mediatype:pdf",
+			defaultOperator: kql.BoolOR,
+			expectedAst: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.GroupNode{Nodes: []ast.Node{
+						&ast.StringNode{Value: "report"},
+						&ast.OperatorNode{Value: kql.BoolOR},
+						&ast.StringNode{Value: "quarterly"},
+					}},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.StringNode{Key: "mediatype", Value: "pdf"},
+				},
+			},
+		},
+		{
+			name:            "explicit operators are kept",
+			givenQuery:      "report AND quarterly annual -draft",
+			defaultOperator: kql.BoolOR,
+			expectedAst: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Value: "report"},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.GroupNode{Nodes: []ast.Node{
+						&ast.StringNode{Value: "quarterly"},
+						&ast.OperatorNode{Value: kql.BoolOR},
+						&ast.StringNode{Value: "annual"},
+					}},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.OperatorNode{Value: kql.BoolNOT},
+					&ast.StringNode{Value: "draft"},
+				},
+			},
+		},
+		{
+			name:            "groups",
+			givenQuery:      "(report quarterly) name:(annual summary)",
+			defaultOperator: kql.BoolOR,
+			expectedAst: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.GroupNode{Nodes: []ast.Node{
+						&ast.GroupNode{Nodes: []ast.Node{
+							&ast.StringNode{Value: "report"},
+							&ast.OperatorNode{Value: kql.BoolOR},
+							&ast.StringNode{Value: "quarterly"},
+						}},
+					}},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.GroupNode{Key: "name", Nodes: []ast.Node{
+						&ast.StringNode{Value: "annual"},
+						&ast.OperatorNode{Value: kql.BoolAND},
+						&ast.StringNode{Value: "summary"},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kql.Builder{DefaultOperator: tt.defaultOperator}.Build(tt.givenQuery)
+			tAssert.Nil(t, err)
+
+			if diff := test.DiffAst(tt.expectedAst, got); diff != "" {
+				t.Fatalf("AST mismatch \nquery: '%s' \n(-expected +got): %s", tt.givenQuery, diff)
+			}
+		})
+	}
+}
+
+func TestBuilder_MinimumShouldMatch(t *testing.T) {
+	got, err := kql.Builder{MinimumShouldMatch: "75%"}.Build("annual report summary 2024 mediatype:pdf")
+	tAssert.Nil(t, err)
+
+	expectedAst := &ast.Ast{
+		Nodes: []ast.Node{
+			&ast.GroupNode{MinimumMatch: 3, Nodes: []ast.Node{
+				&ast.StringNode{Value: "annual"},
+				&ast.OperatorNode{Value: kql.BoolOR},
+				&ast.StringNode{Value: "report"},
+				&ast.OperatorNode{Value: kql.BoolOR},
+				&ast.StringNode{Value: "summary"},
+				&ast.OperatorNode{Value: kql.BoolOR},
+				&ast.StringNode{Value: "2024"},
+			}},
+			&ast.OperatorNode{Value: kql.BoolAND},
+			&ast.StringNode{Key: "mediatype", Value: "pdf"},
+		},
+	}
+	if diff := test.DiffAst(expectedAst, got); diff != "" {
+		t.Fatalf("AST mismatch (-expected +got): %s", diff)
+	}
+
+	_, err = kql.Builder{MinimumShouldMatch: "most"}.Build("annual report")
+	tAssert.Error(t, err)
+}
+
+func TestMinimumMatch(t *testing.T) {
+	tests := []struct {
+		spec     string
+		terms    int
+		expected int
+	}{
+		{spec: "3", terms: 4, expected: 3},
+		{spec: "5", terms: 4, expected: 4},
+		{spec: "0", terms: 4, expected: 1},
+		{spec: "-1", terms: 4, expected: 3},
+		{spec: "-5", terms: 4, expected: 1},
+		{spec: "75%", terms: 4, expected: 3},
+		{spec: "75%", terms: 3, expected: 2},
+		{spec: "75%", terms: 2, expected: 1},
+		{spec: "100%", terms: 4, expected: 4},
+		{spec: "-25%", terms: 4, expected: 3},
+		{spec: "-25%", terms: 5, expected: 4},
+		{spec: "-10%", terms: 4, expected: 4},
+		{spec: " 50% ", terms: 4, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := kql.MinimumMatch(tt.spec, tt.terms)
+			tAssert.Nil(t, err)
+			tAssert.Equal(t, tt.expected, got)
+		})
+	}
+
+	for _, spec := range []string{"", "most", "%", "150%", "3.5"} {
+		_, err := kql.MinimumMatch(spec, 4)
+		tAssert.Error(t, err, spec)
+	}
+}
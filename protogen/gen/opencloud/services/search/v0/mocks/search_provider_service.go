@@ -39,6 +39,255 @@ func (_m *SearchProviderService) EXPECT() *SearchProviderService_Expecter {
 	return &SearchProviderService_Expecter{mock: &_m.Mock}
 }
 
+// Capabilities provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) Capabilities(ctx context.Context, in *v0.CapabilitiesRequest, opts ...client.CallOption) (*v0.CapabilitiesResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for Capabilities")
+	}
+
+	var r0 *v0.CapabilitiesResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.CapabilitiesRequest, ...client.CallOption) (*v0.CapabilitiesResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.CapabilitiesRequest, ...client.CallOption) *v0.CapabilitiesResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.CapabilitiesResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.CapabilitiesRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_Capabilities_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Capabilities'
+type SearchProviderService_Capabilities_Call struct {
+	*mock.Call
+}
+
+// Capabilities is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.CapabilitiesRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) Capabilities(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_Capabilities_Call {
+	return &SearchProviderService_Capabilities_Call{Call: _e.mock.On("Capabilities",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_Capabilities_Call) Run(run func(ctx context.Context, in *v0.CapabilitiesRequest, opts ...client.CallOption)) *SearchProviderService_Capabilities_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.CapabilitiesRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.CapabilitiesRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_Capabilities_Call) Return(indexSpaceResponse *v0.CapabilitiesResponse, err error) *SearchProviderService_Capabilities_Call {
+	_c.Call.Return(indexSpaceResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_Capabilities_Call) RunAndReturn(run func(ctx context.Context, in *v0.CapabilitiesRequest, opts ...client.CallOption) (*v0.CapabilitiesResponse, error)) *SearchProviderService_Capabilities_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDocument provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) GetDocument(ctx context.Context, in *v0.GetDocumentRequest, opts ...client.CallOption) (*v0.GetDocumentResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocument")
+	}
+
+	var r0 *v0.GetDocumentResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetDocumentRequest, ...client.CallOption) (*v0.GetDocumentResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetDocumentRequest, ...client.CallOption) *v0.GetDocumentResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.GetDocumentResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.GetDocumentRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_GetDocument_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocument'
+type SearchProviderService_GetDocument_Call struct {
+	*mock.Call
+}
+
+// GetDocument is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.GetDocumentRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) GetDocument(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_GetDocument_Call {
+	return &SearchProviderService_GetDocument_Call{Call: _e.mock.On("GetDocument",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_GetDocument_Call) Run(run func(ctx context.Context, in *v0.GetDocumentRequest, opts ...client.CallOption)) *SearchProviderService_GetDocument_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.GetDocumentRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.GetDocumentRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_GetDocument_Call) Return(indexSpaceResponse *v0.GetDocumentResponse, err error) *SearchProviderService_GetDocument_Call {
+	_c.Call.Return(indexSpaceResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_GetDocument_Call) RunAndReturn(run func(ctx context.Context, in *v0.GetDocumentRequest, opts ...client.CallOption) (*v0.GetDocumentResponse, error)) *SearchProviderService_GetDocument_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDocuments provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) GetDocuments(ctx context.Context, in *v0.GetDocumentsRequest, opts ...client.CallOption) (*v0.GetDocumentsResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocuments")
+	}
+
+	var r0 *v0.GetDocumentsResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetDocumentsRequest, ...client.CallOption) (*v0.GetDocumentsResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetDocumentsRequest, ...client.CallOption) *v0.GetDocumentsResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.GetDocumentsResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.GetDocumentsRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_GetDocuments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocuments'
+type SearchProviderService_GetDocuments_Call struct {
+	*mock.Call
+}
+
+// GetDocuments is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.GetDocumentsRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) GetDocuments(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_GetDocuments_Call {
+	return &SearchProviderService_GetDocuments_Call{Call: _e.mock.On("GetDocuments",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_GetDocuments_Call) Run(run func(ctx context.Context, in *v0.GetDocumentsRequest, opts ...client.CallOption)) *SearchProviderService_GetDocuments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.GetDocumentsRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.GetDocumentsRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_GetDocuments_Call) Return(indexSpaceResponse *v0.GetDocumentsResponse, err error) *SearchProviderService_GetDocuments_Call {
+	_c.Call.Return(indexSpaceResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_GetDocuments_Call) RunAndReturn(run func(ctx context.Context, in *v0.GetDocumentsRequest, opts ...client.CallOption) (*v0.GetDocumentsResponse, error)) *SearchProviderService_GetDocuments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IndexSpace provides a mock function for the type SearchProviderService
 func (_mock *SearchProviderService) IndexSpace(ctx context.Context, in *v0.IndexSpaceRequest, opts ...client.CallOption) (*v0.IndexSpaceResponse, error) {
 	var tmpRet mock.Arguments
@@ -122,6 +371,89 @@ func (_c *SearchProviderService_IndexSpace_Call) RunAndReturn(run func(ctx conte
 	return _c
 }
 
+// ResetIndex provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) ResetIndex(ctx context.Context, in *v0.ResetIndexRequest, opts ...client.CallOption) (*v0.ResetIndexResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetIndex")
+	}
+
+	var r0 *v0.ResetIndexResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.ResetIndexRequest, ...client.CallOption) (*v0.ResetIndexResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.ResetIndexRequest, ...client.CallOption) *v0.ResetIndexResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.ResetIndexResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.ResetIndexRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_ResetIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetIndex'
+type SearchProviderService_ResetIndex_Call struct {
+	*mock.Call
+}
+
+// ResetIndex is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.ResetIndexRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) ResetIndex(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_ResetIndex_Call {
+	return &SearchProviderService_ResetIndex_Call{Call: _e.mock.On("ResetIndex",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_ResetIndex_Call) Run(run func(ctx context.Context, in *v0.ResetIndexRequest, opts ...client.CallOption)) *SearchProviderService_ResetIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.ResetIndexRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.ResetIndexRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_ResetIndex_Call) Return(indexSpaceResponse *v0.ResetIndexResponse, err error) *SearchProviderService_ResetIndex_Call {
+	_c.Call.Return(indexSpaceResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_ResetIndex_Call) RunAndReturn(run func(ctx context.Context, in *v0.ResetIndexRequest, opts ...client.CallOption) (*v0.ResetIndexResponse, error)) *SearchProviderService_ResetIndex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Search provides a mock function for the type SearchProviderService
 func (_mock *SearchProviderService) Search(ctx context.Context, in *v0.SearchRequest, opts ...client.CallOption) (*v0.SearchResponse, error) {
 	var tmpRet mock.Arguments
@@ -204,3 +536,86 @@ func (_c *SearchProviderService_Search_Call) RunAndReturn(run func(ctx context.C
 	_c.Call.Return(run)
 	return _c
 }
+
+// SumField provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) SumField(ctx context.Context, in *v0.SumFieldRequest, opts ...client.CallOption) (*v0.SumFieldResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for SumField")
+	}
+
+	var r0 *v0.SumFieldResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SumFieldRequest, ...client.CallOption) (*v0.SumFieldResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SumFieldRequest, ...client.CallOption) *v0.SumFieldResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.SumFieldResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.SumFieldRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_SumField_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SumField'
+type SearchProviderService_SumField_Call struct {
+	*mock.Call
+}
+
+// SumField is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.SumFieldRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) SumField(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_SumField_Call {
+	return &SearchProviderService_SumField_Call{Call: _e.mock.On("SumField",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_SumField_Call) Run(run func(ctx context.Context, in *v0.SumFieldRequest, opts ...client.CallOption)) *SearchProviderService_SumField_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.SumFieldRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.SumFieldRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_SumField_Call) Return(sumFieldResponse *v0.SumFieldResponse, err error) *SearchProviderService_SumField_Call {
+	_c.Call.Return(sumFieldResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_SumField_Call) RunAndReturn(run func(ctx context.Context, in *v0.SumFieldRequest, opts ...client.CallOption) (*v0.SumFieldResponse, error)) *SearchProviderService_SumField_Call {
+	_c.Call.Return(run)
+	return _c
+}
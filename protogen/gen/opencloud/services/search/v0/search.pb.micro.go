@@ -47,6 +47,36 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 			Method:  []string{"POST"},
 			Handler: "rpc",
 		},
+		{
+			Name:    "SearchProvider.GetDocument",
+			Path:    []string{"/api/v0/search/document"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.Capabilities",
+			Path:    []string{"/api/v0/search/capabilities"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.GetDocuments",
+			Path:    []string{"/api/v0/search/documents"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.ResetIndex",
+			Path:    []string{"/api/v0/search/reset-index"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.SumField",
+			Path:    []string{"/api/v0/search/sum-field"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
 	}
 }
 
@@ -55,6 +85,11 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 type SearchProviderService interface {
 	Search(ctx context.Context, in *SearchRequest, opts ...client.CallOption) (*SearchResponse, error)
 	IndexSpace(ctx context.Context, in *IndexSpaceRequest, opts ...client.CallOption) (*IndexSpaceResponse, error)
+	GetDocument(ctx context.Context, in *GetDocumentRequest, opts ...client.CallOption) (*GetDocumentResponse, error)
+	Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...client.CallOption) (*CapabilitiesResponse, error)
+	GetDocuments(ctx context.Context, in *GetDocumentsRequest, opts ...client.CallOption) (*GetDocumentsResponse, error)
+	ResetIndex(ctx context.Context, in *ResetIndexRequest, opts ...client.CallOption) (*ResetIndexResponse, error)
+	SumField(ctx context.Context, in *SumFieldRequest, opts ...client.CallOption) (*SumFieldResponse, error)
 }
 
 type searchProviderService struct {
@@ -89,17 +124,77 @@ func (c *searchProviderService) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return out, nil
 }
 
+func (c *searchProviderService) GetDocument(ctx context.Context, in *GetDocumentRequest, opts ...client.CallOption) (*GetDocumentResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.GetDocument", in)
+	out := new(GetDocumentResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...client.CallOption) (*CapabilitiesResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.Capabilities", in)
+	out := new(CapabilitiesResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) GetDocuments(ctx context.Context, in *GetDocumentsRequest, opts ...client.CallOption) (*GetDocumentsResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.GetDocuments", in)
+	out := new(GetDocumentsResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) ResetIndex(ctx context.Context, in *ResetIndexRequest, opts ...client.CallOption) (*ResetIndexResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.ResetIndex", in)
+	out := new(ResetIndexResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) SumField(ctx context.Context, in *SumFieldRequest, opts ...client.CallOption) (*SumFieldResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.SumField", in)
+	out := new(SumFieldResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for SearchProvider service
 
 type SearchProviderHandler interface {
 	Search(context.Context, *SearchRequest, *SearchResponse) error
 	IndexSpace(context.Context, *IndexSpaceRequest, *IndexSpaceResponse) error
+	GetDocument(context.Context, *GetDocumentRequest, *GetDocumentResponse) error
+	Capabilities(context.Context, *CapabilitiesRequest, *CapabilitiesResponse) error
+	GetDocuments(context.Context, *GetDocumentsRequest, *GetDocumentsResponse) error
+	ResetIndex(context.Context, *ResetIndexRequest, *ResetIndexResponse) error
+	SumField(context.Context, *SumFieldRequest, *SumFieldResponse) error
 }
 
 func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler, opts ...server.HandlerOption) error {
 	type searchProvider interface {
 		Search(ctx context.Context, in *SearchRequest, out *SearchResponse) error
 		IndexSpace(ctx context.Context, in *IndexSpaceRequest, out *IndexSpaceResponse) error
+		GetDocument(ctx context.Context, in *GetDocumentRequest, out *GetDocumentResponse) error
+		Capabilities(ctx context.Context, in *CapabilitiesRequest, out *CapabilitiesResponse) error
+		GetDocuments(ctx context.Context, in *GetDocumentsRequest, out *GetDocumentsResponse) error
+		ResetIndex(ctx context.Context, in *ResetIndexRequest, out *ResetIndexResponse) error
+		SumField(ctx context.Context, in *SumFieldRequest, out *SumFieldResponse) error
 	}
 	type SearchProvider struct {
 		searchProvider
@@ -117,6 +212,36 @@ func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler,
 		Method:  []string{"POST"},
 		Handler: "rpc",
 	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.GetDocument",
+		Path:    []string{"/api/v0/search/document"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.Capabilities",
+		Path:    []string{"/api/v0/search/capabilities"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.GetDocuments",
+		Path:    []string{"/api/v0/search/documents"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.ResetIndex",
+		Path:    []string{"/api/v0/search/reset-index"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.SumField",
+		Path:    []string{"/api/v0/search/sum-field"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
 	return s.Handle(s.NewHandler(&SearchProvider{h}, opts...))
 }
 
@@ -132,6 +257,26 @@ func (h *searchProviderHandler) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return h.SearchProviderHandler.IndexSpace(ctx, in, out)
 }
 
+func (h *searchProviderHandler) GetDocument(ctx context.Context, in *GetDocumentRequest, out *GetDocumentResponse) error {
+	return h.SearchProviderHandler.GetDocument(ctx, in, out)
+}
+
+func (h *searchProviderHandler) Capabilities(ctx context.Context, in *CapabilitiesRequest, out *CapabilitiesResponse) error {
+	return h.SearchProviderHandler.Capabilities(ctx, in, out)
+}
+
+func (h *searchProviderHandler) GetDocuments(ctx context.Context, in *GetDocumentsRequest, out *GetDocumentsResponse) error {
+	return h.SearchProviderHandler.GetDocuments(ctx, in, out)
+}
+
+func (h *searchProviderHandler) ResetIndex(ctx context.Context, in *ResetIndexRequest, out *ResetIndexResponse) error {
+	return h.SearchProviderHandler.ResetIndex(ctx, in, out)
+}
+
+func (h *searchProviderHandler) SumField(ctx context.Context, in *SumFieldRequest, out *SumFieldResponse) error {
+	return h.SearchProviderHandler.SumField(ctx, in, out)
+}
+
 // Api Endpoints for IndexProvider service
 
 func NewIndexProviderEndpoints() []*api.Endpoint {
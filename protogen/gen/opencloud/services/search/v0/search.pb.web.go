@@ -75,6 +75,136 @@ func (h *webSearchProviderHandler) IndexSpace(w http.ResponseWriter, r *http.Req
 	render.JSON(w, r, resp)
 }
 
+func (h *webSearchProviderHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	req := &GetDocumentRequest{}
+	resp := &GetDocumentResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.GetDocument(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	req := &CapabilitiesRequest{}
+	resp := &CapabilitiesResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.Capabilities(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) GetDocuments(w http.ResponseWriter, r *http.Request) {
+	req := &GetDocumentsRequest{}
+	resp := &GetDocumentsResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.GetDocuments(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) ResetIndex(w http.ResponseWriter, r *http.Request) {
+	req := &ResetIndexRequest{}
+	resp := &ResetIndexResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.ResetIndex(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) SumField(w http.ResponseWriter, r *http.Request) {
+	req := &SumFieldRequest{}
+	resp := &SumFieldResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.SumField(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
 func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middlewares ...func(http.Handler) http.Handler) {
 	handler := &webSearchProviderHandler{
 		r: r,
@@ -83,6 +213,11 @@ func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middleware
 
 	r.MethodFunc("POST", "/api/v0/search/search", handler.Search)
 	r.MethodFunc("POST", "/api/v0/search/index-space", handler.IndexSpace)
+	r.MethodFunc("POST", "/api/v0/search/document", handler.GetDocument)
+	r.MethodFunc("POST", "/api/v0/search/capabilities", handler.Capabilities)
+	r.MethodFunc("POST", "/api/v0/search/documents", handler.GetDocuments)
+	r.MethodFunc("POST", "/api/v0/search/reset-index", handler.ResetIndex)
+	r.MethodFunc("POST", "/api/v0/search/sum-field", handler.SumField)
 }
 
 type webIndexProviderHandler struct {
@@ -344,3 +479,363 @@ func (m *IndexSpaceResponse) UnmarshalJSON(b []byte) error {
 }
 
 var _ json.Unmarshaler = (*IndexSpaceResponse)(nil)
+
+// GetDocumentRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetDocumentRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetDocumentRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetDocumentRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetDocumentRequest)(nil)
+
+// GetDocumentRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetDocumentRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetDocumentRequest) UnmarshalJSON(b []byte) error {
+	return GetDocumentRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetDocumentRequest)(nil)
+
+// GetDocumentResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetDocumentResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetDocumentResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetDocumentResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetDocumentResponse)(nil)
+
+// GetDocumentResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetDocumentResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetDocumentResponse) UnmarshalJSON(b []byte) error {
+	return GetDocumentResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetDocumentResponse)(nil)
+
+// CapabilitiesRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of CapabilitiesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CapabilitiesRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *CapabilitiesRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := CapabilitiesRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*CapabilitiesRequest)(nil)
+
+// CapabilitiesRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of CapabilitiesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CapabilitiesRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *CapabilitiesRequest) UnmarshalJSON(b []byte) error {
+	return CapabilitiesRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*CapabilitiesRequest)(nil)
+
+// CapabilitiesResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of CapabilitiesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CapabilitiesResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *CapabilitiesResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := CapabilitiesResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*CapabilitiesResponse)(nil)
+
+// CapabilitiesResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of CapabilitiesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CapabilitiesResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *CapabilitiesResponse) UnmarshalJSON(b []byte) error {
+	return CapabilitiesResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*CapabilitiesResponse)(nil)
+
+// GetDocumentsRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetDocumentsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentsRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetDocumentsRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetDocumentsRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetDocumentsRequest)(nil)
+
+// GetDocumentsRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetDocumentsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentsRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetDocumentsRequest) UnmarshalJSON(b []byte) error {
+	return GetDocumentsRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetDocumentsRequest)(nil)
+
+// GetDocumentsResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetDocumentsResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentsResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetDocumentsResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetDocumentsResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetDocumentsResponse)(nil)
+
+// GetDocumentsResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetDocumentsResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetDocumentsResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetDocumentsResponse) UnmarshalJSON(b []byte) error {
+	return GetDocumentsResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetDocumentsResponse)(nil)
+
+// ResetIndexRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ResetIndexRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResetIndexRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ResetIndexRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ResetIndexRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ResetIndexRequest)(nil)
+
+// ResetIndexRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ResetIndexRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResetIndexRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ResetIndexRequest) UnmarshalJSON(b []byte) error {
+	return ResetIndexRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ResetIndexRequest)(nil)
+
+// ResetIndexResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ResetIndexResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResetIndexResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ResetIndexResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ResetIndexResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ResetIndexResponse)(nil)
+
+// ResetIndexResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ResetIndexResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ResetIndexResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ResetIndexResponse) UnmarshalJSON(b []byte) error {
+	return ResetIndexResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ResetIndexResponse)(nil)
+
+// SumFieldRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of SumFieldRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SumFieldRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *SumFieldRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := SumFieldRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*SumFieldRequest)(nil)
+
+// SumFieldRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of SumFieldRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SumFieldRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *SumFieldRequest) UnmarshalJSON(b []byte) error {
+	return SumFieldRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*SumFieldRequest)(nil)
+
+// SumFieldResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of SumFieldResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SumFieldResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *SumFieldResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := SumFieldResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*SumFieldResponse)(nil)
+
+// SumFieldResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of SumFieldResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SumFieldResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *SumFieldResponse) UnmarshalJSON(b []byte) error {
+	return SumFieldResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*SumFieldResponse)(nil)
@@ -1,12 +1,20 @@
 package scanners
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"path"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/opencloud-eu/reva/v2/pkg/mime"
@@ -88,8 +96,14 @@ func (s ICAP) Scan(in Input) (Result, error) {
 	}
 	result.ScanTime = time.Now()
 
+	// some servers report the verdict in the trailers of the encapsulated body instead of the ICAP headers
+	header := res.Header
+	if _, ok := header["X-Infection-Found"]; !ok {
+		header = contentTrailers(res.ContentResponse)
+	}
+
 	// TODO: make header configurable
-	if data, infected := res.Header["X-Infection-Found"]; infected {
+	if data, infected := header["X-Infection-Found"]; infected {
 		result.Infected = infected
 
 		match := regexp.MustCompile(`Threat=(.*);`).FindStringSubmatch(fmt.Sprint(data))
@@ -110,3 +124,58 @@ func (s ICAP) Scan(in Input) (Result, error) {
 
 	return result, nil
 }
+
+// contentTrailers returns the trailer headers following the terminating chunk of the encapsulated body, the body of the
+// response is consumed. The body is decoded already if the encapsulated response declares the chunked transfer encoding,
+// otherwise it still holds the ICAP chunks.
+func contentTrailers(res *http.Response) http.Header {
+	if res == nil || res.Body == nil {
+		return nil
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if slices.Contains(res.TransferEncoding, "chunked") {
+		// the trailers are set once the decoded body has been read completely
+		if err != nil {
+			return nil
+		}
+		return res.Trailer
+	}
+
+	return chunkTrailers(body)
+}
+
+// chunkTrailers parses the trailer headers following the terminating chunk of a chunked body, chunk extensions are ignored.
+// The chunk sizes are not relied on, the icap client trims the lines of the body.
+func chunkTrailers(body []byte) http.Header {
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	last := -1
+	for i, line := range lines {
+		if isLastChunk(string(line)) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil
+	}
+
+	trailers := bytes.Join(lines[last+1:], nil)
+	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(trailers))).ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return http.Header(header)
+}
+
+// isLastChunk checks if the line is the size line of the terminating chunk, for example "0" or "0; ieof"
+func isLastChunk(line string) bool {
+	size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+	if size = strings.TrimSpace(size); size == "" {
+		return false
+	}
+
+	n, err := strconv.ParseUint(size, 16, 64)
+	return err == nil && n == 0
+}
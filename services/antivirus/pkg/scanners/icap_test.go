@@ -1,13 +1,18 @@
 package scanners_test
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"net"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	ic "github.com/opencloud-eu/icap-client"
 
@@ -165,6 +170,66 @@ func TestICAP_Scan(t *testing.T) {
 				assert.Equal(t, "bad threat", result.Description)
 			})
 
+			// some servers return the X-Infection-Found header in the trailers of the encapsulated body
+			t.Run("X-Infection-Found trailer", func(t *testing.T) {
+				for name, transferEncoding := range map[string]string{
+					"with chunked transfer encoding":    "Transfer-Encoding: chunked\r\n",
+					"without chunked transfer encoding": "",
+				} {
+					t.Run(name, func(t *testing.T) {
+						contentResponse, err := http.ReadResponse(bufio.NewReader(strings.NewReader(
+							"HTTP/1.1 403 Forbidden\r\n"+transferEncoding+"\r\n"+
+								"7; ieof\r\nblocked\r\n0\r\nX-Infection-Found: Type=0; Resolution=2; Threat=bad threat;\r\n\r\n",
+						)), nil)
+						require.NoError(t, err)
+
+						client.EXPECT().Do(mock.Anything).Return(ic.Response{}, nil).Once()
+						client.EXPECT().Do(mock.Anything).Return(ic.Response{ContentResponse: contentResponse}, nil).Once()
+
+						result, err := scanner.Scan(scanners.Input{})
+						assert.Nil(t, err)
+						assert.True(t, result.Infected)
+						assert.Equal(t, "bad threat", result.Description)
+					})
+				}
+
+				contentResponse, err := http.ReadResponse(bufio.NewReader(strings.NewReader(
+					"HTTP/1.1 200 OK\r\n\r\n3\r\n0\r\n\r\n0\r\nX-Virus-Scanned: yes\r\n\r\n",
+				)), nil)
+				require.NoError(t, err)
+
+				client.EXPECT().Do(mock.Anything).Return(ic.Response{}, nil).Once()
+				client.EXPECT().Do(mock.Anything).Return(ic.Response{ContentResponse: contentResponse}, nil).Once()
+
+				result, err := scanner.Scan(scanners.Input{})
+				assert.Nil(t, err)
+				assert.False(t, result.Infected)
+			})
+
+			// the chunks may carry extensions, for example the ieof extension of the last chunk
+			t.Run("chunk extensions", func(t *testing.T) {
+				for name, transferEncoding := range map[string]string{
+					"with chunked transfer encoding":    "Transfer-Encoding: chunked\r\n",
+					"without chunked transfer encoding": "",
+				} {
+					t.Run(name, func(t *testing.T) {
+						contentResponse, err := http.ReadResponse(bufio.NewReader(strings.NewReader(
+							"HTTP/1.1 403 Forbidden\r\n"+transferEncoding+"\r\n"+
+								"7;name=\"first;chunk\"\r\nblocked\r\n0; ieof\r\nX-Infection-Found: Type=0; Resolution=2; Threat=bad threat;\r\n\r\n",
+						)), nil)
+						require.NoError(t, err)
+
+						client.EXPECT().Do(mock.Anything).Return(ic.Response{}, nil).Once()
+						client.EXPECT().Do(mock.Anything).Return(ic.Response{ContentResponse: contentResponse}, nil).Once()
+
+						result, err := scanner.Scan(scanners.Input{})
+						assert.Nil(t, err)
+						assert.True(t, result.Infected)
+						assert.Equal(t, "bad threat", result.Description)
+					})
+				}
+			})
+
 			// skyhigh returns the information via the content response
 			t.Run("X-Infection-Found header", func(t *testing.T) {
 				client.EXPECT().Do(mock.Anything).Return(ic.Response{}, nil).Once()
@@ -185,3 +250,39 @@ func TestICAP_Scan(t *testing.T) {
 		})
 	})
 }
+
+func TestICAP_Scan_Trailers(t *testing.T) {
+	responses := []string{
+		"ICAP/1.0 200 OK\r\nMethods: REQMOD\r\nEncapsulated: null-body=0\r\n\r\n",
+		"ICAP/1.0 200 OK\r\nEncapsulated: res-hdr=0, res-body=45\r\n\r\n" +
+			"HTTP/1.1 403 Forbidden\r\nContent-Type: text/html\r\n\r\n" +
+			"7\r\nblocked\r\n0\r\nX-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;\r\n\r\n",
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, l.Close())
+	}()
+
+	go func() {
+		for _, response := range responses {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			_, _ = conn.Read(make([]byte, 4096))
+			_, _ = conn.Write([]byte(response))
+			_ = conn.Close()
+		}
+	}()
+
+	scanner, err := scanners.NewICAP("icap://"+l.Addr().String(), "avscan", 10*time.Second)
+	require.NoError(t, err)
+
+	result, err := scanner.Scan(scanners.Input{Body: strings.NewReader("X5O!P%@AP"), Size: 9, Url: "http://localhost/file", Name: "file.txt"})
+	require.NoError(t, err)
+	assert.True(t, result.Infected)
+	assert.Equal(t, "Eicar-Test-Signature", result.Description)
+}
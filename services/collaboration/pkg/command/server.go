@@ -14,11 +14,13 @@ import (
 	"github.com/opencloud-eu/opencloud/pkg/registry"
 	"github.com/opencloud-eu/opencloud/pkg/runner"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
+	"github.com/opencloud-eu/opencloud/pkg/version"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/connector"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/helpers"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/logging"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/metrics"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/server/debug"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/server/grpc"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/server/http"
@@ -49,6 +51,9 @@ func Server(cfg *config.Config) *cli.Command {
 			}
 			ctx := cfg.Context
 
+			m := metrics.New()
+			m.BuildInfo.WithLabelValues(version.GetString()).Set(1)
+
 			// prepare components
 			if err := helpers.RegisterOpenCloudService(ctx, cfg, logger); err != nil {
 				return err
@@ -72,14 +77,19 @@ func Server(cfg *config.Config) *cli.Command {
 			// use the AppURLs helper (an atomic pointer) to fetch and store the app URLs
 			// this is required as the app URLs are fetched periodically in the background
 			// and read when handling requests
-			appURLs := helpers.NewAppURLs()
+			mimeTypes, err := cfg.App.MimeTypeOverrides()
+			if err != nil {
+				return err
+			}
+			appURLs := helpers.NewAppURLs(mimeTypes)
+			discoveryClient := helpers.NewHTTPClient(cfg.HTTPClient, cfg.App.Insecure, cfg.App.DiscoveryTimeout)
 
 			ticker := time.NewTicker(cfg.CS3Api.APPRegistrationInterval)
 			defer ticker.Stop()
 			go func() {
 				for ; true; <-ticker.C {
 					// fetch and store the app URLs
-					v, err := helpers.GetAppURLs(cfg, logger)
+					v, err := helpers.GetAppURLs(discoveryClient, cfg, logger)
 					if err != nil {
 						logger.Warn().Err(err).Msg("Failed to get app URLs")
 						// empty map to clear previous URLs
@@ -130,6 +140,7 @@ func Server(cfg *config.Config) *cli.Command {
 				debug.Logger(logger),
 				debug.Context(ctx),
 				debug.Config(cfg),
+				debug.LockGatewayHealth(connector.NewLockGatewayCheck(gatewaySelector)),
 			)
 			if err != nil {
 				logger.Error().Err(err).Str("transport", "debug").Msg("Failed to initialize server")
@@ -139,7 +150,7 @@ func Server(cfg *config.Config) *cli.Command {
 
 			// start HTTP server
 			httpServer, err := http.Server(
-				http.Adapter(connector.NewHttpAdapter(gatewaySelector, cfg, st)),
+				http.Adapter(connector.NewHttpAdapter(gatewaySelector, cfg, st, m)),
 				http.Logger(logger),
 				http.Config(cfg),
 				http.Context(ctx),
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // App defines the available app configuration.
 type App struct {
 	Name        string `yaml:"name" env:"COLLABORATION_APP_NAME" desc:"The name of the app which is shown to the user. You can chose freely but you are limited to a single word without special characters or whitespaces. We recommend to use pascalCase like 'CollaboraOnline'." introductionVersion:"1.0.0"`
@@ -10,6 +16,13 @@ type App struct {
 	Addr     string `yaml:"addr" env:"COLLABORATION_APP_ADDR" desc:"The URL where the WOPI app is located, such as https://127.0.0.1:8080." introductionVersion:"1.0.0"`
 	Insecure bool   `yaml:"insecure" env:"COLLABORATION_APP_INSECURE" desc:"Skip TLS certificate verification when connecting to the WOPI app" introductionVersion:"1.0.0"`
 
+	DiscoveryTimeout    time.Duration `yaml:"discovery_timeout" env:"COLLABORATION_APP_DISCOVERY_TIMEOUT" desc:"The maximum time a request to the discovery endpoint of the WOPI app may take. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	DiscoveryRetries    int           `yaml:"discovery_retries" env:"COLLABORATION_APP_DISCOVERY_RETRIES" desc:"The number of times a failed request to the discovery endpoint of the WOPI app is retried before giving up." introductionVersion:"%%NEXT%%"`
+	DiscoveryRetryDelay time.Duration `yaml:"discovery_retry_delay" env:"COLLABORATION_APP_DISCOVERY_RETRY_DELAY" desc:"The time to wait before retrying a failed request to the discovery endpoint of the WOPI app. The delay is doubled after every retry. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	DiscoveryCacheFile  string        `yaml:"discovery_cache_file" env:"COLLABORATION_APP_DISCOVERY_CACHE_FILE" desc:"Path of a file the app URLs of the last successful discovery of the WOPI app are stored in. If the discovery fails, for example on startup while the WOPI app is unavailable, the stored app URLs are used and the discovery is retried in the background. The cache is disabled if empty." introductionVersion:"%%NEXT%%"`
+
+	MimeTypes []string `yaml:"mime_types" env:"COLLABORATION_APP_MIME_TYPES" desc:"A list of file extensions and the mime types they map to, in the format '.ext=mime/type'. The mappings take precedence over the built-in mime type detection and allow to advertise custom file formats of the WOPI app. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+
 	ProofKeys          ProofKeys `yaml:"proofkeys"`
 	LicenseCheckEnable bool      `yaml:"licensecheckenable" env:"COLLABORATION_APP_LICENSE_CHECK_ENABLE" desc:"Enable license checking to edit files. Needs to be enabled when using Microsoft365 with the business flow." introductionVersion:"1.0.0"`
 }
@@ -18,3 +31,22 @@ type ProofKeys struct {
 	Disable  bool   `yaml:"disable" env:"COLLABORATION_APP_PROOF_DISABLE" desc:"Disable the proof keys verification" introductionVersion:"1.0.0"`
 	Duration string `yaml:"duration" env:"COLLABORATION_APP_PROOF_DURATION" desc:"Duration for the proof keys to be cached in memory, using time.ParseDuration format. If the duration can't be parsed, we'll use the default 12h as duration" introductionVersion:"1.0.0"`
 }
+
+// MimeTypeOverrides returns the configured mime types by their lowercase file extension
+func (a App) MimeTypeOverrides() (map[string]string, error) {
+	mimeTypes := make(map[string]string, len(a.MimeTypes))
+	for _, entry := range a.MimeTypes {
+		ext, mimeType, ok := strings.Cut(entry, "=")
+		ext, mimeType = strings.TrimSpace(ext), strings.TrimSpace(mimeType)
+		if !ok || ext == "" || mimeType == "" {
+			return nil, fmt.Errorf("invalid mime type mapping '%s', expected the format '.ext=mime/type'", entry)
+		}
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		mimeTypes[strings.ToLower(ext)] = mimeType
+	}
+
+	return mimeTypes, nil
+}
@@ -20,6 +20,8 @@ type Config struct {
 	GRPC GRPC `yaml:"grpc"`
 	HTTP HTTP `yaml:"http"`
 
+	HTTPClient HTTPClient `yaml:"http_client"`
+
 	Wopi   Wopi   `yaml:"wopi"`
 	CS3Api CS3Api `yaml:"cs3api"`
 
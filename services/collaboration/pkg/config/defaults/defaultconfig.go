@@ -28,6 +28,10 @@ func DefaultConfig() *config.Config {
 			Icon:        "image-edit",
 			Addr:        "https://127.0.0.1:9980",
 			Insecure:    false,
+			// the discovery should not block the startup for more than a few minutes
+			DiscoveryTimeout:    30 * time.Second,
+			DiscoveryRetries:    3,
+			DiscoveryRetryDelay: time.Second,
 			ProofKeys: config.ProofKeys{
 				// they'll be enabled by default
 				Duration: "12h",
@@ -49,6 +53,11 @@ func DefaultConfig() *config.Config {
 			Addr:      "127.0.0.1:9300",
 			Namespace: "eu.opencloud.web",
 		},
+		HTTPClient: config.HTTPClient{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
 		Debug: config.Debug{
 			Addr:   "127.0.0.1:9304",
 			Token:  "",
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/opencloud-eu/opencloud/pkg/shared"
 )
 
@@ -10,3 +12,10 @@ type HTTP struct {
 	Namespace string                `yaml:"-"`
 	TLS       shared.HTTPServiceTLS `yaml:"tls"`
 }
+
+// HTTPClient defines the connection reuse of the http clients connecting to the WOPI app and the data gateway.
+type HTTPClient struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns" env:"COLLABORATION_HTTP_CLIENT_MAX_IDLE_CONNS" desc:"The maximum number of idle connections kept open across all hosts. Zero means no limit." introductionVersion:"%%NEXT%%"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" env:"COLLABORATION_HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST" desc:"The maximum number of idle connections kept open per host." introductionVersion:"%%NEXT%%"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" env:"COLLABORATION_HTTP_CLIENT_IDLE_CONN_TIMEOUT" desc:"The time an idle connection is kept open before it is closed. Zero means no limit. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
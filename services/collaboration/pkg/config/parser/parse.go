@@ -58,6 +58,9 @@ func Validate(cfg *config.Config) error {
 			"the config/corresponding environment variable)",
 			cfg.Service.Name, ocdefaults.BaseConfigPath())
 	}
+	if _, err := cfg.App.MimeTypeOverrides(); err != nil {
+		return fmt.Errorf("The app mime types are invalid in your config for %s: %w", cfg.Service.Name, err)
+	}
 
 	return nil
 }
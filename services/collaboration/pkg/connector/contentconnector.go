@@ -3,7 +3,6 @@ package connector
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,6 +16,7 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/helpers"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/middleware"
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
@@ -44,15 +44,19 @@ type ContentConnectorService interface {
 // uploads (PutFile)
 // Note that operations might return any kind of error, not just ConnectorError
 type ContentConnector struct {
-	gws pool.Selectable[gatewayv1beta1.GatewayAPIClient]
-	cfg *config.Config
+	gws        pool.Selectable[gatewayv1beta1.GatewayAPIClient]
+	cfg        *config.Config
+	httpClient *http.Client
 }
 
-// NewContentConnector creates a new content connector
+// NewContentConnector creates a new content connector. The downloads and
+// uploads share a single http client, so the connections to the data gateway
+// are reused.
 func NewContentConnector(gws pool.Selectable[gatewayv1beta1.GatewayAPIClient], cfg *config.Config) *ContentConnector {
 	return &ContentConnector{
-		gws: gws,
-		cfg: cfg,
+		gws:        gws,
+		cfg:        cfg,
+		httpClient: helpers.NewHTTPClient(cfg.HTTPClient, cfg.CS3Api.DataGateway.Insecure, 0),
 	}
 }
 
@@ -145,15 +149,6 @@ func (c *ContentConnector) GetFile(ctx context.Context, w http.ResponseWriter) e
 		Str("Endpoint", downloadEndpoint).
 		Bool("HasDownloadToken", hasDownloadToken).Logger()
 
-	httpClient := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				MinVersion:         tls.VersionTLS12,
-				InsecureSkipVerify: c.cfg.CS3Api.DataGateway.Insecure,
-			},
-		},
-	}
-
 	// Prepare the request to download the file
 	// public link downloads have the token in the download endpoint
 	httpReq, err := newHttpRequest(ctx, wopiContext, http.MethodGet, downloadEndpoint, downloadToken, bytes.NewReader([]byte("")))
@@ -162,7 +157,7 @@ func (c *ContentConnector) GetFile(ctx context.Context, w http.ResponseWriter) e
 		return err
 	}
 
-	httpResp, err := httpClient.Do(httpReq)
+	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		logger.Error().Err(err).Msg("GetFile: Get request to the download endpoint failed")
 		return err
@@ -310,15 +305,9 @@ func (c *ContentConnector) PutFile(ctx context.Context, stream io.Reader, stream
 			Str("Endpoint", uploadEndpoint).
 			Bool("HasUploadToken", hasUploadToken).Logger()
 
-		httpClient := http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					MinVersion:         tls.VersionTLS12,
-					InsecureSkipVerify: c.cfg.CS3Api.DataGateway.Insecure,
-				},
-			},
-			Timeout: 10 * time.Second,
-		}
+		// the upload shares the transport, only the timeout differs
+		httpClient := *c.httpClient
+		httpClient.Timeout = 10 * time.Second
 
 		// prepare the request to upload the contents to the upload endpoint
 		// public link uploads have the token in the upload endpoint
@@ -10,6 +10,7 @@ import (
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/connector/utf7"
 	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/locks"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/metrics"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/rs/zerolog"
 	microstore "go-micro.dev/v4/store"
@@ -44,11 +45,12 @@ type HttpAdapter struct {
 }
 
 // NewHttpAdapter will create a new HTTP adapter. A new connector using the
-// provided gateway API client and configuration will be used in the adapter
-func NewHttpAdapter(gws pool.Selectable[gatewayv1beta1.GatewayAPIClient], cfg *config.Config, st microstore.Store) *HttpAdapter {
+// provided gateway API client and configuration will be used in the adapter,
+// its lock operations are recorded in the provided metrics
+func NewHttpAdapter(gws pool.Selectable[gatewayv1beta1.GatewayAPIClient], cfg *config.Config, st microstore.Store, m *metrics.Metrics) *HttpAdapter {
 	httpAdapter := &HttpAdapter{
 		con: NewConnector(
-			NewFileConnector(gws, cfg, st),
+			NewLockMetricsFileConnector(NewFileConnector(gws, cfg, st), m),
 			NewContentConnector(gws, cfg),
 		),
 	}
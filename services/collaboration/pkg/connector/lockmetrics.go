@@ -0,0 +1,142 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gatewayv1beta1 "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/middleware"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+)
+
+// the operations and outcomes of the lock metrics
+const (
+	lockOperationAcquire = "acquire"
+	lockOperationRefresh = "refresh"
+	lockOperationRelease = "release"
+
+	lockOutcomeSuccess  = "success"
+	lockOutcomeConflict = "conflict"
+	lockOutcomeFailure  = "failure"
+)
+
+// LockMetricsFileConnector records the lock operations of the wrapped
+// FileConnectorService in the metrics and estimates the locks this instance
+// holds until they are released or expire, all other operations are passed
+// through unchanged.
+type LockMetricsFileConnector struct {
+	FileConnectorService
+	metrics *metrics.Metrics
+}
+
+// NewLockMetricsFileConnector wraps the file connector to record its lock
+// operations in the given metrics
+func NewLockMetricsFileConnector(fc FileConnectorService, m *metrics.Metrics) *LockMetricsFileConnector {
+	return &LockMetricsFileConnector{
+		FileConnectorService: fc,
+		metrics:              m,
+	}
+}
+
+// Lock locks the file and records the operation. A Lock with an oldLockID
+// (UnlockAndRelock) replaces an existing lock, so it is recorded as refresh.
+// A Lock with the id of the current lock refreshes it as well, but it can't be
+// told apart from acquiring the lock, it is recorded as acquire.
+func (l *LockMetricsFileConnector) Lock(ctx context.Context, lockID, oldLockID string) (*ConnectorResponse, error) {
+	response, err := l.FileConnectorService.Lock(ctx, lockID, oldLockID)
+
+	operation := lockOperationAcquire
+	if oldLockID != "" {
+		operation = lockOperationRefresh
+	}
+	if l.record(operation, response, err) == lockOutcomeSuccess {
+		l.hold(ctx)
+	}
+
+	return response, err
+}
+
+// RefreshLock refreshes the lock of the file and records the operation
+func (l *LockMetricsFileConnector) RefreshLock(ctx context.Context, lockID string) (*ConnectorResponse, error) {
+	response, err := l.FileConnectorService.RefreshLock(ctx, lockID)
+	if l.record(lockOperationRefresh, response, err) == lockOutcomeSuccess {
+		l.hold(ctx)
+	}
+
+	return response, err
+}
+
+// UnLock unlocks the file and records the operation
+func (l *LockMetricsFileConnector) UnLock(ctx context.Context, lockID string) (*ConnectorResponse, error) {
+	response, err := l.FileConnectorService.UnLock(ctx, lockID)
+	if l.record(lockOperationRelease, response, err) == lockOutcomeSuccess {
+		if file, ok := lockedFile(ctx); ok {
+			l.metrics.ReleaseLock(file)
+		}
+	}
+
+	return response, err
+}
+
+// hold tracks the lock of the file until it expires, a file has a single lock,
+// so acquiring or refreshing it again only moves the expiry
+func (l *LockMetricsFileConnector) hold(ctx context.Context) {
+	if file, ok := lockedFile(ctx); ok {
+		l.metrics.HoldLock(file, time.Now().Add(lockDuration))
+	}
+}
+
+// lockedFile returns the id of the file the request locks
+func lockedFile(ctx context.Context) (string, bool) {
+	wopiContext, err := middleware.WopiContextFromCtx(ctx)
+	if err != nil {
+		return "", false
+	}
+
+	file, err := storagespace.FormatReference(wopiContext.FileReference)
+	if err != nil {
+		return "", false
+	}
+
+	return file, true
+}
+
+// record counts the operation with the outcome of its response and returns the outcome
+func (l *LockMetricsFileConnector) record(operation string, response *ConnectorResponse, err error) string {
+	outcome := lockOutcomeFailure
+	switch {
+	case err != nil || response == nil:
+	case response.Status == 200:
+		outcome = lockOutcomeSuccess
+	case response.Status == 409:
+		outcome = lockOutcomeConflict
+		l.metrics.LockConflicts.WithLabelValues(operation).Inc()
+	}
+
+	l.metrics.LockOperations.WithLabelValues(operation, outcome).Inc()
+	return outcome
+}
+
+// NewLockGatewayCheck checks that the gateway, which forwards the WOPI lock
+// requests to the storage providers, answers lock requests. The lock of an
+// empty reference is requested, only transport errors are reported, the status
+// of the response is expected to be an error. The storage providers which keep
+// the locks are not checked.
+func NewLockGatewayCheck(gws pool.Selectable[gatewayv1beta1.GatewayAPIClient]) func(context.Context) error {
+	return func(ctx context.Context) error {
+		gwc, err := gws.Next()
+		if err != nil {
+			return fmt.Errorf("could not select the gateway: %w", err)
+		}
+
+		if _, err := gwc.GetLock(ctx, &providerv1beta1.GetLockRequest{Ref: &providerv1beta1.Reference{}}); err != nil {
+			return fmt.Errorf("the gateway does not answer lock requests: %w", err)
+		}
+
+		return nil
+	}
+}
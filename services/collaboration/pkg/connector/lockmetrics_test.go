@@ -0,0 +1,148 @@
+package connector_test
+
+import (
+	"context"
+	"errors"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	collabmocks "github.com/opencloud-eu/opencloud/services/collaboration/mocks"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/connector"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/middleware"
+	"github.com/opencloud-eu/opencloud/services/graph/mocks"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("LockMetricsFileConnector", func() {
+	var (
+		fcs *collabmocks.FileConnectorService
+		lfc *connector.LockMetricsFileConnector
+		m   *metrics.Metrics
+		ctx context.Context
+
+		operations = func(operation, outcome string) float64 {
+			metric := &dto.Metric{}
+			Expect(m.LockOperations.WithLabelValues(operation, outcome).Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue()
+		}
+		conflicts = func(operation string) float64 {
+			metric := &dto.Metric{}
+			Expect(m.LockConflicts.WithLabelValues(operation).Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue()
+		}
+		locksEstimate = func() float64 {
+			metric := &dto.Metric{}
+			Expect(m.InstanceLocksEstimate.Write(metric)).To(Succeed())
+			return metric.GetGauge().GetValue()
+		}
+	)
+
+	BeforeEach(func() {
+		fcs = collabmocks.NewFileConnectorService(GinkgoT())
+		m = metrics.New()
+		lfc = connector.NewLockMetricsFileConnector(fcs, m)
+		ctx = middleware.WopiContextToCtx(context.Background(), middleware.WopiContext{
+			FileReference: &providerv1beta1.Reference{
+				ResourceId: &providerv1beta1.ResourceId{
+					StorageId: "storageid",
+					SpaceId:   "spaceid",
+					OpaqueId:  "opaqueid",
+				},
+				Path: ".",
+			},
+		})
+	})
+
+	It("counts acquired and released locks", func() {
+		acquired, released, active := operations("acquire", "success"), operations("release", "success"), locksEstimate()
+
+		fcs.EXPECT().Lock(mock.Anything, "abc", "").Return(connector.NewResponse(200), nil)
+		fcs.EXPECT().UnLock(mock.Anything, "abc").Return(connector.NewResponse(200), nil)
+
+		_, err := lfc.Lock(ctx, "abc", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(operations("acquire", "success")).To(Equal(acquired + 1))
+		Expect(locksEstimate()).To(Equal(active + 1))
+
+		_, err = lfc.UnLock(ctx, "abc")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(operations("release", "success")).To(Equal(released + 1))
+		Expect(locksEstimate()).To(Equal(active))
+	})
+
+	It("counts refreshed locks", func() {
+		refreshed, active := operations("refresh", "success"), locksEstimate()
+
+		fcs.EXPECT().Lock(mock.Anything, "abc", "").Return(connector.NewResponse(200), nil).Twice()
+		fcs.EXPECT().RefreshLock(mock.Anything, "abc").Return(connector.NewResponse(200), nil)
+		fcs.EXPECT().Lock(mock.Anything, "def", "abc").Return(connector.NewResponse(200), nil)
+		fcs.EXPECT().UnLock(mock.Anything, "def").Return(connector.NewResponse(200), nil)
+
+		_, err := lfc.Lock(ctx, "abc", "")
+		Expect(err).ToNot(HaveOccurred())
+		// locking the file with the same id refreshes the lock
+		_, err = lfc.Lock(ctx, "abc", "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = lfc.RefreshLock(ctx, "abc")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = lfc.Lock(ctx, "def", "abc")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(operations("refresh", "success")).To(Equal(refreshed + 2))
+		Expect(locksEstimate()).To(Equal(active + 1))
+
+		_, err = lfc.UnLock(ctx, "def")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locksEstimate()).To(Equal(active))
+	})
+
+	It("counts lock conflicts and failures", func() {
+		conflicted, conflictedTotal, failed, active := operations("acquire", "conflict"), conflicts("acquire"), operations("release", "failure"), locksEstimate()
+
+		fcs.EXPECT().Lock(mock.Anything, "abc", "").Return(connector.NewResponseLockConflict("def", "Conflicting LockID"), nil)
+		fcs.EXPECT().UnLock(mock.Anything, "abc").Return(nil, errors.New("unavailable"))
+
+		_, err := lfc.Lock(ctx, "abc", "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = lfc.UnLock(ctx, "abc")
+		Expect(err).To(HaveOccurred())
+
+		Expect(operations("acquire", "conflict")).To(Equal(conflicted + 1))
+		Expect(conflicts("acquire")).To(Equal(conflictedTotal + 1))
+		Expect(operations("release", "failure")).To(Equal(failed + 1))
+		Expect(locksEstimate()).To(Equal(active))
+	})
+})
+
+var _ = Describe("LockGatewayCheck", func() {
+	var (
+		gatewayClient   *cs3mocks.GatewayAPIClient
+		gatewaySelector *mocks.Selectable[gateway.GatewayAPIClient]
+	)
+
+	BeforeEach(func() {
+		gatewayClient = cs3mocks.NewGatewayAPIClient(GinkgoT())
+		gatewaySelector = mocks.NewSelectable[gateway.GatewayAPIClient](GinkgoT())
+		gatewaySelector.On("Next").Return(gatewayClient, nil)
+	})
+
+	It("succeeds if the gateway answers the lock request", func() {
+		gatewayClient.On("GetLock", mock.Anything, mock.Anything).Return(&providerv1beta1.GetLockResponse{
+			Status: status.NewInvalid(context.Background(), "invalid reference"),
+		}, nil)
+
+		Expect(connector.NewLockGatewayCheck(gatewaySelector)(context.Background())).To(Succeed())
+	})
+
+	It("fails if the gateway can't be reached", func() {
+		gatewayClient.On("GetLock", mock.Anything, mock.Anything).Return(nil, errors.New("unavailable"))
+
+		Expect(connector.NewLockGatewayCheck(gatewaySelector)(context.Background())).ToNot(Succeed())
+	})
+})
@@ -1,12 +1,17 @@
 package helpers
 
 import (
-	"crypto/tls"
+	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/beevik/etree"
 	"github.com/opencloud-eu/opencloud/pkg/log"
@@ -18,11 +23,15 @@ import (
 // AppURLs holds the app urls fetched from the WOPI app discovery endpoint
 // It is a type safe wrapper around an atomic pointer to a map
 type AppURLs struct {
-	urls atomic.Pointer[map[string]map[string]string]
+	urls      atomic.Pointer[map[string]map[string]string]
+	mimeTypes map[string]string
 }
 
-func NewAppURLs() *AppURLs {
-	a := &AppURLs{}
+// NewAppURLs creates a new AppURLs instance. The mime types map lowercase file
+// extensions to the mime type advertised for them, they take precedence over
+// the detected mime types.
+func NewAppURLs(mimeTypes map[string]string) *AppURLs {
+	a := &AppURLs{mimeTypes: mimeTypes}
 	a.urls.Store(&map[string]map[string]string{})
 	return a
 }
@@ -40,7 +49,7 @@ func (a *AppURLs) GetMimeTypes() []string {
 	mimeTypesMap := make(map[string]bool)
 	for _, extensions := range *currentURLs {
 		for ext := range extensions {
-			m := mime.Detect(false, ext)
+			m := a.mimeTypeOf(ext)
 			// skip the default
 			if m == "application/octet-stream" {
 				continue
@@ -75,39 +84,176 @@ func (a *AppURLs) GetAppURLFor(action, fileExt string) string {
 	return ""
 }
 
+// GetAppURLForMime gets the appURL from the list of appURLs based on the
+// action and mime type provided. The mime type is mapped to the file
+// extensions of the list, if several extensions share the mime type the url
+// of the first one in alphabetical order is returned. If there is no match,
+// an empty string will be returned.
+func (a *AppURLs) GetAppURLForMime(action, mimeType string) string {
+	for _, ext := range a.SupportedExtensions(action) {
+		if strings.EqualFold(a.mimeTypeOf(ext), mimeType) {
+			return a.GetAppURLFor(action, ext)
+		}
+	}
+	return ""
+}
+
+// mimeTypeOf returns the configured or detected mime type of the file extension
+func (a *AppURLs) mimeTypeOf(fileExt string) string {
+	if m, ok := a.mimeTypes[strings.ToLower(fileExt)]; ok {
+		return m
+	}
+	return mime.Detect(false, fileExt)
+}
+
+// CanEdit reports whether the WOPI app provides an edit url for the file extension
+func (a *AppURLs) CanEdit(fileExt string) bool {
+	return a.GetAppURLFor("edit", fileExt) != ""
+}
+
+// CanView reports whether the WOPI app provides a view url for the file extension
+func (a *AppURLs) CanView(fileExt string) bool {
+	return a.GetAppURLFor("view", fileExt) != ""
+}
+
+// SupportedExtensions returns the sorted file extensions the WOPI app
+// provides an url for the given action
+func (a *AppURLs) SupportedExtensions(action string) []string {
+	currentURLs := a.urls.Load()
+	if currentURLs == nil {
+		return []string{}
+	}
+
+	extensions := make([]string, 0, len((*currentURLs)[action]))
+	for ext := range (*currentURLs)[action] {
+		extensions = append(extensions, ext)
+	}
+	slices.Sort(extensions)
+
+	return extensions
+}
+
 // GetAppURLs gets the edit and view urls for different file types from the
 // target WOPI app (onlyoffice, collabora, etc) via their "/hosting/discovery"
-// endpoint.
-func GetAppURLs(cfg *config.Config, logger log.Logger) (map[string]map[string]string, error) {
-	wopiAppUrl := cfg.App.Addr + "/hosting/discovery"
+// endpoint. Failed requests are retried with an increasing delay up to the
+// configured number of retries. The http client is expected to be shared
+// between the calls, so the connection to the WOPI app is reused.
+//
+// If a discovery cache file is configured, the urls of a successful discovery
+// are stored in it, and the stored urls are returned if the discovery fails.
+// This keeps the service working with the last known urls while the WOPI app
+// is unavailable, the caller is expected to retry the discovery later on.
+func GetAppURLs(httpClient *http.Client, cfg *config.Config, logger log.Logger) (map[string]map[string]string, error) {
+	cacheFile := cfg.App.DiscoveryCacheFile
+
+	appURLs, err := fetchAppURLs(httpClient, cfg, logger)
+	if err != nil {
+		if cacheFile == "" {
+			return nil, err
+		}
+
+		cachedAppURLs, cacheErr := readDiscoveryCache(cacheFile)
+		if cacheErr != nil {
+			logger.Warn().
+				Err(cacheErr).
+				Str("CacheFile", cacheFile).
+				Msg("WopiDiscovery: failed to read the discovery cache")
+			return nil, err
+		}
 
-	httpClient := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				MinVersion:         tls.VersionTLS12,
-				InsecureSkipVerify: cfg.App.Insecure,
-			},
-		},
+		logger.Warn().
+			Err(err).
+			Str("CacheFile", cacheFile).
+			Msg("WopiDiscovery: discovery failed, using the cached app urls")
+		return cachedAppURLs, nil
 	}
 
-	httpResp, err := httpClient.Get(wopiAppUrl)
+	if cacheFile != "" {
+		if err := writeDiscoveryCache(cacheFile, appURLs); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("CacheFile", cacheFile).
+				Msg("WopiDiscovery: failed to write the discovery cache")
+		}
+	}
+
+	return appURLs, nil
+}
+
+// readDiscoveryCache reads the app urls stored by writeDiscoveryCache
+func readDiscoveryCache(cacheFile string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(cacheFile)
 	if err != nil {
 		return nil, err
 	}
 
-	defer httpResp.Body.Close()
+	var appURLs map[string]map[string]string
+	if err := json.Unmarshal(data, &appURLs); err != nil {
+		return nil, errors.Wrap(err, "error parsing the discovery cache")
+	}
 
-	if httpResp.StatusCode != http.StatusOK {
-		logger.Error().
+	return appURLs, nil
+}
+
+// writeDiscoveryCache stores the app urls in the cache file. The file is
+// replaced atomically, so a concurrent or interrupted write never leaves a
+// broken cache behind.
+func writeDiscoveryCache(cacheFile string, appURLs map[string]map[string]string) error {
+	data, err := json.Marshal(appURLs)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFile), filepath.Base(cacheFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), cacheFile)
+}
+
+// fetchAppURLs gets the app urls from the discovery endpoint of the WOPI app
+func fetchAppURLs(httpClient *http.Client, cfg *config.Config, logger log.Logger) (map[string]map[string]string, error) {
+	wopiAppUrl := cfg.App.Addr + "/hosting/discovery"
+
+	var body []byte
+	var err error
+	delay := cfg.App.DiscoveryRetryDelay
+	for attempt := 1; ; attempt++ {
+		body, err = fetchWopiDiscovery(httpClient, wopiAppUrl, logger)
+		if err == nil {
+			break
+		}
+		if attempt > cfg.App.DiscoveryRetries {
+			return nil, errors.Wrapf(err, "wopi discovery failed after %d attempts", attempt)
+		}
+
+		logger.Warn().
+			Err(err).
 			Str("WopiAppUrl", wopiAppUrl).
-			Int("HttpCode", httpResp.StatusCode).
-			Msg("WopiDiscovery: wopi app url failed with unexpected code")
-		return nil, errors.New("status code was not 200")
+			Int("Attempt", attempt).
+			Dur("RetryIn", delay).
+			Msg("WopiDiscovery: request failed, retrying")
+		time.Sleep(delay)
+		delay *= 2
 	}
 
 	var appURLs map[string]map[string]string
 
-	appURLs, err = parseWopiDiscovery(httpResp.Body)
+	appURLs, err = parseWopiDiscovery(bytes.NewReader(body))
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -120,6 +266,26 @@ func GetAppURLs(cfg *config.Config, logger log.Logger) (map[string]map[string]st
 	return appURLs, nil
 }
 
+// fetchWopiDiscovery reads the response of the "/hosting/discovery" endpoint
+func fetchWopiDiscovery(httpClient *http.Client, wopiAppUrl string, logger log.Logger) ([]byte, error) {
+	httpResp, err := httpClient.Get(wopiAppUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		logger.Error().
+			Str("WopiAppUrl", wopiAppUrl).
+			Int("HttpCode", httpResp.StatusCode).
+			Msg("WopiDiscovery: wopi app url failed with unexpected code")
+		return nil, errors.New("status code was not 200")
+	}
+
+	return io.ReadAll(httpResp.Body)
+}
+
 // parseWopiDiscovery parses the response of the "/hosting/discovery" endpoint
 func parseWopiDiscovery(body io.Reader) (map[string]map[string]string, error) {
 	appURLs := make(map[string]map[string]string)
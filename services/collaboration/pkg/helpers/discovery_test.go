@@ -3,6 +3,11 @@ package helpers_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -16,7 +21,7 @@ var _ = Describe("AppURLs", func() {
 	var appURLs *helpers.AppURLs
 
 	BeforeEach(func() {
-		appURLs = helpers.NewAppURLs()
+		appURLs = helpers.NewAppURLs(nil)
 	})
 
 	Describe("NewAppURLs", func() {
@@ -187,6 +192,109 @@ var _ = Describe("AppURLs", func() {
 			mimeTypes := appURLs.GetMimeTypes()
 			Expect(mimeTypes).To(BeEmpty())
 		})
+
+		It("should prefer the configured mime types over the detected ones", func() {
+			appURLs = helpers.NewAppURLs(map[string]string{
+				".fake-ext": "application/x-fake",
+				".pdf":      "application/x-custom-pdf",
+			})
+
+			testURLs := map[string]map[string]string{
+				"view": {
+					".FAKE-EXT": "https://example.com/view/fake",
+					".pdf":      "https://example.com/view/pdf",
+					".docx":     "https://example.com/view/docx",
+				},
+			}
+
+			appURLs.Store(testURLs)
+
+			mimeTypes := appURLs.GetMimeTypes()
+			Expect(mimeTypes).To(ConsistOf(
+				"application/x-fake",
+				"application/x-custom-pdf",
+				"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			))
+		})
+	})
+
+	Describe("GetAppURLForMime", func() {
+		BeforeEach(func() {
+			appURLs.Store(map[string]map[string]string{
+				"view": {
+					".pdf":  "https://example.com/view/pdf",
+					".jpeg": "https://example.com/view/jpeg",
+					".jpg":  "https://example.com/view/jpg",
+				},
+				"edit": {
+					".jpeg": "https://example.com/edit/jpeg",
+				},
+			})
+		})
+
+		It("should return the url of the extension of the mime type", func() {
+			Expect(appURLs.GetAppURLForMime("view", "application/pdf")).To(Equal("https://example.com/view/pdf"))
+			Expect(appURLs.GetAppURLForMime("edit", "application/pdf")).To(BeEmpty())
+		})
+
+		It("should return the url of the first extension which has an url for the action", func() {
+			Expect(appURLs.GetAppURLForMime("view", "image/jpeg")).To(Equal("https://example.com/view/jpeg"))
+			Expect(appURLs.GetAppURLForMime("edit", "image/jpeg")).To(Equal("https://example.com/edit/jpeg"))
+		})
+
+		It("should return an empty string for unknown mime types", func() {
+			Expect(appURLs.GetAppURLForMime("view", "application/x-unknown")).To(BeEmpty())
+		})
+
+		It("should use the configured mime types", func() {
+			appURLs = helpers.NewAppURLs(map[string]string{".fake-ext": "application/x-fake"})
+			appURLs.Store(map[string]map[string]string{
+				"edit": {".fake-ext": "https://example.com/edit/fake"},
+			})
+
+			Expect(appURLs.GetAppURLForMime("edit", "application/x-fake")).To(Equal("https://example.com/edit/fake"))
+		})
+	})
+
+	Describe("CanEdit, CanView and SupportedExtensions", func() {
+		BeforeEach(func() {
+			appURLs.Store(map[string]map[string]string{
+				"view": {
+					".pdf":  "https://example.com/view/pdf",
+					".docx": "https://example.com/view/docx",
+				},
+				"edit": {
+					".docx": "https://example.com/edit/docx",
+					".md":   "https://example.com/edit/md",
+				},
+			})
+		})
+
+		It("should report edit-only extensions", func() {
+			Expect(appURLs.CanEdit(".md")).To(BeTrue())
+			Expect(appURLs.CanView(".md")).To(BeFalse())
+		})
+
+		It("should report view-only extensions", func() {
+			Expect(appURLs.CanEdit(".pdf")).To(BeFalse())
+			Expect(appURLs.CanView(".pdf")).To(BeTrue())
+		})
+
+		It("should report extensions which can be edited and viewed", func() {
+			Expect(appURLs.CanEdit(".docx")).To(BeTrue())
+			Expect(appURLs.CanView(".docx")).To(BeTrue())
+		})
+
+		It("should report unknown extensions as unsupported", func() {
+			Expect(appURLs.CanEdit(".exe")).To(BeFalse())
+			Expect(appURLs.CanView(".exe")).To(BeFalse())
+		})
+
+		It("should list the sorted extensions of an action", func() {
+			Expect(appURLs.SupportedExtensions("view")).To(Equal([]string{".docx", ".pdf"}))
+			Expect(appURLs.SupportedExtensions("edit")).To(Equal([]string{".docx", ".md"}))
+			Expect(appURLs.SupportedExtensions("nonexistent")).To(BeEmpty())
+		})
 	})
 
 	Describe("Concurrent Access", func() {
@@ -275,6 +383,12 @@ var _ = Describe("Discovery", func() {
 	var (
 		discoveryContent1 string
 		srv               *httptest.Server
+		requests          atomic.Int32
+		remoteAddrs       *sync.Map
+
+		discoveryClient = func(cfg *config.Config) *http.Client {
+			return helpers.NewHTTPClient(cfg.HTTPClient, cfg.App.Insecure, cfg.App.DiscoveryTimeout)
+		}
 	)
 
 	BeforeEach(func() {
@@ -307,7 +421,11 @@ var _ = Describe("Discovery", func() {
   <proof-key oldvalue="BgIAAACkAABSU0ExAAgAAAEAAQD/NVqekFNi8X3p6Bvdlaxm0GGuggW5kKfVEQzPGuOkGVrz6DrOMNR+k7Pq8tONY+1NHgS6Z+v3959em78qclVDuQX77Tkml0xMHAQHN4sAHF9iQJS8gOBUKSVKaHD7Z8YXch6F212YSUSc8QphpDSHWVShU7rcUeLQsd/0pkflh5+um4YKEZhm4Mou3vstp5p12NeffyK1WFZF7q4jB7jclAslYKQsP82YY3DcRwu5Tl/+W0ifVcXze0mI7v1reJ12pKn8ifRiq+0q5oJST3TRSrvmjLg9Gt3ozhVIt2HUi3La7Qh40YOAUXm0g/hUq2BepeOp1C7WSvaOFHXe6Hqq" oldmodulus="qnro3nUUjvZK1i7UqeOlXmCrVPiDtHlRgIPReAjt2nKL1GG3SBXO6N0aPbiM5rtK0XRPUoLmKu2rYvSJ/Kmkdp14a/3uiEl788VVn0hb/l9OuQtH3HBjmM0/LKRgJQuU3LgHI67uRVZYtSJ/n9fYdZqnLfveLsrgZpgRCoabrp+H5Uem9N+x0OJR3LpToVRZhzSkYQrxnERJmF3bhR5yF8Zn+3BoSiUpVOCAvJRAYl8cAIs3BwQcTEyXJjnt+wW5Q1VyKr+bXp/39+tnugQeTe1jjdPy6rOTftQwzjro81oZpOMazwwR1aeQuQWCrmHQZqyV3Rvo6X3xYlOQnlo1/w==" oldexponent="AQAB" value="BgIAAACkAABSU0ExAAgAAAEAAQD/NVqekFNi8X3p6Bvdlaxm0GGuggW5kKfVEQzPGuOkGVrz6DrOMNR+k7Pq8tONY+1NHgS6Z+v3959em78qclVDuQX77Tkml0xMHAQHN4sAHF9iQJS8gOBUKSVKaHD7Z8YXch6F212YSUSc8QphpDSHWVShU7rcUeLQsd/0pkflh5+um4YKEZhm4Mou3vstp5p12NeffyK1WFZF7q4jB7jclAslYKQsP82YY3DcRwu5Tl/+W0ifVcXze0mI7v1reJ12pKn8ifRiq+0q5oJST3TRSrvmjLg9Gt3ozhVIt2HUi3La7Qh40YOAUXm0g/hUq2BepeOp1C7WSvaOFHXe6Hqq" modulus="qnro3nUUjvZK1i7UqeOlXmCrVPiDtHlRgIPReAjt2nKL1GG3SBXO6N0aPbiM5rtK0XRPUoLmKu2rYvSJ/Kmkdp14a/3uiEl788VVn0hb/l9OuQtH3HBjmM0/LKRgJQuU3LgHI67uRVZYtSJ/n9fYdZqnLfveLsrgZpgRCoabrp+H5Uem9N+x0OJR3LpToVRZhzSkYQrxnERJmF3bhR5yF8Zn+3BoSiUpVOCAvJRAYl8cAIs3BwQcTEyXJjnt+wW5Q1VyKr+bXp/39+tnugQeTe1jjdPy6rOTftQwzjro81oZpOMazwwR1aeQuQWCrmHQZqyV3Rvo6X3xYlOQnlo1/w==" exponent="AQAB"/>
 </wopi-discovery>
 `
+		requests.Store(0)
+		remoteAddrs = &sync.Map{}
 		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			n := requests.Add(1)
+			remoteAddrs.Store(req.RemoteAddr, true)
 			switch req.URL.Path {
 			case "/bad/hosting/discovery":
 				w.WriteHeader(500)
@@ -315,6 +433,15 @@ var _ = Describe("Discovery", func() {
 				w.Write([]byte(discoveryContent1))
 			case "/wrongformat/hosting/discovery":
 				w.Write([]byte("Text that <can't> be XML /form<atted/"))
+			case "/flaky/hosting/discovery":
+				if n <= 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(discoveryContent1))
+			case "/slow/hosting/discovery":
+				time.Sleep(200 * time.Millisecond)
+				w.Write([]byte(discoveryContent1))
 			}
 		}))
 	})
@@ -333,7 +460,7 @@ var _ = Describe("Discovery", func() {
 			}
 			logger := log.NopLogger()
 
-			appUrls, err := helpers.GetAppURLs(cfg, logger)
+			appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, logger)
 
 			expectedAppUrls := map[string]map[string]string{
 				"view": map[string]string{
@@ -361,7 +488,7 @@ var _ = Describe("Discovery", func() {
 			}
 			logger := log.NopLogger()
 
-			appUrls, err := helpers.GetAppURLs(cfg, logger)
+			appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, logger)
 			Expect(err).To(HaveOccurred())
 			Expect(appUrls).To(BeNil())
 		})
@@ -375,9 +502,114 @@ var _ = Describe("Discovery", func() {
 			}
 			logger := log.NopLogger()
 
-			appUrls, err := helpers.GetAppURLs(cfg, logger)
+			appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, logger)
 			Expect(err).To(HaveOccurred())
 			Expect(appUrls).To(BeNil())
 		})
+
+		It("Retries a failing discovery URL", func() {
+			cfg := &config.Config{
+				App: config.App{
+					Addr:                srv.URL + "/flaky",
+					Insecure:            true,
+					DiscoveryRetries:    2,
+					DiscoveryRetryDelay: 10 * time.Millisecond,
+				},
+			}
+			logger := log.NopLogger()
+
+			appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, logger)
+			Expect(err).To(Succeed())
+			Expect(appUrls).To(HaveKey("view"))
+			Expect(requests.Load()).To(Equal(int32(3)))
+		})
+
+		It("Gives up on a slow discovery URL", func() {
+			cfg := &config.Config{
+				App: config.App{
+					Addr:                srv.URL + "/slow",
+					Insecure:            true,
+					DiscoveryTimeout:    50 * time.Millisecond,
+					DiscoveryRetries:    1,
+					DiscoveryRetryDelay: 10 * time.Millisecond,
+				},
+			}
+			logger := log.NopLogger()
+
+			appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, logger)
+			Expect(err).To(MatchError(ContainSubstring("after 2 attempts")))
+			Expect(appUrls).To(BeNil())
+			Expect(requests.Load()).To(Equal(int32(2)))
+		})
+
+		It("reuses the connection of the http client", func() {
+			cfg := &config.Config{
+				App: config.App{
+					Addr:     srv.URL + "/good",
+					Insecure: true,
+				},
+				HTTPClient: config.HTTPClient{
+					MaxIdleConnsPerHost: 1,
+				},
+			}
+			httpClient := discoveryClient(cfg)
+			transport := httpClient.Transport
+
+			for range 3 {
+				_, err := helpers.GetAppURLs(httpClient, cfg, log.NopLogger())
+				Expect(err).To(Succeed())
+			}
+
+			Expect(httpClient.Transport).To(BeIdenticalTo(transport))
+			Expect(requests.Load()).To(Equal(int32(3)))
+			connections := 0
+			remoteAddrs.Range(func(_, _ any) bool {
+				connections++
+				return true
+			})
+			Expect(connections).To(Equal(1))
+		})
+
+		Context("with a discovery cache", func() {
+			var cfg *config.Config
+
+			BeforeEach(func() {
+				cfg = &config.Config{
+					App: config.App{
+						Addr:               srv.URL + "/good",
+						Insecure:           true,
+						DiscoveryCacheFile: filepath.Join(GinkgoT().TempDir(), "cache", "discovery.json"),
+					},
+				}
+			})
+
+			It("uses the cached app urls if the discovery fails", func() {
+				appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, log.NopLogger())
+				Expect(err).To(Succeed())
+				Expect(cfg.App.DiscoveryCacheFile).To(BeAnExistingFile())
+
+				cfg.App.Addr = srv.URL + "/bad"
+				cachedAppUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, log.NopLogger())
+				Expect(err).To(Succeed())
+				Expect(cachedAppUrls).To(Equal(appUrls))
+			})
+
+			It("fails if the discovery fails and nothing is cached", func() {
+				cfg.App.Addr = srv.URL + "/bad"
+
+				appUrls, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, log.NopLogger())
+				Expect(err).To(HaveOccurred())
+				Expect(appUrls).To(BeNil())
+			})
+
+			It("fails if the cache is broken", func() {
+				Expect(os.MkdirAll(filepath.Dir(cfg.App.DiscoveryCacheFile), 0700)).To(Succeed())
+				Expect(os.WriteFile(cfg.App.DiscoveryCacheFile, []byte("not json"), 0600)).To(Succeed())
+				cfg.App.Addr = srv.URL + "/bad"
+
+				_, err := helpers.GetAppURLs(discoveryClient(cfg), cfg, log.NopLogger())
+				Expect(err).To(HaveOccurred())
+			})
+		})
 	})
 })
@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/services/collaboration/pkg/config"
+)
+
+// NewHTTPClient creates an http client which reuses its connections. The
+// client is meant to be created once and shared by all requests to the same
+// hosts, a client per request opens a new connection every time and leaves
+// the idle connections of the previous ones behind.
+func NewHTTPClient(cfg config.HTTPClient, insecure bool, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// LockTracker tracks the WOPI locks which were acquired or refreshed through
+// this instance until they are released or expire. It only sees the lock
+// operations of this instance, a lock released through another instance is
+// tracked until it expires, so its count is an estimate.
+type LockTracker struct {
+	mu       sync.Mutex
+	expiries map[string]time.Time
+	now      func() time.Time
+}
+
+// NewLockTracker returns a new LockTracker without locks
+func NewLockTracker() *LockTracker {
+	return &LockTracker{
+		expiries: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Hold records the lock of the file until its expiry, a lock which is held
+// already gets the new expiry
+func (t *LockTracker) Hold(file string, expiry time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expiries[file] = expiry
+}
+
+// Release removes the lock of the file
+func (t *LockTracker) Release(file string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.expiries, file)
+}
+
+// Count returns the number of locks which are not expired, the expired ones
+// are removed
+func (t *LockTracker) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for file, expiry := range t.expiries {
+		if !expiry.After(now) {
+			delete(t.expiries, file)
+		}
+	}
+
+	return len(t.expiries)
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LockTracker", func() {
+	var (
+		tracker *LockTracker
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		tracker = NewLockTracker()
+		tracker.now = func() time.Time { return now }
+	})
+
+	It("counts the held locks until they are released", func() {
+		tracker.Hold("a", now.Add(time.Minute))
+		tracker.Hold("b", now.Add(time.Minute))
+		Expect(tracker.Count()).To(Equal(2))
+
+		tracker.Release("a")
+		Expect(tracker.Count()).To(Equal(1))
+
+		tracker.Release("unknown")
+		Expect(tracker.Count()).To(Equal(1))
+	})
+
+	It("counts a refreshed lock once", func() {
+		tracker.Hold("a", now.Add(time.Minute))
+		tracker.Hold("a", now.Add(2*time.Minute))
+		Expect(tracker.Count()).To(Equal(1))
+	})
+
+	It("drops the locks once they are expired", func() {
+		tracker.Hold("a", now.Add(time.Minute))
+		tracker.Hold("b", now.Add(2*time.Minute))
+
+		now = now.Add(time.Minute)
+		Expect(tracker.Count()).To(Equal(1))
+
+		// refreshing the lock extends its expiry
+		tracker.Hold("b", now.Add(2*time.Minute))
+		now = now.Add(90 * time.Second)
+		Expect(tracker.Count()).To(Equal(1))
+
+		now = now.Add(time.Minute)
+		Expect(tracker.Count()).To(Equal(0))
+	})
+})
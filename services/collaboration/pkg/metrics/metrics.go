@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// Namespace defines the namespace for the defines metrics.
+	Namespace = "opencloud"
+
+	// Subsystem defines the subsystem for the defines metrics.
+	Subsystem = "collaboration"
+
+	buildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "build_info",
+		Help:      "Build information",
+	}, []string{"version"})
+	lockTracker           = NewLockTracker()
+	instanceLocksEstimate = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "instance_locks_estimate",
+		Help:      "Estimated number of WOPI locks held through this instance: locks acquired or refreshed through this instance which were neither released through it nor expired. Locks released through other instances are only removed on expiry, so the values of several instances must not be summed up",
+	}, func() float64 {
+		return float64(lockTracker.Count())
+	})
+	lockOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "lock_operations_total",
+		Help:      "Number of WOPI lock operations by operation (acquire, refresh, release) and outcome (success, conflict, failure)",
+	}, []string{"operation", "outcome"})
+	lockConflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "lock_conflicts_total",
+		Help:      "Number of WOPI lock operations which failed because the file is locked with another lock",
+	}, []string{"operation"})
+)
+
+// Metrics defines the available metrics of this service.
+type Metrics struct {
+	BuildInfo             *prometheus.GaugeVec
+	InstanceLocksEstimate prometheus.GaugeFunc
+	LockOperations        *prometheus.CounterVec
+	LockConflicts         *prometheus.CounterVec
+
+	lockTracker *LockTracker
+}
+
+// New initializes the available metrics.
+func New() *Metrics {
+	m := &Metrics{
+		BuildInfo:             buildInfo,
+		InstanceLocksEstimate: instanceLocksEstimate,
+		LockOperations:        lockOperations,
+		LockConflicts:         lockConflicts,
+		lockTracker:           lockTracker,
+	}
+
+	return m
+}
+
+// HoldLock counts the lock of the file in the instance locks estimate until
+// its expiry
+func (m *Metrics) HoldLock(file string, expiry time.Time) {
+	m.lockTracker.Hold(file, expiry)
+}
+
+// ReleaseLock removes the lock of the file from the instance locks estimate
+func (m *Metrics) ReleaseLock(file string) {
+	m.lockTracker.Release(file)
+}
@@ -15,6 +15,8 @@ type Options struct {
 	Logger  log.Logger
 	Context context.Context
 	Config  *config.Config
+	// LockGatewayHealth reports whether the gateway answers WOPI lock requests
+	LockGatewayHealth func(ctx context.Context) error
 }
 
 // newOptions initializes the available default options.
@@ -48,3 +50,10 @@ func Config(val *config.Config) Option {
 		o.Config = val
 	}
 }
+
+// LockGatewayHealth provides a function to set the lock gateway health check option.
+func LockGatewayHealth(val func(ctx context.Context) error) Option {
+	return func(o *Options) {
+		o.LockGatewayHealth = val
+	}
+}
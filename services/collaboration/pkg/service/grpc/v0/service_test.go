@@ -81,7 +81,7 @@ var _ = Describe("Discovery", func() {
 		gatewaySelector := mocks.NewSelectable[gatewayv1beta1.GatewayAPIClient](GinkgoT())
 		gatewaySelector.On("Next").Return(gatewayClient, nil)
 
-		appURLs := helpers.NewAppURLs()
+		appURLs := helpers.NewAppURLs(nil)
 		appURLs.Store(map[string]map[string]string{
 			"view": {
 				".pdf":  "https://cloud.opencloud.test/hosting/wopi/word/view",
@@ -335,6 +335,16 @@ func loadMiddlewares(logger log.Logger, cfg *config.Config,
 		logger.Fatal().Err(err).Msg("Failed to load CSP configuration.")
 	}
 
+	claimHeaders, err := config.ParseClaimHeaders(cfg.ClaimHeaders, cfg.Policies)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to parse the claim headers.")
+	}
+
+	claimTransformations, err := config.ParseClaimTransformations(cfg.ClaimTransformations)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to parse the claim transformations.")
+	}
+
 	return alice.New(
 		chimiddleware.RealIP,
 		chimiddleware.RequestID,
@@ -371,6 +381,9 @@ func loadMiddlewares(logger log.Logger, cfg *config.Config,
 			middleware.UserCS3Claim(cfg.UserCS3Claim),
 			middleware.AutoprovisionAccounts(cfg.AutoprovisionAccounts),
 			middleware.MultiTenantEnabled(cfg.Commons.MultiTenantEnabled),
+			middleware.TenantOIDCClaim(cfg.TenantOIDCClaim),
+			middleware.ClaimHeaders(claimHeaders),
+			middleware.ClaimTransformations(claimTransformations),
 			middleware.EventsPublisher(publisher),
 		),
 		middleware.SelectorCookie(
@@ -2,10 +2,17 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/go-ldap/ldap/v3"
 	"github.com/opencloud-eu/opencloud/pkg/shared"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"go-micro.dev/v4/client"
+	"golang.org/x/net/http/httpguts"
 )
 
 // Config combines all available configuration parts.
@@ -35,9 +42,12 @@ type Config struct {
 	AccountBackend        string              `yaml:"account_backend" env:"PROXY_ACCOUNT_BACKEND_TYPE" desc:"Account backend the PROXY service should use. Currently only 'cs3' is possible here." introductionVersion:"1.0.0"`
 	UserOIDCClaim         string              `yaml:"user_oidc_claim" env:"PROXY_USER_OIDC_CLAIM" desc:"The name of an OpenID Connect claim that is used for resolving users with the account backend. The value of the claim must hold a per user unique, stable and non re-assignable identifier. The availability of claims depends on your Identity Provider. There are common claims available for most Identity providers like 'email' or 'preferred_username' but you can also add your own claim." introductionVersion:"1.0.0"`
 	UserCS3Claim          string              `yaml:"user_cs3_claim" env:"PROXY_USER_CS3_CLAIM" desc:"The name of a CS3 user attribute (claim) that should be mapped to the 'user_oidc_claim'. Supported values are 'username', 'mail' and 'userid'." introductionVersion:"1.0.0"`
+	TenantOIDCClaim       string              `yaml:"tenant_oidc_claim" env:"PROXY_TENANT_OIDC_CLAIM" desc:"The name of an OpenID Connect claim that holds the tenant id of the user, e.g. 'tid'. Nested claims can be separated by a '.', a literal '.' is escaped with a '\\'. When multi-tenancy is enabled, requests without the claim are rejected, as are users whose tenant id does not match it. Users can't be auto provisioned if the claim is set. Leave empty to not check the tenant id against a claim." introductionVersion:"%%NEXT%%"`
 	MachineAuthAPIKey     string              `yaml:"machine_auth_api_key" env:"OC_MACHINE_AUTH_API_KEY;PROXY_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary to access resources from other services." introductionVersion:"1.0.0" mask:"password"`
 	AutoprovisionAccounts bool                `yaml:"auto_provision_accounts" env:"PROXY_AUTOPROVISION_ACCOUNTS" desc:"Set this to 'true' to automatically provision users that do not yet exist in the users service on-demand upon first sign-in. To use this a write-enabled libregraph user backend needs to be setup an running." introductionVersion:"1.0.0"`
 	AutoProvisionClaims   AutoProvisionClaims `yaml:"auto_provision_claims"`
+	ClaimTransformations  []string            `yaml:"claim_transformations" env:"PROXY_CLAIM_TRANSFORMATIONS" desc:"A list of transformations which normalize the values of OpenID Connect claims before users are resolved, provisioned and their groups are synced. Each entry applies a transformation to a claim like 'email=lowercase' or 'preferred_username=trim_suffix:@example.com', the entries are applied in the given order. Supported transformations are 'lowercase', 'uppercase', 'trim_prefix:<prefix>', 'trim_suffix:<suffix>', 'strip_domain' which removes everything from the last '@' and 'first_rdn' which maps a distinguished name like 'cn=admins,ou=groups' to the value of its first attribute. Nested claims can be separated by a '.', a literal '.' is escaped with a '\\'. The elements of list claims like groups are transformed one by one. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	ClaimHeaders          []string            `yaml:"claim_headers" env:"PROXY_CLAIM_HEADERS" desc:"A list of OpenID Connect claims which are forwarded to the backends in request headers, each entry maps a claim to a header like 'email=X-User-Email'. Nested claims can be separated by a '.', a literal '.' is escaped with a '\\'. Only string claims are forwarded, headers of these names sent by clients are always removed. The access token header, the remote user headers of the policies and hop-by-hop headers can't be used. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
 	EnableBasicAuth       bool                `yaml:"enable_basic_auth" env:"PROXY_ENABLE_BASIC_AUTH" desc:"Set this to true to enable 'basic authentication' (username/password)." introductionVersion:"1.0.0"`
 	InsecureBackends      bool                `yaml:"insecure_backends" env:"PROXY_INSECURE_BACKENDS" desc:"Disable TLS certificate validation for all HTTP backend connections." introductionVersion:"1.0.0"`
 	BackendHTTPSCACert    string              `yaml:"backend_https_cacert" env:"PROXY_HTTPS_CACERT" desc:"Path/File for the root CA certificate used to validate the server’s TLS certificate for https enabled backend services." introductionVersion:"1.0.0"`
@@ -234,3 +244,133 @@ type Events struct {
 	AuthUsername         string `yaml:"username" env:"OC_EVENTS_AUTH_USERNAME;PROXY_EVENTS_AUTH_USERNAME" desc:"The username to authenticate with the events broker. The events broker is the OpenCloud service which receives and delivers events between the services." introductionVersion:"1.0.0"`
 	AuthPassword         string `yaml:"password" env:"OC_EVENTS_AUTH_PASSWORD;PROXY_EVENTS_AUTH_PASSWORD" desc:"The password to authenticate with the events broker. The events broker is the OpenCloud service which receives and delivers events between the services." introductionVersion:"1.0.0"`
 }
+
+// ClaimHeader maps an OpenID Connect claim to the request header its value is forwarded in
+type ClaimHeader struct {
+	Claim  string
+	Header string
+}
+
+// hopByHopHeaders are only meant for a single connection and are not forwarded to the backends
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ParseClaimHeaders parses the 'claim=Header' entries of the claim headers setting,
+// the header names are returned in their canonical form. The headers set by the proxy itself,
+// the reva token header and the remote user headers of the policies, and the hop-by-hop headers
+// can't be used.
+func ParseClaimHeaders(entries []string, policies []Policy) ([]ClaimHeader, error) {
+	reserved := append([]string{revactx.TokenHeader}, hopByHopHeaders...)
+	for _, policy := range policies {
+		for _, route := range policy.Routes {
+			if route.RemoteUserHeader != "" {
+				reserved = append(reserved, route.RemoteUserHeader)
+			}
+		}
+	}
+
+	claimHeaders := make([]ClaimHeader, 0, len(entries))
+	for _, entry := range entries {
+		claim, header, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		claim, header = strings.TrimSpace(claim), strings.TrimSpace(header)
+		switch {
+		case !ok || claim == "" || header == "":
+			return nil, fmt.Errorf("invalid claim header '%s', expected 'claim=Header'", entry)
+		case !httpguts.ValidHeaderFieldName(header):
+			return nil, fmt.Errorf("invalid header name '%s' for claim '%s'", header, claim)
+		case slices.ContainsFunc(reserved, func(r string) bool { return strings.EqualFold(r, header) }):
+			return nil, fmt.Errorf("reserved header name '%s' for claim '%s'", header, claim)
+		}
+
+		claimHeaders = append(claimHeaders, ClaimHeader{Claim: claim, Header: http.CanonicalHeaderKey(header)})
+	}
+
+	return claimHeaders, nil
+}
+
+// the transformations of claim values
+const (
+	ClaimTransformationLowercase   = "lowercase"
+	ClaimTransformationUppercase   = "uppercase"
+	ClaimTransformationTrimPrefix  = "trim_prefix"
+	ClaimTransformationTrimSuffix  = "trim_suffix"
+	ClaimTransformationStripDomain = "strip_domain"
+	ClaimTransformationFirstRDN    = "first_rdn"
+)
+
+// ClaimTransformation normalizes the value of an OpenID Connect claim, the argument is only used by the
+// transformations which need one like trim_suffix
+type ClaimTransformation struct {
+	Claim          string
+	Transformation string
+	Argument       string
+}
+
+// ParseClaimTransformations parses the 'claim=transformation[:argument]' entries of the claim transformations setting,
+// the order of the entries is kept.
+func ParseClaimTransformations(entries []string) ([]ClaimTransformation, error) {
+	transformations := make([]ClaimTransformation, 0, len(entries))
+	for _, entry := range entries {
+		claim, transformation, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		claim = strings.TrimSpace(claim)
+		// the argument is kept as it is, a suffix may start with a space
+		transformation, argument, hasArgument := strings.Cut(strings.TrimLeft(transformation, " "), ":")
+		transformation = strings.TrimSpace(transformation)
+		if !ok || claim == "" || transformation == "" {
+			return nil, fmt.Errorf("invalid claim transformation '%s', expected 'claim=transformation'", entry)
+		}
+
+		switch transformation {
+		case ClaimTransformationLowercase, ClaimTransformationUppercase, ClaimTransformationStripDomain, ClaimTransformationFirstRDN:
+			if hasArgument {
+				return nil, fmt.Errorf("the claim transformation '%s' of claim '%s' does not take an argument", transformation, claim)
+			}
+		case ClaimTransformationTrimPrefix, ClaimTransformationTrimSuffix:
+			if argument == "" {
+				return nil, fmt.Errorf("the claim transformation '%s' of claim '%s' requires an argument like '%s:value'", transformation, claim, transformation)
+			}
+		default:
+			return nil, fmt.Errorf("unknown claim transformation '%s' for claim '%s'", transformation, claim)
+		}
+
+		transformations = append(transformations, ClaimTransformation{Claim: claim, Transformation: transformation, Argument: argument})
+	}
+
+	return transformations, nil
+}
+
+// Transform returns the transformed claim value, values a transformation does not apply to are returned as they are.
+func (t ClaimTransformation) Transform(value string) string {
+	switch t.Transformation {
+	case ClaimTransformationLowercase:
+		return strings.ToLower(value)
+	case ClaimTransformationUppercase:
+		return strings.ToUpper(value)
+	case ClaimTransformationTrimPrefix:
+		return strings.TrimPrefix(value, t.Argument)
+	case ClaimTransformationTrimSuffix:
+		return strings.TrimSuffix(value, t.Argument)
+	case ClaimTransformationStripDomain:
+		if i := strings.LastIndex(value, "@"); i > 0 {
+			return value[:i]
+		}
+		return value
+	case ClaimTransformationFirstRDN:
+		dn, err := ldap.ParseDN(value)
+		if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+			return value
+		}
+		return dn.RDNs[0].Attributes[0].Value
+	default:
+		return value
+	}
+}
@@ -53,6 +53,22 @@ func Validate(cfg *config.Config) error {
 		)
 	}
 
+	// the graph service can't assign the tenant id of a new user, users of a tenant can't be auto provisioned
+	if cfg.Commons != nil && cfg.Commons.MultiTenantEnabled && cfg.TenantOIDCClaim != "" && cfg.AutoprovisionAccounts {
+		return fmt.Errorf(
+			"Incompatible value '%t' for 'auto_provision_accounts' in service %s. Must be false when multi-tenancy is enabled and 'tenant_oidc_claim' is set.",
+			cfg.AutoprovisionAccounts, cfg.Service.Name,
+		)
+	}
+
+	if _, err := config.ParseClaimHeaders(cfg.ClaimHeaders, cfg.Policies); err != nil {
+		return fmt.Errorf("Invalid value for 'claim_headers' in service %s: %w", cfg.Service.Name, err)
+	}
+
+	if _, err := config.ParseClaimTransformations(cfg.ClaimTransformations); err != nil {
+		return fmt.Errorf("Invalid value for 'claim_transformations' in service %s: %w", cfg.Service.Name, err)
+	}
+
 	if cfg.ServiceAccount.ServiceAccountID == "" {
 		return shared.MissingServiceAccountID(cfg.Service.Name)
 	}
@@ -3,10 +3,12 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/opencloud-eu/opencloud/services/proxy/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/router"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/user/backend"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/userroles"
@@ -44,6 +46,9 @@ func AccountResolver(optionSetters ...Option) func(next http.Handler) http.Handl
 			userRoleAssigner:      options.UserRoleAssigner,
 			autoProvisionAccounts: options.AutoprovisionAccounts,
 			multiTenantEnabled:    options.MultiTenantEnabled,
+			tenantOIDCClaim:       options.TenantOIDCClaim,
+			claimHeaders:          options.ClaimHeaders,
+			claimTransformations:  options.ClaimTransformations,
 			lastGroupSyncCache:    lastGroupSyncCache,
 			eventsPublisher:       options.EventsPublisher,
 		}
@@ -60,6 +65,15 @@ type accountResolver struct {
 	multiTenantEnabled    bool
 	userOIDCClaim         string
 	userCS3Claim          string
+	// tenantOIDCClaim is the claim holding the tenant id of the user, the tenant id
+	// is only verified against the claim if set and multi-tenancy is enabled
+	tenantOIDCClaim string
+	// claimHeaders are the claims which are forwarded in request headers, the headers
+	// are removed from all incoming requests so clients can not spoof them
+	claimHeaders []config.ClaimHeader
+	// claimTransformations normalize the claim values before the user is resolved, provisioned
+	// and its groups are synced, they are applied in order
+	claimTransformations []config.ClaimTransformation
 	// lastGroupSyncCache is used to keep track of when the last sync of group
 	// memberships was done for a specific user. This is used to trigger a sync
 	// with every single request.
@@ -67,7 +81,9 @@ type accountResolver struct {
 	eventsPublisher    events.Publisher
 }
 
-func readUserIDClaim(path string, claims map[string]interface{}) (string, error) {
+// readStringClaim reads the string value of the claim at the given path, nested claims are
+// separated by a '.' which can be escaped with a '\\'.
+func readStringClaim(path string, claims map[string]interface{}) (string, error) {
 	// happy path
 	value, _ := claims[path].(string)
 	if value != "" {
@@ -102,14 +118,86 @@ func readUserIDClaim(path string, claims map[string]interface{}) (string, error)
 	return value, fmt.Errorf("claim path '%s' not set or empty", path)
 }
 
+// transformClaims returns the claims with the transformations applied in order, the claims of the context
+// are not changed. The elements of list claims like groups are transformed one by one, other values are kept.
+func transformClaims(claims map[string]interface{}, transformations []config.ClaimTransformation) map[string]interface{} {
+	if len(transformations) == 0 || claims == nil {
+		return claims
+	}
+
+	transformed := maps.Clone(claims)
+	for _, t := range transformations {
+		transformClaim(transformed, oidc.SplitWithEscaping(t.Claim, ".", "\\"), t)
+	}
+
+	return transformed
+}
+
+// transformClaim transforms the claim at the given path, the nested claims on the path are copied before they are changed
+func transformClaim(claims map[string]interface{}, segments []string, t config.ClaimTransformation) {
+	// like readStringClaim, a claim with a literal '.' in its name takes precedence over a nested claim
+	if value, ok := claims[t.Claim]; ok {
+		claims[t.Claim] = transformClaimValue(value, t)
+		return
+	}
+
+	for i, segment := range segments {
+		value, ok := claims[segment]
+		if !ok {
+			return
+		}
+
+		if i == len(segments)-1 {
+			claims[segment] = transformClaimValue(value, t)
+			return
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		nested = maps.Clone(nested)
+		claims[segment] = nested
+		claims = nested
+	}
+}
+
+func transformClaimValue(value interface{}, t config.ClaimTransformation) interface{} {
+	switch v := value.(type) {
+	case string:
+		return t.Transform(v)
+	case []string:
+		transformed := make([]string, len(v))
+		for i, element := range v {
+			transformed[i] = t.Transform(element)
+		}
+		return transformed
+	case []interface{}:
+		transformed := make([]interface{}, len(v))
+		for i, element := range v {
+			if s, ok := element.(string); ok {
+				transformed[i] = t.Transform(s)
+			} else {
+				transformed[i] = element
+			}
+		}
+		return transformed
+	default:
+		return value
+	}
+}
+
 // TODO do not use the context to store values: https://medium.com/@cep21/how-to-correctly-use-context-context-in-go-1-7-8f2c0fafdf39
 func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx, span := m.tracer.Start(req.Context(), fmt.Sprintf("%s %s", req.Method, req.URL.Path), trace.WithSpanKind(trace.SpanKindServer))
-	claims := oidc.FromContext(ctx)
+	claims := transformClaims(oidc.FromContext(ctx), m.claimTransformations)
 	user, ok := revactx.ContextGetUser(ctx)
 	token, hasToken := revactx.ContextGetToken(ctx)
 	req = req.WithContext(ctx)
 	defer span.End()
+	for _, claimHeader := range m.claimHeaders {
+		req.Header.Del(claimHeader.Header)
+	}
 	if claims == nil && !ok {
 		span.End()
 		m.next.ServeHTTP(w, req)
@@ -117,13 +205,23 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if user == nil && claims != nil {
-		value, err := readUserIDClaim(m.userOIDCClaim, claims)
+		value, err := readStringClaim(m.userOIDCClaim, claims)
 		if err != nil {
 			m.logger.Error().Err(err).Msg("could not read user id claim")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		var tenantID string
+		if m.multiTenantEnabled && m.tenantOIDCClaim != "" {
+			tenantID, err = readStringClaim(m.tenantOIDCClaim, claims)
+			if err != nil {
+				m.logger.Error().Err(err).Str("claim", m.tenantOIDCClaim).Msg("could not read tenant id claim")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
 		user, token, err = m.userProvider.GetUserByClaims(req.Context(), m.userCS3Claim, value)
 
 		if errors.Is(err, backend.ErrAccountNotFound) {
@@ -133,6 +231,13 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
+			// the tenant id of a new user can't be assigned, no user is created for a tenant
+			if tenantID != "" {
+				m.logger.Error().Str("claim", m.userOIDCClaim).Str("value", value).Str("claimedTenantId", tenantID).
+					Msg("Autoprovisioning users of a tenant is not supported, the user has to be provisioned by the user backend")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
 			m.logger.Debug().Interface("claims", claims).Msg("Autoprovisioning user")
 			var newuser *cs3user.User
 			newuser, err = m.userProvider.CreateUserFromClaims(req.Context(), claims)
@@ -141,9 +246,14 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
-			user, token, err = m.userProvider.GetUserByClaims(req.Context(), "userid", newuser.Id.OpaqueId)
+			if newuser.GetId().GetOpaqueId() == "" {
+				m.logger.Error().Interface("claims", claims).Msg("Autoprovisioned user has no id")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			user, token, err = m.userProvider.GetUserByClaims(req.Context(), "userid", newuser.GetId().GetOpaqueId())
 			if err != nil {
-				m.logger.Error().Err(err).Str("userid", newuser.Id.OpaqueId).Msg("Error getting token for autoprovisioned user")
+				m.logger.Error().Err(err).Str("userid", newuser.GetId().GetOpaqueId()).Msg("Error getting token for autoprovisioned user")
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
@@ -161,6 +271,14 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		// the requests of a user without an id can not be attributed to anyone by the services behind the proxy
+		if user.GetId().GetOpaqueId() == "" {
+			m.logger.Error().Str("claim", m.userOIDCClaim).Str("value", value).Str("username", user.GetUsername()).
+				Msg("The user backend returned a user without an id")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		// if this is a multi-tenant setup, make sure the resolved user has a tenant id set
 		if m.multiTenantEnabled && user.GetId().GetTenantId() == "" {
 			m.logger.Error().Str("userid", user.Id.OpaqueId).Msg("User does not have a tenantId assigned")
@@ -168,6 +286,14 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		// and that it belongs to the tenant the identity provider asserted
+		if tenantID != "" && user.GetId().GetTenantId() != tenantID {
+			m.logger.Error().Str("userid", user.Id.OpaqueId).Str("tenantId", user.GetId().GetTenantId()).Str("claimedTenantId", tenantID).
+				Msg("User tenantId does not match the tenant id claim")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		// update user if needed
 		if m.autoProvisionAccounts {
 			if err = m.userProvider.UpdateUserIfNeeded(req.Context(), user, claims); err != nil {
@@ -235,6 +361,12 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	// the claim headers are set first, they must never replace the headers of the proxy
+	for _, claimHeader := range m.claimHeaders {
+		if value, err := readStringClaim(claimHeader.Claim, claims); err == nil {
+			req.Header.Set(claimHeader.Header, value)
+		}
+	}
 	ri := router.ContextRoutingInfo(ctx)
 	if ri.RemoteUserHeader() != "" {
 		req.Header.Set(ri.RemoteUserHeader(), user.GetId().GetOpaqueId())
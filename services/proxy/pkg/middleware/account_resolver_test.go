@@ -2,13 +2,16 @@ package middleware
 
 import (
 	"context"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/pkg/oidc"
+	"github.com/opencloud-eu/opencloud/services/proxy/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/router"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/user/backend"
 	"github.com/opencloud-eu/opencloud/services/proxy/pkg/user/backend/mocks"
@@ -85,7 +88,7 @@ func TestTokenIsAddedWithDotEscapedUsernameClaim(t *testing.T) {
 		Mail: "foo@example.com",
 	}, nil, "li\\.un", "username", false)
 
-	// This tests the . escaping of the readUserIDClaim
+	// This tests the . escaping of the readStringClaim
 	req, rw := mockRequest(map[string]interface{}{
 		oidc.Iss: "https://idx.example.com",
 		"li.un":  "foo",
@@ -105,7 +108,7 @@ func TestTokenIsAddedWithDottedUsernameClaimFallback(t *testing.T) {
 		Mail: "foo@example.com",
 	}, nil, "li.un", "username", false)
 
-	// This tests the . escaping fallback of the readUserIDClaim
+	// This tests the . escaping fallback of the readStringClaim
 	req, rw := mockRequest(map[string]interface{}{
 		oidc.Iss: "https://idx.example.com",
 		"li.un":  "foo",
@@ -158,6 +161,68 @@ func TestUnauthorizedOnUserDisabled(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rw.Code)
 }
 
+func TestUnauthorizedOnUserWithoutId(t *testing.T) {
+	for name, id := range map[string]*userv1beta1.UserId{
+		"no id":           nil,
+		"empty opaque id": {Idp: "https://idx.example.com"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ub := mocks.UserBackend{}
+			ub.On("GetUserByClaims", mock.Anything, "username", "foo").Return(&userv1beta1.User{
+				Id:       id,
+				Username: "foo",
+			}, "token", nil)
+
+			sut := AccountResolver(
+				Logger(log.NewLogger()),
+				UserProvider(&ub),
+				UserRoleAssigner(&userRoleMocks.UserRoleAssigner{}),
+				UserOIDCClaim(oidc.PreferredUsername),
+				UserCS3Claim("username"),
+			)(mockHandler{})
+			req, rw := mockRequest(map[string]interface{}{
+				oidc.Iss:               "https://idx.example.com",
+				oidc.PreferredUsername: "foo",
+			})
+
+			sut.ServeHTTP(rw, req)
+
+			token := req.Header.Get(revactx.TokenHeader)
+			assert.Empty(t, token)
+			assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		})
+	}
+}
+
+func TestUnauthorizedOnAutoprovisionedUserWithoutId(t *testing.T) {
+	ub := mocks.UserBackend{}
+	ub.On("GetUserByClaims", mock.Anything, "username", "foo").Return(nil, "", backend.ErrAccountNotFound)
+	ub.On("CreateUserFromClaims", mock.Anything, mock.Anything).Return(&userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+	}, nil)
+	ub.On("GetUserByClaims", mock.Anything, "userid", "123").Return(&userv1beta1.User{Username: "foo"}, "token", nil)
+
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(&ub),
+		UserRoleAssigner(&userRoleMocks.UserRoleAssigner{}),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+		AutoprovisionAccounts(true),
+	)(mockHandler{})
+	req, rw := mockRequest(map[string]any{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+	})
+
+	sut.ServeHTTP(rw, req)
+
+	assert.Empty(t, req.Header.Get(revactx.TokenHeader))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	ub.AssertNotCalled(t, "UpdateUserIfNeeded", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestInternalServerErrorOnMissingMailAndUsername(t *testing.T) {
 	sut := newMockAccountResolver(nil, backend.ErrAccountNotFound, oidc.Email, "mail", false)
 	req, rw := mockRequest(map[string]interface{}{
@@ -213,6 +278,371 @@ func TestTokenIsAddedWhenUserHasTenantId(t *testing.T) {
 	assert.Contains(t, token, "eyJ")
 }
 
+func TestTokenIsAddedWhenTenantIdMatchesClaim(t *testing.T) {
+	for claim, claims := range map[string]map[string]any{
+		"tid": {
+			"tid": "tenant1",
+		},
+		"org.id": {
+			"org": map[string]any{"id": "tenant1"},
+		},
+	} {
+		t.Run(claim, func(t *testing.T) {
+			sut := newMockTenantAccountResolver(&userv1beta1.User{
+				Id: &userv1beta1.UserId{
+					Idp:      "https://idx.example.com",
+					OpaqueId: "123",
+					TenantId: "tenant1",
+				},
+				Username: "foo",
+			}, claim)
+			claims[oidc.Iss] = "https://idx.example.com"
+			claims[oidc.PreferredUsername] = "foo"
+			req, rw := mockRequest(claims)
+
+			sut.ServeHTTP(rw, req)
+
+			token := req.Header.Get(revactx.TokenHeader)
+			assert.NotEmpty(t, token)
+			assert.Equal(t, http.StatusOK, rw.Code)
+		})
+	}
+}
+
+func TestUnauthorizedOnTenantIdClaimMismatch(t *testing.T) {
+	sut := newMockTenantAccountResolver(&userv1beta1.User{
+		Id: &userv1beta1.UserId{
+			Idp:      "https://idx.example.com",
+			OpaqueId: "123",
+			TenantId: "tenant1",
+		},
+		Username: "foo",
+	}, "tid")
+	req, rw := mockRequest(map[string]any{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+		"tid":                  "tenant2",
+	})
+
+	sut.ServeHTTP(rw, req)
+
+	token := req.Header.Get(revactx.TokenHeader)
+	assert.Empty(t, token)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
+
+func TestUnauthorizedOnMissingTenantIdClaim(t *testing.T) {
+	sut := newMockTenantAccountResolver(&userv1beta1.User{
+		Id: &userv1beta1.UserId{
+			Idp:      "https://idx.example.com",
+			OpaqueId: "123",
+			TenantId: "tenant1",
+		},
+		Username: "foo",
+	}, "tid")
+	req, rw := mockRequest(map[string]any{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+	})
+
+	sut.ServeHTTP(rw, req)
+
+	token := req.Header.Get(revactx.TokenHeader)
+	assert.Empty(t, token)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
+
+func TestForbiddenOnAutoprovisioningUsersOfATenant(t *testing.T) {
+	ub := mocks.UserBackend{}
+	ub.On("GetUserByClaims", mock.Anything, "username", "foo").Return(nil, "", backend.ErrAccountNotFound)
+
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(&ub),
+		UserRoleAssigner(&userRoleMocks.UserRoleAssigner{}),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+		AutoprovisionAccounts(true),
+		MultiTenantEnabled(true),
+		TenantOIDCClaim("tid"),
+	)(mockHandler{})
+	req, rw := mockRequest(map[string]any{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+		"tid":                  "tenant1",
+	})
+
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	assert.Empty(t, req.Header.Get(revactx.TokenHeader))
+	// the tenant of a new user can't be assigned, no user is created at all
+	ub.AssertNotCalled(t, "CreateUserFromClaims", mock.Anything, mock.Anything)
+}
+
+func TestClaimHeadersAreForwarded(t *testing.T) {
+	user := &userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+	}
+	tokenManager, _ := jwt.New(map[string]interface{}{
+		"secret":  "change-me",
+		"expires": int64(60),
+	})
+	s, _ := scope.AddOwnerScope(nil)
+	token, _ := tokenManager.MintToken(context.Background(), user, s)
+
+	ub := mocks.UserBackend{}
+	ub.On("GetUserByClaims", mock.Anything, mock.Anything, mock.Anything).Return(user, token, nil)
+	ra := userRoleMocks.UserRoleAssigner{}
+	ra.On("UpdateUserRoleAssignment", mock.Anything, mock.Anything, mock.Anything).Return(user, nil)
+
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(&ub),
+		UserRoleAssigner(&ra),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+		ClaimHeaders([]config.ClaimHeader{
+			{Claim: oidc.Email, Header: "X-User-Email"},
+			{Claim: "org.department", Header: "X-User-Department"},
+			{Claim: "nickname", Header: "X-User-Nickname"},
+		}),
+	)(mockHandler{})
+	req, rw := mockRequest(map[string]interface{}{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+		oidc.Email:             "foo@example.com",
+		"org":                  map[string]interface{}{"department": "sales"},
+	})
+	// clients can not set the headers of the claims themselves
+	req.Header.Set("X-User-Nickname", "spoofed")
+	req.Header.Set("X-User-Email", "spoofed@example.com")
+
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.NotEmpty(t, req.Header.Get(revactx.TokenHeader))
+	assert.Equal(t, "foo@example.com", req.Header.Get("X-User-Email"))
+	assert.Equal(t, "sales", req.Header.Get("X-User-Department"))
+	assert.Empty(t, req.Header.Values("X-User-Nickname"))
+	// the other claims are not forwarded
+	assert.ElementsMatch(t, []string{http.CanonicalHeaderKey(revactx.TokenHeader), "X-User-Email", "X-User-Department"}, slices.Collect(maps.Keys(req.Header)))
+}
+
+func TestClaimHeadersAreRemovedFromUnauthenticatedRequests(t *testing.T) {
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		ClaimHeaders([]config.ClaimHeader{{Claim: oidc.Email, Header: "X-User-Email"}}),
+	)(mockHandler{})
+	req, rw := mockRequest(nil)
+	req.Header.Set("X-User-Email", "spoofed@example.com")
+
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, req.Header.Values("X-User-Email"))
+}
+
+func TestParseClaimHeaders(t *testing.T) {
+	policies := []config.Policy{{Routes: []config.Route{{RemoteUserHeader: "Remote-User"}}}}
+	for _, entry := range []string{"email", "=X-User-Email", "email=", "email=X User", "sub=x-access-token", "sub=X-Access-Token", "sub=remote-user", "sub=Connection", "sub=transfer-encoding"} {
+		t.Run(entry, func(t *testing.T) {
+			_, err := config.ParseClaimHeaders([]string{entry}, policies)
+			assert.Error(t, err)
+		})
+	}
+
+	claimHeaders, err := config.ParseClaimHeaders([]string{" email = x-user-email "}, policies)
+	assert.NoError(t, err)
+	assert.Equal(t, []config.ClaimHeader{{Claim: oidc.Email, Header: "X-User-Email"}}, claimHeaders)
+}
+
+func TestClaimTransformationsChangeTheResolvedValue(t *testing.T) {
+	user := &userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+		Mail:     "foo@example.com",
+	}
+	tokenManager, _ := jwt.New(map[string]interface{}{
+		"secret":  "change-me",
+		"expires": int64(60),
+	})
+	s, _ := scope.AddOwnerScope(nil)
+	token, _ := tokenManager.MintToken(context.Background(), user, s)
+
+	tests := []struct {
+		name            string
+		oidcClaim       string
+		cs3Claim        string
+		claims          map[string]interface{}
+		transformations []string
+		expectedValue   string
+	}{
+		{
+			name:            "lowercase",
+			oidcClaim:       oidc.Email,
+			cs3Claim:        "mail",
+			claims:          map[string]interface{}{oidc.Email: "Foo@Example.COM"},
+			transformations: []string{"email=lowercase"},
+			expectedValue:   "foo@example.com",
+		},
+		{
+			name:            "trim suffix",
+			oidcClaim:       oidc.PreferredUsername,
+			cs3Claim:        "username",
+			claims:          map[string]interface{}{oidc.PreferredUsername: "foo@corp.example.com"},
+			transformations: []string{"preferred_username=trim_suffix:@corp.example.com"},
+			expectedValue:   "foo",
+		},
+		{
+			name:            "strip domain of a nested claim",
+			oidcClaim:       "li.un",
+			cs3Claim:        "username",
+			claims:          map[string]interface{}{"li": map[string]interface{}{"un": "foo@example.com"}},
+			transformations: []string{"li.un=strip_domain"},
+			expectedValue:   "foo",
+		},
+		{
+			name:            "in order",
+			oidcClaim:       oidc.PreferredUsername,
+			cs3Claim:        "username",
+			claims:          map[string]interface{}{oidc.PreferredUsername: "FOO@CORP.EXAMPLE.COM"},
+			transformations: []string{"preferred_username=lowercase", "preferred_username=trim_suffix:@corp.example.com"},
+			expectedValue:   "foo",
+		},
+		{
+			name:            "of other claims",
+			oidcClaim:       oidc.PreferredUsername,
+			cs3Claim:        "username",
+			claims:          map[string]interface{}{oidc.PreferredUsername: "Foo"},
+			transformations: []string{"email=lowercase"},
+			expectedValue:   "Foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformations, err := config.ParseClaimTransformations(tt.transformations)
+			assert.NoError(t, err)
+
+			ub := mocks.UserBackend{}
+			ub.On("GetUserByClaims", mock.Anything, tt.cs3Claim, tt.expectedValue).Return(user, token, nil)
+			ra := userRoleMocks.UserRoleAssigner{}
+			ra.On("UpdateUserRoleAssignment", mock.Anything, mock.Anything, mock.Anything).Return(user, nil)
+
+			sut := AccountResolver(
+				Logger(log.NewLogger()),
+				UserProvider(&ub),
+				UserRoleAssigner(&ra),
+				UserOIDCClaim(tt.oidcClaim),
+				UserCS3Claim(tt.cs3Claim),
+				ClaimTransformations(transformations),
+			)(mockHandler{})
+			claims := maps.Clone(tt.claims)
+			claims[oidc.Iss] = "https://idx.example.com"
+			req, rw := mockRequest(claims)
+
+			sut.ServeHTTP(rw, req)
+
+			assert.Equal(t, http.StatusOK, rw.Code)
+			ub.AssertCalled(t, "GetUserByClaims", mock.Anything, tt.cs3Claim, tt.expectedValue)
+			// the claims of the request are not changed
+			assert.Equal(t, claims, oidc.FromContext(req.Context()))
+		})
+	}
+}
+
+func TestClaimTransformationsApplyToProvisioningAndGroupSync(t *testing.T) {
+	user := &userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+	}
+	tokenManager, _ := jwt.New(map[string]interface{}{
+		"secret":  "change-me",
+		"expires": int64(60),
+	})
+	s, _ := scope.AddOwnerScope(nil)
+	token, _ := tokenManager.MintToken(context.Background(), user, s)
+
+	expectedClaims := mock.MatchedBy(func(claims map[string]interface{}) bool {
+		return claims[oidc.PreferredUsername] == "foo" &&
+			assert.ObjectsAreEqual([]interface{}{"admins", "sales", 42}, claims["groups"])
+	})
+	ub := mocks.UserBackend{}
+	ub.On("GetUserByClaims", mock.Anything, "username", "foo").Return(nil, "", backend.ErrAccountNotFound)
+	ub.On("CreateUserFromClaims", mock.Anything, expectedClaims).Return(user, nil)
+	ub.On("GetUserByClaims", mock.Anything, "userid", "123").Return(user, token, nil)
+	ub.On("UpdateUserIfNeeded", mock.Anything, mock.Anything, expectedClaims).Return(nil)
+	ub.On("SyncGroupMemberships", mock.Anything, mock.Anything, expectedClaims).Return(nil)
+	ra := userRoleMocks.UserRoleAssigner{}
+	ra.On("UpdateUserRoleAssignment", mock.Anything, mock.Anything, mock.Anything).Return(user, nil)
+
+	transformations, err := config.ParseClaimTransformations([]string{"preferred_username=lowercase", "groups=first_rdn"})
+	assert.NoError(t, err)
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(&ub),
+		UserRoleAssigner(&ra),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+		AutoprovisionAccounts(true),
+		ClaimTransformations(transformations),
+	)(mockHandler{})
+	req, rw := mockRequest(map[string]interface{}{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "Foo",
+		"groups":               []interface{}{"cn=admins,ou=groups,dc=example,dc=com", "sales", 42},
+	})
+
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	ub.AssertExpectations(t)
+}
+
+func TestParseClaimTransformations(t *testing.T) {
+	for _, entry := range []string{"email", "=lowercase", "email=", "email=reverse", "email=lowercase:foo", "preferred_username=trim_suffix", "preferred_username=trim_suffix:"} {
+		t.Run(entry, func(t *testing.T) {
+			_, err := config.ParseClaimTransformations([]string{entry})
+			assert.Error(t, err)
+		})
+	}
+
+	transformations, err := config.ParseClaimTransformations([]string{" email = lowercase ", "preferred_username=trim_suffix:@example.com:8080"})
+	assert.NoError(t, err)
+	assert.Equal(t, []config.ClaimTransformation{
+		{Claim: oidc.Email, Transformation: config.ClaimTransformationLowercase},
+		{Claim: oidc.PreferredUsername, Transformation: config.ClaimTransformationTrimSuffix, Argument: "@example.com:8080"},
+	}, transformations)
+}
+
+func newMockTenantAccountResolver(userBackendResult *userv1beta1.User, tenantClaim string) http.Handler {
+	tokenManager, _ := jwt.New(map[string]interface{}{
+		"secret":  "change-me",
+		"expires": int64(60),
+	})
+
+	s, _ := scope.AddOwnerScope(nil)
+	token, _ := tokenManager.MintToken(context.Background(), userBackendResult, s)
+
+	ub := mocks.UserBackend{}
+	ub.On("GetUserByClaims", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(userBackendResult, token, nil)
+
+	ra := userRoleMocks.UserRoleAssigner{}
+	ra.On("UpdateUserRoleAssignment", mock.Anything, mock.Anything, mock.Anything).Return(userBackendResult, nil)
+
+	return AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(&ub),
+		UserRoleAssigner(&ra),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+		MultiTenantEnabled(true),
+		TenantOIDCClaim(tenantClaim),
+	)(mockHandler{})
+}
+
 func newMockAccountResolver(userBackendResult *userv1beta1.User, userBackendErr error, oidcclaim, cs3claim string, multiTenant bool) http.Handler {
 	tokenManager, _ := jwt.New(map[string]interface{}{
 		"secret":  "change-me",
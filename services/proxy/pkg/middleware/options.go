@@ -73,7 +73,13 @@ type Options struct {
 	SkipUserInfo bool
 	// MultiTenantEnabled causes the account resolve middleware to reject users that don't have a tenant id assigned
 	MultiTenantEnabled bool
-	EventsPublisher    events.Publisher
+	// TenantOIDCClaim to read the tenant id of the user from the oidc claims
+	TenantOIDCClaim string
+	// ClaimHeaders are the oidc claims which are forwarded in request headers
+	ClaimHeaders []config.ClaimHeader
+	// ClaimTransformations normalize the oidc claims before the account resolver uses them
+	ClaimTransformations []config.ClaimTransformation
+	EventsPublisher      events.Publisher
 }
 
 // newOptions initializes the available default options.
@@ -241,6 +247,27 @@ func SkipUserInfo(val bool) Option {
 	}
 }
 
+// TenantOIDCClaim provides a function to set the TenantOIDCClaim config
+func TenantOIDCClaim(val string) Option {
+	return func(o *Options) {
+		o.TenantOIDCClaim = val
+	}
+}
+
+// ClaimHeaders provides a function to set the ClaimHeaders config
+func ClaimHeaders(val []config.ClaimHeader) Option {
+	return func(o *Options) {
+		o.ClaimHeaders = val
+	}
+}
+
+// ClaimTransformations provides a function to set the ClaimTransformations config
+func ClaimTransformations(val []config.ClaimTransformation) Option {
+	return func(o *Options) {
+		o.ClaimTransformations = val
+	}
+}
+
 // MultiTenantEnabled sets the MultiTenantEnabled flag.
 func MultiTenantEnabled(val bool) Option {
 	return func(o *Options) {
@@ -2,11 +2,18 @@ package bleve
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/index/scorch/mergeplan"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
@@ -15,6 +22,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
+	ocsync "github.com/opencloud-eu/opencloud/pkg/sync"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
@@ -24,60 +32,130 @@ import (
 
 const defaultBatchSize = 50
 
-var _ search.Engine = (*Backend)(nil) // ensure Backend implements Engine
+var (
+	_ search.Engine     = (*Backend)(nil) // ensure Backend implements Engine
+	_ search.Resetter   = (*Backend)(nil) // ensure Backend implements Resetter
+	_ search.Aggregator = (*Backend)(nil) // ensure Backend implements Aggregator
+	_ search.Compactor  = (*Backend)(nil) // ensure Backend implements Compactor
+)
 
 type Backend struct {
+	// mu guards the index and the writer, which are replaced when the index is reset
+	mu           sync.RWMutex
 	index        bleve.Index
 	queryCreator searchQuery.Creator[query.Query]
 	log          log.Logger
+	rootLocks    *ocsync.NamedRWMutex
+	writer       *writer
+
+	// resetRoot is the root the index is recreated in when it is reset, resetting is not supported if empty
+	resetRoot    string
+	resetOptions []IndexOption
+
+	// pending holds the batches which are pushed before the index is closed, nothing is flushed if nil
+	pending *pendingBatches
+
+	// descendantsChunkSize and maxDescendants limit the descendants of the folders the batches move, delete or restore
+	descendantsChunkSize int
+	maxDescendants       int
+
+	highlightFragments    int
+	highlightFragmentSize int
+	highlightMaxSize      int
+	highlighter           string
 }
 
-func NewBackend(index bleve.Index, queryCreator searchQuery.Creator[query.Query], log log.Logger) *Backend {
-	return &Backend{
+// BackendOption configures the backend
+type BackendOption func(b *Backend)
+
+// WithHighlights returns up to the given number of highlight fragments with the given size in characters per match,
+// the joined highlights of a match are limited to maxSize bytes, 0 disables the limit.
+// Without it, the best fragment of the default bleve highlighter is returned.
+func WithHighlights(fragments, fragmentSize, maxSize int) BackendOption {
+	return func(b *Backend) {
+		b.highlightFragments = fragments
+		b.highlightFragmentSize = fragmentSize
+		b.highlightMaxSize = maxSize
+	}
+}
+
+// WithFlushOnClose pushes the operations of the batches which are not pushed yet before the index is closed,
+// so the last changes are not lost when the service shuts down while resources are indexed.
+func WithFlushOnClose() BackendOption {
+	return func(b *Backend) {
+		b.pending = newPendingBatches()
+	}
+}
+
+// WithDescendantLimits reads and updates the descendants of moved, deleted or restored folders in chunks of the given size,
+// folders with more than max descendants are refused with search.ErrTooManyDescendants, 0 disables the limit.
+func WithDescendantLimits(chunkSize, max int) BackendOption {
+	return func(b *Backend) {
+		b.descendantsChunkSize = chunkSize
+		b.maxDescendants = max
+	}
+}
+
+// WithIndexReset allows to reset the index, which replaces it with a new and empty index in the given root.
+// The options are used to create the new index, they should match the ones the index was opened with.
+func WithIndexReset(root string, opts ...IndexOption) BackendOption {
+	return func(b *Backend) {
+		b.resetRoot = root
+		b.resetOptions = opts
+	}
+}
+
+func NewBackend(index bleve.Index, queryCreator searchQuery.Creator[query.Query], log log.Logger, opts ...BackendOption) *Backend {
+	rootLocks := ocsync.NewNamedRWMutex()
+	b := &Backend{
 		index:        index,
 		queryCreator: queryCreator,
 		log:          log,
+		rootLocks:    &rootLocks,
+		writer:       newWriter(index),
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if b.highlightFragments > 0 {
+		highlighter, err := defineHighlighter(b.highlightFragments, b.highlightFragmentSize)
+		if err != nil {
+			b.log.Error().Err(err).Msg("could not define the highlighter, falling back to the default highlighter")
+		}
+		b.highlighter = highlighter
+	}
+
+	return b
 }
 
 // Search executes a search request operation within the index.
 // Returns a SearchIndexResponse object or an error.
-func (b *Backend) Search(_ context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
-	createdQuery, err := b.queryCreator.Create(sir.Query)
+func (b *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
+	// the paths of the hits are not checked when only counting, grouping or returning ids, restrict the path in the query instead
+	q, err := b.indexQuery(sir, sir.CountOnly || sir.GroupByParent || sir.IdsOnly)
 	if err != nil {
-		if searchQuery.IsValidationError(err) {
-			return nil, errtypes.BadRequest(err.Error())
-		}
 		return nil, err
 	}
 
-	q := bleve.NewConjunctionQuery(
-		// Skip documents that have been marked as deleted
-		&query.BoolFieldQuery{
-			Bool:     false,
-			FieldVal: "Deleted",
-		},
-		createdQuery,
-	)
+	bleveReq := bleve.NewSearchRequest(q)
 
-	if sir.Ref != nil {
-		q.Conjuncts = append(
-			q.Conjuncts,
-			&query.TermQuery{
-				FieldVal: "RootID",
-				Term: storagespace.FormatResourceID(
-					&storageProvider.ResourceId{
-						StorageId: sir.Ref.GetResourceId().GetStorageId(),
-						SpaceId:   sir.Ref.GetResourceId().GetSpaceId(),
-						OpaqueId:  sir.Ref.GetResourceId().GetOpaqueId(),
-					},
-				),
-			},
-		)
+	if sir.CountOnly {
+		bleveReq.Size = 0
+		res, err := b.currentIndex().SearchInContext(ctx, bleveReq)
+		if err != nil {
+			return nil, engineError(err)
+		}
+
+		return &searchService.SearchIndexResponse{
+			Matches:      []*searchMessage.Match{},
+			TotalMatches: int32(res.Total),
+		}, nil
 	}
 
-	bleveReq := bleve.NewSearchRequest(q)
-	bleveReq.Highlight = bleve.NewHighlight()
+	if sir.GroupByParent {
+		return b.searchParents(ctx, bleveReq, sir.PageSize)
+	}
 
 	switch {
 	case sir.PageSize == -1:
@@ -88,17 +166,33 @@ func (b *Backend) Search(_ context.Context, sir *searchService.SearchIndexReques
 		bleveReq.Size = int(sir.PageSize)
 	}
 
+	// order equal-scored hits by their id, so every request returns them in the same order
+	bleveReq.SortBy([]string{"-_score", "_id"})
+
+	if sir.IdsOnly {
+		return b.searchIDs(ctx, bleveReq)
+	}
+
+	if !sir.NoHighlight {
+		bleveReq.Highlight = bleve.NewHighlight()
+		if b.highlighter != "" {
+			bleveReq.Highlight = bleve.NewHighlightWithStyle(b.highlighter)
+		}
+	}
+	// the locations tell which version matched
+	bleveReq.IncludeLocations = true
 	bleveReq.Fields = []string{"*"}
-	res, err := b.index.Search(bleveReq)
+	// the search is aborted if the request gets cancelled or exceeds its deadline
+	res, err := b.currentIndex().SearchInContext(ctx, bleveReq)
 	if err != nil {
-		return nil, err
+		return nil, engineError(err)
 	}
 
 	matches := make([]*searchMessage.Match, 0, len(res.Hits))
 	totalMatches := res.Total
 	for _, hit := range res.Hits {
 		if sir.Ref != nil {
-			hitPath := strings.TrimSuffix(getFieldValue[string](hit.Fields, "Path"), "/")
+			hitPath := getFieldValue[string](hit.Fields, "Path")
 			requestedPath := utils.MakeRelativePath(sir.Ref.Path)
 			isRoot := hitPath == requestedPath
 
@@ -126,19 +220,22 @@ func (b *Backend) Search(_ context.Context, sir *searchService.SearchIndexReques
 					ResourceId: resourceIDtoSearchID(rootID),
 					Path:       getFieldValue[string](hit.Fields, "Path"),
 				},
-				Id:         resourceIDtoSearchID(rID),
-				Name:       getFieldValue[string](hit.Fields, "Name"),
-				ParentId:   resourceIDtoSearchID(pID),
-				Size:       uint64(getFieldValue[float64](hit.Fields, "Size")),
-				Type:       uint64(getFieldValue[float64](hit.Fields, "Type")),
-				MimeType:   getFieldValue[string](hit.Fields, "MimeType"),
-				Deleted:    getFieldValue[bool](hit.Fields, "Deleted"),
-				Tags:       getFieldSliceValue[string](hit.Fields, "Tags"),
-				Highlights: getFragmentValue(hit.Fragments, "Content", 0),
-				Audio:      getAudioValue[searchMessage.Audio](hit.Fields),
-				Image:      getImageValue[searchMessage.Image](hit.Fields),
-				Location:   getLocationValue[searchMessage.GeoCoordinates](hit.Fields),
-				Photo:      getPhotoValue[searchMessage.Photo](hit.Fields),
+				Id:             resourceIDtoSearchID(rID),
+				Name:           getFieldValue[string](hit.Fields, "Name"),
+				ParentId:       resourceIDtoSearchID(pID),
+				Size:           uint64(getFieldValue[float64](hit.Fields, "Size")),
+				Type:           uint64(getFieldValue[float64](hit.Fields, "Type")),
+				MimeType:       getFieldValue[string](hit.Fields, "MimeType"),
+				Deleted:        getFieldValue[bool](hit.Fields, "Deleted"),
+				Tags:           getFieldSliceValue[string](hit.Fields, "Tags"),
+				Highlights:     search.JoinHighlights(hit.Fragments["Content"], b.highlightMaxSize),
+				Audio:          getAudioValue[searchMessage.Audio](hit.Fields),
+				Image:          getImageValue[searchMessage.Image](hit.Fields),
+				Location:       getLocationValue[searchMessage.GeoCoordinates](hit.Fields),
+				Photo:          getPhotoValue[searchMessage.Photo](hit.Fields),
+				SpaceName:      getFieldValue[string](hit.Fields, "SpaceName"),
+				MatchedVersion: matchedVersion(hit),
+				HasPreview:     getFieldValue[bool](hit.Fields, "HasPreview"),
 			},
 		}
 
@@ -146,6 +243,9 @@ func (b *Backend) Search(_ context.Context, sir *searchService.SearchIndexReques
 			match.Entity.LastModifiedTime = &timestamppb.Timestamp{Seconds: mtime.Unix(), Nanos: int32(mtime.Nanosecond())}
 		}
 
+		// the omitted fields are matched by the query, but not returned
+		search.OmitFields(match.Entity, sir.OmitFields)
+
 		matches = append(matches, match)
 	}
 
@@ -155,12 +255,200 @@ func (b *Backend) Search(_ context.Context, sir *searchService.SearchIndexReques
 	}, nil
 }
 
+// indexQuery builds the query of the search request, which skips the deleted resources and restricts the matches to the
+// root of the reference. The path of the reference is only part of the query if restrictPath is set.
+func (b *Backend) indexQuery(sir *searchService.SearchIndexRequest, restrictPath bool) (*query.ConjunctionQuery, error) {
+	var createdQuery query.Query
+	switch {
+	case search.IsEmptyQuery(sir.Query) && sir.Ref == nil:
+		return nil, search.ErrEmptyQueryWithoutScope
+	case search.IsEmptyQuery(sir.Query):
+		// an empty query lists all resources within the reference
+		createdQuery = bleve.NewMatchAllQuery()
+	default:
+		var err error
+		createdQuery, err = b.queryCreator.Create(sir.Query)
+		if err != nil {
+			if searchQuery.IsValidationError(err) {
+				return nil, errtypes.BadRequest(err.Error())
+			}
+			return nil, err
+		}
+	}
+
+	q := bleve.NewConjunctionQuery(
+		// Skip documents that have been marked as deleted
+		&query.BoolFieldQuery{
+			Bool:     false,
+			FieldVal: "Deleted",
+		},
+		createdQuery,
+	)
+
+	if sir.Ref != nil {
+		q.Conjuncts = append(
+			q.Conjuncts,
+			&query.TermQuery{
+				FieldVal: "RootID",
+				Term: storagespace.FormatResourceID(
+					&storageProvider.ResourceId{
+						StorageId: sir.Ref.GetResourceId().GetStorageId(),
+						SpaceId:   sir.Ref.GetResourceId().GetSpaceId(),
+						OpaqueId:  sir.Ref.GetResourceId().GetOpaqueId(),
+					},
+				),
+			},
+		)
+
+		if requestedPath := utils.MakeRelativePath(sir.Ref.Path); restrictPath && requestedPath != "." {
+			q.Conjuncts = append(
+				q.Conjuncts,
+				bleve.NewDisjunctionQuery(
+					&query.TermQuery{FieldVal: "Path", Term: requestedPath},
+					&query.PrefixQuery{FieldVal: "Path", Prefix: requestedPath + "/"},
+				),
+			)
+		}
+	}
+
+	return q, nil
+}
+
+// matchedVersion returns the key of the latest version whose content matched the query
+func matchedVersion(hit *bleveSearch.DocumentMatch) string {
+	matched := -1
+	for _, locations := range hit.Locations["Versions.Content"] {
+		for _, location := range locations {
+			// the versions are stored latest first, the first array position is the index of the version
+			if len(location.ArrayPositions) > 0 && (matched == -1 || int(location.ArrayPositions[0]) < matched) {
+				matched = int(location.ArrayPositions[0])
+			}
+		}
+	}
+
+	keys := getFieldSliceValue[string](hit.Fields, "Versions.Key")
+	if matched == -1 || matched >= len(keys) {
+		return ""
+	}
+
+	return keys[matched]
+}
+
+// searchParents returns the distinct parents of the matching resources with their number of matches instead of the matches,
+// the hits are grouped after loading only their parent ids.
+func (b *Backend) searchParents(ctx context.Context, req *bleve.SearchRequest, pageSize int32) (*searchService.SearchIndexResponse, error) {
+	req.Size = math.MaxInt
+	req.Fields = []string{"ParentID"}
+	res, err := b.currentIndex().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, engineError(err)
+	}
+
+	counts := make(map[string]int32)
+	for _, hit := range res.Hits {
+		counts[getFieldValue[string](hit.Fields, "ParentID")]++
+	}
+
+	limit := int(pageSize)
+	if pageSize == 0 {
+		limit = 200
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      []*searchMessage.Match{},
+		TotalMatches: int32(res.Total),
+		Parents:      search.ParentMatches(counts, limit),
+	}, nil
+}
+
+// searchIDs returns the ids of the matching resources with their scores instead of the matches, only the ids of the hits are loaded.
+func (b *Backend) searchIDs(ctx context.Context, req *bleve.SearchRequest) (*searchService.SearchIndexResponse, error) {
+	req.Fields = []string{"ID"}
+	res, err := b.currentIndex().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, engineError(err)
+	}
+
+	scores := make(map[string]float32, len(res.Hits))
+	for _, hit := range res.Hits {
+		scores[getFieldValue[string](hit.Fields, "ID")] = float32(hit.Score)
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      []*searchMessage.Match{},
+		TotalMatches: int32(res.Total),
+		IdMatches:    search.IDMatches(scores, -1),
+	}, nil
+}
+
+// SumField returns the sum of the numeric field over the files matching the search request, the containers are left out
+// as their sizes already add up the sizes of their children. Only the field of the hits is loaded.
+func (b *Backend) SumField(ctx context.Context, sir *searchService.SearchIndexRequest, field string) (float64, error) {
+	q, err := b.indexQuery(sir, true)
+	if err != nil {
+		return 0, err
+	}
+
+	container := float64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER)
+	inclusive := true
+	isContainer := query.NewNumericRangeInclusiveQuery(&container, &container, &inclusive, &inclusive)
+	isContainer.SetField("Type")
+	files := bleve.NewBooleanQuery()
+	files.AddMust(q)
+	files.AddMustNot(isContainer)
+
+	req := bleve.NewSearchRequest(files)
+	req.Size = math.MaxInt
+	req.Fields = []string{field}
+	res, err := b.currentIndex().SearchInContext(ctx, req)
+	if err != nil {
+		return 0, engineError(err)
+	}
+
+	var sum float64
+	for _, hit := range res.Hits {
+		sum += getFieldValue[float64](hit.Fields, field)
+	}
+
+	return sum, nil
+}
+
 func (b *Backend) DocCount() (uint64, error) {
-	return b.index.DocCount()
+	count, err := b.currentIndex().DocCount()
+	if err != nil {
+		return 0, engineError(err)
+	}
+
+	return count, nil
+}
+
+// engineError wraps the errors of the index into the errors of the search package, so callers can tell them apart.
+// The index is created if it does not exist when the backend is opened, so it is never reported as not ready.
+func engineError(err error) error {
+	if errors.Is(err, bleve.ErrorIndexClosed) {
+		return fmt.Errorf("%w: %w", search.ErrBackendUnavailable, err)
+	}
+
+	return err
+}
+
+// GetDocument returns the indexed document of the given resource id, including deleted and hidden resources
+func (b *Backend) GetDocument(id string) (*search.Resource, error) {
+	return searchResourceByID(id, b.currentIndex())
+}
+
+// GetDocuments returns the indexed documents of the given resource ids which are part of the index,
+// including deleted and hidden resources. The ids which are not indexed are left out.
+func (b *Backend) GetDocuments(ids []string) ([]*search.Resource, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return searchResourcesByIDs(ids, b.currentIndex())
 }
 
 func (b *Backend) Upsert(id string, r search.Resource) error {
-	batch, err := b.NewBatch(defaultBatchSize)
+	batch, err := b.newBatch(defaultBatchSize)
 	if err != nil {
 		return err
 	}
@@ -172,58 +460,363 @@ func (b *Backend) Upsert(id string, r search.Resource) error {
 	return batch.Push()
 }
 
-func (b *Backend) Move(rootID, parentID, location string) error {
-	batch, err := b.NewBatch(defaultBatchSize)
+// UpsertMany indexes or updates all given resources at once, the items are keyed by the resource id.
+func (b *Backend) UpsertMany(items map[string]search.Resource) error {
+	batch, err := b.newBatch(defaultBatchSize)
 	if err != nil {
 		return err
 	}
 
-	if err := batch.Move(rootID, parentID, location); err != nil {
-		return err
+	for id, r := range items {
+		if err := batch.Upsert(id, r); err != nil {
+			return err
+		}
 	}
 
 	return batch.Push()
 }
 
+func (b *Backend) Move(rootID, parentID, location string) error {
+	// the parent belongs to another space if the resource is moved across spaces
+	defer b.lockRoots(rootID, parentID)()
+
+	// the changed resources are read again if they were written concurrently
+	return search.RetryOnConflict(func() error {
+		batch, err := b.newBatch(defaultBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if err := batch.Move(rootID, parentID, location); err != nil {
+			return err
+		}
+
+		return batch.Push()
+	})
+}
+
 func (b *Backend) Delete(id string) error {
-	batch, err := b.NewBatch(defaultBatchSize)
+	defer b.lockRoots(id)()
+
+	// the changed resources are read again if they were written concurrently
+	return search.RetryOnConflict(func() error {
+		batch, err := b.newBatch(defaultBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if err := batch.Delete(id); err != nil {
+			return err
+		}
+
+		return batch.Push()
+	})
+}
+
+func (b *Backend) Restore(id string) error {
+	defer b.lockRoots(id)()
+
+	// the changed resources are read again if they were written concurrently
+	return search.RetryOnConflict(func() error {
+		batch, err := b.newBatch(defaultBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if err := batch.Restore(id); err != nil {
+			return err
+		}
+
+		return batch.Push()
+	})
+}
+
+func (b *Backend) RestoreMany(ids []string) error {
+	defer b.lockRoots(ids...)()
+
+	// the changed resources are read again if they were written concurrently
+	return search.RetryOnConflict(func() error {
+		batch, err := b.newBatch(defaultBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if err := batch.RestoreMany(ids); err != nil {
+			return err
+		}
+
+		return batch.Push()
+	})
+}
+
+func (b *Backend) Purge(id string, onlyDeleted bool) error {
+	defer b.lockRoots(id)()
+
+	batch, err := b.newBatch(defaultBatchSize)
 	if err != nil {
 		return err
 	}
 
-	if err := batch.Delete(id); err != nil {
+	if err := batch.Purge(id, onlyDeleted); err != nil {
 		return err
 	}
 
 	return batch.Push()
 }
 
-func (b *Backend) Restore(id string) error {
-	batch, err := b.NewBatch(defaultBatchSize)
+func (b *Backend) PurgeMany(ids []string, onlyDeleted bool) error {
+	defer b.lockRoots(ids...)()
+
+	batch, err := b.newBatch(defaultBatchSize)
 	if err != nil {
 		return err
 	}
 
-	if err := batch.Restore(id); err != nil {
+	if err := batch.PurgeMany(ids, onlyDeleted); err != nil {
 		return err
 	}
 
 	return batch.Push()
 }
 
-func (b *Backend) Purge(id string, onlyDeleted bool) error {
-	batch, err := b.NewBatch(defaultBatchSize)
+// RenameTag replaces the tag on all resources within the given roots, on all resources if no roots are given.
+// The tags are compared case-insensitively, resources which already carry the new tag keep a single one.
+func (b *Backend) RenameTag(oldTag, newTag string, rootIDs []string) error {
+	if oldTag == "" || newTag == "" {
+		return errtypes.BadRequest("the tags must not be empty")
+	}
+	if oldTag == newTag {
+		return nil
+	}
+
+	// the resources which were changed concurrently are read again, the renamed ones don't match anymore
+	return search.RetryOnConflict(func() error {
+		resources, err := searchResourcesByTag(oldTag, rootIDs, b.currentIndex())
+		if err != nil {
+			return err
+		}
+
+		batch, err := b.newBatch(defaultBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resources {
+			r.Tags = search.RenameTag(r.Tags, oldTag, newTag)
+			if err := batch.Upsert(r.ID, *r); err != nil {
+				return err
+			}
+		}
+
+		return batch.Push()
+	})
+}
+
+// Suggest returns words of the indexed names and tags which are similar to the given term.
+// Only resources within the given references are taken into account, all resources are if no references are given.
+func (b *Backend) Suggest(ctx context.Context, term string, refs []*searchMessage.Reference) ([]string, error) {
+	candidates, err := similarTerms(ctx, b.currentIndex(), term, suggestionFields)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := batch.Purge(id, onlyDeleted); err != nil {
-		return err
+	suggestions := make([]string, 0, search.MaxSuggestions)
+	for _, candidate := range search.RankSuggestions(term, candidates) {
+		if len(suggestions) >= search.MaxSuggestions {
+			break
+		}
+
+		ok, err := hasWordMatch(ctx, b.currentIndex(), candidate, refs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			suggestions = append(suggestions, candidate)
+		}
 	}
 
-	return batch.Push()
+	return suggestions, nil
 }
 
 func (b *Backend) NewBatch(size int) (search.BatchOperator, error) {
-	return NewBatch(b.index, size)
+	index, writer := b.current()
+	batch, err := newBatch(index, writer, size, b.pending)
+	if err != nil {
+		return nil, err
+	}
+
+	b.limitDescendants(batch)
+	return batch, nil
+}
+
+// newBatch returns a batch for the operations of the backend, they push it right away so it is never flushed on close.
+func (b *Backend) newBatch(size int) (*Batch, error) {
+	index, writer := b.current()
+	batch, err := newBatch(index, writer, size, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b.limitDescendants(batch)
+	return batch, nil
+}
+
+// limitDescendants applies the descendant limits of the backend to the batch
+func (b *Backend) limitDescendants(batch *Batch) {
+	if b.descendantsChunkSize > 0 {
+		batch.descendantsChunkSize = b.descendantsChunkSize
+	}
+	batch.maxDescendants = b.maxDescendants
+}
+
+// ResetIndex replaces the index with a new and empty one, it returns the number of documents of the replaced index.
+// Writes which are in flight while the index is reset fail, they have to be repeated by re-indexing.
+func (b *Backend) ResetIndex() (uint64, error) {
+	if b.resetRoot == "" {
+		return 0, errtypes.NotSupported("the bleve index can not be reset")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count, err := b.index.DocCount()
+	if err != nil {
+		return 0, engineError(err)
+	}
+
+	// wait for the batch which is applied right now, the writer is replaced together with the index
+	b.writer.mu.Lock()
+	defer b.writer.mu.Unlock()
+
+	index, err := resetIndex(b.index, b.resetRoot, b.resetOptions...)
+	if err != nil {
+		return 0, err
+	}
+
+	b.index = index
+	b.writer = newWriter(index)
+	return count, nil
+}
+
+// IndexStats returns the numbers of live and deleted documents of the segments of the index and its size on disk
+func (b *Backend) IndexStats(_ context.Context) (search.IndexStats, error) {
+	index, err := scorchIndex(b.currentIndex())
+	if err != nil {
+		return search.IndexStats{}, err
+	}
+
+	reader, err := index.Reader()
+	if err != nil {
+		return search.IndexStats{}, engineError(err)
+	}
+	defer reader.Close()
+
+	snapshot, ok := reader.(*scorch.IndexSnapshot)
+	if !ok {
+		return search.IndexStats{}, errtypes.NotSupported("the bleve index does not provide segment statistics")
+	}
+
+	var stats search.IndexStats
+	for _, segment := range snapshot.Segments() {
+		stats.LiveDocs += uint64(segment.LiveSize())
+		stats.DeletedDocs += uint64(segment.FullSize() - segment.LiveSize())
+	}
+	if size, ok := index.StatsMap()["CurOnDiskBytes"].(uint64); ok {
+		stats.SizeBytes = size
+	}
+
+	return stats, nil
+}
+
+// Compact merges all persisted segments of the index into a single one, which drops the deleted documents.
+// The segments which are not persisted yet are merged by the index in the background.
+func (b *Backend) Compact(ctx context.Context) error {
+	index, err := scorchIndex(b.currentIndex())
+	if err != nil {
+		return err
+	}
+
+	return index.ForceMerge(ctx, &mergeplan.SingleSegmentMergePlanOptions)
+}
+
+// scorchIndex returns the scorch index of the bleve index, only scorch indexes on disk can be compacted
+func scorchIndex(index bleve.Index) (*scorch.Scorch, error) {
+	advanced, err := index.Advanced()
+	if err != nil {
+		return nil, engineError(err)
+	}
+
+	s, ok := advanced.(*scorch.Scorch)
+	if !ok {
+		return nil, errtypes.NotSupported("only scorch indexes can be compacted")
+	}
+
+	return s, nil
+}
+
+// Close closes the current index, the pending batches are pushed before if enabled.
+// A batch which fails to be pushed doesn't prevent the index from being closed.
+func (b *Backend) Close() error {
+	var errs []error
+	if err := b.pending.flush(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush the pending batches: %w", err))
+	}
+
+	if err := b.currentIndex().Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// current returns the index and the writer, which are replaced when the index is reset
+func (b *Backend) current() (bleve.Index, *writer) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.index, b.writer
+}
+
+// currentIndex returns the index, which is replaced when the index is reset
+func (b *Backend) currentIndex() bleve.Index {
+	index, _ := b.current()
+	return index
+}
+
+// lockRoots locks the roots of the given resources until the returned function is called.
+// Operations which read resources before updating them must hold the lock until the batch is pushed,
+// otherwise concurrent operations on the same tree could overwrite each other with stale data.
+func (b *Backend) lockRoots(ids ...string) func() {
+	rootIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		rootIDs = append(rootIDs, rootIDOf(id))
+	}
+
+	// always lock in the same order to prevent deadlocks between operations on multiple roots
+	slices.Sort(rootIDs)
+	rootIDs = slices.Compact(rootIDs)
+
+	for _, rootID := range rootIDs {
+		b.rootLocks.Lock(rootID)
+	}
+
+	return func() {
+		for _, rootID := range rootIDs {
+			b.rootLocks.Unlock(rootID)
+		}
+	}
+}
+
+// rootIDOf returns the id of the space root the given resource belongs to.
+func rootIDOf(id string) string {
+	rID, err := storagespace.ParseID(id)
+	if err != nil {
+		return id
+	}
+
+	return storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: rID.GetStorageId(),
+		SpaceId:   rID.GetSpaceId(),
+		OpaqueId:  rID.GetSpaceId(),
+	})
 }
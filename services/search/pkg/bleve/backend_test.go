@@ -3,12 +3,20 @@ package bleve_test
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	bleveSearch "github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/scorch"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
@@ -16,6 +24,7 @@ import (
 	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
 	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
@@ -110,7 +119,360 @@ var _ = Describe("Bleve", func() {
 		})
 	})
 
+	Describe("Boosts", func() {
+		var nameMatch, contentMatch search.Resource
+
+		BeforeEach(func() {
+			nameMatch = childResource
+			nameMatch.Name = "report"
+			contentMatch = childResource2
+			contentMatch.Name = "summary"
+			contentMatch.Content = "report"
+		})
+
+		rank := func(boosts map[string]float64) []string {
+			eng = bleve.NewBackend(idx, bleveQuery.NewCreator(searchQuery.Options{Boosts: boosts}), log.Logger{})
+			Expect(eng.Upsert(nameMatch.ID, nameMatch)).To(Succeed())
+			Expect(eng.Upsert(contentMatch.ID, contentMatch)).To(Succeed())
+
+			var names []string
+			for _, match := range assertDocCount(rootResource.ID, "name:report OR content:report", 2) {
+				names = append(names, match.GetEntity().GetName())
+			}
+			return names
+		}
+
+		It("ranks name matches above content matches if the name boost is higher", func() {
+			Expect(rank(map[string]float64{"Name": 10, "Content": 1})).To(Equal([]string{"report", "summary"}))
+		})
+
+		It("ranks content matches above name matches if the content boost is higher", func() {
+			Expect(rank(map[string]float64{"Name": 1, "Content": 10})).To(Equal([]string{"summary", "report"}))
+		})
+	})
+
+	Describe("DefaultOperator", func() {
+		BeforeEach(func() {
+			parentResource.Name = "quarterly report"
+			childResource.Name = "report.pdf"
+			childResource2.Name = "quarterly.pdf"
+		})
+
+		index := func(defaultOperator string) {
+			eng = bleve.NewBackend(idx, bleveQuery.NewCreator(searchQuery.Options{DefaultOperator: defaultOperator}), log.Logger{})
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			Expect(eng.Upsert(childResource2.ID, childResource2)).To(Succeed())
+		}
+
+		It("requires all free-text terms to match with AND", func() {
+			index("AND")
+
+			assertDocCount(rootResource.ID, "*report* *quarterly*", 1)
+			assertDocCount(rootResource.ID, "*report* *quarterly* type:file", 0)
+			assertDocCount(rootResource.ID, "*report* OR *quarterly*", 3)
+		})
+
+		It("requires any free-text term to match with OR", func() {
+			index("OR")
+
+			assertDocCount(rootResource.ID, "*report* *quarterly*", 3)
+			assertDocCount(rootResource.ID, "*report* *quarterly* type:file", 2)
+			assertDocCount(rootResource.ID, "*report* AND *quarterly*", 1)
+		})
+	})
+
+	Describe("MinimumShouldMatch", func() {
+		BeforeEach(func() {
+			parentResource.Name = "annual report summary 2024"
+			childResource.Name = "annual report summary.pdf"
+			childResource2.Name = "annual.pdf"
+		})
+
+		index := func(minimumShouldMatch string) {
+			eng = bleve.NewBackend(idx, bleveQuery.NewCreator(searchQuery.Options{DefaultOperator: "AND", MinimumShouldMatch: minimumShouldMatch}), log.Logger{})
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			Expect(eng.Upsert(childResource2.ID, childResource2)).To(Succeed())
+		}
+
+		It("requires most of the free-text terms to match", func() {
+			index("75%")
+
+			matches := assertDocCount(rootResource.ID, "*annual* *report* *summary* *2024*", 2)
+			Expect(matches[0].GetEntity().GetName()).To(Equal("annual report summary 2024"))
+			Expect(matches[1].GetEntity().GetName()).To(Equal("annual report summary.pdf"))
+			assertDocCount(rootResource.ID, "*annual* *report* *summary* *2024* type:file", 1)
+			assertDocCount(rootResource.ID, "*annual* AND *report* AND *summary* AND *2024*", 1)
+		})
+
+		It("requires all free-text terms to match without it", func() {
+			index("")
+
+			assertDocCount(rootResource.ID, "*annual* *report* *summary* *2024*", 1)
+		})
+	})
+
+	Describe("Transliteration", func() {
+		BeforeEach(func() {
+			parentResource.Name = "Иван Петров"
+			childResource.Name = "Αθήνα.pdf"
+		})
+
+		index := func(transliterate bool) {
+			mapping, err := bleve.NewMapping(bleve.WithTransliteration(transliterate))
+			Expect(err).ToNot(HaveOccurred())
+			idx, err = bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+
+			eng = bleve.NewBackend(idx, bleveQuery.NewCreator(searchQuery.Options{Transliterate: transliterate}), log.Logger{})
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+		}
+
+		It("finds cyrillic and greek names by their latin transliteration", func() {
+			index(true)
+
+			assertDocCount(rootResource.ID, "ivan*", 1)
+			assertDocCount(rootResource.ID, "Ivan*", 1)
+			assertDocCount(rootResource.ID, `"ivan petrov"`, 1)
+			assertDocCount(rootResource.ID, "name:*petrov", 1)
+			assertDocCount(rootResource.ID, "athina*", 1)
+			assertDocCount(rootResource.ID, "иван*", 1)
+			assertDocCount(rootResource.ID, "ivana*", 0)
+
+			matches := assertDocCount(rootResource.ID, "ivan* type:folder", 1)
+			Expect(matches[0].GetEntity().GetName()).To(Equal("Иван Петров"))
+		})
+
+		It("does not transliterate if disabled", func() {
+			index(false)
+
+			assertDocCount(rootResource.ID, "ivan*", 0)
+			assertDocCount(rootResource.ID, "athina*", 0)
+			assertDocCount(rootResource.ID, "иван*", 1)
+		})
+	})
+
+	Describe("Phonetic", func() {
+		BeforeEach(func() {
+			parentResource.Name = "Johnson Smith"
+			childResource.Name = "Johnson.pdf"
+		})
+
+		index := func(phonetic bool) {
+			mapping, err := bleve.NewMapping(bleve.WithPhonetic(phonetic))
+			Expect(err).ToNot(HaveOccurred())
+			idx, err = bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+
+			eng = bleve.NewBackend(idx, bleveQuery.NewCreator(searchQuery.Options{Phonetic: phonetic}), log.Logger{})
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+		}
+
+		It("finds names by how they sound", func() {
+			index(true)
+
+			assertDocCount(rootResource.ID, "name~phonetic:Jonson", 2)
+			assertDocCount(rootResource.ID, "name~phonetic:jonsen", 2)
+			assertDocCount(rootResource.ID, `name~phonetic:"Jonson Smyth"`, 1)
+			assertDocCount(rootResource.ID, `name~phonetic:"Smyth Jonson"`, 0)
+			assertDocCount(rootResource.ID, "name~phonetic:Jonson type:file", 1)
+			assertDocCount(rootResource.ID, "name~phonetic:Jackson", 0)
+			assertDocCount(rootResource.ID, "Jonson", 0)
+		})
+
+		It("matches the names as they are written if disabled", func() {
+			index(false)
+
+			assertDocCount(rootResource.ID, "name~phonetic:Jonson", 0)
+			assertDocCount(rootResource.ID, `name~phonetic:"Johnson Smith"`, 1)
+		})
+	})
+
+	Describe("DisabledFields", func() {
+		It("leaves the disabled fields out of the index", func() {
+			mapping, err := bleve.NewMapping(bleve.WithDisabledFields("location"))
+			Expect(err).ToNot(HaveOccurred())
+			idx, err = bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			childResource.Document.Location = &libregraph.GeoCoordinates{
+				Latitude:  libregraph.PtrFloat64(49.48675890884328),
+				Longitude: libregraph.PtrFloat64(11.103870357204285),
+			}
+			childResource.Document.Image = &libregraph.Image{Width: libregraph.PtrInt32(100)}
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			fields, err := idx.Fields()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fields).To(ContainElement("image.width"))
+			Expect(fields).ToNot(ContainElement(HavePrefix("location.")))
+
+			matches := assertDocCount(rootResource.ID, "Name:"+childResource.Name, 1)
+			Expect(matches[0].GetEntity().GetLocation()).To(BeNil())
+			Expect(matches[0].GetEntity().GetImage().GetWidth()).To(Equal(int32(100)))
+		})
+	})
+
+	Describe("Sorting", func() {
+		It("orders equal-scored matches by their id", func() {
+			for _, id := range []string{"9", "7", "5", "8", "6"} {
+				resource := childResource
+				resource.ID = "1$2!" + id
+				resource.Path = "./parent d!r/child" + id + ".pdf"
+				resource.Name = "child" + id + ".pdf"
+				Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+			}
+
+			for range 5 {
+				matches := assertDocCount(rootResource.ID, "type:file", 5)
+
+				ids := make([]string, 0, len(matches))
+				for _, match := range matches {
+					Expect(match.GetScore()).To(Equal(matches[0].GetScore()))
+					ids = append(ids, match.GetEntity().GetId().GetOpaqueId())
+				}
+				Expect(ids).To(Equal([]string{"5", "6", "7", "8", "9"}))
+			}
+		})
+	})
+
 	Describe("Search", func() {
+		Context("with a cancelled request", func() {
+			BeforeEach(func() {
+				for _, resource := range []search.Resource{parentResource, childResource, childResource2} {
+					Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+				}
+			})
+
+			It("aborts the search once the request is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				eng = bleve.NewBackend(cancellingIndex{bleveIndex: idx, cancel: cancel}, bleveQuery.DefaultCreator, log.Logger{})
+
+				start := time.Now()
+				res, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "child*", PageSize: -1})
+				Expect(err).To(MatchError(context.Canceled))
+				Expect(res).To(BeNil())
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+
+			It("aborts the search if the request exceeded its deadline", func() {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+				defer cancel()
+
+				_, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "child*", CountOnly: true})
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+			})
+		})
+
+		Context("with a closed index", func() {
+			BeforeEach(func() {
+				Expect(idx.Close()).To(Succeed())
+			})
+
+			It("reports the backend as unavailable", func() {
+				_, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "child*"})
+				Expect(err).To(MatchError(search.ErrBackendUnavailable))
+
+				_, err = eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "child*", CountOnly: true})
+				Expect(err).To(MatchError(search.ErrBackendUnavailable))
+
+				_, err = eng.DocCount()
+				Expect(err).To(MatchError(search.ErrBackendUnavailable))
+				Expect(err).To(MatchError(bleveSearch.ErrorIndexClosed))
+			})
+		})
+
+		It("returns the name of the space", func() {
+			parentResource.SpaceName = "Marketing"
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+
+			matches := assertDocCount(rootResource.ID, `name:"parent d!r"`, 1)
+			Expect(matches[0].GetEntity().GetSpaceName()).To(Equal("Marketing"))
+
+			resource, err := eng.GetDocument(parentResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.SpaceName).To(Equal("Marketing"))
+		})
+
+		It("filters by the creation date independently of the modification date", func() {
+			childResource.Ctime = "2024-01-10T09:00:00Z"
+			childResource.Mtime = "2024-06-01T09:00:00Z"
+			childResource2.Ctime = "2024-05-20T09:00:00Z"
+			childResource2.Mtime = "2024-05-21T09:00:00Z"
+			for _, resource := range []search.Resource{parentResource, childResource, childResource2} {
+				Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+			}
+
+			matches := assertDocCount(rootResource.ID, "created>=2024-05-01", 1)
+			Expect(matches[0].GetEntity().GetName()).To(Equal("child2.pdf"))
+
+			matches = assertDocCount(rootResource.ID, "mtime>=2024-05-25", 1)
+			Expect(matches[0].GetEntity().GetName()).To(Equal("child.pdf"))
+
+			matches = assertDocCount(rootResource.ID, "created<2024-02-01 mtime>=2024-05-01", 1)
+			Expect(matches[0].GetEntity().GetName()).To(Equal("child.pdf"))
+
+			assertDocCount(rootResource.ID, "created>=2024-05-01 mtime>=2024-05-25", 0)
+			assertDocCount(rootResource.ID, "created<2024-01-01", 0)
+		})
+
+		It("finds files by the content of their previous versions", func() {
+			childResource.Document.Content = "final report"
+			childResource.Versions = []search.Version{
+				{Key: "childid.REV.2", Mtime: "2024-02-01T00:00:00Z", Content: "draft report with budget"},
+				{Key: "childid.REV.1", Mtime: "2024-01-01T00:00:00Z", Content: "budget notes"},
+			}
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			assertDocCount(rootResource.ID, "content:budget", 0)
+
+			matches := assertDocCount(rootResource.ID, "versions:budget", 1)
+			Expect(matches[0].GetEntity().GetId().GetOpaqueId()).To(Equal("4"))
+			Expect(matches[0].GetEntity().GetMatchedVersion()).To(Equal("childid.REV.2"))
+
+			matches = assertDocCount(rootResource.ID, "versions:notes", 1)
+			Expect(matches[0].GetEntity().GetMatchedVersion()).To(Equal("childid.REV.1"))
+
+			matches = assertDocCount(rootResource.ID, "content:report", 1)
+			Expect(matches[0].GetEntity().GetMatchedVersion()).To(BeEmpty())
+
+			resource, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.Versions).To(Equal(childResource.Versions))
+		})
+
+		It("lists all resources of the scope for an empty query", func() {
+			otherResource := childResource
+			otherResource.ID = "1$3!6"
+			otherResource.RootID = "1$3!3"
+			childResource2.Deleted = true
+			Expect(eng.UpsertMany(map[string]search.Resource{
+				parentResource.ID: parentResource,
+				childResource.ID:  childResource,
+				childResource2.ID: childResource2,
+				otherResource.ID:  otherResource,
+			})).To(Succeed())
+
+			assertDocCount(rootResource.ID, "", 2)
+			assertDocCount(rootResource.ID, "  ", 2)
+
+			res, err := doSearch(rootResource.ID, "", "./parent d!r/child.pdf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Matches).To(HaveLen(1))
+		})
+
+		It("rejects an empty query without a scope", func() {
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: " "})
+			Expect(err).To(MatchError(search.ErrEmptyQueryWithoutScope))
+			Expect(res).To(BeNil())
+		})
+
 		Context("by other fields than filename", func() {
 			It("finds files by tags", func() {
 				parentResource.Document.Tags = []string{"foo", "bar"}
@@ -125,6 +487,132 @@ var _ = Describe("Bleve", func() {
 				assertDocCount(rootResource.ID, "Tags:baz", 0)
 			})
 
+			It("finds files by owner and creator", func() {
+				parentResource.Owner = "Owner-ID"
+				parentResource.CreatedBy = "creator-id"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "owner:owner-id", 1)
+				assertDocCount(rootResource.ID, "owner:other-id", 0)
+				assertDocCount(rootResource.ID, "creator:creator-id", 1)
+				assertDocCount(rootResource.ID, "creator:owner-id", 0)
+				assertDocCount(rootResource.ID, "owner:owner-id AND creator:creator-id", 1)
+			})
+
+			It("does not find files without a creator by the creator", func() {
+				parentResource.Owner = "owner-id"
+				parentResource.CreatedBy = ""
+				Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+
+				assertDocCount(rootResource.ID, "owner:owner-id", 1)
+				assertDocCount(rootResource.ID, "creator:owner-id", 0)
+			})
+
+			It("finds files by the users and groups they are shared with", func() {
+				parentResource.SharedWith = []string{"User-ID", "group-id"}
+				Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+				childResource.SharedWith = []string{"other-id"}
+				Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+				assertDocCount(rootResource.ID, "sharedwith:user-id", 1)
+				assertDocCount(rootResource.ID, "sharedwith:group-id", 1)
+				assertDocCount(rootResource.ID, "sharedwith:other-id", 1)
+				assertDocCount(rootResource.ID, "sharedwith:unknown-id", 0)
+				assertDocCount(rootResource.ID, "sharedwith:user-id OR sharedwith:other-id", 2)
+
+				resource, err := eng.GetDocument(parentResource.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource.SharedWith).To(ConsistOf("User-ID", "group-id"))
+			})
+
+			It("finds files by extension", func() {
+				parentResource.Document.Name = "Report.DOCX"
+				parentResource.Extension = search.Extension(parentResource.Name)
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				childResource.Document.Name = "README"
+				childResource.Extension = search.Extension(childResource.Name)
+				err = eng.Upsert(childResource.ID, childResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "ext:docx", 1)
+				assertDocCount(rootResource.ID, "ext:DOCX", 1)
+				assertDocCount(rootResource.ID, "ext:.docx", 1)
+				assertDocCount(rootResource.ID, "ext:doc", 0)
+				assertDocCount(rootResource.ID, "ext:pdf", 0)
+			})
+
+			It("finds files by extended attribute", func() {
+				parentResource.Attributes = []string{"video.codec=H264", "video.width=1920"}
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "attribute:video.codec=h264", 1)
+				assertDocCount(rootResource.ID, "attribute:video.width=1920 attribute:video.codec=H264", 1)
+				assertDocCount(rootResource.ID, "attribute:video.codec=vp9", 0)
+
+				resource, err := eng.GetDocument(parentResource.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource.Attributes).To(Equal([]string{"video.codec=H264", "video.width=1920"}))
+			})
+
+			It("filters by classification", func() {
+				parentResource.Classification = "Confidential"
+				childResource.Classification = "Strictly Confidential"
+				childResource2.Classification = "Public"
+				Expect(eng.UpsertMany(map[string]search.Resource{
+					parentResource.ID: parentResource,
+					childResource.ID:  childResource,
+					childResource2.ID: childResource2,
+				})).To(Succeed())
+
+				assertDocCount(rootResource.ID, "classification:confidential", 1)
+				assertDocCount(rootResource.ID, "classification:CONFIDENTIAL", 1)
+				assertDocCount(rootResource.ID, `classification:"strictly confidential"`, 1)
+				assertDocCount(rootResource.ID, "classification:confidential OR classification:public", 2)
+				assertDocCount(rootResource.ID, "classification:internal", 0)
+
+				resource, err := eng.GetDocument(parentResource.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource.Classification).To(Equal("Confidential"))
+			})
+
+			It("finds resources by parent id", func() {
+				for _, r := range []search.Resource{parentResource, childResource, childResource2} {
+					Expect(eng.Upsert(r.ID, r)).To(Succeed())
+				}
+
+				assertDocCount(rootResource.ID, "parentid:"+parentResource.ID, 2)
+				assertDocCount(rootResource.ID, "parentid:"+rootResource.ID, 1)
+			})
+
+			It("finds resources by type", func() {
+				childResource2.Type = uint64(sprovider.ResourceType_RESOURCE_TYPE_SYMLINK)
+				for _, r := range []search.Resource{parentResource, childResource, childResource2} {
+					Expect(eng.Upsert(r.ID, r)).To(Succeed())
+				}
+
+				assertDocCount(rootResource.ID, "type:1", 1)
+				assertDocCount(rootResource.ID, "type:2", 1)
+				matches := assertDocCount(rootResource.ID, "type:4", 1)
+				Expect(matches[0].Entity.Name).To(Equal(childResource2.Name))
+			})
+
+			It("finds only folders or only files by type name", func() {
+				for _, r := range []search.Resource{parentResource, childResource, childResource2} {
+					Expect(eng.Upsert(r.ID, r)).To(Succeed())
+				}
+
+				matches := assertDocCount(rootResource.ID, "type:folder", 1)
+				Expect(matches[0].Entity.Name).To(Equal(parentResource.Name))
+				assertDocCount(rootResource.ID, "type:file", 2)
+				assertDocCount(rootResource.ID, "type:file AND name:child2.pdf", 1)
+				assertDocCount(rootResource.ID, "type:folder AND name:child2.pdf", 0)
+				assertDocCount(rootResource.ID, "NOT type:folder", 2)
+			})
+
 			It("finds files by size", func() {
 				parentResource.Document.Size = 12345
 				err := eng.Upsert(parentResource.ID, parentResource)
@@ -165,6 +653,47 @@ var _ = Describe("Bleve", func() {
 				assertDocCount(rootResource.ID, "Hidden:F", 0)
 			})
 
+			It("filters by the boolean properties", func() {
+				childResource.Hidden = true
+				childResource.HasPreview = true
+				Expect(eng.UpsertMany(map[string]search.Resource{
+					childResource.ID:  childResource,
+					childResource2.ID: childResource2,
+				})).To(Succeed())
+
+				assertDocCount(rootResource.ID, "hidden:true", 1)
+				assertDocCount(rootResource.ID, "hidden:false", 1)
+				assertDocCount(rootResource.ID, "haspreview:true AND name:child*", 1)
+				assertDocCount(rootResource.ID, "haspreview:false AND hidden:false", 1)
+
+				_, err := doSearch(rootResource.ID, "hidden:yes", "")
+				Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+			})
+
+			It("filters by the lock, expired locks are not locked", func() {
+				parentResource.Locked = true
+				childResource.Locked = true
+				childResource.LockedBy = "einstein"
+				childResource.LockExpiration = time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+				childResource2.Locked = true
+				childResource2.LockExpiration = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339Nano)
+				Expect(eng.UpsertMany(map[string]search.Resource{
+					parentResource.ID: parentResource,
+					childResource.ID:  childResource,
+					childResource2.ID: childResource2,
+				})).To(Succeed())
+
+				assertDocCount(rootResource.ID, "locked:true", 2)
+				assertDocCount(rootResource.ID, "locked:false", 1)
+				assertDocCount(rootResource.ID, "locked:false AND name:"+childResource2.Name, 1)
+
+				indexed, err := eng.GetDocument(childResource.ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(indexed.Locked).To(BeTrue())
+				Expect(indexed.LockedBy).To(Equal("einstein"))
+				Expect(indexed.LockExpiration).ToNot(BeEmpty())
+			})
+
 			Context("with a file in the root of the space", func() {
 				It("scopes the search to the specified space", func() {
 					parentResource.Document.Name = "foo.pdf"
@@ -283,6 +812,113 @@ var _ = Describe("Bleve", func() {
 				Expect(res.Matches[0].Entity.Highlights).To(Equal("foo <mark>bar</mark> baz"))
 			})
 
+			It("skips the highlighting if requested", func() {
+				parentResource.Document.Name = "baz.pdf"
+				parentResource.Document.Content = "foo bar baz"
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+					Query:       "Content:bar",
+					NoHighlight: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(1)))
+				Expect(res.Matches[0].Entity.Highlights).To(BeEmpty())
+			})
+
+			It("omits the restricted fields from the matches", func() {
+				parentResource.Document.Name = "baz.pdf"
+				parentResource.Document.Content = "foo bar baz"
+				parentResource.Document.Tags = []string{"secret"}
+				err := eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+					Query:      "Content:bar",
+					OmitFields: []string{search.FieldContent, search.FieldTags},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(1)))
+				Expect(res.Matches[0].Entity.Name).To(Equal("baz.pdf"))
+				Expect(res.Matches[0].Entity.Highlights).To(BeEmpty())
+				Expect(res.Matches[0].Entity.Tags).To(BeEmpty())
+			})
+
+			It("returns the configured number and size of fragments", func() {
+				filler := strings.Repeat("lorem ipsum ", 10)
+				parentResource.Document.Content = "bar " + filler + "bar " + filler + "bar " + filler
+
+				highlights := func(opt bleve.BackendOption) []string {
+					eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{}, opt)
+					Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+
+					res, err := doSearch(rootResource.ID, "Content:bar", "")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches).To(HaveLen(1))
+					return strings.Split(res.Matches[0].Entity.Highlights, search.HighlightSeparator)
+				}
+
+				fragments := highlights(bleve.WithHighlights(2, 20, 0))
+				Expect(fragments).To(HaveLen(2))
+				for _, fragment := range fragments {
+					Expect(fragment).To(ContainSubstring("<mark>bar</mark>"))
+					text := strings.NewReplacer("<mark>", "", "</mark>", "", "…", "").Replace(fragment)
+					Expect(utf8.RuneCountInString(text)).To(BeNumerically("<=", 20))
+				}
+
+				Expect(highlights(bleve.WithHighlights(3, 20, 0))).To(HaveLen(3))
+				Expect(highlights(bleve.WithHighlights(3, 20, 60))).To(HaveLen(1))
+			})
+
+		})
+
+		Context("with stopwords", func() {
+			BeforeEach(func() {
+				stopwords, err := bleve.Stopwords("english", []string{"baz"})
+				Expect(err).ToNot(HaveOccurred())
+
+				mapping, err := bleve.NewMapping(bleve.WithStopwords(stopwords...))
+				Expect(err).ToNot(HaveOccurred())
+
+				idx, err = bleveSearch.NewMemOnly(mapping)
+				Expect(err).ToNot(HaveOccurred())
+
+				eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+				parentResource.Document.Content = "the foo bar baz"
+				err = eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("ignores stopwords of the language", func() {
+				assertDocCount(rootResource.ID, "Content:the", 0)
+				assertDocCount(rootResource.ID, "Content:foo", 1)
+			})
+
+			It("ignores custom stopwords", func() {
+				assertDocCount(rootResource.ID, "Content:baz", 0)
+				assertDocCount(rootResource.ID, "Content:bar", 1)
+			})
+
+			It("does not ignore stopwords without configuration", func() {
+				mapping, err := bleve.NewMapping()
+				Expect(err).ToNot(HaveOccurred())
+
+				idx, err = bleveSearch.NewMemOnly(mapping)
+				Expect(err).ToNot(HaveOccurred())
+
+				eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+				err = eng.Upsert(parentResource.ID, parentResource)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(rootResource.ID, "Content:the", 1)
+			})
+
+			It("fails for unsupported languages", func() {
+				_, err := bleve.Stopwords("klingon", nil)
+				Expect(err).To(HaveOccurred())
+			})
 		})
 
 		Context("with a file in the root of the space and folder with a file. all of them have the same name", func() {
@@ -364,8 +1000,197 @@ var _ = Describe("Bleve", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(res.TotalMatches).To(Equal(int32(1)))
 			})
+			It("only counts the matches if requested", func() {
+				for path, total := range map[string]int32{"": 3, "./doc": 2} {
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query: "Name:*doc*",
+						Ref: &searchmsg.Reference{
+							ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+							Path:       path,
+						},
+						CountOnly: true,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches).To(BeEmpty())
+					Expect(res.TotalMatches).To(Equal(total), "unexpected total for path "+path)
+				}
+			})
+			It("returns the distinct parents of the matches if requested", func() {
+				searchParents := func(path string) *searchsvc.SearchIndexResponse {
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query: "Name:*doc*",
+						Ref: &searchmsg.Reference{
+							ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+							Path:       path,
+						},
+						GroupByParent: true,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches).To(BeEmpty())
+					return res
+				}
+				parent := func(opaqueID string, matches int32) *searchsvc.ParentMatch {
+					return &searchsvc.ParentMatch{
+						ParentId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: opaqueID},
+						Matches:  matches,
+					}
+				}
+
+				res := searchParents("")
+				Expect(res.TotalMatches).To(Equal(int32(3)))
+				Expect(res.Parents).To(HaveExactElements(parent("2", 2), parent("3", 1)))
+
+				res = searchParents("./doc")
+				Expect(res.TotalMatches).To(Equal(int32(2)))
+				Expect(res.Parents).To(HaveExactElements(parent("2", 1), parent("3", 1)))
+			})
+			It("returns only the ids and scores of the matches if requested", func() {
+				searchIDs := func(path string) *searchsvc.SearchIndexResponse {
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query: "Name:*doc*",
+						Ref: &searchmsg.Reference{
+							ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+							Path:       path,
+						},
+						IdsOnly: true,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches).To(BeEmpty())
+					Expect(res.Parents).To(BeEmpty())
+					return res
+				}
+				ids := func(res *searchsvc.SearchIndexResponse) []string {
+					var ids []string
+					for _, match := range res.IdMatches {
+						Expect(match.GetScore()).To(BeNumerically(">", 0))
+						ids = append(ids, match.GetId().GetStorageId()+"$"+match.GetId().GetSpaceId()+"!"+match.GetId().GetOpaqueId())
+					}
+					return ids
+				}
+
+				res := searchIDs("")
+				Expect(res.TotalMatches).To(Equal(int32(3)))
+				Expect(ids(res)).To(ConsistOf("1$2!3", "1$2!4", "1$2!5"))
+
+				res = searchIDs("./doc")
+				Expect(res.TotalMatches).To(Equal(int32(2)))
+				Expect(ids(res)).To(ConsistOf("1$2!3", "1$2!4"))
+			})
+		})
+
+	})
+
+	Describe("SumField", func() {
+		var sumField = func(query, path string) (float64, error) {
+			rID, err := storagespace.ParseID(rootResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			return eng.SumField(context.Background(), &searchsvc.SearchIndexRequest{
+				Query: query,
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{
+						StorageId: rID.StorageId,
+						SpaceId:   rID.SpaceId,
+						OpaqueId:  rID.OpaqueId,
+					},
+					Path: path,
+				},
+			}, "Size")
+		}
+
+		BeforeEach(func() {
+			rootFile := childResource
+			rootFile.ID = "1$2!6"
+			rootFile.ParentID = rootResource.ID
+			rootFile.Path = "./root.pdf"
+			rootFile.Name = "root.pdf"
+			rootFile.Size = 1000
+			deletedFile := childResource
+			deletedFile.ID = "1$2!7"
+			deletedFile.Path = "./parent d!r/deleted.pdf"
+			deletedFile.Name = "deleted.pdf"
+			deletedFile.Size = 10000
+			deletedFile.Deleted = true
+			otherFile := childResource
+			otherFile.ID = "1$3!8"
+			otherFile.RootID = "1$3!3"
+			otherFile.Size = 100000
+			parentResource.Size = 30
+			childResource.Size = 10
+			childResource2.Size = 20
+
+			Expect(eng.UpsertMany(map[string]search.Resource{
+				parentResource.ID: parentResource,
+				childResource.ID:  childResource,
+				childResource2.ID: childResource2,
+				rootFile.ID:       rootFile,
+				deletedFile.ID:    deletedFile,
+				otherFile.ID:      otherFile,
+			})).To(Succeed())
+		})
+
+		It("sums the sizes of the matching files within the scope", func() {
+			sum, err := sumField("", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sum).To(Equal(float64(1030)))
+
+			sum, err = sumField("", "./parent d!r")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sum).To(Equal(float64(30)))
+
+			sum, err = sumField("name:child*", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sum).To(Equal(float64(30)))
+		})
+
+		It("sums up nothing if nothing matches", func() {
+			sum, err := sumField("name:missing.pdf", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sum).To(BeZero())
+		})
+
+		It("rejects an empty query without a scope", func() {
+			_, err := eng.SumField(context.Background(), &searchsvc.SearchIndexRequest{}, "Size")
+			Expect(err).To(MatchError(search.ErrEmptyQueryWithoutScope))
+		})
+	})
+
+	Describe("Compact", func() {
+		It("is not supported by indexes in memory", func() {
+			_, err := eng.IndexStats(context.Background())
+			Expect(err).To(MatchError(errtypes.NotSupported("only scorch indexes can be compacted")))
 		})
 
+		It("drops the replaced documents", func() {
+			idx, err := bleve.NewIndex(GinkgoT().TempDir())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(idx.Close)
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			for range 3 {
+				Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+				Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			}
+
+			stats, err := eng.IndexStats(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.LiveDocs).To(Equal(uint64(2)))
+
+			// the index merges the segments in the background as well, the replaced documents are dropped eventually
+			Eventually(func() search.IndexStats {
+				Expect(eng.Compact(context.Background())).To(Succeed())
+				stats, err := eng.IndexStats(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				return stats
+			}).Should(And(
+				HaveField("LiveDocs", uint64(2)),
+				HaveField("DeletedDocs", BeZero()),
+			))
+
+			res, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Name).To(Equal("child.pdf"))
+		})
 	})
 
 	Describe("Upsert", func() {
@@ -383,6 +1208,39 @@ var _ = Describe("Bleve", func() {
 			Expect(res.Hits.Len()).To(Equal(1))
 		})
 
+		It("indexes the paths in a single canonical form", func() {
+			parentResource.Path = "parent d!r/"
+			childResource.Path = "parent d!r//child.pdf"
+			childResource2.Path = "./parent d!r/child2.pdf/"
+			Expect(eng.UpsertMany(map[string]search.Resource{
+				rootResource.ID:   rootResource,
+				parentResource.ID: parentResource,
+				childResource.ID:  childResource,
+				childResource2.ID: childResource2,
+			})).To(Succeed())
+
+			r, err := eng.GetDocument(childResource2.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Path).To(Equal("./parent d!r/child2.pdf"))
+
+			for _, path := range []string{"parent d!r", "./parent d!r/", "/parent d!r"} {
+				res, err := doSearch(rootResource.ID, "Name:child*", path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.GetTotalMatches()).To(BeEquivalentTo(2), path)
+
+				res, err = eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+					Query:     "Name:child*",
+					CountOnly: true,
+					Ref: &searchmsg.Reference{
+						ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+						Path:       path,
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.GetTotalMatches()).To(BeEquivalentTo(2), path)
+			}
+		})
+
 		It("updates an existing resource in the index", func() {
 
 			err := eng.Upsert(childResource.ID, childResource)
@@ -401,6 +1259,85 @@ var _ = Describe("Bleve", func() {
 		})
 	})
 
+	Describe("UpsertMany", func() {
+		It("adds all resources to the index", func() {
+			items := make(map[string]search.Resource, 300)
+			for i := range 300 {
+				resource := childResource
+				resource.ID = fmt.Sprintf("1$2!child-%d", i)
+				resource.Path = fmt.Sprintf("./parent d!r/child-%d.pdf", i)
+				resource.Document.Name = fmt.Sprintf("child-%d.pdf", i)
+				items[resource.ID] = resource
+			}
+
+			Expect(eng.UpsertMany(items)).To(Succeed())
+
+			count, err := eng.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(300)))
+
+			resource, err := eng.GetDocument("1$2!child-123")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.Name).To(Equal("child-123.pdf"))
+		})
+
+		It("updates existing resources", func() {
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			childResource.Document.Name = "renamed.pdf"
+			Expect(eng.UpsertMany(map[string]search.Resource{
+				childResource.ID:  childResource,
+				childResource2.ID: childResource2,
+			})).To(Succeed())
+
+			count, err := eng.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(2)))
+			assertDocCount(rootResource.ID, "Name:renamed.pdf", 1)
+		})
+	})
+
+	Describe("GetDocument", func() {
+		It("returns the stored document including the flags and metadata", func() {
+			childResource.Hidden = true
+			childResource.Document.Tags = []string{"foo", "bar"}
+			err := eng.Upsert(childResource.ID, childResource)
+			Expect(err).ToNot(HaveOccurred())
+			err = eng.Delete(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			resource, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.ID).To(Equal(childResource.ID))
+			Expect(resource.ParentID).To(Equal(parentResource.ID))
+			Expect(resource.Path).To(Equal(childResource.Path))
+			Expect(resource.Name).To(Equal(childResource.Name))
+			Expect(resource.Tags).To(ConsistOf("foo", "bar"))
+			Expect(resource.Hidden).To(BeTrue())
+			Expect(resource.Deleted).To(BeTrue())
+		})
+
+		It("returns a not found error for unknown ids", func() {
+			_, err := eng.GetDocument("1$2!unknown")
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+		})
+	})
+
+	Describe("GetDocuments", func() {
+		It("returns the stored documents and leaves out the unknown ids", func() {
+			for _, r := range []search.Resource{parentResource, childResource} {
+				Expect(eng.Upsert(r.ID, r)).To(Succeed())
+			}
+
+			resources, err := eng.GetDocuments([]string{childResource.ID, "1$2!unknown", parentResource.ID})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+
+			ids := []string{resources[0].ID, resources[1].ID}
+			Expect(ids).To(ConsistOf(childResource.ID, parentResource.ID))
+		})
+	})
+
 	Describe("Delete", func() {
 		It("marks a resource as deleted", func() {
 			err := eng.Upsert(childResource.ID, childResource)
@@ -454,6 +1391,45 @@ var _ = Describe("Bleve", func() {
 		})
 	})
 
+	Describe("RestoreMany", func() {
+		It("restores overlapping trees", func() {
+			otherResource := search.Resource{
+				ID:       "1$2!6",
+				ParentID: rootResource.ID,
+				RootID:   rootResource.ID,
+				Path:     "./other.pdf",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				Document: content.Document{Name: "other.pdf"},
+			}
+
+			for _, resource := range []search.Resource{parentResource, childResource, childResource2, otherResource} {
+				err := eng.Upsert(resource.ID, resource)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			err := eng.Delete(parentResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			err = eng.Delete(otherResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			assertDocCount(rootResource.ID, `Name:*.pdf`, 0)
+
+			err = eng.RestoreMany([]string{childResource.ID, parentResource.ID, otherResource.ID, childResource.ID})
+			Expect(err).ToNot(HaveOccurred())
+
+			assertDocCount(rootResource.ID, `"`+parentResource.Name+`"`, 1)
+			assertDocCount(rootResource.ID, `Name:*.pdf`, 3)
+		})
+
+		It("fails for unknown resources", func() {
+			err := eng.Upsert(childResource.ID, childResource)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = eng.RestoreMany([]string{childResource.ID, "1$2!unknown"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("Purge", func() {
 		It("removes a resource from the index", func() {
 			err := eng.Upsert(childResource.ID, childResource)
@@ -480,6 +1456,43 @@ var _ = Describe("Bleve", func() {
 			assertDocCount(rootResource.ID, `"`+parentResource.Document.Name+`"`, 0)
 			assertDocCount(rootResource.ID, `"`+childResource.Document.Name+`"`, 0)
 		})
+		It("removes live and trashed descendants even if their path diverged", func() {
+			trashedChild := childResource2
+			trashedChild.Path = "./former parent/child2.pdf"
+			trashedChild.Deleted = true
+
+			trashedFolder := search.Resource{
+				ID:       "1$2!6",
+				ParentID: parentResource.ID,
+				RootID:   rootResource.ID,
+				Path:     "./former parent/folder",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER),
+				Deleted:  true,
+				Document: content.Document{Name: "folder"},
+			}
+			nestedResource := search.Resource{
+				ID:       "1$2!7",
+				ParentID: trashedFolder.ID,
+				RootID:   rootResource.ID,
+				Path:     "./former parent/folder/nested.pdf",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				Deleted:  true,
+				Document: content.Document{Name: "nested.pdf"},
+			}
+
+			for _, r := range []search.Resource{parentResource, childResource, trashedChild, trashedFolder, nestedResource} {
+				Expect(eng.Upsert(r.ID, r)).To(Succeed())
+			}
+			count, err := idx.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(5)))
+
+			Expect(eng.Purge(parentResource.ID, false)).To(Succeed())
+
+			count, err = idx.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeZero())
+		})
 		It("removes a resource and ignores its children from the index", func() {
 			err := eng.Upsert(parentResource.ID, parentResource)
 			Expect(err).ToNot(HaveOccurred())
@@ -500,6 +1513,118 @@ var _ = Describe("Bleve", func() {
 			assertDocCount(rootResource.ID, `"`+parentResource.Document.Name+`"`, 0)
 			assertDocCount(rootResource.ID, `"`+childResource.Document.Name+`"`, 1)
 		})
+		It("purges huge trashes in chunks", func() {
+			var batchSizes []int
+			eng = bleve.NewBackend(batchSizeIndex{bleveIndex: idx, sizes: &batchSizes}, bleveQuery.DefaultCreator, log.Logger{})
+
+			rootResource.Type = uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			resources := map[string]search.Resource{rootResource.ID: rootResource, parentResource.ID: parentResource}
+			for i := range 120 {
+				resource := childResource
+				resource.ID = fmt.Sprintf("1$2!trashed-%d", i)
+				resource.Path = fmt.Sprintf("./parent d!r/trashed-%d.pdf", i)
+				resource.Name = fmt.Sprintf("trashed-%d.pdf", i)
+				resource.Deleted = true
+				resources[resource.ID] = resource
+			}
+			Expect(eng.UpsertMany(resources)).To(Succeed())
+			batchSizes = nil
+
+			Expect(eng.Purge(rootResource.ID, true)).To(Succeed())
+
+			count, err := idx.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(2)))
+
+			Expect(len(batchSizes)).To(BeNumerically(">", 1))
+			for _, size := range batchSizes {
+				Expect(size).To(BeNumerically("<=", 50))
+			}
+		})
+	})
+
+	Describe("PurgeMany", func() {
+		It("removes overlapping trees and skips unknown resources", func() {
+			otherResource := search.Resource{
+				ID:       "1$2!6",
+				ParentID: rootResource.ID,
+				RootID:   rootResource.ID,
+				Path:     "./other.pdf",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				Document: content.Document{Name: "other.pdf"},
+			}
+			keptResource := search.Resource{
+				ID:       "1$2!7",
+				ParentID: rootResource.ID,
+				RootID:   rootResource.ID,
+				Path:     "./kept.pdf",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				Document: content.Document{Name: "kept.pdf"},
+			}
+			for _, resource := range []search.Resource{parentResource, childResource, childResource2, otherResource, keptResource} {
+				Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+			}
+
+			err := eng.PurgeMany([]string{childResource.ID, parentResource.ID, otherResource.ID, "1$2!unknown"}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			assertDocCount(rootResource.ID, `"`+parentResource.Document.Name+`"`, 0)
+			assertDocCount(rootResource.ID, `"`+childResource.Document.Name+`"`, 0)
+			assertDocCount(rootResource.ID, `"`+childResource2.Document.Name+`"`, 0)
+			assertDocCount(rootResource.ID, `"`+otherResource.Document.Name+`"`, 0)
+			assertDocCount(rootResource.ID, `"`+keptResource.Document.Name+`"`, 1)
+		})
+	})
+
+	Describe("RenameTag", func() {
+		It("renames the tag across many resources", func() {
+			resources := map[string]search.Resource{}
+			for i := range 120 {
+				resource := childResource
+				resource.ID = fmt.Sprintf("1$2!tagged-%d", i)
+				resource.Path = fmt.Sprintf("./parent d!r/tagged-%d.pdf", i)
+				resource.Name = fmt.Sprintf("tagged-%d.pdf", i)
+				resource.Tags = []string{"Draft", "report"}
+				if i%2 == 0 {
+					resource.Tags = append(resource.Tags, "final")
+				}
+				resources[resource.ID] = resource
+			}
+			Expect(eng.UpsertMany(resources)).To(Succeed())
+
+			Expect(eng.RenameTag("draft", "final", nil)).To(Succeed())
+
+			assertDocCount(rootResource.ID, "tag:draft", 0)
+			assertDocCount(rootResource.ID, "tag:final", 120)
+			for _, id := range []string{"1$2!tagged-0", "1$2!tagged-1"} {
+				resource, err := eng.GetDocument(id)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource.Tags).To(ConsistOf("final", "report"))
+			}
+		})
+
+		It("only renames the tag within the given roots", func() {
+			childResource.Tags = []string{"draft"}
+			otherResource := search.Resource{
+				ID:       "1$3!4",
+				RootID:   "1$3!3",
+				Path:     "./other.pdf",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				Document: content.Document{Name: "other.pdf", Tags: []string{"draft"}},
+			}
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			Expect(eng.Upsert(otherResource.ID, otherResource)).To(Succeed())
+
+			Expect(eng.RenameTag("draft", "final", []string{rootResource.ID})).To(Succeed())
+
+			resource, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.Tags).To(Equal([]string{"final"}))
+
+			resource, err = eng.GetDocument(otherResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.Tags).To(Equal([]string{"draft"}))
+		})
 	})
 
 	Describe("Move", func() {
@@ -521,6 +1646,19 @@ var _ = Describe("Bleve", func() {
 			Expect(matches[0].Entity.Ref.Path).To(Equal("./my/newname/child.pdf"))
 		})
 
+		It("updates the extension of renamed resources", func() {
+			childResource.Extension = search.Extension(childResource.Name)
+			err := eng.Upsert(childResource.ID, childResource)
+			Expect(err).ToNot(HaveOccurred())
+			assertDocCount(rootResource.ID, "ext:pdf", 1)
+
+			err = eng.Move(childResource.ID, childResource.ParentID, "./parent d!r/child.docx")
+			Expect(err).ToNot(HaveOccurred())
+
+			assertDocCount(rootResource.ID, "ext:pdf", 0)
+			assertDocCount(rootResource.ID, "ext:docx", 1)
+		})
+
 		It("moves the parent and its child resources", func() {
 			err := eng.Upsert(parentResource.ID, parentResource)
 			Expect(err).ToNot(HaveOccurred())
@@ -544,6 +1682,375 @@ var _ = Describe("Bleve", func() {
 			Expect(matches[0].Entity.Ref.Path).To(Equal("./somewhere/else/newname"))
 
 		})
+
+		It("moves the parent and its child resources to another space", func() {
+			Expect(eng.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			otherRootID := "1$3!3"
+			Expect(eng.Move(parentResource.ID, otherRootID, "./moved")).To(Succeed())
+
+			assertDocCount(rootResource.ID, "Name:moved", 0)
+			assertDocCount(rootResource.ID, "Name:child.pdf", 0)
+
+			matches := assertDocCount(otherRootID, "Name:moved", 1)
+			Expect(matches[0].Entity.Ref.ResourceId.SpaceId).To(Equal("3"))
+			Expect(matches[0].Entity.Ref.Path).To(Equal("./moved"))
+
+			matches = assertDocCount(otherRootID, "Name:child.pdf", 1)
+			Expect(matches[0].Entity.Ref.ResourceId.SpaceId).To(Equal("3"))
+			Expect(matches[0].Entity.Ref.Path).To(Equal("./moved/child.pdf"))
+		})
+
+		It("keeps the tree consistent for concurrent moves of the same folder", func() {
+			// widen the gap between reading and writing the resources
+			eng = bleve.NewBackend(slowIndex{idx}, bleveQuery.DefaultCreator, log.Logger{})
+			for _, resource := range []search.Resource{parentResource, childResource, childResource2} {
+				Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+			}
+
+			var wg sync.WaitGroup
+			for i := range 2 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					// start the second move while the first one is in progress
+					time.Sleep(time.Duration(i) * 30 * time.Millisecond)
+					Expect(eng.Move(parentResource.ID, parentResource.ParentID, fmt.Sprintf("./moved-%d", i))).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			matches := assertDocCount(rootResource.ID, "Name:child.pdf", 1)
+			parentPath := strings.TrimSuffix(matches[0].Entity.Ref.Path, "/child.pdf")
+			Expect(parentPath).To(HavePrefix("./moved-"))
+
+			matches = assertDocCount(rootResource.ID, "Name:child2.pdf", 1)
+			Expect(matches[0].Entity.Ref.Path).To(Equal(parentPath + "/child2.pdf"))
+
+			matches = assertDocCount(rootResource.ID, "Name:"+strings.TrimPrefix(parentPath, "./"), 1)
+			Expect(matches[0].Entity.Ref.Path).To(Equal(parentPath))
+		})
+	})
+
+	Describe("descendant limits", func() {
+		var hits, sizes []int
+
+		BeforeEach(func() {
+			hits, sizes = nil, nil
+			eng = bleve.NewBackend(batchSizeIndex{bleveIndex: hitCountIndex{bleveIndex: idx, hits: &hits}, sizes: &sizes},
+				bleveQuery.DefaultCreator, log.Logger{}, bleve.WithDescendantLimits(10, 100))
+
+			descendants := map[string]search.Resource{parentResource.ID: parentResource}
+			for i := range 100 {
+				descendant := childResource
+				descendant.ID = fmt.Sprintf("1$2!child-%d", i)
+				descendant.Path = fmt.Sprintf("./parent d!r/child-%d.pdf", i)
+				descendant.Name = fmt.Sprintf("child-%d.pdf", i)
+				descendants[descendant.ID] = descendant
+			}
+			Expect(eng.UpsertMany(descendants)).To(Succeed())
+			hits, sizes = nil, nil
+		})
+
+		It("moves the descendants of huge folders in chunks", func() {
+			Expect(eng.Move(parentResource.ID, rootResource.ID, "./moved")).To(Succeed())
+
+			Expect(hits).To(HaveEach(BeNumerically("<=", 10)))
+			// the batch is pushed whenever it is full instead of once with all descendants
+			Expect(len(sizes)).To(BeNumerically(">", 1))
+
+			matches := assertDocCount(rootResource.ID, "Name:child-*", 100)
+			for _, match := range matches {
+				Expect(match.Entity.Ref.Path).To(HavePrefix("./moved/child-"))
+			}
+			assertDocCount(rootResource.ID, "Name:moved", 1)
+		})
+
+		It("deletes and restores the descendants of huge folders in chunks", func() {
+			Expect(eng.Delete(parentResource.ID)).To(Succeed())
+			Expect(hits).To(HaveEach(BeNumerically("<=", 10)))
+			assertDocCount(rootResource.ID, "Name:child-*", 0)
+
+			Expect(eng.Restore(parentResource.ID)).To(Succeed())
+			assertDocCount(rootResource.ID, "Name:child-*", 100)
+		})
+
+		It("refuses folders with too many descendants without changing them", func() {
+			descendant := childResource
+			descendant.ID = "1$2!one-too-many"
+			Expect(eng.Upsert(descendant.ID, descendant)).To(Succeed())
+
+			Expect(eng.Delete(parentResource.ID)).To(MatchError(search.ErrTooManyDescendants))
+			Expect(eng.Move(parentResource.ID, rootResource.ID, "./moved")).To(MatchError(search.ErrTooManyDescendants))
+
+			assertDocCount(rootResource.ID, "Name:child-*", 100)
+			assertDocCount(rootResource.ID, "Name:moved", 0)
+		})
+	})
+
+	Describe("Suggest", func() {
+		refs := func(id, path string) []*searchmsg.Reference {
+			rID, err := storagespace.ParseID(id)
+			Expect(err).ToNot(HaveOccurred())
+			return []*searchmsg.Reference{{
+				ResourceId: &searchmsg.ResourceID{StorageId: rID.StorageId, SpaceId: rID.SpaceId, OpaqueId: rID.OpaqueId},
+				Path:       path,
+			}}
+		}
+
+		BeforeEach(func() {
+			// only scorch indexes look up similar terms
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err = bleveSearch.NewUsing("", mapping, scorch.Name, scorch.Name, nil)
+			Expect(err).ToNot(HaveOccurred())
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			childResource.Document.Name = "invoice.pdf"
+			childResource.Document.Content = "quarterly budget"
+			childResource.Document.Tags = []string{"finance"}
+			err = eng.Upsert(childResource.ID, childResource)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("suggests similar words of indexed names", func() {
+			suggestions, err := eng.Suggest(context.Background(), "Invoce", refs(rootResource.ID, ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(ContainElement("invoice"))
+		})
+
+		It("suggests similar indexed tags", func() {
+			suggestions, err := eng.Suggest(context.Background(), "financ", refs(rootResource.ID, ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(ContainElement("finance"))
+		})
+
+		It("does not suggest the stemmed words of indexed contents", func() {
+			suggestions, err := eng.Suggest(context.Background(), "quartrly", refs(rootResource.ID, ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).ToNot(ContainElement("quarterli"))
+		})
+
+		It("aborts the lookup once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := eng.Suggest(ctx, "invoce", refs(rootResource.ID, ""))
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("ignores resources of other roots", func() {
+			suggestions, err := eng.Suggest(context.Background(), "invoce", refs("9$8!7", ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(BeEmpty())
+		})
+
+		It("ignores resources outside of the path of the reference", func() {
+			otherResource := childResource2
+			otherResource.Document.Name = "receipt.pdf"
+			otherResource.Path = "./other/receipt.pdf"
+			Expect(eng.Upsert(otherResource.ID, otherResource)).To(Succeed())
+
+			suggestions, err := eng.Suggest(context.Background(), "reciept", refs(rootResource.ID, "./parent d!r"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(BeEmpty())
+
+			suggestions, err = eng.Suggest(context.Background(), "invoce", refs(rootResource.ID, "./parent d!r"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(ContainElement("invoice"))
+		})
+
+		It("ignores deleted resources", func() {
+			err := eng.Delete(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			suggestions, err := eng.Suggest(context.Background(), "invoce", refs(rootResource.ID, ""))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suggestions).To(BeEmpty())
+		})
+	})
+
+	Describe("concurrent writes", func() {
+		It("applies the writes of concurrent operations one after the other", func() {
+			var active, maxActive atomic.Int32
+			eng = bleve.NewBackend(concurrencyIndex{bleveIndex: idx, active: &active, max: &maxActive}, bleveQuery.DefaultCreator, log.Logger{})
+
+			var wg sync.WaitGroup
+			for i := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					resource := childResource
+					resource.ID = fmt.Sprintf("1$2!child-%d", i)
+					Expect(eng.Upsert(resource.ID, resource)).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(maxActive.Load()).To(Equal(int32(1)))
+			assertDocCount(rootResource.ID, "Name:child.pdf", 8)
+		})
+
+		It("rejects the upsert of a resource which was changed after it was read", func() {
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			fresh, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			stale, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			fresh.Tags = []string{"fresh"}
+			Expect(eng.Upsert(fresh.ID, *fresh)).To(Succeed())
+
+			stale.Tags = []string{"stale"}
+			Expect(eng.Upsert(stale.ID, *stale)).To(MatchError(search.ErrConflict))
+
+			current, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current.Tags).To(ConsistOf("fresh"))
+			Expect(current.SeqNo).To(BeNumerically(">", fresh.SeqNo))
+
+			current.Tags = []string{"retried"}
+			Expect(eng.Upsert(current.ID, *current)).To(Succeed())
+		})
+
+		It("rejects the upsert of a resource which was moved after it was read", func() {
+			for _, r := range []search.Resource{parentResource, childResource} {
+				Expect(eng.Upsert(r.ID, r)).To(Succeed())
+			}
+
+			stale, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(eng.Move(parentResource.ID, rootResource.ID, "./moved")).To(Succeed())
+			Expect(eng.Upsert(stale.ID, *stale)).To(MatchError(search.ErrConflict))
+
+			current, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current.Path).To(Equal("./moved/child.pdf"))
+		})
+
+		It("upserts resources which were not read from the index unconditionally", func() {
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			childResource.Tags = []string{"overwritten"}
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			current, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current.Tags).To(ConsistOf("overwritten"))
+		})
+
+		It("continues the sequence numbers of the index", func() {
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			previous, err := eng.GetDocument(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			Expect(eng.Upsert(childResource2.ID, childResource2)).To(Succeed())
+
+			next, err := eng.GetDocument(childResource2.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(next.SeqNo).To(BeNumerically(">", previous.SeqNo))
+		})
+	})
+
+	Describe("ResetIndex", func() {
+		It("is not supported without a root", func() {
+			_, err := eng.ResetIndex()
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotSupported("")))
+		})
+
+		It("replaces the index with a new and empty one", func() {
+			root := GinkgoT().TempDir()
+			idx, err := bleve.NewIndex(root, bleve.WithDisabledFields("location"))
+			Expect(err).ToNot(HaveOccurred())
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{}, bleve.WithIndexReset(root, bleve.WithDisabledFields("location")))
+			DeferCleanup(eng.Close)
+
+			Expect(eng.UpsertMany(map[string]search.Resource{
+				rootResource.ID:   rootResource,
+				parentResource.ID: parentResource,
+				childResource.ID:  childResource,
+			})).To(Succeed())
+
+			count, err := eng.ResetIndex()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(3)))
+
+			count, err = eng.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeZero())
+			assertDocCount(rootResource.ID, "Name:"+childResource.Name, 0)
+
+			// the new index is queryable and created with the same options
+			childResource.Document.Location = &libregraph.GeoCoordinates{
+				Latitude:  libregraph.PtrFloat64(49.48675890884328),
+				Longitude: libregraph.PtrFloat64(11.103870357204285),
+			}
+			Expect(eng.Upsert(childResource.ID, childResource)).To(Succeed())
+			matches := assertDocCount(rootResource.ID, "Name:"+childResource.Name, 1)
+			Expect(matches[0].GetEntity().GetLocation()).To(BeNil())
+		})
+	})
+
+	Describe("Close", func() {
+		var root string
+
+		BeforeEach(func() {
+			root = GinkgoT().TempDir()
+		})
+
+		// reopen simulates a restart of the service, it opens the index which was closed on shutdown
+		reopen := func() *bleve.Backend {
+			idx, err := bleve.NewIndex(root)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			DeferCleanup(eng.Close)
+			return eng
+		}
+
+		It("pushes the pending batches before the index is closed", func() {
+			idx, err := bleve.NewIndex(root)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{}, bleve.WithFlushOnClose())
+
+			pushed, err := eng.NewBatch(100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pushed.Upsert(rootResource.ID, rootResource)).To(Succeed())
+			Expect(pushed.Push()).To(Succeed())
+
+			pending, err := eng.NewBatch(100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pending.Upsert(parentResource.ID, parentResource)).To(Succeed())
+			Expect(pending.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			Expect(eng.Close()).To(Succeed())
+
+			count, err := reopen().DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(3)))
+		})
+
+		It("drops the pending batches if not enabled", func() {
+			idx, err := bleve.NewIndex(root)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			pending, err := eng.NewBatch(100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pending.Upsert(childResource.ID, childResource)).To(Succeed())
+
+			Expect(eng.Close()).To(Succeed())
+
+			count, err := reopen().DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeZero())
+		})
 	})
 
 	Describe("StartBatch", func() {
@@ -697,3 +2204,75 @@ var _ = Describe("Bleve", func() {
 		})
 	})
 })
+
+// bleveIndex allows embedding the index interface, whose Index method would otherwise clash with the field name
+type bleveIndex = bleveSearch.Index
+
+type slowIndex struct {
+	bleveIndex
+}
+
+func (i slowIndex) Search(req *bleveSearch.SearchRequest) (*bleveSearch.SearchResult, error) {
+	res, err := i.bleveIndex.Search(req)
+	time.Sleep(20 * time.Millisecond)
+	return res, err
+}
+
+// cancellingIndex cancels the request context once the search got started
+type cancellingIndex struct {
+	bleveIndex
+	cancel context.CancelFunc
+}
+
+func (i cancellingIndex) SearchInContext(ctx context.Context, req *bleveSearch.SearchRequest) (*bleveSearch.SearchResult, error) {
+	i.cancel()
+	return i.bleveIndex.SearchInContext(ctx, req)
+}
+
+// batchSizeIndex records the sizes of the applied batches
+type batchSizeIndex struct {
+	bleveIndex
+	sizes *[]int
+}
+
+func (i batchSizeIndex) Batch(b *bleveSearch.Batch) error {
+	*i.sizes = append(*i.sizes, b.Size())
+	return i.bleveIndex.Batch(b)
+}
+
+// hitCountIndex records the number of hits of the searches which load the whole documents
+type hitCountIndex struct {
+	bleveIndex
+	hits *[]int
+}
+
+func (i hitCountIndex) Search(req *bleveSearch.SearchRequest) (*bleveSearch.SearchResult, error) {
+	res, err := i.bleveIndex.Search(req)
+	if err == nil && slices.Contains(req.Fields, "*") {
+		*i.hits = append(*i.hits, len(res.Hits))
+	}
+	return res, err
+}
+
+// concurrencyIndex records the maximum number of batches which are applied at the same time
+type concurrencyIndex struct {
+	bleveIndex
+	active *atomic.Int32
+	max    *atomic.Int32
+}
+
+func (i concurrencyIndex) Batch(b *bleveSearch.Batch) error {
+	active := i.active.Add(1)
+	defer i.active.Add(-1)
+
+	for {
+		current := i.max.Load()
+		if active <= current || i.max.CompareAndSwap(current, active) {
+			break
+		}
+	}
+
+	// give concurrent writers the chance to overlap
+	time.Sleep(10 * time.Millisecond)
+	return i.bleveIndex.Batch(b)
+}
@@ -2,8 +2,10 @@ package bleve
 
 import (
 	"errors"
+	"fmt"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/blevesearch/bleve/v2"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
@@ -15,31 +17,77 @@ import (
 
 var _ search.BatchOperator = (*Batch)(nil) // ensure Batch implements BatchOperator
 
+// defaultDescendantsChunkSize is the number of descendants of a folder which are read at once while they are updated
+const defaultDescendantsChunkSize = 1000
+
 type Batch struct {
-	batch *bleve.Batch
-	index bleve.Index
-	size  int
-	log   log.Logger
+	// mu serializes the operations of the batch with the flush of the pending batches on close
+	mu     sync.Mutex
+	batch  *bleve.Batch
+	index  bleve.Index
+	writer *writer
+	size   int
+	log    log.Logger
+	// conditions holds the sequence numbers the documents changed by the batch were read with
+	conditions map[string]int64
+	// pending tracks the batch while it holds operations which are not pushed yet, it is nil if the batch isn't flushed on close
+	pending *pendingBatches
+	// descendantsChunkSize is the number of descendants of a moved, deleted or restored folder which are read at once
+	descendantsChunkSize int
+	// maxDescendants is the number of descendants above which a folder is not moved, deleted or restored, 0 disables the limit
+	maxDescendants int
+}
+
+// document is the indexed form of a resource, SeqNo is the sequence number of its latest write
+type document struct {
+	search.Resource
+	SeqNo int64
 }
 
+// NewBatch returns a batch which writes to the index on its own,
+// use Backend.NewBatch to serialize the writes with the other operations of the backend.
 func NewBatch(index bleve.Index, size int) (*Batch, error) {
+	return newBatch(index, newWriter(index), size, nil)
+}
+
+func newBatch(index bleve.Index, w *writer, size int, pending *pendingBatches) (*Batch, error) {
 	if size <= 0 {
 		return nil, errors.New("batch size must be greater than 0")
 	}
 
 	return &Batch{
-		batch: index.NewBatch(),
-		index: index,
-		size:  size,
+		batch:      index.NewBatch(),
+		index:      index,
+		writer:     w,
+		size:       size,
+		conditions: make(map[string]int64),
+		pending:    pending,
+
+		descendantsChunkSize: defaultDescendantsChunkSize,
 	}, nil
 }
 
+// Upsert indexes the resource, resources read from the index are only written if their document
+// wasn't changed in the meantime. Push fails with search.ErrConflict otherwise.
 func (b *Batch) Upsert(id string, r search.Resource) error {
 	return b.withSizeLimit(func() error {
-		return b.batch.Index(id, r)
+		return b.indexResource(id, &r)
 	})
 }
 
+// indexResource adds the resource to the batch with the next sequence number,
+// the resource is only written if its document still has the sequence number the resource was read with.
+func (b *Batch) indexResource(id string, r *search.Resource) error {
+	if _, ok := b.conditions[id]; !ok && r.HasSequence() {
+		b.conditions[id] = r.SeqNo
+	}
+
+	// the paths are indexed in a single canonical form, so the subtree of a path is matched by its prefix
+	r.Path = utils.MakeRelativePath(r.Path)
+
+	return b.batch.Index(id, document{Resource: r.WithoutSequence(), SeqNo: b.writer.nextSeqNo()})
+}
+
 func (b *Batch) Move(id, parentID, location string) error {
 	return b.withSizeLimit(func() error {
 		rootResource, err := searchResourceByID(id, b.index)
@@ -48,61 +96,61 @@ func (b *Batch) Move(id, parentID, location string) error {
 		}
 		currentPath := rootResource.Path
 		nextPath := utils.MakeRelativePath(location)
-
-		rootResource.Path = nextPath
-		rootResource.Name = path.Base(nextPath)
-		rootResource.ParentID = parentID
-
-		resources := []*search.Resource{rootResource}
-
-		if rootResource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
-			descendantResources, err := searchResourcesByPath(rootResource.RootID, currentPath, b.index)
-			if err != nil {
-				return err
-			}
-
-			for _, descendantResource := range descendantResources {
-				descendantResource.Path = strings.Replace(descendantResource.Path, currentPath, nextPath, 1)
-				resources = append(resources, descendantResource)
-			}
-		}
-
-		for _, resource := range resources {
-			if err := b.batch.Index(resource.ID, resource); err != nil {
-				return err
-			}
+		nextRootID := rootResource.RootID
+		if parentID != "" {
+			// the new parent belongs to another space if the resource was moved across spaces
+			nextRootID = rootIDOf(parentID)
 		}
 
-		return nil
+		return b.updateSubtree(rootResource, func(resource *search.Resource) {
+			resource.Path = nextPath
+			resource.Name = path.Base(nextPath)
+			resource.Extension = search.Extension(resource.Name)
+			resource.ParentID = parentID
+			resource.RootID = nextRootID
+		}, func(descendant *search.Resource) {
+			descendant.Path = strings.Replace(descendant.Path, currentPath, nextPath, 1)
+			descendant.RootID = nextRootID
+		})
 	})
 }
 
 func (b *Batch) Delete(id string) error {
 	return b.withSizeLimit(func() error {
-		affectedResources, err := searchAndUpdateResourcesDeletionState(id, true, b.index)
+		rootResource, err := searchResourceByID(id, b.index)
 		if err != nil {
 			return err
 		}
 
-		for _, resource := range affectedResources {
-			if err := b.batch.Index(resource.ID, resource); err != nil {
-				return err
-			}
-		}
-
-		return nil
+		return b.updateDeletionState(rootResource, true)
 	})
 }
 
 func (b *Batch) Restore(id string) error {
 	return b.withSizeLimit(func() error {
-		affectedResources, err := searchAndUpdateResourcesDeletionState(id, false, b.index)
+		rootResource, err := searchResourceByID(id, b.index)
 		if err != nil {
 			return err
 		}
 
-		for _, resource := range affectedResources {
-			if err := b.batch.Index(resource.ID, resource); err != nil {
+		return b.updateDeletionState(rootResource, false)
+	})
+}
+
+func (b *Batch) RestoreMany(ids []string) error {
+	return b.withSizeLimit(func() error {
+		rootResources := make([]*search.Resource, 0, len(ids))
+		for _, id := range ids {
+			rootResource, err := searchResourceByID(id, b.index)
+			if err != nil {
+				return err
+			}
+			rootResources = append(rootResources, rootResource)
+		}
+
+		// descendants of nested roots are already covered by their top level root
+		for _, rootResource := range search.TopLevelResources(rootResources) {
+			if err := b.updateDeletionState(rootResource, false); err != nil {
 				return err
 			}
 		}
@@ -111,6 +159,58 @@ func (b *Batch) Restore(id string) error {
 	})
 }
 
+// updateDeletionState marks the resource and its descendants as deleted or restores them
+func (b *Batch) updateDeletionState(resource *search.Resource, deleted bool) error {
+	setDeleted := func(r *search.Resource) {
+		r.Deleted = deleted
+	}
+
+	return b.updateSubtree(resource, setDeleted, setDeleted)
+}
+
+// updateSubtree applies the updates to the resource and its descendants and adds them to the batch.
+// The descendants are read in chunks and the batch is pushed whenever it reaches its size, so the descendants
+// of huge folders are never loaded at once. search.ErrTooManyDescendants is returned before anything is changed
+// if the folder has more descendants than allowed.
+// The resource itself is added last, an operation which is repeated after a conflict still finds the descendants
+// which were not updated yet by the path of the resource.
+func (b *Batch) updateSubtree(resource *search.Resource, updateSelf, updateDescendant func(*search.Resource)) error {
+	if resource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		if b.maxDescendants > 0 {
+			count, err := countResourcesByPath(resource.RootID, resource.Path, b.index)
+			if err != nil {
+				return err
+			}
+			if count > uint64(b.maxDescendants) {
+				return fmt.Errorf("%w: %s has %d descendants, at most %d are updated", search.ErrTooManyDescendants, resource.ID, count, b.maxDescendants)
+			}
+		}
+
+		err := forEachResourceByPath(resource.RootID, resource.Path, b.index, b.descendantsChunkSize, func(descendants []*search.Resource) error {
+			for _, descendant := range descendants {
+				updateDescendant(descendant)
+				if err := b.indexResource(descendant.ID, descendant); err != nil {
+					return err
+				}
+
+				if b.batch.Size() >= b.size {
+					if err := b.push(); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	updateSelf(resource)
+	return b.indexResource(resource.ID, resource)
+}
+
 func (b *Batch) Purge(id string, onlyDeleted bool) error {
 	return b.withSizeLimit(func() error {
 		rootResource, err := searchResourceByID(id, b.index)
@@ -130,7 +230,7 @@ func (b *Batch) Purge(id string, onlyDeleted bool) error {
 		add(rootResource)
 
 		if rootResource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
-			descendantResources, err := searchResourcesByPath(rootResource.RootID, rootResource.Path, b.index)
+			descendantResources, err := searchDescendants(rootResource, b.index)
 			if err != nil {
 				return err
 			}
@@ -140,36 +240,161 @@ func (b *Batch) Purge(id string, onlyDeleted bool) error {
 			}
 		}
 
-		for _, resource := range affectResources {
-			b.batch.Delete(resource.ID)
+		return b.deleteInChunks(affectResources)
+	})
+}
+
+// PurgeMany removes the resources and their descendants from the index,
+// resources which are not part of the index are skipped.
+func (b *Batch) PurgeMany(ids []string, onlyDeleted bool) error {
+	return b.withSizeLimit(func() error {
+		rootResources, err := searchResourcesByIDs(ids, b.index)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		var affectResources []*search.Resource
+		add := func(resource *search.Resource) {
+			if onlyDeleted && !resource.Deleted {
+				return
+			}
+
+			affectResources = append(affectResources, resource)
+		}
+
+		// descendants of nested roots are already covered by their top level root
+		for _, rootResource := range search.TopLevelResources(rootResources) {
+			add(rootResource)
+
+			if rootResource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+				descendantResources, err := searchDescendants(rootResource, b.index)
+				if err != nil {
+					return err
+				}
+
+				for _, descendantResource := range descendantResources {
+					add(descendantResource)
+				}
+			}
+		}
+
+		return b.deleteInChunks(affectResources)
 	})
 }
 
+// deleteInChunks deletes the resources and pushes the batch whenever it reaches its size,
+// so purging huge trees doesn't pile up all deletes in a single batch.
+func (b *Batch) deleteInChunks(resources []*search.Resource) error {
+	for _, resource := range resources {
+		b.batch.Delete(resource.ID)
+
+		if b.batch.Size() >= b.size {
+			if err := b.push(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Push writes the operations of the batch to the index. The batch is discarded and search.ErrConflict is returned
+// if a resource it changes was written by another operation after it was read, the changes have to be made again with fresh data.
 func (b *Batch) Push() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.push()
+}
+
+func (b *Batch) push() error {
 	if b.batch.Size() == 0 {
 		return nil
 	}
 
-	if err := b.index.Batch(b.batch); err != nil {
+	err := b.writer.Batch(b.batch, b.conditions)
+	if err != nil && !errors.Is(err, search.ErrConflict) {
 		return err
 	}
 
-	b.batch.Reset()
+	b.reset()
 
-	return nil
+	return err
+}
+
+// reset empties the batch
+func (b *Batch) reset() {
+	b.batch.Reset()
+	clear(b.conditions)
+	b.pending.remove(b)
 }
 
 func (b *Batch) withSizeLimit(f func() error) error {
-	if err := f(); err != nil {
-		return err
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := f()
+	if err == nil && b.batch.Size() >= b.size {
+		err = b.push()
 	}
 
-	if b.batch.Size() >= b.size {
-		return b.Push()
+	if b.batch.Size() > 0 {
+		b.pending.add(b)
 	}
 
-	return nil
+	return err
+}
+
+// pendingBatches tracks the batches of a backend which hold operations that are not pushed yet,
+// so they can be flushed before the index is closed.
+type pendingBatches struct {
+	mu      sync.Mutex
+	batches map[*Batch]struct{}
+}
+
+func newPendingBatches() *pendingBatches {
+	return &pendingBatches{batches: make(map[*Batch]struct{})}
+}
+
+func (p *pendingBatches) add(b *Batch) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches[b] = struct{}{}
+}
+
+func (p *pendingBatches) remove(b *Batch) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.batches, b)
+}
+
+// flush pushes all pending batches, it waits for the operations which are currently added to them.
+func (p *pendingBatches) flush() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	batches := make([]*Batch, 0, len(p.batches))
+	for b := range p.batches {
+		batches = append(batches, b)
+	}
+	p.mu.Unlock()
+
+	var errs []error
+	for _, b := range batches {
+		if err := b.Push(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
@@ -1,14 +1,21 @@
 package bleve
 
 import (
+	"context"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/blevesearch/bleve/v2"
 	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	bleveIndex "github.com/blevesearch/bleve_index_api"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
@@ -36,6 +43,23 @@ func resourceIDtoSearchID(id storageProvider.ResourceId) *searchMessage.Resource
 		OpaqueId:  id.GetOpaqueId()}
 }
 
+// getVersionsValue returns the stored versions, bleve stores the fields of the versions as separate slices
+func getVersionsValue(m map[string]interface{}) []search.Version {
+	keys := getFieldSliceValue[string](m, "Versions.Key")
+	mtimes := getFieldSliceValue[string](m, "Versions.Mtime")
+	contents := getFieldSliceValue[string](m, "Versions.Content")
+	if len(keys) == 0 || len(keys) != len(mtimes) || len(keys) != len(contents) {
+		return nil
+	}
+
+	versions := make([]search.Version, 0, len(keys))
+	for i, key := range keys {
+		versions = append(versions, search.Version{Key: key, Mtime: mtimes[i], Content: contents[i]})
+	}
+
+	return versions
+}
+
 func getFieldSliceValue[T any](m map[string]interface{}, key string) (out []T) {
 	iv := getFieldValue[interface{}](m, key)
 	add := func(v interface{}) {
@@ -62,19 +86,6 @@ func getFieldSliceValue[T any](m map[string]interface{}, key string) (out []T) {
 	return
 }
 
-func getFragmentValue(m bleveSearch.FieldFragmentMap, key string, idx int) string {
-	val, ok := m[key]
-	if !ok {
-		return ""
-	}
-
-	if len(val) <= idx {
-		return ""
-	}
-
-	return val[idx]
-}
-
 func getAudioValue[T any](fields map[string]interface{}) *T {
 	if !strings.HasPrefix(getFieldValue[string](fields, "MimeType"), "audio/") {
 		return nil
@@ -178,13 +189,29 @@ func getFieldName(structField reflect.StructField) string {
 }
 
 func matchToResource(match *bleveSearch.DocumentMatch) *search.Resource {
-	return &search.Resource{
-		ID:       getFieldValue[string](match.Fields, "ID"),
-		RootID:   getFieldValue[string](match.Fields, "RootID"),
-		Path:     getFieldValue[string](match.Fields, "Path"),
-		ParentID: getFieldValue[string](match.Fields, "ParentID"),
-		Type:     uint64(getFieldValue[float64](match.Fields, "Type")),
-		Deleted:  getFieldValue[bool](match.Fields, "Deleted"),
+	r := &search.Resource{
+		ID:             getFieldValue[string](match.Fields, "ID"),
+		RootID:         getFieldValue[string](match.Fields, "RootID"),
+		Path:           getFieldValue[string](match.Fields, "Path"),
+		ParentID:       getFieldValue[string](match.Fields, "ParentID"),
+		Type:           uint64(getFieldValue[float64](match.Fields, "Type")),
+		Deleted:        getFieldValue[bool](match.Fields, "Deleted"),
+		Hidden:         getFieldValue[bool](match.Fields, "Hidden"),
+		Truncated:      getFieldValue[bool](match.Fields, "Truncated"),
+		Owner:          getFieldValue[string](match.Fields, "Owner"),
+		CreatedBy:      getFieldValue[string](match.Fields, "CreatedBy"),
+		Extension:      getFieldValue[string](match.Fields, "Extension"),
+		HasPreview:     getFieldValue[bool](match.Fields, "HasPreview"),
+		Locked:         getFieldValue[bool](match.Fields, "Locked"),
+		LockedBy:       getFieldValue[string](match.Fields, "LockedBy"),
+		LockExpiration: getFieldValue[string](match.Fields, "LockExpiration"),
+		SharedWith:     getFieldSliceValue[string](match.Fields, "SharedWith"),
+		Metadata:       getFieldSliceValue[string](match.Fields, "Metadata"),
+		Attributes:     getFieldSliceValue[string](match.Fields, "Attributes"),
+		Classification: getFieldValue[string](match.Fields, "Classification"),
+		SpaceName:      getFieldValue[string](match.Fields, "SpaceName"),
+		Versions:       getVersionsValue(match.Fields),
+		LastEventTS:    getFieldValue[string](match.Fields, "LastEventTS"),
 		Document: content.Document{
 			Name:     getFieldValue[string](match.Fields, "Name"),
 			Title:    getFieldValue[string](match.Fields, "Title"),
@@ -199,8 +226,136 @@ func matchToResource(match *bleveSearch.DocumentMatch) *search.Resource {
 			Photo:    getPhotoValue[libregraph.Photo](match.Fields),
 		},
 	}
+
+	// documents written before the sequence numbers were introduced have none, they are overwritten unconditionally
+	if seqNo := int64(getFieldValue[float64](match.Fields, "SeqNo")); seqNo > 0 {
+		// bleve has no primary terms, all sequence numbers belong to the same one
+		r.SeqNo, r.PrimaryTerm = seqNo, 1
+	}
+
+	return r
 }
 
 func escapeQuery(s string) string {
 	return queryEscape.ReplaceAllString(s, "\\$1")
 }
+
+// suggestionFields are the fields whose terms are suggested, Name.words holds the words of the names.
+// The terms of the contents are stemmed, they are no words which could be suggested.
+var suggestionFields = []string{"Name.words", "Tags"}
+
+// maxSuggestionCandidates limits the number of similar terms which are ranked for the suggestions
+const maxSuggestionCandidates = 100
+
+// similarTerms returns the terms of the fields which are within the maximum suggestion distance of the term with
+// their number of documents. The term dictionaries are only walked along the terms within the distance,
+// at most maxSuggestionCandidates terms are collected.
+func similarTerms(ctx context.Context, index bleve.Index, term string, fields []string) (map[string]int, error) {
+	advanced, err := index.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := advanced.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	fuzzyReader, ok := reader.(bleveIndex.IndexReaderFuzzy)
+	if !ok {
+		return nil, errtypes.NotSupported("only scorch indexes can look up similar terms")
+	}
+
+	// the terms of all suggestion fields are lowercase
+	term = strings.ToLower(term)
+	candidates := make(map[string]int)
+	for _, field := range fields {
+		if err := collectSimilarTerms(ctx, fuzzyReader, field, term, candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	return candidates, nil
+}
+
+// collectSimilarTerms adds the terms of the field which are similar to the term to the candidates
+func collectSimilarTerms(ctx context.Context, reader bleveIndex.IndexReaderFuzzy, field, term string, candidates map[string]int) error {
+	dict, err := reader.FieldDictFuzzy(field, term, search.MaxSuggestionDistance, "")
+	if err != nil {
+		return err
+	}
+	defer dict.Close()
+
+	for len(candidates) < maxSuggestionCandidates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry, err := dict.Next()
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return nil
+		}
+
+		candidates[entry.Term] += int(entry.Count)
+	}
+
+	return nil
+}
+
+// hasWordMatch checks if any not deleted resource within the given references contains the word in its name or tags.
+func hasWordMatch(ctx context.Context, index bleve.Index, word string, refs []*searchMessage.Reference) (bool, error) {
+	fields := bleve.NewDisjunctionQuery()
+	for _, field := range suggestionFields {
+		fieldQuery := bleve.NewTermQuery(word)
+		fieldQuery.SetField(field)
+		fields.AddQuery(fieldQuery)
+	}
+
+	q := bleve.NewConjunctionQuery(
+		&query.BoolFieldQuery{Bool: false, FieldVal: "Deleted"},
+		fields,
+	)
+
+	if len(refs) > 0 {
+		scopes := bleve.NewDisjunctionQuery()
+		for _, ref := range refs {
+			scopes.AddQuery(referenceQuery(ref))
+		}
+		q.AddQuery(scopes)
+	}
+
+	req := bleve.NewSearchRequest(q)
+	req.Size = 0
+	res, err := index.SearchInContext(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Total > 0, nil
+}
+
+// referenceQuery matches the resources within the root of the reference and the subtree of its path
+func referenceQuery(ref *searchMessage.Reference) query.Query {
+	rootQuery := bleve.NewTermQuery(storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: ref.GetResourceId().GetStorageId(),
+		SpaceId:   ref.GetResourceId().GetSpaceId(),
+		OpaqueId:  ref.GetResourceId().GetOpaqueId(),
+	}))
+	rootQuery.SetField("RootID")
+
+	requestedPath := utils.MakeRelativePath(ref.GetPath())
+	if requestedPath == "." {
+		return rootQuery
+	}
+
+	return bleve.NewConjunctionQuery(
+		rootQuery,
+		bleve.NewDisjunctionQuery(
+			&query.TermQuery{FieldVal: "Path", Term: requestedPath},
+			&query.PrefixQuery{FieldVal: "Path", Prefix: requestedPath + "/"},
+		),
+	)
+}
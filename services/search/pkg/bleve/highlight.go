@@ -0,0 +1,77 @@
+package bleve
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	htmlFormatter "github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	simpleFragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// fragmentsHighlighter is the highlighter type which returns a configured number of fragments of a configured size,
+// bleve itself only asks the highlighters for the best fragment of a field.
+const fragmentsHighlighter = "opencloudFragments"
+
+// highlighter marks the matches with the html formatter like the default highlighter of bleve,
+// but returns up to the given number of fragments.
+type highlighter struct {
+	*simpleHighlighter.Highlighter
+	fragments int
+}
+
+func (h *highlighter) BestFragmentsInField(dm *search.DocumentMatch, doc index.Document, field string, _ int) []string {
+	return h.Highlighter.BestFragmentsInField(dm, doc, field, h.fragments)
+}
+
+func init() {
+	err := registry.RegisterHighlighter(fragmentsHighlighter, func(config map[string]interface{}, cache *registry.Cache) (highlight.Highlighter, error) {
+		fragments, ok := config["fragments"].(int)
+		if !ok || fragments < 1 {
+			return nil, fmt.Errorf("must specify a positive number of fragments")
+		}
+
+		fragmentSize, ok := config["fragment_size"].(int)
+		if !ok || fragmentSize < 1 {
+			return nil, fmt.Errorf("must specify a positive fragment size")
+		}
+
+		formatter, err := cache.FragmentFormatterNamed(htmlFormatter.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error building fragment formatter: %w", err)
+		}
+
+		return &highlighter{
+			Highlighter: simpleHighlighter.NewHighlighter(
+				simpleFragmenter.NewFragmenter(fragmentSize),
+				formatter,
+				simpleHighlighter.DefaultSeparator,
+			),
+			fragments: fragments,
+		}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// defineHighlighter defines a highlighter returning the given number of fragments with the given size in characters
+// and returns its name. Highlighters are kept in the global bleve cache, so backends with the same settings share one.
+func defineHighlighter(fragments, fragmentSize int) (string, error) {
+	name := fmt.Sprintf("%s-%d-%d", fragmentsHighlighter, fragments, fragmentSize)
+	_, err := bleve.Config.Cache.DefineHighlighter(name, map[string]interface{}{
+		"type":          fragmentsHighlighter,
+		"fragments":     fragments,
+		"fragment_size": fragmentSize,
+	})
+	if err != nil && !errors.Is(err, registry.ErrAlreadyDefined) {
+		return "", err
+	}
+
+	return name, nil
+}
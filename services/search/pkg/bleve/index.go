@@ -2,45 +2,425 @@ package bleve
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"math"
+	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/v2/analysis/token/porter"
+	"github.com/blevesearch/bleve/v2/analysis/token/stop"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/query"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	bolterrors "go.etcd.io/bbolt/errors"
 
+	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
-func NewIndex(root string) (bleve.Index, error) {
-	destination := filepath.Join(root, "bleve")
-	index, err := bleve.Open(destination)
-	if errors.Is(bleve.ErrorIndexPathDoesNotExist, err) {
-		indexMapping, err := NewMapping()
-		if err != nil {
-			return nil, err
+// stopwordsTokenMap is the token map type holding the configured stopwords,
+// it is persisted with the index mapping and therefore needs to be registered before an index is opened.
+const stopwordsTokenMap = "opencloudStopwords"
+
+// transliterationTokenFilter is the token filter type which transliterates cyrillic and greek letters to latin,
+// it is persisted with the index mapping and therefore needs to be registered before an index is opened.
+const transliterationTokenFilter = "opencloudTransliteration"
+
+// transliterationFilter transliterates the terms of the tokens to latin
+type transliterationFilter struct{}
+
+func (transliterationFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	for _, token := range input {
+		token.Term = []byte(search.Transliterate(string(token.Term)))
+	}
+	return input
+}
+
+// phoneticTokenFilter is the token filter type which replaces the words of the terms by their soundex codes,
+// it is persisted with the index mapping and therefore needs to be registered before an index is opened.
+const phoneticTokenFilter = "opencloudPhonetic"
+
+// phoneticFilter replaces the tokens by one token per soundex code of the words of their terms
+type phoneticFilter struct{}
+
+func (phoneticFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		for _, code := range search.PhoneticCodes(string(token.Term)) {
+			output = append(output, &analysis.Token{
+				Term:     []byte(code),
+				Start:    token.Start,
+				End:      token.End,
+				Position: len(output) + 1,
+				Type:     analysis.AlphaNumeric,
+			})
 		}
-		index, err = bleve.New(destination, indexMapping)
-		if err != nil {
+	}
+	return output
+}
+
+// wordsTokenFilter is the token filter type which splits the terms into their words of letters and numbers,
+// it is persisted with the index mapping and therefore needs to be registered before an index is opened.
+const wordsTokenFilter = "opencloudWords"
+
+// wordsFilter replaces the tokens by one token per word of their terms
+type wordsFilter struct{}
+
+func (wordsFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		for _, word := range search.SuggestionWords(string(token.Term)) {
+			output = append(output, &analysis.Token{
+				Term:     []byte(word),
+				Start:    token.Start,
+				End:      token.End,
+				Position: len(output) + 1,
+				Type:     analysis.AlphaNumeric,
+			})
+		}
+	}
+	return output
+}
+
+func init() {
+	err := registry.RegisterTokenFilter(transliterationTokenFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return transliterationFilter{}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = registry.RegisterTokenFilter(phoneticTokenFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return phoneticFilter{}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = registry.RegisterTokenFilter(wordsTokenFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return wordsFilter{}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	err = registry.RegisterTokenMap(stopwordsTokenMap, func(config map[string]interface{}, _ *registry.Cache) (analysis.TokenMap, error) {
+		tokens, ok := config["tokens"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("must specify tokens")
+		}
+
+		tokenMap := analysis.NewTokenMap()
+		for _, token := range tokens {
+			word, ok := token.(string)
+			if !ok {
+				return nil, fmt.Errorf("token must be a string, got %T", token)
+			}
+			tokenMap.AddToken(word)
+		}
+
+		return tokenMap, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Stopwords returns the built-in stopwords of the given language merged with the given words.
+// An empty language only returns the given words, 'english' is the only supported language.
+func Stopwords(language string, words []string) ([]string, error) {
+	tokenMap := analysis.NewTokenMap()
+	switch strings.ToLower(language) {
+	case "":
+	case "english":
+		if err := tokenMap.LoadBytes(en.EnglishStopWords); err != nil {
 			return nil, err
 		}
+	default:
+		return nil, fmt.Errorf("unsupported stopword language: %s", language)
+	}
+
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			tokenMap.AddToken(word)
+		}
+	}
+
+	stopwords := make([]string, 0, len(tokenMap))
+	for word := range tokenMap {
+		stopwords = append(stopwords, word)
+	}
+
+	return stopwords, nil
+}
+
+const (
+	// CorruptionPolicyFail refuses to start with a corrupt index
+	CorruptionPolicyFail = "fail"
+	// CorruptionPolicyRecreate moves a corrupt index aside and creates a new one
+	CorruptionPolicyRecreate = "recreate"
+)
+
+// ErrIndexCorrupt is returned if an existing index can not be opened
+var ErrIndexCorrupt = errors.New("bleve index is corrupt")
+
+// ErrIndexNotWritable is returned if the directory of the index can not be created or written to
+var ErrIndexNotWritable = errors.New("bleve index directory is not writable")
+
+// IndexOption configures how the index is opened or created
+type IndexOption func(o *indexOptions)
+
+type indexOptions struct {
+	stopwords        []string
+	transliteration  bool
+	phonetic         bool
+	disabledFields   []string
+	corruptionPolicy string
+	onRecreate       func()
+	logger           log.Logger
+}
+
+// WithStopwords sets the stopwords of newly created indexes,
+// existing indexes keep the stopwords they were created with.
+func WithStopwords(stopwords ...string) IndexOption {
+	return func(o *indexOptions) {
+		o.stopwords = stopwords
+	}
+}
+
+// WithTransliteration adds the Name.translit field to newly created indexes, which holds the name
+// with cyrillic and greek letters transliterated to latin. Existing indexes keep the fields they were created with.
+func WithTransliteration(enabled bool) IndexOption {
+	return func(o *indexOptions) {
+		o.transliteration = enabled
+	}
+}
+
+// WithPhonetic adds the Name.phonetic field to newly created indexes, which holds the soundex codes
+// of the words of the name. Existing indexes keep the fields they were created with.
+func WithPhonetic(enabled bool) IndexOption {
+	return func(o *indexOptions) {
+		o.phonetic = enabled
+	}
+}
+
+// WithDisabledFields leaves the given optional metadata fields like 'location' out of newly created indexes,
+// existing indexes keep the fields they were created with.
+func WithDisabledFields(fields ...string) IndexOption {
+	return func(o *indexOptions) {
+		o.disabledFields = fields
+	}
+}
 
+// WithCorruptionPolicy defines how to handle an existing index which can not be opened,
+// onRecreate is called after a corrupt index got replaced by a new and therefore empty one.
+func WithCorruptionPolicy(policy string, onRecreate func()) IndexOption {
+	return func(o *indexOptions) {
+		o.corruptionPolicy = policy
+		o.onRecreate = onRecreate
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger log.Logger) IndexOption {
+	return func(o *indexOptions) {
+		o.logger = logger
+	}
+}
+
+// NewIndex opens the index in the given root or creates it if it does not exist yet.
+func NewIndex(root string, opts ...IndexOption) (bleve.Index, error) {
+	options := indexOptions{
+		corruptionPolicy: CorruptionPolicyFail,
+		logger:           log.NopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	destination := filepath.Join(root, "bleve")
+	if err := ensureWritable(root, destination); err != nil {
+		return nil, err
+	}
+
+	index, err := bleve.Open(destination)
+	switch {
+	case err == nil:
 		return index, nil
+	case errors.Is(err, bleve.ErrorIndexPathDoesNotExist):
+		return createIndex(destination, options)
+	case !isCorruptIndexError(destination, err):
+		// like a full disk or too many open files, the index itself is intact
+		return nil, err
+	}
+
+	if options.corruptionPolicy != CorruptionPolicyRecreate {
+		options.logger.Error().Err(err).Str("path", destination).
+			Msg("the search index is corrupt, move it aside and re-index all spaces or set SEARCH_ENGINE_BLEVE_CORRUPTION_POLICY=recreate")
+		return nil, fmt.Errorf(
+			"%w, failed to open %s: %w, stop the service, move the directory aside and re-index all spaces with 'opencloud search index --all-spaces' or set SEARCH_ENGINE_BLEVE_CORRUPTION_POLICY=recreate",
+			ErrIndexCorrupt, destination, err,
+		)
+	}
+
+	corruptDestination := destination + ".corrupt-" + time.Now().UTC().Format("20060102T150405")
+	if err := os.Rename(destination, corruptDestination); err != nil {
+		return nil, fmt.Errorf("%w, failed to move %s aside: %w", ErrIndexCorrupt, destination, err)
+	}
+	options.logger.Error().Err(err).Str("path", destination).Str("corruptPath", corruptDestination).
+		Msg("the search index is corrupt, moved it aside and created a new index, all spaces will be re-indexed")
+
+	index, err = createIndex(destination, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.onRecreate != nil {
+		options.onRecreate()
+	}
+
+	return index, nil
+}
+
+// corruptSegmentErrors are the messages of the errors scorch reports for damaged snapshots and segments,
+// it does not wrap the errors of their causes
+var corruptSegmentErrors = []string{
+	"bucket missing",
+	"segment path missing",
+	"failed to decode segment id",
+	"failed to load segment",
+	"error opening bolt segment",
+	"unable to load correct segment wrapper",
+}
+
+// systemErrors are the errors of the system which prevent opening an intact index, like a full disk
+var systemErrors = []syscall.Errno{
+	syscall.ENOSPC, syscall.EMFILE, syscall.ENFILE, syscall.ENOMEM, syscall.EACCES, syscall.EPERM,
+	syscall.EAGAIN, syscall.EIO, syscall.EROFS, syscall.EDQUOT,
+}
+
+// isCorruptIndexError reports whether opening the index at the given path failed because the index is corrupt,
+// like an invalid meta file or a damaged bolt database. All other errors, like a full disk, too many open files
+// or a lock held by another process, leave the index intact and must not get it recreated.
+func isCorruptIndexError(path string, err error) bool {
+	var errno syscall.Errno
+	switch {
+	case errors.Is(err, bleve.ErrorIndexMetaCorrupt),
+		errors.Is(err, bleve.ErrorUnknownIndexType),
+		errors.Is(err, bolterrors.ErrInvalid),
+		errors.Is(err, bolterrors.ErrInvalidMapping),
+		errors.Is(err, bolterrors.ErrVersionMismatch),
+		errors.Is(err, bolterrors.ErrChecksum):
+		return true
+	case errors.Is(err, bleve.ErrorIndexMetaMissing):
+		// the meta file is reported as missing if it can't be read for any reason
+		_, statErr := os.Stat(filepath.Join(path, "index_meta.json"))
+		return errors.Is(statErr, fs.ErrNotExist)
+	case errors.As(err, &errno):
+		return false
 	}
 
-	return index, err
+	msg := err.Error()
+	for _, systemErr := range systemErrors {
+		if strings.Contains(msg, systemErr.Error()) {
+			return false
+		}
+	}
+	for _, corruptErr := range corruptSegmentErrors {
+		if strings.Contains(msg, corruptErr) {
+			return true
+		}
+	}
+	return false
 }
 
-func NewMapping() (mapping.IndexMapping, error) {
+// ensureWritable creates the root directory if it does not exist yet and checks that new files can be written
+// to the index directory, or to the root directory if the index does not exist yet. Without the check a missing
+// or foreign-owned root, which is common for freshly mounted volumes, only surfaces as a cryptic error of bleve.
+func ensureWritable(root, destination string) error {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return fmt.Errorf("%w, failed to create %s: %w, create the directory and make it writable for the user running the service (uid %d)",
+			ErrIndexNotWritable, root, err, os.Getuid())
+	}
+
+	dir := destination
+	if _, err := os.Stat(destination); errors.Is(err, fs.ErrNotExist) {
+		dir = root
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%w, failed to write to %s: %w, make the directory writable for the user running the service (uid %d)",
+			ErrIndexNotWritable, dir, err, os.Getuid())
+	}
+	_ = probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+// resetIndex closes the given index and replaces it with a new and empty one in the given root.
+func resetIndex(index bleve.Index, root string, opts ...IndexOption) (bleve.Index, error) {
+	options := indexOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := index.Close(); err != nil && !errors.Is(err, bleve.ErrorIndexClosed) {
+		return nil, err
+	}
+
+	destination := filepath.Join(root, "bleve")
+	if err := os.RemoveAll(destination); err != nil {
+		return nil, err
+	}
+
+	return createIndex(destination, options)
+}
+
+func createIndex(destination string, options indexOptions) (bleve.Index, error) {
+	indexMapping, err := newMapping(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return bleve.New(destination, indexMapping)
+}
+
+// NewMapping returns the index mapping, only the stopwords, the transliteration, the phonetic and the disabled fields options are applied.
+func NewMapping(opts ...IndexOption) (mapping.IndexMapping, error) {
+	options := indexOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return newMapping(options)
+}
+
+func newMapping(options indexOptions) (mapping.IndexMapping, error) {
 	nameMapping := bleve.NewTextFieldMapping()
 	nameMapping.Analyzer = "lowercaseKeyword"
 
+	// the words of the names are looked up for the suggestions
+	wordsMapping := bleve.NewTextFieldMapping()
+	wordsMapping.Name = "Name.words"
+	wordsMapping.Analyzer = "words"
+	wordsMapping.Store = false
+	wordsMapping.IncludeInAll = false
+
 	lowercaseMapping := bleve.NewTextFieldMapping()
 	lowercaseMapping.IncludeInAll = false
 	lowercaseMapping.Analyzer = "lowercaseKeyword"
@@ -49,10 +429,44 @@ func NewMapping() (mapping.IndexMapping, error) {
 	fulltextFieldMapping.Analyzer = "fulltext"
 	fulltextFieldMapping.IncludeInAll = false
 
+	nameMappings := []*mapping.FieldMapping{nameMapping, wordsMapping}
+	if options.transliteration {
+		translitMapping := bleve.NewTextFieldMapping()
+		translitMapping.Name = "Name.translit"
+		translitMapping.Analyzer = "transliteratedKeyword"
+		translitMapping.Store = false
+		translitMapping.IncludeInAll = false
+		nameMappings = append(nameMappings, translitMapping)
+	}
+	if options.phonetic {
+		phoneticMapping := bleve.NewTextFieldMapping()
+		phoneticMapping.Name = "Name.phonetic"
+		phoneticMapping.Analyzer = "phonetic"
+		phoneticMapping.Store = false
+		phoneticMapping.IncludeInAll = false
+		nameMappings = append(nameMappings, phoneticMapping)
+	}
+
 	docMapping := bleve.NewDocumentMapping()
-	docMapping.AddFieldMappingsAt("Name", nameMapping)
+	docMapping.AddFieldMappingsAt("Name", nameMappings...)
 	docMapping.AddFieldMappingsAt("Tags", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("Owner", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("CreatedBy", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("Extension", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("SharedWith", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("Metadata", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("Attributes", lowercaseMapping)
+	docMapping.AddFieldMappingsAt("Classification", lowercaseMapping)
 	docMapping.AddFieldMappingsAt("Content", fulltextFieldMapping)
+	docMapping.AddFieldMappingsAt("Ctime", bleve.NewDateTimeFieldMapping())
+
+	versionMapping := bleve.NewDocumentMapping()
+	versionMapping.AddFieldMappingsAt("Content", fulltextFieldMapping)
+	docMapping.AddSubDocumentMapping("Versions", versionMapping)
+
+	for _, field := range options.disabledFields {
+		docMapping.AddSubDocumentMapping(field, bleve.NewDocumentDisabledMapping())
+	}
 
 	indexMapping := bleve.NewIndexMapping()
 	indexMapping.DefaultAnalyzer = keyword.Name
@@ -70,13 +484,113 @@ func NewMapping() (mapping.IndexMapping, error) {
 		return nil, err
 	}
 
+	if options.transliteration {
+		err = indexMapping.AddCustomTokenFilter("transliteration",
+			map[string]interface{}{
+				"type": transliterationTokenFilter,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = indexMapping.AddCustomAnalyzer("transliteratedKeyword",
+			map[string]interface{}{
+				"type":      custom.Name,
+				"tokenizer": single.Name,
+				"token_filters": []string{
+					lowercase.Name,
+					"transliteration",
+				},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.phonetic {
+		err = indexMapping.AddCustomTokenFilter("phonetic",
+			map[string]interface{}{
+				"type": phoneticTokenFilter,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = indexMapping.AddCustomAnalyzer("phonetic",
+			map[string]interface{}{
+				"type":      custom.Name,
+				"tokenizer": single.Name,
+				"token_filters": []string{
+					"phonetic",
+				},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fulltextFilters := []string{lowercase.Name}
+	if len(options.stopwords) > 0 {
+		tokens := make([]interface{}, 0, len(options.stopwords))
+		for _, word := range options.stopwords {
+			tokens = append(tokens, word)
+		}
+
+		err = indexMapping.AddCustomTokenMap("stopwords",
+			map[string]interface{}{
+				"type":   stopwordsTokenMap,
+				"tokens": tokens,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = indexMapping.AddCustomTokenFilter("stopwords",
+			map[string]interface{}{
+				"type":           stop.Name,
+				"stop_token_map": "stopwords",
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		fulltextFilters = append(fulltextFilters, "stopwords")
+	}
+	fulltextFilters = append(fulltextFilters, porter.Name)
+
 	err = indexMapping.AddCustomAnalyzer("fulltext",
+		map[string]interface{}{
+			"type":          custom.Name,
+			"tokenizer":     unicode.Name,
+			"token_filters": fulltextFilters,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomTokenFilter("words",
+		map[string]interface{}{
+			"type": wordsTokenFilter,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomAnalyzer("words",
 		map[string]interface{}{
 			"type":      custom.Name,
-			"tokenizer": unicode.Name,
+			"tokenizer": single.Name,
 			"token_filters": []string{
 				lowercase.Name,
-				porter.Name,
+				"words",
 			},
 		},
 	)
@@ -95,17 +609,121 @@ func searchResourceByID(id string, index bleve.Index) (*search.Resource, error)
 		return nil, err
 	}
 	if res.Hits.Len() == 0 {
-		return nil, errors.New("entity not found")
+		return nil, errtypes.NotFound(fmt.Sprintf("document with id %s not found", id))
 	}
 
 	return matchToResource(res.Hits[0]), nil
 }
 
+// searchResourcesByIDs returns the resources of the given ids which are part of the index.
+func searchResourcesByIDs(ids []string, index bleve.Index) ([]*search.Resource, error) {
+	req := bleve.NewSearchRequest(bleve.NewDocIDQuery(ids))
+	req.Size = len(ids)
+	req.Fields = []string{"*"}
+	res, err := index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*search.Resource, 0, res.Hits.Len())
+	for _, match := range res.Hits {
+		resources = append(resources, matchToResource(match))
+	}
+
+	return resources, nil
+}
+
 func searchResourcesByPath(rootId, lookupPath string, index bleve.Index) ([]*search.Resource, error) {
-	q := bleve.NewConjunctionQuery(
-		bleve.NewQueryStringQuery("RootID:"+rootId),
+	bleveReq := bleve.NewSearchRequest(resourcesByPathQuery(rootId, lookupPath))
+	bleveReq.Size = math.MaxInt
+	bleveReq.Fields = []string{"*"}
+	res, err := index.Search(bleveReq)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*search.Resource, 0, res.Hits.Len())
+	for _, match := range res.Hits {
+		resources = append(resources, matchToResource(match))
+	}
+
+	return resources, nil
+}
+
+// countResourcesByPath returns the number of resources below the path within the root
+func countResourcesByPath(rootID, lookupPath string, index bleve.Index) (uint64, error) {
+	bleveReq := bleve.NewSearchRequest(resourcesByPathQuery(rootID, lookupPath))
+	bleveReq.Size = 0
+	res, err := index.Search(bleveReq)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.Total, nil
+}
+
+// forEachResourceByPath calls f with the resources below the path within the root, chunkSize resources at a time.
+// The chunks are ordered by id and each one starts after the last id of the previous one,
+// so the resources f changes in the meantime don't shift the following chunks.
+func forEachResourceByPath(rootID, lookupPath string, index bleve.Index, chunkSize int, f func([]*search.Resource) error) error {
+	var after []string
+	for {
+		bleveReq := bleve.NewSearchRequest(resourcesByPathQuery(rootID, lookupPath))
+		bleveReq.Size = chunkSize
+		bleveReq.Fields = []string{"*"}
+		bleveReq.SortBy([]string{"_id"})
+		if after != nil {
+			bleveReq.SetSearchAfter(after)
+		}
+		res, err := index.Search(bleveReq)
+		if err != nil {
+			return err
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+
+		resources := make([]*search.Resource, 0, len(res.Hits))
+		for _, match := range res.Hits {
+			resources = append(resources, matchToResource(match))
+		}
+
+		if err := f(resources); err != nil {
+			return err
+		}
+
+		if len(res.Hits) < chunkSize {
+			return nil
+		}
+		after = []string{res.Hits[len(res.Hits)-1].ID}
+	}
+}
+
+// resourcesByPathQuery matches the resources below the path within the root
+func resourcesByPathQuery(rootID, lookupPath string) query.Query {
+	return bleve.NewConjunctionQuery(
+		bleve.NewQueryStringQuery("RootID:"+rootID),
 		bleve.NewQueryStringQuery("Path:"+escapeQuery(lookupPath+"/*")),
 	)
+}
+
+// searchResourcesByTag returns the resources within the given roots which carry the tag, all of them if no roots are given.
+func searchResourcesByTag(tag string, rootIDs []string, index bleve.Index) ([]*search.Resource, error) {
+	// the tags are indexed in lowercase
+	tagQuery := bleve.NewTermQuery(strings.ToLower(tag))
+	tagQuery.SetField("Tags")
+
+	q := bleve.NewConjunctionQuery(tagQuery)
+	if len(rootIDs) > 0 {
+		roots := bleve.NewDisjunctionQuery()
+		for _, rootID := range rootIDs {
+			rootQuery := bleve.NewTermQuery(rootID)
+			rootQuery.SetField("RootID")
+			roots.AddQuery(rootQuery)
+		}
+		q.AddQuery(roots)
+	}
+
 	bleveReq := bleve.NewSearchRequest(q)
 	bleveReq.Size = math.MaxInt
 	bleveReq.Fields = []string{"*"}
@@ -122,26 +740,63 @@ func searchResourcesByPath(rootId, lookupPath string, index bleve.Index) ([]*sea
 	return resources, nil
 }
 
-func searchAndUpdateResourcesDeletionState(id string, state bool, index bleve.Index) ([]*search.Resource, error) {
-	rootResource, err := searchResourceByID(id, index)
+// searchDescendants returns all descendants of the container, they are found by their path and by their parent ids,
+// so trashed descendants whose path diverged from the path of the container are included.
+func searchDescendants(container *search.Resource, index bleve.Index) ([]*search.Resource, error) {
+	descendants, err := searchResourcesByPath(container.RootID, container.Path, index)
 	if err != nil {
 		return nil, err
 	}
-	rootResource.Deleted = state
 
-	resources := []*search.Resource{rootResource}
+	seen := make(map[string]struct{}, len(descendants))
+	for _, descendant := range descendants {
+		seen[descendant.ID] = struct{}{}
+	}
 
-	if rootResource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
-		descendantResources, err := searchResourcesByPath(rootResource.RootID, rootResource.Path, index)
+	parentIDs := []string{container.ID}
+	for len(parentIDs) > 0 {
+		children, err := searchResourcesByParentIDs(parentIDs, index)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, descendantResource := range descendantResources {
-			descendantResource.Deleted = state
-			resources = append(resources, descendantResource)
+		parentIDs = nil
+		for _, child := range children {
+			if child.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+				parentIDs = append(parentIDs, child.ID)
+			}
+
+			if _, ok := seen[child.ID]; ok {
+				continue
+			}
+			seen[child.ID] = struct{}{}
+			descendants = append(descendants, child)
 		}
 	}
 
+	return descendants, nil
+}
+
+func searchResourcesByParentIDs(parentIDs []string, index bleve.Index) ([]*search.Resource, error) {
+	queries := make([]query.Query, 0, len(parentIDs))
+	for _, parentID := range parentIDs {
+		q := bleve.NewTermQuery(parentID)
+		q.SetField("ParentID")
+		queries = append(queries, q)
+	}
+
+	bleveReq := bleve.NewSearchRequest(bleve.NewDisjunctionQuery(queries...))
+	bleveReq.Size = math.MaxInt
+	bleveReq.Fields = []string{"*"}
+	res, err := index.Search(bleveReq)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*search.Resource, 0, res.Hits.Len())
+	for _, match := range res.Hits {
+		resources = append(resources, matchToResource(match))
+	}
+
 	return resources, nil
 }
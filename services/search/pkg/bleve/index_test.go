@@ -0,0 +1,139 @@
+package bleve_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
+)
+
+var _ = Describe("Index", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("creates and reopens an index", func() {
+		idx, err := bleve.NewIndex(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idx.Close()).To(Succeed())
+
+		idx, err = bleve.NewIndex(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idx.Close()).To(Succeed())
+	})
+
+	It("creates a missing root directory", func() {
+		root = filepath.Join(root, "data", "search")
+
+		idx, err := bleve.NewIndex(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idx.Close()).To(Succeed())
+
+		info, err := os.Stat(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+	})
+
+	It("fails if the root directory can not be created", func() {
+		Expect(os.WriteFile(filepath.Join(root, "data"), nil, 0600)).To(Succeed())
+
+		_, err := bleve.NewIndex(filepath.Join(root, "data", "search"))
+		Expect(err).To(MatchError(bleve.ErrIndexNotWritable))
+		Expect(err).To(MatchError(ContainSubstring("failed to create")))
+	})
+
+	It("fails if the root directory is read-only", func() {
+		if os.Geteuid() == 0 {
+			Skip("the permissions are not enforced for root")
+		}
+		Expect(os.Chmod(root, 0500)).To(Succeed())
+		DeferCleanup(os.Chmod, root, os.FileMode(0700))
+
+		_, err := bleve.NewIndex(root)
+		Expect(err).To(MatchError(bleve.ErrIndexNotWritable))
+		Expect(err).To(MatchError(ContainSubstring("failed to write to")))
+	})
+
+	Context("with a corrupt index", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Join(root, "bleve"), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, "bleve", "index_meta.json"), []byte("{corrupt"), 0600)).To(Succeed())
+		})
+
+		It("fails by default", func() {
+			_, err := bleve.NewIndex(root)
+			Expect(err).To(MatchError(bleve.ErrIndexCorrupt))
+		})
+
+		It("fails with the fail policy", func() {
+			_, err := bleve.NewIndex(root, bleve.WithCorruptionPolicy(bleve.CorruptionPolicyFail, nil))
+			Expect(err).To(MatchError(bleve.ErrIndexCorrupt))
+		})
+
+		It("moves the index aside and recreates it with the recreate policy", func() {
+			recreated := false
+			idx, err := bleve.NewIndex(root, bleve.WithCorruptionPolicy(bleve.CorruptionPolicyRecreate, func() { recreated = true }))
+			Expect(err).ToNot(HaveOccurred())
+			defer idx.Close()
+
+			Expect(recreated).To(BeTrue())
+
+			count, err := idx.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(0)))
+
+			moved, err := filepath.Glob(filepath.Join(root, "bleve.corrupt-*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(moved).To(HaveLen(1))
+			Expect(filepath.Join(moved[0], "index_meta.json")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("with an existing index", func() {
+		var rootBolt string
+
+		BeforeEach(func() {
+			idx, err := bleve.NewIndex(root)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(idx.Close()).To(Succeed())
+
+			rootBolt = filepath.Join(root, "bleve", "store", "root.bolt")
+			Expect(rootBolt).To(BeAnExistingFile())
+		})
+
+		It("recreates an index with a damaged database with the recreate policy", func() {
+			Expect(os.WriteFile(rootBolt, bytes.Repeat([]byte("corrupt"), 4096), 0600)).To(Succeed())
+
+			idx, err := bleve.NewIndex(root, bleve.WithCorruptionPolicy(bleve.CorruptionPolicyRecreate, nil))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(idx.Close()).To(Succeed())
+
+			moved, err := filepath.Glob(filepath.Join(root, "bleve.corrupt-*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(moved).To(HaveLen(1))
+		})
+
+		It("keeps an index which can't be opened for other reasons with the recreate policy", func() {
+			// opening the database fails like it would on a full disk or with too many open files
+			Expect(os.Remove(rootBolt)).To(Succeed())
+			Expect(os.Mkdir(rootBolt, 0700)).To(Succeed())
+
+			recreated := false
+			_, err := bleve.NewIndex(root, bleve.WithCorruptionPolicy(bleve.CorruptionPolicyRecreate, func() { recreated = true }))
+			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(MatchError(bleve.ErrIndexCorrupt))
+			Expect(recreated).To(BeFalse())
+
+			moved, err := filepath.Glob(filepath.Join(root, "bleve.corrupt-*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(moved).To(BeEmpty())
+			Expect(filepath.Join(root, "bleve", "index_meta.json")).To(BeAnExistingFile())
+		})
+	})
+})
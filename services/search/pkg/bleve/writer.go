@@ -0,0 +1,105 @@
+package bleve
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// seqNoKey is the internal key of the index which holds the latest sequence number
+var seqNoKey = []byte("seqNo")
+
+// writer funnels all writes to the index through a single writer. Bleve applies the batches
+// one after the other anyway, concurrent writers would only contend for the index.
+type writer struct {
+	mu    sync.Mutex
+	index bleve.Index
+	seqNo atomic.Int64
+	// storedSeqNo is the sequence number which is stored in the index
+	storedSeqNo int64
+}
+
+func newWriter(index bleve.Index) *writer {
+	w := &writer{
+		index: index,
+	}
+
+	// the sequence numbers continue where the previous writes to the index left off
+	if data, err := index.GetInternal(seqNoKey); err == nil && len(data) == 8 {
+		w.storedSeqNo = int64(binary.BigEndian.Uint64(data))
+		w.seqNo.Store(w.storedSeqNo)
+	}
+
+	return w
+}
+
+// nextSeqNo returns the sequence number of the next document write, it increases with every write to the index.
+func (w *writer) nextSeqNo() int64 {
+	return w.seqNo.Add(1)
+}
+
+// Batch applies the batch to the index once all previous batches are applied. The documents of the conditions
+// must still have the sequence numbers they were read with, the batch is rejected with search.ErrConflict otherwise.
+func (w *writer) Batch(b *bleve.Batch, conditions map[string]int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.checkConditions(conditions); err != nil {
+		return err
+	}
+
+	// the latest sequence number is stored together with the documents which got it
+	seqNo := w.seqNo.Load()
+	if seqNo != w.storedSeqNo {
+		data := make([]byte, 8)
+		binary.BigEndian.PutUint64(data, uint64(seqNo))
+		b.SetInternal(seqNoKey, data)
+	}
+
+	if err := w.index.Batch(b); err != nil {
+		return err
+	}
+
+	w.storedSeqNo = seqNo
+	return nil
+}
+
+// checkConditions fails if a document was written or removed since it was read with the sequence number of the conditions
+func (w *writer) checkConditions(conditions map[string]int64) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(conditions))
+	for id := range conditions {
+		ids = append(ids, id)
+	}
+
+	req := bleve.NewSearchRequest(bleve.NewDocIDQuery(ids))
+	req.Size = len(ids)
+	req.Fields = []string{"SeqNo"}
+	res, err := w.index.Search(req)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]int64, res.Hits.Len())
+	for _, hit := range res.Hits {
+		if seqNo, ok := hit.Fields["SeqNo"].(float64); ok {
+			current[hit.ID] = int64(seqNo)
+		}
+	}
+
+	for id, seqNo := range conditions {
+		if current[id] != seqNo {
+			return fmt.Errorf("%w: document %s was written or removed after it was read", search.ErrConflict, id)
+		}
+	}
+
+	return nil
+}
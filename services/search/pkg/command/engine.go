@@ -0,0 +1,231 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	opensearchgo "github.com/opensearch-project/opensearch-go/v4"
+	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	"github.com/opencloud-eu/opencloud/pkg/generators"
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
+	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// defaultConnectInterval is the interval OpenSearch is connected in if it is unavailable on startup
+// and no health check interval is configured
+const defaultConnectInterval = 30 * time.Second
+
+// newEngine initializes the configured search engine, the returned function releases it.
+// onRecreate is called if a corrupt bleve index has been replaced by an empty one.
+// onOutdated is called if the OpenSearch index differs from the current index definition and has to be rebuilt,
+// the engine fails to start on an outdated index without it. With the bleve fallback, the engine starts even if
+// OpenSearch is unreachable and connects to it in the background, onConnected is called once it is connected.
+func newEngine(cfg *config.Config, m *metrics.Metrics, logger log.Logger, onRecreate, onOutdated, onConnected func()) (search.Engine, func(), error) {
+	switch cfg.Engine.Type {
+	case "bleve":
+		return newBleveEngine(cfg, logger, onRecreate)
+	case "open-search":
+		client, err := opensearchgoAPI.NewClient(opensearchgoAPI.Config{
+			Client: opensearchgo.Config{
+				Addresses:             cfg.Engine.OpenSearch.Client.Addresses,
+				Username:              cfg.Engine.OpenSearch.Client.Username,
+				Password:              cfg.Engine.OpenSearch.Client.Password,
+				Header:                cfg.Engine.OpenSearch.Client.Header,
+				CACert:                cfg.Engine.OpenSearch.Client.CACert,
+				RetryOnStatus:         cfg.Engine.OpenSearch.Client.RetryOnStatus,
+				DisableRetry:          cfg.Engine.OpenSearch.Client.DisableRetry,
+				EnableRetryOnTimeout:  cfg.Engine.OpenSearch.Client.EnableRetryOnTimeout,
+				MaxRetries:            cfg.Engine.OpenSearch.Client.MaxRetries,
+				CompressRequestBody:   cfg.Engine.OpenSearch.Client.CompressRequestBody,
+				DiscoverNodesOnStart:  cfg.Engine.OpenSearch.Client.DiscoverNodesOnStart,
+				DiscoverNodesInterval: cfg.Engine.OpenSearch.Client.DiscoverNodesInterval,
+				EnableMetrics:         cfg.Engine.OpenSearch.Client.EnableMetrics,
+				EnableDebugLogger:     cfg.Engine.OpenSearch.Client.EnableDebugLogger,
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{
+						MinVersion:         tls.VersionTLS12,
+						InsecureSkipVerify: cfg.Engine.OpenSearch.Client.Insecure,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OpenSearch client: %w", err)
+		}
+
+		newOpenSearchBackend := func(onOutdated func()) (*opensearch.Backend, error) {
+			return opensearch.NewBackend(
+				cfg.Engine.OpenSearch.ResourceIndex.Name,
+				client,
+				opensearch.WithIndexOptions(
+					opensearch.WithStopwords(cfg.Engine.Stopwords.Language, cfg.Engine.Stopwords.Words),
+					opensearch.WithDisabledFields(cfg.Extractor.DisabledFields...),
+					opensearch.WithShards(cfg.Engine.OpenSearch.Shards),
+				),
+				opensearch.WithMaxDocumentSize(cfg.Engine.OpenSearch.MaxDocumentSize),
+				opensearch.WithRefreshDisabledDuringBulkIndexing(cfg.Engine.OpenSearch.DisableRefreshDuringReindex),
+				opensearch.WithRefreshAfterWrites(cfg.Engine.OpenSearch.RefreshAfterWrites),
+				opensearch.WithSpaceRouting(cfg.Engine.OpenSearch.RoutingBySpace),
+				opensearch.WithQueryOptions(queryOptions(cfg)),
+				opensearch.WithHighlights(cfg.Engine.Highlights.Fragments, cfg.Engine.Highlights.FragmentSize, cfg.Engine.Highlights.MaxSize),
+				opensearch.WithTimeouts(cfg.Engine.SearchTimeout, cfg.Engine.IndexTimeout),
+				opensearch.WithDescendantLimits(cfg.Engine.DescendantsChunkSize, cfg.Engine.MaxDescendants),
+				opensearch.WithOutdatedIndex(onOutdated),
+				opensearch.WithLogger(logger),
+			)
+		}
+
+		openSearchBackend, err := newOpenSearchBackend(onOutdated)
+		switch {
+		case err == nil && !cfg.Engine.OpenSearch.BleveFallback:
+			return openSearchBackend, func() {}, nil
+		case err != nil && (!cfg.Engine.OpenSearch.BleveFallback || !errors.Is(err, opensearch.ErrUnhealthyCluster)):
+			return nil, nil, fmt.Errorf("failed to create OpenSearch backend: %w", err)
+		}
+
+		bleveBackend, closeBleve, err := newBleveEngine(cfg, logger, onRecreate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create the bleve fallback: %w", err)
+		}
+
+		if openSearchBackend != nil {
+			return search.NewFallbackEngine(openSearchBackend, bleveBackend, m, logger), closeBleve, nil
+		}
+
+		// the searches are served by the bleve index until OpenSearch is reachable. An outdated index
+		// found later on is kept, the service is already running and can't rebuild it anymore.
+		logger.Warn().Msg("OpenSearch is unavailable, serving the searches from the bleve fallback until it is reachable")
+		interval := cfg.Engine.HealthCheckInterval
+		if interval <= 0 {
+			interval = defaultConnectInterval
+		}
+		connecting := search.NewConnectingEngine(func() (search.Engine, error) {
+			return newOpenSearchBackend(func() {
+				logger.Warn().Msg("the index is outdated, the properties added by the current index definition are not searchable until it is rebuilt with 'opencloud search index --all-spaces --rebuild'")
+			})
+		}, interval, onConnected, logger)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go connecting.Run(ctx)
+
+		return search.NewFallbackEngine(connecting, bleveBackend, m, logger), func() {
+			cancel()
+			closeBleve()
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown search engine: %s", cfg.Engine.Type)
+	}
+}
+
+// newBleveEngine initializes the bleve engine, the returned function closes its index.
+func newBleveEngine(cfg *config.Config, logger log.Logger, onRecreate func()) (search.Engine, func(), error) {
+	stopwords, err := bleve.Stopwords(cfg.Engine.Stopwords.Language, cfg.Engine.Stopwords.Words)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the options of new indexes, they are used again when the index is reset
+	indexOptions := []bleve.IndexOption{
+		bleve.WithStopwords(stopwords...),
+		bleve.WithTransliteration(cfg.Engine.Transliteration),
+		bleve.WithPhonetic(cfg.Engine.Phonetic),
+		bleve.WithDisabledFields(cfg.Extractor.DisabledFields...),
+	}
+
+	idx, err := bleve.NewIndex(cfg.Engine.Bleve.Datapath, append(slices.Clone(indexOptions),
+		bleve.WithLogger(logger),
+		bleve.WithCorruptionPolicy(cfg.Engine.Bleve.CorruptionPolicy, onRecreate),
+	)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backendOptions := []bleve.BackendOption{
+		bleve.WithHighlights(cfg.Engine.Highlights.Fragments, cfg.Engine.Highlights.FragmentSize, cfg.Engine.Highlights.MaxSize),
+		bleve.WithIndexReset(cfg.Engine.Bleve.Datapath, indexOptions...),
+		bleve.WithDescendantLimits(cfg.Engine.DescendantsChunkSize, cfg.Engine.MaxDescendants),
+	}
+	if cfg.Engine.Bleve.FlushOnShutdown {
+		backendOptions = append(backendOptions, bleve.WithFlushOnClose())
+	}
+
+	backend := bleve.NewBackend(idx, bleveQuery.NewCreator(queryOptions(cfg)), logger, backendOptions...)
+
+	// the backend closes the current index, which is a new one once the index was reset
+	closeIndex := func() {
+		if err := backend.Close(); err != nil {
+			logger.Error().Err(err).Msg("could not close bleve index")
+		}
+	}
+
+	return backend, closeIndex, nil
+}
+
+// queryOptions returns how the engines turn the query strings into their queries.
+func queryOptions(cfg *config.Config) query.Options {
+	return query.Options{
+		Boosts: map[string]float64{
+			"Name":    cfg.Engine.Boosts.Name,
+			"Content": cfg.Engine.Boosts.Content,
+			"Tags":    cfg.Engine.Boosts.Tags,
+		},
+		DefaultOperator:    strings.ToUpper(cfg.Engine.DefaultOperator),
+		MinimumShouldMatch: cfg.Engine.MinimumShouldMatch,
+		Transliterate:      cfg.Engine.Transliteration,
+		Phonetic:           cfg.Engine.Phonetic,
+	}
+}
+
+// newExtractor initializes the configured content extractor, the extractors registered for specific mime types take precedence over it.
+func newExtractor(cfg *config.Config, selector pool.Selectable[gateway.GatewayAPIClient], logger log.Logger) (content.Extractor, error) {
+	var (
+		extractor content.Extractor
+		err       error
+	)
+	switch cfg.Extractor.Type {
+	case "basic":
+		extractor, err = content.NewBasicExtractor(logger)
+	case "tika":
+		extractor, err = content.NewTikaExtractor(selector, logger, cfg)
+	default:
+		return nil, fmt.Errorf("unknown search extractor: %s", cfg.Extractor.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return content.NewRegistryFromRegistrations(extractor, selector, logger, cfg)
+}
+
+// newEventStream connects to the configured event system.
+func newEventStream(cfg *config.Config) (raw.Stream, error) {
+	connName := generators.GenerateConnectionName(cfg.Service.Name, generators.NTypeBus)
+	return raw.FromConfig(context.Background(), connName, raw.Config{
+		Endpoint:             cfg.Events.Endpoint,
+		Cluster:              cfg.Events.Cluster,
+		EnableTLS:            cfg.Events.EnableTLS,
+		TLSInsecure:          cfg.Events.TLSInsecure,
+		TLSRootCACertificate: cfg.Events.TLSRootCACertificate,
+		AuthUsername:         cfg.Events.AuthUsername,
+		AuthPassword:         cfg.Events.AuthPassword,
+		MaxAckPending:        cfg.Events.MaxAckPending,
+		AckWait:              cfg.Events.AckWait,
+	})
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config/defaults"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// unreachableAddress returns the address of a port nothing listens on
+func unreachableAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return "http://" + addr
+}
+
+func TestNewEngineFailsWithUnreachableOpenSearch(t *testing.T) {
+	cfg := defaults.DefaultConfig()
+	cfg.Engine.Type = "open-search"
+	cfg.Engine.OpenSearch.Client.Addresses = []string{unreachableAddress(t)}
+	cfg.Engine.OpenSearch.Client.DisableRetry = true
+
+	_, _, err := newEngine(cfg, nil, log.NopLogger(), func() {}, nil, nil)
+	require.Error(t, err)
+}
+
+func TestNewEngineServesFromTheBleveFallbackWithUnreachableOpenSearch(t *testing.T) {
+	cfg := defaults.DefaultConfig()
+	cfg.Engine.Type = "open-search"
+	cfg.Engine.OpenSearch.Client.Addresses = []string{unreachableAddress(t)}
+	cfg.Engine.OpenSearch.Client.DisableRetry = true
+	cfg.Engine.OpenSearch.BleveFallback = true
+	cfg.Engine.Bleve.Datapath = t.TempDir()
+
+	eng, closeEngine, err := newEngine(cfg, metrics.New(), log.NopLogger(), func() {}, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(closeEngine)
+
+	// the write to OpenSearch fails, the bleve index is updated nevertheless
+	err = eng.Upsert("1$2!3", search.Resource{
+		ID:       "1$2!3",
+		RootID:   "1$2!2",
+		ParentID: "1$2!2",
+		Path:     "./report.pdf",
+		Document: content.Document{Name: "report.pdf"},
+	})
+	require.ErrorIs(t, err, search.ErrBackendUnavailable)
+
+	res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+		Query: "name:report*",
+		Ref: &searchmsg.Reference{
+			ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.GetMatches(), 1)
+	assert.Equal(t, "report.pdf", res.GetMatches()[0].GetEntity().GetName())
+}
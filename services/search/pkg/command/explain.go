@@ -0,0 +1,63 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/opencloud-eu/opencloud/pkg/kql"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
+	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// ExplainQuery is the entrypoint for the explain-query command.
+func ExplainQuery(_ *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "explain-query",
+		Usage:     "validate a KQL query and print its parsed query tree, neither the search service nor the index is required",
+		Category:  "debug",
+		ArgsUsage: "<query>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "default-operator",
+				Value:   kql.BoolAND,
+				Usage:   "the operator between free-text terms without an explicit operator, 'AND' or 'OR'.",
+				EnvVars: []string{"SEARCH_ENGINE_DEFAULT_OPERATOR"},
+			},
+			&cli.StringFlag{
+				Name:    "minimum-should-match",
+				Usage:   "how many of the free-text terms without an explicit operator have to match, like '3' or '75%'.",
+				EnvVars: []string{"SEARCH_ENGINE_MINIMUM_SHOULD_MATCH"},
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 1 {
+				return errors.New("exactly one query is required, quote it if it contains spaces")
+			}
+			qs := ctx.Args().First()
+			defaultOperator := strings.ToUpper(ctx.String("default-operator"))
+			minimumShouldMatch := ctx.String("minimum-should-match")
+
+			tree, err := search.ExplainQuery(qs, defaultOperator, minimumShouldMatch)
+			var syntaxErr *kql.SyntaxError
+			switch {
+			case errors.As(err, &syntaxErr):
+				return fmt.Errorf("invalid query at line %d, column %d: %w", syntaxErr.Position.Line, syntaxErr.Position.Column, syntaxErr.Err)
+			case err != nil:
+				return fmt.Errorf("invalid query: %w", err)
+			}
+
+			// the query is compiled like the engines do, which rejects the parsed queries no engine can search for
+			if _, err := bleveQuery.NewCreator(query.Options{DefaultOperator: defaultOperator, MinimumShouldMatch: minimumShouldMatch}).Create(qs); err != nil {
+				return fmt.Errorf("failed to compile the query: %w", err)
+			}
+
+			fmt.Print(tree)
+			return nil
+		},
+	}
+}
@@ -34,6 +34,10 @@ func Index(cfg *config.Config) *cli.Command {
 				Name:  "all-spaces",
 				Usage: "index all spaces instead. This or --space is required.",
 			},
+			&cli.BoolFlag{
+				Name:  "rebuild",
+				Usage: "index all spaces into a new index which replaces the current one once done. Requires --all-spaces and the open-search engine.",
+			},
 		},
 		Before: func(_ *cli.Context) error {
 			return configlog.ReturnFatal(parser.ParseConfig(cfg))
@@ -42,6 +46,9 @@ func Index(cfg *config.Config) *cli.Command {
 			if ctx.String("space") == "" && !ctx.Bool("all-spaces") {
 				return errors.New("either --space or --all-spaces is required")
 			}
+			if ctx.Bool("rebuild") && !ctx.Bool("all-spaces") {
+				return errors.New("--rebuild requires --all-spaces")
+			}
 
 			traceProvider, err := tracing.GetServiceTraceProvider(cfg.Tracing, cfg.Service.Name)
 			if err != nil {
@@ -59,6 +66,7 @@ func Index(cfg *config.Config) *cli.Command {
 			c := searchsvc.NewSearchProviderService("eu.opencloud.api.search", grpcClient)
 			_, err = c.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{
 				SpaceId: ctx.String("space"),
+				Rebuild: ctx.Bool("rebuild"),
 			}, func(opts *client.CallOptions) { opts.RequestTimeout = 10 * time.Minute })
 			if err != nil {
 				fmt.Println("failed to index space: " + err.Error())
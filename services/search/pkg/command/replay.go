@@ -0,0 +1,121 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/urfave/cli/v2"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/pkg/registry"
+	"github.com/opencloud-eu/opencloud/pkg/runner"
+	ogrpc "github.com/opencloud-eu/opencloud/pkg/service/grpc"
+	"github.com/opencloud-eu/opencloud/pkg/tracing"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config/parser"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/logging"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	svcEvent "github.com/opencloud-eu/opencloud/services/search/pkg/service/event"
+)
+
+// Replay is the entrypoint for the replay command.
+func Replay(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "replay",
+		Usage:    "rebuild the index from the events retained by the event system",
+		Category: "index management",
+		Flags: []cli.Flag{
+			&cli.TimestampFlag{
+				Name:   "since",
+				Usage:  "replay the events which were retained after the given time, e.g. 2006-01-02T15:04:05Z",
+				Layout: time.RFC3339,
+			},
+			&cli.Uint64Flag{
+				Name:  "start-sequence",
+				Usage: "replay the events starting with the given stream sequence. Takes precedence over --since.",
+			},
+		},
+		Before: func(_ *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			if cfg.Events.Disabled {
+				return errors.New("the event system is disabled")
+			}
+
+			logger := logging.Configure(cfg.Service.Name, cfg.Log)
+			traceProvider, err := tracing.GetServiceTraceProvider(cfg.Tracing, cfg.Service.Name)
+			if err != nil {
+				return err
+			}
+
+			cfg.GrpcClient, err = ogrpc.NewClient(
+				append(ogrpc.GetClientOptions(cfg.GRPCClientTLS), ogrpc.WithTraceProvider(traceProvider))...,
+			)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), runner.StopSignals...)
+			defer cancel()
+
+			eng, closeEngine, err := newEngine(cfg, nil, logger, func() {}, nil, nil)
+			if err != nil {
+				return err
+			}
+			defer closeEngine()
+
+			selector, err := pool.GatewaySelector(cfg.Reva.Address, pool.WithRegistry(registry.GetRegistry()), pool.WithTracerProvider(traceProvider))
+			if err != nil {
+				return err
+			}
+
+			extractor, err := newExtractor(cfg, selector, logger)
+			if err != nil {
+				return err
+			}
+
+			ss := search.NewService(selector, eng, extractor, metrics.New(), logger, cfg)
+
+			bus, err := newEventStream(cfg)
+			if err != nil {
+				return err
+			}
+
+			eventSvc, err := svcEvent.New(ctx, bus, ss,
+				svcEvent.Logger(logger),
+				svcEvent.TracerProvider(traceProvider),
+				svcEvent.DebounceDuration(cfg.Events.DebounceDuration),
+				svcEvent.ConsumerName(cfg.Events.ConsumerName),
+				svcEvent.MaxProcessingTime(cfg.Events.MaxProcessingTime),
+				svcEvent.ShutdownTimeout(cfg.Events.ShutdownTimeout),
+				svcEvent.AsyncUploads(cfg.Events.AsyncUploads),
+			)
+			if err != nil {
+				return err
+			}
+			defer eventSvc.Close()
+
+			opts := svcEvent.ReplayOptions{StartSequence: c.Uint64("start-sequence")}
+			if since := c.Timestamp("since"); since != nil {
+				opts.StartTime = *since
+			}
+
+			progress, err := eventSvc.Replay(ctx, opts, func(p svcEvent.ReplayProgress) {
+				fmt.Printf("replayed events up to sequence %d of %d (%d processed, %d skipped)\n", p.Sequence, p.LastSequence, p.Processed, p.Skipped)
+			})
+			if err != nil {
+				fmt.Println("failed to replay events: " + err.Error())
+				return err
+			}
+
+			fmt.Printf("replay finished, %d events processed, %d skipped\n", progress.Processed, progress.Skipped)
+			return nil
+		},
+	}
+}
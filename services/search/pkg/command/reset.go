@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	"github.com/urfave/cli/v2"
+	"go-micro.dev/v4/client"
+	"go-micro.dev/v4/metadata"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/pkg/service/grpc"
+	"github.com/opencloud-eu/opencloud/pkg/tracing"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config/parser"
+)
+
+// ResetIndex is the entrypoint for the reset-index command.
+func ResetIndex(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "reset-index",
+		Usage:    "delete all documents by replacing the index with a new and empty one",
+		Category: "index management",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "confirm",
+				Usage: "confirm that all documents are deleted. Requires SEARCH_ALLOW_INDEX_RESET to be enabled for the search service.",
+			},
+		},
+		Before: func(_ *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Bool("confirm") {
+				return errors.New("resetting the index deletes all documents, --confirm is required")
+			}
+
+			traceProvider, err := tracing.GetServiceTraceProvider(cfg.Tracing, cfg.Service.Name)
+			if err != nil {
+				return err
+			}
+			grpcClient, err := grpc.NewClient(
+				append(grpc.GetClientOptions(cfg.GRPCClientTLS),
+					grpc.WithTraceProvider(traceProvider),
+				)...,
+			)
+			if err != nil {
+				return err
+			}
+
+			// resetting the index is only allowed to admins, the service account has the required permission
+			gatewayClient, err := pool.GetGatewayServiceClient(cfg.Reva.Address)
+			if err != nil {
+				return fmt.Errorf("error selecting gateway client %w", err)
+			}
+			token, err := utils.GetServiceUserToken(context.Background(), gatewayClient, cfg.ServiceAccount.ServiceAccountID, cfg.ServiceAccount.ServiceAccountSecret)
+			if err != nil {
+				return fmt.Errorf("could not authenticate the service account %w", err)
+			}
+
+			c := searchsvc.NewSearchProviderService("eu.opencloud.api.search", grpcClient)
+			res, err := c.ResetIndex(metadata.Set(context.Background(), revactx.TokenHeader, token), &searchsvc.ResetIndexRequest{
+				Confirm: true,
+			}, func(opts *client.CallOptions) { opts.RequestTimeout = time.Minute })
+			if err != nil {
+				fmt.Println("failed to reset the index: " + err.Error())
+				return err
+			}
+
+			fmt.Printf("deleted %d documents, re-index all spaces with 'opencloud search index --all-spaces'\n", res.GetDocumentCount())
+			return nil
+		},
+	}
+}
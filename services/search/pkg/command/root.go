@@ -17,6 +17,11 @@ func GetCommands(cfg *config.Config) cli.Commands {
 
 		// interaction with this service
 		Index(cfg),
+		Replay(cfg),
+		ResetIndex(cfg),
+
+		// debugging without a running service
+		ExplainQuery(cfg),
 
 		// infos about this service
 		Health(cfg),
@@ -2,36 +2,30 @@ package command
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net/http"
 	"os/signal"
+	"time"
 
-	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
-	opensearchgo "github.com/opensearch-project/opensearch-go/v4"
-	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/urfave/cli/v2"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
-	"github.com/opencloud-eu/opencloud/pkg/generators"
+	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/pkg/registry"
 	"github.com/opencloud-eu/opencloud/pkg/runner"
 	ogrpc "github.com/opencloud-eu/opencloud/pkg/service/grpc"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
 	"github.com/opencloud-eu/opencloud/pkg/version"
-	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config/parser"
-	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/logging"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
-	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch"
-	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/server/debug"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/server/grpc"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/server/http"
 	svcEvent "github.com/opencloud-eu/opencloud/services/search/pkg/service/event"
+	svcGRPC "github.com/opencloud-eu/opencloud/services/search/pkg/service/grpc/v0"
 )
 
 // Server is the entrypoint for the server command.
@@ -68,59 +62,13 @@ func Server(cfg *config.Config) *cli.Command {
 			mtrcs.BuildInfo.WithLabelValues(version.GetString()).Set(1)
 
 			// initialize search engine
-			var eng search.Engine
-			switch cfg.Engine.Type {
-			case "bleve":
-				idx, err := bleve.NewIndex(cfg.Engine.Bleve.Datapath)
-				if err != nil {
-					return err
-				}
-
-				defer func() {
-					if err = idx.Close(); err != nil {
-						logger.Error().Err(err).Msg("could not close bleve index")
-					}
-				}()
-
-				eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, logger)
-			case "open-search":
-				client, err := opensearchgoAPI.NewClient(opensearchgoAPI.Config{
-					Client: opensearchgo.Config{
-						Addresses:             cfg.Engine.OpenSearch.Client.Addresses,
-						Username:              cfg.Engine.OpenSearch.Client.Username,
-						Password:              cfg.Engine.OpenSearch.Client.Password,
-						Header:                cfg.Engine.OpenSearch.Client.Header,
-						CACert:                cfg.Engine.OpenSearch.Client.CACert,
-						RetryOnStatus:         cfg.Engine.OpenSearch.Client.RetryOnStatus,
-						DisableRetry:          cfg.Engine.OpenSearch.Client.DisableRetry,
-						EnableRetryOnTimeout:  cfg.Engine.OpenSearch.Client.EnableRetryOnTimeout,
-						MaxRetries:            cfg.Engine.OpenSearch.Client.MaxRetries,
-						CompressRequestBody:   cfg.Engine.OpenSearch.Client.CompressRequestBody,
-						DiscoverNodesOnStart:  cfg.Engine.OpenSearch.Client.DiscoverNodesOnStart,
-						DiscoverNodesInterval: cfg.Engine.OpenSearch.Client.DiscoverNodesInterval,
-						EnableMetrics:         cfg.Engine.OpenSearch.Client.EnableMetrics,
-						EnableDebugLogger:     cfg.Engine.OpenSearch.Client.EnableDebugLogger,
-						Transport: &http.Transport{
-							TLSClientConfig: &tls.Config{
-								MinVersion:         tls.VersionTLS12,
-								InsecureSkipVerify: cfg.Engine.OpenSearch.Client.Insecure,
-							},
-						},
-					},
-				})
-				if err != nil {
-					return fmt.Errorf("failed to create OpenSearch client: %w", err)
-				}
-
-				openSearchBackend, err := opensearch.NewBackend(cfg.Engine.OpenSearch.ResourceIndex.Name, client)
-				if err != nil {
-					return fmt.Errorf("failed to create OpenSearch backend: %w", err)
-				}
-
-				eng = openSearchBackend
-			default:
-				return fmt.Errorf("unknown search engine: %s", cfg.Engine.Type)
+			reindex, rebuild := false, false
+			connected := make(chan struct{})
+			eng, closeEngine, err := newEngine(cfg, mtrcs, logger, func() { reindex = true }, func() { rebuild = true }, func() { close(connected) })
+			if err != nil {
+				return err
 			}
+			defer closeEngine()
 
 			// initialize gateway selector
 			selector, err := pool.GatewaySelector(cfg.Reva.Address, pool.WithRegistry(registry.GetRegistry()), pool.WithTracerProvider(traceProvider))
@@ -130,26 +78,54 @@ func Server(cfg *config.Config) *cli.Command {
 			}
 
 			// initialize search content extractor
-			var extractor content.Extractor
-			switch cfg.Extractor.Type {
-			case "basic":
-				if extractor, err = content.NewBasicExtractor(logger); err != nil {
-					return err
-				}
-			case "tika":
-				if extractor, err = content.NewTikaExtractor(selector, logger, cfg); err != nil {
-					return err
-				}
-			default:
-				return fmt.Errorf("unknown search extractor: %s", cfg.Extractor.Type)
+			extractor, err := newExtractor(cfg, selector, logger)
+			if err != nil {
+				return err
 			}
 
 			ss := search.NewService(selector, eng, extractor, mtrcs, logger, cfg)
 
+			// keep track of the engine health, so no searches are routed to this instance while the engine is down
+			healthMonitor := search.NewHealthMonitor(eng, cfg.Engine.HealthCheckInterval, logger)
+			go healthMonitor.Run(ctx)
+
+			// compact the index once too many of its documents are deleted or replaced
+			compactionMonitor := search.NewCompactionMonitor(eng, cfg.Engine.Compaction.Interval, cfg.Engine.Compaction.DeletedRatio, logger)
+			go compactionMonitor.Run(ctx)
+
+			switch {
+			case reindex:
+				// the index has been recreated, fill it again in the background
+				go retryInBackground(ctx, logger, ss.IndexAllSpaces, "reindex all spaces after recreating the bleve index")
+			case rebuild && cfg.Engine.OpenSearch.RebuildOutdatedIndex:
+				// the index is outdated, it keeps serving the searches until the rebuilt index replaces it
+				go retryInBackground(ctx, logger, ss.RebuildIndex, "rebuild the outdated index")
+			case rebuild:
+				logger.Warn().Msg("the index is outdated, the properties added by the current index definition are not searchable until it is rebuilt with 'opencloud search index --all-spaces --rebuild'")
+			}
+
+			// OpenSearch was unreachable on startup, the changes made until it is connected are only in the bleve fallback
+			go func() {
+				select {
+				case <-connected:
+					retryInBackground(ctx, logger, ss.IndexAllSpaces, "index all spaces after connecting to OpenSearch")
+				case <-ctx.Done():
+				}
+			}()
+
 			// setup the servers
 			gr := runner.NewGroup()
 
 			if !cfg.GRPC.Disabled {
+				var secrets svcGRPC.SecretSource
+				if cfg.TokenManager.JWTSecretFile != "" {
+					secrets, err = svcGRPC.NewFileSecretSource(cfg.TokenManager.JWTSecretFile, cfg.TokenManager.JWTSecretFileCheckInterval, logger)
+					if err != nil {
+						logger.Error().Err(err).Str("path", cfg.TokenManager.JWTSecretFile).Msg("Failed to read the jwt secret file")
+						return err
+					}
+				}
+
 				grpcServer, err := grpc.Server(
 					grpc.Config(cfg),
 					grpc.Logger(logger),
@@ -157,6 +133,8 @@ func Server(cfg *config.Config) *cli.Command {
 					grpc.Context(ctx),
 					grpc.Metrics(mtrcs),
 					grpc.JWTSecret(cfg.TokenManager.JWTSecret),
+					grpc.PreviousJWTSecrets(cfg.TokenManager.PreviousJWTSecrets),
+					grpc.JWTSecretSource(secrets),
 					grpc.TraceProvider(traceProvider),
 					grpc.GatewaySelector(selector),
 					grpc.Searcher(ss),
@@ -172,24 +150,26 @@ func Server(cfg *config.Config) *cli.Command {
 			}
 
 			if !cfg.Events.Disabled {
-				connName := generators.GenerateConnectionName(cfg.Service.Name, generators.NTypeBus)
-				bus, err := raw.FromConfig(context.Background(), connName, raw.Config{
-					Endpoint:             cfg.Events.Endpoint,
-					Cluster:              cfg.Events.Cluster,
-					EnableTLS:            cfg.Events.EnableTLS,
-					TLSInsecure:          cfg.Events.TLSInsecure,
-					TLSRootCACertificate: cfg.Events.TLSRootCACertificate,
-					AuthUsername:         cfg.Events.AuthUsername,
-					AuthPassword:         cfg.Events.AuthPassword,
-					MaxAckPending:        cfg.Events.MaxAckPending,
-					AckWait:              cfg.Events.AckWait,
-				})
+				bus, err := newEventStream(cfg)
 				if err != nil {
 					logger.Error().Err(err).Msg("Failed to create event bus client")
 					return err
 				}
 
-				eventSvc, err := svcEvent.New(ctx, bus, logger, traceProvider, mtrcs, ss, cfg.Events.DebounceDuration, cfg.Events.NumConsumers, cfg.Events.AsyncUploads)
+				eventSvc, err := svcEvent.New(ctx, bus, ss,
+					svcEvent.Logger(logger),
+					svcEvent.TracerProvider(traceProvider),
+					svcEvent.Metrics(mtrcs),
+					svcEvent.DebounceDuration(cfg.Events.DebounceDuration),
+					svcEvent.PurgeBatchDuration(cfg.Events.PurgeBatchDuration),
+					svcEvent.NumConsumers(cfg.Events.NumConsumers),
+					svcEvent.ConsumerName(cfg.Events.ConsumerName),
+					svcEvent.MaxInFlight(cfg.Events.MaxInFlight),
+					svcEvent.MaxProcessingTime(cfg.Events.MaxProcessingTime),
+					svcEvent.AckWait(cfg.Events.AckWait),
+					svcEvent.ShutdownTimeout(cfg.Events.ShutdownTimeout),
+					svcEvent.AsyncUploads(cfg.Events.AsyncUploads),
+				)
 				if err != nil {
 					logger.Error().Err(err).Str("transport", "event").Msg("Failed to initialize server")
 					return err
@@ -198,18 +178,35 @@ func Server(cfg *config.Config) *cli.Command {
 				gr.Add(runner.New(cfg.Service.Name+".svc", func() error {
 					return eventSvc.Run()
 				}, func() {
-					eventSvc.Close()
+					if err := eventSvc.Close(); err != nil {
+						logger.Error().Err(err).Str("transport", "event").Msg("Failed to drain the event processing")
+					}
 				}))
 			} else {
 				logger.Info().Msg("event listening disabled, not starting event service")
 			}
 
+			if cfg.MetadataWebhook.Addr != "" {
+				webhookServer, err := http.Server(
+					http.Logger(logger),
+					http.Config(cfg),
+					http.Updater(ss),
+				)
+				if err != nil {
+					logger.Error().Err(err).Str("transport", "http").Msg("Failed to initialize server")
+					return err
+				}
+
+				gr.Add(runner.NewGolangHttpServerRunner(cfg.Service.Name+".webhook", webhookServer))
+			}
+
 			// always start a debug server
 			{
 				debugServer, err := debug.Server(
 					debug.Logger(logger),
 					debug.Context(ctx),
 					debug.Config(cfg),
+					debug.EngineHealth(healthMonitor.Check),
 				)
 				if err != nil {
 					logger.Error().Err(err).Str("transport", "debug").Msg("Failed to initialize server")
@@ -231,3 +228,21 @@ func Server(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+// retryInBackground runs the task until it succeeds or the context is done, failed attempts are retried after 10 seconds
+func retryInBackground(ctx context.Context, logger log.Logger, task func() error, description string) {
+	for {
+		err := task()
+		if err == nil {
+			logger.Info().Msg("managed to " + description)
+			return
+		}
+		logger.Warn().Err(err).Msg("could not " + description + ", retrying")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/shared"
 	"go-micro.dev/v4/client"
@@ -27,14 +28,71 @@ type Config struct {
 	Events                     Events                `yaml:"events"`
 	Engine                     Engine                `yaml:"engine"`
 	Extractor                  Extractor             `yaml:"extractor"`
+	IncrementalIndexing        IncrementalIndexing   `yaml:"incremental_indexing"`
+	ResourceTypes              ResourceTypes         `yaml:"resource_types"`
+	GatewayRetries             GatewayRetries        `yaml:"gateway_retries"`
+	IndexSharedWith            bool                  `yaml:"index_shared_with" env:"SEARCH_INDEX_SHARED_WITH" desc:"Index the users and groups a resource is shared with, so users can search for the resources shared with them using 'sharedwith:me'. Listing the shares adds a request to the indexing of every resource. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
+	IndexLocks                 bool                  `yaml:"index_locks" env:"SEARCH_INDEX_LOCKS" desc:"Index whether a resource is locked and the user holding the lock, so resources which are currently edited, for example in an office application, can be found using 'locked:true'. The index is updated by the lock and unlock events, expired locks are not matched as locked. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
+	IndexVersions              int                   `yaml:"index_versions" env:"SEARCH_INDEX_VERSIONS" desc:"The number of previous versions of a file whose content is indexed in addition to the current content, starting with the latest version. Users can search the content of the versions using 'versions:'. Every indexed version increases the size of the index. Set to 0 to disable. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
+	ExtendedAttributes         []string              `yaml:"extended_attributes" env:"SEARCH_EXTENDED_ATTRIBUTES" desc:"The names of the libregraph extended attributes of the resources which are indexed, like 'video.codec' for the 'libre.graph.video.codec' attribute. Users can search the attributes using 'attribute:<name>=<value>'. Changing this setting requires a reindex. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	ClassificationAttribute    string                `yaml:"classification_attribute" env:"SEARCH_CLASSIFICATION_ATTRIBUTE" desc:"The key of the metadata of the resources which holds their classification label, like 'Public', 'Internal' or 'Confidential'. Users can filter the resources by the label using 'classification:<label>', the labels are matched exactly and case-insensitively. Leave empty to not index the classifications. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
 	ContentExtractionSizeLimit uint64                `yaml:"content_extraction_size_limit" env:"SEARCH_CONTENT_EXTRACTION_SIZE_LIMIT" desc:"Maximum file size in bytes that is allowed for content extraction." introductionVersion:"1.0.0"`
+	IndexConcurrency           int                   `yaml:"index_concurrency" env:"SEARCH_INDEX_CONCURRENCY" desc:"The maximum number of spaces which are indexed at the same time when all spaces are indexed or the index is rebuilt. Higher values speed up the indexing of many spaces but put more load on the gateway and the storage providers. Defaults to 4." introductionVersion:"%%NEXT%%"`
 	BatchSize                  int                   `yaml:"batch_size" env:"SEARCH_BATCH_SIZE" desc:"The number of documents to process in a single batch. Defaults to 500." introductionVersion:"1.0.0"`
+	SlowSearchThreshold        time.Duration         `yaml:"slow_search_threshold" env:"SEARCH_SLOW_SEARCH_THRESHOLD" desc:"Searches taking longer than this duration are logged at warn level and counted in the slow searches metric. Set to 0 to disable. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	MaxNameLength              int                   `yaml:"max_name_length" env:"SEARCH_MAX_NAME_LENGTH" desc:"The maximum number of characters of the indexed names. Longer names are truncated at a character boundary and end with '…' in the index, they are only found by the beginning of their name and not by their full name anymore. The file extension is taken from the full name. Defaults to 0, which disables the limit." introductionVersion:"%%NEXT%%"`
+	MaxQueryLength             int                   `yaml:"max_query_length" env:"SEARCH_MAX_QUERY_LENGTH" desc:"The maximum number of characters of a search query. Longer queries are rejected as bad request. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+	MaxQueryTerms              int                   `yaml:"max_query_terms" env:"SEARCH_MAX_QUERY_TERMS" desc:"The maximum number of terms of a search query, including the terms of nested groups. Queries with more terms are rejected as bad request. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+	MaxPageSize                int32                 `yaml:"max_page_size" env:"SEARCH_MAX_PAGE_SIZE" desc:"The maximum number of matches returned by a search. Searches requesting more matches or all matches only return this number of matches. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+	QueryFields                []string              `yaml:"query_fields" env:"SEARCH_QUERY_FIELDS" desc:"The properties users may restrict the terms of a search query to, like 'name' or 'mediatype'. Queries using other properties, like internal fields of the index, are rejected as bad request. Leave empty to allow all properties. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	ExcludedPaths              []string              `yaml:"excluded_paths" env:"SEARCH_EXCLUDED_PATHS" desc:"Glob patterns of resources which are never indexed, like '.DS_Store', '*.tmp' or '.~lock.*#'. Patterns without a slash are matched against the names of the resources and their parent folders, patterns with a slash against the paths relative to the space root. Matching resources which are already indexed are removed on the next indexing. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	PreviewMimeTypes           []string              `yaml:"preview_mime_types" env:"SEARCH_PREVIEW_MIME_TYPES" desc:"A list of mime types the thumbnails service renders previews for. The search results of resources with these mime types are marked as having a preview, so clients can request a thumbnail without checking the availability first. Wildcards like 'image/*' are supported. Changing this setting requires a reindex. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AllowIndexReset            bool                  `yaml:"allow_index_reset" env:"SEARCH_ALLOW_INDEX_RESET" desc:"Enables the ResetIndex gRPC method, which deletes all documents by replacing the index with a new and empty one. Use it to start over with an empty index, for example after changing a setting which requires a reindex. The spaces are not re-indexed automatically. Only users with the Settings Management permission, by default the admins, can reset the index." introductionVersion:"%%NEXT%%"`
 
-	ServiceAccount ServiceAccount `yaml:"service_account"`
+	ServiceAccount  ServiceAccount  `yaml:"service_account"`
+	MetadataWebhook MetadataWebhook `yaml:"metadata_webhook"`
+	RateLimit       RateLimit       `yaml:"rate_limit"`
+	ContentAccess   ContentAccess   `yaml:"content_access"`
 
 	Context context.Context `yaml:"-"`
 }
 
+// IncrementalIndexing configures the incremental indexing of spaces
+type IncrementalIndexing struct {
+	Enabled       bool   `yaml:"enabled" env:"SEARCH_INCREMENTAL_INDEXING_ENABLED" desc:"Only reindex the resources of a space which have been modified since the space was indexed the last time. Resources removed from the space in the meantime are marked as deleted." introductionVersion:"%%NEXT%%"`
+	WatermarkPath string `yaml:"watermark_path" env:"SEARCH_INCREMENTAL_INDEXING_WATERMARK_PATH" desc:"The file storing the modification time up to which each space has been indexed. If not defined, the path derives from $OC_BASE_DATA_PATH/search/watermarks.json." introductionVersion:"%%NEXT%%"`
+}
+
+// ResourceTypes configures the indexing of special resource types. Files and folders are always indexed.
+type ResourceTypes struct {
+	SkipSymlinks   bool `yaml:"skip_symlinks" env:"SEARCH_SKIP_SYMLINKS" desc:"Do not index symlinks. Indexed symlinks are searchable by their name and metadata, their target is not followed. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
+	SkipReferences bool `yaml:"skip_references" env:"SEARCH_SKIP_REFERENCES" desc:"Do not index references like share mount points. Indexed references are searchable by their name and metadata, their target is not followed. Changing this setting requires a reindex." introductionVersion:"%%NEXT%%"`
+}
+
+// GatewayRetries configures the retries of the gateway requests of the indexing which failed with a transient error
+type GatewayRetries struct {
+	Attempts int           `yaml:"attempts" env:"SEARCH_GATEWAY_RETRIES_ATTEMPTS" desc:"The number of times the stats and listings of resources are retried during the indexing if the gateway is unavailable, times out or is overloaded. Other errors are not retried. Set to 0 to disable the retries." introductionVersion:"%%NEXT%%"`
+	Backoff  time.Duration `yaml:"backoff" env:"SEARCH_GATEWAY_RETRIES_BACKOFF" desc:"The time to wait before the first retry of a failed gateway request, the time doubles with every further retry. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
+// MetadataWebhook configures the webhook external systems use to add metadata to the indexed resources
+type MetadataWebhook struct {
+	Addr   string `yaml:"addr" env:"SEARCH_METADATA_WEBHOOK_ADDR" desc:"Bind address of the webhook external systems use to add metadata like classifications or retention labels to the indexed resources. The webhook is disabled if empty." introductionVersion:"%%NEXT%%"`
+	Secret string `yaml:"secret" env:"SEARCH_METADATA_WEBHOOK_SECRET" desc:"The secret callers of the metadata webhook have to send as bearer token. Required if the webhook is enabled." introductionVersion:"%%NEXT%%"`
+}
+
+// RateLimit configures the number of searches each user may run
+type RateLimit struct {
+	Rate  float64 `yaml:"rate" env:"SEARCH_RATE_LIMIT_RATE" desc:"The number of searches per second each user may run on average. Further searches are rejected with a too many requests error. Service accounts are not limited. Set to 0 to disable the rate limit." introductionVersion:"%%NEXT%%"`
+	Burst int     `yaml:"burst" env:"SEARCH_RATE_LIMIT_BURST" desc:"The number of searches each user may run at once before the rate limit applies." introductionVersion:"%%NEXT%%"`
+}
+
+// ContentAccess restricts the fields of the search results to the users with one of the given roles
+type ContentAccess struct {
+	RestrictedFields []string `yaml:"restricted_fields" env:"SEARCH_CONTENT_ACCESS_RESTRICTED_FIELDS" desc:"The fields which are omitted from the search results of users without one of the content access roles, the queries still match them. Supported values are 'content' for the highlights and the matched version, 'tags', 'audio', 'image', 'location' and 'photo'. Leave empty to return all fields to all users. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Roles            []string `yaml:"roles" env:"SEARCH_CONTENT_ACCESS_ROLES" desc:"The ids of the roles whose users get the restricted fields returned with the search results. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
 // ServiceAccount is the configuration for the used service account
 type ServiceAccount struct {
 	ServiceAccountID     string `yaml:"service_account_id" env:"OC_SERVICE_ACCOUNT_ID;SEARCH_SERVICE_ACCOUNT_ID" desc:"The ID of the service account the service should use. See the 'auth-service' service description for more details." introductionVersion:"1.0.0"`
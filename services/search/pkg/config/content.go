@@ -1,10 +1,19 @@
 package config
 
+import "time"
+
 // Extractor defines which extractor to use
 type Extractor struct {
 	Type             string        `yaml:"type" env:"SEARCH_EXTRACTOR_TYPE" desc:"Defines the content extraction engine. Defaults to 'basic'. Supported values are: 'basic' and 'tika'." introductionVersion:"1.0.0"`
 	CS3AllowInsecure bool          `yaml:"cs3_allow_insecure" env:"OC_INSECURE;SEARCH_EXTRACTOR_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the CS3 source." introductionVersion:"1.0.0"`
 	Tika             ExtractorTika `yaml:"tika"`
+
+	MetadataOnlySpaces    []string `yaml:"metadata_only_spaces" env:"SEARCH_EXTRACTOR_METADATA_ONLY_SPACES" desc:"A list of space IDs for which only metadata like name, tags, size and mtime is indexed. The content of resources in those spaces is not extracted and therefore not searchable. Changing this setting requires a reindex of the affected spaces. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	MetadataOnlyMimeTypes []string `yaml:"metadata_only_mime_types" env:"SEARCH_EXTRACTOR_METADATA_ONLY_MIME_TYPES" desc:"A list of mime types for which only metadata like name, tags, size and mtime is indexed. Wildcards like 'video/*' are supported. Changing this setting requires a reindex. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+
+	DisabledFields []string `yaml:"disabled_fields" env:"SEARCH_EXTRACTOR_DISABLED_FIELDS" desc:"The optional metadata fields which are neither extracted nor indexed, to reduce the size of the index and speed up the indexing. Supported values are 'audio', 'image', 'location' and 'photo'. Changing this setting requires a reindex. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+
+	Timeout time.Duration `yaml:"timeout" env:"SEARCH_EXTRACTOR_TIMEOUT" desc:"The maximum time the content extraction of a single file may take. Files exceeding it are indexed with their metadata only, so malformed files can not stall the indexing. Set to 0 to wait indefinitely. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
 }
 
 // ExtractorTika configures the Tika extractor
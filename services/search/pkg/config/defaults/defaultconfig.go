@@ -8,6 +8,7 @@ import (
 	"github.com/opencloud-eu/opencloud/pkg/shared"
 	"github.com/opencloud-eu/opencloud/pkg/structs"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
 )
 
 // FullDefaultConfig returns a fully initialized default configuration
@@ -34,16 +35,41 @@ func DefaultConfig() *config.Config {
 			Name: "search",
 		},
 		Reva: shared.DefaultRevaConfig(),
+		TokenManager: &config.TokenManager{
+			JWTSecretFileCheckInterval: 10 * time.Second,
+		},
 		Engine: config.Engine{
-			Type: "bleve",
+			Type:                "bleve",
+			HealthCheckInterval: 30 * time.Second,
+			SearchTimeout:       30 * time.Second,
+			IndexTimeout:        5 * time.Minute,
 			Bleve: config.EngineBleve{
-				Datapath: filepath.Join(defaults.BaseDataPath(), "search"),
+				Datapath:         filepath.Join(defaults.BaseDataPath(), "search"),
+				CorruptionPolicy: "fail",
+				FlushOnShutdown:  true,
 			},
 			OpenSearch: config.EngineOpenSearch{
 				ResourceIndex: config.EngineOpenSearchResourceIndex{
 					Name: "opencloud-resource",
 				},
+				MaxDocumentSize: 10 * 1024 * 1024,
+			},
+			Boosts: config.EngineBoosts{
+				Name:    1,
+				Content: 1,
+				Tags:    1,
+			},
+			Highlights: config.EngineHighlights{
+				Fragments:    3,
+				FragmentSize: 200,
+				MaxSize:      1024,
+			},
+			Compaction: config.EngineCompaction{
+				Interval: time.Hour,
 			},
+			DefaultOperator:      "AND",
+			MaxDescendants:       100000,
+			DescendantsChunkSize: 1000,
 		},
 		Extractor: config.Extractor{
 			Type:             "basic",
@@ -52,19 +78,47 @@ func DefaultConfig() *config.Config {
 				TikaURL:        "http://127.0.0.1:9998",
 				CleanStopWords: true,
 			},
+			Timeout: 2 * time.Minute,
 		},
 		Events: config.Events{
-			Endpoint:         "127.0.0.1:9233",
-			Cluster:          "opencloud-cluster",
-			DebounceDuration: 1000,
-			AsyncUploads:     true,
-			NumConsumers:     1,
-			EnableTLS:        false,
-			MaxAckPending:    1000,
-			AckWait:          1 * time.Minute,
+			Endpoint:          "127.0.0.1:9233",
+			Cluster:           "opencloud-cluster",
+			DebounceDuration:  1000,
+			AsyncUploads:      true,
+			NumConsumers:      0,
+			ConsumerName:      "search-pull",
+			EnableTLS:         false,
+			MaxAckPending:     1000,
+			AckWait:           1 * time.Minute,
+			MaxInFlight:       10,
+			MaxProcessingTime: 1 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+
+			StaleEventGracePeriod:  1 * time.Second,
+			DuringReindex:          "queue",
+			MaxQueuedDuringReindex: 10000,
+		},
+		GatewayRetries: config.GatewayRetries{
+			Attempts: 3,
+			Backoff:  500 * time.Millisecond,
 		},
 		ContentExtractionSizeLimit: 20 * 1024 * 1024, // Limit content extraction to <20MB files by default
 		BatchSize:                  500,
+		IndexConcurrency:           4,
+		SlowSearchThreshold:        5 * time.Second,
+		MaxQueryLength:             4096,
+		MaxQueryTerms:              1000,
+		// the image types the thumbnails service supports in all builds
+		PreviewMimeTypes: []string{
+			"image/png", "image/jpg", "image/jpeg", "image/gif", "image/bmp", "image/x-ms-bmp", "image/tiff",
+		},
+		QueryFields: query.SearchableFields(),
+		RateLimit: config.RateLimit{
+			Burst: 20,
+		},
+		IncrementalIndexing: config.IncrementalIndexing{
+			WatermarkPath: filepath.Join(defaults.BaseDataPath(), "search", "watermarks.json"),
+		},
 	}
 }
 
@@ -93,13 +147,13 @@ func EnsureDefaults(cfg *config.Config) {
 		cfg.Tracing = &config.Tracing{}
 	}
 
-	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
-		cfg.TokenManager = &config.TokenManager{
-			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
-		}
-	} else if cfg.TokenManager == nil {
+	if cfg.TokenManager == nil {
 		cfg.TokenManager = &config.TokenManager{}
 	}
+	// the token manager is not nil by default, it holds the default check interval of the jwt secret file
+	if cfg.TokenManager.JWTSecret == "" && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
+		cfg.TokenManager.JWTSecret = cfg.Commons.TokenManager.JWTSecret
+	}
 
 	if cfg.Reva == nil && cfg.Commons != nil {
 		cfg.Reva = structs.CopyOrZeroValue(cfg.Commons.Reva)
@@ -114,5 +168,19 @@ func EnsureDefaults(cfg *config.Config) {
 
 // Sanitize sanitizes the configuration
 func Sanitize(cfg *config.Config) {
-	// no http endpoint to be sanitized
+	if cfg.Events.NumConsumers <= 0 {
+		cfg.Events.NumConsumers = defaultNumConsumers(cfg.Engine.Type)
+	}
+}
+
+// defaultNumConsumers returns the number of event consumers which suits the search engine.
+// OpenSearch is accessed over the network and benefits from many parallel requests. Bleve applies
+// all writes one after the other, a few consumers are enough to keep the content extraction busy.
+func defaultNumConsumers(engineType string) int {
+	switch engineType {
+	case "open-search":
+		return 8
+	default:
+		return 2
+	}
 }
@@ -10,22 +10,75 @@ type Engine struct {
 	Type       string           `yaml:"type" env:"SEARCH_ENGINE_TYPE" desc:"Defines which search engine to use. Defaults to 'bleve'. Supported values are: 'bleve'." introductionVersion:"1.0.0"`
 	Bleve      EngineBleve      `yaml:"bleve"`
 	OpenSearch EngineOpenSearch `yaml:"open_search"`
+	Stopwords  EngineStopwords  `yaml:"stopwords"`
+	Boosts     EngineBoosts     `yaml:"boosts"`
+	Highlights EngineHighlights `yaml:"highlights"`
+	Compaction EngineCompaction `yaml:"compaction"`
+
+	Transliteration    bool   `yaml:"transliteration" env:"SEARCH_ENGINE_TRANSLITERATION" desc:"Indexes the names with cyrillic and greek letters transliterated to latin, so a latin query like 'ivan' also finds a resource named 'Иван'. Supported are the russian, ukrainian, belarusian, bulgarian, serbian and macedonian cyrillic alphabets and the modern greek alphabet. Enabling it requires a re-index." introductionVersion:"%%NEXT%%"`
+	Phonetic           bool   `yaml:"phonetic" env:"SEARCH_ENGINE_PHONETIC" desc:"Indexes the soundex codes of the words of the names, so a query like 'name~phonetic:jonson' also finds a resource named 'Johnson'. The 'open-search' engine requires the analysis-phonetic plugin for it. Enabling it requires a re-index." introductionVersion:"%%NEXT%%"`
+	DefaultOperator    string `yaml:"default_operator" env:"SEARCH_ENGINE_DEFAULT_OPERATOR" desc:"The operator between free-text terms of a query without an explicit operator. Supported values are 'AND' and 'OR'. With 'AND' a resource has to match all terms, with 'OR' it has to match at least one of them. Property restrictions like 'mediatype:pdf' are always combined with 'AND'." introductionVersion:"%%NEXT%%"`
+	MinimumShouldMatch string `yaml:"minimum_should_match" env:"SEARCH_ENGINE_MINIMUM_SHOULD_MATCH" desc:"How many of the free-text terms of a query without an explicit operator a resource has to match. Either a number like '3', a percentage of the terms like '75%' which is rounded down, or a negative number or percentage like '-1' of the terms which may be missing. At least one term has to match. Takes precedence over the default operator if set, the default operator applies if empty." introductionVersion:"%%NEXT%%"`
+
+	MaxDescendants       int `yaml:"max_descendants" env:"SEARCH_ENGINE_MAX_DESCENDANTS" desc:"The maximum number of descendants of a moved, deleted or restored folder which are updated in the index. A folder with more descendants is removed from the index and its space is indexed completely instead. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+	DescendantsChunkSize int `yaml:"descendants_chunk_size" env:"SEARCH_ENGINE_DESCENDANTS_CHUNK_SIZE" desc:"The number of descendants of a moved, deleted or restored folder which are read and updated at once, so the descendants of large folders are never loaded into memory at once." introductionVersion:"%%NEXT%%"`
+
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env:"SEARCH_ENGINE_HEALTH_CHECK_INTERVAL" desc:"The interval in which the health of the search engine backend is checked. The service reports not ready while the backend is unhealthy, so no searches are routed to it. Only supported by the 'open-search' engine. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	SearchTimeout       time.Duration `yaml:"search_timeout" env:"SEARCH_ENGINE_SEARCH_TIMEOUT" desc:"The maximum time a search of the engine may take before it is canceled and reported as timed out to the client. Set to 0 to disable the timeout. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	IndexTimeout        time.Duration `yaml:"index_timeout" env:"SEARCH_ENGINE_INDEX_TIMEOUT" desc:"The maximum time a single request to the engine while indexing, like looking up indexed resources or writing a batch, may take before it is canceled. The writes of the 'bleve' engine are local and can not be canceled. Set to 0 to disable the timeout. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
+// EngineBoosts configures how much the matches of a field contribute to the score of a resource
+type EngineBoosts struct {
+	Name    float64 `yaml:"name" env:"SEARCH_ENGINE_BOOSTS_NAME" desc:"The factor the score of a match in the name is multiplied with. Raise it to rank name matches above content matches." introductionVersion:"%%NEXT%%"`
+	Content float64 `yaml:"content" env:"SEARCH_ENGINE_BOOSTS_CONTENT" desc:"The factor the score of a match in the content is multiplied with." introductionVersion:"%%NEXT%%"`
+	Tags    float64 `yaml:"tags" env:"SEARCH_ENGINE_BOOSTS_TAGS" desc:"The factor the score of a match in the tags is multiplied with." introductionVersion:"%%NEXT%%"`
+}
+
+// EngineHighlights configures the highlighted fragments of the content which are returned with the matches
+type EngineHighlights struct {
+	Disabled     bool `yaml:"disabled" env:"SEARCH_ENGINE_HIGHLIGHTS_DISABLED" desc:"Do not compute the highlighted fragments of the matches, which saves the time of the highlighting. Clients can also skip the highlighting of single searches." introductionVersion:"%%NEXT%%"`
+	Fragments    int  `yaml:"fragments" env:"SEARCH_ENGINE_HIGHLIGHTS_FRAGMENTS" desc:"The maximum number of highlighted fragments of the content returned per match. The best fragments are returned first." introductionVersion:"%%NEXT%%"`
+	FragmentSize int  `yaml:"fragment_size" env:"SEARCH_ENGINE_HIGHLIGHTS_FRAGMENT_SIZE" desc:"The size of a highlighted fragment in characters." introductionVersion:"%%NEXT%%"`
+	MaxSize      int  `yaml:"max_size" env:"SEARCH_ENGINE_HIGHLIGHTS_MAX_SIZE" desc:"The maximum size of all highlighted fragments of a match in bytes. Fragments exceeding it are left out, the best fragment is always returned. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+}
+
+// EngineCompaction configures when the index is compacted to reclaim the space of deleted documents
+type EngineCompaction struct {
+	Interval     time.Duration `yaml:"interval" env:"SEARCH_ENGINE_COMPACTION_INTERVAL" desc:"The interval in which the ratio of deleted to live documents of the index is sampled. Set to 0 to disable the compaction. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	DeletedRatio float64       `yaml:"deleted_ratio" env:"SEARCH_ENGINE_COMPACTION_DELETED_RATIO" desc:"The ratio of deleted or replaced to live documents of the index above which the index is compacted, for example 0.3 compacts the index once there are more than 3 deleted documents for every 10 live ones. Set to 0 to disable the compaction." introductionVersion:"%%NEXT%%"`
+}
+
+// EngineStopwords configures the words which are removed from the content at index and query time
+type EngineStopwords struct {
+	Language string   `yaml:"language" env:"SEARCH_ENGINE_STOPWORDS_LANGUAGE" desc:"The language of the built-in stopword list which is removed from the content at index and query time. Supported values are: 'english'. Leave empty to not use a built-in list. Changing the stopwords requires a reindex." introductionVersion:"%%NEXT%%"`
+	Words    []string `yaml:"words" env:"SEARCH_ENGINE_STOPWORDS_WORDS" desc:"A list of additional stopwords which are removed from the content at index and query time. Changing the stopwords requires a reindex. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
 }
 
 // EngineBleve configures the bleve engine
 type EngineBleve struct {
-	Datapath string `yaml:"data_path" env:"SEARCH_ENGINE_BLEVE_DATA_PATH" desc:"The directory where the filesystem will store search data. If not defined, the root directory derives from $OC_BASE_DATA_PATH/search." introductionVersion:"1.0.0"`
+	Datapath         string `yaml:"data_path" env:"SEARCH_ENGINE_BLEVE_DATA_PATH" desc:"The directory where the filesystem will store search data. If not defined, the root directory derives from $OC_BASE_DATA_PATH/search." introductionVersion:"1.0.0"`
+	CorruptionPolicy string `yaml:"corruption_policy" env:"SEARCH_ENGINE_BLEVE_CORRUPTION_POLICY" desc:"Defines how to handle an existing index which can not be opened because it is corrupt, for example after an unclean shutdown. Supported values are 'fail' and 'recreate'. 'fail' stops the service with instructions how to repair the index. 'recreate' moves the corrupt index aside, creates a new index and re-indexes all spaces." introductionVersion:"%%NEXT%%"`
+	FlushOnShutdown  bool   `yaml:"flush_on_shutdown" env:"SEARCH_ENGINE_BLEVE_FLUSH_ON_SHUTDOWN" desc:"Writes the changes which are collected for the index but not written yet, for example while a space is indexed, before the index is closed on shutdown. Otherwise these changes are lost and only indexed again with the next re-index or change of the resources." introductionVersion:"%%NEXT%%"`
 }
 
 // EngineOpenSearch configures the OpenSearch engine
 type EngineOpenSearch struct {
-	Client        EngineOpenSearchClient        `yaml:"client"`
-	ResourceIndex EngineOpenSearchResourceIndex `yaml:"resource_index"`
+	Client          EngineOpenSearchClient        `yaml:"client"`
+	ResourceIndex   EngineOpenSearchResourceIndex `yaml:"resource_index"`
+	MaxDocumentSize int                           `yaml:"max_document_size" env:"SEARCH_ENGINE_OPEN_SEARCH_MAX_DOCUMENT_SIZE" desc:"The maximum size of a serialized index document in bytes. Larger resources are indexed without their content and with at most 100 tags, so they do not fail the whole bulk request. Set to 0 to disable the limit." introductionVersion:"%%NEXT%%"`
+	Shards          int                           `yaml:"shards" env:"SEARCH_ENGINE_OPEN_SEARCH_SHARDS" desc:"The number of primary shards of the index. Set to 0 to keep the default of a single shard. Changing it requires removing the index and re-indexing all spaces." introductionVersion:"%%NEXT%%"`
+
+	DisableRefreshDuringReindex bool `yaml:"disable_refresh_during_reindex" env:"SEARCH_ENGINE_OPEN_SEARCH_DISABLE_REFRESH_DURING_REINDEX" desc:"Disables the periodic refresh of the index while spaces are re-indexed and restores it afterwards. This reduces the load on OpenSearch during large re-indexes, but newly indexed resources are not searchable until the re-index finished." introductionVersion:"%%NEXT%%"`
+	RefreshAfterWrites          bool `yaml:"refresh_after_writes" env:"SEARCH_ENGINE_OPEN_SEARCH_REFRESH_AFTER_WRITES" desc:"Refreshes the index after each change like a move or delete, so a search right afterwards reflects the change. This adds latency to every index update." introductionVersion:"%%NEXT%%"`
+	RoutingBySpace              bool `yaml:"routing_by_space" env:"SEARCH_ENGINE_OPEN_SEARCH_ROUTING_BY_SPACE" desc:"Routes the documents of a space to the same shard, so searches within a space only hit a single shard instead of all of them. The index has to be created with the routing, enabling or disabling it requires removing the index and re-indexing all spaces." introductionVersion:"%%NEXT%%"`
+	BleveFallback               bool `yaml:"bleve_fallback" env:"SEARCH_ENGINE_OPEN_SEARCH_BLEVE_FALLBACK" desc:"Keeps a local bleve index in the SEARCH_ENGINE_BLEVE_DATA_PATH as warm standby. All changes are written to both indexes and searches are served from the bleve index while OpenSearch fails, also if OpenSearch is unreachable when the service starts, all spaces are indexed once it is reachable. The bleve index needs to be filled with a re-index of all spaces after enabling the fallback." introductionVersion:"%%NEXT%%"`
+	RebuildOutdatedIndex        bool `yaml:"rebuild_outdated_index" env:"SEARCH_ENGINE_OPEN_SEARCH_REBUILD_OUTDATED_INDEX" desc:"Rebuilds an index which differs from the current index definition in the background on startup. The rebuild indexes all spaces again, which takes as long as re-indexing all spaces and loads OpenSearch and the storage meanwhile. Only enable it on a single search service instance, every instance with it enabled rebuilds the index." introductionVersion:"%%NEXT%%"`
 }
 
 // EngineOpenSearchResourceIndex defines the OpenSearch index for resources
 type EngineOpenSearchResourceIndex struct {
-	Name string `yaml:"name" env:"SEARCH_ENGINE_OPEN_SEARCH_RESOURCE_INDEX_NAME" desc:"The name of the OpenSearch index for resources." introductionVersion:"%%NEXT%%"`
+	Name string `yaml:"name" env:"SEARCH_ENGINE_OPEN_SEARCH_RESOURCE_INDEX_NAME" desc:"The name of the OpenSearch alias for resources. The service operates on the index the alias points to, a new index and the alias are created if neither exist. An index of this name created by a previous version is used until the index is rebuilt." introductionVersion:"%%NEXT%%"`
 }
 
 // EngineOpenSearchClient configures the OpenSearch client
@@ -2,6 +2,9 @@ package parser
 
 import (
 	"errors"
+	"fmt"
+	"path"
+	"strings"
 
 	occfg "github.com/opencloud-eu/opencloud/pkg/config"
 	"github.com/opencloud-eu/opencloud/pkg/shared"
@@ -9,6 +12,7 @@ import (
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config/defaults"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/envdecode"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
 )
 
 // ParseConfig loads configuration from known paths.
@@ -34,10 +38,14 @@ func ParseConfig(cfg *config.Config) error {
 }
 
 func Validate(cfg *config.Config) error {
-	if cfg.TokenManager.JWTSecret == "" {
+	if cfg.TokenManager.JWTSecret == "" && cfg.TokenManager.JWTSecretFile == "" {
 		return shared.MissingJWTTokenError(cfg.Service.Name)
 	}
 
+	if cfg.TokenManager.JWTSecretFileCheckInterval < 0 {
+		return fmt.Errorf("the jwt secret file check interval for %s must not be negative", cfg.Service.Name)
+	}
+
 	if cfg.ServiceAccount.ServiceAccountID == "" {
 		return shared.MissingServiceAccountID(cfg.Service.Name)
 	}
@@ -45,5 +53,111 @@ func Validate(cfg *config.Config) error {
 		return shared.MissingServiceAccountSecret(cfg.Service.Name)
 	}
 
+	if !cfg.Events.Disabled && cfg.Events.ConsumerName == "" {
+		return fmt.Errorf("the event consumer name for %s must not be empty", cfg.Service.Name)
+	}
+
+	switch cfg.Events.DuringReindex {
+	case "", "queue", "apply":
+	default:
+		return fmt.Errorf("unsupported handling of the events during reindexing '%s' for %s, supported values are: 'queue', 'apply'", cfg.Events.DuringReindex, cfg.Service.Name)
+	}
+
+	switch cfg.Engine.Bleve.CorruptionPolicy {
+	case "", "fail", "recreate":
+	default:
+		return fmt.Errorf("unsupported bleve corruption policy '%s' for %s, supported values are: 'fail', 'recreate'", cfg.Engine.Bleve.CorruptionPolicy, cfg.Service.Name)
+	}
+
+	switch strings.ToLower(cfg.Engine.Stopwords.Language) {
+	case "", "english":
+	default:
+		return fmt.Errorf("unsupported stopword language '%s' for %s, supported values are: 'english'", cfg.Engine.Stopwords.Language, cfg.Service.Name)
+	}
+
+	switch strings.ToUpper(cfg.Engine.DefaultOperator) {
+	case "", "AND", "OR":
+	default:
+		return fmt.Errorf("unsupported default operator '%s' for %s, supported values are: 'AND', 'OR'", cfg.Engine.DefaultOperator, cfg.Service.Name)
+	}
+
+	if cfg.Engine.MinimumShouldMatch != "" {
+		if _, err := kql.MinimumMatch(cfg.Engine.MinimumShouldMatch, 1); err != nil {
+			return fmt.Errorf("%w for %s", err, cfg.Service.Name)
+		}
+	}
+
+	if cfg.Engine.Boosts.Name < 0 || cfg.Engine.Boosts.Content < 0 || cfg.Engine.Boosts.Tags < 0 {
+		return fmt.Errorf("the search boosts for %s must not be negative", cfg.Service.Name)
+	}
+
+	if cfg.Engine.Highlights.Fragments < 1 || cfg.Engine.Highlights.FragmentSize < 1 {
+		return fmt.Errorf("the number and size of the highlight fragments for %s must be positive", cfg.Service.Name)
+	}
+
+	if cfg.Engine.Highlights.MaxSize < 0 {
+		return fmt.Errorf("the maximum highlights size for %s must not be negative", cfg.Service.Name)
+	}
+
+	if cfg.Engine.MaxDescendants < 0 {
+		return fmt.Errorf("the maximum number of descendants for %s must not be negative", cfg.Service.Name)
+	}
+
+	if cfg.Engine.DescendantsChunkSize < 1 {
+		return fmt.Errorf("the descendants chunk size for %s must be at least 1", cfg.Service.Name)
+	}
+
+	if cfg.MaxPageSize < 0 {
+		return fmt.Errorf("the maximum page size for %s must not be negative", cfg.Service.Name)
+	}
+
+	if cfg.IndexConcurrency < 1 {
+		return fmt.Errorf("the number of concurrently indexed spaces for %s must be at least 1", cfg.Service.Name)
+	}
+
+	if cfg.IndexVersions < 0 {
+		return fmt.Errorf("the number of indexed versions for %s must not be negative", cfg.Service.Name)
+	}
+
+	for _, name := range cfg.ExtendedAttributes {
+		if name == "" || strings.ContainsAny(name, "= ") {
+			return fmt.Errorf("invalid extended attribute name '%s' for %s", name, cfg.Service.Name)
+		}
+	}
+
+	for _, pattern := range cfg.ExcludedPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid excluded path pattern '%s' for %s: %w", pattern, cfg.Service.Name, err)
+		}
+	}
+
+	if cfg.RateLimit.Rate < 0 {
+		return fmt.Errorf("the search rate limit for %s must not be negative", cfg.Service.Name)
+	}
+
+	if cfg.RateLimit.Rate > 0 && cfg.RateLimit.Burst < 1 {
+		return fmt.Errorf("the search rate limit burst for %s must be positive if the rate limit is enabled", cfg.Service.Name)
+	}
+
+	for _, field := range cfg.ContentAccess.RestrictedFields {
+		switch field {
+		case "content", "tags", "audio", "image", "location", "photo":
+		default:
+			return fmt.Errorf("unsupported restricted field '%s' for %s, supported values are: 'content', 'tags', 'audio', 'image', 'location', 'photo'", field, cfg.Service.Name)
+		}
+	}
+
+	for _, field := range cfg.Extractor.DisabledFields {
+		switch field {
+		case "audio", "image", "location", "photo":
+		default:
+			return fmt.Errorf("unsupported disabled field '%s' for %s, supported values are: 'audio', 'image', 'location', 'photo'", field, cfg.Service.Name)
+		}
+	}
+
+	if cfg.MetadataWebhook.Addr != "" && cfg.MetadataWebhook.Secret == "" {
+		return fmt.Errorf("the metadata webhook secret for %s must not be empty if the webhook is enabled", cfg.Service.Name)
+	}
+
 	return nil
 }
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Reva defines all available REVA configuration.
 type Reva struct {
 	Address string `yaml:"address" env:"OC_REVA_GATEWAY" desc:"The CS3 gateway endpoint." introductionVersion:"1.0.0"`
@@ -7,5 +9,8 @@ type Reva struct {
 
 // TokenManager is the config for using the reva token manager
 type TokenManager struct {
-	JWTSecret string `yaml:"jwt_secret" env:"OC_JWT_SECRET;SEARCH_JWT_SECRET" desc:"The secret to mint and validate jwt tokens." introductionVersion:"1.0.0"`
+	JWTSecret                  string        `yaml:"jwt_secret" env:"OC_JWT_SECRET;SEARCH_JWT_SECRET" desc:"The secret to mint and validate jwt tokens." introductionVersion:"1.0.0"`
+	PreviousJWTSecrets         []string      `yaml:"previous_jwt_secrets" env:"SEARCH_PREVIOUS_JWT_SECRETS" desc:"Secrets which were used to mint jwt tokens before the current secret. Tokens signed with one of them are still accepted, which avoids failing searches while the jwt secret is rotated. Remove the secrets once all services use the current secret. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	JWTSecretFile              string        `yaml:"jwt_secret_file" env:"SEARCH_JWT_SECRET_FILE" desc:"The path to a file containing the jwt secret, like a file written by a secret store agent. The first line is the current secret, further lines are previous secrets which are still accepted. The file is read again when it changes, so the secret can be rotated without restarting the service. Takes precedence over the jwt secret and the previous jwt secrets." introductionVersion:"%%NEXT%%"`
+	JWTSecretFileCheckInterval time.Duration `yaml:"jwt_secret_file_check_interval" env:"SEARCH_JWT_SECRET_FILE_CHECK_INTERVAL" desc:"The interval in which the jwt secret file is checked for changes. It is only checked while searches are running. Set to 0 to check it for every search. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
 }
@@ -8,8 +8,11 @@ type Events struct {
 	Endpoint         string `yaml:"endpoint" env:"OC_EVENTS_ENDPOINT;SEARCH_EVENTS_ENDPOINT" desc:"The address of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture." introductionVersion:"1.0.0"`
 	Cluster          string `yaml:"cluster" env:"OC_EVENTS_CLUSTER;SEARCH_EVENTS_CLUSTER" desc:"The clusterID of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture. Mandatory when using NATS as event system." introductionVersion:"1.0.0"`
 	AsyncUploads     bool   `yaml:"async_uploads" env:"OC_ASYNC_UPLOADS;SEARCH_EVENTS_ASYNC_UPLOADS" desc:"Enable asynchronous file uploads." introductionVersion:"1.0.0"`
-	NumConsumers     int    `yaml:"num_consumers" env:"SEARCH_EVENTS_NUM_CONSUMERS" desc:"The amount of concurrent event consumers to start. Event consumers are used for searching files. Multiple consumers increase parallelisation, but will also increase CPU and memory demands." introductionVersion:"1.0.0"`
+	NumConsumers     int    `yaml:"num_consumers" env:"SEARCH_EVENTS_NUM_CONSUMERS" desc:"The amount of concurrent event consumers to start. Event consumers are used for searching files. Multiple consumers increase parallelisation, but will also increase CPU and memory demands. Defaults to 8 for the 'open-search' engine and to 2 for the 'bleve' engine, which applies all writes one after the other." introductionVersion:"1.0.0"`
 	DebounceDuration int    `yaml:"debounce_duration" env:"SEARCH_EVENTS_REINDEX_DEBOUNCE_DURATION" desc:"The duration in milliseconds the reindex debouncer waits before triggering a reindex of a space that was modified." introductionVersion:"1.0.0"`
+	ConsumerName     string `yaml:"consumer_name" env:"SEARCH_EVENTS_CONSUMER_NAME" desc:"The name of the durable consumer the search service uses to receive events. All instances using the same name share the events between them. Deployments sharing one event system, like blue/green deployments, must use different names, for example by prefixing the default with the deployment id." introductionVersion:"%%NEXT%%"`
+
+	PurgeBatchDuration int `yaml:"purge_batch_duration" env:"SEARCH_EVENTS_PURGE_BATCH_DURATION" desc:"The duration in milliseconds purge events of a space are collected before they are applied to the index at once. This reduces the load on the search backend when many items are purged. Set to 0 to apply every purge event immediately." introductionVersion:"%%NEXT%%"`
 
 	TLSInsecure          bool   `yaml:"tls_insecure" env:"OC_INSECURE;SEARCH_EVENTS_TLS_INSECURE" desc:"Whether to verify the server TLS certificates." introductionVersion:"1.0.0"`
 	TLSRootCACertificate string `yaml:"tls_root_ca_certificate" env:"OC_EVENTS_TLS_ROOT_CA_CERTIFICATE;SEARCH_EVENTS_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided SEARCH_EVENTS_TLS_INSECURE will be seen as false." introductionVersion:"1.0.0"`
@@ -19,4 +22,14 @@ type Events struct {
 
 	MaxAckPending int           `yaml:"max_ack_pending" env:"SEARCH_EVENTS_MAX_ACK_PENDING" desc:"The maximum number of unacknowledged messages. This is used to limit the number of messages that can be in flight at the same time." introductionVersion:"%%NEXT%%"`
 	AckWait       time.Duration `yaml:"ack_wait" env:"SEARCH_EVENTS_ACK_WAIT" desc:"The time to wait for an ack before the message is redelivered. This is used to ensure that messages are not lost if the consumer crashes." introductionVersion:"%%NEXT%%"`
+
+	MaxInFlight       int           `yaml:"max_in_flight" env:"SEARCH_EVENTS_MAX_IN_FLIGHT" desc:"The maximum number of events which are processed at the same time, including events whose processing exceeded the maximum processing time. The consumers wait for a free slot once the limit is reached. Defaults to the number of consumers if not set." introductionVersion:"%%NEXT%%"`
+	MaxProcessingTime time.Duration `yaml:"max_processing_time" env:"SEARCH_EVENTS_MAX_PROCESSING_TIME" desc:"The maximum time a consumer waits for an event to be processed before it continues with the next event. The event is reported to be in progress to the event system until it is processed, so it is not redelivered meanwhile. Set to 0 to wait indefinitely. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SEARCH_EVENTS_SHUTDOWN_TIMEOUT" desc:"The maximum time the service waits on shutdown for the events in flight to be processed and the pending reindexing and purges to finish. Set to 0 to wait indefinitely. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+
+	SkipStaleEvents       bool          `yaml:"skip_stale_events" env:"SEARCH_EVENTS_SKIP_STALE_EVENTS" desc:"Skip the trash and move events of resources to which a newer event has been applied. Events can arrive out of order, a stale event would mark a resource as deleted or moved which has been recreated or changed since." introductionVersion:"%%NEXT%%"`
+	StaleEventGracePeriod time.Duration `yaml:"stale_event_grace_period" env:"SEARCH_EVENTS_STALE_EVENT_GRACE_PERIOD" desc:"The time the last applied event of a resource must be newer than a trash or move event to skip the event as stale. It compensates for clock differences between the services. Only used if SEARCH_EVENTS_SKIP_STALE_EVENTS is enabled. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+
+	DuringReindex          string `yaml:"during_reindex" env:"SEARCH_EVENTS_DURING_REINDEX" desc:"How the changes of the resources of a space are handled while the space is indexed completely. 'queue' applies them in order after the indexing, 'apply' applies them right away, which can lose a change if the indexing writes the resource as it read it before the change. Supported values are: 'queue' and 'apply'." introductionVersion:"%%NEXT%%"`
+	MaxQueuedDuringReindex int    `yaml:"max_queued_during_reindex" env:"SEARCH_EVENTS_MAX_QUEUED_DURING_REINDEX" desc:"The maximum number of changes which are queued per space while the space is indexed completely. Further changes are applied right away. Only used if SEARCH_EVENTS_DURING_REINDEX is 'queue'. Set to 0 to not limit the queue." introductionVersion:"%%NEXT%%"`
 }
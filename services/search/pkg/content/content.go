@@ -2,6 +2,8 @@ package content
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bbalet/stopwords"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
@@ -27,6 +29,47 @@ type Document struct {
 	Photo    *libregraph.Photo          `json:"photo,omitempty"`
 }
 
+// OmitFields removes the given optional metadata fields from the document,
+// the supported fields are 'audio', 'image', 'location' and 'photo'.
+func (d *Document) OmitFields(fields ...string) {
+	for _, field := range fields {
+		switch field {
+		case "audio":
+			d.Audio = nil
+		case "image":
+			d.Image = nil
+		case "location":
+			d.Location = nil
+		case "photo":
+			d.Photo = nil
+		}
+	}
+}
+
 func CleanString(content, langCode string) string {
 	return strings.TrimSpace(stopwords.CleanString(content, langCode, true))
 }
+
+// Sanitize replaces invalid UTF-8 sequences and control characters with spaces. Binary or malformed files
+// yield such content, which breaks the serialization of the documents and garbles the highlights.
+// Line breaks and tabs are kept.
+func Sanitize(s string) string {
+	if utf8.ValidString(s) && strings.IndexFunc(s, isUnprintable) == -1 {
+		return s
+	}
+
+	return strings.Map(func(r rune) rune {
+		if isUnprintable(r) {
+			return ' '
+		}
+		return r
+	}, strings.ToValidUTF8(s, " "))
+}
+
+func isUnprintable(r rune) bool {
+	switch r {
+	case '\n', '\r', '\t':
+		return false
+	}
+	return unicode.IsControl(r)
+}
@@ -34,3 +34,33 @@ func TestCleanContent(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		given  string
+		expect string
+	}{
+		{
+			name:   "valid content",
+			given:  "Grüße, 世界\nfoo\tbar",
+			expect: "Grüße, 世界\nfoo\tbar",
+		},
+		{
+			name:   "invalid UTF-8",
+			given:  "foo\xff\xfebar",
+			expect: "foo bar",
+		},
+		{
+			name:   "control characters",
+			given:  "foo\x00bar\x1bbaz\u0085qux\x7f",
+			expect: "foo bar baz qux ",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			Equal(t, tc.expect, content.Sanitize(tc.given))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+// ExtractorFunc is an adapter to use a function as Extractor.
+type ExtractorFunc func(ctx context.Context, ri *provider.ResourceInfo) (Document, error)
+
+// Extract calls f(ctx, ri).
+func (f ExtractorFunc) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	return f(ctx, ri)
+}
+
+// NewExtractorFunc creates an extractor which is registered for a mime type pattern.
+type NewExtractorFunc func(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], logger log.Logger, cfg *config.Config) (Extractor, error)
+
+type registration struct {
+	pattern      string
+	newExtractor NewExtractorFunc
+}
+
+var (
+	registrationsMu sync.Mutex
+	registrations   []registration
+)
+
+// Register registers an extractor for the resources whose mime type matches the pattern,
+// it is added to all registries created by NewRegistryFromRegistrations.
+// Register is meant to be called from the init function of the package providing the extractor,
+// it panics if the pattern is invalid.
+func Register(pattern string, newExtractor NewExtractorFunc) {
+	pattern = strings.ToLower(pattern)
+	if err := validatePattern(pattern); err != nil {
+		panic(err)
+	}
+
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+
+	registrations = append(registrations, registration{pattern: pattern, newExtractor: newExtractor})
+}
+
+// Registry dispatches the extraction of a resource to the extractor registered for its mime type,
+// the resources without a matching extractor are extracted by the fallback extractor.
+type Registry struct {
+	fallback Extractor
+
+	mu         sync.RWMutex
+	extractors []registeredExtractor
+}
+
+type registeredExtractor struct {
+	pattern   string
+	extractor Extractor
+}
+
+// NewRegistry creates a Registry without any registered extractors.
+func NewRegistry(fallback Extractor) *Registry {
+	return &Registry{fallback: fallback}
+}
+
+// NewRegistryFromRegistrations creates a Registry with the extractors of all packages which called Register.
+func NewRegistryFromRegistrations(fallback Extractor, gatewaySelector pool.Selectable[gateway.GatewayAPIClient], logger log.Logger, cfg *config.Config) (*Registry, error) {
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+
+	r := NewRegistry(fallback)
+	for _, reg := range registrations {
+		extractor, err := reg.newExtractor(gatewaySelector, logger, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the extractor for %s: %w", reg.pattern, err)
+		}
+		if err := r.Register(reg.pattern, extractor); err != nil {
+			return nil, err
+		}
+		logger.Info().Str("pattern", reg.pattern).Msg("registered content extractor")
+	}
+
+	return r, nil
+}
+
+// Register registers the extractor for the resources whose mime type matches the pattern.
+// The patterns use the syntax of path.Match, like "image/*" or "application/vnd.ms-*", and are matched case-insensitively.
+// Patterns equal to the mime type take precedence, the other patterns are tried in the order of their registration.
+func (r *Registry) Register(pattern string, extractor Extractor) error {
+	pattern = strings.ToLower(pattern)
+	if err := validatePattern(pattern); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.extractors = append(r.extractors, registeredExtractor{pattern: pattern, extractor: extractor})
+	return nil
+}
+
+// Extract extracts the resource with the extractor registered for its mime type or with the fallback extractor.
+func (r *Registry) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	return r.extractorFor(ri.GetMimeType()).Extract(ctx, ri)
+}
+
+func (r *Registry) extractorFor(mimeType string) Extractor {
+	// the parameters like the charset are not part of the match
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType == "" {
+		return r.fallback
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched Extractor
+	for _, e := range r.extractors {
+		if e.pattern == mimeType {
+			return e.extractor
+		}
+		if ok, _ := path.Match(e.pattern, mimeType); ok && matched == nil {
+			matched = e.extractor
+		}
+	}
+
+	if matched == nil {
+		return r.fallback
+	}
+	return matched
+}
+
+func validatePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty mime type pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid mime type pattern %s: %w", pattern, err)
+	}
+
+	return nil
+}
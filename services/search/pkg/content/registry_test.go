@@ -0,0 +1,59 @@
+package content_test
+
+import (
+	"context"
+
+	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		registry *content.Registry
+		ctx      = context.TODO()
+
+		extractorNamed = func(name string) content.Extractor {
+			return content.ExtractorFunc(func(_ context.Context, ri *storageProvider.ResourceInfo) (content.Document, error) {
+				return content.Document{Name: ri.GetName(), Content: name}, nil
+			})
+		}
+		extractedBy = func(mimeType string) string {
+			doc, err := registry.Extract(ctx, &storageProvider.ResourceInfo{Name: "file", MimeType: mimeType})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Name).To(Equal("file"))
+			return doc.Content
+		}
+	)
+
+	BeforeEach(func() {
+		registry = content.NewRegistry(extractorNamed("fallback"))
+	})
+
+	It("extracts the resources with the extractor registered for their mime type", func() {
+		Expect(registry.Register("application/x-proprietary", extractorNamed("proprietary"))).To(Succeed())
+		Expect(registry.Register("image/*", extractorNamed("image"))).To(Succeed())
+
+		Expect(extractedBy("application/x-proprietary")).To(Equal("proprietary"))
+		Expect(extractedBy("Application/X-Proprietary; version=2")).To(Equal("proprietary"))
+		Expect(extractedBy("image/png")).To(Equal("image"))
+		Expect(extractedBy("application/pdf")).To(Equal("fallback"))
+		Expect(extractedBy("")).To(Equal("fallback"))
+	})
+
+	It("prefers the extractor registered for the exact mime type", func() {
+		Expect(registry.Register("image/*", extractorNamed("image"))).To(Succeed())
+		Expect(registry.Register("image/heic", extractorNamed("heic"))).To(Succeed())
+		Expect(registry.Register("image/*", extractorNamed("other image"))).To(Succeed())
+
+		Expect(extractedBy("image/heic")).To(Equal("heic"))
+		Expect(extractedBy("image/png")).To(Equal("image"))
+	})
+
+	It("rejects invalid patterns", func() {
+		Expect(registry.Register("", extractorNamed("empty"))).ToNot(Succeed())
+		Expect(registry.Register("image/[", extractorNamed("invalid"))).ToNot(Succeed())
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,8 +12,8 @@ import (
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/google/go-tika/tika"
-	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
@@ -26,6 +27,7 @@ type Tika struct {
 	tika                       *tika.Client
 	ContentExtractionSizeLimit uint64
 	CleanStopWords             bool
+	DisabledFields             []string
 }
 
 // NewTikaExtractor creates a new Tika instance.
@@ -48,6 +50,7 @@ func NewTikaExtractor(gatewaySelector pool.Selectable[gateway.GatewayAPIClient],
 		tika:                       tika.NewClient(nil, cfg.Extractor.Tika.TikaURL),
 		ContentExtractionSizeLimit: cfg.ContentExtractionSizeLimit,
 		CleanStopWords:             cfg.Extractor.Tika.CleanStopWords,
+		DisabledFields:             cfg.Extractor.DisabledFields,
 	}, nil
 }
 
@@ -91,11 +94,17 @@ func (t Tika) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document,
 			doc.Content = strings.TrimSpace(fmt.Sprintf("%s %s", doc.Content, content))
 		}
 
-		doc.Location = t.getLocation(meta)
-		doc.Image = t.getImage(meta)
-		doc.Photo = t.getPhoto(meta)
+		if t.extracts("location") {
+			doc.Location = t.getLocation(meta)
+		}
+		if t.extracts("image") {
+			doc.Image = t.getImage(meta)
+		}
+		if t.extracts("photo") {
+			doc.Photo = t.getPhoto(meta)
+		}
 
-		if contentType, err := getFirstValue(meta, "Content-Type"); err == nil && strings.HasPrefix(contentType, "audio/") {
+		if contentType, err := getFirstValue(meta, "Content-Type"); err == nil && strings.HasPrefix(contentType, "audio/") && t.extracts("audio") {
 			doc.Audio = t.getAudio(meta)
 		}
 	}
@@ -107,6 +116,11 @@ func (t Tika) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document,
 	return doc, nil
 }
 
+// extracts reports whether the optional metadata field is extracted
+func (t Tika) extracts(field string) bool {
+	return !slices.Contains(t.DisabledFields, field)
+}
+
 func (t Tika) getImage(meta map[string][]string) *libregraph.Image {
 	var image *libregraph.Image
 	initImage := func() {
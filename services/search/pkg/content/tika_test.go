@@ -163,6 +163,26 @@ var _ = Describe("Tika", func() {
 			Expect(location.Longitude).To(Equal(libregraph.PtrFloat64(11.103870357204285)))
 		})
 
+		It("skips the disabled fields", func() {
+			fullResponse = `[
+				{
+					"geo:lat": "49.48675890884328",
+					"geo:long": "11.103870357204285",
+					"tiff:ImageWidth": "100",
+					"tiff:ImageLength": "100"
+				}
+			]`
+			tika.DisabledFields = []string{"location"}
+			doc, err := tika.Extract(context.TODO(), &provider.ResourceInfo{
+				Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+				Size: 1,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(doc.Location).To(BeNil())
+			Expect(doc.Image).ToNot(BeNil())
+		})
+
 		It("adds image content", func() {
 			fullResponse = `[
 				{
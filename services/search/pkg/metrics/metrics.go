@@ -36,6 +36,18 @@ var (
 		Name:      "events_redelivered",
 		Help:      "Number of redelivered events",
 	})
+	eventsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "events_in_flight",
+		Help:      "Number of events which are currently processed",
+	})
+	unhandledEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "unhandled_events_total",
+		Help:      "Number of consumed events which have no handler",
+	}, []string{"type"})
 	searchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: Namespace,
 		Subsystem: Subsystem,
@@ -43,6 +55,30 @@ var (
 		Help:      "Duration of search operations in seconds",
 		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
 	}, []string{"status"})
+	slowSearches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "slow_searches_total",
+		Help:      "Number of searches which exceeded the slow search threshold",
+	})
+	userSearches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "user_searches_total",
+		Help:      "Number of searches per user, only counted if the rate limit is enabled",
+	}, []string{"user"})
+	userSearchesThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "user_searches_throttled_total",
+		Help:      "Number of searches per user which were rejected by the rate limit",
+	}, []string{"user"})
+	engineFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "engine_fallbacks_total",
+		Help:      "Number of reads which were served by the fallback search engine because the search engine failed",
+	}, []string{"operation"})
 	indexDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: Namespace,
 		Subsystem: Subsystem,
@@ -50,6 +86,12 @@ var (
 		Help:      "Duration of indexing operations in seconds",
 		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
 	}, []string{"status"})
+	extractionTimeouts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "extraction_timeouts_total",
+		Help:      "Number of content extractions which exceeded the extraction timeout",
+	})
 )
 
 // Metrics defines the available metrics of this service.
@@ -59,8 +101,15 @@ type Metrics struct {
 	EventsOutstandingAcks prometheus.Gauge
 	EventsUnprocessed     prometheus.Gauge
 	EventsRedelivered     prometheus.Gauge
+	EventsInFlight        prometheus.Gauge
+	UnhandledEvents       *prometheus.CounterVec
 	SearchDuration        *prometheus.HistogramVec
+	SlowSearches          prometheus.Counter
+	UserSearches          *prometheus.CounterVec
+	UserSearchesThrottled *prometheus.CounterVec
+	EngineFallbacks       *prometheus.CounterVec
 	IndexDuration         *prometheus.HistogramVec
+	ExtractionTimeouts    prometheus.Counter
 }
 
 // New initializes the available metrics.
@@ -70,8 +119,15 @@ func New() *Metrics {
 		EventsOutstandingAcks: eventsOutstandingAcks,
 		EventsUnprocessed:     eventsUnprocessed,
 		EventsRedelivered:     eventsRedelivered,
+		EventsInFlight:        eventsInFlight,
+		UnhandledEvents:       unhandledEvents,
 		SearchDuration:        searchDuration,
+		SlowSearches:          slowSearches,
+		UserSearches:          userSearches,
+		UserSearchesThrottled: userSearchesThrottled,
+		EngineFallbacks:       engineFallbacks,
 		IndexDuration:         indexDuration,
+		ExtractionTimeouts:    extractionTimeouts,
 	}
 
 	return m
@@ -0,0 +1,303 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+	"github.com/tidwall/sjson"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// ErrRebuildRunning is returned if the index is rebuilt while a rebuild is already running
+var ErrRebuildRunning = errors.New("the index is already being rebuilt")
+
+// rebuildControl keeps track of the index which is rebuilt behind the alias
+type rebuildControl struct {
+	running atomic.Bool
+
+	mu     sync.Mutex
+	target *Backend
+}
+
+// withAlias adds the alias to the index definition, so a new index is created together with its alias
+func withAlias(alias string) IndexOption {
+	return func(body []byte) ([]byte, error) {
+		return sjson.SetBytes(body, "aliases."+strings.ReplaceAll(alias, ".", `\.`), map[string]any{})
+	}
+}
+
+// newIndexName returns the name of a new index behind the alias, the names sort by their creation time
+func newIndexName(alias string, now time.Time) string {
+	return alias + "-" + now.UTC().Format("20060102150405.000")
+}
+
+// resolveAlias returns the index the alias points to, an empty string if neither the alias nor an index with its name exist.
+// Indexes created before the alias support carry the name of the alias themselves, they are used as they are
+// until the next rebuild replaces them.
+func resolveAlias(ctx context.Context, client *opensearchgoAPI.Client, alias string) (string, error) {
+	existsResp, err := client.Indices.Exists(ctx, opensearchgoAPI.IndicesExistsReq{
+		Indices: []string{alias},
+	})
+	switch {
+	case existsResp != nil && existsResp.StatusCode == 404:
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to check if index %s exists: %w", alias, err)
+	}
+
+	resp, err := client.Indices.Get(ctx, opensearchgoAPI.IndicesGetReq{
+		Indices: []string{alias},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get index %s: %w", alias, err)
+	}
+
+	indexes := slices.Sorted(maps.Keys(resp.Indices))
+	if len(indexes) != 1 {
+		return "", fmt.Errorf("alias %s points to the indexes %v instead of a single one, %w", alias, indexes, ErrManualActionRequired)
+	}
+
+	return indexes[0], nil
+}
+
+// deleteIndex deletes the index
+func deleteIndex(ctx context.Context, client *opensearchgoAPI.Client, index string) error {
+	resp, err := client.Indices.Delete(ctx, opensearchgoAPI.IndicesDeleteReq{
+		Indices: []string{index},
+	})
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to delete index %s: %w", index, err)
+	case !resp.Acknowledged:
+		return fmt.Errorf("failed to delete index %s: not acknowledged", index)
+	}
+
+	return nil
+}
+
+// Rebuild creates a new index and passes a backend writing to it to build, which has to index all resources.
+// Searches are served by the current index until build succeeded, then the alias is switched to the new index
+// in a single atomic step and the previous index is deleted. Changes made during the rebuild are written to both indexes.
+// The new index is deleted again and the alias is left untouched if build or the switch fails.
+func (b *Backend) Rebuild(build func(search.Engine) error) (err error) {
+	if !b.rebuild.running.CompareAndSwap(false, true) {
+		return ErrRebuildRunning
+	}
+	defer b.rebuild.running.Store(false)
+
+	ctx := context.TODO()
+	current, target, err := b.createTarget(ctx)
+	if err != nil {
+		return err
+	}
+	b.log.Info().Str("alias", b.index).Str("index", current).Str("target", target).Msg("rebuilding the index")
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		b.setRebuildTarget(nil)
+		if err := deleteIndex(context.TODO(), b.client, target); err != nil {
+			b.log.Error().Err(err).Str("index", target).Msg("failed to delete the index of the failed rebuild")
+		}
+	}()
+
+	rebuilt := b.forIndex(target)
+	b.setRebuildTarget(rebuilt)
+
+	if err := build(rebuilt); err != nil {
+		return fmt.Errorf("failed to rebuild index %s: %w", target, err)
+	}
+
+	if err := refreshIndex(ctx, b.client, target); err != nil {
+		return err
+	}
+
+	if err := b.switchAlias(ctx, current, target); err != nil {
+		return err
+	}
+	b.log.Info().Str("alias", b.index).Str("index", target).Msg("switched the alias to the rebuilt index")
+
+	if current != b.index {
+		if err := deleteIndex(ctx, b.client, current); err != nil {
+			b.log.Error().Err(err).Str("index", current).Msg("failed to delete the replaced index")
+		}
+	}
+
+	return nil
+}
+
+// ResetIndex replaces the index behind the alias with a new and empty one, which is created with the current index
+// definition. It returns the number of documents of the replaced index. The alias is switched to the new index in a
+// single atomic step and the previous index is deleted, the new index is deleted again if the switch fails.
+func (b *Backend) ResetIndex() (uint64, error) {
+	if !b.rebuild.running.CompareAndSwap(false, true) {
+		return 0, ErrRebuildRunning
+	}
+	defer b.rebuild.running.Store(false)
+
+	count, err := b.DocCount()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := context.TODO()
+	current, target, err := b.createTarget(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := b.switchAlias(ctx, current, target); err != nil {
+		if err := deleteIndex(context.TODO(), b.client, target); err != nil {
+			b.log.Error().Err(err).Str("index", target).Msg("failed to delete the index of the failed reset")
+		}
+		return 0, err
+	}
+	b.log.Info().Str("alias", b.index).Str("index", target).Uint64("documents", count).Msg("switched the alias to a new and empty index")
+
+	if current != b.index {
+		if err := deleteIndex(ctx, b.client, current); err != nil {
+			b.log.Error().Err(err).Str("index", current).Msg("failed to delete the replaced index")
+		}
+	}
+
+	return count, nil
+}
+
+// createTarget creates a new index which is going to replace the current index behind the alias
+func (b *Backend) createTarget(ctx context.Context) (current, target string, err error) {
+	current, err = resolveAlias(ctx, b.client, b.index)
+	switch {
+	case err != nil:
+		return "", "", err
+	case current == "":
+		return "", "", fmt.Errorf("index %s does not exist", b.index)
+	}
+
+	target = newIndexName(b.index, time.Now())
+	if target == current {
+		return "", "", fmt.Errorf("index %s already exists", target)
+	}
+	if err := IndexManagerLatest.Apply(ctx, target, b.client, b.indexOptions...); err != nil {
+		return "", "", fmt.Errorf("failed to create index %s: %w", target, err)
+	}
+
+	return current, target, nil
+}
+
+// switchAlias points the alias to the target index instead of the current one in a single atomic step,
+// the writes to both indexes end with it.
+func (b *Backend) switchAlias(ctx context.Context, current, target string) error {
+	actions := []map[string]any{
+		{"add": map[string]any{"index": target, "alias": b.index}},
+	}
+	if current == b.index {
+		// the index predates the alias support, it has to be removed in the same step to free its name for the alias
+		actions = append(actions, map[string]any{"remove_index": map[string]any{"index": current}})
+	} else {
+		actions = append(actions, map[string]any{"remove": map[string]any{"index": current, "alias": b.index}})
+	}
+
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return err
+	}
+
+	// no new batches are created while switching, so no change is written to the current index only
+	b.rebuild.mu.Lock()
+	defer b.rebuild.mu.Unlock()
+
+	resp, err := b.client.Aliases(ctx, opensearchgoAPI.AliasesReq{
+		Body: strings.NewReader(string(body)),
+	})
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to switch alias %s to index %s: %w", b.index, target, err)
+	case !resp.Acknowledged:
+		return fmt.Errorf("failed to switch alias %s to index %s: not acknowledged", b.index, target)
+	}
+
+	b.rebuild.target = nil
+	return nil
+}
+
+func (b *Backend) setRebuildTarget(target *Backend) {
+	b.rebuild.mu.Lock()
+	defer b.rebuild.mu.Unlock()
+
+	b.rebuild.target = target
+}
+
+// rebuildTarget returns the backend of the index which is currently rebuilt, nil if there is none
+func (b *Backend) rebuildTarget() *Backend {
+	b.rebuild.mu.Lock()
+	defer b.rebuild.mu.Unlock()
+
+	return b.rebuild.target
+}
+
+// rebuildBatch applies the operations to the current index and to the index which is rebuilt
+type rebuildBatch struct {
+	current search.BatchOperator
+	target  search.BatchOperator
+	log     log.Logger
+}
+
+func (b *rebuildBatch) Upsert(id string, r search.Resource) error {
+	// the sequence of the resource belongs to the document of the current index
+	if err := b.target.Upsert(id, r.WithoutSequence()); err != nil {
+		b.log.Debug().Err(err).Msg("failed to apply the change to the rebuilt index")
+	}
+
+	return b.current.Upsert(id, r)
+}
+
+func (b *rebuildBatch) Move(id string, parentID string, target string) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.Move(id, parentID, target) })
+}
+
+func (b *rebuildBatch) Delete(id string) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.Delete(id) })
+}
+
+func (b *rebuildBatch) Restore(id string) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.Restore(id) })
+}
+
+func (b *rebuildBatch) RestoreMany(ids []string) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.RestoreMany(ids) })
+}
+
+func (b *rebuildBatch) Purge(id string, onlyDeleted bool) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.Purge(id, onlyDeleted) })
+}
+
+func (b *rebuildBatch) PurgeMany(ids []string, onlyDeleted bool) error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.PurgeMany(ids, onlyDeleted) })
+}
+
+func (b *rebuildBatch) Push() error {
+	return b.apply(func(batch search.BatchOperator) error { return batch.Push() })
+}
+
+// apply returns the error of the current index, the rebuilt index fails for resources it does not contain yet,
+// they are indexed with their latest state once the rebuild reaches them.
+func (b *rebuildBatch) apply(operation func(search.BatchOperator) error) error {
+	if err := operation(b.target); err != nil {
+		b.log.Debug().Err(err).Msg("failed to apply the change to the rebuilt index")
+	}
+
+	return operation(b.current)
+}
@@ -2,36 +2,212 @@ package opensearch
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 
 	"github.com/opencloud-eu/opencloud/pkg/conversions"
+	"github.com/opencloud-eu/opencloud/pkg/log"
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
 const defaultBatchSize = 50
 
+// defaultPurgeChunkSize is the number of documents a single delete by query removes while purging
+const defaultPurgeChunkSize = 1000
+
+// defaultDescendantsChunkSize is the number of descendants of a moved, deleted or restored resource a single update changes
+const defaultDescendantsChunkSize = 1000
+
 var (
 	ErrUnhealthyCluster = fmt.Errorf("cluster is not healthy")
 )
 
+// Backend searches and indexes the resources in OpenSearch, it operates on the index through an alias
+// of the configured name, so the index behind it can be replaced by a rebuild.
 type Backend struct {
-	index  string
-	client *opensearchgoAPI.Client
+	index                string
+	client               *opensearchgoAPI.Client
+	indexOptions         []IndexOption
+	maxDocumentSize      int
+	queryOptions         searchQuery.Options
+	disableRefreshOnBulk bool
+	refreshAfterWrites   bool
+	spaceRouting         bool
+	refresh              refreshControl
+	rebuild              rebuildControl
+	purgeChunkSize       int
+	descendantsChunkSize int
+	maxDescendants       int
+	searchTimeout        time.Duration
+	indexTimeout         time.Duration
+	log                  log.Logger
+
+	highlightFragments    int
+	highlightFragmentSize int
+	highlightMaxSize      int
+}
+
+type backendOptions struct {
+	indexOptions         []IndexOption
+	maxDocumentSize      int
+	queryOptions         searchQuery.Options
+	disableRefreshOnBulk bool
+	refreshAfterWrites   bool
+	spaceRouting         bool
+	purgeChunkSize       int
+	descendantsChunkSize int
+	maxDescendants       int
+	searchTimeout        time.Duration
+	indexTimeout         time.Duration
+	onOutdatedIndex      func()
+	logger               log.Logger
+
+	highlightFragments    int
+	highlightFragmentSize int
+	highlightMaxSize      int
+}
+
+// BackendOption configures the backend
+type BackendOption func(o *backendOptions)
+
+// WithIndexOptions adjusts the index definition which gets applied on startup
+func WithIndexOptions(opts ...IndexOption) BackendOption {
+	return func(o *backendOptions) {
+		o.indexOptions = append(o.indexOptions, opts...)
+	}
+}
+
+// WithMaxDocumentSize limits the size of the serialized documents in bytes, 0 disables the limit.
+// Larger documents are indexed without content and with a limited number of tags.
+func WithMaxDocumentSize(size int) BackendOption {
+	return func(o *backendOptions) {
+		o.maxDocumentSize = size
+	}
+}
+
+// WithQueryOptions configures how the query strings are turned into OpenSearch queries.
+// With transliteration, the names with cyrillic and greek letters are indexed transliterated to latin
+// in the Name.translit sub-field, so latin queries find cyrillic and greek names.
+// With phonetic, the soundex codes of the words of the names are indexed in the Name.phonetic sub-field,
+// so names are found by how they sound. The sub-field is analyzed by the phonetic token filter of the
+// OpenSearch analysis-phonetic plugin.
+func WithQueryOptions(queryOptions searchQuery.Options) BackendOption {
+	return func(o *backendOptions) {
+		o.queryOptions = queryOptions
+	}
+}
+
+// WithHighlights returns up to the given number of highlight fragments with the given size in characters per match,
+// the joined highlights of a match are limited to maxSize bytes, 0 disables the limit.
+// Without it, the OpenSearch defaults are used.
+func WithHighlights(fragments, fragmentSize, maxSize int) BackendOption {
+	return func(o *backendOptions) {
+		o.highlightFragments = fragments
+		o.highlightFragmentSize = fragmentSize
+		o.highlightMaxSize = maxSize
+	}
+}
+
+// WithRefreshDisabledDuringBulkIndexing disables the periodic refresh of the index while many resources are indexed at once
+func WithRefreshDisabledDuringBulkIndexing(disabled bool) BackendOption {
+	return func(o *backendOptions) {
+		o.disableRefreshOnBulk = disabled
+	}
 }
 
-func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error) {
+// WithRefreshAfterWrites refreshes the index after each write, so changes are searchable right away at the cost of latency
+func WithRefreshAfterWrites(enabled bool) BackendOption {
+	return func(o *backendOptions) {
+		o.refreshAfterWrites = enabled
+	}
+}
+
+// WithSpaceRouting routes the documents of a space to the same shard and restricts the searches within a space to it.
+// The index requires the routing then, an existing index without it has to be rebuilt.
+func WithSpaceRouting(enabled bool) BackendOption {
+	return func(o *backendOptions) {
+		o.spaceRouting = enabled
+	}
+}
+
+// WithPurgeChunkSize sets the number of documents a single delete by query removes while purging,
+// large trees are purged with multiple requests so none of them times out.
+func WithPurgeChunkSize(size int) BackendOption {
+	return func(o *backendOptions) {
+		o.purgeChunkSize = size
+	}
+}
+
+// WithDescendantLimits updates the descendants of moved, deleted or restored resources in chunks of the given size,
+// resources with more than max descendants are refused with search.ErrTooManyDescendants, 0 disables the limit.
+func WithDescendantLimits(chunkSize, max int) BackendOption {
+	return func(o *backendOptions) {
+		o.descendantsChunkSize = chunkSize
+		o.maxDescendants = max
+	}
+}
+
+// WithTimeouts cancels the requests to OpenSearch which take longer than the given timeouts, 0 disables a timeout.
+// The searchTimeout applies to the searches and lookups, the indexTimeout to each request which changes the index.
+func WithTimeouts(searchTimeout, indexTimeout time.Duration) BackendOption {
+	return func(o *backendOptions) {
+		o.searchTimeout = searchTimeout
+		o.indexTimeout = indexTimeout
+	}
+}
+
+// WithOutdatedIndex keeps using an existing index which differs from the current index definition instead of failing
+// and calls onOutdated. Until the index is rebuilt, the properties added by the current index definition are not searchable.
+func WithOutdatedIndex(onOutdated func()) BackendOption {
+	return func(o *backendOptions) {
+		o.onOutdatedIndex = onOutdated
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger log.Logger) BackendOption {
+	return func(o *backendOptions) {
+		o.logger = logger
+	}
+}
+
+// NewBackend creates a backend which operates on the index the given alias points to,
+// a new index and the alias are created if neither exist.
+func NewBackend(index string, client *opensearchgoAPI.Client, opts ...BackendOption) (*Backend, error) {
+	options := backendOptions{
+		purgeChunkSize:       defaultPurgeChunkSize,
+		descendantsChunkSize: defaultDescendantsChunkSize,
+		logger:               log.NopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.queryOptions.Transliterate {
+		options.indexOptions = append(options.indexOptions, withNameTransliteration())
+	}
+	if options.queryOptions.Phonetic {
+		options.indexOptions = append(options.indexOptions, withNamePhonetic())
+	}
+	if options.spaceRouting {
+		options.indexOptions = append(options.indexOptions, withRequiredRouting())
+	}
+
 	pingResp, err := client.Ping(context.TODO(), &opensearchgoAPI.PingReq{})
 	switch {
 	case err != nil:
@@ -40,14 +216,122 @@ func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error)
 		return nil, fmt.Errorf("%w, failed to ping opensearch", ErrUnhealthyCluster)
 	}
 
-	// apply the index template
-	if err := IndexManagerLatest.Apply(context.TODO(), index, client); err != nil {
+	current, err := resolveAlias(context.TODO(), client, index)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the index template, a new index is created together with the alias
+	indexOptions := options.indexOptions
+	created := current == ""
+	if created {
+		current = newIndexName(index, time.Now())
+		indexOptions = append(slices.Clone(indexOptions), withAlias(index))
+	}
+	err = IndexManagerLatest.Apply(context.TODO(), current, client, indexOptions...)
+	switch {
+	case !created && errors.Is(err, ErrManualActionRequired) && options.onOutdatedIndex != nil:
+		options.logger.Warn().Err(err).Str("alias", index).Str("index", current).Msg("the index is outdated and has to be rebuilt")
+		options.onOutdatedIndex()
+	case err != nil:
 		return nil, fmt.Errorf("failed to apply index template: %w", err)
 	}
 
 	// first check if the cluster is healthy
+	if err := checkClusterHealth(context.TODO(), client, index); err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		index:                index,
+		client:               client,
+		indexOptions:         options.indexOptions,
+		maxDocumentSize:      options.maxDocumentSize,
+		queryOptions:         options.queryOptions,
+		disableRefreshOnBulk: options.disableRefreshOnBulk,
+		refreshAfterWrites:   options.refreshAfterWrites,
+		spaceRouting:         options.spaceRouting,
+		purgeChunkSize:       options.purgeChunkSize,
+		descendantsChunkSize: options.descendantsChunkSize,
+		maxDescendants:       options.maxDescendants,
+		searchTimeout:        options.searchTimeout,
+		indexTimeout:         options.indexTimeout,
+		log:                  options.logger,
+
+		highlightFragments:    options.highlightFragments,
+		highlightFragmentSize: options.highlightFragmentSize,
+		highlightMaxSize:      options.highlightMaxSize,
+	}, nil
+}
+
+// forIndex returns a backend with the same options which operates on the given index
+func (b *Backend) forIndex(index string) *Backend {
+	return &Backend{
+		index:                index,
+		client:               b.client,
+		indexOptions:         b.indexOptions,
+		maxDocumentSize:      b.maxDocumentSize,
+		queryOptions:         b.queryOptions,
+		disableRefreshOnBulk: b.disableRefreshOnBulk,
+		refreshAfterWrites:   b.refreshAfterWrites,
+		spaceRouting:         b.spaceRouting,
+		purgeChunkSize:       b.purgeChunkSize,
+		descendantsChunkSize: b.descendantsChunkSize,
+		maxDescendants:       b.maxDescendants,
+		searchTimeout:        b.searchTimeout,
+		indexTimeout:         b.indexTimeout,
+		log:                  b.log,
+
+		highlightFragments:    b.highlightFragments,
+		highlightFragmentSize: b.highlightFragmentSize,
+		highlightMaxSize:      b.highlightMaxSize,
+	}
+}
+
+// CheckHealth reports an ErrUnhealthyCluster if the cluster health of the index is neither green nor yellow
+func (b *Backend) CheckHealth(ctx context.Context) error {
+	return checkClusterHealth(ctx, b.client, b.index)
+}
+
+// IndexStats returns the numbers of live and deleted documents of the primary shards of the index
+// and the size of all its shards, including the replicas
+func (b *Backend) IndexStats(ctx context.Context) (search.IndexStats, error) {
+	resp, err := b.client.Indices.Stats(ctx, &opensearchgoAPI.IndicesStatsReq{
+		Indices: []string{b.index},
+		Metrics: []string{"docs", "store"},
+	})
+	if err != nil {
+		return search.IndexStats{}, convert.OpenSearchError(fmt.Errorf("failed to get index stats: %w", err))
+	}
 
-	resp, err := client.Cluster.Health(context.TODO(), &opensearchgoAPI.ClusterHealthReq{
+	return search.IndexStats{
+		LiveDocs:    uint64(resp.All.Primaries.Docs.Count),
+		DeletedDocs: uint64(resp.All.Primaries.Docs.Deleted),
+		SizeBytes:   uint64(resp.All.Total.Store.SizeInBytes),
+	}, nil
+}
+
+// Compact expunges the deleted documents from the segments of the index, it returns once all shards are done.
+// The segments are not merged into a single one, so the index stays writable without penalty.
+func (b *Backend) Compact(ctx context.Context) error {
+	resp, err := b.client.Indices.Forcemerge(ctx, &opensearchgoAPI.IndicesForcemergeReq{
+		Indices: []string{b.index},
+		Params: opensearchgoAPI.IndicesForcemergeParams{
+			OnlyExpungeDeletes: conversions.ToPointer(true),
+		},
+	})
+	switch {
+	case err != nil:
+		return convert.OpenSearchError(fmt.Errorf("failed to force merge the index: %w", err))
+	case resp.Shards.Failed > 0:
+		return fmt.Errorf("failed to force merge %d of %d shards of the index", resp.Shards.Failed, resp.Shards.Total)
+	}
+
+	return nil
+}
+
+func checkClusterHealth(ctx context.Context, client *opensearchgoAPI.Client, index string) error {
+	resp, err := client.Cluster.Health(ctx, &opensearchgoAPI.ClusterHealthReq{
 		Indices: []string{index},
 		Params: opensearchgoAPI.ClusterHealthParams{
 			Local:   opensearchgoAPI.ToPointer(true),
@@ -56,45 +340,36 @@ func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error)
 	})
 	switch {
 	case err != nil:
-		return nil, fmt.Errorf("%w, failed to get cluster health: %w", ErrUnhealthyCluster, err)
+		return fmt.Errorf("%w, failed to get cluster health: %w", ErrUnhealthyCluster, err)
 	case resp.TimedOut:
-		return nil, fmt.Errorf("%w, cluster health request timed out", ErrUnhealthyCluster)
+		return fmt.Errorf("%w, cluster health request timed out", ErrUnhealthyCluster)
 	case resp.Status != "green" && resp.Status != "yellow":
-		return nil, fmt.Errorf("%w, cluster health is not green or yellow: %s", ErrUnhealthyCluster, resp.Status)
+		return fmt.Errorf("%w, cluster health is not green or yellow: %s", ErrUnhealthyCluster, resp.Status)
 	}
 
-	return &Backend{index: index, client: client}, nil
+	return nil
 }
 
 func (b *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
-	boolQuery, err := convert.KQLToOpenSearchBoolQuery(sir.Query)
+	ctx, cancel := requestContext(ctx, b.searchTimeout)
+	defer cancel()
+
+	// the paths of the hits are not loaded when only counting, grouping or returning ids, restrict the path in the query instead
+	boolQuery, err := b.indexQuery(sir, sir.CountOnly || sir.GroupByParent || sir.IdsOnly)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert KQL query to OpenSearch bool query: %w", err)
+		return nil, err
 	}
 
-	// filter out deleted resources
-	boolQuery.Filter(
-		osu.NewTermQuery[bool]("Deleted").Value(false),
-	)
-
-	if sir.Ref != nil {
-		// if a reference is provided, filter by the root ID
-		boolQuery.Filter(
-			osu.NewTermQuery[string]("RootID").Value(
-				storagespace.FormatResourceID(
-					&storageProvider.ResourceId{
-						StorageId: sir.Ref.GetResourceId().GetStorageId(),
-						SpaceId:   sir.Ref.GetResourceId().GetSpaceId(),
-						OpaqueId:  sir.Ref.GetResourceId().GetOpaqueId(),
-					},
-				),
-			),
-		)
+	if sir.GroupByParent {
+		return b.searchParents(ctx, boolQuery, b.searchRouting(sir), sir.PageSize)
 	}
 
-	searchParams := opensearchgoAPI.SearchParams{}
+	searchParams := opensearchgoAPI.SearchParams{Routing: b.searchRouting(sir)}
 
 	switch {
+	case sir.CountOnly:
+		searchParams.Size = conversions.ToPointer(0)
+		searchParams.TrackTotalHits = true
 	case sir.PageSize == -1:
 		searchParams.Size = conversions.ToPointer(1000)
 	case sir.PageSize == 0:
@@ -103,18 +378,44 @@ func (b *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequ
 		searchParams.Size = conversions.ToPointer(int(sir.PageSize))
 	}
 
+	if sir.IdsOnly && !sir.CountOnly {
+		return b.searchIDs(ctx, boolQuery, searchParams)
+	}
+
+	searchParams.SourceExcludes = sourceExcludes(sir.OmitFields)
+
+	var highlight *osu.BodyParamHighlight
+	if !sir.NoHighlight && !slices.Contains(sir.OmitFields, search.FieldContent) {
+		highlight = &osu.BodyParamHighlight{
+			PreTags:  []string{"<mark>"},
+			PostTags: []string{"</mark>"},
+			Fields: map[string]osu.BodyParamHighlight{
+				"Content": {
+					NumberOfFragments: b.highlightFragments,
+					FragmentSize:      b.highlightFragmentSize,
+					// best fragments first, the joined highlights are cut after the fragments exceeding the max size
+					Order: "score",
+				},
+				// the highlights of the versions tell which version matched
+				"Versions.Content": {
+					NumberOfFragments: b.highlightFragments,
+					FragmentSize:      b.highlightFragmentSize,
+				},
+			},
+		}
+	}
+
 	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
 		Indices: []string{b.index},
 		Params:  searchParams,
 	},
 		boolQuery,
 		osu.SearchBodyParams{
-			Highlight: &osu.BodyParamHighlight{
-				PreTags:  []string{"<mark>"},
-				PostTags: []string{"</mark>"},
-				Fields: map[string]osu.BodyParamHighlight{
-					"Content": {},
-				},
+			Highlight: highlight,
+			// order equal-scored hits by their id, so every request returns them in the same order
+			Sort: []map[string]string{
+				{"_score": "desc"},
+				{"ID": "asc"},
 			},
 		},
 	)
@@ -124,19 +425,20 @@ func (b *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequ
 
 	resp, err := b.client.Search(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search: %w", err)
+		return nil, convert.OpenSearchError(fmt.Errorf("failed to search: %w", err))
 	}
 
 	matches := make([]*searchMessage.Match, 0, len(resp.Hits.Hits))
 	totalMatches := resp.Hits.Total.Value
 	for _, hit := range resp.Hits.Hits {
-		match, err := convert.OpenSearchHitToMatch(hit)
+		match, err := convert.OpenSearchHitToMatch(hit, b.highlightMaxSize)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert hit to match: %w", err)
 		}
+		search.OmitFields(match.GetEntity(), sir.OmitFields)
 
 		if sir.Ref != nil {
-			hitPath := strings.TrimSuffix(match.GetEntity().GetRef().GetPath(), "/")
+			hitPath := match.GetEntity().GetRef().GetPath()
 			requestedPath := utils.MakeRelativePath(sir.Ref.Path)
 			isRoot := hitPath == requestedPath
 
@@ -155,6 +457,248 @@ func (b *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequ
 	}, nil
 }
 
+// indexQuery builds the query of the search request, which skips the deleted resources and restricts the matches to the
+// root of the reference. The path of the reference is only part of the query if restrictPath is set.
+func (b *Backend) indexQuery(sir *searchService.SearchIndexRequest, restrictPath bool) (*osu.BoolQuery, error) {
+	var boolQuery *osu.BoolQuery
+	switch {
+	case search.IsEmptyQuery(sir.Query) && sir.Ref == nil:
+		return nil, search.ErrEmptyQueryWithoutScope
+	case search.IsEmptyQuery(sir.Query):
+		// an empty query lists all resources within the reference, a bool query with filters only matches all of them
+		boolQuery = osu.NewBoolQuery()
+	default:
+		var err error
+		boolQuery, err = convert.KQLToOpenSearchBoolQuery(sir.Query, b.queryOptions)
+		if err != nil {
+			if searchQuery.IsValidationError(err) {
+				return nil, errtypes.BadRequest(err.Error())
+			}
+			return nil, fmt.Errorf("failed to convert KQL query to OpenSearch bool query: %w", err)
+		}
+	}
+
+	// filter out deleted resources
+	boolQuery.Filter(
+		osu.NewTermQuery[bool]("Deleted").Value(false),
+	)
+
+	if sir.Ref != nil {
+		// if a reference is provided, filter by the root ID
+		boolQuery.Filter(
+			osu.NewTermQuery[string]("RootID").Value(
+				storagespace.FormatResourceID(
+					&storageProvider.ResourceId{
+						StorageId: sir.Ref.GetResourceId().GetStorageId(),
+						SpaceId:   sir.Ref.GetResourceId().GetSpaceId(),
+						OpaqueId:  sir.Ref.GetResourceId().GetOpaqueId(),
+					},
+				),
+			),
+		)
+
+		if requestedPath := utils.MakeRelativePath(sir.Ref.Path); restrictPath && requestedPath != "." {
+			boolQuery.Filter(
+				osu.NewTermQuery[string]("Path").Value(strings.ToLower(requestedPath)),
+			)
+		}
+	}
+
+	return boolQuery, nil
+}
+
+// searchRouting returns the routing of the space the search request is restricted to,
+// nil if the documents are not routed by space or the search is not restricted to a space.
+func (b *Backend) searchRouting(sir *searchService.SearchIndexRequest) []string {
+	if !b.spaceRouting || sir.Ref == nil {
+		return nil
+	}
+
+	rootID := sir.Ref.GetResourceId()
+	return []string{storagespace.FormatStorageID(rootID.GetStorageId(), rootID.GetSpaceId())}
+}
+
+// SumField returns the sum of the numeric field over the files matching the search request, the containers are left out
+// as their sizes already add up the sizes of their children. The sum is calculated by an aggregation without loading the hits.
+func (b *Backend) SumField(ctx context.Context, sir *searchService.SearchIndexRequest, field string) (float64, error) {
+	ctx, cancel := requestContext(ctx, b.searchTimeout)
+	defer cancel()
+
+	boolQuery, err := b.indexQuery(sir, true)
+	if err != nil {
+		return 0, err
+	}
+	boolQuery.MustNot(
+		osu.NewTermQuery[uint64]("Type").Value(uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER)),
+	)
+
+	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
+		Indices: []string{b.index},
+		Params: opensearchgoAPI.SearchParams{
+			Size:    conversions.ToPointer(0),
+			Routing: b.searchRouting(sir),
+		},
+	},
+		boolQuery,
+		osu.SearchBodyParams{
+			Aggs: map[string]osu.BodyParamAggregation{
+				"sum": {
+					Sum: &osu.BodyParamSumAggregation{
+						Field: field,
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	resp, err := b.client.Search(ctx, req)
+	if err != nil {
+		return 0, convert.OpenSearchError(fmt.Errorf("failed to search: %w", err))
+	}
+
+	var aggregations struct {
+		Sum struct {
+			Value float64 `json:"value"`
+		} `json:"sum"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggregations); err != nil {
+		return 0, fmt.Errorf("failed to decode the sum: %w", err)
+	}
+
+	return aggregations.Sum.Value, nil
+}
+
+// searchParents returns the distinct parents of the matching resources with their number of matches instead of the matches,
+// they are collected by a terms aggregation on the parent ids.
+func (b *Backend) searchParents(ctx context.Context, boolQuery *osu.BoolQuery, routing []string, pageSize int32) (*searchService.SearchIndexResponse, error) {
+	size := int(pageSize)
+	switch pageSize {
+	case -1:
+		size = 1000
+	case 0:
+		size = 200
+	}
+
+	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
+		Indices: []string{b.index},
+		Params: opensearchgoAPI.SearchParams{
+			Size:           conversions.ToPointer(0),
+			TrackTotalHits: true,
+			Routing:        routing,
+		},
+	},
+		boolQuery,
+		osu.SearchBodyParams{
+			Aggs: map[string]osu.BodyParamAggregation{
+				"parents": {
+					Terms: &osu.BodyParamTermsAggregation{
+						Field: "ParentID",
+						// the space root has no parent, its bucket is dropped
+						Size: size + 1,
+						Order: []map[string]string{
+							{"_count": "desc"},
+							{"_key": "asc"},
+						},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	resp, err := b.client.Search(ctx, req)
+	if err != nil {
+		return nil, convert.OpenSearchError(fmt.Errorf("failed to search: %w", err))
+	}
+
+	var aggregations struct {
+		Parents struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int32  `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"parents"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggregations); err != nil {
+		return nil, fmt.Errorf("failed to decode the parents: %w", err)
+	}
+
+	counts := make(map[string]int32, len(aggregations.Parents.Buckets))
+	for _, bucket := range aggregations.Parents.Buckets {
+		counts[bucket.Key] = bucket.DocCount
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      []*searchMessage.Match{},
+		TotalMatches: int32(resp.Hits.Total.Value),
+		Parents:      search.ParentMatches(counts, size),
+	}, nil
+}
+
+// searchIDs returns the ids of the matching resources with their scores instead of the matches,
+// only the ids of the hits are loaded.
+func (b *Backend) searchIDs(ctx context.Context, boolQuery *osu.BoolQuery, searchParams opensearchgoAPI.SearchParams) (*searchService.SearchIndexResponse, error) {
+	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
+		Indices: []string{b.index},
+		Params:  searchParams,
+	},
+		boolQuery,
+		osu.SearchBodyParams{
+			Source: []string{"ID"},
+			// order equal-scored hits by their id, so every request returns them in the same order
+			Sort: []map[string]string{
+				{"_score": "desc"},
+				{"ID": "asc"},
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	resp, err := b.client.Search(ctx, req)
+	if err != nil {
+		return nil, convert.OpenSearchError(fmt.Errorf("failed to search: %w", err))
+	}
+
+	scores := make(map[string]float32, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		resource, err := conversions.To[search.Resource](hit.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert hit source: %w", err)
+		}
+		scores[resource.ID] = hit.Score
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      []*searchMessage.Match{},
+		TotalMatches: int32(resp.Hits.Total.Value),
+		IdMatches:    search.IDMatches(scores, -1),
+	}, nil
+}
+
+// sourceExcludes returns the document fields which are not loaded for the omitted fields, the query still matches them
+func sourceExcludes(omitFields []string) []string {
+	var excludes []string
+	for _, field := range omitFields {
+		switch field {
+		case search.FieldContent:
+			excludes = append(excludes, "Content", "Versions")
+		case search.FieldTags:
+			excludes = append(excludes, "Tags")
+		case search.FieldAudio, search.FieldImage, search.FieldLocation, search.FieldPhoto:
+			excludes = append(excludes, field)
+		}
+	}
+
+	return excludes
+}
+
 func (b *Backend) DocCount() (uint64, error) {
 	req, err := osu.BuildIndicesCountReq(
 		&opensearchgoAPI.IndicesCountReq{
@@ -166,14 +710,53 @@ func (b *Backend) DocCount() (uint64, error) {
 		return 0, fmt.Errorf("failed to build count request: %w", err)
 	}
 
-	resp, err := b.client.Indices.Count(context.TODO(), req)
+	ctx, cancel := requestContext(context.Background(), b.searchTimeout)
+	defer cancel()
+
+	resp, err := b.client.Indices.Count(ctx, req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count documents: %w", err)
+		return 0, convert.OpenSearchError(fmt.Errorf("failed to count documents: %w", err))
 	}
 
 	return uint64(resp.Count), nil
 }
 
+// GetDocument returns the indexed document of the given resource id, including deleted and hidden resources
+func (b *Backend) GetDocument(id string) (*search.Resource, error) {
+	ctx, cancel := requestContext(context.Background(), b.searchTimeout)
+	defer cancel()
+
+	resource, err := getResourceByID(ctx, b.client, b.index, b.spaceRouting, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resource, nil
+}
+
+// GetDocuments returns the indexed documents of the given resource ids which are part of the index,
+// including deleted and hidden resources. The ids which are not indexed are left out.
+func (b *Backend) GetDocuments(ids []string) ([]*search.Resource, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := requestContext(context.Background(), b.searchTimeout)
+	defer cancel()
+
+	resources, err := getResources(ctx, b.client, b.index, b.spaceRouting, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*search.Resource, 0, len(resources))
+	for i := range resources {
+		found = append(found, &resources[i])
+	}
+
+	return found, nil
+}
+
 func (b *Backend) Upsert(id string, r search.Resource) error {
 	batch, err := b.NewBatch(defaultBatchSize)
 	if err != nil {
@@ -187,6 +770,22 @@ func (b *Backend) Upsert(id string, r search.Resource) error {
 	return batch.Push()
 }
 
+// UpsertMany indexes or updates all given resources at once, the items are keyed by the resource id.
+func (b *Backend) UpsertMany(items map[string]search.Resource) error {
+	batch, err := b.NewBatch(defaultBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for id, r := range items {
+		if err := batch.Upsert(id, r); err != nil {
+			return err
+		}
+	}
+
+	return batch.Push()
+}
+
 func (b *Backend) Move(id string, parentID string, target string) error {
 	batch, err := b.NewBatch(defaultBatchSize)
 	if err != nil {
@@ -226,6 +825,19 @@ func (b *Backend) Restore(id string) error {
 	return batch.Push()
 }
 
+func (b *Backend) RestoreMany(ids []string) error {
+	batch, err := b.NewBatch(defaultBatchSize)
+	if err != nil {
+		return err
+	}
+
+	if err := batch.RestoreMany(ids); err != nil {
+		return err
+	}
+
+	return batch.Push()
+}
+
 func (b *Backend) Purge(id string, onlyDeleted bool) error {
 	batch, err := b.NewBatch(defaultBatchSize)
 	if err != nil {
@@ -239,6 +851,241 @@ func (b *Backend) Purge(id string, onlyDeleted bool) error {
 	return batch.Push()
 }
 
+func (b *Backend) PurgeMany(ids []string, onlyDeleted bool) error {
+	batch, err := b.NewBatch(defaultBatchSize)
+	if err != nil {
+		return err
+	}
+
+	if err := batch.PurgeMany(ids, onlyDeleted); err != nil {
+		return err
+	}
+
+	return batch.Push()
+}
+
+// RenameTag replaces the tag on all resources within the given roots with a single update by query,
+// on all resources if no roots are given. The tags are compared case-insensitively, resources which already
+// carry the new tag keep a single one. A rename which failed halfway can be run again.
+func (b *Backend) RenameTag(oldTag, newTag string, rootIDs []string) error {
+	if oldTag == "" || newTag == "" {
+		return errtypes.BadRequest("the tags must not be empty")
+	}
+	if oldTag == newTag {
+		return nil
+	}
+
+	// the rebuilt index renames the tags of the resources it already contains
+	if target := b.rebuildTarget(); target != nil {
+		if err := target.RenameTag(oldTag, newTag, rootIDs); err != nil {
+			b.log.Debug().Err(err).Msg("failed to apply the change to the rebuilt index")
+		}
+	}
+
+	boolQuery := osu.NewBoolQuery().Filter(osu.NewMatchPhraseQuery("Tags").Query(oldTag))
+	if len(rootIDs) > 0 {
+		roots := make([]osu.Builder, 0, len(rootIDs))
+		for _, rootID := range rootIDs {
+			roots = append(roots, osu.NewTermQuery[string]("RootID").Value(rootID))
+		}
+		boolQuery.Filter(
+			osu.NewBoolQuery().
+				Should(roots...).
+				Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}),
+		)
+	}
+
+	ctx, cancel := requestContext(context.Background(), b.indexTimeout)
+	defer cancel()
+
+	// the phrase query also matches tags which only contain the old one, they are left untouched
+	err := updateByQuery(ctx, b.client, b.index, routingOf(b.spaceRouting, rootIDs...), boolQuery, &osu.BodyParamScript{
+		Source: `
+			List tags = new ArrayList();
+			boolean changed = false;
+			boolean hasNewTag = false;
+			for (def tag : ctx._source.Tags) {
+				def renamed = tag;
+				if (tag.equalsIgnoreCase(params.oldTag)) { renamed = params.newTag; changed = true; }
+				if (renamed.equalsIgnoreCase(params.newTag)) {
+					if (hasNewTag) { changed = true; continue; }
+					hasNewTag = true;
+				}
+				tags.add(renamed);
+			}
+			if (changed) { ctx._source.Tags = tags } else { ctx.op = 'noop' }
+		`,
+		Lang: "painless",
+		Params: map[string]any{
+			"oldTag": oldTag,
+			"newTag": newTag,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.refreshAfterWrites && !b.isBulkIndexing() {
+		return refreshIndex(ctx, b.client, b.index)
+	}
+
+	return nil
+}
+
+// Suggest returns indexed terms of names and contents which are similar to the given term.
+// Only resources within the given references are taken into account, all resources are if no references are given.
+func (b *Backend) Suggest(ctx context.Context, term string, refs []*searchMessage.Reference) ([]string, error) {
+	rootIDs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		rootIDs = append(rootIDs, referenceRootID(ref))
+	}
+
+	fields := []string{"Name", "Content"}
+	suggesters := make(map[string]osu.BodyParamSuggest, len(fields))
+	for _, field := range fields {
+		suggesters[field] = osu.BodyParamSuggest{
+			Text: term,
+			Term: &osu.BodyParamTermSuggester{
+				Field:       field,
+				Size:        search.MaxSuggestions * 2,
+				SuggestMode: "always",
+				MaxEdits:    search.MaxSuggestionDistance,
+			},
+		}
+	}
+
+	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
+		Indices: []string{b.index},
+		Params: opensearchgoAPI.SearchParams{
+			Size:    conversions.ToPointer(0),
+			Routing: routingOf(b.spaceRouting, rootIDs...),
+		},
+	},
+		osu.NewTermQuery[bool]("Deleted").Value(false),
+		osu.SearchBodyParams{Suggest: suggesters},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build suggest request: %w", err)
+	}
+
+	ctx, cancel := requestContext(ctx, b.searchTimeout)
+	defer cancel()
+
+	resp, err := b.client.Search(ctx, req)
+	if err != nil {
+		return nil, convert.OpenSearchError(fmt.Errorf("failed to suggest: %w", err))
+	}
+
+	candidates := make(map[string]int)
+	for _, entries := range resp.Suggest {
+		for _, entry := range entries {
+			for _, option := range entry.Options {
+				candidates[option.Text] += option.Freq
+			}
+		}
+	}
+
+	suggestions := make([]string, 0, search.MaxSuggestions)
+	for _, candidate := range search.RankSuggestions(term, candidates) {
+		if len(suggestions) >= search.MaxSuggestions {
+			break
+		}
+
+		ok, err := b.hasTermMatch(ctx, candidate, refs, rootIDs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// hasTermMatch checks if any not deleted resource within the given references contains the term in its name or content,
+// the root ids of the references route the request.
+func (b *Backend) hasTermMatch(ctx context.Context, term string, refs []*searchMessage.Reference, rootIDs []string) (bool, error) {
+	boolQuery := osu.NewBoolQuery().
+		Filter(
+			osu.NewTermQuery[bool]("Deleted").Value(false),
+			osu.NewBoolQuery().
+				Should(
+					osu.NewMatchPhraseQuery("Name").Query(term),
+					osu.NewMatchPhraseQuery("Content").Query(term),
+				).
+				Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}),
+		)
+
+	if len(refs) > 0 {
+		scopes := make([]osu.Builder, 0, len(refs))
+		for _, ref := range refs {
+			scope := osu.NewBoolQuery().Filter(osu.NewTermQuery[string]("RootID").Value(referenceRootID(ref)))
+			if requestedPath := utils.MakeRelativePath(ref.GetPath()); requestedPath != "." {
+				scope.Filter(osu.NewTermQuery[string]("Path").Value(strings.ToLower(requestedPath)))
+			}
+			scopes = append(scopes, scope)
+		}
+		boolQuery.Filter(
+			osu.NewBoolQuery().
+				Should(scopes...).
+				Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}),
+		)
+	}
+
+	req, err := osu.BuildIndicesCountReq(
+		&opensearchgoAPI.IndicesCountReq{
+			Indices: []string{b.index},
+			Params:  opensearchgoAPI.IndicesCountParams{Routing: routingOf(b.spaceRouting, rootIDs...)},
+		},
+		boolQuery,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to build count request: %w", err)
+	}
+
+	resp, err := b.client.Indices.Count(ctx, req)
+	if err != nil {
+		return false, convert.OpenSearchError(fmt.Errorf("failed to count documents: %w", err))
+	}
+
+	return resp.Count > 0, nil
+}
+
+// referenceRootID returns the formatted id of the root of the reference
+func referenceRootID(ref *searchMessage.Reference) string {
+	return storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: ref.GetResourceId().GetStorageId(),
+		SpaceId:   ref.GetResourceId().GetSpaceId(),
+		OpaqueId:  ref.GetResourceId().GetOpaqueId(),
+	})
+}
+
 func (b *Backend) NewBatch(size int) (search.BatchOperator, error) {
-	return NewBatch(b.client, b.index, size)
+	batch, err := NewBatch(b.client, b.index, size)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.maxDocumentSize = b.maxDocumentSize
+	batch.purgeChunkSize = b.purgeChunkSize
+	batch.descendantsChunkSize = b.descendantsChunkSize
+	batch.maxDescendants = b.maxDescendants
+	batch.timeout = b.indexTimeout
+	// refreshing after each push would defeat a refresh which is disabled for bulk indexing
+	batch.refreshAfterPush = b.refreshAfterWrites && !b.isBulkIndexing()
+	batch.spaceRouting = b.spaceRouting
+	batch.log = b.log
+
+	// the changes made during a rebuild have to reach the rebuilt index as well
+	if target := b.rebuildTarget(); target != nil {
+		targetBatch, err := target.NewBatch(size)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rebuildBatch{current: batch, target: targetBatch, log: b.log}, nil
+	}
+
+	return batch, nil
 }
@@ -1,17 +1,26 @@
 package opensearch_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	opensearchgo "github.com/opensearch-project/opensearch-go/v4"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
@@ -28,6 +37,30 @@ func TestNewBackend(t *testing.T) {
 		require.Nil(t, backend)
 		require.ErrorIs(t, err, opensearch.ErrUnhealthyCluster)
 	})
+
+	t.Run("uses an outdated index until it is rebuilt", func(t *testing.T) {
+		indexName := "opencloud-test-engine-outdated-index"
+		tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+		tc.Require.IndicesReset([]string{indexName})
+		tc.Require.IndicesCreate(indexName, strings.NewReader(opensearch.IndexIndexManagerResourceV1.String()))
+
+		defer tc.Require.IndicesDelete([]string{indexName})
+
+		_, err := opensearch.NewBackend(indexName, tc.Client())
+		require.ErrorIs(t, err, opensearch.ErrManualActionRequired)
+
+		outdated := false
+		backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithOutdatedIndex(func() { outdated = true }))
+		require.NoError(t, err)
+		require.True(t, outdated)
+
+		require.NoError(t, backend.Rebuild(func(search.Engine) error { return nil }))
+
+		outdated = false
+		_, err = opensearch.NewBackend(indexName, tc.Client(), opensearch.WithOutdatedIndex(func() { outdated = true }))
+		require.NoError(t, err)
+		require.False(t, outdated)
+	})
 }
 
 func TestEngine_Search(t *testing.T) {
@@ -55,6 +88,16 @@ func TestEngine_Search(t *testing.T) {
 		require.Equal(t, document.ID, fmt.Sprintf("%s$%s!%s", resp.Matches[0].Entity.Id.StorageId, resp.Matches[0].Entity.Id.SpaceId, resp.Matches[0].Entity.Id.OpaqueId))
 	})
 
+	t.Run("aborts the search if the request is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := backend.Search(ctx, &searchService.SearchIndexRequest{
+			Query: fmt.Sprintf(`"%s"`, document.Name),
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
 	t.Run("ignores files that are marked as deleted", func(t *testing.T) {
 		deletedDocument := opensearchtest.Testdata.Resources.File
 		deletedDocument.ID = "1$2!4"
@@ -71,185 +114,387 @@ func TestEngine_Search(t *testing.T) {
 		require.Equal(t, int32(1), resp.TotalMatches)
 		require.Equal(t, document.ID, fmt.Sprintf("%s$%s!%s", resp.Matches[0].Entity.Id.StorageId, resp.Matches[0].Entity.Id.SpaceId, resp.Matches[0].Entity.Id.OpaqueId))
 	})
+
+	t.Run("highlights the content", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: "content:dummy",
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+		require.Equal(t, "<mark>dummy</mark> content", resp.Matches[0].Entity.Highlights)
+	})
+
+	t.Run("skips the highlighting if requested", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query:       "content:dummy",
+			NoHighlight: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+		require.Empty(t, resp.Matches[0].Entity.Highlights)
+	})
+
+	t.Run("omits the restricted fields", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query:      "content:dummy",
+			OmitFields: []string{search.FieldContent},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+		require.Equal(t, document.Name, resp.Matches[0].Entity.Name)
+		require.Empty(t, resp.Matches[0].Entity.Highlights)
+	})
+
+	t.Run("only counts the matches", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query:     fmt.Sprintf(`"%s"`, document.Name),
+			CountOnly: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Matches)
+		require.Equal(t, int32(1), resp.TotalMatches)
+	})
+
+	t.Run("returns the distinct parents of the matches", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query:         fmt.Sprintf(`"%s"`, document.Name),
+			GroupByParent: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Matches)
+		require.Equal(t, int32(1), resp.TotalMatches)
+		require.Len(t, resp.Parents, 1)
+		parentID := resp.Parents[0].GetParentId()
+		require.Equal(t, document.ParentID, parentID.GetStorageId()+"$"+parentID.GetSpaceId()+"!"+parentID.GetOpaqueId())
+		require.Equal(t, int32(1), resp.Parents[0].GetMatches())
+	})
+
+	t.Run("returns only the ids and scores of the matches", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query:   fmt.Sprintf(`"%s"`, document.Name),
+			IdsOnly: true,
+		})
+		require.NoError(t, err)
+		require.Empty(t, resp.Matches)
+		require.Equal(t, int32(1), resp.TotalMatches)
+		require.Len(t, resp.IdMatches, 1)
+		id := resp.IdMatches[0].GetId()
+		require.Equal(t, document.ID, id.GetStorageId()+"$"+id.GetSpaceId()+"!"+id.GetOpaqueId())
+		require.Positive(t, resp.IdMatches[0].GetScore())
+	})
+
+	t.Run("lists all resources of the scope for an empty query", func(t *testing.T) {
+		rootID, err := storagespace.ParseID(document.RootID)
+		require.NoError(t, err)
+
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: " ",
+			Ref: &searchMessage.Reference{
+				ResourceId: &searchMessage.ResourceID{
+					StorageId: rootID.GetStorageId(),
+					SpaceId:   rootID.GetSpaceId(),
+					OpaqueId:  rootID.GetOpaqueId(),
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+		require.Equal(t, document.Name, resp.Matches[0].Entity.Name)
+	})
+
+	t.Run("rejects an empty query without a scope", func(t *testing.T) {
+		_, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{Query: ""})
+		require.ErrorIs(t, err, search.ErrEmptyQueryWithoutScope)
+	})
 }
 
-func TestEngine_Upsert(t *testing.T) {
-	indexName := "opencloud-test-engine-upsert"
+func TestEngine_Stopwords(t *testing.T) {
+	indexName := "opencloud-test-engine-stopwords"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
-	tc.Require.IndicesCount([]string{indexName}, nil, 0)
 
 	defer tc.Require.IndicesDelete([]string{indexName})
 
-	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithIndexOptions(opensearch.WithStopwords("english", []string{"fox"})))
 	require.NoError(t, err)
 
-	t.Run("upsert with full document", func(t *testing.T) {
-		document := opensearchtest.Testdata.Resources.File
-		require.NoError(t, backend.Upsert(document.ID, document))
+	document := opensearchtest.Testdata.Resources.File
+	document.Content = "the quick brown fox"
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	t.Run("ignores stopwords", func(t *testing.T) {
+		for _, query := range []string{"content:the", "content:fox"} {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, int32(0), resp.TotalMatches, query)
+		}
+	})
+
+	t.Run("finds other words", func(t *testing.T) {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: "content:quick",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), resp.TotalMatches)
 	})
 }
 
-func TestEngine_Move(t *testing.T) {
-	indexName := "opencloud-test-engine-move"
+func TestEngine_Transliteration(t *testing.T) {
+	indexName := "opencloud-test-engine-transliteration"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
-	tc.Require.IndicesCount([]string{indexName}, nil, 0)
 
 	defer tc.Require.IndicesDelete([]string{indexName})
 
-	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithQueryOptions(searchQuery.Options{Transliterate: true}))
 	require.NoError(t, err)
 
-	t.Run("moves the document to a new path", func(t *testing.T) {
-		document := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	document := opensearchtest.Testdata.Resources.File
+	document.Name = "Иван Петров.pdf"
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
 
-		body := opensearchtest.JSONMustMarshal(t, map[string]any{
-			"query": map[string]any{
-				"ids": map[string]any{
-					"values": []string{document.ID},
-				},
-			},
+	for query, expected := range map[string]int32{
+		"ivan":      1,
+		"Ivan":      1,
+		"petrov*":   1,
+		"иван":      1,
+		"name:ivan": 1,
+		"ivana":     0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
 		})
+	}
+}
 
-		resources := opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
-		require.Len(t, resources, 1)
-		require.Equal(t, document.Path, resources[0].Path)
+func TestEngine_SearchByUser(t *testing.T) {
+	indexName := "opencloud-test-engine-search-by-user"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
 
-		document.Path = "./new/path/to/resource"
-		require.NoError(t, backend.Move(document.ID, document.ParentID, document.Path))
+	defer tc.Require.IndicesDelete([]string{indexName})
 
-		resources = opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
-		require.Len(t, resources, 1)
-		require.Equal(t, document.Path, resources[0].Path)
-	})
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	document := opensearchtest.Testdata.Resources.File
+	document.Owner = "Owner-ID"
+	document.CreatedBy = "creator-id"
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+	for query, expected := range map[string]int32{
+		"owner:owner-id":     1,
+		"owner:other-id":     0,
+		"creator:creator-id": 1,
+		"creator:owner-id":   0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
+		})
+	}
 }
 
-func TestEngine_Delete(t *testing.T) {
-	indexName := "opencloud-test-engine-delete"
+func TestEngine_SearchBySharedWith(t *testing.T) {
+	indexName := "opencloud-test-engine-search-by-shared-with"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
-	tc.Require.IndicesCount([]string{indexName}, nil, 0)
 
 	defer tc.Require.IndicesDelete([]string{indexName})
 
 	backend, err := opensearch.NewBackend(indexName, tc.Client())
 	require.NoError(t, err)
 
-	t.Run("mark document as deleted", func(t *testing.T) {
-		document := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	document := opensearchtest.Testdata.Resources.File
+	document.SharedWith = []string{"User-ID", "group-id"}
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
 
-		body := opensearchtest.JSONMustMarshal(t, map[string]any{
-			"query": map[string]any{
-				"term": map[string]any{
-					"Deleted": map[string]any{
-						"value": true,
-					},
-				},
-			},
+	for query, expected := range map[string]int32{
+		"sharedwith:user-id":  1,
+		"sharedwith:group-id": 1,
+		"sharedwith:other-id": 0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
 		})
-
-		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
-
-		require.NoError(t, backend.Delete(document.ID))
-		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 1)
-	})
+	}
 }
 
-func TestEngine_Restore(t *testing.T) {
-	indexName := "opencloud-test-engine-restore"
+func TestEngine_SearchByExtension(t *testing.T) {
+	indexName := "opencloud-test-engine-search-by-extension"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
-	tc.Require.IndicesCount([]string{indexName}, nil, 0)
 
 	defer tc.Require.IndicesDelete([]string{indexName})
 
 	backend, err := opensearch.NewBackend(indexName, tc.Client())
 	require.NoError(t, err)
 
-	t.Run("mark document as not deleted", func(t *testing.T) {
-		document := opensearchtest.Testdata.Resources.File
-		document.Deleted = true
-		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	document := opensearchtest.Testdata.Resources.File
+	document.Name = "Report.DOCX"
+	document.Extension = search.Extension(document.Name)
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
 
-		body := opensearchtest.JSONMustMarshal(t, map[string]any{
-			"query": map[string]any{
-				"term": map[string]any{
-					"Deleted": map[string]any{
-						"value": true,
-					},
-				},
-			},
+	for query, expected := range map[string]int32{
+		"ext:docx":  1,
+		"ext:DOCX":  1,
+		"ext:.docx": 1,
+		"ext:doc":   0,
+		"ext:pdf":   0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
 		})
-
-		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 1)
-
-		require.NoError(t, backend.Restore(document.ID))
-		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
-	})
+	}
 }
 
-func TestEngine_Purge(t *testing.T) {
-	indexName := "opencloud-test-engine-purge"
+func TestEngine_SearchByCreationTime(t *testing.T) {
+	indexName := "opencloud-test-engine-search-by-creation-time"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
-	tc.Require.IndicesCount([]string{indexName}, nil, 0)
 
 	defer tc.Require.IndicesDelete([]string{indexName})
 
 	backend, err := opensearch.NewBackend(indexName, tc.Client())
 	require.NoError(t, err)
 
-	t.Run("purge with full document", func(t *testing.T) {
-		document := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
-
-		require.NoError(t, backend.Purge(document.ID, false))
+	document := opensearchtest.Testdata.Resources.File
+	document.Ctime = "2024-01-10T09:00:00Z"
+	document.Mtime = "2024-06-01T09:00:00Z"
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 0)
-	})
+	for query, expected := range map[string]int32{
+		"created>=2024-01-01":                     1,
+		"created>=2024-05-01":                     0,
+		"mtime>=2024-05-01":                       1,
+		"created<2024-02-01 mtime>=2024-05-01":    1,
+		"created>=2024-05-01 OR mtime<2024-02-01": 0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
+		})
+	}
+}
 
-	t.Run("purge resource trees", func(t *testing.T) {
-		resourceFolder := opensearchtest.Testdata.Resources.Folder
-		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+func TestEngine_SearchByType(t *testing.T) {
+	indexName := "opencloud-test-engine-search-by-type"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
 
-		resourceFile := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+	defer tc.Require.IndicesDelete([]string{indexName})
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
 
-		require.NoError(t, backend.Purge(resourceFolder.ID, false))
+	for _, document := range []search.Resource{
+		opensearchtest.Testdata.Resources.File,
+		opensearchtest.Testdata.Resources.Folder,
+	} {
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	}
+	tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+	for query, expected := range map[string]int32{
+		"type:folder":                         1,
+		"type:file":                           1,
+		"type:symlink":                        0,
+		"type:file OR type:folder":            2,
+		"type:folder AND name:\"dummy name\"": 0,
+		"type:file AND name:\"dummy name\"":   1,
+	} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+				Query: query,
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, resp.TotalMatches)
+		})
+	}
+}
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 0)
-	})
+func TestEngine_SumField(t *testing.T) {
+	indexName := "opencloud-test-engine-sum-field"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
 
-	t.Run("purge resource trees and ignores undeleted resources", func(t *testing.T) {
-		resourceFolder := opensearchtest.Testdata.Resources.Folder
-		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+	defer tc.Require.IndicesDelete([]string{indexName})
 
-		resourceFile := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+	folder := opensearchtest.Testdata.Resources.Folder
+	folder.Size = 100
+	file := opensearchtest.Testdata.Resources.File
+	otherFile := opensearchtest.Testdata.Resources.File
+	otherFile.ID = "1$1!4"
+	otherFile.ParentID = folder.ParentID
+	otherFile.Path = "./other.jpg"
+	otherFile.Size = 8
+	deletedFile := opensearchtest.Testdata.Resources.File
+	deletedFile.ID = "1$1!5"
+	deletedFile.Path = "./parent d!r/deleted.jpg"
+	deletedFile.Size = 1000
+	deletedFile.Deleted = true
+
+	for _, document := range []search.Resource{folder, file, otherFile, deletedFile} {
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	}
+	tc.Require.IndicesCount([]string{indexName}, nil, 4)
 
-		require.NoError(t, backend.Delete(resourceFile.ID))
-		tc.Require.IndicesRefresh([]string{indexName}, nil)
-		require.NoError(t, backend.Purge(resourceFolder.ID, true))
+	rootID, err := storagespace.ParseID(file.RootID)
+	require.NoError(t, err)
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
-	})
+	for path, expected := range map[string]float64{
+		"":              50,
+		"./parent d!r":  42,
+		"./missing dir": 0,
+	} {
+		t.Run("sums the sizes of the files in "+path, func(t *testing.T) {
+			sum, err := backend.SumField(t.Context(), &searchService.SearchIndexRequest{
+				Ref: &searchMessage.Reference{
+					ResourceId: &searchMessage.ResourceID{
+						StorageId: rootID.GetStorageId(),
+						SpaceId:   rootID.GetSpaceId(),
+						OpaqueId:  rootID.GetOpaqueId(),
+					},
+					Path: path,
+				},
+			}, "Size")
+			require.NoError(t, err)
+			require.Equal(t, expected, sum)
+		})
+	}
 }
 
-func TestEngine_DocCount(t *testing.T) {
-	indexName := "opencloud-test-engine-doc-count"
+func TestEngine_Upsert(t *testing.T) {
+	indexName := "opencloud-test-engine-upsert"
 	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
 	tc.Require.IndicesReset([]string{indexName})
 	tc.Require.IndicesCount([]string{indexName}, nil, 0)
@@ -259,25 +504,1107 @@ func TestEngine_DocCount(t *testing.T) {
 	backend, err := opensearch.NewBackend(indexName, tc.Client())
 	require.NoError(t, err)
 
-	t.Run("ignore deleted documents", func(t *testing.T) {
+	t.Run("upsert with full document", func(t *testing.T) {
 		document := opensearchtest.Testdata.Resources.File
-		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		require.NoError(t, backend.Upsert(document.ID, document))
+
 		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	})
 
-		count, err := backend.DocCount()
+	t.Run("upsert truncates oversized documents", func(t *testing.T) {
+		backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithMaxDocumentSize(1024))
 		require.NoError(t, err)
-		require.Equal(t, uint64(1), count)
 
-		tc.Require.Update(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, map[string]any{
-			"doc": map[string]any{
-				"Deleted": true,
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = "1$2!oversized"
+		document.Content = strings.Repeat("content ", 1024)
+		document.Tags = make([]string, 1000)
+		for i := range document.Tags {
+			document.Tags[i] = fmt.Sprintf("tag-%d", i)
+		}
+		require.NoError(t, backend.Upsert(document.ID, document))
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"ids": map[string]any{
+					"values": []string{document.ID},
+				},
 			},
-		})))
+		})
 
-		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+		resources := opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
+		require.Len(t, resources, 1)
+		require.True(t, resources[0].Truncated)
+		require.Empty(t, resources[0].Content)
+		require.Len(t, resources[0].Tags, 100)
+		require.Equal(t, document.Name, resources[0].Name)
+	})
 
-		count, err = backend.DocCount()
+	t.Run("upsert normalizes the path", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = "1$2!unnormalized"
+		document.Path = "folder//file.pdf/"
+		require.NoError(t, backend.Upsert(document.ID, document))
+
+		resource, err := backend.GetDocument(document.ID)
 		require.NoError(t, err)
-		require.Equal(t, uint64(0), count)
+		require.Equal(t, "./folder/file.pdf", resource.Path)
 	})
 }
+
+func TestEngine_UpsertConflict(t *testing.T) {
+	indexName := "opencloud-test-engine-upsert-conflict"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	document := opensearchtest.Testdata.Resources.File
+	require.NoError(t, backend.Upsert(document.ID, document))
+
+	t.Run("rejects the upsert of a resource which was changed after it was read", func(t *testing.T) {
+		fresh, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+		stale, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+		require.True(t, stale.HasSequence())
+
+		fresh.Tags = []string{"fresh"}
+		require.NoError(t, backend.Upsert(fresh.ID, *fresh))
+
+		stale.Tags = []string{"stale"}
+		require.ErrorIs(t, backend.Upsert(stale.ID, *stale), search.ErrConflict)
+
+		current, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"fresh"}, current.Tags)
+	})
+
+	t.Run("rejects the upsert of a resource which was deleted after it was read", func(t *testing.T) {
+		stale, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, backend.Delete(document.ID))
+		require.ErrorIs(t, backend.Upsert(stale.ID, *stale), search.ErrConflict)
+
+		current, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+		require.True(t, current.Deleted)
+	})
+}
+
+func TestEngine_UpsertMany(t *testing.T) {
+	indexName := "opencloud-test-engine-upsert-many"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	items := make(map[string]search.Resource, 300)
+	for i := range 300 {
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = fmt.Sprintf("1$1!upsert-many-%d", i)
+		document.Name = fmt.Sprintf("file-%d.txt", i)
+		items[document.ID] = document
+	}
+	require.NoError(t, backend.UpsertMany(items))
+
+	tc.Require.IndicesCount([]string{indexName}, nil, 300)
+}
+
+func TestEngine_SpaceRouting(t *testing.T) {
+	indexName := "opencloud-test-engine-space-routing"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client(),
+		opensearch.WithIndexOptions(opensearch.WithShards(3)),
+		opensearch.WithSpaceRouting(true),
+	)
+	require.NoError(t, err)
+
+	folder := opensearchtest.Testdata.Resources.Folder
+	file := opensearchtest.Testdata.Resources.File
+	otherFile := opensearchtest.Testdata.Resources.File
+	otherFile.ID = "1$2!3"
+	otherFile.RootID = "1$2!2"
+	otherFile.ParentID = "1$2!2"
+	otherFile.Path = "./child.jpg"
+	for _, document := range []search.Resource{folder, file, otherFile} {
+		require.NoError(t, backend.Upsert(document.ID, document))
+	}
+
+	routings := func(t *testing.T) map[string]string {
+		hits := tc.Require.Search(indexName, strings.NewReader(`{"query":{"match_all":{}}}`)).Hits
+		routings := make(map[string]string, len(hits))
+		for _, hit := range hits {
+			routings[hit.ID] = hit.Routing
+		}
+		return routings
+	}
+	expected := map[string]string{folder.ID: "1$1", file.ID: "1$1", otherFile.ID: "1$2"}
+
+	t.Run("routes the documents by the space of their root", func(t *testing.T) {
+		require.Equal(t, expected, routings(t))
+	})
+
+	t.Run("rejects documents without routing", func(t *testing.T) {
+		err := tc.DocumentCreate(t.Context(), indexName, "1$1!unrouted", strings.NewReader(opensearchtest.JSONMustMarshal(t, file)))
+		require.Error(t, err)
+	})
+
+	t.Run("moves and deletes the documents with the routing of their space", func(t *testing.T) {
+		require.NoError(t, backend.Move(file.ID, file.RootID, "./moved.jpg"))
+		require.NoError(t, backend.Delete(folder.ID))
+
+		moved, err := backend.GetDocument(file.ID)
+		require.NoError(t, err)
+		require.Equal(t, "./moved.jpg", moved.Path)
+
+		deleted, err := backend.GetDocument(folder.ID)
+		require.NoError(t, err)
+		require.True(t, deleted.Deleted)
+
+		require.Equal(t, expected, routings(t))
+	})
+
+	t.Run("searches only the shard of the space within a space", func(t *testing.T) {
+		searchShards := func(routing []string) int {
+			resp, err := tc.Client().Search(t.Context(), &opensearchgoAPI.SearchReq{
+				Indices: []string{indexName},
+				Params:  opensearchgoAPI.SearchParams{Routing: routing},
+			})
+			require.NoError(t, err)
+			return resp.Shards.Total
+		}
+		require.Equal(t, 3, searchShards(nil))
+		require.Equal(t, 1, searchShards([]string{"1$1"}))
+
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: `name:"dummy name"`,
+			Ref: &searchMessage.Reference{
+				ResourceId: &searchMessage.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+		require.Equal(t, otherFile.ID, fmt.Sprintf("%s$%s!%s", resp.Matches[0].Entity.Id.StorageId, resp.Matches[0].Entity.Id.SpaceId, resp.Matches[0].Entity.Id.OpaqueId))
+	})
+
+	t.Run("moves the documents to the shard of the space they are moved to", func(t *testing.T) {
+		movedFolder := opensearchtest.Testdata.Resources.Folder
+		movedFolder.ID = "1$1!moving"
+		movedFolder.Path = "./moving"
+		movedChild := opensearchtest.Testdata.Resources.File
+		movedChild.ID = "1$1!moving-child"
+		movedChild.ParentID = movedFolder.ID
+		movedChild.Path = "./moving/moving-child.pdf"
+		movedChild.Name = "moving-child.pdf"
+		for _, document := range []search.Resource{movedFolder, movedChild} {
+			require.NoError(t, backend.Upsert(document.ID, document))
+		}
+
+		require.NoError(t, backend.Move(movedFolder.ID, otherFile.RootID, "./moved"))
+
+		routing := routings(t)
+		require.Len(t, routing, 5)
+		require.Equal(t, "1$2", routing[movedFolder.ID])
+		require.Equal(t, "1$2", routing[movedChild.ID])
+
+		moved, err := backend.GetDocument(movedChild.ID)
+		require.NoError(t, err)
+		require.Equal(t, "./moved/moving-child.pdf", moved.Path)
+		require.Equal(t, otherFile.RootID, moved.RootID)
+
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: `name:"moving-child.pdf"`,
+			Ref: &searchMessage.Reference{
+				ResourceId: &searchMessage.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Matches, 1)
+
+		require.NoError(t, backend.Delete(movedFolder.ID))
+		deleted, err := backend.GetDocument(movedChild.ID)
+		require.NoError(t, err)
+		require.True(t, deleted.Deleted)
+	})
+}
+
+func TestEngine_SpaceRouting_SingleShard(t *testing.T) {
+	indexName := "opencloud-test-engine-space-routing-single-shard"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client(),
+		opensearch.WithIndexOptions(opensearch.WithShards(1)),
+		opensearch.WithSpaceRouting(true),
+	)
+	require.NoError(t, err)
+
+	t.Run("keeps the documents moved to a space on the same shard", func(t *testing.T) {
+		movedFolder := opensearchtest.Testdata.Resources.Folder
+		movedFolder.ID = "1$1!ax"
+		movedFolder.Path = "./a/x"
+		movedFolder.Name = "x"
+		movedChild := opensearchtest.Testdata.Resources.File
+		movedChild.ID = "1$1!ax-child"
+		movedChild.ParentID = "1$1!ax-a"
+		movedChild.Path = "./a/x/a/x"
+		movedChild.Name = "x"
+		for _, document := range []search.Resource{movedFolder, movedChild} {
+			require.NoError(t, backend.Upsert(document.ID, document))
+		}
+
+		require.NoError(t, backend.Move(movedFolder.ID, "1$2!2", "./b"))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		moved, err := backend.GetDocument(movedChild.ID)
+		require.NoError(t, err)
+		require.Equal(t, "./b/a/x", moved.Path)
+		require.Equal(t, "1$2!2", moved.RootID)
+	})
+}
+
+func TestEngine_Move(t *testing.T) {
+	indexName := "opencloud-test-engine-move"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("moves the document to a new path", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"ids": map[string]any{
+					"values": []string{document.ID},
+				},
+			},
+		})
+
+		resources := opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
+		require.Len(t, resources, 1)
+		require.Equal(t, document.Path, resources[0].Path)
+
+		document.Path = "./new/path/to/resource"
+		require.NoError(t, backend.Move(document.ID, document.ParentID, document.Path))
+
+		resources = opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
+		require.Len(t, resources, 1)
+		require.Equal(t, document.Path, resources[0].Path)
+	})
+
+	t.Run("reflects the new path in a search right after the move if enabled", func(t *testing.T) {
+		backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithRefreshAfterWrites(true))
+		require.NoError(t, err)
+
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = "refresh-after-move"
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+
+		// only an explicit refresh makes the move searchable
+		_, err = tc.Client().Indices.Settings.Put(t.Context(), opensearchgoAPI.SettingsPutReq{
+			Indices: []string{indexName},
+			Body:    strings.NewReader(`{"index":{"refresh_interval":"-1"}}`),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, backend.Move(document.ID, document.ParentID, "./moved/right-after-move.pdf"))
+
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{
+			Query: `name:"right-after-move.pdf"`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), resp.TotalMatches)
+		require.Equal(t, "./moved/right-after-move.pdf", resp.Matches[0].Entity.Ref.Path)
+	})
+
+	t.Run("moves the document to another space", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = "1$1!cross-space-move"
+		document.Path = "./cross-space-move.pdf"
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+
+		require.NoError(t, backend.Move(document.ID, "1$2!2", "./moved/cross-space-move.pdf"))
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"ids": map[string]any{
+					"values": []string{document.ID},
+				},
+			},
+		})
+		resources := opensearchtest.SearchHitsMustBeConverted[search.Resource](t, tc.Require.Search(indexName, strings.NewReader(body)).Hits)
+		require.Len(t, resources, 1)
+		require.Equal(t, "1$2!2", resources[0].RootID)
+		require.Equal(t, "1$2!2", resources[0].ParentID)
+		require.Equal(t, "./moved/cross-space-move.pdf", resources[0].Path)
+	})
+}
+
+func TestEngine_Delete(t *testing.T) {
+	indexName := "opencloud-test-engine-delete"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("mark document as deleted", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"term": map[string]any{
+					"Deleted": map[string]any{
+						"value": true,
+					},
+				},
+			},
+		})
+
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
+
+		require.NoError(t, backend.Delete(document.ID))
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 1)
+	})
+
+	t.Run("marks the descendants of huge folders as deleted in chunks", func(t *testing.T) {
+		chunkedBackend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithDescendantLimits(10, 0))
+		require.NoError(t, err)
+
+		folder := opensearchtest.Testdata.Resources.Folder
+		folder.ID = "1$1!huge-folder"
+		folder.Path = "./huge-folder"
+		tc.Require.DocumentCreate(indexName, folder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, folder)))
+		for i := range 25 {
+			child := opensearchtest.Testdata.Resources.File
+			child.ID = fmt.Sprintf("1$1!huge-folder-child-%d", i)
+			child.ParentID = folder.ID
+			child.Path = fmt.Sprintf("%s/child-%d.jpg", folder.Path, i)
+			tc.Require.DocumentCreate(indexName, child.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, child)))
+		}
+
+		require.NoError(t, chunkedBackend.Delete(folder.ID))
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"bool": map[string]any{
+					"must": []map[string]any{
+						{"term": map[string]any{"Path": map[string]any{"value": folder.Path}}},
+						{"term": map[string]any{"Deleted": map[string]any{"value": true}}},
+					},
+				},
+			},
+		})
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 26)
+	})
+
+	t.Run("refuses to delete folders with too many descendants", func(t *testing.T) {
+		limitedBackend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithDescendantLimits(10, 2))
+		require.NoError(t, err)
+
+		folder := opensearchtest.Testdata.Resources.Folder
+		folder.ID = "1$1!limited-folder"
+		folder.Path = "./limited-folder"
+		tc.Require.DocumentCreate(indexName, folder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, folder)))
+		for i := range 3 {
+			child := opensearchtest.Testdata.Resources.File
+			child.ID = fmt.Sprintf("1$1!limited-folder-child-%d", i)
+			child.ParentID = folder.ID
+			child.Path = fmt.Sprintf("%s/child-%d.jpg", folder.Path, i)
+			tc.Require.DocumentCreate(indexName, child.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, child)))
+		}
+
+		require.ErrorIs(t, limitedBackend.Delete(folder.ID), search.ErrTooManyDescendants)
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"bool": map[string]any{
+					"must": []map[string]any{
+						{"term": map[string]any{"Path": map[string]any{"value": folder.Path}}},
+						{"term": map[string]any{"Deleted": map[string]any{"value": true}}},
+					},
+				},
+			},
+		})
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
+	})
+}
+
+func TestEngine_Restore(t *testing.T) {
+	indexName := "opencloud-test-engine-restore"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("mark document as not deleted", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		document.Deleted = true
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"term": map[string]any{
+					"Deleted": map[string]any{
+						"value": true,
+					},
+				},
+			},
+		})
+
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 1)
+
+		require.NoError(t, backend.Restore(document.ID))
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
+	})
+}
+
+func TestEngine_RestoreMany(t *testing.T) {
+	indexName := "opencloud-test-engine-restore-many"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("mark overlapping trees as not deleted", func(t *testing.T) {
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		resourceFolder.Deleted = true
+		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+
+		resourceFile := opensearchtest.Testdata.Resources.File
+		resourceFile.Deleted = true
+		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"term": map[string]any{
+					"Deleted": map[string]any{
+						"value": true,
+					},
+				},
+			},
+		})
+
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 2)
+
+		require.NoError(t, backend.RestoreMany([]string{resourceFile.ID, resourceFolder.ID}))
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 0)
+	})
+}
+
+func TestEngine_Purge(t *testing.T) {
+	indexName := "opencloud-test-engine-purge"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("purge with full document", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		require.NoError(t, backend.Purge(document.ID, false))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 0)
+	})
+
+	t.Run("purge resource trees", func(t *testing.T) {
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+
+		resourceFile := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		require.NoError(t, backend.Purge(resourceFolder.ID, false))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 0)
+	})
+
+	t.Run("purge live and trashed descendants even if their path diverged", func(t *testing.T) {
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+
+		resourceFile := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+
+		trashedFolder := opensearchtest.Testdata.Resources.Folder
+		trashedFolder.ID = "1$1!4"
+		trashedFolder.ParentID = resourceFolder.ID
+		trashedFolder.Path = "./former parent/folder"
+		trashedFolder.Deleted = true
+		tc.Require.DocumentCreate(indexName, trashedFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, trashedFolder)))
+
+		trashedFile := opensearchtest.Testdata.Resources.File
+		trashedFile.ID = "1$1!5"
+		trashedFile.ParentID = trashedFolder.ID
+		trashedFile.Path = "./former parent/folder/child.jpg"
+		trashedFile.Deleted = true
+		tc.Require.DocumentCreate(indexName, trashedFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, trashedFile)))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 4)
+
+		require.NoError(t, backend.Purge(resourceFolder.ID, false))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 0)
+	})
+
+	t.Run("purge resource trees and ignores undeleted resources", func(t *testing.T) {
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+
+		resourceFile := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		require.NoError(t, backend.Delete(resourceFile.ID))
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+		require.NoError(t, backend.Purge(resourceFolder.ID, true))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	})
+
+	t.Run("purge huge trashes in chunks", func(t *testing.T) {
+		chunkedBackend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithPurgeChunkSize(10))
+		require.NoError(t, err)
+
+		// the folder is left over from the previous subtest
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		for i := range 25 {
+			trashedFile := opensearchtest.Testdata.Resources.File
+			trashedFile.ID = fmt.Sprintf("1$1!trashed-%d", i)
+			trashedFile.Path = fmt.Sprintf("%s/trashed-%d.jpg", resourceFolder.Path, i)
+			trashedFile.Deleted = true
+			tc.Require.DocumentCreate(indexName, trashedFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, trashedFile)))
+		}
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 26)
+
+		require.NoError(t, chunkedBackend.Purge(resourceFolder.ID, true))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+	})
+}
+
+func TestEngine_PurgeMany(t *testing.T) {
+	indexName := "opencloud-test-engine-purge-many"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("purge overlapping trees and skip unknown resources", func(t *testing.T) {
+		resourceFolder := opensearchtest.Testdata.Resources.Folder
+		tc.Require.DocumentCreate(indexName, resourceFolder.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFolder)))
+
+		resourceFile := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, resourceFile.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, resourceFile)))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+		require.NoError(t, backend.PurgeMany([]string{resourceFile.ID, resourceFolder.ID, "1$2!unknown"}, false))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 0)
+	})
+}
+
+func TestEngine_RenameTag(t *testing.T) {
+	indexName := "opencloud-test-engine-rename-tag"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("rename the tag across many resources", func(t *testing.T) {
+		items := make(map[string]search.Resource)
+		for i := range 120 {
+			document := opensearchtest.Testdata.Resources.File
+			document.ID = fmt.Sprintf("1$1!tagged-%d", i)
+			document.Tags = []string{"Draft", "report"}
+			if i%2 == 0 {
+				document.Tags = append(document.Tags, "final")
+			}
+			items[document.ID] = document
+		}
+		other := opensearchtest.Testdata.Resources.File
+		other.ID = "3$4!other"
+		other.RootID = "3$4!4"
+		other.Tags = []string{"draft"}
+		items[other.ID] = other
+
+		require.NoError(t, backend.UpsertMany(items))
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+
+		require.NoError(t, backend.RenameTag("draft", "final", []string{opensearchtest.Testdata.Resources.File.RootID}))
+
+		for _, id := range []string{"1$1!tagged-0", "1$1!tagged-1"} {
+			resource, err := backend.GetDocument(id)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"final", "report"}, resource.Tags)
+		}
+
+		resource, err := backend.GetDocument(other.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"draft"}, resource.Tags)
+
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+		body := opensearchtest.JSONMustMarshal(t, map[string]any{
+			"query": map[string]any{
+				"match_phrase": map[string]any{
+					"Tags": "final",
+				},
+			},
+		})
+		tc.Require.IndicesCount([]string{indexName}, strings.NewReader(body), 120)
+	})
+}
+
+func TestEngine_DocCount(t *testing.T) {
+	indexName := "opencloud-test-engine-doc-count"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("ignore deleted documents", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		count, err := backend.DocCount()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), count)
+
+		tc.Require.Update(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, map[string]any{
+			"doc": map[string]any{
+				"Deleted": true,
+			},
+		})))
+
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		count, err = backend.DocCount()
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), count)
+	})
+}
+
+func TestEngine_Compact(t *testing.T) {
+	indexName := "opencloud-test-engine-compact"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("expunges the replaced documents", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		for range 3 {
+			require.NoError(t, backend.Upsert(document.ID, document))
+			tc.Require.IndicesRefresh([]string{indexName}, nil)
+		}
+
+		stats, err := backend.IndexStats(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), stats.LiveDocs)
+		require.NotZero(t, stats.SizeBytes)
+
+		require.NoError(t, backend.Compact(context.Background()))
+		tc.Require.IndicesRefresh([]string{indexName}, nil)
+
+		stats, err = backend.IndexStats(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), stats.LiveDocs)
+		require.Zero(t, stats.DeletedDocs)
+	})
+}
+
+func TestEngine_MissingIndex(t *testing.T) {
+	indexName := "opencloud-test-engine-missing-index"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	tc.Require.IndicesDelete([]string{indexName})
+
+	t.Run("reports the index as not ready", func(t *testing.T) {
+		_, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{Query: "*"})
+		require.ErrorIs(t, err, search.ErrIndexNotReady)
+
+		_, err = backend.DocCount()
+		require.ErrorIs(t, err, search.ErrIndexNotReady)
+	})
+}
+
+func TestEngine_GetDocument(t *testing.T) {
+	indexName := "opencloud-test-engine-get-document"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	t.Run("returns the stored document including the flags", func(t *testing.T) {
+		document := opensearchtest.Testdata.Resources.File
+		document.Deleted = true
+		document.Hidden = true
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+		tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+		resource, err := backend.GetDocument(document.ID)
+		require.NoError(t, err)
+		require.Equal(t, document.ID, resource.ID)
+		require.Equal(t, document.Name, resource.Name)
+		require.True(t, resource.Deleted)
+		require.True(t, resource.Hidden)
+	})
+
+	t.Run("returns a not found error for unknown ids", func(t *testing.T) {
+		_, err := backend.GetDocument("unknown")
+		require.IsType(t, errtypes.NotFound(""), err)
+	})
+}
+
+func TestEngine_GetDocuments(t *testing.T) {
+	indexName := "opencloud-test-engine-get-documents"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	file := opensearchtest.Testdata.Resources.File
+	folder := opensearchtest.Testdata.Resources.Folder
+	for _, document := range []search.Resource{file, folder} {
+		tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	}
+	tc.Require.IndicesCount([]string{indexName}, nil, 2)
+
+	t.Run("returns the stored documents and leaves out the unknown ids", func(t *testing.T) {
+		resources, err := backend.GetDocuments([]string{file.ID, "unknown", folder.ID})
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+		require.ElementsMatch(t, []string{file.ID, folder.ID}, []string{resources[0].ID, resources[1].ID})
+	})
+
+	t.Run("returns nothing for unknown ids", func(t *testing.T) {
+		resources, err := backend.GetDocuments([]string{"unknown"})
+		require.NoError(t, err)
+		require.Empty(t, resources)
+	})
+}
+
+func TestEngine_Suggest(t *testing.T) {
+	indexName := "opencloud-test-engine-suggest"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+	tc.Require.IndicesCount([]string{indexName}, nil, 0)
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client())
+	require.NoError(t, err)
+
+	document := opensearchtest.Testdata.Resources.File
+	document.Name = "invoice.pdf"
+	tc.Require.DocumentCreate(indexName, document.ID, strings.NewReader(opensearchtest.JSONMustMarshal(t, document)))
+	tc.Require.IndicesCount([]string{indexName}, nil, 1)
+
+	refs := func(id, path string) []*searchMessage.Reference {
+		rID, err := storagespace.ParseID(id)
+		require.NoError(t, err)
+		return []*searchMessage.Reference{{
+			ResourceId: &searchMessage.ResourceID{StorageId: rID.StorageId, SpaceId: rID.SpaceId, OpaqueId: rID.OpaqueId},
+			Path:       path,
+		}}
+	}
+
+	t.Run("suggests similar terms", func(t *testing.T) {
+		suggestions, err := backend.Suggest(t.Context(), "invoce", refs(document.RootID, ""))
+		require.NoError(t, err)
+		require.Contains(t, suggestions, "invoice")
+	})
+
+	t.Run("ignores terms of other roots", func(t *testing.T) {
+		suggestions, err := backend.Suggest(t.Context(), "invoce", refs("9$8!7", ""))
+		require.NoError(t, err)
+		require.Empty(t, suggestions)
+	})
+
+	t.Run("ignores terms outside of the path of the reference", func(t *testing.T) {
+		suggestions, err := backend.Suggest(t.Context(), "invoce", refs(document.RootID, "./some/other/folder"))
+		require.NoError(t, err)
+		require.Empty(t, suggestions)
+	})
+}
+
+func TestEngine_StartBulkIndexing(t *testing.T) {
+	indexName := "opencloud-test-engine-bulk-indexing"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	refreshInterval := func(t *testing.T) string {
+		resp, err := tc.Client().Indices.Settings.Get(t.Context(), &opensearchgoAPI.SettingsGetReq{
+			Indices:  []string{indexName},
+			Settings: []string{"index.refresh_interval"},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Indices, 1)
+		for _, index := range resp.Indices {
+			return gjson.GetBytes(index.Settings, "index.refresh_interval").String()
+		}
+		return ""
+	}
+
+	t.Run("does not touch the refresh interval if not enabled", func(t *testing.T) {
+		backend, err := opensearch.NewBackend(indexName, tc.Client())
+		require.NoError(t, err)
+
+		end, err := backend.StartBulkIndexing()
+		require.NoError(t, err)
+		require.Empty(t, refreshInterval(t))
+		require.NoError(t, end())
+		require.Empty(t, refreshInterval(t))
+	})
+
+	t.Run("disables the refresh and restores it once all bulk indexing operations ended", func(t *testing.T) {
+		backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithRefreshDisabledDuringBulkIndexing(true))
+		require.NoError(t, err)
+
+		_, err = tc.Client().Indices.Settings.Put(t.Context(), opensearchgoAPI.SettingsPutReq{
+			Indices: []string{indexName},
+			Body:    strings.NewReader(`{"index":{"refresh_interval":"5s"}}`),
+		})
+		require.NoError(t, err)
+
+		endFirst, err := backend.StartBulkIndexing()
+		require.NoError(t, err)
+		require.Equal(t, "-1", refreshInterval(t))
+
+		endSecond, err := backend.StartBulkIndexing()
+		require.NoError(t, err)
+
+		require.NoError(t, endFirst())
+		require.Equal(t, "-1", refreshInterval(t))
+
+		require.NoError(t, endSecond())
+		require.Equal(t, "5s", refreshInterval(t))
+	})
+}
+
+func TestEngine_Rebuild(t *testing.T) {
+	indexName := "opencloud-test-engine-rebuild"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithRefreshAfterWrites(true))
+	require.NoError(t, err)
+
+	totalMatches := func(t *testing.T, query string) int32 {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{Query: query})
+		require.NoError(t, err)
+		return resp.TotalMatches
+	}
+
+	indexes := func(t *testing.T) []string {
+		resp, err := tc.Client().Indices.Get(t.Context(), opensearchgoAPI.IndicesGetReq{
+			Indices: []string{indexName + "*"},
+		})
+		require.NoError(t, err)
+		return slices.Sorted(maps.Keys(resp.Indices))
+	}
+
+	document := opensearchtest.Testdata.Resources.File
+	document.ID = "1$2!old"
+	document.Name = "old.pdf"
+	require.NoError(t, backend.Upsert(document.ID, document))
+	require.Equal(t, int32(1), totalMatches(t, "name:old.pdf"))
+
+	t.Run("serves the searches from the current index until the rebuild is done", func(t *testing.T) {
+		previous := indexes(t)
+
+		require.NoError(t, backend.Rebuild(func(engine search.Engine) error {
+			rebuilt := opensearchtest.Testdata.Resources.File
+			rebuilt.ID = "1$2!rebuilt"
+			rebuilt.Name = "rebuilt.pdf"
+			require.NoError(t, engine.Upsert(rebuilt.ID, rebuilt))
+
+			// changes made during the rebuild reach both indexes
+			changed := opensearchtest.Testdata.Resources.File
+			changed.ID = "1$2!changed"
+			changed.Name = "changed.pdf"
+			require.NoError(t, backend.Upsert(changed.ID, changed))
+
+			// the half-built index is not searchable yet
+			require.Equal(t, int32(1), totalMatches(t, "name:old.pdf"))
+			require.Equal(t, int32(0), totalMatches(t, "name:rebuilt.pdf"))
+			require.Equal(t, int32(1), totalMatches(t, "name:changed.pdf"))
+			return nil
+		}))
+
+		require.Equal(t, int32(0), totalMatches(t, "name:old.pdf"))
+		require.Equal(t, int32(1), totalMatches(t, "name:rebuilt.pdf"))
+		require.Equal(t, int32(1), totalMatches(t, "name:changed.pdf"))
+
+		// the previous index is replaced
+		current := indexes(t)
+		require.Len(t, current, 1)
+		require.NotEqual(t, previous, current)
+	})
+
+	t.Run("keeps the current index if the rebuild fails", func(t *testing.T) {
+		previous := indexes(t)
+
+		err := backend.Rebuild(func(engine search.Engine) error {
+			document := opensearchtest.Testdata.Resources.File
+			document.ID = "1$2!failed"
+			document.Name = "failed.pdf"
+			require.NoError(t, engine.Upsert(document.ID, document))
+			return errors.New("walking the spaces failed")
+		})
+		require.Error(t, err)
+
+		require.Equal(t, int32(1), totalMatches(t, "name:rebuilt.pdf"))
+		require.Equal(t, int32(0), totalMatches(t, "name:failed.pdf"))
+		require.Equal(t, previous, indexes(t))
+	})
+
+	t.Run("replaces an index which predates the alias", func(t *testing.T) {
+		tc.Require.IndicesReset([]string{indexName})
+		tc.Require.IndicesCreate(indexName, strings.NewReader(opensearch.IndexManagerLatest.String()))
+
+		backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithRefreshAfterWrites(true))
+		require.NoError(t, err)
+		require.Equal(t, []string{indexName}, indexes(t))
+
+		require.NoError(t, backend.Rebuild(func(engine search.Engine) error {
+			return engine.Upsert(document.ID, document)
+		}))
+
+		current := indexes(t)
+		require.Len(t, current, 1)
+		require.NotEqual(t, indexName, current[0])
+
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{Query: "name:old.pdf"})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), resp.TotalMatches)
+	})
+}
+
+func TestEngine_ResetIndex(t *testing.T) {
+	indexName := "opencloud-test-engine-reset-index"
+	tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+	tc.Require.IndicesReset([]string{indexName})
+
+	defer tc.Require.IndicesDelete([]string{indexName})
+
+	backend, err := opensearch.NewBackend(indexName, tc.Client(), opensearch.WithRefreshAfterWrites(true))
+	require.NoError(t, err)
+
+	totalMatches := func(t *testing.T, query string) int32 {
+		resp, err := backend.Search(t.Context(), &searchService.SearchIndexRequest{Query: query})
+		require.NoError(t, err)
+		return resp.TotalMatches
+	}
+
+	for _, name := range []string{"first.pdf", "second.pdf"} {
+		document := opensearchtest.Testdata.Resources.File
+		document.ID = "1$2!" + name
+		document.Name = name
+		require.NoError(t, backend.Upsert(document.ID, document))
+	}
+	require.Equal(t, int32(1), totalMatches(t, "name:first.pdf"))
+
+	count, err := backend.ResetIndex()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	count, err = backend.DocCount()
+	require.NoError(t, err)
+	require.Zero(t, count)
+	require.Equal(t, int32(0), totalMatches(t, "name:first.pdf"))
+
+	// the new index is queryable
+	document := opensearchtest.Testdata.Resources.File
+	document.ID = "1$2!third.pdf"
+	document.Name = "third.pdf"
+	require.NoError(t, backend.Upsert(document.ID, document))
+	require.Equal(t, int32(1), totalMatches(t, "name:third.pdf"))
+}
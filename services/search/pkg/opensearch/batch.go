@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
+	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 
@@ -20,13 +23,29 @@ import (
 
 var _ search.BatchOperator = (*Batch)(nil) // ensure Batch implements BatchOperator
 
+// maxTruncatedTags is the number of tags kept for documents which exceed the maximum document size
+const maxTruncatedTags = 100
+
 type Batch struct {
-	client     *opensearchgoAPI.Client
-	index      string
-	size       int
-	log        log.Logger
-	operations []any
-	mu         sync.Mutex
+	client          *opensearchgoAPI.Client
+	index           string
+	size            int
+	maxDocumentSize int
+	// purgeChunkSize limits the number of documents a single delete by query removes, 0 disables the limit
+	purgeChunkSize int
+	// descendantsChunkSize limits the number of descendants a single update by query changes, 0 disables the limit
+	descendantsChunkSize int
+	// maxDescendants is the number of descendants above which a resource is not moved, deleted or restored, 0 disables the limit
+	maxDescendants int
+	// timeout cancels each request to OpenSearch which takes longer, 0 disables the timeout
+	timeout time.Duration
+	// refreshAfterPush makes the pushed operations searchable right away
+	refreshAfterPush bool
+	// spaceRouting routes the documents of a space to the same shard
+	spaceRouting bool
+	log          log.Logger
+	operations   []any
+	mu           sync.Mutex
 }
 
 func NewBatch(client *opensearchgoAPI.Client, index string, size int) (*Batch, error) {
@@ -35,22 +54,48 @@ func NewBatch(client *opensearchgoAPI.Client, index string, size int) (*Batch, e
 	}
 
 	return &Batch{
-		client: client,
-		size:   size,
-		index:  index,
+		client:         client,
+		size:           size,
+		index:          index,
+		purgeChunkSize: defaultPurgeChunkSize,
+		log:            log.NopLogger(),
+
+		descendantsChunkSize: defaultDescendantsChunkSize,
 	}, nil
 }
 
 func (b *Batch) Upsert(id string, r search.Resource) error {
 	return b.withSizeLimit(func() error {
+		// the paths are indexed in a single canonical form, so the subtree of a path is matched by its prefix
+		r.Path = utils.MakeRelativePath(r.Path)
+
+		truncated, size, err := truncate(&r, b.maxDocumentSize)
+		if err != nil {
+			return fmt.Errorf("failed to marshal resource: %w", err)
+		}
+		if truncated {
+			b.log.Warn().Str("id", id).Str("path", r.Path).Int("size", size).Int("maxDocumentSize", b.maxDocumentSize).
+				Msg("resource exceeds the maximum document size, indexing a truncated version")
+		}
+
 		body, err := conversions.To[map[string]any](r)
 		if err != nil {
 			return fmt.Errorf("failed to marshal resource: %w", err)
 		}
 
+		action := map[string]any{"_index": b.index, "_id": id}
+		if b.spaceRouting {
+			action["routing"] = documentRouting(id, r.RootID)
+		}
+		if r.HasSequence() {
+			// resources read from the index are only written if their document wasn't changed in the meantime
+			action["if_seq_no"] = r.SeqNo
+			action["if_primary_term"] = r.PrimaryTerm
+		}
+
 		op := func() []map[string]any {
 			return []map[string]any{
-				{"index": map[string]any{"_index": b.index, "_id": id}},
+				{"index": action},
 				body,
 			}
 		}
@@ -66,19 +111,35 @@ func (b *Batch) Upsert(id string, r search.Resource) error {
 func (b *Batch) Move(id, parentID, location string) error {
 	return b.withSizeLimit(func() error {
 		op := func() error {
-			return updateSelfAndDescendants(context.Background(), b.client, b.index, id, func(rootResource search.Resource) *osu.BodyParamScript {
+			if b.spaceRouting && parentID != "" {
+				moved, err := b.moveAcrossSpaces(id, parentID, location)
+				if err != nil || moved {
+					return err
+				}
+			}
+
+			return b.updateSelfAndDescendants(id, func(rootResource search.Resource) *osu.BodyParamScript {
+				newRootID := rootResource.RootID
+				if parentID != "" {
+					// the new parent belongs to another space if the resource was moved across spaces
+					newRootID = rootIDOf(parentID)
+				}
+
 				return &osu.BodyParamScript{
 					Source: `
-					if (ctx._source.ID == params.id ) { ctx._source.Name = params.newName; ctx._source.ParentID = params.parentID; }
-					ctx._source.Path = ctx._source.Path.replace(params.oldPath, params.newPath)
+					if (ctx._source.ID == params.id ) { ctx._source.Name = params.newName; ctx._source.Extension = params.newExtension; ctx._source.ParentID = params.parentID; }
+					ctx._source.Path = ctx._source.Path.replace(params.oldPath, params.newPath);
+					ctx._source.RootID = params.newRootID
 				`,
 					Lang: "painless",
 					Params: map[string]any{
-						"id":       id,
-						"parentID": parentID,
-						"oldPath":  rootResource.Path,
-						"newPath":  utils.MakeRelativePath(location),
-						"newName":  path.Base(utils.MakeRelativePath(location)),
+						"id":           id,
+						"parentID":     parentID,
+						"oldPath":      rootResource.Path,
+						"newPath":      utils.MakeRelativePath(location),
+						"newName":      path.Base(utils.MakeRelativePath(location)),
+						"newExtension": search.Extension(path.Base(location)),
+						"newRootID":    newRootID,
 					},
 				}
 			})
@@ -95,7 +156,7 @@ func (b *Batch) Move(id, parentID, location string) error {
 func (b *Batch) Delete(id string) error {
 	return b.withSizeLimit(func() error {
 		op := func() error {
-			return updateSelfAndDescendants(context.Background(), b.client, b.index, id, func(_ search.Resource) *osu.BodyParamScript {
+			return b.updateSelfAndDescendants(id, func(_ search.Resource) *osu.BodyParamScript {
 				return &osu.BodyParamScript{
 					Source: "ctx._source.Deleted = params.deleted",
 					Lang:   "painless",
@@ -117,7 +178,7 @@ func (b *Batch) Delete(id string) error {
 func (b *Batch) Restore(id string) error {
 	return b.withSizeLimit(func() error {
 		op := func() error {
-			return updateSelfAndDescendants(context.Background(), b.client, b.index, id, func(_ search.Resource) *osu.BodyParamScript {
+			return b.updateSelfAndDescendants(id, func(_ search.Resource) *osu.BodyParamScript {
 				return &osu.BodyParamScript{
 					Source: "ctx._source.Deleted = params.deleted",
 					Lang:   "painless",
@@ -136,49 +197,418 @@ func (b *Batch) Restore(id string) error {
 	})
 }
 
+func (b *Batch) RestoreMany(ids []string) error {
+	return b.withSizeLimit(func() error {
+		op := func() error {
+			ctx, cancel := requestContext(context.Background(), b.timeout)
+			defer cancel()
+
+			rootResources := make([]*search.Resource, 0, len(ids))
+			for _, id := range ids {
+				rootResource, err := getResourceByID(ctx, b.client, b.index, b.spaceRouting, id)
+				if err != nil {
+					return fmt.Errorf("failed to get resource: %w", err)
+				}
+				rootResources = append(rootResources, &rootResource)
+			}
+
+			// the path hierarchy of the top level roots already covers all nested roots
+			var paths []osu.Builder
+			for _, rootResource := range search.TopLevelResources(rootResources) {
+				paths = append(paths, osu.NewTermQuery[string]("Path").Value(rootResource.Path))
+			}
+			if len(paths) == 0 {
+				return nil
+			}
+
+			resources := make([]search.Resource, 0, len(rootResources))
+			for _, rootResource := range rootResources {
+				resources = append(resources, *rootResource)
+			}
+
+			return updateByQuery(ctx, b.client, b.index, resourceRouting(b.spaceRouting, resources...),
+				osu.NewBoolQuery().Should(paths...).Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}),
+				&osu.BodyParamScript{
+					Source: "ctx._source.Deleted = params.deleted",
+					Lang:   "painless",
+					Params: map[string]any{
+						"deleted": false,
+					},
+				},
+			)
+		}
+
+		b.mu.Lock()
+		b.operations = append(b.operations, op)
+		b.mu.Unlock()
+
+		return nil
+	})
+}
+
 func (b *Batch) Purge(id string, onlyDeleted bool) error {
 	return b.withSizeLimit(func() error {
-		resource, err := searchResourceByID(context.Background(), b.client, b.index, id)
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		resource, err := getResourceByID(ctx, b.client, b.index, b.spaceRouting, id)
 		if err != nil {
 			return fmt.Errorf("failed to get resource: %w", err)
 		}
 
-		query := osu.NewBoolQuery().Must(osu.NewTermQuery[string]("Path").Value(resource.Path))
+		subtree, err := b.subtreeQuery(ctx, &resource)
+		if err != nil {
+			return err
+		}
+
+		query := osu.NewBoolQuery().Must(subtree)
 		if onlyDeleted {
 			query.Must(osu.NewTermQuery[bool]("Deleted").Value(true))
 		}
 
-		req, err := osu.BuildDocumentDeleteByQueryReq(
-			opensearchgoAPI.DocumentDeleteByQueryReq{
+		routing := resourceRouting(b.spaceRouting, resource)
+		op := func() error {
+			return b.deleteByQuery(routing, query)
+		}
+
+		b.mu.Lock()
+		b.operations = append(b.operations, op)
+		b.mu.Unlock()
+
+		return nil
+	})
+}
+
+// PurgeMany removes the resources and their descendants from the index with a single query,
+// resources which are not part of the index are skipped.
+func (b *Batch) PurgeMany(ids []string, onlyDeleted bool) error {
+	return b.withSizeLimit(func() error {
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		resources, err := searchResourcesByIDs(ctx, b.client, b.index, ids...)
+		if err != nil {
+			return fmt.Errorf("failed to get resources: %w", err)
+		}
+
+		rootResources := make([]*search.Resource, 0, len(resources))
+		for i := range resources {
+			rootResources = append(rootResources, &resources[i])
+		}
+
+		// the subtrees of the top level roots already cover all nested roots
+		var subtrees []osu.Builder
+		for _, rootResource := range search.TopLevelResources(rootResources) {
+			subtree, err := b.subtreeQuery(ctx, rootResource)
+			if err != nil {
+				return err
+			}
+			subtrees = append(subtrees, subtree)
+		}
+		if len(subtrees) == 0 {
+			return nil
+		}
+
+		query := osu.NewBoolQuery().Should(subtrees...).Params(&osu.BoolQueryParams{MinimumShouldMatch: 1})
+		if onlyDeleted {
+			query.Must(osu.NewTermQuery[bool]("Deleted").Value(true))
+		}
+
+		routing := resourceRouting(b.spaceRouting, resources...)
+		op := func() error {
+			return b.deleteByQuery(routing, query)
+		}
+
+		b.mu.Lock()
+		b.operations = append(b.operations, op)
+		b.mu.Unlock()
+
+		return nil
+	})
+}
+
+// updateSelfAndDescendants applies the script to the resource and its descendants, search.ErrTooManyDescendants is returned
+// before anything is changed if the resource has more descendants than allowed.
+func (b *Batch) updateSelfAndDescendants(id string, scriptProvider func(search.Resource) *osu.BodyParamScript) error {
+	if scriptProvider == nil {
+		return fmt.Errorf("script cannot be nil")
+	}
+
+	// the resource is read again if it was changed between reading and updating it
+	return search.RetryOnConflict(func() error {
+		resource, descendants, err := b.descendantsToUpdate(id)
+		if err != nil {
+			return err
+		}
+
+		routing := resourceRouting(b.spaceRouting, resource)
+
+		// the resource is updated last, a retry after a failed chunk still finds the descendants which were not updated
+		script := scriptProvider(resource)
+		if err := b.updateInChunks(routing, descendants, script); err != nil {
+			return err
+		}
+
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		return updateIfUnchanged(ctx, b.client, b.index, b.spaceRouting, resource, script)
+	})
+}
+
+// descendantsToUpdate returns the resource and the query of its descendants, search.ErrTooManyDescendants is returned
+// if the resource has more descendants than allowed.
+func (b *Batch) descendantsToUpdate(id string) (search.Resource, osu.Builder, error) {
+	ctx, cancel := requestContext(context.Background(), b.timeout)
+	defer cancel()
+
+	resource, err := getResourceByID(ctx, b.client, b.index, b.spaceRouting, id)
+	if err != nil {
+		return search.Resource{}, nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+
+	descendants := osu.NewBoolQuery().
+		Must(osu.NewTermQuery[string]("Path").Value(resource.Path)).
+		MustNot(osu.NewIDsQuery(resource.ID))
+
+	if b.maxDescendants > 0 {
+		count, err := countDocuments(ctx, b.client, b.index, resourceRouting(b.spaceRouting, resource), descendants)
+		if err != nil {
+			return search.Resource{}, nil, err
+		}
+		if count > b.maxDescendants {
+			return search.Resource{}, nil, fmt.Errorf("%w: %s has %d descendants, at most %d are updated", search.ErrTooManyDescendants, resource.ID, count, b.maxDescendants)
+		}
+	}
+
+	return resource, descendants, nil
+}
+
+// moveAcrossSpaces moves the resource and its descendants to the space of the new parent, false is returned if the parent
+// belongs to the space of the resource. Routed documents are stored on the shard of their space, so they are removed
+// from the shard of the previous space first and are indexed again with the routing of the new space afterwards.
+// Both spaces may be routed to the same shard, indexing them last keeps the moved documents in that case.
+func (b *Batch) moveAcrossSpaces(id, parentID, location string) (bool, error) {
+	resource, _, err := b.descendantsToUpdate(id)
+	if err != nil {
+		return false, err
+	}
+
+	newRootID := rootIDOf(parentID)
+	oldRouting := documentRouting(resource.ID, resource.RootID)
+	newRouting := spaceRouting(newRootID)
+	if oldRouting == newRouting {
+		return false, nil
+	}
+
+	newPath := utils.MakeRelativePath(location)
+	move := func(r search.Resource) search.Resource {
+		if r.ID == id {
+			r.Name = path.Base(newPath)
+			r.Extension = search.Extension(path.Base(location))
+			r.ParentID = parentID
+		}
+		r.Path = strings.Replace(r.Path, resource.Path, newPath, 1)
+		r.RootID = newRootID
+		return r
+	}
+
+	chunkSize := b.descendantsChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultDescendantsChunkSize
+	}
+
+	// the resource itself matches the path of its subtree as well
+	subtree := osu.NewTermQuery[string]("Path").Value(resource.Path)
+	var after []any
+	for {
+		req, err := osu.BuildSearchReq(
+			&opensearchgoAPI.SearchReq{
 				Indices: []string{b.index},
-				Params: opensearchgoAPI.DocumentDeleteByQueryParams{
-					WaitForCompletion: conversions.ToPointer(true),
+				Params: opensearchgoAPI.SearchParams{
+					Size:    conversions.ToPointer(chunkSize),
+					Routing: []string{oldRouting},
 				},
 			},
-			query,
+			subtree,
+			osu.SearchBodyParams{
+				Sort:        []map[string]string{{"ID": "asc"}},
+				SearchAfter: after,
+			},
 		)
 		if err != nil {
-			return fmt.Errorf("failed to build delete by query request: %w", err)
+			return false, fmt.Errorf("failed to build search request: %w", err)
 		}
 
-		op := func() error {
-			resp, err := b.client.Document.DeleteByQuery(context.TODO(), req)
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		resp, err := b.client.Search(ctx, req)
+		cancel()
+		if err != nil {
+			return false, fmt.Errorf("failed to search for descendants: %w", err)
+		}
+
+		var body strings.Builder
+		for _, hit := range resp.Hits.Hits {
+			r, err := conversions.To[search.Resource](hit.Source)
+			if err != nil {
+				return false, fmt.Errorf("failed to convert hit source: %w", err)
+			}
+
+			for _, operation := range []any{
+				map[string]any{"delete": map[string]any{"_index": b.index, "_id": hit.ID, "routing": oldRouting}},
+				map[string]any{"index": map[string]any{"_index": b.index, "_id": hit.ID, "routing": newRouting}},
+				move(r),
+			} {
+				part, err := json.Marshal(operation)
+				if err != nil {
+					return false, fmt.Errorf("failed to marshal bulk operation: %w", err)
+				}
+				body.Write(part)
+				body.WriteString("\n")
+			}
+		}
+
+		if body.Len() > 0 {
+			ctx, cancel := requestContext(context.Background(), b.timeout)
+			resp, err := b.client.Bulk(ctx, opensearchgoAPI.BulkReq{
+				Body: strings.NewReader(body.String()),
+			})
+			cancel()
 			switch {
 			case err != nil:
-				return fmt.Errorf("failed to delete by query: %w", err)
-			case len(resp.Failures) != 0:
-				return fmt.Errorf("failed to delete by query, failures: %v", resp.Failures)
+				return false, fmt.Errorf("failed to move the documents to the new space: %w", err)
+			case resp.Errors:
+				return false, fmt.Errorf("failed to move the documents to the new space: %v", resp.Items)
 			}
+		}
 
+		if len(resp.Hits.Hits) < chunkSize {
+			break
+		}
+		after = []any{resp.Hits.Hits[len(resp.Hits.Hits)-1].ID}
+	}
+
+	// the moved documents are looked up by searches until their ids are used for them again
+	ctx, cancel := requestContext(context.Background(), b.timeout)
+	defer cancel()
+
+	return true, refreshIndex(ctx, b.client, b.index)
+}
+
+// updateInChunks applies the script to the documents matching the query, in chunks of descendantsChunkSize documents.
+// The ids of each chunk are looked up ordered by id after the last id of the previous chunk and updated with a request
+// of its own, so the documents which no longer match the query once they are updated don't shift the following chunks.
+func (b *Batch) updateInChunks(routing []string, query osu.Builder, script *osu.BodyParamScript) error {
+	if b.descendantsChunkSize <= 0 {
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		return updateByQuery(ctx, b.client, b.index, routing, query, script)
+	}
+
+	var after []any
+	for {
+		req, err := osu.BuildSearchReq(
+			&opensearchgoAPI.SearchReq{
+				Indices: []string{b.index},
+				Params: opensearchgoAPI.SearchParams{
+					Size:    conversions.ToPointer(b.descendantsChunkSize),
+					Routing: routing,
+				},
+			},
+			query,
+			osu.SearchBodyParams{
+				Source:      []string{"ID"},
+				Sort:        []map[string]string{{"ID": "asc"}},
+				SearchAfter: after,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to build search request: %w", err)
+		}
+
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		resp, err := b.client.Search(ctx, req)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to search for descendants: %w", err)
+		}
+
+		ids := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if len(ids) > 0 {
+			err = updateByQuery(ctx, b.client, b.index, routing, osu.NewIDsQuery(ids...), script)
+		}
+		cancel()
+
+		switch {
+		case err != nil:
+			return err
+		case len(ids) < b.descendantsChunkSize:
 			return nil
 		}
+		after = []any{ids[len(ids)-1]}
+	}
+}
 
-		b.mu.Lock()
-		b.operations = append(b.operations, op)
-		b.mu.Unlock()
+// deleteByQuery removes the documents matching the query, in chunks of purgeChunkSize documents.
+// A single delete by query over a huge trash might time out, each chunk is a request of its own
+// and the index gets refreshed in between, so the next chunk doesn't see the deleted documents anymore.
+// Only the shards of the given routing values are searched for the documents, all shards are if no routing is given.
+func (b *Batch) deleteByQuery(routing []string, query osu.Builder) error {
+	params := opensearchgoAPI.DocumentDeleteByQueryParams{
+		WaitForCompletion: conversions.ToPointer(true),
+		Routing:           routing,
+	}
+	if b.purgeChunkSize > 0 {
+		params.MaxDocs = conversions.ToPointer(b.purgeChunkSize)
+		params.Refresh = conversions.ToPointer(true)
+	}
 
-		return nil
-	})
+	for {
+		req, err := osu.BuildDocumentDeleteByQueryReq(
+			opensearchgoAPI.DocumentDeleteByQueryReq{
+				Indices: []string{b.index},
+				Params:  params,
+			},
+			query,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to build delete by query request: %w", err)
+		}
+
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		resp, err := b.client.Document.DeleteByQuery(ctx, req)
+		cancel()
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to delete by query: %w", err)
+		case len(resp.Failures) != 0:
+			return fmt.Errorf("failed to delete by query, failures: %v", resp.Failures)
+		case b.purgeChunkSize <= 0 || resp.Deleted < b.purgeChunkSize:
+			return nil
+		}
+	}
+}
+
+// subtreeQuery matches the resource and all its descendants, the descendants are found by their path
+// and by their parent ids, so trashed descendants whose path diverged from the resource are included.
+func (b *Batch) subtreeQuery(ctx context.Context, resource *search.Resource) (osu.Builder, error) {
+	subtree := []osu.Builder{osu.NewTermQuery[string]("Path").Value(resource.Path)}
+
+	if resource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		descendantIDs, err := searchDescendantIDs(ctx, b.client, b.index, resourceRouting(b.spaceRouting, *resource), resource.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get descendants: %w", err)
+		}
+		if len(descendantIDs) > 0 {
+			subtree = append(subtree, osu.NewIDsQuery(descendantIDs...))
+		}
+	}
+
+	return osu.NewBoolQuery().Should(subtree...).Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}), nil
 }
 
 func (b *Batch) Push() error {
@@ -189,6 +619,7 @@ func (b *Batch) Push() error {
 	}()
 
 	var bulkOperations []map[string]any
+	var conflicts []string
 	pushBulkOperations := func() error {
 		if len(bulkOperations) == 0 {
 			return nil
@@ -204,11 +635,16 @@ func (b *Batch) Push() error {
 			body.WriteString("\n")
 		}
 
-		if _, err := b.client.Bulk(context.Background(), opensearchgoAPI.BulkReq{
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		resp, err := b.client.Bulk(ctx, opensearchgoAPI.BulkReq{
 			Body: strings.NewReader(body.String()),
-		}); err != nil {
+		})
+		if err != nil {
 			return fmt.Errorf("failed to execute bulk operations: %w", err)
 		}
+		conflicts = append(conflicts, conflictingIDs(resp)...)
 
 		bulkOperations = nil
 		return nil
@@ -232,7 +668,43 @@ func (b *Batch) Push() error {
 		}
 	}
 
-	return pushBulkOperations()
+	if err := pushBulkOperations(); err != nil {
+		return err
+	}
+
+	if b.refreshAfterPush && len(b.operations) > 0 {
+		ctx, cancel := requestContext(context.Background(), b.timeout)
+		defer cancel()
+
+		if err := refreshIndex(ctx, b.client, b.index); err != nil {
+			return err
+		}
+	}
+
+	// the other operations are applied, only the stale upserts have to be made again with fresh data
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: documents %v were written after they were read", search.ErrConflict, conflicts)
+	}
+
+	return nil
+}
+
+// conflictingIDs returns the ids of the documents whose conditional writes were rejected because of a version conflict
+func conflictingIDs(resp *opensearchgoAPI.BulkResp) []string {
+	if resp == nil || !resp.Errors {
+		return nil
+	}
+
+	var ids []string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Status == http.StatusConflict {
+				ids = append(ids, result.ID)
+			}
+		}
+	}
+
+	return ids
 }
 
 func (b *Batch) withSizeLimit(f func() error) error {
@@ -246,3 +718,26 @@ func (b *Batch) withSizeLimit(f func() error) error {
 
 	return nil
 }
+
+// truncate drops the content and the versions and caps the tags of the resource if its serialized document exceeds maxSize bytes,
+// the resource is flagged as truncated in that case. It returns the size of the original serialized document.
+func truncate(r *search.Resource, maxSize int) (bool, int, error) {
+	if maxSize <= 0 {
+		return false, 0, nil
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return false, 0, err
+	}
+	if len(data) <= maxSize {
+		return false, len(data), nil
+	}
+
+	r.Content = ""
+	r.Versions = nil
+	r.Tags = r.Tags[:min(len(r.Tags), maxTruncatedTags)]
+	r.Truncated = true
+
+	return true, len(data), nil
+}
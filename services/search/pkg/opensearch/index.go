@@ -8,16 +8,24 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/go-jose/go-jose/v3/json"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
 var (
 	ErrManualActionRequired                  = errors.New("manual action required")
-	IndexManagerLatest                       = IndexIndexManagerResourceV1
+	IndexManagerLatest                       = IndexIndexManagerResourceV2
 	IndexIndexManagerResourceV1 IndexManager = "resource_v1.json"
+	IndexIndexManagerResourceV2 IndexManager = "resource_v2.json"
 )
 
 //go:embed internal/indexes/*.json
@@ -25,6 +33,189 @@ var indexes embed.FS
 
 type IndexManager string
 
+// IndexOption adjusts the index definition before it gets applied
+type IndexOption func(body []byte) ([]byte, error)
+
+// WithStopwords adds a fulltext analyzer to the content mapping which removes the stopwords
+// of the given language and the given words at index and query time.
+// The index definition is left untouched if neither a language nor words are given.
+func WithStopwords(language string, words []string) IndexOption {
+	return func(body []byte) ([]byte, error) {
+		var filters []string
+		var err error
+
+		if language != "" {
+			filters = append(filters, "stopwords_language")
+			body, err = sjson.SetBytes(body, "settings.analysis.filter.stopwords_language", map[string]any{
+				"type":      "stop",
+				"stopwords": "_" + strings.ToLower(language) + "_",
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(words) > 0 {
+			filters = append(filters, "stopwords")
+			stopwords := make([]string, 0, len(words))
+			for _, word := range words {
+				stopwords = append(stopwords, strings.ToLower(word))
+			}
+
+			body, err = sjson.SetBytes(body, "settings.analysis.filter.stopwords", map[string]any{
+				"type":      "stop",
+				"stopwords": stopwords,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(filters) == 0 {
+			return body, nil
+		}
+
+		body, err = sjson.SetBytes(body, "settings.analysis.analyzer.fulltext", map[string]any{
+			"type":      "custom",
+			"tokenizer": "standard",
+			"filter":    append([]string{"lowercase"}, filters...),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return sjson.SetBytes(body, "mappings.properties.Content", map[string]any{
+			"type":     "text",
+			"analyzer": "fulltext",
+		})
+	}
+}
+
+// WithDisabledFields maps the given optional metadata fields like 'location' as objects which are neither parsed nor indexed,
+// so documents which still carry them don't add their sub-fields to the mapping.
+func WithDisabledFields(fields ...string) IndexOption {
+	return func(body []byte) ([]byte, error) {
+		var err error
+		for _, field := range fields {
+			body, err = sjson.SetBytes(body, "mappings.properties."+field, map[string]any{
+				"type":    "object",
+				"enabled": false,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return body, nil
+	}
+}
+
+// WithShards sets the number of primary shards the index is split into,
+// the index definition is left untouched if the number is not positive.
+func WithShards(shards int) IndexOption {
+	return func(body []byte) ([]byte, error) {
+		if shards <= 0 {
+			return body, nil
+		}
+
+		return sjson.SetBytes(body, "settings.number_of_shards", strconv.Itoa(shards))
+	}
+}
+
+// withRequiredRouting rejects the documents which are written without a routing value,
+// so no document of a space ends up on another shard than the one its space is routed to.
+func withRequiredRouting() IndexOption {
+	return func(body []byte) ([]byte, error) {
+		return sjson.SetBytes(body, "mappings._routing.required", true)
+	}
+}
+
+// withNameTransliteration adds the Name.translit sub-field which holds the name
+// with cyrillic and greek letters transliterated to latin.
+func withNameTransliteration() IndexOption {
+	return func(body []byte) ([]byte, error) {
+		var mappings []string
+		for letter, latin := range search.LatinTransliterations() {
+			mappings = append(mappings, string(letter)+"=>"+latin)
+			if upper := unicode.ToUpper(letter); upper != letter {
+				mappings = append(mappings, string(upper)+"=>"+latin)
+			}
+		}
+		// the order of the rules does not matter, sort them to keep the index definition stable
+		slices.Sort(mappings)
+
+		body, err := sjson.SetBytes(body, "settings.analysis.char_filter.transliteration", map[string]any{
+			"type":     "mapping",
+			"mappings": mappings,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		body, err = sjson.SetBytes(body, "settings.analysis.analyzer.transliteration", map[string]any{
+			"type":        "custom",
+			"tokenizer":   "standard",
+			"char_filter": []string{"transliteration"},
+			"filter":      []string{"lowercase"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return withNameSubField(body, "translit", map[string]any{
+			"type":     "text",
+			"analyzer": "transliteration",
+		})
+	}
+}
+
+// withNamePhonetic adds the Name.phonetic sub-field which holds the soundex codes of the words of the name,
+// it requires the analysis-phonetic plugin.
+func withNamePhonetic() IndexOption {
+	return func(body []byte) ([]byte, error) {
+		body, err := sjson.SetBytes(body, "settings.analysis.filter.phonetic", map[string]any{
+			"type":    "phonetic",
+			"encoder": "soundex",
+			"replace": true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		body, err = sjson.SetBytes(body, "settings.analysis.analyzer.phonetic", map[string]any{
+			"type":      "custom",
+			"tokenizer": "letter",
+			"filter":    []string{"lowercase", "phonetic"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return withNameSubField(body, "phonetic", map[string]any{
+			"type":     "text",
+			"analyzer": "phonetic",
+		})
+	}
+}
+
+// withNameSubField adds the sub-field to the name, the mapping opensearch creates for the name dynamically is kept
+func withNameSubField(body []byte, name string, field map[string]any) ([]byte, error) {
+	body, err := sjson.SetBytes(body, "mappings.properties.Name.type", "text")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = sjson.SetBytes(body, "mappings.properties.Name.fields.keyword", map[string]any{
+		"type":         "keyword",
+		"ignore_above": 256,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sjson.SetBytes(body, "mappings.properties.Name.fields."+name, field)
+}
+
 func (m IndexManager) String() string {
 	b, err := m.MarshalJSON()
 	if err != nil {
@@ -47,12 +238,19 @@ func (m IndexManager) MarshalJSON() ([]byte, error) {
 	return body, nil
 }
 
-func (m IndexManager) Apply(ctx context.Context, name string, client *opensearchgoAPI.Client) error {
+func (m IndexManager) Apply(ctx context.Context, name string, client *opensearchgoAPI.Client, opts ...IndexOption) error {
 	localIndexB, err := m.MarshalJSON()
 	if err != nil {
 		return fmt.Errorf("failed to marshal index %s: %w", name, err)
 	}
 
+	for _, opt := range opts {
+		localIndexB, err = opt(localIndexB)
+		if err != nil {
+			return fmt.Errorf("failed to apply option to index %s: %w", name, err)
+		}
+	}
+
 	indicesExistsResp, err := client.Indices.Exists(ctx, opensearchgoAPI.IndicesExistsReq{
 		Indices: []string{name},
 	})
@@ -115,6 +313,13 @@ func (m IndexManager) Apply(ctx context.Context, name string, client *opensearch
 			}
 		}
 
+		// the documents of an index which does not require the routing might be on other shards than their routing points to
+		if localIndexJson.Get("mappings._routing").Exists() || remoteIndexJson.Get("mappings._routing").Exists() {
+			if _, _, ok := compare("mappings._routing", "mappings._routing"); !ok {
+				errs = append(errs, fmt.Errorf("mappings._routing"))
+			}
+		}
+
 		if errs != nil {
 			return fmt.Errorf(
 				"index %s allready exists and is different from the requested version, %w: %w",
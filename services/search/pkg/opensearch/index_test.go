@@ -60,4 +60,42 @@ func TestIndexManager(t *testing.T) {
 
 		require.ErrorIs(t, indexManager.Apply(t.Context(), indexName, tc.Client()), opensearch.ErrManualActionRequired)
 	})
+	t.Run("applies the stopword options", func(t *testing.T) {
+		indexManager := opensearch.IndexManagerLatest
+		indexName := "opencloud-test-resource"
+
+		tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+		tc.Require.IndicesReset([]string{indexName})
+
+		withStopwords := opensearch.WithStopwords("english", []string{"Foo"})
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withStopwords))
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withStopwords))
+		require.ErrorIs(t, indexManager.Apply(t.Context(), indexName, tc.Client()), opensearch.ErrManualActionRequired)
+	})
+
+	t.Run("applies the number of shards", func(t *testing.T) {
+		indexManager := opensearch.IndexManagerLatest
+		indexName := "opencloud-test-resource"
+
+		tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+		tc.Require.IndicesReset([]string{indexName})
+
+		withShards := opensearch.WithShards(3)
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withShards))
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withShards))
+		require.ErrorIs(t, indexManager.Apply(t.Context(), indexName, tc.Client()), opensearch.ErrManualActionRequired)
+	})
+
+	t.Run("applies the disabled fields", func(t *testing.T) {
+		indexManager := opensearch.IndexManagerLatest
+		indexName := "opencloud-test-resource"
+
+		tc := opensearchtest.NewDefaultTestClient(t, defaultConfig.Engine.OpenSearch.Client)
+		tc.Require.IndicesReset([]string{indexName})
+
+		withDisabledFields := opensearch.WithDisabledFields("location", "photo")
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withDisabledFields))
+		require.NoError(t, indexManager.Apply(t.Context(), indexName, tc.Client(), withDisabledFields))
+		require.ErrorIs(t, indexManager.Apply(t.Context(), indexName, tc.Client()), opensearch.ErrManualActionRequired)
+	})
 }
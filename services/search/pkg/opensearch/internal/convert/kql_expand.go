@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+
 	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
 )
 
 func ExpandKQL(nodes []ast.Node) ([]ast.Node, error) {
@@ -48,6 +52,10 @@ func (e kqlExpander) expand(nodes []ast.Node, defaultKey string) ([]ast.Node, er
 		case *ast.StringNode:
 			cnode.Key = e.remapKey(cnode.Key, defaultKey)
 			cnode.Value = e.lowerValue(cnode.Key, cnode.Value)
+			if cnode.Key == "Extension" {
+				// allow to search for extensions as they are written, e.g. ext:.docx
+				cnode.Value = strings.TrimPrefix(cnode.Value, ".")
+			}
 			unfoldedNodes = e.unfoldValue(cnode.Key, cnode.Value)
 		case *ast.DateTimeNode:
 			cnode.Key = e.remapKey(cnode.Key, defaultKey)
@@ -71,21 +79,11 @@ func (_ kqlExpander) remapKey(current string, defaultKey string) string {
 		defaultKey = "Name" // Set a default key if none is provided
 	}
 
-	key, ok := map[string]string{
-		"":          defaultKey, // Default case if current is empty
-		"rootid":    "RootID",
-		"path":      "Path",
-		"id":        "ID",
-		"name":      "Name",
-		"size":      "Size",
-		"mtime":     "Mtime",
-		"mediatype": "MimeType",
-		"type":      "Type",
-		"tag":       "Tags",
-		"tags":      "Tags",
-		"content":   "Content",
-		"hidden":    "Hidden",
-	}[current]
+	if current == "" {
+		return defaultKey
+	}
+
+	key, ok := query.Fields[current]
 	if !ok {
 		return current // Return the original key if not found
 	}
@@ -188,6 +186,18 @@ func (_ kqlExpander) unfoldValue(key, value string) []ast.Node {
 				&ast.StringNode{Key: key, Value: "application/x-tgz"},
 			}},
 		},
+		"Type:file": {
+			&ast.StringNode{Key: key, Value: strconv.Itoa(int(provider.ResourceType_RESOURCE_TYPE_FILE))},
+		},
+		"Type:folder": {
+			&ast.StringNode{Key: key, Value: strconv.Itoa(int(provider.ResourceType_RESOURCE_TYPE_CONTAINER))},
+		},
+		"Type:reference": {
+			&ast.StringNode{Key: key, Value: strconv.Itoa(int(provider.ResourceType_RESOURCE_TYPE_REFERENCE))},
+		},
+		"Type:symlink": {
+			&ast.StringNode{Key: key, Value: strconv.Itoa(int(provider.ResourceType_RESOURCE_TYPE_SYMLINK))},
+		},
 	}[fmt.Sprintf("%s:%s", key, value)]
 	if !ok {
 		return nil
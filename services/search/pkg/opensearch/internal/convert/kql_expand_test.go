@@ -129,20 +129,28 @@ func TestExpandKQLAST(t *testing.T) {
 		var tests []opensearchtest.TableTest[[]ast.Node, []ast.Node]
 
 		for k, v := range map[string]string{
-			"":          "Name", // Default to "Name" if no key is provided
-			"rootid":    "RootID",
-			"path":      "Path",
-			"id":        "ID",
-			"name":      "Name",
-			"size":      "Size",
-			"mtime":     "Mtime",
-			"mediatype": "MimeType",
-			"type":      "Type",
-			"tag":       "Tags",
-			"tags":      "Tags",
-			"content":   "Content",
-			"hidden":    "Hidden",
-			"any":       "any", // Example of an unknown key that should remain unchanged
+			"":               "Name", // Default to "Name" if no key is provided
+			"rootid":         "RootID",
+			"path":           "Path",
+			"id":             "ID",
+			"parentid":       "ParentID",
+			"name":           "Name",
+			"size":           "Size",
+			"mtime":          "Mtime",
+			"modified":       "Mtime",
+			"created":        "Ctime",
+			"mediatype":      "MimeType",
+			"type":           "Type",
+			"tag":            "Tags",
+			"tags":           "Tags",
+			"content":        "Content",
+			"hidden":         "Hidden",
+			"owner":          "Owner",
+			"creator":        "CreatedBy",
+			"sharedwith":     "SharedWith",
+			"ext":            "Extension",
+			"classification": "Classification",
+			"any":            "any", // Example of an unknown key that should remain unchanged
 		} {
 			tests = append(tests, opensearchtest.TableTest[[]ast.Node, []ast.Node]{
 				Name: fmt.Sprintf("%s -> %s", k, v),
@@ -273,6 +281,24 @@ func TestExpandKQLAST(t *testing.T) {
 					}},
 				},
 			},
+			{
+				Name: "Extension: .DOCX -> docx",
+				Got: []ast.Node{
+					ast.StringNode{Key: "ext", Value: ".DOCX"},
+				},
+				Want: []ast.Node{
+					&ast.StringNode{Key: "Extension", Value: "docx"},
+				},
+			},
+			{
+				Name: "Classification: Confidential -> confidential",
+				Got: []ast.Node{
+					ast.StringNode{Key: "classification", Value: "Confidential"},
+				},
+				Want: []ast.Node{
+					&ast.StringNode{Key: "Classification", Value: "confidential"},
+				},
+			},
 		}
 
 		for _, test := range tests {
@@ -313,6 +339,28 @@ func TestExpandKQLAST(t *testing.T) {
 					&ast.StringNode{Key: "Name", Value: `some-name`},
 				},
 			},
+			{
+				Name: "Type:folder",
+				Got: []ast.Node{
+					&ast.StringNode{Key: "Type", Value: "folder"},
+					&ast.OperatorNode{Value: "AND"},
+					&ast.StringNode{Key: "Name", Value: "some-name"},
+				},
+				Want: []ast.Node{
+					&ast.StringNode{Key: "Type", Value: "2"},
+					&ast.OperatorNode{Value: "AND"},
+					&ast.StringNode{Key: "Name", Value: "some-name"},
+				},
+			},
+			{
+				Name: "type:file",
+				Got: []ast.Node{
+					&ast.StringNode{Key: "type", Value: "File"},
+				},
+				Want: []ast.Node{
+					&ast.StringNode{Key: "Type", Value: "1"},
+				},
+			},
 			{
 				Name: "MimeType:folder",
 				Got: []ast.Node{
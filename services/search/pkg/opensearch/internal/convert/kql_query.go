@@ -5,24 +5,30 @@ import (
 
 	"github.com/opencloud-eu/opencloud/pkg/kql"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
 )
 
 var (
 	ErrUnsupportedNodeType = fmt.Errorf("unsupported node type")
 )
 
-func KQLToOpenSearchBoolQuery(kqlQuery string) (*osu.BoolQuery, error) {
-	kqlAst, err := kql.Builder{}.Build(kqlQuery)
+func KQLToOpenSearchBoolQuery(kqlQuery string, opts query.Options) (*osu.BoolQuery, error) {
+	kqlAst, err := kql.Builder{DefaultOperator: opts.DefaultOperator, MinimumShouldMatch: opts.MinimumShouldMatch}.Build(kqlQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
-	kqlNodes, err := ExpandKQL(kqlAst.Nodes)
+	kqlNodes, err := query.NormalizeBooleans(kqlAst.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	kqlNodes, err = ExpandKQL(kqlNodes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to expand KQL AST nodes: %w", err)
 	}
 
-	builder, err := TranspileKQLToOpenSearch(kqlNodes)
+	builder, err := kqlOpensearchTranspiler{boosts: opts.Boosts, transliterate: opts.Transliterate, phonetic: opts.Phonetic}.Transpile(kqlNodes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile query: %w", err)
 	}
@@ -1 +1,269 @@
 package convert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
+)
+
+func TestKQLToOpenSearchBoolQuery_MinimumShouldMatch(t *testing.T) {
+	var (
+		annual  = osu.NewTermQuery[string]("Name").Value("annual")
+		report  = osu.NewTermQuery[string]("Name").Value("report")
+		summary = osu.NewTermQuery[string]("Name").Value("summary")
+		year    = osu.NewTermQuery[string]("Name").Value("2024")
+		file    = osu.NewTermQuery[uint64]("Type").Value(1)
+	)
+
+	tests := []struct {
+		name               string
+		query              string
+		minimumShouldMatch string
+		want               osu.Builder
+	}{
+		{
+			name:               "most of the terms",
+			query:              "annual report summary 2024",
+			minimumShouldMatch: "75%",
+			want:               osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 3}).Should(annual, report, summary, year),
+		},
+		{
+			name:               "all but one term",
+			query:              "annual report summary 2024 type:file",
+			minimumShouldMatch: "-1",
+			want: osu.NewBoolQuery().Must(
+				osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 3}).Should(annual, report, summary, year),
+				file,
+			),
+		},
+		{
+			name:               "any of two terms",
+			query:              "annual report",
+			minimumShouldMatch: "75%",
+			want:               osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}).Should(annual, report),
+		},
+		{
+			name:               "all terms",
+			query:              "annual report",
+			minimumShouldMatch: "100%",
+			want:               osu.NewBoolQuery().Must(annual, report),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsl, err := convert.KQLToOpenSearchBoolQuery(tt.query, query.Options{MinimumShouldMatch: tt.minimumShouldMatch})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, tt.want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+}
+
+func TestKQLToOpenSearchBoolQuery_DefaultOperator(t *testing.T) {
+	var (
+		report    = osu.NewTermQuery[string]("Name").Value("report")
+		quarterly = osu.NewTermQuery[string]("Name").Value("quarterly")
+		file      = osu.NewTermQuery[uint64]("Type").Value(1)
+		anyTerm   = osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}).Should(report, quarterly)
+	)
+
+	tests := []struct {
+		name            string
+		query           string
+		defaultOperator string
+		want            osu.Builder
+	}{
+		{
+			name:  "all terms by default",
+			query: "report quarterly",
+			want:  osu.NewBoolQuery().Must(report, quarterly),
+		},
+		{
+			name:            "all terms with AND",
+			query:           "report quarterly type:file",
+			defaultOperator: "AND",
+			want:            osu.NewBoolQuery().Must(report, quarterly, file),
+		},
+		{
+			name:            "any term with OR",
+			query:           "report quarterly",
+			defaultOperator: "OR",
+			want:            anyTerm,
+		},
+		{
+			name:            "property restrictions stay AND-ed with OR",
+			query:           "report quarterly type:file",
+			defaultOperator: "OR",
+			want:            osu.NewBoolQuery().Must(anyTerm, file),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsl, err := convert.KQLToOpenSearchBoolQuery(tt.query, query.Options{DefaultOperator: tt.defaultOperator})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, tt.want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+}
+
+func TestKQLToOpenSearchBoolQuery_Transliteration(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		transliterate bool
+		want          osu.Builder
+	}{
+		{
+			name:  "name only without transliteration",
+			query: "ivan",
+			want:  osu.NewBoolQuery().Must(osu.NewTermQuery[string]("Name").Value("ivan")),
+		},
+		{
+			name:          "latin name term",
+			query:         "Ivan*",
+			transliterate: true,
+			want: osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}).Should(
+				osu.NewWildcardQuery("Name").Value("ivan*"),
+				osu.NewWildcardQuery("Name.translit").Value("ivan*"),
+			),
+		},
+		{
+			name:          "cyrillic name term",
+			query:         "name:Иван",
+			transliterate: true,
+			want: osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}).Should(
+				osu.NewTermQuery[string]("Name").Value("иван"),
+				osu.NewTermQuery[string]("Name.translit").Value("ivan"),
+			),
+		},
+		{
+			name:          "other fields are not transliterated",
+			query:         "content:иван",
+			transliterate: true,
+			want:          osu.NewBoolQuery().Must(osu.NewTermQuery[string]("Content").Value("иван")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsl, err := convert.KQLToOpenSearchBoolQuery(tt.query, query.Options{Transliterate: tt.transliterate})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, tt.want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+}
+
+func TestKQLToOpenSearchBoolQuery_Phonetic(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		phonetic bool
+		want     osu.Builder
+	}{
+		{
+			name:     "phonetic name term",
+			query:    "name~phonetic:Jonson",
+			phonetic: true,
+			want:     osu.NewBoolQuery().Must(osu.NewMatchPhraseQuery("Name.phonetic").Query("jonson")),
+		},
+		{
+			name:     "phonetic name phrase and plain name term",
+			query:    `name~phonetic:"Jon Smith" AND name:report`,
+			phonetic: true,
+			want: osu.NewBoolQuery().Must(
+				osu.NewMatchPhraseQuery("Name.phonetic").Query("jon smith"),
+				osu.NewTermQuery[string]("Name").Value("report"),
+			),
+		},
+		{
+			name:  "phonetic name term without phonetic matching",
+			query: "name~phonetic:Jonson",
+			want:  osu.NewBoolQuery().Must(osu.NewTermQuery[string]("Name").Value("jonson")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsl, err := convert.KQLToOpenSearchBoolQuery(tt.query, query.Options{Phonetic: tt.phonetic})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, tt.want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+}
+
+func TestKQLToOpenSearchBoolQuery_Boolean(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  osu.Builder
+	}{
+		{
+			name:  "true",
+			query: "hidden:true",
+			want:  osu.NewBoolQuery().Must(osu.NewTermQuery[bool]("Hidden").Value(true)),
+		},
+		{
+			name:  "false",
+			query: "haspreview:false",
+			want:  osu.NewBoolQuery().MustNot(osu.NewTermQuery[bool]("HasPreview").Value(true)),
+		},
+		{
+			name:  "short value",
+			query: "Truncated:T",
+			want:  osu.NewBoolQuery().Must(osu.NewTermQuery[bool]("Truncated").Value(true)),
+		},
+		{
+			name:  "not a boolean property",
+			query: "name:true",
+			want:  osu.NewBoolQuery().Must(osu.NewTermQuery[string]("Name").Value("true")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsl, err := convert.KQLToOpenSearchBoolQuery(tt.query, query.Options{})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, tt.want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+
+	t.Run("locked", func(t *testing.T) {
+		dsl, err := convert.KQLToOpenSearchBoolQuery("locked:true", query.Options{})
+		assert.NoError(t, err)
+
+		body := opensearchtest.JSONMustMarshal(t, dsl)
+		assert.True(t, gjson.Get(body, "bool.must.0.term.Locked.value").Bool(), body)
+		assert.True(t, gjson.Get(body, "bool.must_not.0.range.LockExpiration").Exists(), body)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := convert.KQLToOpenSearchBoolQuery("hidden:yes", query.Options{})
+		assert.True(t, query.IsValidationError(err))
+	})
+}
+
+func TestKQLToOpenSearchBoolQuery_RelativeModified(t *testing.T) {
+	dsl, err := convert.KQLToOpenSearchBoolQuery("modified:last-7d", query.Options{})
+	assert.NoError(t, err)
+
+	body := opensearchtest.JSONMustMarshal(t, dsl)
+	from, err := time.Parse(time.RFC3339Nano, gjson.Get(body, "bool.must.0.range.Mtime.gte").String())
+	assert.NoError(t, err, body)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -7), from, time.Minute)
+	to, err := time.Parse(time.RFC3339Nano, gjson.Get(body, "bool.must.1.range.Mtime.lte").String())
+	assert.NoError(t, err, body)
+	assert.WithinDuration(t, time.Now(), to, time.Minute)
+}
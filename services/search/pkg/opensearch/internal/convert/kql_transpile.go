@@ -3,19 +3,29 @@ package convert
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
 	"github.com/opencloud-eu/opencloud/pkg/kql"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
-func TranspileKQLToOpenSearch(nodes []ast.Node) (osu.Builder, error) {
-	return kqlOpensearchTranspiler{}.Transpile(nodes)
+// TranspileKQLToOpenSearch converts the KQL nodes into an OpenSearch query,
+// the matches of a field are weighted in the score by its boost if set.
+func TranspileKQLToOpenSearch(nodes []ast.Node, boosts map[string]float64) (osu.Builder, error) {
+	return kqlOpensearchTranspiler{boosts: boosts}.Transpile(nodes)
 }
 
-type kqlOpensearchTranspiler struct{}
+type kqlOpensearchTranspiler struct {
+	boosts map[string]float64
+	// transliterate also matches the name terms against the latin transliteration of the names
+	transliterate bool
+	// phonetic matches the name~phonetic terms against the soundex codes of the names, they match like plain name terms if not set
+	phonetic bool
+}
 
 func (t kqlOpensearchTranspiler) Transpile(nodes []ast.Node) (osu.Builder, error) {
 	q, err := t.transpile(nodes)
@@ -97,24 +107,45 @@ func (t kqlOpensearchTranspiler) toBuilder(node ast.Node) (osu.Builder, error) {
 
 	switch node := node.(type) {
 	case *ast.BooleanNode:
-		return osu.NewTermQuery[bool](node.Key).Value(node.Value), nil
+		var isTrue osu.Builder = osu.NewTermQuery[bool](node.Key).Value(true)
+		if node.Key == "Locked" {
+			// expired locks are not matched, locks without expiration never expire
+			isTrue = osu.NewBoolQuery().
+				Must(isTrue).
+				MustNot(osu.NewRangeQuery[time.Time]("LockExpiration").Lte(time.Now()))
+		}
+		if node.Value {
+			return isTrue, nil
+		}
+		// false matches all documents whose field is not true, including the ones which omit the field
+		return osu.NewBoolQuery().MustNot(isTrue), nil
 	case *ast.StringNode:
-		isWildcard := strings.Contains(node.Value, "*")
-		if isWildcard {
-			return osu.NewWildcardQuery(node.Key).Value(node.Value), nil
+		if node.Key == "Type" {
+			// the resource type is indexed as number
+			if resourceType, err := strconv.ParseUint(node.Value, 10, 64); err == nil {
+				return osu.NewTermQuery[uint64](node.Key).Value(resourceType), nil
+			}
 		}
 
-		totalTerms := strings.Split(node.Value, " ")
-		isSingleTerm := len(totalTerms) == 1
-		isMultiTerm := len(totalTerms) >= 1
-		switch {
-		case isSingleTerm:
-			return osu.NewTermQuery[string](node.Key).Value(node.Value), nil
-		case isMultiTerm:
-			return osu.NewMatchPhraseQuery(node.Key).Query(node.Value), nil
+		if node.Key == "Name" && node.Mode == kql.MatchPhonetic && t.phonetic {
+			query := osu.NewMatchPhraseQuery("Name.phonetic").Query(node.Value)
+			if boost := t.boost(node.Key); boost != 0 {
+				query.Params(&osu.MatchPhraseQueryParams{Boost: boost})
+			}
+			return query, nil
+		}
+
+		query, err := t.stringQuery(node.Key, node.Value, t.boost(node.Key))
+		if err != nil || node.Key != "Name" || !t.transliterate {
+			return query, err
 		}
 
-		return nil, fmt.Errorf("unsupported string node value: %s", node.Value)
+		translitQuery, err := t.stringQuery("Name.translit", search.Transliterate(node.Value), t.boost(node.Key))
+		if err != nil {
+			return nil, err
+		}
+
+		return osu.NewBoolQuery().Params(&osu.BoolQueryParams{MinimumShouldMatch: 1}).Should(query, translitQuery), nil
 	case *ast.DateTimeNode:
 		if node.Operator == nil {
 			return builder, fmt.Errorf("date time node without operator: %w", ErrUnsupportedNodeType)
@@ -140,8 +171,55 @@ func (t kqlOpensearchTranspiler) toBuilder(node ast.Node) (osu.Builder, error) {
 			return nil, fmt.Errorf("failed to build group: %w", err)
 		}
 
+		// the nodes of a group with a minimum match are OR-connected, they are all should clauses
+		if boolQuery, ok := group.(*osu.BoolQuery); ok && node.MinimumMatch > 0 {
+			boolQuery.Params(&osu.BoolQueryParams{MinimumShouldMatch: int16(node.MinimumMatch)})
+		}
+
 		return group, nil
 	}
 
 	return nil, fmt.Errorf("%w: %T", ErrUnsupportedNodeType, node)
 }
+
+// stringQuery matches the value in the field, the boost is not applied if 0
+func (t kqlOpensearchTranspiler) stringQuery(field, value string, boost float32) (osu.Builder, error) {
+	isWildcard := strings.Contains(value, "*")
+	if isWildcard {
+		query := osu.NewWildcardQuery(field).Value(value)
+		if boost != 0 {
+			query.Params(&osu.WildcardQueryParams{Boost: boost})
+		}
+		return query, nil
+	}
+
+	totalTerms := strings.Split(value, " ")
+	isSingleTerm := len(totalTerms) == 1
+	isMultiTerm := len(totalTerms) >= 1
+	switch {
+	case isSingleTerm:
+		query := osu.NewTermQuery[string](field).Value(value)
+		if boost != 0 {
+			query.Params(&osu.TermQueryParams{Boost: boost})
+		}
+		return query, nil
+	case isMultiTerm:
+		query := osu.NewMatchPhraseQuery(field).Query(value)
+		if boost != 0 {
+			query.Params(&osu.MatchPhraseQueryParams{Boost: boost})
+		}
+		return query, nil
+	}
+
+	return nil, fmt.Errorf("unsupported string node value: %s", value)
+}
+
+// boost returns the boost of the field, 0 if the field is not boosted
+func (t kqlOpensearchTranspiler) boost(field string) float32 {
+	boost, ok := t.boosts[field]
+	if !ok || boost <= 0 || boost == 1 {
+		return 0
+	}
+
+	return float32(boost)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
@@ -40,7 +41,21 @@ func TestTranspileKQLToOpenSearch(t *testing.T) {
 					&ast.BooleanNode{Key: "Deleted", Value: false},
 				},
 			},
-			Want: osu.NewTermQuery[bool]("Deleted").Value(false),
+			Want: osu.NewBoolQuery().MustNot(osu.NewTermQuery[bool]("Deleted").Value(true)),
+		},
+		{
+			Name: "term query - resource type",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "Type", Value: "2"},
+					&ast.OperatorNode{Value: "AND"},
+					&ast.StringNode{Key: "Name", Value: "openCloud"},
+				},
+			},
+			Want: osu.NewBoolQuery().Must(
+				osu.NewTermQuery[uint64]("Type").Value(2),
+				osu.NewTermQuery[string]("Name").Value("openCloud"),
+			),
 		},
 		{
 			Name: "match-phrase query - string node",
@@ -369,10 +384,90 @@ func TestTranspileKQLToOpenSearch(t *testing.T) {
 				t.Skip("skipping test: " + test.Name)
 			}
 
-			dsl, err := convert.TranspileKQLToOpenSearch(test.Got.Nodes)
+			dsl, err := convert.TranspileKQLToOpenSearch(test.Got.Nodes, nil)
 			assert.NoError(t, err)
 
 			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, test.Want), opensearchtest.JSONMustMarshal(t, dsl))
 		})
 	}
 }
+
+func TestTranspileKQLToOpenSearch_Boosts(t *testing.T) {
+	boosts := map[string]float64{"Name": 10, "Content": 1, "Tags": 2}
+	tests := []opensearchtest.TableTest[*ast.Ast, osu.Builder]{
+		{
+			Name: "term query",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "Name", Value: "openCloud"},
+				},
+			},
+			Want: osu.NewTermQuery[string]("Name").Value("openCloud").Params(&osu.TermQueryParams{Boost: 10}),
+		},
+		{
+			Name: "wildcard query",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "Tags", Value: "open*"},
+				},
+			},
+			Want: osu.NewWildcardQuery("Tags").Value("open*").Params(&osu.WildcardQueryParams{Boost: 2}),
+		},
+		{
+			Name: "match phrase query",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "Name", Value: "open cloud"},
+				},
+			},
+			Want: osu.NewMatchPhraseQuery("Name").Query("open cloud").Params(&osu.MatchPhraseQueryParams{Boost: 10}),
+		},
+		{
+			Name: "neutral boost",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "Content", Value: "openCloud"},
+				},
+			},
+			Want: osu.NewTermQuery[string]("Content").Value("openCloud"),
+		},
+		{
+			Name: "field without boost",
+			Got: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "MimeType", Value: "application/pdf"},
+				},
+			},
+			Want: osu.NewTermQuery[string]("MimeType").Value("application/pdf"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			dsl, err := convert.TranspileKQLToOpenSearch(test.Got.Nodes, boosts)
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, test.Want), opensearchtest.JSONMustMarshal(t, dsl))
+		})
+	}
+}
+
+func TestTranspileKQLToOpenSearch_Locked(t *testing.T) {
+	for _, value := range []bool{true, false} {
+		dsl, err := convert.TranspileKQLToOpenSearch([]ast.Node{&ast.BooleanNode{Key: "Locked", Value: value}}, nil)
+		assert.NoError(t, err)
+
+		body := opensearchtest.JSONMustMarshal(t, dsl)
+		locked := gjson.Get(body, "bool")
+		if !value {
+			// unlocked are the resources which are not locked
+			locked = gjson.Get(body, "bool.must_not.0.bool")
+		}
+
+		assert.True(t, locked.Get("must.0.term.Locked.value").Bool(), body)
+		// expired locks are not matched as locked
+		expired, err := time.Parse(time.RFC3339Nano, locked.Get("must_not.0.range.LockExpiration.lte").String())
+		assert.NoError(t, err, body)
+		assert.WithinDuration(t, time.Now(), expired, time.Minute)
+	}
+}
@@ -1,10 +1,15 @@
 package convert
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/opensearch-project/opensearch-go/v4"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -15,7 +20,8 @@ import (
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
-func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit) (*searchMessage.Match, error) {
+// OpenSearchHitToMatch converts the hit to a match, the joined content highlights are limited to maxHighlightSize bytes.
+func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit, maxHighlightSize int) (*searchMessage.Match, error) {
 	resource, err := conversions.To[search.Resource](hit.Source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert hit source: %w", err)
@@ -55,19 +61,12 @@ func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit) (*searchMessage.Match,
 				SpaceId:   resourceParentID.GetSpaceId(),
 				OpaqueId:  resourceParentID.GetOpaqueId(),
 			},
-			Size:     resource.Size,
-			Type:     resource.Type,
-			MimeType: resource.MimeType,
-			Deleted:  resource.Deleted,
-			Tags:     resource.Tags,
-			Highlights: func() string {
-				contentHighlights, ok := hit.Highlight["Content"]
-				if !ok {
-					return ""
-				}
-
-				return strings.Join(contentHighlights[:], "; ")
-			}(),
+			Size:       resource.Size,
+			Type:       resource.Type,
+			MimeType:   resource.MimeType,
+			Deleted:    resource.Deleted,
+			Tags:       resource.Tags,
+			Highlights: search.JoinHighlights(hit.Highlight["Content"], maxHighlightSize),
 			Audio: func() *searchMessage.Audio {
 				if !strings.HasPrefix(resource.MimeType, "audio/") {
 					return nil
@@ -88,6 +87,9 @@ func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit) (*searchMessage.Match,
 				photo, _ := conversions.To[*searchMessage.Photo](resource.Photo)
 				return photo
 			}(),
+			SpaceName:      resource.SpaceName,
+			MatchedVersion: matchedVersion(resource.Versions, hit.Highlight["Versions.Content"]),
+			HasPreview:     resource.HasPreview,
 		},
 	}
 
@@ -97,3 +99,58 @@ func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit) (*searchMessage.Match,
 
 	return match, nil
 }
+
+// matchedVersion returns the key of the latest version whose content contains one of the highlight fragments
+func matchedVersion(versions []search.Version, fragments []string) string {
+	if len(fragments) == 0 {
+		return ""
+	}
+
+	unmark := strings.NewReplacer("<mark>", "", "</mark>", "")
+	for _, version := range versions {
+		for _, fragment := range fragments {
+			if strings.Contains(version.Content, unmark.Replace(fragment)) {
+				return version.Key
+			}
+		}
+	}
+
+	return ""
+}
+
+// OpenSearchError wraps the error of an OpenSearch request into the errors of the search package,
+// a missing index is reported as not ready, a version conflict as conflict and an unreachable or overloaded cluster as unavailable.
+// Cancelled requests and other errors are returned unchanged.
+func OpenSearchError(err error) error {
+	var (
+		structErr *opensearch.StructError
+		stringErr *opensearch.StringError
+		netErr    net.Error
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// the url errors of cancelled requests are net errors as well
+		return err
+	case errors.As(err, &structErr) && structErr.Err.Type == "index_not_found_exception":
+		return fmt.Errorf("%w: %w", search.ErrIndexNotReady, err)
+	case errors.As(err, &structErr) && structErr.Status == http.StatusConflict:
+		return fmt.Errorf("%w: %w", search.ErrConflict, err)
+	case errors.As(err, &structErr) && isUnavailableStatus(structErr.Status),
+		errors.As(err, &stringErr) && isUnavailableStatus(stringErr.Status),
+		errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", search.ErrBackendUnavailable, err)
+	default:
+		return err
+	}
+}
+
+func isUnavailableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
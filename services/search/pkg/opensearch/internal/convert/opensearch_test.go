@@ -1,9 +1,15 @@
 package convert_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"testing"
 
+	"github.com/opensearch-project/opensearch-go/v4"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/stretchr/testify/assert"
 
@@ -11,20 +17,23 @@ import (
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
 func TestOpenSearchHitToMatch(t *testing.T) {
 	resource := opensearchtest.Testdata.Resources.File
 	resource.MimeType = "audio/anything"
+	resource.SpaceName = "Marketing"
 
 	hit := opensearchgoAPI.SearchHit{
 		Score:  1.1,
 		Source: json.RawMessage(opensearchtest.JSONMustMarshal(t, resource)),
 	}
-	match, err := convert.OpenSearchHitToMatch(hit)
+	match, err := convert.OpenSearchHitToMatch(hit, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, hit.Score, match.Score)
 	assert.Equal(t, resource.Name, match.Entity.Name)
+	assert.Equal(t, "Marketing", match.Entity.SpaceName)
 	t.Parallel()
 	t.Run("converts the audio field to the expected type", func(t *testing.T) {
 		// searchMessage.Audio contains int64, int32 ... values that are converted to strings by the JSON marshaler,
@@ -36,3 +45,100 @@ func TestOpenSearchHitToMatch(t *testing.T) {
 		assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, audio), opensearchtest.JSONMustMarshal(t, match.Entity.Audio))
 	})
 }
+
+func TestOpenSearchHitToMatch_Highlights(t *testing.T) {
+	hit := opensearchgoAPI.SearchHit{
+		Source: json.RawMessage(opensearchtest.JSONMustMarshal(t, opensearchtest.Testdata.Resources.File)),
+		Highlight: map[string][]string{
+			"Content": {"the <mark>first</mark> fragment", "the <mark>second</mark> fragment", "the <mark>third</mark> fragment"},
+		},
+	}
+
+	match, err := convert.OpenSearchHitToMatch(hit, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "the <mark>first</mark> fragment; the <mark>second</mark> fragment; the <mark>third</mark> fragment", match.Entity.Highlights)
+
+	match, err = convert.OpenSearchHitToMatch(hit, 70)
+	assert.NoError(t, err)
+	assert.Equal(t, "the <mark>first</mark> fragment; the <mark>second</mark> fragment", match.Entity.Highlights)
+}
+
+func TestOpenSearchHitToMatch_MatchedVersion(t *testing.T) {
+	resource := opensearchtest.Testdata.Resources.File
+	resource.Versions = []search.Version{
+		{Key: "v3", Content: "the final draft"},
+		{Key: "v2", Content: "the budget draft"},
+		{Key: "v1", Content: "the budget notes"},
+	}
+
+	hit := opensearchgoAPI.SearchHit{
+		Source: json.RawMessage(opensearchtest.JSONMustMarshal(t, resource)),
+		Highlight: map[string][]string{
+			"Versions.Content": {"the <mark>budget</mark> notes", "the <mark>budget</mark> draft"},
+		},
+	}
+
+	match, err := convert.OpenSearchHitToMatch(hit, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", match.Entity.MatchedVersion)
+
+	hit.Highlight = nil
+	match, err = convert.OpenSearchHitToMatch(hit, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, match.Entity.MatchedVersion)
+}
+
+func TestOpenSearchError(t *testing.T) {
+	connErr := &url.Error{Op: "Post", URL: "http://localhost:9200/_search", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "missing index",
+			err:  &opensearch.StructError{Status: 404, Err: opensearch.Err{Type: "index_not_found_exception", Reason: "no such index [opencloud-resource]"}},
+			want: search.ErrIndexNotReady,
+		},
+		{
+			name: "version conflict",
+			err:  &opensearch.StructError{Status: 409, Err: opensearch.Err{Type: "version_conflict_engine_exception", Reason: "required seqNo [1], primary term [1]. current document has seqNo [2] and primary term [1]"}},
+			want: search.ErrConflict,
+		},
+		{
+			name: "unavailable shards",
+			err:  &opensearch.StructError{Status: 503, Err: opensearch.Err{Type: "search_phase_execution_exception", Reason: "all shards failed"}},
+			want: search.ErrBackendUnavailable,
+		},
+		{
+			name: "bad gateway",
+			err:  &opensearch.StringError{Status: 502, Err: "bad gateway"},
+			want: search.ErrBackendUnavailable,
+		},
+		{
+			name: "unreachable cluster",
+			err:  connErr,
+			want: search.ErrBackendUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := convert.OpenSearchError(fmt.Errorf("failed to search: %w", tc.err))
+			assert.ErrorIs(t, err, tc.want)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	t.Run("other errors are returned unchanged", func(t *testing.T) {
+		assert.NoError(t, convert.OpenSearchError(nil))
+
+		for _, err := range []error{
+			&opensearch.StructError{Status: 400, Err: opensearch.Err{Type: "parsing_exception", Reason: "unknown query"}},
+			&url.Error{Op: "Post", URL: "http://localhost:9200/_search", Err: context.Canceled},
+			errors.New("failed to build search request"),
+		} {
+			assert.Equal(t, err, convert.OpenSearchError(err))
+		}
+	})
+}
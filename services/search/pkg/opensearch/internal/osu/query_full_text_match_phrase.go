@@ -11,9 +11,10 @@ type MatchPhraseQuery struct {
 }
 
 type MatchPhraseQueryParams struct {
-	Analyzer       string `json:"analyzer,omitempty"`
-	Slop           int    `json:"slop,omitempty"`
-	ZeroTermsQuery string `json:"zero_terms_query,omitempty"`
+	Analyzer       string  `json:"analyzer,omitempty"`
+	Boost          float32 `json:"boost,omitempty"`
+	Slop           int     `json:"slop,omitempty"`
+	ZeroTermsQuery string  `json:"zero_terms_query,omitempty"`
 }
 
 func NewMatchPhraseQuery(field string) *MatchPhraseQuery {
@@ -0,0 +1,56 @@
+package osu
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+type TermsQuery[T comparable] struct {
+	field  string
+	values []T
+	params *TermsQueryParams
+}
+
+type TermsQueryParams struct {
+	Boost float32 `json:"boost,omitempty"`
+	Name  string  `json:"_name,omitempty"`
+}
+
+func NewTermsQuery[T comparable](field string) *TermsQuery[T] {
+	return &TermsQuery[T]{field: field}
+}
+
+func (q *TermsQuery[T]) Params(v *TermsQueryParams) *TermsQuery[T] {
+	q.params = v
+	return q
+}
+
+func (q *TermsQuery[T]) Values(v ...T) *TermsQuery[T] {
+	q.values = slices.Compact(v)
+	return q
+}
+
+func (q *TermsQuery[T]) Map() (map[string]any, error) {
+	base, err := newBase(q.params)
+	if err != nil {
+		return nil, err
+	}
+
+	applyValue(base, q.field, q.values)
+
+	if isEmpty(base) {
+		return nil, nil
+	}
+
+	return map[string]any{
+		"terms": base,
+	}, nil
+}
+
+func (q *TermsQuery[T]) MarshalJSON() ([]byte, error) {
+	data, err := q.Map()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,49 @@
+package osu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+)
+
+func TestTermsQuery(t *testing.T) {
+	tests := []opensearchtest.TableTest[osu.Builder, map[string]any]{
+		{
+			Name: "empty",
+			Got:  osu.NewTermsQuery[string]("empty"),
+			Want: nil,
+		},
+		{
+			Name: "no params",
+			Got:  osu.NewTermsQuery[string]("name").Values("tom", "jerry", "jerry"),
+			Want: map[string]any{
+				"terms": map[string]any{
+					"name": []string{"tom", "jerry"},
+				},
+			},
+		},
+		{
+			Name: "with params",
+			Got: osu.NewTermsQuery[string]("name").Values("tom").Params(&osu.TermsQueryParams{
+				Boost: 1.0,
+				Name:  "named",
+			}),
+			Want: map[string]any{
+				"terms": map[string]any{
+					"name":  []string{"tom"},
+					"boost": 1.0,
+					"_name": "named",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, test.Want), opensearchtest.JSONMustMarshal(t, test.Got))
+		})
+	}
+}
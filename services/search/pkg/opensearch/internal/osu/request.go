@@ -41,9 +41,40 @@ func (q QueryReqBody[O]) MarshalJSON() ([]byte, error) {
 //----------------------------------------------------------------------------//
 
 type BodyParamHighlight struct {
-	PreTags  []string                      `json:"pre_tags,omitempty"`
-	PostTags []string                      `json:"post_tags,omitempty"`
-	Fields   map[string]BodyParamHighlight `json:"fields,omitempty"`
+	PreTags           []string                      `json:"pre_tags,omitempty"`
+	PostTags          []string                      `json:"post_tags,omitempty"`
+	Fields            map[string]BodyParamHighlight `json:"fields,omitempty"`
+	NumberOfFragments int                           `json:"number_of_fragments,omitempty"`
+	FragmentSize      int                           `json:"fragment_size,omitempty"`
+	Order             string                        `json:"order,omitempty"`
+}
+
+type BodyParamSuggest struct {
+	Text string                  `json:"text,omitempty"`
+	Term *BodyParamTermSuggester `json:"term,omitempty"`
+}
+
+type BodyParamTermSuggester struct {
+	Field       string `json:"field,omitempty"`
+	Size        int    `json:"size,omitempty"`
+	SuggestMode string `json:"suggest_mode,omitempty"`
+	Sort        string `json:"sort,omitempty"`
+	MaxEdits    int    `json:"max_edits,omitempty"`
+}
+
+type BodyParamAggregation struct {
+	Terms *BodyParamTermsAggregation `json:"terms,omitempty"`
+	Sum   *BodyParamSumAggregation   `json:"sum,omitempty"`
+}
+
+type BodyParamTermsAggregation struct {
+	Field string              `json:"field,omitempty"`
+	Size  int                 `json:"size,omitempty"`
+	Order []map[string]string `json:"order,omitempty"`
+}
+
+type BodyParamSumAggregation struct {
+	Field string `json:"field,omitempty"`
 }
 
 type BodyParamScript struct {
@@ -64,7 +95,12 @@ func BuildSearchReq(req *opensearchgoAPI.SearchReq, q Builder, p ...SearchBodyPa
 }
 
 type SearchBodyParams struct {
-	Highlight *BodyParamHighlight `json:"highlight,omitempty"`
+	Highlight   *BodyParamHighlight             `json:"highlight,omitempty"`
+	Suggest     map[string]BodyParamSuggest     `json:"suggest,omitempty"`
+	Sort        []map[string]string             `json:"sort,omitempty"`
+	Aggs        map[string]BodyParamAggregation `json:"aggs,omitempty"`
+	Source      []string                        `json:"_source,omitempty"`
+	SearchAfter []any                           `json:"search_after,omitempty"`
 }
 
 //----------------------------------------------------------------------------//
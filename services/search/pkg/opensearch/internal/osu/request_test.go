@@ -74,6 +74,254 @@ func TestBuildSearchReq(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "highlight fragments",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[string]("content").Value("content"),
+					osu.SearchBodyParams{
+						Highlight: &osu.BodyParamHighlight{
+							Fields: map[string]osu.BodyParamHighlight{
+								"content": {
+									NumberOfFragments: 3,
+									FragmentSize:      50,
+									Order:             "score",
+								},
+							},
+						},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"content": map[string]any{
+							"value": "content",
+						},
+					},
+				},
+				"highlight": map[string]any{
+					"fields": map[string]any{
+						"content": map[string]any{
+							"number_of_fragments": 3,
+							"fragment_size":       50,
+							"order":               "score",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "sort",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[string]("content").Value("content"),
+					osu.SearchBodyParams{
+						Sort: []map[string]string{
+							{"_score": "desc"},
+							{"ID": "asc"},
+						},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"content": map[string]any{
+							"value": "content",
+						},
+					},
+				},
+				"sort": []map[string]any{
+					{"_score": "desc"},
+					{"ID": "asc"},
+				},
+			},
+		},
+		{
+			Name: "search after",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[string]("Path").Value("./folder"),
+					osu.SearchBodyParams{
+						Sort: []map[string]string{
+							{"ID": "asc"},
+						},
+						SearchAfter: []any{"storage$space!item"},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"Path": map[string]any{
+							"value": "./folder",
+						},
+					},
+				},
+				"sort": []map[string]any{
+					{"ID": "asc"},
+				},
+				"search_after": []any{"storage$space!item"},
+			},
+		},
+		{
+			Name: "suggest",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[bool]("deleted").Value(false),
+					osu.SearchBodyParams{
+						Suggest: map[string]osu.BodyParamSuggest{
+							"name": {
+								Text: "invoce",
+								Term: &osu.BodyParamTermSuggester{
+									Field:       "name",
+									SuggestMode: "always",
+									MaxEdits:    2,
+								},
+							},
+						},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"deleted": map[string]any{
+							"value": false,
+						},
+					},
+				},
+				"suggest": map[string]any{
+					"name": map[string]any{
+						"text": "invoce",
+						"term": map[string]any{
+							"field":        "name",
+							"suggest_mode": "always",
+							"max_edits":    2,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "aggregations",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[bool]("deleted").Value(false),
+					osu.SearchBodyParams{
+						Aggs: map[string]osu.BodyParamAggregation{
+							"parents": {
+								Terms: &osu.BodyParamTermsAggregation{
+									Field: "parent",
+									Size:  10,
+									Order: []map[string]string{
+										{"_count": "desc"},
+										{"_key": "asc"},
+									},
+								},
+							},
+						},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"deleted": map[string]any{
+							"value": false,
+						},
+					},
+				},
+				"aggs": map[string]any{
+					"parents": map[string]any{
+						"terms": map[string]any{
+							"field": "parent",
+							"size":  10,
+							"order": []map[string]string{
+								{"_count": "desc"},
+								{"_key": "asc"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "source",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[bool]("deleted").Value(false),
+					osu.SearchBodyParams{
+						Source: []string{"ID"},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"deleted": map[string]any{
+							"value": false,
+						},
+					},
+				},
+				"_source": []string{"ID"},
+			},
+		},
+		{
+			Name: "sum aggregation",
+			Got: func() io.Reader {
+				req, _ := osu.BuildSearchReq(
+					&opensearchgoAPI.SearchReq{},
+					osu.NewTermQuery[bool]("deleted").Value(false),
+					osu.SearchBodyParams{
+						Aggs: map[string]osu.BodyParamAggregation{
+							"sum": {
+								Sum: &osu.BodyParamSumAggregation{
+									Field: "size",
+								},
+							},
+						},
+					},
+				)
+
+				return req.Body
+			}(),
+			Want: map[string]any{
+				"query": map[string]any{
+					"term": map[string]any{
+						"deleted": map[string]any{
+							"value": false,
+						},
+					},
+				},
+				"aggs": map[string]any{
+					"sum": map[string]any{
+						"sum": map[string]any{
+							"field": "size",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"slices"
 	"testing"
 
@@ -109,9 +110,17 @@ func (tc *TestClient) IndicesDelete(ctx context.Context, indices []string) error
 		return err
 	}
 
-	resp, err := tc.c.Indices.Delete(ctx, opensearchgoAPI.IndicesDeleteReq{
+	// aliases can't be deleted by their name, delete the indexes they point to instead
+	getResp, err := tc.c.Indices.Get(ctx, opensearchgoAPI.IndicesGetReq{
 		Indices: indices,
 	})
+	if err != nil {
+		return fmt.Errorf("failed to get indices: %w", err)
+	}
+
+	resp, err := tc.c.Indices.Delete(ctx, opensearchgoAPI.IndicesDeleteReq{
+		Indices: slices.Collect(maps.Keys(getResp.Indices)),
+	})
 	switch {
 	case err != nil:
 		return fmt.Errorf("failed to delete indices: %w", err)
@@ -2,63 +2,256 @@ package opensearch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 
 	"github.com/opencloud-eu/opencloud/pkg/conversions"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
 )
 
-func searchResourceByID(ctx context.Context, client *opensearchgoAPI.Client, index string, id string) (search.Resource, error) {
+// requestContext returns the context of a request to OpenSearch which is canceled after the given timeout,
+// 0 disables the timeout.
+func requestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// getResourceByID returns the resource of the given id with the sequence number of its document.
+func getResourceByID(ctx context.Context, client *opensearchgoAPI.Client, index string, routed bool, id string) (search.Resource, error) {
+	resources, err := getResources(ctx, client, index, routed, id)
+	switch {
+	case err != nil:
+		return search.Resource{}, err
+	case len(resources) == 0:
+		return search.Resource{}, errtypes.NotFound(fmt.Sprintf("document with id %s not found", id))
+	}
+
+	return resources[0], nil
+}
+
+// getResources returns the resources of the given ids which are part of the index with the sequence numbers of their documents.
+// Unlike searches, the documents are read in real time, they include the writes which are not refreshed yet.
+// Routed documents are read from the shard of the space of their id, the ones which are not found there are searched on
+// all shards, they belong to resources which were moved across spaces.
+func getResources(ctx context.Context, client *opensearchgoAPI.Client, index string, routed bool, ids ...string) ([]search.Resource, error) {
+	var payload any = map[string][]string{"ids": ids}
+	if routed {
+		docs := make([]map[string]string, 0, len(ids))
+		for _, id := range ids {
+			docs = append(docs, map[string]string{"_id": id, "routing": spaceRouting(id)})
+		}
+		payload = map[string]any{"docs": docs}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.MGet(ctx, opensearchgoAPI.MGetReq{
+		Index: index,
+		Body:  strings.NewReader(string(body)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+
+	resources := make([]search.Resource, 0, len(resp.Docs))
+	var missing []string
+	for _, doc := range resp.Docs {
+		if !doc.Found {
+			missing = append(missing, doc.ID)
+			continue
+		}
+
+		resource, err := conversions.To[search.Resource](doc.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert document source: %w", err)
+		}
+		resource.SeqNo, resource.PrimaryTerm = int64(doc.SeqNo), int64(doc.PrimaryTerm)
+		resources = append(resources, resource)
+	}
+
+	if !routed || len(missing) == 0 {
+		return resources, nil
+	}
+
+	moved, err := searchResourcesByIDs(ctx, client, index, missing...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(resources, moved...), nil
+}
+
+// searchResourcesByIDs returns the resources of the given ids which are part of the index with the sequence numbers
+// of their documents. All shards are searched, so the documents of resources moved across spaces are found as well.
+func searchResourcesByIDs(ctx context.Context, client *opensearchgoAPI.Client, index string, ids ...string) ([]search.Resource, error) {
 	req, err := osu.BuildSearchReq(
 		&opensearchgoAPI.SearchReq{
 			Indices: []string{index},
+			Params: opensearchgoAPI.SearchParams{
+				Size:             conversions.ToPointer(len(ids)),
+				SeqNoPrimaryTerm: conversions.ToPointer(true),
+			},
 		},
-		osu.NewIDsQuery(id),
+		osu.NewIDsQuery(ids...),
 	)
 	if err != nil {
-		return search.Resource{}, fmt.Errorf("failed to build search request: %w", err)
+		return nil, fmt.Errorf("failed to build search request: %w", err)
 	}
 
 	resp, err := client.Search(ctx, req)
-	switch {
-	case err != nil:
-		return search.Resource{}, fmt.Errorf("failed to search for resource: %w", err)
-	case resp.Hits.Total.Value == 0 || len(resp.Hits.Hits) == 0:
-		return search.Resource{}, fmt.Errorf("document with id %s not found", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for resources: %w", err)
 	}
 
-	resource, err := conversions.To[search.Resource](resp.Hits.Hits[0].Source)
-	if err != nil {
-		return search.Resource{}, fmt.Errorf("failed to convert hit source: %w", err)
+	resources := make([]search.Resource, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		resource, err := conversions.To[search.Resource](hit.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert hit source: %w", err)
+		}
+		if hit.SeqNo != nil && hit.PrimaryTerm != nil {
+			resource.SeqNo, resource.PrimaryTerm = int64(*hit.SeqNo), int64(*hit.PrimaryTerm)
+		}
+		resources = append(resources, resource)
 	}
 
-	return resource, nil
+	return resources, nil
 }
 
-func updateSelfAndDescendants(ctx context.Context, client *opensearchgoAPI.Client, index string, id string, scriptProvider func(search.Resource) *osu.BodyParamScript) error {
-	if scriptProvider == nil {
-		return fmt.Errorf("script cannot be nil")
+// maxResultWindow is the default maximum number of hits a single search request can return
+const maxResultWindow = 10000
+
+// searchDescendantIDs returns the ids of all descendants of the given containers by following the parent ids,
+// which also finds descendants whose path diverged from the path of their container, like trashed ones.
+// Only the first maxResultWindow resources of each level of the tree are taken into account. The descendants belong to
+// the space of their containers, only the shards of the given routing values are searched, all shards are if none is given.
+func searchDescendantIDs(ctx context.Context, client *opensearchgoAPI.Client, index string, routing []string, containerIDs ...string) ([]string, error) {
+	var ids []string
+	seen := make(map[string]struct{})
+	for len(containerIDs) > 0 {
+		req, err := osu.BuildSearchReq(
+			&opensearchgoAPI.SearchReq{
+				Indices: []string{index},
+				Params: opensearchgoAPI.SearchParams{
+					Size:           conversions.ToPointer(maxResultWindow),
+					SourceIncludes: []string{"ID", "Type"},
+					Routing:        routing,
+				},
+			},
+			osu.NewTermsQuery[string]("ParentID").Values(containerIDs...),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build search request: %w", err)
+		}
+
+		resp, err := client.Search(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for descendants: %w", err)
+		}
+
+		containerIDs = nil
+		for _, hit := range resp.Hits.Hits {
+			resource, err := conversions.To[search.Resource](hit.Source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert hit source: %w", err)
+			}
+
+			if _, ok := seen[resource.ID]; ok {
+				continue
+			}
+			seen[resource.ID] = struct{}{}
+
+			ids = append(ids, resource.ID)
+			if resource.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+				containerIDs = append(containerIDs, resource.ID)
+			}
+		}
 	}
 
-	resource, err := searchResourceByID(context.Background(), client, index, id)
+	return ids, nil
+}
+
+// countDocuments returns the number of documents matching the query, only the shards of the given routing values
+// are searched for them, all shards are if no routing is given.
+func countDocuments(ctx context.Context, client *opensearchgoAPI.Client, index string, routing []string, query osu.Builder) (int, error) {
+	req, err := osu.BuildIndicesCountReq(
+		&opensearchgoAPI.IndicesCountReq{
+			Indices: []string{index},
+			Params:  opensearchgoAPI.IndicesCountParams{Routing: routing},
+		},
+		query,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build count request: %w", err)
+	}
+
+	resp, err := client.Indices.Count(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to get resource: %w", err)
+		return 0, convert.OpenSearchError(fmt.Errorf("failed to count documents: %w", err))
 	}
 
+	return resp.Count, nil
+}
+
+// updateIfUnchanged applies the script to the document of the resource if it still has the sequence number
+// the resource was read with, search.ErrConflict is returned otherwise.
+func updateIfUnchanged(ctx context.Context, client *opensearchgoAPI.Client, index string, routed bool, resource search.Resource, script *osu.BodyParamScript) error {
+	body, err := json.Marshal(map[string]any{"script": script})
+	if err != nil {
+		return err
+	}
+
+	params := opensearchgoAPI.UpdateParams{}
+	if resource.HasSequence() {
+		params.IfSeqNo = conversions.ToPointer(int(resource.SeqNo))
+		params.IfPrimaryTerm = conversions.ToPointer(int(resource.PrimaryTerm))
+	}
+	if routed {
+		params.Routing = documentRouting(resource.ID, resource.RootID)
+	}
+
+	if _, err := client.Update(ctx, opensearchgoAPI.UpdateReq{
+		Index:      index,
+		DocumentID: resource.ID,
+		Body:       strings.NewReader(string(body)),
+		Params:     params,
+	}); err != nil {
+		return convert.OpenSearchError(fmt.Errorf("failed to update resource %s: %w", resource.ID, err))
+	}
+
+	return nil
+}
+
+// updateByQuery applies the script to the documents matching the query, only the shards of the given routing values
+// are searched for them, all shards are if no routing is given.
+func updateByQuery(ctx context.Context, client *opensearchgoAPI.Client, index string, routing []string, query osu.Builder, script *osu.BodyParamScript) error {
 	req, err := osu.BuildUpdateByQueryReq(
 		opensearchgoAPI.UpdateByQueryReq{
 			Indices: []string{index},
 			Params: opensearchgoAPI.UpdateByQueryParams{
 				WaitForCompletion: conversions.ToPointer(true),
+				Routing:           routing,
 			},
 		},
-		osu.NewTermQuery[string]("Path").Value(resource.Path),
+		query,
 		osu.UpdateByQueryBodyParams{
-			Script: scriptProvider(resource),
+			Script: script,
 		},
 	)
 	if err != nil {
@@ -75,3 +268,73 @@ func updateSelfAndDescendants(ctx context.Context, client *opensearchgoAPI.Clien
 
 	return nil
 }
+
+// rootIDOf returns the id of the space root the given resource belongs to.
+func rootIDOf(id string) string {
+	rID, err := storagespace.ParseID(id)
+	if err != nil {
+		return id
+	}
+
+	return storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: rID.GetStorageId(),
+		SpaceId:   rID.GetSpaceId(),
+		OpaqueId:  rID.GetSpaceId(),
+	})
+}
+
+// spaceRouting returns the routing value of the documents of the space the given resource or space root belongs to.
+// The ids of the resources of a space share the storage and space id of its root, so the routing derived from the
+// RootID of a resource equals the routing derived from its own id, which is all that is known when looking it up.
+// Only resources moved across spaces keep an id of their previous space.
+func spaceRouting(id string) string {
+	rID, err := storagespace.ParseID(id)
+	if err != nil || rID.GetSpaceId() == "" {
+		return id
+	}
+
+	return storagespace.FormatStorageID(rID.GetStorageId(), rID.GetSpaceId())
+}
+
+// documentRouting returns the routing value of the document of a resource, the documents are routed by the space
+// the resources belong to. The id is used for resources without a RootID.
+func documentRouting(id, rootID string) string {
+	if rootID != "" {
+		return spaceRouting(rootID)
+	}
+	return spaceRouting(id)
+}
+
+// resourceRouting returns the distinct routing values of the documents of the given resources,
+// nil if the documents are not routed by space, so the requests hit all shards.
+func resourceRouting(routed bool, resources ...search.Resource) []string {
+	if !routed {
+		return nil
+	}
+
+	var routing []string
+	for _, resource := range resources {
+		if value := documentRouting(resource.ID, resource.RootID); !slices.Contains(routing, value) {
+			routing = append(routing, value)
+		}
+	}
+
+	return routing
+}
+
+// routingOf returns the distinct routing values of the spaces the given resources belong to,
+// nil if the documents are not routed by space, so the requests hit all shards.
+func routingOf(routed bool, ids ...string) []string {
+	if !routed {
+		return nil
+	}
+
+	var routing []string
+	for _, id := range ids {
+		if value := spaceRouting(id); !slices.Contains(routing, value) {
+			routing = append(routing, value)
+		}
+	}
+
+	return routing
+}
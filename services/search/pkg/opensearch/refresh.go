@@ -0,0 +1,133 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+	"github.com/tidwall/gjson"
+
+	"github.com/opencloud-eu/opencloud/pkg/conversions"
+)
+
+// refreshControl keeps track of the bulk indexing operations which disabled the refresh of the index
+type refreshControl struct {
+	mu       sync.Mutex
+	active   int
+	interval *string
+}
+
+// StartBulkIndexing disables the periodic refresh of the index if configured, which speeds up indexing many resources.
+// The returned function restores the previous refresh interval and refreshes the index once all bulk indexing
+// operations are done, it has to be called even if the indexing failed.
+func (b *Backend) StartBulkIndexing() (func() error, error) {
+	if !b.disableRefreshOnBulk {
+		return func() error { return nil }, nil
+	}
+
+	b.refresh.mu.Lock()
+	defer b.refresh.mu.Unlock()
+
+	if b.refresh.active == 0 {
+		interval, err := b.getRefreshInterval(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := b.setRefreshInterval(context.TODO(), conversions.ToPointer("-1")); err != nil {
+			return nil, err
+		}
+		b.refresh.interval = interval
+		b.log.Debug().Str("index", b.index).Msg("disabled the index refresh for bulk indexing")
+	}
+	b.refresh.active++
+
+	var once sync.Once
+	return func() error {
+		var err error
+		once.Do(func() {
+			err = b.endBulkIndexing()
+		})
+		return err
+	}, nil
+}
+
+func (b *Backend) endBulkIndexing() error {
+	b.refresh.mu.Lock()
+	defer b.refresh.mu.Unlock()
+
+	b.refresh.active--
+	if b.refresh.active > 0 {
+		return nil
+	}
+
+	if err := b.setRefreshInterval(context.TODO(), b.refresh.interval); err != nil {
+		return err
+	}
+	b.log.Debug().Str("index", b.index).Msg("restored the index refresh after bulk indexing")
+
+	// make the bulk indexed resources searchable right away
+	return refreshIndex(context.TODO(), b.client, b.index)
+}
+
+// isBulkIndexing reports whether the refresh of the index is currently disabled for bulk indexing
+func (b *Backend) isBulkIndexing() bool {
+	b.refresh.mu.Lock()
+	defer b.refresh.mu.Unlock()
+
+	return b.refresh.active > 0
+}
+
+// refreshIndex makes all changes of the index searchable
+func refreshIndex(ctx context.Context, client *opensearchgoAPI.Client, index string) error {
+	if _, err := client.Indices.Refresh(ctx, &opensearchgoAPI.IndicesRefreshReq{
+		Indices: []string{index},
+	}); err != nil {
+		return fmt.Errorf("failed to refresh index: %w", err)
+	}
+
+	return nil
+}
+
+// getRefreshInterval returns the refresh interval of the index, nil if the default is used
+func (b *Backend) getRefreshInterval(ctx context.Context) (*string, error) {
+	resp, err := b.client.Indices.Settings.Get(ctx, &opensearchgoAPI.SettingsGetReq{
+		Indices:  []string{b.index},
+		Settings: []string{"index.refresh_interval"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index settings: %w", err)
+	}
+
+	// the settings are keyed by the index the alias points to
+	for _, index := range resp.Indices {
+		interval := gjson.GetBytes(index.Settings, "index.refresh_interval")
+		if interval.Exists() {
+			return conversions.ToPointer(interval.String()), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// setRefreshInterval sets the refresh interval of the index, nil resets it to the default
+func (b *Backend) setRefreshInterval(ctx context.Context, interval *string) error {
+	body, err := json.Marshal(map[string]map[string]*string{
+		"index": {"refresh_interval": interval},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.client.Indices.Settings.Put(ctx, opensearchgoAPI.SettingsPutReq{
+		Indices: []string{b.index},
+		Body:    strings.NewReader(string(body)),
+	}); err != nil {
+		return fmt.Errorf("failed to update index settings: %w", err)
+	}
+
+	return nil
+}
@@ -32,3 +32,11 @@ func (c Creator[T]) Create(qs string) (T, error) {
 
 // DefaultCreator exposes a kql to bleve query creator.
 var DefaultCreator = Creator[bQuery.Query]{kql.Builder{}, Compiler{}}
+
+// NewCreator returns a kql to bleve query creator configured by the given options.
+func NewCreator(opts query.Options) Creator[bQuery.Query] {
+	return Creator[bQuery.Query]{
+		kql.Builder{DefaultOperator: opts.DefaultOperator, MinimumShouldMatch: opts.MinimumShouldMatch},
+		Compiler{Boosts: opts.Boosts, Transliterate: opts.Transliterate, Phonetic: opts.Phonetic},
+	}
+}
@@ -2,29 +2,19 @@ package bleve
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	bleveQuery "github.com/blevesearch/bleve/v2/search/query"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/opencloud/pkg/ast"
 	"github.com/opencloud-eu/opencloud/pkg/kql"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
-var _fields = map[string]string{
-	"rootid":    "RootID",
-	"path":      "Path",
-	"id":        "ID",
-	"name":      "Name",
-	"size":      "Size",
-	"mtime":     "Mtime",
-	"mediatype": "MimeType",
-	"type":      "Type",
-	"tag":       "Tags",
-	"tags":      "Tags",
-	"content":   "Content",
-	"hidden":    "Hidden",
-}
-
 // The following quoted string enumerates the characters which may be escaped: "+-=&|><!(){}[]^\"~*?:\\/ "
 // based on bleve docs https://blevesearch.com/docs/Query-String-Query/
 // Wildcards * and ? are excluded
@@ -54,19 +44,32 @@ var bleveEscaper = strings.NewReplacer(
 )
 
 // Compiler represents a KQL query search string to the bleve query formatter.
-type Compiler struct{}
+type Compiler struct {
+	// Boosts weights the matches of a field in the score, the fields are not boosted if not set.
+	Boosts map[string]float64
+	// Transliterate also matches the name terms against the latin transliteration of the names in the Name.translit field.
+	Transliterate bool
+	// Phonetic matches the name~phonetic terms against the soundex codes of the names in the Name.phonetic field,
+	// they match like plain name terms if not set.
+	Phonetic bool
+}
 
 // Compile implements the query formatter which converts the KQL query search string to the bleve query.
 func (c Compiler) Compile(givenAst *ast.Ast) (bleveQuery.Query, error) {
-	q, err := compile(givenAst)
+	q, err := c.compile(givenAst)
 	if err != nil {
 		return nil, err
 	}
 	return q, nil
 }
 
-func compile(a *ast.Ast) (bleveQuery.Query, error) {
-	q, _, err := walk(0, a.Nodes)
+func (c Compiler) compile(a *ast.Ast) (bleveQuery.Query, error) {
+	nodes, err := query.NormalizeBooleans(a.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	q, _, err := c.walk(0, nodes)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +80,7 @@ func compile(a *ast.Ast) (bleveQuery.Query, error) {
 	return bleve.NewConjunctionQuery(q), nil
 }
 
-func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
+func (c Compiler) walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 	var prev, next bleveQuery.Query
 	var operator *ast.OperatorNode
 	var isGroup bool
@@ -86,12 +89,15 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 		case *ast.StringNode:
 			k := getField(n.Key)
 			v := n.Value
-			if k != "ID" && k != "Size" {
+			if k != "ID" && k != "ParentID" && k != "Size" {
 				v = bleveEscaper.Replace(n.Value)
 			}
 
-			if k != "Hidden" {
-				v = strings.ToLower(v)
+			v = strings.ToLower(v)
+
+			if k == "Extension" {
+				// allow to search for extensions as they are written, e.g. ext:.docx
+				v = strings.TrimPrefix(v, ".")
 			}
 
 			var q bleveQuery.Query
@@ -102,8 +108,26 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 				if prev == nil {
 					isGroup = group
 				}
+			case "Type":
+				q = resourceType(k, v)
+			case "Name":
+				if n.Mode == kql.MatchPhonetic && c.Phonetic {
+					q = c.phonetic(n.Value)
+					break
+				}
+				fallthrough
 			default:
+				// the boost of a query string query is ignored, it has to be part of the query string
+				if boost, ok := c.Boosts[k]; ok && boost > 0 && boost != 1 {
+					v += "^" + strconv.FormatFloat(boost, 'f', -1, 64)
+				}
 				q = bleveQuery.NewQueryStringQuery(k + ":" + v)
+				if k == "Name" && c.Transliterate {
+					q = bleveQuery.NewBooleanQuery(nil, []bleveQuery.Query{
+						q,
+						bleveQuery.NewQueryStringQuery("Name.translit:" + search.Transliterate(v)),
+					}, nil)
+				}
 			}
 
 			if prev == nil {
@@ -147,7 +171,7 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 				next = q
 			}
 		case *ast.BooleanNode:
-			q := bleveQuery.NewQueryStringQuery(getField(n.Key) + fmt.Sprintf(":%v", n.Value))
+			q := booleanField(getField(n.Key), n.Value)
 			if prev == nil {
 				prev = q
 			} else {
@@ -157,7 +181,7 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 			if n.Key != "" {
 				n = normalizeGroupingProperty(n)
 			}
-			q, _, err := walk(0, n.Nodes)
+			q, err := c.group(n)
 			if err != nil {
 				return nil, 0, err
 			}
@@ -172,7 +196,7 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 				operator = n
 			} else if n.Value == kql.BoolNOT {
 				var err error
-				next, offset, err = nextNode(i+1, nodes)
+				next, offset, err = c.nextNode(i+1, nodes)
 				if err != nil {
 					return nil, 0, err
 				}
@@ -202,9 +226,9 @@ func walk(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 	return prev, offset, nil
 }
 
-func nextNode(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
+func (c Compiler) nextNode(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 	if n, ok := nodes[offset].(*ast.GroupNode); ok {
-		gq, _, err := walk(0, n.Nodes)
+		gq, err := c.group(n)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -212,11 +236,25 @@ func nextNode(offset int, nodes []ast.Node) (bleveQuery.Query, int, error) {
 	}
 	if n, ok := nodes[offset].(*ast.OperatorNode); ok {
 		if n.Value == kql.BoolNOT {
-			return walk(offset, nodes)
+			return c.walk(offset, nodes)
 		}
 	}
 	one := nodes[:offset+1]
-	return walk(offset, one)
+	return c.walk(offset, one)
+}
+
+// group compiles the nodes of the group, at least the minimum match of its OR-connected nodes have to match
+func (c Compiler) group(n *ast.GroupNode) (bleveQuery.Query, error) {
+	q, _, err := c.walk(0, n.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if dq, ok := q.(*bleveQuery.DisjunctionQuery); ok && n.MinimumMatch > 0 {
+		dq.SetMin(float64(n.MinimumMatch))
+	}
+
+	return q, nil
 }
 
 func mapBinary(operator *ast.OperatorNode, ln, rn bleveQuery.Query, leftIsGroup bool) bleveQuery.Query {
@@ -265,12 +303,24 @@ func mapBinary(operator *ast.OperatorNode, ln, rn bleveQuery.Query, leftIsGroup
 	})
 }
 
+// phonetic matches the names whose words sound like the words of the value, in the same order
+func (c Compiler) phonetic(value string) bleveQuery.Query {
+	q := bleveQuery.NewMatchPhraseQuery(value)
+	q.SetField("Name.phonetic")
+	// bleve resolves the analyzer of a field by its document path, which is shared by all fields of the name
+	q.Analyzer = "phonetic"
+	if boost, ok := c.Boosts["Name"]; ok && boost > 0 && boost != 1 {
+		q.SetBoost(boost)
+	}
+	return q
+}
+
 func getField(name string) string {
 	if name == "" {
 		return "Name"
 	}
-	if _, ok := _fields[strings.ToLower(name)]; ok {
-		return _fields[strings.ToLower(name)]
+	if field, ok := query.Fields[strings.ToLower(name)]; ok {
+		return field
 	}
 	return name
 }
@@ -284,6 +334,67 @@ func normalizeGroupingProperty(group *ast.GroupNode) *ast.GroupNode {
 	return group
 }
 
+// booleanField returns a query on the boolean field, false matches all documents whose field is not true,
+// including the documents which were indexed before the field existed.
+func booleanField(k string, v bool) bleveQuery.Query {
+	var isTrue bleveQuery.Query
+	if k == "Locked" {
+		isTrue = locked(time.Now())
+	} else {
+		field := bleveQuery.NewBoolFieldQuery(true)
+		field.SetField(k)
+		isTrue = field
+	}
+	if v {
+		return isTrue
+	}
+
+	q := bleve.NewBooleanQuery()
+	q.AddMustNot(isTrue)
+	return q
+}
+
+// locked matches the locked resources whose lock has not expired at the given time, locks without expiration never expire
+func locked(now time.Time) bleveQuery.Query {
+	isLocked := bleveQuery.NewBoolFieldQuery(true)
+	isLocked.SetField("Locked")
+
+	inclusive := true
+	expired := bleveQuery.NewDateRangeInclusiveQuery(time.Time{}, now, nil, &inclusive)
+	expired.SetField("LockExpiration")
+
+	q := bleve.NewBooleanQuery()
+	q.AddMust(isLocked)
+	q.AddMustNot(expired)
+	return q
+}
+
+// resourceType returns a numeric term query on the resource type, which is given by its name or its number
+func resourceType(k, v string) bleveQuery.Query {
+	var value float64
+	switch v {
+	case "file":
+		value = float64(provider.ResourceType_RESOURCE_TYPE_FILE)
+	case "folder":
+		value = float64(provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+	case "reference":
+		value = float64(provider.ResourceType_RESOURCE_TYPE_REFERENCE)
+	case "symlink":
+		value = float64(provider.ResourceType_RESOURCE_TYPE_SYMLINK)
+	default:
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return bleveQuery.NewQueryStringQuery(k + ":" + v)
+		}
+		value = float64(n)
+	}
+
+	inclusive := true
+	q := bleveQuery.NewNumericRangeInclusiveQuery(&value, &value, &inclusive, &inclusive)
+	q.SetField(k)
+	return q
+}
+
 func mimeType(k, v string) (bleveQuery.Query, bool) {
 	switch v {
 	case "file":
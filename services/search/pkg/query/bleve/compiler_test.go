@@ -1,12 +1,17 @@
 package bleve
 
 import (
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
 	tAssert "github.com/stretchr/testify/assert"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config/defaults"
+	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
 )
 
 var timeMustParse = func(t *testing.T, ts string) time.Time {
@@ -18,6 +23,13 @@ var timeMustParse = func(t *testing.T, ts string) time.Time {
 	return tp
 }
 
+var numericTermQuery = func(field string, value float64) query.Query {
+	inclusive := true
+	q := query.NewNumericRangeInclusiveQuery(&value, &value, &inclusive, &inclusive)
+	q.SetField(field)
+	return q
+}
+
 func Test_compile(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -64,6 +76,36 @@ func Test_compile(t *testing.T) {
 			}),
 			wantErr: false,
 		},
+		{
+			name: `owner:ALICE-ID creator:bob-id`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "owner", Value: "ALICE-ID"},
+					&ast.OperatorNode{Value: "AND"},
+					&ast.StringNode{Key: "creator", Value: "bob-id"},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				query.NewQueryStringQuery(`Owner:alice\-id`),
+				query.NewQueryStringQuery(`CreatedBy:bob\-id`),
+			}),
+			wantErr: false,
+		},
+		{
+			name: `ext:DOCX ext:.pdf`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "ext", Value: "DOCX"},
+					&ast.OperatorNode{Value: "OR"},
+					&ast.StringNode{Key: "ext", Value: ".pdf"},
+				},
+			},
+			want: query.NewDisjunctionQuery([]query.Query{
+				query.NewQueryStringQuery(`Extension:docx`),
+				query.NewQueryStringQuery(`Extension:pdf`),
+			}),
+			wantErr: false,
+		},
 		{
 			name: `tag:bestseller tag:book`,
 			args: &ast.Ast{
@@ -281,6 +323,71 @@ func Test_compile(t *testing.T) {
 			}),
 			wantErr: false,
 		},
+		{
+			name: `created<2023-09-05T12:40:59.14741+02:00`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.DateTimeNode{
+						Key:      "created",
+						Operator: &ast.OperatorNode{Value: "<"},
+						Value:    timeMustParse(t, "2023-09-05T08:42:11.23554+02:00"),
+					},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				func() query.Query {
+					q := query.NewDateRangeInclusiveQuery(time.Time{}, timeMustParse(t, "2023-09-05T08:42:11.23554+02:00"), nil, &[]bool{false}[0])
+					q.FieldVal = "Ctime"
+					return q
+				}(),
+			}),
+			wantErr: false,
+		},
+		{
+			name: `parentid:b27d3bf1-b254-459f-92e8-bdba668d6d3f$d0648459-25fb-4ed8-8684-bc62c7dca29c!d0648459-25fb-4ed8-8684-bc62c7dca29c`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{
+						Key:   "parentid",
+						Value: "b27d3bf1-b254-459f-92e8-bdba668d6d3f$d0648459-25fb-4ed8-8684-bc62c7dca29c!d0648459-25fb-4ed8-8684-bc62c7dca29c",
+					},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				query.NewQueryStringQuery(`ParentID:b27d3bf1-b254-459f-92e8-bdba668d6d3f$d0648459-25fb-4ed8-8684-bc62c7dca29c!d0648459-25fb-4ed8-8684-bc62c7dca29c`),
+			}),
+			wantErr: false,
+		},
+		{
+			name: `type:folder AND name:Jane`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "type", Value: "folder"},
+					&ast.OperatorNode{Value: "AND"},
+					&ast.StringNode{Key: "name", Value: "Jane"},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				numericTermQuery("Type", 2),
+				query.NewQueryStringQuery(`Name:jane`),
+			}),
+			wantErr: false,
+		},
+		{
+			name: `type:File OR type:4`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "type", Value: "File"},
+					&ast.OperatorNode{Value: "OR"},
+					&ast.StringNode{Key: "type", Value: "4"},
+				},
+			},
+			want: query.NewDisjunctionQuery([]query.Query{
+				numericTermQuery("Type", 1),
+				numericTermQuery("Type", 4),
+			}),
+			wantErr: false,
+		},
 		{
 			name: `StringNode value lowercase`,
 			args: &ast.Ast{
@@ -294,8 +401,8 @@ func Test_compile(t *testing.T) {
 			},
 			want: query.NewConjunctionQuery([]query.Query{
 				query.NewQueryStringQuery(`Name:john\ smith`),
-				query.NewQueryStringQuery(`Hidden:T`),
-				query.NewQueryStringQuery(`Hidden:T`),
+				boolFieldQuery("Hidden", true),
+				boolFieldQuery("Hidden", true),
 			}),
 			wantErr: false,
 		},
@@ -531,7 +638,7 @@ func Test_compile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := compile(tt.args)
+			got, err := Compiler{}.compile(tt.args)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("compile() error = %v, wantErr %v", err, tt.wantErr)
@@ -542,6 +649,226 @@ func Test_compile(t *testing.T) {
 	}
 }
 
+func Test_compilePhonetic(t *testing.T) {
+	phoneticQuery := func(value string) query.Query {
+		q := query.NewMatchPhraseQuery(value)
+		q.SetField("Name.phonetic")
+		q.Analyzer = "phonetic"
+		return q
+	}
+
+	tests := []struct {
+		name     string
+		phonetic bool
+		args     *ast.Ast
+		want     query.Query
+	}{
+		{
+			name:     `name~phonetic:Jonson`,
+			phonetic: true,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "name", Value: "Jonson", Mode: kql.MatchPhonetic},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				phoneticQuery("Jonson"),
+			}),
+		},
+		{
+			name:     `name~phonetic:"Jon Smith" AND name:report`,
+			phonetic: true,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "name", Value: "Jon Smith", Mode: kql.MatchPhonetic},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.StringNode{Key: "name", Value: "report"},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				phoneticQuery("Jon Smith"),
+				query.NewQueryStringQuery(`Name:report`),
+			}),
+		},
+		{
+			name: `name~phonetic:Jonson without phonetic matching`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "name", Value: "Jonson", Mode: kql.MatchPhonetic},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				query.NewQueryStringQuery(`Name:jonson`),
+			}),
+		},
+	}
+
+	assert := tAssert.New(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compiler{Phonetic: tt.phonetic}.compile(tt.args)
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func Test_compileBoolean(t *testing.T) {
+	notTrue := func(field string) query.Query {
+		q := query.NewBooleanQuery(nil, nil, nil)
+		q.AddMustNot(boolFieldQuery(field, true))
+		return q
+	}
+
+	tests := []struct {
+		name    string
+		args    *ast.Ast
+		want    query.Query
+		wantErr bool
+	}{
+		{
+			name: `hidden:true`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.BooleanNode{Key: "hidden", Value: true},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				boolFieldQuery("Hidden", true),
+			}),
+		},
+		{
+			name: `haspreview:false AND truncated:F`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.BooleanNode{Key: "haspreview", Value: false},
+					&ast.OperatorNode{Value: kql.BoolAND},
+					&ast.StringNode{Key: "truncated", Value: "F"},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				notTrue("HasPreview"),
+				notTrue("Truncated"),
+			}),
+		},
+		{
+			name: `hidden:(true)`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.GroupNode{Key: "hidden", Nodes: []ast.Node{
+						&ast.StringNode{Value: "true"},
+					}},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				boolFieldQuery("Hidden", true),
+			}),
+		},
+		{
+			name: `name:true`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.BooleanNode{Key: "name", Value: true},
+				},
+			},
+			want: query.NewConjunctionQuery([]query.Query{
+				query.NewQueryStringQuery(`Name:true`),
+			}),
+		},
+		{
+			name: `hidden:yes`,
+			args: &ast.Ast{
+				Nodes: []ast.Node{
+					&ast.StringNode{Key: "hidden", Value: "yes"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	assert := tAssert.New(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compiler{}.compile(tt.args)
+			if tt.wantErr {
+				assert.True(searchQuery.IsValidationError(err))
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func Test_compileLocked(t *testing.T) {
+	assert := tAssert.New(t)
+
+	got, err := Compiler{}.compile(&ast.Ast{Nodes: []ast.Node{&ast.BooleanNode{Key: "locked", Value: true}}})
+	assert.NoError(err)
+
+	conjunction, ok := got.(*query.ConjunctionQuery)
+	assert.True(ok)
+	assert.Len(conjunction.Conjuncts, 1)
+	locked, ok := conjunction.Conjuncts[0].(*query.BooleanQuery)
+	assert.True(ok)
+	assert.Equal(query.NewConjunctionQuery([]query.Query{boolFieldQuery("Locked", true)}), locked.Must)
+
+	// expired locks are not matched as locked
+	expired, ok := locked.MustNot.(*query.DisjunctionQuery)
+	assert.True(ok)
+	assert.Len(expired.Disjuncts, 1)
+	expiration, ok := expired.Disjuncts[0].(*query.DateRangeQuery)
+	assert.True(ok)
+	assert.Equal("LockExpiration", expiration.Field())
+	assert.True(expiration.Start.IsZero())
+	assert.WithinDuration(time.Now(), expiration.End.Time, time.Minute)
+}
+
+func Test_defaultQueryFields(t *testing.T) {
+	assert := tAssert.New(t)
+
+	queryFields := defaults.DefaultConfig().QueryFields
+	for field := range searchQuery.Fields {
+		if slices.Contains(searchQuery.InternalFields, field) {
+			assert.NotContains(queryFields, field)
+			continue
+		}
+		assert.Contains(queryFields, field)
+		assert.NotEqual(field, getField(field), "the property %s is not compiled to a field of the index", field)
+	}
+	for field := range searchQuery.BooleanFields {
+		assert.Contains(queryFields, field)
+	}
+}
+
+func Test_compileRelativeModified(t *testing.T) {
+	assert := tAssert.New(t)
+
+	got, err := NewCreator(searchQuery.Options{}).Create("modified:last-7d")
+	assert.NoError(err)
+
+	conjunction, ok := got.(*query.ConjunctionQuery)
+	assert.True(ok)
+	assert.Len(conjunction.Conjuncts, 2)
+	from, ok := conjunction.Conjuncts[0].(*query.DateRangeQuery)
+	assert.True(ok)
+	assert.Equal("Mtime", from.Field())
+	assert.WithinDuration(time.Now().AddDate(0, 0, -7), from.Start.Time, time.Minute)
+	to, ok := conjunction.Conjuncts[1].(*query.DateRangeQuery)
+	assert.True(ok)
+	assert.Equal("Mtime", to.Field())
+	assert.WithinDuration(time.Now(), to.End.Time, time.Minute)
+}
+
+var boolFieldQuery = func(field string, value bool) query.Query {
+	q := query.NewBoolFieldQuery(value)
+	q.SetField(field)
+	return q
+}
+
 func Test_escape(t *testing.T) {
 	type args struct {
 		str string
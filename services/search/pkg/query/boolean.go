@@ -0,0 +1,64 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/opencloud-eu/opencloud/pkg/ast"
+)
+
+// BooleanFields maps the lowercase names of the boolean properties, which are queried with 'property:true'
+// or 'property:false', to the fields of the index.
+var BooleanFields = map[string]string{
+	"hidden":     "Hidden",
+	"haspreview": "HasPreview",
+	"truncated":  "Truncated",
+	"locked":     "Locked",
+}
+
+// NormalizeBooleans turns the terms of the boolean properties into boolean nodes on the fields of the index,
+// their values are parsed like strconv.ParseBool does. The boolean nodes of other properties are turned into
+// string nodes, so 'name:true' still matches the names. The terms of named groups inherit the property of the group.
+func NormalizeBooleans(nodes []ast.Node) ([]ast.Node, error) {
+	return normalizeBooleans(nodes, "")
+}
+
+func normalizeBooleans(nodes []ast.Node, groupKey string) ([]ast.Node, error) {
+	normalized := make([]ast.Node, 0, len(nodes))
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *ast.GroupNode:
+			groupNodes, err := normalizeBooleans(n.Nodes, n.Key)
+			if err != nil {
+				return nil, err
+			}
+			n.Nodes = groupNodes
+		case *ast.StringNode:
+			key := n.Key
+			if key == "" {
+				key = groupKey
+			}
+
+			field, ok := BooleanFields[strings.ToLower(key)]
+			if !ok {
+				break
+			}
+
+			value, err := strconv.ParseBool(n.Value)
+			if err != nil {
+				return nil, &InvalidBooleanValueError{Node: n, Key: key}
+			}
+			node = &ast.BooleanNode{Base: n.Base, Key: field, Value: value}
+		case *ast.BooleanNode:
+			if field, ok := BooleanFields[strings.ToLower(n.Key)]; ok {
+				n.Key = field
+				break
+			}
+			node = &ast.StringNode{Base: n.Base, Key: n.Key, Value: strconv.FormatBool(n.Value)}
+		}
+
+		normalized = append(normalized, node)
+	}
+
+	return normalized, nil
+}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
@@ -38,10 +39,27 @@ func (e UnsupportedTimeRangeError) Error() string {
 	return fmt.Sprintf("unable to convert '%v' to a time range", e.Value)
 }
 
+// InvalidBooleanValueError records an error and the node of the boolean property that caused it.
+type InvalidBooleanValueError struct {
+	Node *ast.StringNode
+	Key  string
+}
+
+func (e InvalidBooleanValueError) Error() string {
+	return fmt.Sprintf("'%s' is not a valid value of the boolean property '%s', use true or false", e.Node.Value, e.Key)
+}
+
+// IsValidationError reports whether the error, or an error it wraps, is caused by an invalid query.
 func IsValidationError(err error) bool {
-	switch err.(type) {
-	case *StartsWithBinaryOperatorError, *NamedGroupInvalidNodesError, *UnsupportedTimeRangeError:
-		return true
-	}
-	return false
+	var (
+		startsWithBinaryOperator *StartsWithBinaryOperatorError
+		namedGroupInvalidNodes   *NamedGroupInvalidNodesError
+		unsupportedTimeRange     *UnsupportedTimeRangeError
+		invalidBooleanValue      *InvalidBooleanValueError
+	)
+
+	return errors.As(err, &startsWithBinaryOperator) ||
+		errors.As(err, &namedGroupInvalidNodes) ||
+		errors.As(err, &unsupportedTimeRange) ||
+		errors.As(err, &invalidBooleanValue)
 }
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"maps"
+	"slices"
+)
+
+// Fields maps the lowercase names of the properties, which the terms of a query can be restricted to,
+// to the fields of the index. The boolean properties are listed in BooleanFields.
+var Fields = map[string]string{
+	"rootid":         "RootID",
+	"path":           "Path",
+	"id":             "ID",
+	"parentid":       "ParentID",
+	"name":           "Name",
+	"size":           "Size",
+	"mtime":          "Mtime",
+	"modified":       "Mtime",
+	"created":        "Ctime",
+	"mediatype":      "MimeType",
+	"type":           "Type",
+	"tag":            "Tags",
+	"tags":           "Tags",
+	"content":        "Content",
+	"hidden":         "Hidden",
+	"owner":          "Owner",
+	"creator":        "CreatedBy",
+	"sharedwith":     "SharedWith",
+	"ext":            "Extension",
+	"metadata":       "Metadata",
+	"versions":       "Versions.Content",
+	"attribute":      "Attributes",
+	"classification": "Classification",
+}
+
+// InternalFields are the properties the search service restricts the queries to itself, users may not query them.
+var InternalFields = []string{"rootid"}
+
+// SearchableFields returns the sorted names of the properties users may restrict the terms of a query to,
+// these are all properties of Fields and BooleanFields except the InternalFields.
+func SearchableFields() []string {
+	fields := slices.Collect(maps.Keys(Fields))
+	for field := range BooleanFields {
+		if _, ok := Fields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	fields = slices.DeleteFunc(fields, func(field string) bool {
+		return slices.Contains(InternalFields, field)
+	})
+	slices.Sort(fields)
+	return fields
+}
@@ -17,3 +17,19 @@ type Compiler[T any] interface {
 type Creator[T any] interface {
 	Create(qs string) (T, error)
 }
+
+// Options configures how the engines turn the query strings into their queries.
+type Options struct {
+	// Boosts weights the matches of a field in the score, the fields are not boosted if not set.
+	Boosts map[string]float64
+	// DefaultOperator connects the free-text terms without an explicit operator, "AND" if not set.
+	DefaultOperator string
+	// MinimumShouldMatch sets how many of the free-text terms without an explicit operator have to match,
+	// like "75%" or "-1", the default operator applies if not set.
+	MinimumShouldMatch string
+	// Transliterate also matches the name terms against the latin transliteration of cyrillic and greek names.
+	Transliterate bool
+	// Phonetic matches the name~phonetic terms against the names which sound alike,
+	// they match like plain name terms if not set.
+	Phonetic bool
+}
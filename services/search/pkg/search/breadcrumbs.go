@@ -0,0 +1,112 @@
+package search
+
+import (
+	"path"
+	"strings"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+)
+
+// addBreadcrumbs adds the folders on the path of the matches below the root of their space or share, down to their parents.
+// The names are taken from the paths of the matches, which are relative to the share root for the matches within shares,
+// so no folders above a share are revealed. The ids are resolved from the indexed parent chain, one lookup per level
+// for all matches. A folder which is not indexed ends the chain, the breadcrumbs above it have no id.
+func (s *Service) addBreadcrumbs(matches []*searchmsg.Match) {
+	// the ids of the folders of every match, starting with the parent and going up
+	chains := make([][]string, len(matches))
+	names := make([][]string, len(matches))
+	for i, match := range matches {
+		names[i] = breadcrumbNames(match.GetEntity().GetRef().GetPath())
+		if len(names[i]) == 0 {
+			continue
+		}
+
+		parentID := match.GetEntity().GetParentId()
+		if parentID.GetOpaqueId() != "" {
+			chains[i] = []string{storagespace.FormatResourceID(&provider.ResourceId{
+				StorageId: parentID.GetStorageId(),
+				SpaceId:   parentID.GetSpaceId(),
+				OpaqueId:  parentID.GetOpaqueId(),
+			})}
+		}
+	}
+
+	// the folders are looked up level by level, the matches share most of their ancestors
+	folders := make(map[string]*Resource)
+	for level := 0; ; level++ {
+		var ids []string
+		for i, chain := range chains {
+			if len(chain) == level+1 && len(chain) <= len(names[i]) {
+				if _, ok := folders[chain[level]]; !ok {
+					ids = append(ids, chain[level])
+				}
+			}
+		}
+		if len(ids) > 0 {
+			resources, err := s.engine.GetDocuments(ids)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to look up the folders of the breadcrumbs")
+				break
+			}
+			for _, r := range resources {
+				folders[r.ID] = r
+			}
+		}
+
+		next := false
+		for i, chain := range chains {
+			if len(chain) != level+1 || len(chain) >= len(names[i]) {
+				continue
+			}
+			if folder, ok := folders[chain[level]]; ok && folder.ParentID != "" {
+				chains[i] = append(chain, folder.ParentID)
+				next = true
+			}
+		}
+		if !next {
+			break
+		}
+	}
+
+	for i, match := range matches {
+		if len(names[i]) == 0 {
+			continue
+		}
+
+		breadcrumbs := make([]*searchmsg.Breadcrumb, len(names[i]))
+		for j, name := range names[i] {
+			breadcrumbs[j] = &searchmsg.Breadcrumb{Name: name}
+
+			// the chain starts with the parent, which is the last breadcrumb
+			level := len(names[i]) - 1 - j
+			if level >= len(chains[i]) {
+				continue
+			}
+			if _, ok := folders[chains[i][level]]; !ok {
+				continue
+			}
+			if rID, err := storagespace.ParseID(chains[i][level]); err == nil {
+				breadcrumbs[j].Id = &searchmsg.ResourceID{
+					StorageId: rID.GetStorageId(),
+					SpaceId:   rID.GetSpaceId(),
+					OpaqueId:  rID.GetOpaqueId(),
+				}
+			}
+		}
+		match.Breadcrumbs = breadcrumbs
+	}
+}
+
+// breadcrumbNames returns the names of the folders of the relative path, without the name of the resource itself
+func breadcrumbNames(p string) []string {
+	p = strings.TrimPrefix(path.Join("/", p), "/")
+	if p == "" {
+		return nil
+	}
+
+	names := strings.Split(p, "/")
+	return names[:len(names)-1]
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// CompactionMonitor periodically samples the ratio of deleted to live documents of the index and compacts
+// the index once the ratio exceeds the threshold, which is a better signal of wasted space than a fixed schedule.
+type CompactionMonitor struct {
+	compactor Compactor
+	interval  time.Duration
+	threshold float64
+	logger    log.Logger
+}
+
+// NewCompactionMonitor creates a CompactionMonitor for the engine. Engines which can not be compacted,
+// a zero interval or a zero threshold disable the compaction.
+func NewCompactionMonitor(engine Engine, interval time.Duration, threshold float64, logger log.Logger) *CompactionMonitor {
+	m := &CompactionMonitor{
+		interval:  interval,
+		threshold: threshold,
+		logger:    logger,
+	}
+
+	if compactor, ok := engine.(Compactor); ok && interval > 0 && threshold > 0 {
+		m.compactor = compactor
+	}
+
+	return m
+}
+
+// Run samples the index in the configured interval and compacts it if needed until the context is done.
+func (m *CompactionMonitor) Run(ctx context.Context) {
+	if m.compactor == nil {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := m.CompactIfNeeded(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error().Err(err).Msg("failed to compact the search index")
+		}
+	}
+}
+
+// CompactIfNeeded compacts the index if the ratio of deleted to live documents exceeds the threshold
+// and reports whether it did.
+func (m *CompactionMonitor) CompactIfNeeded(ctx context.Context) (bool, error) {
+	if m.compactor == nil {
+		return false, nil
+	}
+
+	before, err := m.compactor.IndexStats(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ratio := deletedRatio(before)
+	if ratio <= m.threshold {
+		m.logger.Debug().Uint64("live", before.LiveDocs).Uint64("deleted", before.DeletedDocs).Float64("ratio", ratio).Msg("search index does not need to be compacted")
+		return false, nil
+	}
+
+	m.logger.Info().Uint64("live", before.LiveDocs).Uint64("deleted", before.DeletedDocs).Float64("ratio", ratio).Msg("compacting the search index")
+	start := time.Now()
+	if err := m.compactor.Compact(ctx); err != nil {
+		return false, err
+	}
+
+	after, err := m.compactor.IndexStats(ctx)
+	if err != nil {
+		return true, err
+	}
+
+	var reclaimed uint64
+	if after.SizeBytes < before.SizeBytes {
+		reclaimed = before.SizeBytes - after.SizeBytes
+	}
+	m.logger.Info().
+		Uint64("deleted", after.DeletedDocs).
+		Uint64("size_bytes", after.SizeBytes).
+		Uint64("reclaimed_bytes", reclaimed).
+		Dur("duration", time.Since(start)).
+		Msg("compacted the search index")
+
+	return true, nil
+}
+
+// deletedRatio returns the ratio of deleted to live documents, an index of deleted documents only has an infinite ratio
+func deletedRatio(stats IndexStats) float64 {
+	switch {
+	case stats.DeletedDocs == 0:
+		return 0
+	case stats.LiveDocs == 0:
+		return math.Inf(1)
+	default:
+		return float64(stats.DeletedDocs) / float64(stats.LiveDocs)
+	}
+}
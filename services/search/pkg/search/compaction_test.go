@@ -0,0 +1,101 @@
+package search_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+)
+
+// compactingEngine is an engine whose deleted documents are dropped by the compaction
+type compactingEngine struct {
+	*engineMocks.Engine
+
+	mu          sync.Mutex
+	stats       search.IndexStats
+	compactions atomic.Int32
+}
+
+func (e *compactingEngine) IndexStats(_ context.Context) (search.IndexStats, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.stats, nil
+}
+
+func (e *compactingEngine) Compact(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stats.SizeBytes -= e.stats.SizeBytes * e.stats.DeletedDocs / (e.stats.LiveDocs + e.stats.DeletedDocs)
+	e.stats.DeletedDocs = 0
+	e.compactions.Add(1)
+	return nil
+}
+
+func (e *compactingEngine) setStats(stats search.IndexStats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stats = stats
+}
+
+var _ = Describe("CompactionMonitor", func() {
+	var (
+		eng *compactingEngine
+	)
+
+	BeforeEach(func() {
+		eng = &compactingEngine{Engine: &engineMocks.Engine{}}
+	})
+
+	DescribeTable("compacts the index once the ratio of deleted to live documents exceeds the threshold",
+		func(stats search.IndexStats, compactions int32) {
+			eng.setStats(stats)
+			monitor := search.NewCompactionMonitor(eng, time.Hour, 0.3, log.NopLogger())
+
+			ok, err := monitor.CompactIfNeeded(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(Equal(compactions > 0))
+			Expect(eng.compactions.Load()).To(Equal(compactions))
+		},
+		Entry("no deleted documents", search.IndexStats{LiveDocs: 100, SizeBytes: 1000}, int32(0)),
+		Entry("below the threshold", search.IndexStats{LiveDocs: 100, DeletedDocs: 20, SizeBytes: 1200}, int32(0)),
+		Entry("at the threshold", search.IndexStats{LiveDocs: 100, DeletedDocs: 30, SizeBytes: 1300}, int32(0)),
+		Entry("above the threshold", search.IndexStats{LiveDocs: 100, DeletedDocs: 31, SizeBytes: 1310}, int32(1)),
+		Entry("deleted documents only", search.IndexStats{DeletedDocs: 5, SizeBytes: 50}, int32(1)),
+		Entry("empty index", search.IndexStats{}, int32(0)),
+	)
+
+	It("does not compact engines without a threshold", func() {
+		eng.setStats(search.IndexStats{LiveDocs: 1, DeletedDocs: 100, SizeBytes: 1010})
+		monitor := search.NewCompactionMonitor(eng, time.Hour, 0, log.NopLogger())
+
+		ok, err := monitor.CompactIfNeeded(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+		Expect(eng.compactions.Load()).To(BeZero())
+	})
+
+	It("samples the index in the interval and compacts it whenever the threshold is crossed", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		eng.setStats(search.IndexStats{LiveDocs: 100, DeletedDocs: 10, SizeBytes: 1100})
+		monitor := search.NewCompactionMonitor(eng, 10*time.Millisecond, 0.3, log.NopLogger())
+		go monitor.Run(ctx)
+
+		Consistently(eng.compactions.Load, 100*time.Millisecond).Should(BeZero())
+
+		eng.setStats(search.IndexStats{LiveDocs: 100, DeletedDocs: 50, SizeBytes: 1500})
+		Eventually(eng.compactions.Load).Should(Equal(int32(1)))
+		Consistently(eng.compactions.Load, 100*time.Millisecond).Should(Equal(int32(1)))
+	})
+})
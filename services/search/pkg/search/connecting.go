@@ -0,0 +1,285 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+)
+
+// errNotConnected is returned by a ConnectingEngine until it is connected to its backend
+var errNotConnected = fmt.Errorf("%w: not connected yet", ErrBackendUnavailable)
+
+// ConnectingEngine is an engine whose backend could not be reached when the service started, it keeps
+// connecting to it in the background. Until it is connected, all operations fail with ErrBackendUnavailable,
+// so a FallbackEngine serves the reads from its fallback engine meanwhile.
+type ConnectingEngine struct {
+	connect     func() (Engine, error)
+	interval    time.Duration
+	onConnected func()
+	logger      log.Logger
+
+	mu     sync.RWMutex
+	engine Engine
+}
+
+// NewConnectingEngine creates a ConnectingEngine, connect is called in the given interval until it succeeds.
+// onConnected is called once it is connected, the backend misses the changes made until then, so it has to catch up,
+// e.g. by indexing all spaces.
+func NewConnectingEngine(connect func() (Engine, error), interval time.Duration, onConnected func(), logger log.Logger) *ConnectingEngine {
+	return &ConnectingEngine{
+		connect:     connect,
+		interval:    interval,
+		onConnected: onConnected,
+		logger:      logger,
+	}
+}
+
+// Run connects to the backend right away and then in the configured interval until it succeeds or the context is done.
+func (e *ConnectingEngine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		engine, err := e.connect()
+		if err == nil {
+			e.mu.Lock()
+			e.engine = engine
+			e.mu.Unlock()
+
+			e.logger.Info().Msg("connected to the search engine")
+			if e.onConnected != nil {
+				e.onConnected()
+			}
+			return
+		}
+		e.logger.Warn().Err(err).Dur("interval", e.interval).Msg("could not connect to the search engine, retrying")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Connected reports whether the backend has been connected.
+func (e *ConnectingEngine) Connected() bool {
+	_, err := e.current()
+	return err == nil
+}
+
+// current returns the connected engine or errNotConnected
+func (e *ConnectingEngine) current() (Engine, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.engine == nil {
+		return nil, errNotConnected
+	}
+	return e.engine, nil
+}
+
+func (e *ConnectingEngine) Search(ctx context.Context, req *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
+	eng, err := e.current()
+	if err != nil {
+		return nil, err
+	}
+	return eng.Search(ctx, req)
+}
+
+func (e *ConnectingEngine) DocCount() (uint64, error) {
+	eng, err := e.current()
+	if err != nil {
+		return 0, err
+	}
+	return eng.DocCount()
+}
+
+func (e *ConnectingEngine) GetDocument(id string) (*Resource, error) {
+	eng, err := e.current()
+	if err != nil {
+		return nil, err
+	}
+	return eng.GetDocument(id)
+}
+
+func (e *ConnectingEngine) GetDocuments(ids []string) ([]*Resource, error) {
+	eng, err := e.current()
+	if err != nil {
+		return nil, err
+	}
+	return eng.GetDocuments(ids)
+}
+
+func (e *ConnectingEngine) Upsert(id string, r Resource) error {
+	return e.apply(func(eng Engine) error { return eng.Upsert(id, r) })
+}
+
+func (e *ConnectingEngine) UpsertMany(items map[string]Resource) error {
+	return e.apply(func(eng Engine) error { return eng.UpsertMany(items) })
+}
+
+func (e *ConnectingEngine) Move(id string, parentid string, target string) error {
+	return e.apply(func(eng Engine) error { return eng.Move(id, parentid, target) })
+}
+
+func (e *ConnectingEngine) Delete(id string) error {
+	return e.apply(func(eng Engine) error { return eng.Delete(id) })
+}
+
+func (e *ConnectingEngine) Restore(id string) error {
+	return e.apply(func(eng Engine) error { return eng.Restore(id) })
+}
+
+func (e *ConnectingEngine) RestoreMany(ids []string) error {
+	return e.apply(func(eng Engine) error { return eng.RestoreMany(ids) })
+}
+
+func (e *ConnectingEngine) Purge(id string, onlyDeleted bool) error {
+	return e.apply(func(eng Engine) error { return eng.Purge(id, onlyDeleted) })
+}
+
+func (e *ConnectingEngine) PurgeMany(ids []string, onlyDeleted bool) error {
+	return e.apply(func(eng Engine) error { return eng.PurgeMany(ids, onlyDeleted) })
+}
+
+func (e *ConnectingEngine) RenameTag(oldTag, newTag string, rootIDs []string) error {
+	return e.apply(func(eng Engine) error { return eng.RenameTag(oldTag, newTag, rootIDs) })
+}
+
+func (e *ConnectingEngine) Suggest(ctx context.Context, term string, refs []*searchmsg.Reference) ([]string, error) {
+	eng, err := e.current()
+	if err != nil {
+		return nil, err
+	}
+	return eng.Suggest(ctx, term, refs)
+}
+
+// NewBatch returns a batch of the connected engine, until it is connected the operations of the batch fail,
+// so the batch of a FallbackEngine still updates its fallback engine.
+func (e *ConnectingEngine) NewBatch(batchSize int) (BatchOperator, error) {
+	eng, err := e.current()
+	if err != nil {
+		return notConnectedBatch{}, nil
+	}
+	return eng.NewBatch(batchSize)
+}
+
+// SumField sums the field with the connected engine if it can aggregate fields.
+func (e *ConnectingEngine) SumField(ctx context.Context, req *searchService.SearchIndexRequest, field string) (float64, error) {
+	eng, err := e.current()
+	if err != nil {
+		return 0, err
+	}
+	aggregator, ok := eng.(Aggregator)
+	if !ok {
+		return 0, errtypes.NotSupported("the search engine can not aggregate fields")
+	}
+	return aggregator.SumField(ctx, req, field)
+}
+
+// StartBulkIndexing starts the bulk indexing of the connected engine if it supports it.
+func (e *ConnectingEngine) StartBulkIndexing() (func() error, error) {
+	eng, err := e.current()
+	if err != nil {
+		return nil, err
+	}
+	bulkIndexer, ok := eng.(BulkIndexer)
+	if !ok {
+		return func() error { return nil }, nil
+	}
+	return bulkIndexer.StartBulkIndexing()
+}
+
+// Rebuild rebuilds the index of the connected engine if it can rebuild its index.
+func (e *ConnectingEngine) Rebuild(build func(Engine) error) error {
+	eng, err := e.current()
+	if err != nil {
+		return err
+	}
+	rebuilder, ok := eng.(Rebuilder)
+	if !ok {
+		return errtypes.NotSupported("the search engine can not rebuild its index")
+	}
+	return rebuilder.Rebuild(build)
+}
+
+// ResetIndex resets the index of the connected engine if it can reset its index.
+func (e *ConnectingEngine) ResetIndex() (uint64, error) {
+	eng, err := e.current()
+	if err != nil {
+		return 0, err
+	}
+	resetter, ok := eng.(Resetter)
+	if !ok {
+		return 0, errtypes.NotSupported("the search engine can not reset its index")
+	}
+	return resetter.ResetIndex()
+}
+
+// IndexStats returns the statistics of the index of the connected engine if it can be compacted.
+func (e *ConnectingEngine) IndexStats(ctx context.Context) (IndexStats, error) {
+	eng, err := e.current()
+	if err != nil {
+		return IndexStats{}, err
+	}
+	compactor, ok := eng.(Compactor)
+	if !ok {
+		return IndexStats{}, errtypes.NotSupported("the search engine can not be compacted")
+	}
+	return compactor.IndexStats(ctx)
+}
+
+// Compact compacts the index of the connected engine if it can be compacted.
+func (e *ConnectingEngine) Compact(ctx context.Context) error {
+	eng, err := e.current()
+	if err != nil {
+		return err
+	}
+	compactor, ok := eng.(Compactor)
+	if !ok {
+		return errtypes.NotSupported("the search engine can not be compacted")
+	}
+	return compactor.Compact(ctx)
+}
+
+// CheckHealth reports the health of the connected engine, engines which can not report their health are healthy.
+func (e *ConnectingEngine) CheckHealth(ctx context.Context) error {
+	eng, err := e.current()
+	if err != nil {
+		return err
+	}
+	checker, ok := eng.(HealthChecker)
+	if !ok {
+		return nil
+	}
+	return checker.CheckHealth(ctx)
+}
+
+// apply applies the write to the connected engine
+func (e *ConnectingEngine) apply(write func(Engine) error) error {
+	eng, err := e.current()
+	if err != nil {
+		return err
+	}
+	return write(eng)
+}
+
+// notConnectedBatch is the batch of a ConnectingEngine which is not connected yet
+type notConnectedBatch struct{}
+
+func (notConnectedBatch) Upsert(string, Resource) error     { return errNotConnected }
+func (notConnectedBatch) Move(string, string, string) error { return errNotConnected }
+func (notConnectedBatch) Delete(string) error               { return errNotConnected }
+func (notConnectedBatch) Restore(string) error              { return errNotConnected }
+func (notConnectedBatch) RestoreMany([]string) error        { return errNotConnected }
+func (notConnectedBatch) Purge(string, bool) error          { return errNotConnected }
+func (notConnectedBatch) PurgeMany([]string, bool) error    { return errNotConnected }
+func (notConnectedBatch) Push() error                       { return errNotConnected }
@@ -0,0 +1,78 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+)
+
+var _ = Describe("ConnectingEngine", func() {
+	var (
+		backend  *engineMocks.Engine
+		attempts atomic.Int32
+		eng      *search.ConnectingEngine
+	)
+
+	BeforeEach(func() {
+		backend = &engineMocks.Engine{}
+		attempts.Store(0)
+		eng = search.NewConnectingEngine(func() (search.Engine, error) {
+			if attempts.Add(1) < 3 {
+				return nil, errors.New("cluster is not healthy")
+			}
+			return backend, nil
+		}, 10*time.Millisecond, nil, log.NopLogger())
+	})
+
+	It("fails as unavailable until it is connected", func() {
+		_, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "foo"})
+		Expect(err).To(MatchError(search.ErrBackendUnavailable))
+		Expect(eng.Upsert("id", search.Resource{})).To(MatchError(search.ErrBackendUnavailable))
+
+		batch, err := eng.NewBatch(10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batch.Upsert("id", search.Resource{})).To(MatchError(search.ErrBackendUnavailable))
+	})
+
+	It("keeps connecting and uses the engine once it is connected", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		go eng.Run(ctx)
+
+		Eventually(eng.Connected).Should(BeTrue())
+		Expect(attempts.Load()).To(Equal(int32(3)))
+
+		res := &searchsvc.SearchIndexResponse{TotalMatches: 1}
+		backend.On("Search", mock.Anything, mock.Anything).Return(res, nil)
+		Expect(eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "foo"})).To(Equal(res))
+	})
+
+	It("stops connecting once the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		eng.Run(ctx)
+
+		Expect(eng.Connected()).To(BeFalse())
+		Expect(attempts.Load()).To(Equal(int32(1)))
+	})
+
+	It("lets the fallback engine serve the searches until it is connected", func() {
+		fallback := &engineMocks.Engine{}
+		res := &searchsvc.SearchIndexResponse{TotalMatches: 1}
+		fallback.On("Search", mock.Anything, mock.Anything).Return(res, nil)
+
+		Expect(search.NewFallbackEngine(eng, fallback, nil, log.NopLogger()).
+			Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "foo"})).To(Equal(res))
+	})
+})
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query"
+)
+
+// ExplainQuery parses the KQL query like the engines do before compiling it and returns the parsed query tree,
+// one node per line with the nodes of a group indented below it. Free-text terms without an explicit operator
+// are connected with the given default operator or minimum should match. The errors of invalid queries
+// are returned as *kql.SyntaxError with the position of the invalid part of the query.
+func ExplainQuery(qs, defaultOperator, minimumShouldMatch string) (string, error) {
+	q, err := kql.Builder{DefaultOperator: defaultOperator, MinimumShouldMatch: minimumShouldMatch}.Build(qs)
+	if err != nil {
+		return "", atErrorNode(err)
+	}
+
+	nodes, err := query.NormalizeBooleans(q.Nodes)
+	if err != nil {
+		return "", atErrorNode(err)
+	}
+
+	var tree strings.Builder
+	writeQueryTree(&tree, nodes, 0)
+
+	return tree.String(), nil
+}
+
+// atErrorNode positions the error at the node which caused it, the parser only knows the start of the rule it applied
+func atErrorNode(err error) error {
+	var (
+		node                     ast.Node
+		startsWithBinaryOperator *query.StartsWithBinaryOperatorError
+		namedGroupInvalidNodes   *query.NamedGroupInvalidNodesError
+		invalidBooleanValue      *query.InvalidBooleanValueError
+	)
+	switch {
+	case errors.As(err, &startsWithBinaryOperator):
+		node = startsWithBinaryOperator.Node
+	case errors.As(err, &namedGroupInvalidNodes):
+		node = namedGroupInvalidNodes.Node
+	case errors.As(err, &invalidBooleanValue):
+		node = invalidBooleanValue.Node
+	default:
+		return err
+	}
+
+	if node.Location() == nil {
+		return err
+	}
+
+	var syntaxErr *kql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		err = syntaxErr.Err
+	}
+
+	return &kql.SyntaxError{Err: err, Position: node.Location().Start}
+}
+
+func writeQueryTree(tree *strings.Builder, nodes []ast.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *ast.StringNode:
+			fmt.Fprintf(tree, "%sstring %s%q\n", indent, queryProperty(n.Key, n.Mode), n.Value)
+		case *ast.BooleanNode:
+			fmt.Fprintf(tree, "%sboolean %s%t\n", indent, queryProperty(n.Key, ""), n.Value)
+		case *ast.DateTimeNode:
+			operator := ":"
+			if n.Operator != nil {
+				operator = n.Operator.Value
+			}
+			fmt.Fprintf(tree, "%sdatetime %s%s%s\n", indent, n.Key, operator, n.Value.Format(time.RFC3339))
+		case *ast.OperatorNode:
+			fmt.Fprintf(tree, "%soperator %s\n", indent, n.Value)
+		case *ast.GroupNode:
+			tree.WriteString(indent + "group")
+			if n.Key != "" {
+				tree.WriteString(" " + n.Key)
+			}
+			if n.MinimumMatch > 0 {
+				fmt.Fprintf(tree, " minimum match %d", n.MinimumMatch)
+			}
+			tree.WriteString("\n")
+			writeQueryTree(tree, n.Nodes, depth+1)
+		}
+	}
+}
+
+// queryProperty returns the property restriction of a term as written in the query, like 'name:' or 'name~phonetic:'
+func queryProperty(key, mode string) string {
+	switch {
+	case key == "":
+		return ""
+	case mode != "":
+		return key + "~" + mode + ":"
+	default:
+		return key + ":"
+	}
+}
@@ -0,0 +1,44 @@
+package search_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+var _ = DescribeTable("ExplainQuery",
+	func(qs, minimumShouldMatch, tree string) {
+		got, err := search.ExplainQuery(qs, "", minimumShouldMatch)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(tree))
+	},
+	Entry("free text", "report summary", "",
+		"string \"report\"\noperator AND\nstring \"summary\"\n"),
+	Entry("properties and groups", `name:"annual report" OR mediatype:(pdf OR docx)`, "",
+		"string name:\"annual report\"\noperator OR\ngroup mediatype\n  string \"pdf\"\n  operator OR\n  string \"docx\"\n"),
+	Entry("boolean properties", "hidden:true locked:0", "",
+		"boolean Hidden:true\noperator AND\nboolean Locked:false\n"),
+	Entry("phonetic match", "name~phonetic:jonson", "",
+		"string name~phonetic:\"jonson\"\n"),
+	Entry("date ranges", "mtime>=2024-01-01", "",
+		"datetime mtime>=2024-01-01T00:00:00Z\n"),
+	Entry("minimum should match", "annual report summary", "2",
+		"group minimum match 2\n  string \"annual\"\n  operator OR\n  string \"report\"\n  operator OR\n  string \"summary\"\n"),
+)
+
+var _ = DescribeTable("ExplainQuery with invalid queries",
+	func(qs string, position ast.Position, message string) {
+		_, err := search.ExplainQuery(qs, "", "")
+		var syntaxErr *kql.SyntaxError
+		Expect(err).To(BeAssignableToTypeOf(syntaxErr))
+		Expect(err.(*kql.SyntaxError).Position).To(Equal(position))
+		Expect(err).To(MatchError(ContainSubstring(message)))
+	},
+	Entry("empty query", "", ast.Position{Line: 1, Column: 1}, "no match found"),
+	Entry("leading binary operator", "AND report", ast.Position{Line: 1, Column: 1}, "can't begin from a binary operator"),
+	Entry("property within a named group", "tag:(name:report)", ast.Position{Line: 1, Column: 6}, "is not valid"),
+	Entry("invalid boolean value", "report hidden:maybe", ast.Position{Line: 1, Column: 8}, "is not a valid value of the boolean property 'hidden'"),
+)
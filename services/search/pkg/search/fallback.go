@@ -0,0 +1,344 @@
+package search
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+)
+
+// FallbackEngine reads from the primary engine and serves the reads from the fallback engine
+// if the primary engine is unavailable, for example while the OpenSearch cluster is down.
+// Writes go to both engines, so the fallback is kept current.
+type FallbackEngine struct {
+	primary  Engine
+	fallback Engine
+	metrics  *metrics.Metrics
+	logger   log.Logger
+}
+
+// NewFallbackEngine creates a FallbackEngine, the metrics are optional.
+func NewFallbackEngine(primary, fallback Engine, m *metrics.Metrics, logger log.Logger) *FallbackEngine {
+	return &FallbackEngine{
+		primary:  primary,
+		fallback: fallback,
+		metrics:  m,
+		logger:   logger,
+	}
+}
+
+// Search searches the primary engine and the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) Search(ctx context.Context, req *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
+	res, err := e.primary.Search(ctx, req)
+	if !isUnavailable(err) || ctx.Err() != nil {
+		return res, err
+	}
+
+	e.degraded("search", err)
+	return e.fallback.Search(ctx, req)
+}
+
+// SumField sums the field with the primary engine and with the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) SumField(ctx context.Context, req *searchService.SearchIndexRequest, field string) (float64, error) {
+	primary, ok := e.primary.(Aggregator)
+	if !ok {
+		return 0, errtypes.NotSupported("the search engine can not aggregate fields")
+	}
+
+	sum, err := primary.SumField(ctx, req, field)
+	if !isUnavailable(err) || ctx.Err() != nil {
+		return sum, err
+	}
+
+	fallback, ok := e.fallback.(Aggregator)
+	if !ok {
+		return 0, err
+	}
+
+	e.degraded("sum_field", err)
+	return fallback.SumField(ctx, req, field)
+}
+
+// DocCount returns the number of documents of the primary engine or of the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) DocCount() (uint64, error) {
+	count, err := e.primary.DocCount()
+	if !isUnavailable(err) {
+		return count, err
+	}
+
+	e.degraded("doc_count", err)
+	return e.fallback.DocCount()
+}
+
+// GetDocument returns the document from the primary engine or from the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) GetDocument(id string) (*Resource, error) {
+	r, err := e.primary.GetDocument(id)
+	if !isUnavailable(err) {
+		return r, err
+	}
+
+	e.degraded("get_document", err)
+	return e.fallback.GetDocument(id)
+}
+
+// GetDocuments returns the documents from the primary engine or from the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) GetDocuments(ids []string) ([]*Resource, error) {
+	resources, err := e.primary.GetDocuments(ids)
+	if !isUnavailable(err) {
+		return resources, err
+	}
+
+	e.degraded("get_documents", err)
+	return e.fallback.GetDocuments(ids)
+}
+
+// Suggest returns the suggestions of the primary engine or of the fallback engine if the primary one is unavailable.
+func (e *FallbackEngine) Suggest(ctx context.Context, term string, refs []*searchMessage.Reference) ([]string, error) {
+	suggestions, err := e.primary.Suggest(ctx, term, refs)
+	if !isUnavailable(err) {
+		return suggestions, err
+	}
+
+	e.degraded("suggest", err)
+	return e.fallback.Suggest(ctx, term, refs)
+}
+
+// Upsert indexes the resource in both engines. The sequence of the resource belongs to the primary engine
+// it was read from, the fallback engine overwrites its document unconditionally.
+func (e *FallbackEngine) Upsert(id string, r Resource) error {
+	return e.writeEach("upsert",
+		func() error { return e.fallback.Upsert(id, r.WithoutSequence()) },
+		func() error { return e.primary.Upsert(id, r) },
+	)
+}
+
+// UpsertMany indexes the resources in both engines, the fallback engine overwrites its documents unconditionally.
+func (e *FallbackEngine) UpsertMany(items map[string]Resource) error {
+	return e.writeEach("upsert",
+		func() error { return e.fallback.UpsertMany(withoutSequences(items)) },
+		func() error { return e.primary.UpsertMany(items) },
+	)
+}
+
+// Move moves the resource in both engines.
+func (e *FallbackEngine) Move(id string, parentid string, target string) error {
+	return e.write("move", func(eng Engine) error { return eng.Move(id, parentid, target) })
+}
+
+// Delete marks the resource as deleted in both engines.
+func (e *FallbackEngine) Delete(id string) error {
+	return e.write("delete", func(eng Engine) error { return eng.Delete(id) })
+}
+
+// Restore restores the resource in both engines.
+func (e *FallbackEngine) Restore(id string) error {
+	return e.write("restore", func(eng Engine) error { return eng.Restore(id) })
+}
+
+// RestoreMany restores the resources in both engines.
+func (e *FallbackEngine) RestoreMany(ids []string) error {
+	return e.write("restore", func(eng Engine) error { return eng.RestoreMany(ids) })
+}
+
+// Purge removes the resource from both engines.
+func (e *FallbackEngine) Purge(id string, onlyDeleted bool) error {
+	return e.write("purge", func(eng Engine) error { return eng.Purge(id, onlyDeleted) })
+}
+
+// PurgeMany removes the resources from both engines.
+func (e *FallbackEngine) PurgeMany(ids []string, onlyDeleted bool) error {
+	return e.write("purge", func(eng Engine) error { return eng.PurgeMany(ids, onlyDeleted) })
+}
+
+// RenameTag renames the tag in both engines.
+func (e *FallbackEngine) RenameTag(oldTag, newTag string, rootIDs []string) error {
+	return e.write("rename_tag", func(eng Engine) error { return eng.RenameTag(oldTag, newTag, rootIDs) })
+}
+
+// NewBatch returns a batch which applies its operations to both engines.
+func (e *FallbackEngine) NewBatch(batchSize int) (BatchOperator, error) {
+	primary, err := e.primary.NewBatch(batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback, err := e.fallback.NewBatch(batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fallbackBatch{primary: primary, fallback: fallback, logger: e.logger}, nil
+}
+
+// StartBulkIndexing starts the bulk indexing of the engines which support it.
+func (e *FallbackEngine) StartBulkIndexing() (func() error, error) {
+	var ends []func() error
+	for _, eng := range []Engine{e.primary, e.fallback} {
+		bulkIndexer, ok := eng.(BulkIndexer)
+		if !ok {
+			continue
+		}
+
+		end, err := bulkIndexer.StartBulkIndexing()
+		if err != nil {
+			for _, end := range ends {
+				_ = end()
+			}
+			return nil, err
+		}
+		ends = append(ends, end)
+	}
+
+	return func() error {
+		var errs []error
+		for _, end := range ends {
+			errs = append(errs, end())
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// Rebuild rebuilds the index of the primary engine, the fallback engine is brought up to date with the next re-index.
+func (e *FallbackEngine) Rebuild(build func(Engine) error) error {
+	rebuilder, ok := e.primary.(Rebuilder)
+	if !ok {
+		return errtypes.NotSupported("the search engine can not rebuild its index")
+	}
+
+	return rebuilder.Rebuild(build)
+}
+
+// IndexStats returns the statistics of the index of the primary engine.
+func (e *FallbackEngine) IndexStats(ctx context.Context) (IndexStats, error) {
+	primary, ok := e.primary.(Compactor)
+	if !ok {
+		return IndexStats{}, errtypes.NotSupported("the search engine can not be compacted")
+	}
+
+	return primary.IndexStats(ctx)
+}
+
+// Compact compacts the index of the primary engine, the bleve index of the fallback engine merges its segments on its own.
+func (e *FallbackEngine) Compact(ctx context.Context) error {
+	primary, ok := e.primary.(Compactor)
+	if !ok {
+		return errtypes.NotSupported("the search engine can not be compacted")
+	}
+
+	return primary.Compact(ctx)
+}
+
+// ResetIndex resets the index of both engines, the number of documents of the primary engine is returned.
+// Failures of the fallback engine are only logged.
+func (e *FallbackEngine) ResetIndex() (uint64, error) {
+	primary, ok := e.primary.(Resetter)
+	if !ok {
+		return 0, errtypes.NotSupported("the search engine can not reset its index")
+	}
+
+	if fallback, ok := e.fallback.(Resetter); ok {
+		if _, err := fallback.ResetIndex(); err != nil {
+			e.logger.Error().Err(err).Str("operation", "reset").Msg("failed to update the fallback search engine")
+		}
+	}
+
+	return primary.ResetIndex()
+}
+
+// write applies the operation to both engines, the error of the primary engine is returned.
+// Failures of the fallback engine are only logged, the fallback is brought up to date with the next re-index.
+func (e *FallbackEngine) write(operation string, apply func(Engine) error) error {
+	return e.writeEach(operation,
+		func() error { return apply(e.fallback) },
+		func() error { return apply(e.primary) },
+	)
+}
+
+// writeEach is write with a dedicated operation for each engine
+func (e *FallbackEngine) writeEach(operation string, applyFallback, applyPrimary func() error) error {
+	if err := applyFallback(); err != nil {
+		e.logger.Error().Err(err).Str("operation", operation).Msg("failed to update the fallback search engine")
+	}
+
+	return applyPrimary()
+}
+
+// withoutSequences returns a copy of the resources which are upserted unconditionally
+func withoutSequences(items map[string]Resource) map[string]Resource {
+	unconditional := make(map[string]Resource, len(items))
+	for id, r := range items {
+		unconditional[id] = r.WithoutSequence()
+	}
+
+	return unconditional
+}
+
+// isUnavailable reports whether the engine failed because its backend can't serve any requests right now,
+// the fallback engine can't do better on invalid requests or missing documents.
+func isUnavailable(err error) bool {
+	return errors.Is(err, ErrBackendUnavailable) || errors.Is(err, ErrIndexNotReady)
+}
+
+// degraded logs and counts a read which is served by the fallback engine
+func (e *FallbackEngine) degraded(operation string, err error) {
+	e.logger.Warn().Err(err).Str("operation", operation).Msg("the search engine failed, using the fallback search engine")
+	if e.metrics != nil {
+		e.metrics.EngineFallbacks.WithLabelValues(operation).Inc()
+	}
+}
+
+// fallbackBatch applies the operations to the batches of both engines
+type fallbackBatch struct {
+	primary  BatchOperator
+	fallback BatchOperator
+	logger   log.Logger
+}
+
+func (b *fallbackBatch) Upsert(id string, r Resource) error {
+	if err := b.fallback.Upsert(id, r.WithoutSequence()); err != nil {
+		b.logger.Error().Err(err).Str("operation", "upsert").Msg("failed to update the fallback search engine")
+	}
+
+	return b.primary.Upsert(id, r)
+}
+
+func (b *fallbackBatch) Move(rootID, parentID, location string) error {
+	return b.write("move", func(batch BatchOperator) error { return batch.Move(rootID, parentID, location) })
+}
+
+func (b *fallbackBatch) Delete(id string) error {
+	return b.write("delete", func(batch BatchOperator) error { return batch.Delete(id) })
+}
+
+func (b *fallbackBatch) Restore(id string) error {
+	return b.write("restore", func(batch BatchOperator) error { return batch.Restore(id) })
+}
+
+func (b *fallbackBatch) RestoreMany(ids []string) error {
+	return b.write("restore", func(batch BatchOperator) error { return batch.RestoreMany(ids) })
+}
+
+func (b *fallbackBatch) Purge(id string, onlyDeleted bool) error {
+	return b.write("purge", func(batch BatchOperator) error { return batch.Purge(id, onlyDeleted) })
+}
+
+func (b *fallbackBatch) PurgeMany(ids []string, onlyDeleted bool) error {
+	return b.write("purge", func(batch BatchOperator) error { return batch.PurgeMany(ids, onlyDeleted) })
+}
+
+func (b *fallbackBatch) Push() error {
+	return b.write("push", func(batch BatchOperator) error { return batch.Push() })
+}
+
+func (b *fallbackBatch) write(operation string, apply func(BatchOperator) error) error {
+	if err := apply(b.fallback); err != nil {
+		b.logger.Error().Err(err).Str("operation", operation).Msg("failed to update the fallback search engine")
+	}
+
+	return apply(b.primary)
+}
@@ -0,0 +1,142 @@
+package search_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+)
+
+var _ = Describe("FallbackEngine", func() {
+	var (
+		primary  *engineMocks.Engine
+		fallback *engineMocks.Engine
+		m        *metrics.Metrics
+		eng      *search.FallbackEngine
+
+		errUnhealthy = fmt.Errorf("%w: cluster is not healthy", search.ErrBackendUnavailable)
+		fallbacks    = func(operation string) float64 {
+			metric := &dto.Metric{}
+			Expect(m.EngineFallbacks.WithLabelValues(operation).Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue()
+		}
+	)
+
+	BeforeEach(func() {
+		primary = &engineMocks.Engine{}
+		fallback = &engineMocks.Engine{}
+		m = metrics.New()
+		eng = search.NewFallbackEngine(primary, fallback, m, log.NopLogger())
+	})
+
+	It("serves the searches from the primary engine", func() {
+		res := &searchsvc.SearchIndexResponse{TotalMatches: 1}
+		primary.On("Search", mock.Anything, mock.Anything).Return(res, nil)
+
+		Expect(eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "foo"})).To(Equal(res))
+		fallback.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+	})
+
+	It("serves the searches from the fallback engine if the primary engine is unavailable", func() {
+		before := fallbacks("search")
+		primary.On("Search", mock.Anything, mock.Anything).Return(nil, errUnhealthy)
+		fallback.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+			TotalMatches: 1,
+			Matches:      []*searchmsg.Match{{Entity: &searchmsg.Entity{Name: "foo.pdf"}}},
+		}, nil)
+
+		res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "foo"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.GetMatches()).To(HaveLen(1))
+		Expect(res.GetMatches()[0].GetEntity().GetName()).To(Equal("foo.pdf"))
+		Expect(fallbacks("search")).To(Equal(before + 1))
+	})
+
+	It("does not fall back for cancelled searches", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		primary.On("Search", mock.Anything, mock.Anything).Return(nil, context.Canceled)
+
+		_, err := eng.Search(ctx, &searchsvc.SearchIndexRequest{Query: "foo"})
+		Expect(err).To(MatchError(context.Canceled))
+		fallback.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+	})
+
+	It("does not fall back for invalid queries", func() {
+		before := fallbacks("search")
+		primary.On("Search", mock.Anything, mock.Anything).Return(nil, errtypes.BadRequest("invalid query"))
+
+		_, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "name:("})
+		Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+		fallback.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+		Expect(fallbacks("search")).To(Equal(before))
+	})
+
+	It("does not fall back for unknown documents", func() {
+		primary.On("GetDocument", "1$2!3").Return(nil, errtypes.NotFound("1$2!3"))
+
+		_, err := eng.GetDocument("1$2!3")
+		Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+		fallback.AssertNotCalled(GinkgoT(), "GetDocument", mock.Anything)
+	})
+
+	It("writes to both engines", func() {
+		primary.On("Upsert", "1$2!3", mock.Anything).Return(nil)
+		fallback.On("Upsert", "1$2!3", mock.Anything).Return(nil)
+		primary.On("Delete", "1$2!3").Return(errUnhealthy)
+		fallback.On("Delete", "1$2!3").Return(nil)
+
+		Expect(eng.Upsert("1$2!3", search.Resource{ID: "1$2!3"})).To(Succeed())
+		primary.AssertCalled(GinkgoT(), "Upsert", "1$2!3", mock.Anything)
+		fallback.AssertCalled(GinkgoT(), "Upsert", "1$2!3", mock.Anything)
+
+		// the fallback is kept current even if the primary engine fails
+		Expect(eng.Delete("1$2!3")).To(MatchError(errUnhealthy))
+		fallback.AssertCalled(GinkgoT(), "Delete", "1$2!3")
+	})
+
+	It("upserts the resources read from the primary engine unconditionally in the fallback engine", func() {
+		primary.On("Upsert", "1$2!3", mock.Anything).Return(nil)
+		fallback.On("Upsert", "1$2!3", mock.Anything).Return(nil)
+
+		Expect(eng.Upsert("1$2!3", search.Resource{ID: "1$2!3", SeqNo: 7, PrimaryTerm: 1})).To(Succeed())
+		primary.AssertCalled(GinkgoT(), "Upsert", "1$2!3", mock.MatchedBy(func(r search.Resource) bool {
+			return r.SeqNo == 7 && r.PrimaryTerm == 1
+		}))
+		fallback.AssertCalled(GinkgoT(), "Upsert", "1$2!3", mock.MatchedBy(func(r search.Resource) bool {
+			return !r.HasSequence()
+		}))
+	})
+
+	It("writes the batches to both engines", func() {
+		primaryBatch := &engineMocks.BatchOperator{}
+		fallbackBatch := &engineMocks.BatchOperator{}
+		primary.On("NewBatch", 10).Return(primaryBatch, nil)
+		fallback.On("NewBatch", 10).Return(fallbackBatch, nil)
+		for _, batch := range []*engineMocks.BatchOperator{primaryBatch, fallbackBatch} {
+			batch.On("Upsert", "1$2!3", mock.Anything).Return(nil)
+			batch.On("Push").Return(nil)
+		}
+
+		batch, err := eng.NewBatch(10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batch.Upsert("1$2!3", search.Resource{ID: "1$2!3"})).To(Succeed())
+		Expect(batch.Push()).To(Succeed())
+
+		for _, batch := range []*engineMocks.BatchOperator{primaryBatch, fallbackBatch} {
+			batch.AssertCalled(GinkgoT(), "Upsert", "1$2!3", mock.Anything)
+			batch.AssertCalled(GinkgoT(), "Push")
+		}
+	})
+})
@@ -0,0 +1,119 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// IsTransientGatewayError reports whether the gateway request failed with an error which is likely gone if the request
+// is retried, like an unavailable, timed out or overloaded gateway. The other errors, like a resource which is not found
+// or may not be accessed, are permanent.
+func IsTransientGatewayError(status *rpc.Status, err error) bool {
+	if err != nil {
+		switch grpcstatus.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	switch status.GetCode() {
+	case rpc.Code_CODE_UNAVAILABLE, rpc.Code_CODE_DEADLINE_EXCEEDED, rpc.Code_CODE_RESOURCE_EXHAUSTED:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryingGatewaySelector selects gateway clients which retry the stats and listings of resources failing with a
+// transient error, so a flaky gateway doesn't abort the indexing of a whole space.
+type retryingGatewaySelector struct {
+	selector pool.Selectable[gateway.GatewayAPIClient]
+	attempts int
+	backoff  time.Duration
+	logger   log.Logger
+}
+
+// newRetryingGatewaySelector returns the selector as it is if the requests are not retried.
+func newRetryingGatewaySelector(selector pool.Selectable[gateway.GatewayAPIClient], attempts int, backoff time.Duration, logger log.Logger) pool.Selectable[gateway.GatewayAPIClient] {
+	if attempts <= 0 {
+		return selector
+	}
+
+	return &retryingGatewaySelector{
+		selector: selector,
+		attempts: attempts,
+		backoff:  backoff,
+		logger:   logger,
+	}
+}
+
+// Next returns the next gateway client of the selector wrapped into a client retrying the transient errors
+func (s *retryingGatewaySelector) Next(opts ...pool.Option) (gateway.GatewayAPIClient, error) {
+	client, err := s.selector.Next(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &retryingGatewayClient{GatewayAPIClient: client, selector: s}, nil
+}
+
+// retry runs the request again while it fails with a transient error, waiting twice as long before every further
+// attempt. The result of the last attempt is returned once the attempts are used up or the context is done.
+func (s *retryingGatewaySelector) retry(ctx context.Context, method string, request func() (*rpc.Status, error)) error {
+	backoff := s.backoff
+	for attempt := 1; ; attempt++ {
+		status, err := request()
+		if attempt > s.attempts || !IsTransientGatewayError(status, err) || ctx.Err() != nil {
+			return err
+		}
+
+		s.logger.Warn().Err(err).Interface("status", status).Str("method", method).Int("attempt", attempt).Dur("backoff", backoff).Msg("the gateway request failed with a transient error, retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+type retryingGatewayClient struct {
+	gateway.GatewayAPIClient
+	selector *retryingGatewaySelector
+}
+
+// Stat stats the resource and retries the transient errors
+func (c *retryingGatewayClient) Stat(ctx context.Context, req *provider.StatRequest, opts ...grpc.CallOption) (*provider.StatResponse, error) {
+	var res *provider.StatResponse
+	err := c.selector.retry(ctx, "Stat", func() (*rpc.Status, error) {
+		var err error
+		res, err = c.GatewayAPIClient.Stat(ctx, req, opts...)
+		return res.GetStatus(), err
+	})
+
+	return res, err
+}
+
+// ListContainer lists the children of the container and retries the transient errors
+func (c *retryingGatewayClient) ListContainer(ctx context.Context, req *provider.ListContainerRequest, opts ...grpc.CallOption) (*provider.ListContainerResponse, error) {
+	var res *provider.ListContainerResponse
+	err := c.selector.retry(ctx, "ListContainer", func() (*rpc.Status, error) {
+		var err error
+		res, err = c.GatewayAPIClient.ListContainer(ctx, req, opts...)
+		return res.GetStatus(), err
+	})
+
+	return res, err
+}
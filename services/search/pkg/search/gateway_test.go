@@ -0,0 +1,32 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+var _ = DescribeTable("IsTransientGatewayError",
+	func(s *rpc.Status, err error, transient bool) {
+		Expect(search.IsTransientGatewayError(s, err)).To(Equal(transient))
+	},
+	Entry("unavailable gateway", nil, grpcstatus.Error(codes.Unavailable, "connection refused"), true),
+	Entry("timed out request", nil, grpcstatus.Error(codes.DeadlineExceeded, "deadline exceeded"), true),
+	Entry("overloaded gateway", nil, grpcstatus.Error(codes.ResourceExhausted, "too many requests"), true),
+	Entry("failed request", nil, grpcstatus.Error(codes.Internal, "failed"), false),
+	Entry("other error", nil, errors.New("failed"), false),
+	Entry("unavailable storage", &rpc.Status{Code: rpc.Code_CODE_UNAVAILABLE}, nil, true),
+	Entry("timed out storage", &rpc.Status{Code: rpc.Code_CODE_DEADLINE_EXCEEDED}, nil, true),
+	Entry("overloaded storage", &rpc.Status{Code: rpc.Code_CODE_RESOURCE_EXHAUSTED}, nil, true),
+	Entry("successful request", status.NewOK(context.Background()), nil, false),
+	Entry("resource not found", status.NewNotFound(context.Background(), "not found"), nil, false),
+	Entry("access denied", status.NewPermissionDenied(context.Background(), nil, "denied"), nil, false),
+)
@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// errHealthNotChecked is reported until the health of the engine was checked for the first time
+var errHealthNotChecked = errors.New("engine health not checked yet")
+
+// HealthMonitor periodically checks the health of the engine backend, so readiness checks
+// only report the latest result instead of querying the backend on every probe.
+type HealthMonitor struct {
+	checker  HealthChecker
+	interval time.Duration
+	logger   log.Logger
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewHealthMonitor creates a HealthMonitor for the engine, engines which can not
+// report their health are always considered healthy.
+func NewHealthMonitor(engine Engine, interval time.Duration, logger log.Logger) *HealthMonitor {
+	m := &HealthMonitor{
+		interval: interval,
+		logger:   logger,
+	}
+
+	if checker, ok := engine.(HealthChecker); ok && interval > 0 {
+		m.checker = checker
+		m.err = errHealthNotChecked
+	}
+
+	return m
+}
+
+// Run checks the health of the engine right away and then in the configured interval until the context is done.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	if m.checker == nil {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check returns the result of the latest health check, it is meant to be used as readiness check.
+func (m *HealthMonitor) Check(_ context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.err
+}
+
+func (m *HealthMonitor) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+
+	err := m.checker.CheckHealth(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wasHealthy := m.err == nil || errors.Is(m.err, errHealthNotChecked)
+	switch {
+	case err != nil && wasHealthy:
+		m.logger.Warn().Err(err).Msg("search engine is unhealthy")
+	case err != nil:
+		m.logger.Debug().Err(err).Msg("search engine is still unhealthy")
+	case !wasHealthy:
+		m.logger.Info().Msg("search engine is healthy again")
+	}
+	m.err = err
+}
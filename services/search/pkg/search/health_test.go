@@ -0,0 +1,77 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/handlers"
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+)
+
+// healthCheckingEngine is an engine whose backend health can be toggled
+type healthCheckingEngine struct {
+	*engineMocks.Engine
+	unhealthy atomic.Bool
+}
+
+func (e *healthCheckingEngine) CheckHealth(_ context.Context) error {
+	if e.unhealthy.Load() {
+		return errors.New("cluster is red")
+	}
+	return nil
+}
+
+var _ = Describe("HealthMonitor", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("considers engines which can not report their health as healthy", func() {
+		monitor := search.NewHealthMonitor(&engineMocks.Engine{}, time.Second, log.NopLogger())
+		Expect(monitor.Check(ctx)).To(Succeed())
+	})
+
+	It("flips the readiness with the health of the engine", func() {
+		eng := &healthCheckingEngine{Engine: &engineMocks.Engine{}}
+		monitor := search.NewHealthMonitor(eng, 10*time.Millisecond, log.NopLogger())
+		Expect(monitor.Check(ctx)).ToNot(Succeed(), "not ready before the first check")
+
+		ready := handlers.NewCheckHandler(
+			handlers.NewCheckHandlerConfiguration().
+				WithLogger(log.NopLogger()).
+				WithCheck("search engine health", monitor.Check),
+		)
+		readiness := func() int {
+			rec := httptest.NewRecorder()
+			ready.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			return rec.Code
+		}
+
+		go monitor.Run(ctx)
+		Eventually(readiness).Should(Equal(http.StatusOK))
+
+		eng.unhealthy.Store(true)
+		Eventually(readiness).Should(Equal(http.StatusInternalServerError))
+
+		eng.unhealthy.Store(false)
+		Eventually(readiness).Should(Equal(http.StatusOK))
+	})
+})
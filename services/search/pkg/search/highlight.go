@@ -0,0 +1,25 @@
+package search
+
+import "strings"
+
+// HighlightSeparator separates the highlight fragments of a match.
+const HighlightSeparator = "; "
+
+// JoinHighlights joins the given highlight fragments, best first, as long as the highlights stay within maxSize bytes.
+// The best fragment is always kept, a maxSize of 0 disables the limit.
+func JoinHighlights(fragments []string, maxSize int) string {
+	if len(fragments) == 0 {
+		return ""
+	}
+
+	size := len(fragments[0])
+	n := 1
+	for ; n < len(fragments); n++ {
+		size += len(HighlightSeparator) + len(fragments[n])
+		if maxSize > 0 && size > maxSize {
+			break
+		}
+	}
+
+	return strings.Join(fragments[:n], HighlightSeparator)
+}
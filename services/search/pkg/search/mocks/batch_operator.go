@@ -207,6 +207,63 @@ func (_c *BatchOperator_Purge_Call) RunAndReturn(run func(id string, onlyDeleted
 	return _c
 }
 
+// PurgeMany provides a mock function for the type BatchOperator
+func (_mock *BatchOperator) PurgeMany(ids []string, onlyDeleted bool) error {
+	ret := _mock.Called(ids, onlyDeleted)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]string, bool) error); ok {
+		r0 = returnFunc(ids, onlyDeleted)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// BatchOperator_PurgeMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeMany'
+type BatchOperator_PurgeMany_Call struct {
+	*mock.Call
+}
+
+// PurgeMany is a helper method to define mock.On call
+//   - ids []string
+//   - onlyDeleted bool
+func (_e *BatchOperator_Expecter) PurgeMany(ids interface{}, onlyDeleted interface{}) *BatchOperator_PurgeMany_Call {
+	return &BatchOperator_PurgeMany_Call{Call: _e.mock.On("PurgeMany", ids, onlyDeleted)}
+}
+
+func (_c *BatchOperator_PurgeMany_Call) Run(run func(ids []string, onlyDeleted bool)) *BatchOperator_PurgeMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		var arg1 bool
+		if args[1] != nil {
+			arg1 = args[1].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *BatchOperator_PurgeMany_Call) Return(err error) *BatchOperator_PurgeMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *BatchOperator_PurgeMany_Call) RunAndReturn(run func(ids []string, onlyDeleted bool) error) *BatchOperator_PurgeMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Push provides a mock function for the type BatchOperator
 func (_mock *BatchOperator) Push() error {
 	ret := _mock.Called()
@@ -302,6 +359,57 @@ func (_c *BatchOperator_Restore_Call) RunAndReturn(run func(id string) error) *B
 	return _c
 }
 
+// RestoreMany provides a mock function for the type BatchOperator
+func (_mock *BatchOperator) RestoreMany(ids []string) error {
+	ret := _mock.Called(ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]string) error); ok {
+		r0 = returnFunc(ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// BatchOperator_RestoreMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreMany'
+type BatchOperator_RestoreMany_Call struct {
+	*mock.Call
+}
+
+// RestoreMany is a helper method to define mock.On call
+//   - ids []string
+func (_e *BatchOperator_Expecter) RestoreMany(ids interface{}) *BatchOperator_RestoreMany_Call {
+	return &BatchOperator_RestoreMany_Call{Call: _e.mock.On("RestoreMany", ids)}
+}
+
+func (_c *BatchOperator_RestoreMany_Call) Run(run func(ids []string)) *BatchOperator_RestoreMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *BatchOperator_RestoreMany_Call) Return(err error) *BatchOperator_RestoreMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *BatchOperator_RestoreMany_Call) RunAndReturn(run func(ids []string) error) *BatchOperator_RestoreMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Upsert provides a mock function for the type BatchOperator
 func (_mock *BatchOperator) Upsert(id string, r search.Resource) error {
 	ret := _mock.Called(id, r)
@@ -7,6 +7,7 @@ package mocks
 import (
 	"context"
 
+	v00 "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	"github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 	mock "github.com/stretchr/testify/mock"
@@ -143,6 +144,130 @@ func (_c *Engine_DocCount_Call) RunAndReturn(run func() (uint64, error)) *Engine
 	return _c
 }
 
+// GetDocument provides a mock function for the type Engine
+func (_mock *Engine) GetDocument(id string) (*search.Resource, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocument")
+	}
+
+	var r0 *search.Resource
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*search.Resource, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *search.Resource); ok {
+		r0 = returnFunc(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*search.Resource)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Engine_GetDocument_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocument'
+type Engine_GetDocument_Call struct {
+	*mock.Call
+}
+
+// GetDocument is a helper method to define mock.On call
+//   - id string
+func (_e *Engine_Expecter) GetDocument(id interface{}) *Engine_GetDocument_Call {
+	return &Engine_GetDocument_Call{Call: _e.mock.On("GetDocument", id)}
+}
+
+func (_c *Engine_GetDocument_Call) Run(run func(id string)) *Engine_GetDocument_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_GetDocument_Call) Return(resource *search.Resource, err error) *Engine_GetDocument_Call {
+	_c.Call.Return(resource, err)
+	return _c
+}
+
+func (_c *Engine_GetDocument_Call) RunAndReturn(run func(id string) (*search.Resource, error)) *Engine_GetDocument_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDocuments provides a mock function for the type Engine
+func (_mock *Engine) GetDocuments(ids []string) ([]*search.Resource, error) {
+	ret := _mock.Called(ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocuments")
+	}
+
+	var r0 []*search.Resource
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]string) ([]*search.Resource, error)); ok {
+		return returnFunc(ids)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]string) []*search.Resource); ok {
+		r0 = returnFunc(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*search.Resource)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = returnFunc(ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Engine_GetDocuments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocuments'
+type Engine_GetDocuments_Call struct {
+	*mock.Call
+}
+
+// GetDocuments is a helper method to define mock.On call
+//   - ids []string
+func (_e *Engine_Expecter) GetDocuments(ids interface{}) *Engine_GetDocuments_Call {
+	return &Engine_GetDocuments_Call{Call: _e.mock.On("GetDocuments", ids)}
+}
+
+func (_c *Engine_GetDocuments_Call) Run(run func(ids []string)) *Engine_GetDocuments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_GetDocuments_Call) Return(resources []*search.Resource, err error) *Engine_GetDocuments_Call {
+	_c.Call.Return(resources, err)
+	return _c
+}
+
+func (_c *Engine_GetDocuments_Call) RunAndReturn(run func(ids []string) ([]*search.Resource, error)) *Engine_GetDocuments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Move provides a mock function for the type Engine
 func (_mock *Engine) Move(id string, parentid string, target string) error {
 	ret := _mock.Called(id, parentid, target)
@@ -325,6 +450,126 @@ func (_c *Engine_Purge_Call) RunAndReturn(run func(id string, onlyDeleted bool)
 	return _c
 }
 
+// PurgeMany provides a mock function for the type Engine
+func (_mock *Engine) PurgeMany(ids []string, onlyDeleted bool) error {
+	ret := _mock.Called(ids, onlyDeleted)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]string, bool) error); ok {
+		r0 = returnFunc(ids, onlyDeleted)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Engine_PurgeMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeMany'
+type Engine_PurgeMany_Call struct {
+	*mock.Call
+}
+
+// PurgeMany is a helper method to define mock.On call
+//   - ids []string
+//   - onlyDeleted bool
+func (_e *Engine_Expecter) PurgeMany(ids interface{}, onlyDeleted interface{}) *Engine_PurgeMany_Call {
+	return &Engine_PurgeMany_Call{Call: _e.mock.On("PurgeMany", ids, onlyDeleted)}
+}
+
+func (_c *Engine_PurgeMany_Call) Run(run func(ids []string, onlyDeleted bool)) *Engine_PurgeMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		var arg1 bool
+		if args[1] != nil {
+			arg1 = args[1].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_PurgeMany_Call) Return(err error) *Engine_PurgeMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Engine_PurgeMany_Call) RunAndReturn(run func(ids []string, onlyDeleted bool) error) *Engine_PurgeMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenameTag provides a mock function for the type Engine
+func (_mock *Engine) RenameTag(oldTag string, newTag string, rootIDs []string) error {
+	ret := _mock.Called(oldTag, newTag, rootIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameTag")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, []string) error); ok {
+		r0 = returnFunc(oldTag, newTag, rootIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Engine_RenameTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenameTag'
+type Engine_RenameTag_Call struct {
+	*mock.Call
+}
+
+// RenameTag is a helper method to define mock.On call
+//   - oldTag string
+//   - newTag string
+//   - rootIDs []string
+func (_e *Engine_Expecter) RenameTag(oldTag interface{}, newTag interface{}, rootIDs interface{}) *Engine_RenameTag_Call {
+	return &Engine_RenameTag_Call{Call: _e.mock.On("RenameTag", oldTag, newTag, rootIDs)}
+}
+
+func (_c *Engine_RenameTag_Call) Run(run func(oldTag string, newTag string, rootIDs []string)) *Engine_RenameTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_RenameTag_Call) Return(err error) *Engine_RenameTag_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Engine_RenameTag_Call) RunAndReturn(run func(oldTag string, newTag string, rootIDs []string) error) *Engine_RenameTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Restore provides a mock function for the type Engine
 func (_mock *Engine) Restore(id string) error {
 	ret := _mock.Called(id)
@@ -376,6 +621,57 @@ func (_c *Engine_Restore_Call) RunAndReturn(run func(id string) error) *Engine_R
 	return _c
 }
 
+// RestoreMany provides a mock function for the type Engine
+func (_mock *Engine) RestoreMany(ids []string) error {
+	ret := _mock.Called(ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]string) error); ok {
+		r0 = returnFunc(ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Engine_RestoreMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreMany'
+type Engine_RestoreMany_Call struct {
+	*mock.Call
+}
+
+// RestoreMany is a helper method to define mock.On call
+//   - ids []string
+func (_e *Engine_Expecter) RestoreMany(ids interface{}) *Engine_RestoreMany_Call {
+	return &Engine_RestoreMany_Call{Call: _e.mock.On("RestoreMany", ids)}
+}
+
+func (_c *Engine_RestoreMany_Call) Run(run func(ids []string)) *Engine_RestoreMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_RestoreMany_Call) Return(err error) *Engine_RestoreMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Engine_RestoreMany_Call) RunAndReturn(run func(ids []string) error) *Engine_RestoreMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Search provides a mock function for the type Engine
 func (_mock *Engine) Search(ctx context.Context, req *v0.SearchIndexRequest) (*v0.SearchIndexResponse, error) {
 	ret := _mock.Called(ctx, req)
@@ -444,6 +740,80 @@ func (_c *Engine_Search_Call) RunAndReturn(run func(ctx context.Context, req *v0
 	return _c
 }
 
+// Suggest provides a mock function for the type Engine
+func (_mock *Engine) Suggest(ctx context.Context, term string, refs []*v00.Reference) ([]string, error) {
+	ret := _mock.Called(ctx, term, refs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Suggest")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []*v00.Reference) ([]string, error)); ok {
+		return returnFunc(ctx, term, refs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []*v00.Reference) []string); ok {
+		r0 = returnFunc(ctx, term, refs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []*v00.Reference) error); ok {
+		r1 = returnFunc(ctx, term, refs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Engine_Suggest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Suggest'
+type Engine_Suggest_Call struct {
+	*mock.Call
+}
+
+// Suggest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - term string
+//   - refs []*v00.Reference
+func (_e *Engine_Expecter) Suggest(ctx interface{}, term interface{}, refs interface{}) *Engine_Suggest_Call {
+	return &Engine_Suggest_Call{Call: _e.mock.On("Suggest", ctx, term, refs)}
+}
+
+func (_c *Engine_Suggest_Call) Run(run func(ctx context.Context, term string, refs []*v00.Reference)) *Engine_Suggest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []*v00.Reference
+		if args[2] != nil {
+			arg2 = args[2].([]*v00.Reference)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_Suggest_Call) Return(strings []string, err error) *Engine_Suggest_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *Engine_Suggest_Call) RunAndReturn(run func(ctx context.Context, term string, refs []*v00.Reference) ([]string, error)) *Engine_Suggest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Upsert provides a mock function for the type Engine
 func (_mock *Engine) Upsert(id string, r search.Resource) error {
 	ret := _mock.Called(id, r)
@@ -500,3 +870,54 @@ func (_c *Engine_Upsert_Call) RunAndReturn(run func(id string, r search.Resource
 	_c.Call.Return(run)
 	return _c
 }
+
+// UpsertMany provides a mock function for the type Engine
+func (_mock *Engine) UpsertMany(items map[string]search.Resource) error {
+	ret := _mock.Called(items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(map[string]search.Resource) error); ok {
+		r0 = returnFunc(items)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Engine_UpsertMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertMany'
+type Engine_UpsertMany_Call struct {
+	*mock.Call
+}
+
+// UpsertMany is a helper method to define mock.On call
+//   - items map[string]search.Resource
+func (_e *Engine_Expecter) UpsertMany(items interface{}) *Engine_UpsertMany_Call {
+	return &Engine_UpsertMany_Call{Call: _e.mock.On("UpsertMany", items)}
+}
+
+func (_c *Engine_UpsertMany_Call) Run(run func(items map[string]search.Resource)) *Engine_UpsertMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 map[string]search.Resource
+		if args[0] != nil {
+			arg0 = args[0].(map[string]search.Resource)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_UpsertMany_Call) Return(err error) *Engine_UpsertMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Engine_UpsertMany_Call) RunAndReturn(run func(items map[string]search.Resource) error) *Engine_UpsertMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -6,9 +6,11 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -39,6 +41,182 @@ func (_m *Searcher) EXPECT() *Searcher_Expecter {
 	return &Searcher_Expecter{mock: &_m.Mock}
 }
 
+// GetDocument provides a mock function for the type Searcher
+func (_mock *Searcher) GetDocument(id string) (*search.Resource, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocument")
+	}
+
+	var r0 *search.Resource
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*search.Resource, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *search.Resource); ok {
+		r0 = returnFunc(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*search.Resource)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Searcher_GetDocument_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocument'
+type Searcher_GetDocument_Call struct {
+	*mock.Call
+}
+
+// GetDocument is a helper method to define mock.On call
+//   - id string
+func (_e *Searcher_Expecter) GetDocument(id interface{}) *Searcher_GetDocument_Call {
+	return &Searcher_GetDocument_Call{Call: _e.mock.On("GetDocument", id)}
+}
+
+func (_c *Searcher_GetDocument_Call) Run(run func(id string)) *Searcher_GetDocument_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_GetDocument_Call) Return(resource *search.Resource, err error) *Searcher_GetDocument_Call {
+	_c.Call.Return(resource, err)
+	return _c
+}
+
+func (_c *Searcher_GetDocument_Call) RunAndReturn(run func(id string) (*search.Resource, error)) *Searcher_GetDocument_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDocuments provides a mock function for the type Searcher
+func (_mock *Searcher) GetDocuments(ids []string) ([]*search.Resource, []string, error) {
+	ret := _mock.Called(ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDocuments")
+	}
+
+	var r0 []*search.Resource
+	var r1 []string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func([]string) ([]*search.Resource, []string, error)); ok {
+		return returnFunc(ids)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]string) []*search.Resource); ok {
+		r0 = returnFunc(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*search.Resource)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]string) []string); ok {
+		r1 = returnFunc(ids)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func([]string) error); ok {
+		r2 = returnFunc(ids)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Searcher_GetDocuments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDocuments'
+type Searcher_GetDocuments_Call struct {
+	*mock.Call
+}
+
+// GetDocuments is a helper method to define mock.On call
+//   - ids []string
+func (_e *Searcher_Expecter) GetDocuments(ids interface{}) *Searcher_GetDocuments_Call {
+	return &Searcher_GetDocuments_Call{Call: _e.mock.On("GetDocuments", ids)}
+}
+
+func (_c *Searcher_GetDocuments_Call) Run(run func(ids []string)) *Searcher_GetDocuments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []string
+		if args[0] != nil {
+			arg0 = args[0].([]string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_GetDocuments_Call) Return(resources []*search.Resource, strings []string, err error) *Searcher_GetDocuments_Call {
+	_c.Call.Return(resources, strings, err)
+	return _c
+}
+
+func (_c *Searcher_GetDocuments_Call) RunAndReturn(run func(ids []string) ([]*search.Resource, []string, error)) *Searcher_GetDocuments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IndexAllSpaces provides a mock function for the type Searcher
+func (_mock *Searcher) IndexAllSpaces() error {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IndexAllSpaces")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func() error); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Searcher_IndexAllSpaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IndexAllSpaces'
+type Searcher_IndexAllSpaces_Call struct {
+	*mock.Call
+}
+
+// IndexAllSpaces is a helper method to define mock.On call
+func (_e *Searcher_Expecter) IndexAllSpaces() *Searcher_IndexAllSpaces_Call {
+	return &Searcher_IndexAllSpaces_Call{Call: _e.mock.On("IndexAllSpaces")}
+}
+
+func (_c *Searcher_IndexAllSpaces_Call) Run(run func()) *Searcher_IndexAllSpaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Searcher_IndexAllSpaces_Call) Return(err error) *Searcher_IndexAllSpaces_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Searcher_IndexAllSpaces_Call) RunAndReturn(run func() error) *Searcher_IndexAllSpaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IndexSpace provides a mock function for the type Searcher
 func (_mock *Searcher) IndexSpace(rID *providerv1beta1.StorageSpaceId) error {
 	ret := _mock.Called(rID)
@@ -91,8 +269,8 @@ func (_c *Searcher_IndexSpace_Call) RunAndReturn(run func(rID *providerv1beta1.S
 }
 
 // MoveItem provides a mock function for the type Searcher
-func (_mock *Searcher) MoveItem(ref *providerv1beta1.Reference) {
-	_mock.Called(ref)
+func (_mock *Searcher) MoveItem(ref *providerv1beta1.Reference, eventTime time.Time) {
+	_mock.Called(ref, eventTime)
 	return
 }
 
@@ -103,18 +281,24 @@ type Searcher_MoveItem_Call struct {
 
 // MoveItem is a helper method to define mock.On call
 //   - ref *providerv1beta1.Reference
-func (_e *Searcher_Expecter) MoveItem(ref interface{}) *Searcher_MoveItem_Call {
-	return &Searcher_MoveItem_Call{Call: _e.mock.On("MoveItem", ref)}
+//   - eventTime time.Time
+func (_e *Searcher_Expecter) MoveItem(ref interface{}, eventTime interface{}) *Searcher_MoveItem_Call {
+	return &Searcher_MoveItem_Call{Call: _e.mock.On("MoveItem", ref, eventTime)}
 }
 
-func (_c *Searcher_MoveItem_Call) Run(run func(ref *providerv1beta1.Reference)) *Searcher_MoveItem_Call {
+func (_c *Searcher_MoveItem_Call) Run(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_MoveItem_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 *providerv1beta1.Reference
 		if args[0] != nil {
 			arg0 = args[0].(*providerv1beta1.Reference)
 		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -125,7 +309,7 @@ func (_c *Searcher_MoveItem_Call) Return() *Searcher_MoveItem_Call {
 	return _c
 }
 
-func (_c *Searcher_MoveItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference)) *Searcher_MoveItem_Call {
+func (_c *Searcher_MoveItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_MoveItem_Call {
 	_c.Run(run)
 	return _c
 }
@@ -221,9 +405,157 @@ func (_c *Searcher_PurgeItem_Call) RunAndReturn(run func(rID *providerv1beta1.Re
 	return _c
 }
 
+// PurgeItems provides a mock function for the type Searcher
+func (_mock *Searcher) PurgeItems(refs []*providerv1beta1.Reference) error {
+	ret := _mock.Called(refs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeItems")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]*providerv1beta1.Reference) error); ok {
+		r0 = returnFunc(refs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Searcher_PurgeItems_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeItems'
+type Searcher_PurgeItems_Call struct {
+	*mock.Call
+}
+
+// PurgeItems is a helper method to define mock.On call
+//   - refs []*providerv1beta1.Reference
+func (_e *Searcher_Expecter) PurgeItems(refs interface{}) *Searcher_PurgeItems_Call {
+	return &Searcher_PurgeItems_Call{Call: _e.mock.On("PurgeItems", refs)}
+}
+
+func (_c *Searcher_PurgeItems_Call) Run(run func(refs []*providerv1beta1.Reference)) *Searcher_PurgeItems_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []*providerv1beta1.Reference
+		if args[0] != nil {
+			arg0 = args[0].([]*providerv1beta1.Reference)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_PurgeItems_Call) Return(err error) *Searcher_PurgeItems_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Searcher_PurgeItems_Call) RunAndReturn(run func(refs []*providerv1beta1.Reference) error) *Searcher_PurgeItems_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RebuildIndex provides a mock function for the type Searcher
+func (_mock *Searcher) RebuildIndex() error {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RebuildIndex")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func() error); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Searcher_RebuildIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RebuildIndex'
+type Searcher_RebuildIndex_Call struct {
+	*mock.Call
+}
+
+// RebuildIndex is a helper method to define mock.On call
+func (_e *Searcher_Expecter) RebuildIndex() *Searcher_RebuildIndex_Call {
+	return &Searcher_RebuildIndex_Call{Call: _e.mock.On("RebuildIndex")}
+}
+
+func (_c *Searcher_RebuildIndex_Call) Run(run func()) *Searcher_RebuildIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Searcher_RebuildIndex_Call) Return(err error) *Searcher_RebuildIndex_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Searcher_RebuildIndex_Call) RunAndReturn(run func() error) *Searcher_RebuildIndex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetIndex provides a mock function for the type Searcher
+func (_mock *Searcher) ResetIndex() (uint64, error) {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetIndex")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func() (uint64, error)); ok {
+		return returnFunc()
+	}
+	if returnFunc, ok := ret.Get(0).(func() uint64); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func() error); ok {
+		r1 = returnFunc()
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Searcher_ResetIndex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetIndex'
+type Searcher_ResetIndex_Call struct {
+	*mock.Call
+}
+
+// ResetIndex is a helper method to define mock.On call
+func (_e *Searcher_Expecter) ResetIndex() *Searcher_ResetIndex_Call {
+	return &Searcher_ResetIndex_Call{Call: _e.mock.On("ResetIndex")}
+}
+
+func (_c *Searcher_ResetIndex_Call) Run(run func()) *Searcher_ResetIndex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Searcher_ResetIndex_Call) Return(v uint64, err error) *Searcher_ResetIndex_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *Searcher_ResetIndex_Call) RunAndReturn(run func() (uint64, error)) *Searcher_ResetIndex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RestoreItem provides a mock function for the type Searcher
-func (_mock *Searcher) RestoreItem(ref *providerv1beta1.Reference) {
-	_mock.Called(ref)
+func (_mock *Searcher) RestoreItem(ref *providerv1beta1.Reference, eventTime time.Time) {
+	_mock.Called(ref, eventTime)
 	return
 }
 
@@ -234,18 +566,24 @@ type Searcher_RestoreItem_Call struct {
 
 // RestoreItem is a helper method to define mock.On call
 //   - ref *providerv1beta1.Reference
-func (_e *Searcher_Expecter) RestoreItem(ref interface{}) *Searcher_RestoreItem_Call {
-	return &Searcher_RestoreItem_Call{Call: _e.mock.On("RestoreItem", ref)}
+//   - eventTime time.Time
+func (_e *Searcher_Expecter) RestoreItem(ref interface{}, eventTime interface{}) *Searcher_RestoreItem_Call {
+	return &Searcher_RestoreItem_Call{Call: _e.mock.On("RestoreItem", ref, eventTime)}
 }
 
-func (_c *Searcher_RestoreItem_Call) Run(run func(ref *providerv1beta1.Reference)) *Searcher_RestoreItem_Call {
+func (_c *Searcher_RestoreItem_Call) Run(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_RestoreItem_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 *providerv1beta1.Reference
 		if args[0] != nil {
 			arg0 = args[0].(*providerv1beta1.Reference)
 		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -256,7 +594,7 @@ func (_c *Searcher_RestoreItem_Call) Return() *Searcher_RestoreItem_Call {
 	return _c
 }
 
-func (_c *Searcher_RestoreItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference)) *Searcher_RestoreItem_Call {
+func (_c *Searcher_RestoreItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_RestoreItem_Call {
 	_c.Run(run)
 	return _c
 }
@@ -329,9 +667,77 @@ func (_c *Searcher_Search_Call) RunAndReturn(run func(ctx context.Context, req *
 	return _c
 }
 
+// SumField provides a mock function for the type Searcher
+func (_mock *Searcher) SumField(ctx context.Context, req *v0.SumFieldRequest) (*v0.SumFieldResponse, error) {
+	ret := _mock.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SumField")
+	}
+
+	var r0 *v0.SumFieldResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SumFieldRequest) (*v0.SumFieldResponse, error)); ok {
+		return returnFunc(ctx, req)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SumFieldRequest) *v0.SumFieldResponse); ok {
+		r0 = returnFunc(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.SumFieldResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.SumFieldRequest) error); ok {
+		r1 = returnFunc(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Searcher_SumField_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SumField'
+type Searcher_SumField_Call struct {
+	*mock.Call
+}
+
+// SumField is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req *v0.SumFieldRequest
+func (_e *Searcher_Expecter) SumField(ctx interface{}, req interface{}) *Searcher_SumField_Call {
+	return &Searcher_SumField_Call{Call: _e.mock.On("SumField", ctx, req)}
+}
+
+func (_c *Searcher_SumField_Call) Run(run func(ctx context.Context, req *v0.SumFieldRequest)) *Searcher_SumField_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.SumFieldRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.SumFieldRequest)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_SumField_Call) Return(sumFieldResponse *v0.SumFieldResponse, err error) *Searcher_SumField_Call {
+	_c.Call.Return(sumFieldResponse, err)
+	return _c
+}
+
+func (_c *Searcher_SumField_Call) RunAndReturn(run func(ctx context.Context, req *v0.SumFieldRequest) (*v0.SumFieldResponse, error)) *Searcher_SumField_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // TrashItem provides a mock function for the type Searcher
-func (_mock *Searcher) TrashItem(rID *providerv1beta1.ResourceId) {
-	_mock.Called(rID)
+func (_mock *Searcher) TrashItem(rID *providerv1beta1.ResourceId, eventTime time.Time) {
+	_mock.Called(rID, eventTime)
 	return
 }
 
@@ -342,18 +748,24 @@ type Searcher_TrashItem_Call struct {
 
 // TrashItem is a helper method to define mock.On call
 //   - rID *providerv1beta1.ResourceId
-func (_e *Searcher_Expecter) TrashItem(rID interface{}) *Searcher_TrashItem_Call {
-	return &Searcher_TrashItem_Call{Call: _e.mock.On("TrashItem", rID)}
+//   - eventTime time.Time
+func (_e *Searcher_Expecter) TrashItem(rID interface{}, eventTime interface{}) *Searcher_TrashItem_Call {
+	return &Searcher_TrashItem_Call{Call: _e.mock.On("TrashItem", rID, eventTime)}
 }
 
-func (_c *Searcher_TrashItem_Call) Run(run func(rID *providerv1beta1.ResourceId)) *Searcher_TrashItem_Call {
+func (_c *Searcher_TrashItem_Call) Run(run func(rID *providerv1beta1.ResourceId, eventTime time.Time)) *Searcher_TrashItem_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 *providerv1beta1.ResourceId
 		if args[0] != nil {
 			arg0 = args[0].(*providerv1beta1.ResourceId)
 		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -364,17 +776,211 @@ func (_c *Searcher_TrashItem_Call) Return() *Searcher_TrashItem_Call {
 	return _c
 }
 
-func (_c *Searcher_TrashItem_Call) RunAndReturn(run func(rID *providerv1beta1.ResourceId)) *Searcher_TrashItem_Call {
+func (_c *Searcher_TrashItem_Call) RunAndReturn(run func(rID *providerv1beta1.ResourceId, eventTime time.Time)) *Searcher_TrashItem_Call {
 	_c.Run(run)
 	return _c
 }
 
-// UpsertItem provides a mock function for the type Searcher
-func (_mock *Searcher) UpsertItem(ref *providerv1beta1.Reference) {
+// UpdateLock provides a mock function for the type Searcher
+func (_mock *Searcher) UpdateLock(ref *providerv1beta1.Reference) {
 	_mock.Called(ref)
 	return
 }
 
+// Searcher_UpdateLock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateLock'
+type Searcher_UpdateLock_Call struct {
+	*mock.Call
+}
+
+// UpdateLock is a helper method to define mock.On call
+//   - ref *providerv1beta1.Reference
+func (_e *Searcher_Expecter) UpdateLock(ref interface{}) *Searcher_UpdateLock_Call {
+	return &Searcher_UpdateLock_Call{Call: _e.mock.On("UpdateLock", ref)}
+}
+
+func (_c *Searcher_UpdateLock_Call) Run(run func(ref *providerv1beta1.Reference)) *Searcher_UpdateLock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *providerv1beta1.Reference
+		if args[0] != nil {
+			arg0 = args[0].(*providerv1beta1.Reference)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_UpdateLock_Call) Return() *Searcher_UpdateLock_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Searcher_UpdateLock_Call) RunAndReturn(run func(ref *providerv1beta1.Reference)) *Searcher_UpdateLock_Call {
+	_c.Run(run)
+	return _c
+}
+
+// UpdateMetadata provides a mock function for the type Searcher
+func (_mock *Searcher) UpdateMetadata(id string, patch map[string]string) error {
+	ret := _mock.Called(id, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, map[string]string) error); ok {
+		r0 = returnFunc(id, patch)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Searcher_UpdateMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMetadata'
+type Searcher_UpdateMetadata_Call struct {
+	*mock.Call
+}
+
+// UpdateMetadata is a helper method to define mock.On call
+//   - id string
+//   - patch map[string]string
+func (_e *Searcher_Expecter) UpdateMetadata(id interface{}, patch interface{}) *Searcher_UpdateMetadata_Call {
+	return &Searcher_UpdateMetadata_Call{Call: _e.mock.On("UpdateMetadata", id, patch)}
+}
+
+func (_c *Searcher_UpdateMetadata_Call) Run(run func(id string, patch map[string]string)) *Searcher_UpdateMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 map[string]string
+		if args[1] != nil {
+			arg1 = args[1].(map[string]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_UpdateMetadata_Call) Return(err error) *Searcher_UpdateMetadata_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Searcher_UpdateMetadata_Call) RunAndReturn(run func(id string, patch map[string]string) error) *Searcher_UpdateMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateSharedWith provides a mock function for the type Searcher
+func (_mock *Searcher) UpdateSharedWith(rID *providerv1beta1.ResourceId) {
+	_mock.Called(rID)
+	return
+}
+
+// Searcher_UpdateSharedWith_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSharedWith'
+type Searcher_UpdateSharedWith_Call struct {
+	*mock.Call
+}
+
+// UpdateSharedWith is a helper method to define mock.On call
+//   - rID *providerv1beta1.ResourceId
+func (_e *Searcher_Expecter) UpdateSharedWith(rID interface{}) *Searcher_UpdateSharedWith_Call {
+	return &Searcher_UpdateSharedWith_Call{Call: _e.mock.On("UpdateSharedWith", rID)}
+}
+
+func (_c *Searcher_UpdateSharedWith_Call) Run(run func(rID *providerv1beta1.ResourceId)) *Searcher_UpdateSharedWith_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *providerv1beta1.ResourceId
+		if args[0] != nil {
+			arg0 = args[0].(*providerv1beta1.ResourceId)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_UpdateSharedWith_Call) Return() *Searcher_UpdateSharedWith_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Searcher_UpdateSharedWith_Call) RunAndReturn(run func(rID *providerv1beta1.ResourceId)) *Searcher_UpdateSharedWith_Call {
+	_c.Run(run)
+	return _c
+}
+
+// UpdateSpaceName provides a mock function for the type Searcher
+func (_mock *Searcher) UpdateSpaceName(spaceID *providerv1beta1.StorageSpaceId, name string) error {
+	ret := _mock.Called(spaceID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSpaceName")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*providerv1beta1.StorageSpaceId, string) error); ok {
+		r0 = returnFunc(spaceID, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Searcher_UpdateSpaceName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSpaceName'
+type Searcher_UpdateSpaceName_Call struct {
+	*mock.Call
+}
+
+// UpdateSpaceName is a helper method to define mock.On call
+//   - spaceID *providerv1beta1.StorageSpaceId
+//   - name string
+func (_e *Searcher_Expecter) UpdateSpaceName(spaceID interface{}, name interface{}) *Searcher_UpdateSpaceName_Call {
+	return &Searcher_UpdateSpaceName_Call{Call: _e.mock.On("UpdateSpaceName", spaceID, name)}
+}
+
+func (_c *Searcher_UpdateSpaceName_Call) Run(run func(spaceID *providerv1beta1.StorageSpaceId, name string)) *Searcher_UpdateSpaceName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *providerv1beta1.StorageSpaceId
+		if args[0] != nil {
+			arg0 = args[0].(*providerv1beta1.StorageSpaceId)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Searcher_UpdateSpaceName_Call) Return(err error) *Searcher_UpdateSpaceName_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Searcher_UpdateSpaceName_Call) RunAndReturn(run func(spaceID *providerv1beta1.StorageSpaceId, name string) error) *Searcher_UpdateSpaceName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpsertItem provides a mock function for the type Searcher
+func (_mock *Searcher) UpsertItem(ref *providerv1beta1.Reference, eventTime time.Time) {
+	_mock.Called(ref, eventTime)
+	return
+}
+
 // Searcher_UpsertItem_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertItem'
 type Searcher_UpsertItem_Call struct {
 	*mock.Call
@@ -382,18 +988,24 @@ type Searcher_UpsertItem_Call struct {
 
 // UpsertItem is a helper method to define mock.On call
 //   - ref *providerv1beta1.Reference
-func (_e *Searcher_Expecter) UpsertItem(ref interface{}) *Searcher_UpsertItem_Call {
-	return &Searcher_UpsertItem_Call{Call: _e.mock.On("UpsertItem", ref)}
+//   - eventTime time.Time
+func (_e *Searcher_Expecter) UpsertItem(ref interface{}, eventTime interface{}) *Searcher_UpsertItem_Call {
+	return &Searcher_UpsertItem_Call{Call: _e.mock.On("UpsertItem", ref, eventTime)}
 }
 
-func (_c *Searcher_UpsertItem_Call) Run(run func(ref *providerv1beta1.Reference)) *Searcher_UpsertItem_Call {
+func (_c *Searcher_UpsertItem_Call) Run(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_UpsertItem_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 *providerv1beta1.Reference
 		if args[0] != nil {
 			arg0 = args[0].(*providerv1beta1.Reference)
 		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
@@ -404,7 +1016,7 @@ func (_c *Searcher_UpsertItem_Call) Return() *Searcher_UpsertItem_Call {
 	return _c
 }
 
-func (_c *Searcher_UpsertItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference)) *Searcher_UpsertItem_Call {
+func (_c *Searcher_UpsertItem_Call) RunAndReturn(run func(ref *providerv1beta1.Reference, eventTime time.Time)) *Searcher_UpsertItem_Call {
 	_c.Run(run)
 	return _c
 }
@@ -0,0 +1,62 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// soundexDigits holds the soundex digit of the letters a to z, vowels are 0,
+// h and w are - as they neither get a digit nor separate letters with the same digit.
+const soundexDigits = "0123012-02245501262301-202"
+
+// Soundex returns the american soundex code of the word, like J525 for both Johnson and Jonson.
+// Only the latin letters a to z are encoded, an empty string is returned if the word has none.
+func Soundex(word string) string {
+	code := make([]byte, 0, 4)
+	var last byte
+	for _, r := range strings.ToLower(word) {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+
+		digit := soundexDigits[r-'a']
+		if len(code) == 0 {
+			code = append(code, byte(unicode.ToUpper(r)))
+			last = digit
+			continue
+		}
+
+		switch {
+		case digit == '-':
+			continue
+		case digit != '0' && digit != last:
+			code = append(code, digit)
+		}
+		last = digit
+
+		if len(code) == cap(code) {
+			break
+		}
+	}
+
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < cap(code) {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
+
+// PhoneticCodes returns the soundex codes of the words of s, the words are separated by all characters which are not letters.
+func PhoneticCodes(s string) []string {
+	var codes []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if code := Soundex(word); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
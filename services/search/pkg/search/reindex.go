@@ -0,0 +1,171 @@
+package search
+
+import (
+	"sync"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// The supported ways to handle the changes of the resources of a space which arrive while the space is indexed completely
+const (
+	// EventsDuringReindexQueue queues the changes and applies them in order once the indexing of the space is done
+	EventsDuringReindexQueue = "queue"
+	// EventsDuringReindexApply applies the changes right away
+	EventsDuringReindexApply = "apply"
+)
+
+// reindexingSpaces keeps track of the spaces which are indexed completely right now and queues the changes of their
+// resources. Applied right away, the indexing could write a resource again which it read before a concurrent change
+// deleted, moved or purged it, and the change would be lost.
+type reindexingSpaces struct {
+	mu     sync.Mutex
+	spaces map[string]*reindexingSpace
+	// maxQueued limits the number of changes queued per space, further changes are applied right away
+	maxQueued int
+	logger    log.Logger
+}
+
+type reindexingSpace struct {
+	indexings int
+	queue     []queuedChange
+	// changes counts the queued changes, the callbacks queued with after don't count
+	changes  int
+	draining bool
+}
+
+type queuedChange struct {
+	apply  func()
+	change bool
+}
+
+func newReindexingSpaces(maxQueued int, logger log.Logger) *reindexingSpaces {
+	return &reindexingSpaces{
+		spaces:    make(map[string]*reindexingSpace),
+		maxQueued: maxQueued,
+		logger:    logger,
+	}
+}
+
+// start marks the space as being indexed completely. The returned function applies the changes queued in the meantime
+// in the background once the last running indexing of the space is done, it has to be called after the last batch was pushed.
+// A nil reindexingSpaces applies all changes right away.
+func (r *reindexingSpaces) start(spaceID string) func() {
+	if r == nil {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	space, ok := r.spaces[spaceID]
+	if !ok {
+		space = &reindexingSpace{}
+		r.spaces[spaceID] = space
+	}
+	space.indexings++
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.finish(spaceID, space)
+		})
+	}
+}
+
+func (r *reindexingSpaces) finish(spaceID string, space *reindexingSpace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	space.indexings--
+	if space.indexings > 0 || space.draining {
+		// the running drain applies the queue once the last indexing is done
+		return
+	}
+	if len(space.queue) == 0 {
+		r.remove(spaceID, space)
+		return
+	}
+
+	// the queue is applied in the background, so the indexing doesn't wait for it
+	space.draining = true
+	go r.drain(spaceID, space)
+}
+
+// drain applies the queued changes in order. The changes which are queued while the queue is applied are applied
+// afterwards, unless the space is indexed again.
+func (r *reindexingSpaces) drain(spaceID string, space *reindexingSpace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for space.indexings == 0 && len(space.queue) > 0 {
+		queue := space.queue
+		space.queue = nil
+		space.changes = 0
+
+		r.mu.Unlock()
+		for _, c := range queue {
+			c.apply()
+		}
+		r.mu.Lock()
+	}
+	space.draining = false
+
+	if space.indexings == 0 {
+		r.remove(spaceID, space)
+	}
+}
+
+// remove removes the entry of the space. A change applied from the queue may have indexed the space again, which
+// removed the entry, and another indexing may have started since, its entry must be kept.
+func (r *reindexingSpaces) remove(spaceID string, space *reindexingSpace) {
+	if r.spaces[spaceID] == space {
+		delete(r.spaces, spaceID)
+	}
+}
+
+// enqueue queues the change if the space is indexed completely right now and reports whether it did.
+// The change is not queued once the queue of the space is full.
+func (r *reindexingSpaces) enqueue(spaceID string, change func()) bool {
+	return r.push(spaceID, queuedChange{apply: change, change: true})
+}
+
+// after queues f behind the changes which are queued for the space and reports whether it did,
+// it is not queued if there are no queued changes or the space is not indexed completely right now.
+func (r *reindexingSpaces) after(spaceID string, f func()) bool {
+	return r.push(spaceID, queuedChange{apply: f})
+}
+
+func (r *reindexingSpaces) push(spaceID string, c queuedChange) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	space, ok := r.spaces[spaceID]
+	if !ok {
+		return false
+	}
+
+	switch {
+	case !c.change && len(space.queue) == 0 && !space.draining:
+		return false
+	case c.change && r.maxQueued > 0 && space.changes >= r.maxQueued:
+		r.logger.Warn().Str("spaceID", spaceID).Int("maxQueued", r.maxQueued).Msg("too many changes are queued while the space is indexed, applying the change right away")
+		return false
+	}
+
+	space.queue = append(space.queue, c)
+	if c.change {
+		space.changes++
+	}
+	return true
+}
+
+// spaceOf returns the id of the space of the resource the reindexing spaces are tracked by
+func spaceOf(id *provider.ResourceId) string {
+	return storagespace.FormatStorageID(id.GetStorageId(), id.GetSpaceId())
+}
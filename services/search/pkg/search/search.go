@@ -1,20 +1,32 @@
 package search
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/agnivade/levenshtein"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/reva/v2/pkg/conversions"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/grants"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	"github.com/rivo/uniseg"
 
+	"github.com/opencloud-eu/opencloud/pkg/ast"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
@@ -23,16 +35,52 @@ import (
 
 var scopeRegex = regexp.MustCompile(`scope:\s*([^" "\n\r]*)`)
 
+const (
+	// MaxSuggestions is the maximum number of suggestions returned for a term.
+	MaxSuggestions = 5
+	// MaxSuggestionDistance is the maximum edit distance between a term and its suggestions.
+	MaxSuggestionDistance = 2
+)
+
+var (
+	// ErrIndexNotReady is returned by the engines if the index does not exist yet, for example before the first indexing.
+	ErrIndexNotReady = errors.New("the search index is not ready")
+	// ErrBackendUnavailable is returned by the engines if the search backend can not be reached or is closed.
+	ErrBackendUnavailable = errors.New("the search backend is unavailable")
+	// ErrConflict is returned by the engines if a resource read from the index is upserted
+	// after its document was changed by another write, the resource has to be read and changed again.
+	ErrConflict = errors.New("the document was changed concurrently")
+	// ErrTimeout is returned if a search of the engine did not finish within the configured timeout.
+	ErrTimeout = errors.New("the search engine did not respond in time")
+	// ErrTooManyDescendants is returned by the engines if a folder which is moved, deleted or restored has more descendants
+	// than they update, nothing has been changed then. The folder has to be indexed again instead.
+	ErrTooManyDescendants = errors.New("the folder has too many descendants to update")
+	// ErrEmptyQueryWithoutScope is returned for empty queries without a scope, they would list the whole index.
+	ErrEmptyQueryWithoutScope = errtypes.BadRequest("empty query provided without a scope")
+)
+
 // Engine is the interface to the search engine
 type Engine interface {
 	Search(ctx context.Context, req *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error)
 	DocCount() (uint64, error)
+	GetDocument(id string) (*Resource, error)
+	GetDocuments(ids []string) ([]*Resource, error)
 
 	Upsert(id string, r Resource) error
+	UpsertMany(items map[string]Resource) error
 	Move(id string, parentid string, target string) error
 	Delete(id string) error
 	Restore(id string) error
+	RestoreMany(ids []string) error
 	Purge(id string, onlyDeleted bool) error
+	PurgeMany(ids []string, onlyDeleted bool) error
+	// RenameTag replaces the tag on all resources within the given roots, on all resources if no roots are given
+	RenameTag(oldTag, newTag string, rootIDs []string) error
+
+	// Suggest returns indexed terms which are similar to the given term, the lookup is aborted once the context is done.
+	// Only the resources within the given references, the roots and the subtrees of their paths, are taken into account.
+	// Root ids alone would not do, the suggestions of a shared space must not reveal the terms outside of the shared resources.
+	Suggest(ctx context.Context, term string, refs []*searchmsg.Reference) ([]string, error)
 
 	NewBatch(batchSize int) (BatchOperator, error)
 }
@@ -42,11 +90,64 @@ type BatchOperator interface {
 	Move(rootID, parentID, location string) error
 	Delete(id string) error
 	Restore(id string) error
+	RestoreMany(ids []string) error
 	Purge(id string, onlyDeleted bool) error
+	PurgeMany(ids []string, onlyDeleted bool) error
 
 	Push() error
 }
 
+// BulkIndexer is implemented by engines which can speed up indexing many resources at once.
+type BulkIndexer interface {
+	// StartBulkIndexing prepares the engine for indexing many resources, the returned
+	// function ends the bulk indexing and has to be called even if the indexing failed.
+	StartBulkIndexing() (func() error, error)
+}
+
+// Rebuilder is implemented by engines which can rebuild their index while the searches are served by the current one.
+type Rebuilder interface {
+	// Rebuild passes an engine writing to a new index to build, which has to index all resources.
+	// The searches are switched to the new index once build succeeded, it is dropped if build fails.
+	Rebuild(build func(Engine) error) error
+}
+
+// Resetter is implemented by engines which can delete all documents at once.
+type Resetter interface {
+	// ResetIndex replaces the index with a new and empty one, it returns the number of documents of the replaced index.
+	ResetIndex() (uint64, error)
+}
+
+// HealthChecker is implemented by engines which can report the health of their backend.
+type HealthChecker interface {
+	// CheckHealth returns an error if the backend is not able to serve searches.
+	CheckHealth(ctx context.Context) error
+}
+
+// Aggregator is implemented by engines which can aggregate the values of a field over the matches of a search.
+type Aggregator interface {
+	// SumField returns the sum of the numeric field over the files matching the search request without loading the hits.
+	// The containers are left out, their sizes already add up the sizes of their children.
+	SumField(ctx context.Context, req *searchService.SearchIndexRequest, field string) (float64, error)
+}
+
+// IndexStats are the statistics of an index the compaction is decided on.
+type IndexStats struct {
+	// LiveDocs is the number of documents which are part of the index
+	LiveDocs uint64
+	// DeletedDocs is the number of deleted or replaced documents which still take up space until the index is compacted
+	DeletedDocs uint64
+	// SizeBytes is the size of the index on disk
+	SizeBytes uint64
+}
+
+// Compactor is implemented by engines which can reclaim the space of deleted documents.
+type Compactor interface {
+	// IndexStats returns the current statistics of the index.
+	IndexStats(ctx context.Context) (IndexStats, error)
+	// Compact merges the segments of the index, which drops the deleted documents, and returns once it is done.
+	Compact(ctx context.Context) error
+}
+
 // Resource is the entity that is stored in the index.
 type Resource struct {
 	content.Document
@@ -58,6 +159,237 @@ type Resource struct {
 	Type     uint64
 	Deleted  bool
 	Hidden   bool
+
+	// Truncated is set if the content and tags were cut to keep the document within the size limit of the engine
+	Truncated bool `json:",omitempty"`
+
+	// Owner and CreatedBy hold the opaque ids of the owning and the creating user
+	Owner     string
+	CreatedBy string
+
+	// Ctime is the creation time of the resource formatted as RFC3339, as reported by the storage in the opaque of
+	// the resource info. It is empty for storages which do not report it, such resources never match a creation date.
+	Ctime string `json:",omitempty"`
+
+	// SharedWith holds the opaque ids of the users and groups the resource is shared with.
+	// It must only be returned to users which are allowed to see the shares of the resource.
+	SharedWith []string
+
+	// Extension is the lowercase file extension of the name without the leading dot
+	Extension string
+
+	// HasPreview is set if the thumbnails service renders a preview of the resource
+	HasPreview bool `json:",omitempty"`
+
+	// Locked is set if the resource is locked, for example while it is edited in an office application.
+	// LockedBy holds the opaque id of the user holding the lock and LockExpiration the time the lock expires,
+	// formatted as RFC3339, locks without expiration have none. Expired locks are not matched as locked.
+	Locked         bool   `json:",omitempty"`
+	LockedBy       string `json:",omitempty"`
+	LockExpiration string `json:",omitempty"`
+
+	// SpaceName is the name of the space the resource belongs to, it is updated when the space is renamed
+	SpaceName string
+
+	// Metadata holds the metadata external systems added to the resource, like classifications
+	// or retention labels, as sorted "key=value" entries. It is kept when the resource is reindexed.
+	Metadata []string `json:",omitempty"`
+
+	// Attributes holds the configured libregraph extended attributes of the resource as sorted "name=value" entries
+	Attributes []string `json:",omitempty"`
+
+	// Classification is the sensitivity label of the resource, like "Confidential", read from the configured metadata key
+	Classification string `json:",omitempty"`
+
+	// Versions holds the content of the latest previous versions of a file, the latest version first
+	Versions []Version `json:",omitempty"`
+
+	// LastEventTS is the time of the latest event which has been applied to the resource, formatted as RFC3339.
+	// It is the time of the event, not the modification time of the resource, which comes from another clock.
+	// Trash and move events older than it are stale and can be skipped.
+	LastEventTS string `json:",omitempty"`
+
+	// SeqNo and PrimaryTerm identify the state of the document the resource was read from, they are not part of the document.
+	// Upserts of resources read from the index are rejected with ErrConflict if the document was written in the meantime,
+	// resources with a PrimaryTerm of 0 are written unconditionally.
+	SeqNo       int64 `json:"-"`
+	PrimaryTerm int64 `json:"-"`
+}
+
+// HasSequence reports whether the resource was read from the index, its upserts are conditional then.
+func (r Resource) HasSequence() bool {
+	return r.PrimaryTerm > 0
+}
+
+// WithoutSequence returns a copy of the resource which is upserted unconditionally.
+func (r Resource) WithoutSequence() Resource {
+	r.SeqNo, r.PrimaryTerm = 0, 0
+	return r
+}
+
+// maxConflictRetries limits how often a change of an indexed resource is retried with fresh data
+const maxConflictRetries = 3
+
+// RetryOnConflict runs the read-modify-write of indexed resources again while the write is rejected with ErrConflict,
+// so a concurrent change is not overwritten with stale data. update has to read the resources again on each run.
+func RetryOnConflict(update func() error) error {
+	err := update()
+	for i := 0; i < maxConflictRetries && errors.Is(err, ErrConflict); i++ {
+		err = update()
+	}
+
+	return err
+}
+
+// Version is a previous version of a file, Key identifies the version within the storage
+type Version struct {
+	Key     string
+	Mtime   string
+	Content string
+}
+
+// PatchMetadata applies the patch to the "key=value" metadata entries and returns the sorted result.
+// The patch sets the values of its keys, keys with an empty value are removed.
+func PatchMetadata(metadata []string, patch map[string]string) ([]string, error) {
+	values := make(map[string]string, len(metadata)+len(patch))
+	for _, entry := range metadata {
+		key, value, _ := strings.Cut(entry, "=")
+		values[key] = value
+	}
+
+	for key, value := range patch {
+		if key == "" || strings.ContainsAny(key, "= ") {
+			return nil, fmt.Errorf("invalid metadata key '%s'", key)
+		}
+
+		if value == "" {
+			delete(values, key)
+			continue
+		}
+		values[key] = value
+	}
+
+	patched := make([]string, 0, len(values))
+	for key, value := range values {
+		patched = append(patched, key+"="+value)
+	}
+	slices.Sort(patched)
+
+	return patched, nil
+}
+
+// RenameTag returns the tags with the old tag replaced by the new one. The tags are compared case-insensitively
+// and the new tag is kept only once, so resources which already carry it don't end up with a duplicate.
+func RenameTag(tags []string, oldTag, newTag string) []string {
+	renamed := make([]string, 0, len(tags))
+	hasNewTag := false
+	for _, tag := range tags {
+		if strings.EqualFold(tag, oldTag) {
+			tag = newTag
+		}
+
+		if strings.EqualFold(tag, newTag) {
+			if hasNewTag {
+				continue
+			}
+			hasNewTag = true
+		}
+		renamed = append(renamed, tag)
+	}
+
+	return renamed
+}
+
+// The fields which can be omitted from the search results of users without access to the content
+const (
+	// FieldContent covers the highlights and the matched version, which both reveal the content of a file
+	FieldContent  = "content"
+	FieldTags     = "tags"
+	FieldAudio    = "audio"
+	FieldImage    = "image"
+	FieldLocation = "location"
+	FieldPhoto    = "photo"
+)
+
+// OmitFields clears the given fields of the entity
+func OmitFields(entity *searchmsg.Entity, fields []string) {
+	if entity == nil {
+		return
+	}
+
+	for _, field := range fields {
+		switch field {
+		case FieldContent:
+			entity.Highlights = ""
+			entity.MatchedVersion = ""
+		case FieldTags:
+			entity.Tags = nil
+		case FieldAudio:
+			entity.Audio = nil
+		case FieldImage:
+			entity.Image = nil
+		case FieldLocation:
+			entity.Location = nil
+		case FieldPhoto:
+			entity.Photo = nil
+		}
+	}
+}
+
+// LibreGraphAttributePrefix is the prefix of the libregraph extended attributes in the arbitrary metadata of a resource
+const LibreGraphAttributePrefix = "libre.graph."
+
+// ExtendedAttributes returns the given libregraph extended attributes of the resource as sorted "name=value" entries,
+// the names are given without the "libre.graph." prefix. Attributes which are not set are left out.
+func ExtendedAttributes(ri *provider.ResourceInfo, names []string) []string {
+	metadata := ri.GetArbitraryMetadata().GetMetadata()
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	var attributes []string
+	for _, name := range names {
+		if value := metadata[LibreGraphAttributePrefix+name]; value != "" {
+			attributes = append(attributes, name+"="+value)
+		}
+	}
+	slices.Sort(attributes)
+
+	return attributes
+}
+
+// Extension returns the lowercase extension of the file name without the
+// leading dot. Names without an extension, like hidden files with a single
+// leading dot, have an empty extension.
+func Extension(name string) string {
+	ext := path.Ext(name)
+	if ext == name {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// TruncatedNameIndicator marks the names which were truncated for the index
+const TruncatedNameIndicator = "…"
+
+// TruncateName cuts names longer than maxLength characters at a grapheme cluster boundary, so no letter with its
+// combining marks or emoji sequence is split, and marks them with the TruncatedNameIndicator, which counts as one
+// of the maxLength characters. The characters are counted as grapheme clusters, 0 disables the limit.
+func TruncateName(name string, maxLength int) string {
+	if maxLength <= 0 || len(name) <= maxLength || uniseg.GraphemeClusterCount(name) <= maxLength {
+		return name
+	}
+
+	var truncated strings.Builder
+	state := -1
+	for rest := name; rest != "" && maxLength > 1; maxLength-- {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		truncated.WriteString(cluster)
+	}
+
+	return truncated.String() + TruncatedNameIndicator
 }
 
 // ResolveReference makes sure the path is relative to the space root
@@ -96,7 +428,52 @@ func (ma matchArray) Swap(i, j int) {
 	ma[i], ma[j] = ma[j], ma[i]
 }
 func (ma matchArray) Less(i, j int) bool {
-	return ma[i].GetScore() > ma[j].GetScore()
+	if ma[i].GetScore() != ma[j].GetScore() {
+		return ma[i].GetScore() > ma[j].GetScore()
+	}
+
+	// order equal-scored matches by their id like the engines do, so every request returns them in the same order
+	return matchID(ma[i]) < matchID(ma[j])
+}
+
+func matchID(match *searchmsg.Match) string {
+	id := match.GetEntity().GetId()
+	return id.GetStorageId() + "$" + id.GetSpaceId() + "!" + id.GetOpaqueId()
+}
+
+// searchWithTimeout searches the engine and cancels the search after the given timeout, 0 disables the timeout.
+// A search which is canceled by the timeout is reported as ErrTimeout.
+func searchWithTimeout(ctx context.Context, engine Engine, req *searchService.SearchIndexRequest, timeout time.Duration) (*searchService.SearchIndexResponse, error) {
+	if timeout <= 0 {
+		return engine.Search(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := engine.Search(ctx, req)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s: %w", ErrTimeout, timeout, err)
+	}
+
+	return res, err
+}
+
+// sumWithTimeout sums the field like searchWithTimeout searches, the sum is aborted after the timeout if it is set.
+func sumWithTimeout(ctx context.Context, aggregator Aggregator, req *searchService.SearchIndexRequest, field string, timeout time.Duration) (float64, error) {
+	if timeout <= 0 {
+		return aggregator.SumField(ctx, req, field)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sum, err := aggregator.SumField(ctx, req, field)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, fmt.Errorf("%w after %s: %w", ErrTimeout, timeout, err)
+	}
+
+	return sum, err
 }
 
 func logDocCount(engine Engine, logger log.Logger) {
@@ -187,12 +564,392 @@ func convertToWebDAVPermissions(isShared, isMountpoint, isDir bool, p *provider.
 	return b.String()
 }
 
-// ParseScope extract a scope value from the query string and returns search, scope strings
-func ParseScope(query string) (string, string) {
+// ParentMatches returns the parents with their number of matching resources, the counts are keyed by the parent ids.
+// The parents with the most matches come first, parents with the same number of matches are ordered by their id.
+// At most limit parents are returned, all of them if limit is negative.
+func ParentMatches(counts map[string]int32, limit int) []*searchService.ParentMatch {
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		// the space roots have no parent
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if limit >= 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	parents := make([]*searchService.ParentMatch, 0, len(ids))
+	for _, id := range ids {
+		rID, err := storagespace.ParseID(id)
+		if err != nil {
+			continue
+		}
+		parents = append(parents, &searchService.ParentMatch{
+			ParentId: &searchmsg.ResourceID{
+				StorageId: rID.GetStorageId(),
+				SpaceId:   rID.GetSpaceId(),
+				OpaqueId:  rID.GetOpaqueId(),
+			},
+			Matches: counts[id],
+		})
+	}
+
+	return parents
+}
+
+// IDMatches returns the ids of the matching resources with their scores, the scores are keyed by the resource ids.
+// The best matches come first, matches with the same score are ordered by their id like the engines do.
+// At most limit matches are returned, all of them if limit is negative.
+func IDMatches(scores map[string]float32, limit int) []*searchService.IdMatch {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		if c := cmp.Compare(scores[b], scores[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if limit >= 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	matches := make([]*searchService.IdMatch, 0, len(ids))
+	for _, id := range ids {
+		rID, err := storagespace.ParseID(id)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, &searchService.IdMatch{
+			Id: &searchmsg.ResourceID{
+				StorageId: rID.GetStorageId(),
+				SpaceId:   rID.GetSpaceId(),
+				OpaqueId:  rID.GetOpaqueId(),
+			},
+			Score: scores[id],
+		})
+	}
+
+	return matches
+}
+
+// TopLevelResources returns the resources which are not located below another of the given resources,
+// duplicates are removed. Operations which affect the descendants of a resource only need to process those.
+func TopLevelResources(resources []*Resource) []*Resource {
+	sorted := slices.Clone(resources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Path) < len(sorted[j].Path)
+	})
+
+	topLevel := make([]*Resource, 0, len(sorted))
+	for _, resource := range sorted {
+		covered := slices.ContainsFunc(topLevel, func(ancestor *Resource) bool {
+			if resource.RootID != ancestor.RootID {
+				return false
+			}
+
+			return resource.ID == ancestor.ID ||
+				resource.Path == ancestor.Path ||
+				strings.HasPrefix(resource.Path, strings.TrimSuffix(ancestor.Path, "/")+"/")
+		})
+		if !covered {
+			topLevel = append(topLevel, resource)
+		}
+	}
+
+	return topLevel
+}
+
+// IsEmptyQuery reports whether the query has no terms. Empty queries match all resources within their scope,
+// without a scope they are rejected with ErrEmptyQueryWithoutScope.
+func IsEmptyQuery(query string) bool {
+	return strings.TrimSpace(query) == ""
+}
+
+// ParseScope extract a scope value from the query string and returns search, scope strings.
+// The scope is a resource id optionally followed by a path, like <storageid>$<spaceid>!<opaqueid>/folder,
+// the path is normalized and scopes without resource id or with a path leaving the resource are rejected.
+func ParseScope(query string) (string, string, error) {
 	match := scopeRegex.FindStringSubmatch(query)
-	if len(match) >= 2 {
-		cut := match[0]
-		return strings.TrimSpace(strings.ReplaceAll(query, cut, "")), strings.TrimSpace(match[1])
+	if len(match) < 2 {
+		return query, "", nil
+	}
+
+	cut := match[0]
+	scope, err := normalizeScope(strings.TrimSpace(match[1]))
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(query, cut, "")), scope, nil
+}
+
+func normalizeScope(scope string) (string, error) {
+	id, p, _ := strings.Cut(scope, "/")
+	if id == "" {
+		return "", errtypes.BadRequest(fmt.Sprintf("invalid scope '%s': missing resource id", scope))
+	}
+	if id == "." || id == ".." || slices.Contains(strings.Split(p, "/"), "..") {
+		return "", errtypes.BadRequest(fmt.Sprintf("invalid scope '%s': the path must not leave the resource", scope))
+	}
+
+	// MakeRelativePath cleans the path and returns it with a leading "."
+	if p = strings.TrimPrefix(utils.MakeRelativePath(p), "."); p == "" {
+		return id, nil
+	}
+	return id + p, nil
+}
+
+// SuggestionWords returns the words of s which are looked up for the suggestions,
+// the words are separated by all characters which are neither letters nor numbers.
+func SuggestionWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// RankSuggestions returns the candidates which are within MaxSuggestionDistance of the given term,
+// ordered by their closeness to the term and their frequency.
+// The candidates map holds the frequency of each candidate, the term itself is never suggested.
+func RankSuggestions(term string, candidates map[string]int) []string {
+	type suggestion struct {
+		term      string
+		distance  int
+		frequency int
+	}
+
+	term = strings.ToLower(term)
+	termLen := len([]rune(term))
+	suggestions := make([]suggestion, 0, len(candidates))
+	for candidate, frequency := range candidates {
+		candidateLen := len([]rune(candidate))
+		if candidateLen < termLen-MaxSuggestionDistance || candidateLen > termLen+MaxSuggestionDistance {
+			continue
+		}
+
+		distance := levenshtein.ComputeDistance(term, candidate)
+		if distance == 0 || distance > MaxSuggestionDistance {
+			continue
+		}
+
+		suggestions = append(suggestions, suggestion{term: candidate, distance: distance, frequency: frequency})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		switch {
+		case suggestions[i].distance != suggestions[j].distance:
+			return suggestions[i].distance < suggestions[j].distance
+		case suggestions[i].frequency != suggestions[j].frequency:
+			return suggestions[i].frequency > suggestions[j].frequency
+		default:
+			return suggestions[i].term < suggestions[j].term
+		}
+	})
+
+	ranked := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		ranked = append(ranked, s.term)
+	}
+
+	return ranked
+}
+
+// QueryTermCount returns the number of terms of the given KQL query, including the terms of nested groups.
+func QueryTermCount(qs string) (int, error) {
+	q, err := kql.Builder{}.Build(qs)
+	if err != nil {
+		return 0, err
+	}
+
+	var count func(nodes []ast.Node) int
+	count = func(nodes []ast.Node) int {
+		n := 0
+		for _, node := range nodes {
+			switch node := node.(type) {
+			case *ast.GroupNode:
+				n += count(node.Nodes)
+			case *ast.StringNode, *ast.BooleanNode, *ast.DateTimeNode:
+				n++
+			}
+		}
+		return n
+	}
+
+	return count(q.Nodes), nil
+}
+
+// QueryFields returns the lowercase property names the given KQL query restricts its terms to, free text terms have none.
+func QueryFields(qs string) ([]string, error) {
+	q, err := kql.Builder{}.Build(qs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	var walk func(nodes []ast.Node)
+	walk = func(nodes []ast.Node) {
+		for _, node := range nodes {
+			if group, ok := node.(*ast.GroupNode); ok {
+				walk(group.Nodes)
+			}
+
+			if key := strings.ToLower(ast.NodeKey(node)); key != "" && !slices.Contains(fields, key) {
+				fields = append(fields, key)
+			}
+		}
+	}
+	walk(q.Nodes)
+
+	return fields, nil
+}
+
+// SuggestionTerms returns the free text, name and content terms of the given KQL query,
+// these are the terms for which suggestions can be looked up.
+func SuggestionTerms(qs string) []string {
+	q, err := kql.Builder{}.Build(qs)
+	if err != nil {
+		return nil
+	}
+
+	var terms []string
+	var walk func(key string, nodes []ast.Node)
+	walk = func(key string, nodes []ast.Node) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *ast.GroupNode:
+				groupKey := key
+				if n.Key != "" {
+					groupKey = n.Key
+				}
+				walk(groupKey, n.Nodes)
+			case *ast.StringNode:
+				nodeKey := key
+				if n.Key != "" {
+					nodeKey = n.Key
+				}
+				switch strings.ToLower(nodeKey) {
+				case "", "name", "content":
+				default:
+					continue
+				}
+
+				term := strings.ToLower(strings.Trim(n.Value, "*?"))
+				if term == "" || strings.ContainsAny(term, " *?") {
+					continue
+				}
+				terms = append(terms, term)
+			}
+		}
+	}
+	walk("", q.Nodes)
+
+	return terms
+}
+
+// replaceConditions replaces the values of the conditions of the given KQL query which are restricted to one of the
+// given properties, including the terms of groups restricted to them like owner:(alice OR bob). The conditions are
+// looked up on the parsed query, terms merely containing a condition like name:"owner:alice" are kept as they are.
+// Invalid queries are returned unchanged, they are rejected by the engine.
+func replaceConditions(qs string, keys []string, replace func(key, value string) (string, error)) (string, error) {
+	q, err := kql.Builder{}.Build(qs)
+	if err != nil {
+		return qs, nil
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	var replacements []replacement
+	var walk func(key string, nodes []ast.Node) error
+	walk = func(key string, nodes []ast.Node) error {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *ast.GroupNode:
+				groupKey := key
+				if n.Key != "" {
+					groupKey = n.Key
+				}
+				if err := walk(groupKey, n.Nodes); err != nil {
+					return err
+				}
+			case *ast.StringNode:
+				nodeKey := key
+				if n.Key != "" {
+					nodeKey = n.Key
+				}
+				if n.Mode != "" || n.Loc == nil || n.Loc.Source == nil || !slices.ContainsFunc(keys, func(k string) bool {
+					return strings.EqualFold(k, nodeKey)
+				}) {
+					continue
+				}
+
+				value, err := replace(strings.ToLower(nodeKey), n.Value)
+				if err != nil {
+					return err
+				}
+				if value == n.Value {
+					continue
+				}
+				if strings.ContainsAny(value, " ()") {
+					value = `"` + value + `"`
+				}
+				if n.Key != "" {
+					value = n.Key + ":" + value
+				}
+
+				// the source of the node may include the surrounding whitespace
+				source := *n.Loc.Source
+				start := queryOffset(qs, n.Loc.Start) + len(source) - len(strings.TrimLeftFunc(source, unicode.IsSpace))
+				end := start + len(strings.TrimSpace(source))
+				if end > len(qs) {
+					continue
+				}
+				replacements = append(replacements, replacement{start: start, end: end, text: value})
+			}
+		}
+		return nil
+	}
+	if err := walk("", q.Nodes); err != nil {
+		return qs, err
+	}
+
+	slices.SortFunc(replacements, func(a, b replacement) int { return cmp.Compare(a.start, b.start) })
+	var sb strings.Builder
+	last := 0
+	for _, r := range replacements {
+		if r.start < last {
+			continue
+		}
+		sb.WriteString(qs[last:r.start])
+		sb.WriteString(r.text)
+		last = r.end
+	}
+	sb.WriteString(qs[last:])
+
+	return sb.String(), nil
+}
+
+// queryOffset returns the byte offset of the given position of a parsed KQL query,
+// the lines and columns of the parser count runes starting at 1.
+func queryOffset(qs string, pos ast.Position) int {
+	line, column := 1, 0
+	for i, r := range qs {
+		column++
+		if line == pos.Line && column == pos.Column {
+			return i
+		}
+		if r == '\n' {
+			line++
+			column = 0
+		}
 	}
-	return query, ""
+	return len(qs)
 }
@@ -0,0 +1,179 @@
+package search_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+var _ = DescribeTable("Extension",
+	func(name, extension string) {
+		Expect(search.Extension(name)).To(Equal(extension))
+	},
+	Entry("simple extension", "report.docx", "docx"),
+	Entry("uppercase extension", "Report.DOCX", "docx"),
+	Entry("multiple dots", "archive.tar.gz", "gz"),
+	Entry("no extension", "README", ""),
+	Entry("trailing dot", "report.", ""),
+	Entry("hidden file", ".bashrc", ""),
+	Entry("hidden file with extension", ".config.yaml", "yaml"),
+)
+
+var _ = DescribeTable("TruncateName",
+	func(name string, maxLength int, truncated string) {
+		Expect(search.TruncateName(name, maxLength)).To(Equal(truncated))
+	},
+	Entry("short name", "report.docx", 20, "report.docx"),
+	Entry("name of the maximum length", "report.docx", 11, "report.docx"),
+	Entry("long name", "quarterly report.docx", 10, "quarterly…"),
+	Entry("multibyte characters are counted once", "Объём.txt", 9, "Объём.txt"),
+	Entry("combining marks are kept with their letter", "cafe\u0301 menu", 5, "cafe\u0301…"),
+	Entry("emoji sequences are not split", "👩‍💻👩‍💻👩‍💻", 2, "👩‍💻…"),
+	Entry("disabled limit", "quarterly report.docx", 0, "quarterly report.docx"),
+)
+
+var _ = DescribeTable("Transliterate",
+	func(s, latin string) {
+		Expect(search.Transliterate(s)).To(Equal(latin))
+	},
+	Entry("russian", "Иван Петров", "ivan petrov"),
+	Entry("russian digraphs", "щука и ёжик", "shchuka i ezhik"),
+	Entry("signs are dropped", "Объём", "obem"),
+	Entry("ukrainian", "Львів", "lviv"),
+	Entry("serbian", "Љубљана", "ljubljana"),
+	Entry("greek", "Αθήνα", "athina"),
+	Entry("greek final sigma", "Οδυσσεύς", "odysseys"),
+	Entry("latin is kept", "Report 2024.PDF", "Report 2024.PDF"),
+	Entry("mixed scripts", "Отчёт_Q1.xlsx", "otchet_Q1.xlsx"),
+)
+
+var _ = DescribeTable("Soundex",
+	func(word, code string) {
+		Expect(search.Soundex(word)).To(Equal(code))
+	},
+	Entry("name", "Johnson", "J525"),
+	Entry("name spelled as it sounds", "Jonson", "J525"),
+	Entry("case is ignored", "ROBERT", "R163"),
+	Entry("similar name", "Rupert", "R163"),
+	Entry("padded with zeros", "Lee", "L000"),
+	Entry("same digits are collapsed", "Pfister", "P236"),
+	Entry("h and w do not separate same digits", "Ashcraft", "A261"),
+	Entry("vowels separate same digits", "Tymczak", "T522"),
+	Entry("no latin letters", "Иван", ""),
+)
+
+var _ = DescribeTable("PhoneticCodes",
+	func(s string, codes []string) {
+		Expect(search.PhoneticCodes(s)).To(Equal(codes))
+	},
+	Entry("words", "Johnson Report.pdf", []string{"J525", "R163", "P310"}),
+	Entry("digits and punctuation", "2024_smith-notes", []string{"S530", "N320"}),
+	Entry("none", "2024", nil),
+)
+
+var _ = DescribeTable("JoinHighlights",
+	func(fragments []string, maxSize int, highlights string) {
+		Expect(search.JoinHighlights(fragments, maxSize)).To(Equal(highlights))
+	},
+	Entry("no fragments", nil, 0, ""),
+	Entry("without limit", []string{"foo", "bar", "baz"}, 0, "foo; bar; baz"),
+	Entry("within the limit", []string{"foo", "bar", "baz"}, 13, "foo; bar; baz"),
+	Entry("exceeding the limit", []string{"foo", "bar", "baz"}, 12, "foo; bar"),
+	Entry("keeps the best fragment", []string{"foo", "bar", "baz"}, 1, "foo"),
+)
+
+var _ = DescribeTable("QueryTermCount",
+	func(query string, count int) {
+		Expect(search.QueryTermCount(query)).To(Equal(count))
+	},
+	Entry("single term", "foo", 1),
+	Entry("field terms", "name:foo AND size:>10", 2),
+	Entry("nested groups", "a OR (b AND (c d))", 4),
+)
+
+var _ = DescribeTable("QueryFields",
+	func(query string, fields []string) {
+		Expect(search.QueryFields(query)).To(Equal(fields))
+	},
+	Entry("free text", "foo bar", nil),
+	Entry("field terms", "Name:foo AND size:>10 name:bar", []string{"name", "size"}),
+	Entry("date and boolean terms", "mtime>=2024-01-01 hidden:true", []string{"mtime", "hidden"}),
+	Entry("grouped terms", "foo OR (tag:a AND (content:b c))", []string{"tag", "content"}),
+	Entry("group keys", `mediatype:("pdf" OR "document")`, []string{"mediatype"}),
+)
+
+var _ = DescribeTable("RenameTag",
+	func(tags []string, oldTag, newTag string, renamed []string) {
+		Expect(search.RenameTag(tags, oldTag, newTag)).To(Equal(renamed))
+	},
+	Entry("renames the tag", []string{"draft", "report"}, "draft", "final", []string{"final", "report"}),
+	Entry("ignores the case", []string{"Draft", "report"}, "DRAFT", "final", []string{"final", "report"}),
+	Entry("changes the case", []string{"draft"}, "draft", "Draft", []string{"Draft"}),
+	Entry("keeps the new tag once", []string{"final", "draft", "report"}, "draft", "final", []string{"final", "report"}),
+	Entry("keeps other tags", []string{"report"}, "draft", "final", []string{"report"}),
+)
+
+var _ = Describe("ParentMatches", func() {
+	parent := func(opaqueID string, matches int32) *searchService.ParentMatch {
+		return &searchService.ParentMatch{
+			ParentId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: opaqueID},
+			Matches:  matches,
+		}
+	}
+	counts := map[string]int32{"1$2!4": 1, "1$2!3": 5, "1$2!5": 1, "": 7}
+
+	It("orders the parents by their number of matches and their id", func() {
+		Expect(search.ParentMatches(counts, -1)).To(HaveExactElements(parent("3", 5), parent("4", 1), parent("5", 1)))
+	})
+
+	It("limits the number of parents", func() {
+		Expect(search.ParentMatches(counts, 2)).To(HaveExactElements(parent("3", 5), parent("4", 1)))
+	})
+})
+
+var _ = Describe("IDMatches", func() {
+	match := func(opaqueID string, score float32) *searchService.IdMatch {
+		return &searchService.IdMatch{
+			Id:    &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: opaqueID},
+			Score: score,
+		}
+	}
+	scores := map[string]float32{"1$2!4": 0.5, "1$2!3": 2.5, "1$2!5": 0.5}
+
+	It("orders the matches by their score and their id", func() {
+		Expect(search.IDMatches(scores, -1)).To(HaveExactElements(match("3", 2.5), match("4", 0.5), match("5", 0.5)))
+	})
+
+	It("limits the number of matches", func() {
+		Expect(search.IDMatches(scores, 2)).To(HaveExactElements(match("3", 2.5), match("4", 0.5)))
+	})
+})
+
+var _ = Describe("TopLevelResources", func() {
+	var (
+		folder    = &search.Resource{ID: "1$2!3", RootID: "1$2!2", Path: "./folder"}
+		subFolder = &search.Resource{ID: "1$2!4", RootID: "1$2!2", Path: "./folder/sub"}
+		file      = &search.Resource{ID: "1$2!5", RootID: "1$2!2", Path: "./folder/sub/file.txt"}
+		sibling   = &search.Resource{ID: "1$2!6", RootID: "1$2!2", Path: "./folder-sibling"}
+		otherRoot = &search.Resource{ID: "1$7!8", RootID: "1$7!7", Path: "./folder/sub"}
+	)
+
+	It("skips resources which are located below other resources", func() {
+		Expect(search.TopLevelResources([]*search.Resource{file, subFolder, folder})).To(Equal([]*search.Resource{folder}))
+	})
+
+	It("keeps siblings with a common name prefix", func() {
+		Expect(search.TopLevelResources([]*search.Resource{sibling, folder})).To(ConsistOf(folder, sibling))
+	})
+
+	It("keeps resources of other roots", func() {
+		Expect(search.TopLevelResources([]*search.Resource{subFolder, otherRoot, folder})).To(ConsistOf(folder, otherRoot))
+	})
+
+	It("removes duplicates", func() {
+		Expect(search.TopLevelResources([]*search.Resource{file, file})).To(Equal([]*search.Resource{file}))
+	})
+})
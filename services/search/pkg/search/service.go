@@ -1,28 +1,38 @@
 package search
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	rpcv1beta1 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaborationv1beta1 "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	sdk "github.com/opencloud-eu/reva/v2/pkg/sdk/common"
+	"github.com/opencloud-eu/reva/v2/pkg/share"
 	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/walker"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
@@ -39,21 +49,47 @@ const (
 	_spaceTypePersonal   = "personal"
 	_spaceTypeProject    = "project"
 	_spaceTypeGrant      = "grant"
-	_slowQueryDuration   = 500 * time.Millisecond
+	_defaultPageSize     = 200
+
+	_maxLoggedQueryLength = 256
 )
 
+// _summableFields maps the numeric properties which can be summed up to their fields in the index
+var _summableFields = map[string]string{
+	"size": "Size",
+}
+
 // Searcher is the interface to the SearchService
 type Searcher interface {
 	Search(ctx context.Context, req *searchsvc.SearchRequest) (*searchsvc.SearchResponse, error)
+	SumField(ctx context.Context, req *searchsvc.SumFieldRequest) (*searchsvc.SumFieldResponse, error)
+
+	GetDocument(id string) (*Resource, error)
+	GetDocuments(ids []string) ([]*Resource, []string, error)
 
 	IndexSpace(rID *provider.StorageSpaceId) error
+	IndexAllSpaces() error
+	RebuildIndex() error
+	ResetIndex() (uint64, error)
 	PurgeDeleted(spaceID *provider.StorageSpaceId) error
 
-	TrashItem(rID *provider.ResourceId)
+	TrashItem(rID *provider.ResourceId, eventTime time.Time)
 	PurgeItem(rID *provider.Reference)
-	UpsertItem(ref *provider.Reference)
-	RestoreItem(ref *provider.Reference)
-	MoveItem(ref *provider.Reference)
+	PurgeItems(refs []*provider.Reference) error
+	UpsertItem(ref *provider.Reference, eventTime time.Time)
+	RestoreItem(ref *provider.Reference, eventTime time.Time)
+	MoveItem(ref *provider.Reference, eventTime time.Time)
+	UpdateSharedWith(rID *provider.ResourceId)
+	UpdateLock(ref *provider.Reference)
+	UpdateMetadata(id string, patch map[string]string) error
+	UpdateSpaceName(spaceID *provider.StorageSpaceId, name string) error
+}
+
+// ChangeQueue is implemented by searchers which apply the changes of resources later, like the changes which arrive
+// while the space of the resource is indexed completely.
+type ChangeQueue interface {
+	// AfterQueuedChanges calls f once the changes of the space which are queued so far are applied, right away if there are none.
+	AfterQueuedChanges(spaceID *provider.StorageSpaceId, f func())
 }
 
 // Service is responsible for indexing spaces and pass on a search
@@ -65,14 +101,60 @@ type Service struct {
 	extractor       content.Extractor
 	metrics         *metrics.Metrics
 
+	// indexingGatewaySelector retries the stats and listings of the indexing which failed with a transient error
+	indexingGatewaySelector pool.Selectable[gateway.GatewayAPIClient]
+
+	metadataExtractor     content.Extractor
+	metadataOnlySpaces    map[string]struct{}
+	metadataOnlyMimeTypes []string
+	extractionTimeout     time.Duration
+	skipSymlinks          bool
+	skipReferences        bool
+	indexSharedWith       bool
+	indexLocks            bool
+	classificationKey     string
+	indexVersions         int
+	extendedAttributes    []string
+	excludedPaths         []string
+	previewMimeTypes      []string
+	disabledFields        []string
+
 	serviceAccountID     string
 	serviceAccountSecret string
 
-	batchSize int
+	batchSize        int
+	indexConcurrency int
+
+	engineType          string
+	slowSearchThreshold time.Duration
+	searchTimeout       time.Duration
+	indexTimeout        time.Duration
+	maxQueryLength      int
+	maxQueryTerms       int
+	maxNameLength       int
+	maxPageSize         int32
+	highlightsDisabled  bool
+	queryFields         []string
+
+	// restrictedFields are omitted from the search results of users without one of the contentRoles
+	restrictedFields []string
+	contentRoles     []string
+
+	// watermarks enables the incremental indexing of spaces if set
+	watermarks WatermarkStore
+
+	// skipStaleEvents skips the trash and move events of resources which have been changed after the event
+	skipStaleEvents       bool
+	staleEventGracePeriod time.Duration
+
+	// reindexing queues the changes of the spaces which are indexed completely right now, nil applies them right away
+	reindexing *reindexingSpaces
 }
 
 var errSkipSpace error
 
+var errExtractionTimeout = errors.New("the content extraction timed out")
+
 // NewService creates a new Provider instance.
 func NewService(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], eng Engine, extractor content.Extractor, metrics *metrics.Metrics, logger log.Logger, cfg *config.Config) *Service {
 	var s = &Service{
@@ -82,23 +164,100 @@ func NewService(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], eng E
 		extractor:       extractor,
 		metrics:         metrics,
 
+		indexingGatewaySelector: newRetryingGatewaySelector(gatewaySelector, cfg.GatewayRetries.Attempts, cfg.GatewayRetries.Backoff, logger),
+
 		serviceAccountID:     cfg.ServiceAccount.ServiceAccountID,
 		serviceAccountSecret: cfg.ServiceAccount.ServiceAccountSecret,
 
-		batchSize: cfg.BatchSize,
+		batchSize:        cfg.BatchSize,
+		indexConcurrency: max(cfg.IndexConcurrency, 1),
+
+		engineType:          cfg.Engine.Type,
+		slowSearchThreshold: cfg.SlowSearchThreshold,
+		searchTimeout:       cfg.Engine.SearchTimeout,
+		indexTimeout:        cfg.Engine.IndexTimeout,
+		maxQueryLength:      cfg.MaxQueryLength,
+		maxQueryTerms:       cfg.MaxQueryTerms,
+		maxNameLength:       cfg.MaxNameLength,
+		maxPageSize:         cfg.MaxPageSize,
+		highlightsDisabled:  cfg.Engine.Highlights.Disabled,
+		queryFields:         make([]string, 0, len(cfg.QueryFields)),
+		restrictedFields:    cfg.ContentAccess.RestrictedFields,
+		contentRoles:        cfg.ContentAccess.Roles,
+
+		skipStaleEvents:       cfg.Events.SkipStaleEvents,
+		staleEventGracePeriod: cfg.Events.StaleEventGracePeriod,
+
+		metadataOnlySpaces:    make(map[string]struct{}, len(cfg.Extractor.MetadataOnlySpaces)),
+		metadataOnlyMimeTypes: cfg.Extractor.MetadataOnlyMimeTypes,
+		extractionTimeout:     cfg.Extractor.Timeout,
+		skipSymlinks:          cfg.ResourceTypes.SkipSymlinks,
+		skipReferences:        cfg.ResourceTypes.SkipReferences,
+		indexSharedWith:       cfg.IndexSharedWith,
+		indexLocks:            cfg.IndexLocks,
+		classificationKey:     cfg.ClassificationAttribute,
+		indexVersions:         cfg.IndexVersions,
+		excludedPaths:         make([]string, 0, len(cfg.ExcludedPaths)),
+		extendedAttributes:    make([]string, 0, len(cfg.ExtendedAttributes)),
+		previewMimeTypes:      cfg.PreviewMimeTypes,
+		disabledFields:        cfg.Extractor.DisabledFields,
+	}
+
+	for _, name := range cfg.ExtendedAttributes {
+		s.extendedAttributes = append(s.extendedAttributes, strings.TrimPrefix(name, LibreGraphAttributePrefix))
+	}
+
+	for _, pattern := range cfg.ExcludedPaths {
+		s.excludedPaths = append(s.excludedPaths, strings.TrimPrefix(strings.TrimPrefix(pattern, "./"), "/"))
+	}
+
+	for _, field := range cfg.QueryFields {
+		s.queryFields = append(s.queryFields, strings.ToLower(field))
+	}
+
+	// the basic extractor never fails, it only rearranges the resource info
+	s.metadataExtractor, _ = content.NewBasicExtractor(logger)
+
+	for _, id := range cfg.Extractor.MetadataOnlySpaces {
+		_, spaceID, _, err := storagespace.SplitID(id)
+		if err != nil {
+			logger.Error().Err(err).Str("spaceID", id).Msg("invalid metadata only space id")
+			continue
+		}
+		s.metadataOnlySpaces[spaceID] = struct{}{}
+	}
+
+	if cfg.IncrementalIndexing.Enabled {
+		s.watermarks = NewFileWatermarkStore(cfg.IncrementalIndexing.WatermarkPath)
+	}
+
+	if cfg.Events.DuringReindex != EventsDuringReindexApply {
+		s.reindexing = newReindexingSpaces(cfg.Events.MaxQueuedDuringReindex, logger)
 	}
 
 	return s
 }
 
+// SetWatermarkStore enables the incremental indexing of spaces, the store keeps the modification time up to
+// which the spaces are indexed. A nil store disables the incremental indexing.
+func (s *Service) SetWatermarkStore(store WatermarkStore) {
+	s.watermarks = store
+}
+
 // Search processes a search request and passes it down to the engine.
 func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*searchsvc.SearchResponse, error) {
 	s.logger.Debug().Str("query", req.Query).Msg("performing a search")
 
 	// collect metrics
+	query := req.Query
 	startTime := time.Now()
 	success := false
 	defer func() {
+		duration := time.Since(startTime)
+		if s.slowSearchThreshold > 0 && duration > s.slowSearchThreshold {
+			s.reportSlowSearch(ctx, query, req.PageSize, duration)
+		}
+
 		if s.metrics == nil {
 			return
 		}
@@ -107,7 +266,7 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		if !success {
 			status = "error"
 		}
-		s.metrics.SearchDuration.WithLabelValues(status).Observe(time.Since(startTime).Seconds())
+		s.metrics.SearchDuration.WithLabelValues(status).Observe(duration.Seconds())
 	}()
 
 	gatewayClient, err := s.gatewaySelector.Next()
@@ -115,95 +274,30 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		return nil, err
 	}
 	currentUser := revactx.ContextMustGetUser(ctx)
-
-	// Extract scope from query if set
-	query, scope := ParseScope(req.Query)
-	if query == "" {
-		return nil, errtypes.BadRequest("empty query provided")
-	}
-	req.Query = query
-	if len(scope) > 0 {
-		scopedID, err := storagespace.ParseID(scope)
-		if err != nil {
-			s.logger.Error().Err(err).Msg("failed to parse scope")
-		}
-
-		// Stat the scope to get the resource id
-		statRes, err := gatewayClient.Stat(ctx, &provider.StatRequest{
-			Ref: &provider.Reference{
-				ResourceId: &scopedID,
-			},
-			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"space"}},
-		})
-		if err != nil {
-			return nil, err
-		}
-		// GetPath the scope to get the full path in the space
-		gpRes, err := gatewayClient.GetPath(ctx, &provider.GetPathRequest{
-			ResourceId: statRes.GetInfo().GetId(),
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		req.Ref = &searchmsg.Reference{
-			ResourceId: &searchmsg.ResourceID{
-				StorageId: statRes.GetInfo().GetSpace().GetRoot().GetStorageId(),
-				SpaceId:   statRes.GetInfo().GetSpace().GetRoot().GetSpaceId(),
-				OpaqueId:  statRes.GetInfo().GetSpace().GetRoot().GetOpaqueId(),
-			},
-			Path: gpRes.Path,
+	omitFields := s.omittedFields(currentUser)
+
+	if s.maxPageSize > 0 {
+		switch {
+		case req.PageSize == -1, req.PageSize > s.maxPageSize:
+			req.PageSize = s.maxPageSize
+		case req.PageSize == 0:
+			req.PageSize = min(s.maxPageSize, _defaultPageSize)
 		}
 	}
-	filters := []*provider.ListStorageSpacesRequest_Filter{
-		{
-			Type: provider.ListStorageSpacesRequest_Filter_TYPE_USER,
-			Term: &provider.ListStorageSpacesRequest_Filter_User{User: currentUser.GetId()},
-		},
-		{
-			Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
-			Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "+grant"},
-		},
-	}
 
-	// Get the spaces to search
-	spaces := []*provider.StorageSpace{}
-	listSpacesRes, err := gatewayClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{Filters: filters})
-	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to list the user's storage spaces")
+	if err := s.prepareQuery(ctx, gatewayClient, currentUser, req); err != nil {
 		return nil, err
 	}
-	for _, space := range listSpacesRes.StorageSpaces {
-		if utils.ReadPlainFromOpaque(space.Opaque, "trashed") == _spaceStateTrashed {
-			// Do not consider disabled spaces
-			continue
-		}
-		if space.SpaceType != "mountpoint" && req.Ref != nil && (req.Ref.GetResourceId().GetSpaceId() != space.Root.GetSpaceId()) {
-			// Do not search (non-mountpoint) spaces that do not match the given scope (if a scope is set)
-			// We still need the mountpoint in order to map the result paths to the according share
-			continue
-		}
-		spaces = append(spaces, space)
-	}
 
-	mountpointMap := map[string]string{}
-	for _, space := range spaces {
-		if space.SpaceType != _spaceTypeMountpoint {
-			continue
-		}
-		opaqueMap := sdk.DecodeOpaqueMap(space.Opaque)
-		grantSpaceID := storagespace.FormatResourceID(&provider.ResourceId{
-			StorageId: opaqueMap["grantStorageID"],
-			SpaceId:   opaqueMap["grantSpaceID"],
-			OpaqueId:  opaqueMap["grantOpaqueID"],
-		})
-		mountpointMap[grantSpaceID] = space.Id.OpaqueId
+	spaces, mountpointMap, err := s.userSpaces(ctx, gatewayClient, currentUser, req.Ref)
+	if err != nil {
+		return nil, err
 	}
 
 	matches := matchArray{}
 	total := int32(0)
 
-	errg, ctx := errgroup.WithContext(ctx)
+	errg, errgCtx := errgroup.WithContext(ctx)
 	work := make(chan *provider.StorageSpace, len(spaces))
 	results := make(chan *searchsvc.SearchIndexResponse, len(spaces))
 
@@ -213,8 +307,8 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		for _, space := range spaces {
 			select {
 			case work <- space:
-			case <-ctx.Done():
-				return ctx.Err()
+			case <-errgCtx.Done():
+				return errgCtx.Err()
 			}
 		}
 		return nil
@@ -228,15 +322,15 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 	for i := 0; i < numWorkers; i++ {
 		errg.Go(func() error {
 			for space := range work {
-				res, err := s.searchIndex(ctx, req, space, mountpointMap[space.Id.OpaqueId])
+				res, err := s.searchIndex(errgCtx, req, space, mountpointMap[space.Id.OpaqueId], omitFields)
 				if err != nil && err != errSkipSpace {
 					return err
 				}
 
 				select {
 				case results <- res:
-				case <-ctx.Done():
-					return ctx.Err()
+				case <-errgCtx.Done():
+					return errgCtx.Err()
 				}
 			}
 			return nil
@@ -260,6 +354,8 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		return nil, err
 	}
 
+	parentCounts := make(map[string]int32)
+	idScores := make(map[string]float32)
 	for _, res := range responses {
 		if res == nil {
 			continue
@@ -268,160 +364,570 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 		for _, match := range res.Matches {
 			matches = append(matches, match)
 		}
+		for _, parent := range res.Parents {
+			// a shared folder is also searched within the space it belongs to, its matches are not counted twice
+			id := storagespace.FormatResourceID(&provider.ResourceId{
+				StorageId: parent.GetParentId().GetStorageId(),
+				SpaceId:   parent.GetParentId().GetSpaceId(),
+				OpaqueId:  parent.GetParentId().GetOpaqueId(),
+			})
+			parentCounts[id] = max(parentCounts[id], parent.GetMatches())
+		}
+		for _, match := range res.IdMatches {
+			// like the parents, the resources of a shared folder are not returned twice
+			id := storagespace.FormatResourceID(&provider.ResourceId{
+				StorageId: match.GetId().GetStorageId(),
+				SpaceId:   match.GetId().GetSpaceId(),
+				OpaqueId:  match.GetId().GetOpaqueId(),
+			})
+			idScores[id] = max(idScores[id], match.GetScore())
+		}
 	}
 
 	// compile one sorted list of matches from all spaces and apply the limit if needed
 	sort.Sort(matches)
 	limit := req.PageSize
 	if limit == 0 {
-		limit = 200
+		limit = _defaultPageSize
 	}
 	if int32(len(matches)) > limit && limit != -1 {
 		matches = matches[0:limit]
 	}
 
+	var parents []*searchsvc.ParentMatch
+	if req.GroupByParent {
+		parents = ParentMatches(parentCounts, int(limit))
+	}
+
+	var idMatches []*searchsvc.IdMatch
+	if req.IdsOnly {
+		idMatches = IDMatches(idScores, int(limit))
+	}
+
+	// the breadcrumbs are only resolved for the returned matches
+	if req.WithBreadcrumbs {
+		s.addBreadcrumbs(matches)
+	}
+
+	var suggestions []string
+	if total == 0 && !req.CountOnly && !req.IdsOnly && !IsEmptyQuery(req.Query) {
+		suggestions = s.suggest(ctx, req.Query, req.Ref, spaces, mountpointMap)
+	}
+
 	success = true
 	return &searchsvc.SearchResponse{
 		Matches:      matches,
 		TotalMatches: total,
+		Suggestions:  suggestions,
+		Parents:      parents,
+		IdMatches:    idMatches,
 	}, nil
 }
 
-func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest, space *provider.StorageSpace, mountpointID string) (*searchsvc.SearchIndexResponse, error) {
-	if req.Ref != nil &&
-		(req.Ref.ResourceId.StorageId != space.Root.StorageId ||
-			req.Ref.ResourceId.SpaceId != space.Root.SpaceId) {
-		return nil, errSkipSpace
+// SumField sums the numeric property up over the files matching the query within the spaces of the current user,
+// like the total size of the matching documents. The scope and the permissions are applied like for a search.
+func (s *Service) SumField(ctx context.Context, req *searchsvc.SumFieldRequest) (*searchsvc.SumFieldResponse, error) {
+	field, ok := _summableFields[strings.ToLower(req.GetField())]
+	if !ok {
+		return nil, errtypes.BadRequest(fmt.Sprintf("the property '%s' can not be summed up", req.GetField()))
+	}
+	aggregator, ok := s.engine.(Aggregator)
+	if !ok {
+		return nil, errtypes.NotSupported("the search engine can not aggregate fields")
 	}
 
-	searchRootID := &searchmsg.ResourceID{
-		StorageId: space.Root.StorageId,
-		SpaceId:   space.Root.SpaceId,
-		OpaqueId:  space.Root.OpaqueId,
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		return nil, err
 	}
+	currentUser := revactx.ContextMustGetUser(ctx)
 
-	var (
-		mountpointRootID *searchmsg.ResourceID
-		rootName         string
-		permissions      *provider.ResourcePermissions
-		remoteItemId     *searchmsg.ResourceID
-	)
-	mountpointPrefix := ""
-	searchPathPrefix := req.Ref.GetPath()
-	switch space.SpaceType {
-	case _spaceTypeMountpoint:
-		return nil, errSkipSpace // mountpoint spaces are only "links" to the shared spaces. we have to search the shared "grant" space instead
-	case _spaceTypeGrant:
-		// In case of grant spaces we search the root of the outer space and translate the paths to the according mountpoint
-		searchRootID.OpaqueId = space.Root.SpaceId
-		if mountpointID == "" {
-			s.logger.Warn().Interface("space", space).Msg("could not find mountpoint space for grant space")
-			return nil, errSkipSpace
+	searchReq := &searchsvc.SearchRequest{
+		Query: req.GetQuery(),
+		Ref:   req.GetRef(),
+	}
+	if err := s.prepareQuery(ctx, gatewayClient, currentUser, searchReq); err != nil {
+		return nil, err
+	}
+
+	spaces, mountpointMap, err := s.userSpaces(ctx, gatewayClient, currentUser, searchReq.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// the shares within spaces of the user are covered by the spaces, they must not be counted twice
+	spaceIDs := make(map[string]struct{}, len(spaces))
+	for _, space := range spaces {
+		if space.GetSpaceType() == _spaceTypePersonal || space.GetSpaceType() == _spaceTypeProject {
+			spaceIDs[space.GetRoot().GetSpaceId()] = struct{}{}
+		}
+	}
+
+	var sum float64
+	for _, space := range spaces {
+		if _, ok := spaceIDs[space.GetRoot().GetSpaceId()]; ok && space.GetSpaceType() == _spaceTypeGrant {
+			continue
 		}
 
-		gatewayClient, err := s.gatewaySelector.Next()
+		sp, err := s.resolveSpace(ctx, searchReq.Ref, space, mountpointMap[space.GetId().GetOpaqueId()])
 		if err != nil {
 			return nil, err
 		}
+		if sp == nil {
+			continue
+		}
 
-		serviceCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+		spaceSum, err := sumWithTimeout(ctx, aggregator, &searchsvc.SearchIndexRequest{
+			Query: searchReq.Query,
+			Ref:   sp.ref,
+		}, field, s.searchTimeout)
 		if err != nil {
+			s.logger.Error().Err(err).Str("space", space.GetId().GetOpaqueId()).Msg("failed to sum up the field")
 			return nil, err
 		}
+		sum += spaceSum
+	}
 
-		gpRes, err := gatewayClient.GetPath(serviceCtx, &provider.GetPathRequest{
-			ResourceId: space.Root,
-		})
-		if err != nil {
-			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Msg("failed to get path for grant space root")
-			return nil, errSkipSpace
+	return &searchsvc.SumFieldResponse{Sum: sum}, nil
+}
+
+// prepareQuery validates the query of the search request and resolves its users and its scope,
+// the query and the reference of the request are replaced by the resolved ones.
+func (s *Service) prepareQuery(ctx context.Context, gatewayClient gateway.GatewayAPIClient, currentUser *userv1beta1.User, req *searchsvc.SearchRequest) error {
+	// reject expensive queries before they are parsed by the engine
+	if s.maxQueryLength > 0 && utf8.RuneCountInString(req.Query) > s.maxQueryLength {
+		return errtypes.BadRequest(fmt.Sprintf("query exceeds the maximum length of %d characters", s.maxQueryLength))
+	}
+
+	// Extract scope from query if set
+	query, scope, err := ParseScope(req.Query)
+	if err != nil {
+		return err
+	}
+	if IsEmptyQuery(query) && scope == "" && req.Ref == nil {
+		return ErrEmptyQueryWithoutScope
+	}
+	if s.maxQueryTerms > 0 {
+		// invalid queries are rejected by the engine
+		if terms, err := QueryTermCount(query); err == nil && terms > s.maxQueryTerms {
+			return errtypes.BadRequest(fmt.Sprintf("query exceeds the maximum number of %d terms", s.maxQueryTerms))
 		}
-		if gpRes.Status.Code != rpcv1beta1.Code_CODE_OK {
-			s.logger.Error().Interface("status", gpRes.Status).Str("space", space.Id.OpaqueId).Msg("failed to get path for grant space root")
-			return nil, errSkipSpace
+	}
+	if len(s.queryFields) > 0 {
+		// only the user query is restricted, the filters added by the service and the engines are not part of it.
+		// A query which can't be parsed can't be checked, so it is rejected here instead of by the engine.
+		fields, err := QueryFields(query)
+		if err != nil {
+			return errtypes.BadRequest(fmt.Sprintf("invalid query: %s", err))
 		}
-		mountpointPrefix = utils.MakeRelativePath(gpRes.Path)
-		if searchPathPrefix == "" {
-			searchPathPrefix = mountpointPrefix
+		for _, field := range fields {
+			if !slices.Contains(s.queryFields, field) {
+				return errtypes.BadRequest(fmt.Sprintf("the property '%s' is not searchable", field))
+			}
 		}
-		sid, spid, oid, err := storagespace.SplitID(mountpointID)
+	}
+	req.Query = s.resolveUsers(ctx, gatewayClient, query)
+	req.Query, err = restrictSharedWith(currentUser, req.Query)
+	if err != nil {
+		return err
+	}
+	if len(scope) > 0 {
+		scopedRef, err := storagespace.ParseReference(scope)
 		if err != nil {
-			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Str("mountpointId", mountpointID).Msg("invalid mountpoint space id")
-			return nil, errSkipSpace
+			return errtypes.BadRequest(fmt.Sprintf("invalid scope '%s': %s", scope, err))
 		}
-		// exclude the hidden shares
-		rs, err := gatewayClient.GetReceivedShare(ctx, &collaborationv1beta1.GetReceivedShareRequest{
-			Ref: &collaborationv1beta1.ShareReference{
-				Spec: &collaborationv1beta1.ShareReference_Id{
-					Id: &collaborationv1beta1.ShareId{
-						OpaqueId: oid,
-					},
-				},
-			},
+
+		// Stat the scope to get the resource id
+		statRes, err := gatewayClient.Stat(ctx, &provider.StatRequest{
+			Ref:       &scopedRef,
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"space"}},
 		})
 		if err != nil {
-			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Str("shareId", oid).Msg("invalid receive share")
+			return err
 		}
-		if rs.GetStatus().GetCode() == rpcv1beta1.Code_CODE_OK && rs.GetShare().GetHidden() {
-			return nil, errSkipSpace
+		// GetPath the scope to get the full path in the space
+		gpRes, err := gatewayClient.GetPath(ctx, &provider.GetPathRequest{
+			ResourceId: statRes.GetInfo().GetId(),
+		})
+		if err != nil {
+			return err
 		}
 
-		mountpointRootID = &searchmsg.ResourceID{
-			StorageId: sid,
-			SpaceId:   spid,
-			OpaqueId:  oid,
-		}
-		rootName = space.GetRootInfo().GetPath()
-		permissions = space.GetRootInfo().GetPermissionSet()
-		remoteItemId = &searchmsg.ResourceID{
-			StorageId: space.GetRootInfo().GetId().GetStorageId(),
-			SpaceId:   space.GetRootInfo().GetId().GetSpaceId(),
-			OpaqueId:  space.GetRootInfo().GetId().GetOpaqueId(),
+		req.Ref = &searchmsg.Reference{
+			ResourceId: &searchmsg.ResourceID{
+				StorageId: statRes.GetInfo().GetSpace().GetRoot().GetStorageId(),
+				SpaceId:   statRes.GetInfo().GetSpace().GetRoot().GetSpaceId(),
+				OpaqueId:  statRes.GetInfo().GetSpace().GetRoot().GetOpaqueId(),
+			},
+			Path: gpRes.Path,
 		}
-		s.logger.Debug().Interface("grantSpace", space).Interface("mountpointRootId", mountpointRootID).Msg("searching a grant")
-	case _spaceTypePersonal, _spaceTypeProject:
-		permissions = space.GetRootInfo().GetPermissionSet()
 	}
 
-	searchRequest := &searchsvc.SearchIndexRequest{
-		Query: req.Query,
-		Ref: &searchmsg.Reference{
-			ResourceId: searchRootID,
-			Path:       searchPathPrefix,
+	return nil
+}
+
+// userSpaces returns the spaces of the current user which are searched for the reference, all of them if the reference is nil,
+// together with the ids of the mountpoints of the shared spaces by the ids of the shared spaces.
+func (s *Service) userSpaces(ctx context.Context, gatewayClient gateway.GatewayAPIClient, currentUser *userv1beta1.User, ref *searchmsg.Reference) ([]*provider.StorageSpace, map[string]string, error) {
+	filters := []*provider.ListStorageSpacesRequest_Filter{
+		{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_USER,
+			Term: &provider.ListStorageSpacesRequest_Filter_User{User: currentUser.GetId()},
+		},
+		{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+			Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "+grant"},
 		},
-		PageSize: req.PageSize,
 	}
-	start := time.Now()
-	res, err := s.engine.Search(ctx, searchRequest)
-	duration := time.Since(start)
+
+	// Get the spaces to search
+	spaces := []*provider.StorageSpace{}
+	listSpacesRes, err := gatewayClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{Filters: filters})
 	if err != nil {
-		s.logger.Error().Err(err).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Msg("failed to search the index")
-		return nil, err
-	}
-	if duration > _slowQueryDuration {
-		s.logger.Info().Interface("searchRequest", searchRequest).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("slow space search")
-	} else {
-		s.logger.Debug().Interface("searchRequest", searchRequest).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("space search done")
+		s.logger.Error().Err(err).Msg("failed to list the user's storage spaces")
+		return nil, nil, err
 	}
-
-	matches := make([]*searchmsg.Match, 0, len(res.Matches))
-
+	for _, space := range listSpacesRes.StorageSpaces {
+		if utils.ReadPlainFromOpaque(space.Opaque, "trashed") == _spaceStateTrashed {
+			// Do not consider disabled spaces
+			continue
+		}
+		if space.SpaceType != "mountpoint" && ref != nil && (ref.GetResourceId().GetSpaceId() != space.Root.GetSpaceId()) {
+			// Do not search (non-mountpoint) spaces that do not match the given scope (if a scope is set)
+			// We still need the mountpoint in order to map the result paths to the according share
+			continue
+		}
+		spaces = append(spaces, space)
+	}
+
+	mountpointMap := map[string]string{}
+	for _, space := range spaces {
+		if space.SpaceType != _spaceTypeMountpoint {
+			continue
+		}
+		opaqueMap := sdk.DecodeOpaqueMap(space.Opaque)
+		grantSpaceID := storagespace.FormatResourceID(&provider.ResourceId{
+			StorageId: opaqueMap["grantStorageID"],
+			SpaceId:   opaqueMap["grantSpaceID"],
+			OpaqueId:  opaqueMap["grantOpaqueID"],
+		})
+		mountpointMap[grantSpaceID] = space.Id.OpaqueId
+	}
+
+	return spaces, mountpointMap, nil
+}
+
+// resolveUsers replaces the usernames of owner and creator conditions in the query with the user ids,
+// values which do not match a username are kept as they are, they are considered to be user ids already.
+func (s *Service) resolveUsers(ctx context.Context, gatewayClient gateway.GatewayAPIClient, query string) string {
+	query, _ = replaceConditions(query, []string{"owner", "creator"}, func(_, username string) (string, error) {
+		res, err := gatewayClient.GetUserByClaim(ctx, &userv1beta1.GetUserByClaimRequest{
+			Claim:                  "username",
+			Value:                  username,
+			SkipFetchingUserGroups: true,
+		})
+		if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+			return username, nil
+		}
+
+		return res.GetUser().GetId().GetOpaqueId(), nil
+	})
+	return query
+}
+
+// restrictSharedWith resolves the sharedwith conditions of the query. Who a resource is shared with is only
+// visible to the users managing its shares, so users may only search for the resources shared with themselves
+// or with one of their groups. The value `me` refers to the current user.
+func restrictSharedWith(u *userv1beta1.User, query string) (string, error) {
+	return replaceConditions(query, []string{"sharedwith"}, func(_, id string) (string, error) {
+		switch {
+		case strings.EqualFold(id, "me"):
+			return u.GetId().GetOpaqueId(), nil
+		case strings.EqualFold(id, u.GetId().GetOpaqueId()):
+			return id, nil
+		}
+		for _, group := range u.GetGroups() {
+			if strings.EqualFold(id, group) {
+				return id, nil
+			}
+		}
+
+		return id, errtypes.BadRequest("sharedwith only supports the current user and their groups")
+	})
+}
+
+// suggest looks up similar terms for the given query within the given spaces, all lookups together are limited to the
+// search timeout. Failing lookups are logged only, suggestions are a best effort addition to the search results.
+// Like the search, the lookups in shared spaces are restricted to the shared resources.
+func (s *Service) suggest(ctx context.Context, query string, ref *searchmsg.Reference, spaces []*provider.StorageSpace, mountpointMap map[string]string) []string {
+	refs := make([]*searchmsg.Reference, 0, len(spaces))
+	for _, space := range spaces {
+		sp, err := s.resolveSpace(ctx, ref, space, mountpointMap[space.GetId().GetOpaqueId()])
+		if err != nil || sp == nil {
+			continue
+		}
+
+		refs = append(refs, sp.ref)
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if s.searchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.searchTimeout)
+		defer cancel()
+	}
+
+	var suggestions []string
+	seen := make(map[string]struct{})
+	for _, term := range SuggestionTerms(query) {
+		if ctx.Err() != nil {
+			s.logger.Warn().Err(ctx.Err()).Msg("aborted looking up search suggestions")
+			break
+		}
+
+		termSuggestions, err := s.engine.Suggest(ctx, term, refs)
+		if err != nil {
+			s.logger.Error().Err(err).Str("term", term).Msg("failed to look up search suggestions")
+			continue
+		}
+
+		for _, suggestion := range termSuggestions {
+			if _, ok := seen[suggestion]; ok {
+				continue
+			}
+			seen[suggestion] = struct{}{}
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	if len(suggestions) > MaxSuggestions {
+		suggestions = suggestions[:MaxSuggestions]
+	}
+
+	return suggestions
+}
+
+// reportSlowSearch logs a search which exceeded the slow search threshold and counts it.
+func (s *Service) reportSlowSearch(ctx context.Context, query string, pageSize int32, duration time.Duration) {
+	if s.metrics != nil {
+		s.metrics.SlowSearches.Inc()
+	}
+
+	u, _ := revactx.ContextGetUser(ctx)
+	s.logger.Warn().
+		Str("query", sanitizeQuery(query)).
+		Int32("pageSize", pageSize).
+		Str("userID", u.GetId().GetOpaqueId()).
+		Str("engine", s.engineType).
+		Dur("duration", duration).
+		Msg("slow search")
+}
+
+// sanitizeQuery strips control characters from the query and shortens it for logging.
+func sanitizeQuery(query string) string {
+	query = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, query)
+
+	if runes := []rune(query); len(runes) > _maxLoggedQueryLength {
+		return string(runes[:_maxLoggedQueryLength]) + "…"
+	}
+	return query
+}
+
+// omittedFields returns the restricted fields if the user has none of the roles which are allowed to read them
+func (s *Service) omittedFields(u *userv1beta1.User) []string {
+	if len(s.restrictedFields) == 0 {
+		return nil
+	}
+
+	var roleIDs []string
+	if err := utils.ReadJSONFromOpaque(u.GetOpaque(), "roles", &roleIDs); err != nil {
+		s.logger.Debug().Err(err).Str("userid", u.GetId().GetOpaqueId()).Msg("failed to read the roles of the user")
+	}
+
+	for _, roleID := range roleIDs {
+		if slices.Contains(s.contentRoles, roleID) {
+			return nil
+		}
+	}
+
+	return s.restrictedFields
+}
+
+// spaceSearch is the search of the index within one of the spaces of the user
+type spaceSearch struct {
+	// ref is the reference the index is searched in
+	ref *searchmsg.Reference
+
+	// the matches of shared spaces are mapped to their mountpoints
+	mountpointPrefix string
+	mountpointRootID *searchmsg.ResourceID
+	rootName         string
+	remoteItemID     *searchmsg.ResourceID
+	permissions      *provider.ResourcePermissions
+}
+
+// resolveSpace returns the search within the space for the reference, a nil search and error if the space is skipped.
+// Mountpoints, the shared spaces without mountpoint and the hidden shares are skipped.
+func (s *Service) resolveSpace(ctx context.Context, ref *searchmsg.Reference, space *provider.StorageSpace, mountpointID string) (*spaceSearch, error) {
+	if ref != nil &&
+		(ref.ResourceId.StorageId != space.Root.StorageId ||
+			ref.ResourceId.SpaceId != space.Root.SpaceId) {
+		return nil, errSkipSpace
+	}
+
+	searchRootID := &searchmsg.ResourceID{
+		StorageId: space.Root.StorageId,
+		SpaceId:   space.Root.SpaceId,
+		OpaqueId:  space.Root.OpaqueId,
+	}
+
+	var (
+		mountpointRootID *searchmsg.ResourceID
+		rootName         string
+		permissions      *provider.ResourcePermissions
+		remoteItemID     *searchmsg.ResourceID
+	)
+	mountpointPrefix := ""
+	searchPathPrefix := ref.GetPath()
+	switch space.SpaceType {
+	case _spaceTypeMountpoint:
+		return nil, errSkipSpace // mountpoint spaces are only "links" to the shared spaces. we have to search the shared "grant" space instead
+	case _spaceTypeGrant:
+		// In case of grant spaces we search the root of the outer space and translate the paths to the according mountpoint
+		searchRootID.OpaqueId = space.Root.SpaceId
+		if mountpointID == "" {
+			s.logger.Warn().Interface("space", space).Msg("could not find mountpoint space for grant space")
+			return nil, errSkipSpace
+		}
+
+		gatewayClient, err := s.gatewaySelector.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		serviceCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		gpRes, err := gatewayClient.GetPath(serviceCtx, &provider.GetPathRequest{
+			ResourceId: space.Root,
+		})
+		if err != nil {
+			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Msg("failed to get path for grant space root")
+			return nil, errSkipSpace
+		}
+		if gpRes.Status.Code != rpcv1beta1.Code_CODE_OK {
+			s.logger.Error().Interface("status", gpRes.Status).Str("space", space.Id.OpaqueId).Msg("failed to get path for grant space root")
+			return nil, errSkipSpace
+		}
+		mountpointPrefix = utils.MakeRelativePath(gpRes.Path)
+		if searchPathPrefix == "" {
+			searchPathPrefix = mountpointPrefix
+		}
+		sid, spid, oid, err := storagespace.SplitID(mountpointID)
+		if err != nil {
+			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Str("mountpointId", mountpointID).Msg("invalid mountpoint space id")
+			return nil, errSkipSpace
+		}
+		// exclude the hidden shares
+		rs, err := gatewayClient.GetReceivedShare(ctx, &collaborationv1beta1.GetReceivedShareRequest{
+			Ref: &collaborationv1beta1.ShareReference{
+				Spec: &collaborationv1beta1.ShareReference_Id{
+					Id: &collaborationv1beta1.ShareId{
+						OpaqueId: oid,
+					},
+				},
+			},
+		})
+		if err != nil {
+			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Str("shareId", oid).Msg("invalid receive share")
+		}
+		if rs.GetStatus().GetCode() == rpcv1beta1.Code_CODE_OK && rs.GetShare().GetHidden() {
+			return nil, errSkipSpace
+		}
+
+		mountpointRootID = &searchmsg.ResourceID{
+			StorageId: sid,
+			SpaceId:   spid,
+			OpaqueId:  oid,
+		}
+		rootName = space.GetRootInfo().GetPath()
+		permissions = space.GetRootInfo().GetPermissionSet()
+		remoteItemID = &searchmsg.ResourceID{
+			StorageId: space.GetRootInfo().GetId().GetStorageId(),
+			SpaceId:   space.GetRootInfo().GetId().GetSpaceId(),
+			OpaqueId:  space.GetRootInfo().GetId().GetOpaqueId(),
+		}
+		s.logger.Debug().Interface("grantSpace", space).Interface("mountpointRootId", mountpointRootID).Msg("searching a grant")
+	case _spaceTypePersonal, _spaceTypeProject:
+		permissions = space.GetRootInfo().GetPermissionSet()
+	}
+
+	return &spaceSearch{
+		ref: &searchmsg.Reference{
+			ResourceId: searchRootID,
+			Path:       searchPathPrefix,
+		},
+		mountpointPrefix: mountpointPrefix,
+		mountpointRootID: mountpointRootID,
+		rootName:         rootName,
+		remoteItemID:     remoteItemID,
+		permissions:      permissions,
+	}, nil
+}
+
+func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest, space *provider.StorageSpace, mountpointID string, omitFields []string) (*searchsvc.SearchIndexResponse, error) {
+	sp, err := s.resolveSpace(ctx, req.Ref, space, mountpointID)
+	if err != nil || sp == nil {
+		return nil, err
+	}
+
+	searchRequest := &searchsvc.SearchIndexRequest{
+		Query:         req.Query,
+		Ref:           sp.ref,
+		PageSize:      req.PageSize,
+		CountOnly:     req.CountOnly,
+		NoHighlight:   req.NoHighlight || s.highlightsDisabled || slices.Contains(omitFields, FieldContent),
+		OmitFields:    omitFields,
+		GroupByParent: req.GroupByParent,
+		IdsOnly:       req.IdsOnly,
+	}
+	start := time.Now()
+	res, err := searchWithTimeout(ctx, s.engine, searchRequest, s.searchTimeout)
+	duration := time.Since(start)
+	if err != nil {
+		s.logger.Error().Err(err).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Msg("failed to search the index")
+		return nil, err
+	}
+	s.logger.Debug().Interface("searchRequest", searchRequest).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("space search done")
+
+	matches := make([]*searchmsg.Match, 0, len(res.Matches))
+
 	for _, match := range res.Matches {
-		if mountpointPrefix != "" {
-			match.Entity.Ref.Path = utils.MakeRelativePath(strings.TrimPrefix(match.Entity.Ref.Path, mountpointPrefix))
+		if sp.mountpointPrefix != "" {
+			match.Entity.Ref.Path = utils.MakeRelativePath(strings.TrimPrefix(match.Entity.Ref.Path, sp.mountpointPrefix))
 		}
-		if mountpointRootID != nil {
-			match.Entity.Ref.ResourceId = mountpointRootID
+		if sp.mountpointRootID != nil {
+			match.Entity.Ref.ResourceId = sp.mountpointRootID
 		}
-		match.Entity.ShareRootName = rootName
-		match.Entity.RemoteItemId = remoteItemId
+		match.Entity.ShareRootName = sp.rootName
+		match.Entity.RemoteItemId = sp.remoteItemID
 
 		isShared := match.GetEntity().GetRef().GetResourceId().GetSpaceId() == utils.ShareStorageSpaceID
 		isMountpoint := isShared && match.GetEntity().GetRef().GetPath() == "."
 		isDir := match.GetEntity().GetMimeType() == "httpd/unix-directory"
-		match.Entity.Permissions = convertToWebDAVPermissions(isShared, isMountpoint, isDir, permissions)
+		match.Entity.Permissions = convertToWebDAVPermissions(isShared, isMountpoint, isDir, sp.permissions)
 
-		if req.Ref != nil && searchPathPrefix == "/"+match.Entity.Name {
+		if req.Ref != nil && sp.ref.GetPath() == "/"+match.Entity.Name {
 			continue
 		}
 
@@ -430,216 +936,1140 @@ func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest,
 
 	res.Matches = matches
 
-	return res, nil
-}
+	return res, nil
+}
+
+// GetDocument returns the indexed document of the given resource id.
+func (s *Service) GetDocument(id string) (*Resource, error) {
+	return s.engine.GetDocument(id)
+}
+
+// GetDocuments returns the indexed documents of the given resource ids in the order of the ids,
+// together with the ids which are not indexed.
+func (s *Service) GetDocuments(ids []string) ([]*Resource, []string, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+
+	resources, err := s.engine.GetDocuments(unique)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string]*Resource, len(resources))
+	for _, r := range resources {
+		found[r.ID] = r
+	}
+
+	documents := make([]*Resource, 0, len(resources))
+	var missing []string
+	for _, id := range unique {
+		if r, ok := found[id]; ok {
+			documents = append(documents, r)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return documents, missing, nil
+}
+
+// IndexAllSpaces (re)indexes all resources of all spaces.
+func (s *Service) IndexAllSpaces() error {
+	return s.indexAllSpaces(s.engine, false)
+}
+
+// RebuildIndex indexes all resources of all spaces into a new index which replaces the current one once done,
+// the searches are served by the current index in the meantime.
+func (s *Service) RebuildIndex() error {
+	rebuilder, ok := s.engine.(Rebuilder)
+	if !ok {
+		return errtypes.NotSupported("the search engine can not rebuild its index")
+	}
+
+	return rebuilder.Rebuild(func(engine Engine) error {
+		return s.indexAllSpaces(engine, true)
+	})
+}
+
+// ResetIndex deletes all documents by replacing the index with a new and empty one, it returns the number of
+// deleted documents. The watermarks are cleared, so the next indexing of each space indexes all of its resources.
+func (s *Service) ResetIndex() (uint64, error) {
+	resetter, ok := s.engine.(Resetter)
+	if !ok {
+		return 0, errtypes.NotSupported("the search engine can not reset its index")
+	}
+
+	count, err := resetter.ResetIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	if s.watermarks != nil {
+		if err := s.watermarks.Clear(); err != nil {
+			s.logger.Error().Err(err).Msg("failed to clear the index watermarks")
+		}
+	}
+
+	s.logger.Info().Uint64("documents", count).Msg("the search index has been reset")
+	return count, nil
+}
+
+// indexAllSpaces indexes all resources of all spaces into the given engine, see indexSpace.
+// Up to indexConcurrency spaces are indexed at the same time, a failing space does not stop the indexing
+// of the others, the errors of all failed spaces are returned.
+func (s *Service) indexAllSpaces(engine Engine, rebuild bool) error {
+	ownerCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+	if err != nil {
+		return err
+	}
+
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	resp, err := gatewayClient.ListStorageSpaces(ownerCtx, &provider.ListStorageSpacesRequest{})
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return errors.New(resp.GetStatus().GetMessage())
+	}
+
+	spaces := resp.GetStorageSpaces()
+	errs := make([]error, len(spaces))
+	var errg errgroup.Group
+	errg.SetLimit(s.indexConcurrency)
+	for i, space := range spaces {
+		errg.Go(func() error {
+			if err := s.indexSpace(engine, space.GetId(), rebuild); err != nil {
+				s.logger.Error().Err(err).Str("spaceID", space.GetId().GetOpaqueId()).Msg("failed to index the space")
+				errs[i] = fmt.Errorf("failed to index space %s: %w", space.GetId().GetOpaqueId(), err)
+			}
+			return nil
+		})
+	}
+	_ = errg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// IndexSpace (re)indexes all resources of a given space.
+// With incremental indexing only the resources modified after the last indexing of the space are reindexed,
+// resources which have been removed from the changed containers meanwhile are marked as deleted.
+func (s *Service) IndexSpace(spaceID *provider.StorageSpaceId) error {
+	return s.indexSpace(s.engine, spaceID, false)
+}
+
+// indexSpace indexes all resources of a given space into the given engine.
+// A rebuild indexes the whole space into a new index, it neither uses nor updates the watermarks of the current index.
+func (s *Service) indexSpace(engine Engine, spaceID *provider.StorageSpaceId, rebuild bool) error {
+	ownerCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+	if err != nil {
+		return err
+	}
+
+	rootID, err := storagespace.ParseID(spaceID.OpaqueId)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("invalid space id")
+		return err
+	}
+	if rootID.StorageId == "" || rootID.SpaceId == "" {
+		s.logger.Error().Err(err).Msg("invalid space id")
+		return fmt.Errorf("invalid space id")
+	}
+	rootID.OpaqueId = rootID.SpaceId
+	spaceName := s.spaceName(ownerCtx, &rootID)
+
+	// Collect metrics
+	startTime := time.Now()
+	success := false
+	defer func() {
+		if s.metrics == nil {
+			return
+		}
+		status := "success"
+		if !success {
+			status = "error"
+		}
+		s.metrics.IndexDuration.WithLabelValues(status).Observe(time.Since(startTime).Seconds())
+	}()
+
+	if bulkIndexer, ok := engine.(BulkIndexer); ok {
+		// the bulk indexing only speeds up the indexing, go on without it if it can not be started
+		if endBulkIndexing, err := bulkIndexer.StartBulkIndexing(); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to start bulk indexing")
+		} else {
+			// deferred first to end the bulk indexing after the last batch was pushed
+			defer func() {
+				if err := endBulkIndexing(); err != nil {
+					s.logger.Error().Err(err).Msg("failed to end bulk indexing")
+				}
+			}()
+		}
+	}
+
+	watermarks := s.watermarks
+	if rebuild {
+		watermarks = nil
+	}
+
+	var watermark, nextWatermark time.Time
+	if watermarks != nil {
+		if watermark, err = watermarks.Get(spaceID.GetOpaqueId()); err != nil {
+			s.logger.Warn().Err(err).Str("spaceID", spaceID.GetOpaqueId()).Msg("failed to get the index watermark, indexing the whole space")
+			watermark = time.Time{}
+		}
+	}
+	incremental := !watermark.IsZero()
+
+	if !incremental && !rebuild {
+		// deferred before the batch is pushed to apply the changes which arrived meanwhile after the last batch
+		defer s.reindexing.start(storagespace.FormatStorageID(rootID.StorageId, rootID.SpaceId))()
+	}
+
+	// the children of the containers which changed since the last indexing, by container id
+	changedContainers := map[string]map[string]struct{}{}
+
+	w := walker.NewWalker(s.indexingGatewaySelector)
+	batch, err := engine.NewBatch(s.batchSize)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := batch.Push(); err != nil {
+			s.logger.Error().Err(err).Msg("failed to end batch")
+		} else if success && watermarks != nil {
+			if err := watermarks.Set(spaceID.GetOpaqueId(), nextWatermark); err != nil {
+				s.logger.Error().Err(err).Str("spaceID", spaceID.GetOpaqueId()).Msg("failed to set the index watermark")
+			}
+		}
+		logDocCount(engine, s.logger)
+	}()
+	// the indexed documents are fetched for a whole batch at once instead of for every resource
+	upserts := &upsertQueue{s: s, batch: batch, size: max(s.batchSize, 1)}
+	upsert := func(ref *provider.Reference) {
+		if u, ok := s.prepareUpsert(ref, batch, &spaceName); ok {
+			upserts.add(u)
+		}
+	}
+	err = w.Walk(ownerCtx, &rootID, func(wd string, info *provider.ResourceInfo, err error) error {
+		var notFound errtypes.NotFound
+		switch {
+		case info != nil && errors.As(err, &notFound):
+			// the container was removed while the space was walked, its removal is indexed separately
+			s.logger.Debug().Err(err).Str("path", info.GetPath()).Msg("container was removed during the indexing. Skipping.")
+			return filepath.SkipDir
+		case err != nil:
+			s.logger.Error().Err(err).Msg("error walking the tree")
+			return err
+		}
+
+		if info == nil {
+			return nil
+		}
+
+		ref := &provider.Reference{
+			Path:       utils.MakeRelativePath(filepath.Join(wd, info.Path)),
+			ResourceId: &rootID,
+		}
+		s.logger.Debug().Str("path", ref.Path).Msg("Walking tree")
+
+		if !s.isIndexable(info) {
+			s.logger.Debug().Str("path", ref.Path).Str("type", info.GetType().String()).Msg("resource type is not indexed. Skipping.")
+			return nil
+		}
+
+		if s.isExcluded(ref.Path) {
+			s.logger.Debug().Str("path", ref.Path).Msg("resource is excluded from indexing. Skipping.")
+			s.removeExcluded(storagespace.FormatResourceID(info.GetId()), batch)
+			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		mtime := utils.TSToTime(info.Mtime)
+		if nextWatermark.IsZero() {
+			// the space root is walked first, its mtime covers all changes in the space
+			nextWatermark = mtime
+		}
+
+		if incremental {
+			if children, ok := changedContainers[storagespace.FormatResourceID(info.GetParentId())]; ok {
+				children[storagespace.FormatResourceID(info.GetId())] = struct{}{}
+			}
+
+			if !mtime.After(watermark) {
+				if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+					s.logger.Debug().Str("path", ref.Path).Msg("subtree hasn't changed since the last indexing. Skipping.")
+					return filepath.SkipDir
+				}
+				s.logger.Debug().Str("path", ref.Path).Msg("element hasn't changed since the last indexing. Skipping.")
+				return nil
+			}
+
+			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				changedContainers[storagespace.FormatResourceID(info.GetId())] = map[string]struct{}{}
+			}
+
+			upsert(ref)
+			return nil
+		}
+
+		searchRes, err := searchWithTimeout(ownerCtx, engine, &searchsvc.SearchIndexRequest{
+			Query: "id:" + storagespace.FormatResourceID(info.Id) + ` mtime>=` + mtime.Format(time.RFC3339Nano),
+		}, s.indexTimeout)
+
+		if err == nil && len(searchRes.Matches) >= 1 {
+			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				s.logger.Debug().Str("path", ref.Path).Msg("subtree hasn't changed. Skipping.")
+				return filepath.SkipDir
+			}
+			s.logger.Debug().Str("path", ref.Path).Msg("element hasn't changed. Skipping.")
+			return nil
+		}
+
+		upsert(ref)
+
+		return nil
+	})
+	upserts.flush()
+
+	if err != nil {
+		return err
+	}
+
+	for containerID, children := range changedContainers {
+		if err := s.deleteRemovedChildren(ownerCtx, batch, containerID, children); err != nil {
+			return err
+		}
+	}
+	success = true
+
+	return nil
+}
+
+// deleteRemovedChildren marks the indexed children of the container as deleted which are no longer part of it.
+func (s *Service) deleteRemovedChildren(ctx context.Context, batch BatchOperator, containerID string, children map[string]struct{}) error {
+	res, err := searchWithTimeout(ctx, s.engine, &searchsvc.SearchIndexRequest{
+		Query:    "parentid:" + containerID,
+		PageSize: -1,
+	}, s.indexTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to search the children of %s: %w", containerID, err)
+	}
+
+	for _, match := range res.GetMatches() {
+		id := storagespace.FormatResourceID(&provider.ResourceId{
+			StorageId: match.GetEntity().GetId().GetStorageId(),
+			SpaceId:   match.GetEntity().GetId().GetSpaceId(),
+			OpaqueId:  match.GetEntity().GetId().GetOpaqueId(),
+		})
+		if _, ok := children[id]; ok {
+			continue
+		}
+
+		s.logger.Debug().Str("id", id).Str("parentID", containerID).Msg("resource has been removed since the last indexing. Deleting.")
+		err := batch.Delete(id)
+		if errors.Is(err, ErrTooManyDescendants) {
+			// the space is indexed already, removing the resource and its descendants is all that is left to do
+			err = s.engine.Purge(id, false)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// TrashItem marks the item as deleted, unless the trash event is stale.
+func (s *Service) TrashItem(rID *provider.ResourceId, eventTime time.Time) {
+	s.applyChange(rID, func() {
+		s.trashItem(rID, eventTime)
+	})
+}
+
+func (s *Service) trashItem(rID *provider.ResourceId, eventTime time.Time) {
+	id := storagespace.FormatResourceID(rID)
+	if s.isStaleEvent(id, eventTime) {
+		return
+	}
+
+	err := s.engine.Delete(id)
+	switch {
+	case errors.Is(err, ErrTooManyDescendants):
+		s.reindexInstead(id, err)
+	case err != nil:
+		s.logger.Error().Err(err).Interface("Id", rID).Msg("failed to remove item from index")
+	default:
+		s.recordEvent(id, eventTime)
+	}
+}
+
+// PurgeItem removes the referenced item and its descendants from the index.
+func (s *Service) PurgeItem(ref *provider.Reference) {
+	s.applyChange(ref.GetResourceId(), func() {
+		s.purgeItem(ref)
+	})
+}
+
+func (s *Service) purgeItem(ref *provider.Reference) {
+	if ref.Path != "" && ref.Path != "." {
+		s.logger.Warn().Str("path", ref.Path).Msg("purging an item with a path is not supported")
+		return
+	}
+
+	err := s.engine.Purge(storagespace.FormatResourceID(ref.ResourceId), false)
+	if err != nil {
+		s.logger.Error().Err(err).Interface("Id", ref.ResourceId).Msg("failed to purge item from index")
+		return
+	}
+	s.logger.Info().Interface("Id", ref.ResourceId).Msg("purged item from index")
+	logDocCount(s.engine, s.logger)
+}
+
+// PurgeItems removes the referenced items and their descendants from the index at once.
+// The items of spaces which are indexed completely right now are purged one by one after the indexing.
+func (s *Service) PurgeItems(refs []*provider.Reference) error {
+	purge := make([]*provider.Reference, 0, len(refs))
+	for _, ref := range refs {
+		queued := s.reindexing.enqueue(spaceOf(ref.GetResourceId()), func() {
+			s.purgeItem(ref)
+		})
+		if !queued {
+			purge = append(purge, ref)
+		}
+	}
+
+	return s.purgeItems(purge)
+}
+
+func (s *Service) purgeItems(refs []*provider.Reference) error {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.GetPath() != "" && ref.GetPath() != "." {
+			s.logger.Warn().Str("path", ref.GetPath()).Msg("purging an item with a path is not supported")
+			continue
+		}
+
+		ids = append(ids, storagespace.FormatResourceID(ref.GetResourceId()))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.engine.PurgeMany(ids, false); err != nil {
+		s.logger.Error().Err(err).Strs("ids", ids).Msg("failed to purge items from index")
+		return err
+	}
+	s.logger.Info().Strs("ids", ids).Msg("purged items from index")
+	logDocCount(s.engine, s.logger)
+
+	return nil
+}
+
+func (s *Service) PurgeDeleted(spaceID *provider.StorageSpaceId) error {
+	if spaceID == nil {
+		return fmt.Errorf("spaceID must not be nil")
+	}
+
+	rootID, err := storagespace.ParseID(spaceID.OpaqueId)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("invalid space id")
+		return err
+	}
+	if rootID.StorageId == "" || rootID.SpaceId == "" {
+		s.logger.Error().Err(err).Msg("invalid space id")
+		return fmt.Errorf("invalid space id")
+	}
+	rootID.OpaqueId = rootID.SpaceId
+
+	if err := s.engine.Purge(storagespace.FormatResourceID(&rootID), true); err != nil {
+		s.logger.Error().Err(err).Interface("Id", &rootID).Msg("failed to purge deleted items from index")
+		return err
+	}
+
+	logDocCount(s.engine, s.logger)
+
+	return nil
+}
+
+// UpsertItem indexes or stores Resource data fields.
+func (s *Service) UpsertItem(ref *provider.Reference, eventTime time.Time) {
+	s.applyChange(ref.GetResourceId(), func() {
+		if id := s.doUpsertItem(ref); id != "" {
+			s.recordEvent(id, eventTime)
+		}
+	})
+}
+
+// doUpsertItem indexes or stores Resource data fields, the name of the space is looked up.
+// It returns the id of the resource, or an empty string if it is not indexed.
+func (s *Service) doUpsertItem(ref *provider.Reference) string {
+	u, ok := s.prepareUpsert(ref, nil, nil)
+	if !ok {
+		return ""
+	}
+
+	err := RetryOnConflict(func() error {
+		indexed, err := s.engine.GetDocument(u.r.ID)
+		if err != nil {
+			indexed = nil
+		}
+		return s.upsert(u, indexed, nil)
+	})
+	s.finishUpsert(u, err)
+	if err != nil {
+		return ""
+	}
+	return u.r.ID
+}
+
+// pendingUpsert is a resource which is upserted once the indexed document is known
+type pendingUpsert struct {
+	ctx          context.Context
+	ref          *provider.Reference
+	info         *provider.ResourceInfo
+	doc          content.Document
+	metadataOnly bool
+	r            Resource
+}
+
+// upsertQueue collects the resources of a space walk, the indexed documents of them are fetched at once
+// instead of one by one before they are added to the batch.
+type upsertQueue struct {
+	s       *Service
+	batch   BatchOperator
+	size    int
+	pending []*pendingUpsert
+}
+
+// add queues the resource and upserts the queued ones once the queue is full
+func (q *upsertQueue) add(u *pendingUpsert) {
+	q.pending = append(q.pending, u)
+	if len(q.pending) >= q.size {
+		q.flush()
+	}
+}
+
+// flush upserts the queued resources, the indexed documents are looked up one by one if they can't be fetched at once
+func (q *upsertQueue) flush() {
+	if len(q.pending) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(q.pending))
+	for _, u := range q.pending {
+		ids = append(ids, u.r.ID)
+	}
+	indexed, batchErr := q.s.engine.GetDocuments(ids)
+	if batchErr != nil {
+		q.s.logger.Warn().Err(batchErr).Int("count", len(ids)).Msg("failed to get the indexed documents at once, getting them one by one")
+	}
+	documents := make(map[string]*Resource, len(indexed))
+	for _, r := range indexed {
+		documents[r.ID] = r
+	}
+
+	for _, u := range q.pending {
+		document := documents[u.r.ID]
+		if batchErr != nil {
+			var err error
+			if document, err = q.s.engine.GetDocument(u.r.ID); err != nil {
+				document = nil
+			}
+		}
+		// the batch is pushed later, a conflict could not be retried with fresh data anymore
+		q.s.finishUpsert(u, q.s.upsert(u, document, q.batch))
+	}
+	q.pending = q.pending[:0]
+}
+
+// prepareUpsert stats the resource and extracts the document of it, false is returned if it is not indexed.
+// The name of the space is looked up if none is given.
+func (s *Service) prepareUpsert(ref *provider.Reference, batch BatchOperator, spaceName *string) (*pendingUpsert, bool) {
+	ctx, stat, path := s.resInfo(ref)
+	if ctx == nil || stat == nil || path == "" {
+		return nil, false
+	}
+
+	if !s.isIndexable(stat.GetInfo()) {
+		s.logger.Debug().Str("path", path).Str("type", stat.GetInfo().GetType().String()).Msg("resource type is not indexed")
+		return nil, false
+	}
+
+	if s.isExcluded(path) {
+		s.logger.Debug().Str("path", path).Msg("resource is excluded from indexing")
+		s.removeExcluded(storagespace.FormatResourceID(stat.GetInfo().GetId()), batch)
+		return nil, false
+	}
+
+	extractor := s.extractor
+	metadataOnly := s.isMetadataOnly(stat.GetInfo())
+	if metadataOnly {
+		s.logger.Debug().Str("path", path).Msg("content extraction disabled for resource, indexing metadata only")
+		extractor = s.metadataExtractor
+	}
+
+	doc, err := s.extract(ctx, extractor, stat.Info)
+	if errors.Is(err, errExtractionTimeout) {
+		// a malformed file must not stall the indexing, it is found by its metadata at least
+		s.logger.Warn().Err(err).Str("path", path).Msg("the content extraction timed out, indexing the metadata only")
+		metadataOnly = true
+		doc, err = s.metadataExtractor.Extract(ctx, stat.Info)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to extract resource content")
+		return nil, false
+	}
+	doc.Name = content.Sanitize(doc.Name)
+	doc.Content = content.Sanitize(doc.Content)
+	// the custom extractors don't know about the disabled fields
+	doc.OmitFields(s.disabledFields...)
+
+	r := Resource{
+		ID: storagespace.FormatResourceID(stat.Info.Id),
+		RootID: storagespace.FormatResourceID(&provider.ResourceId{
+			StorageId: stat.Info.Id.StorageId,
+			OpaqueId:  stat.Info.Id.SpaceId,
+			SpaceId:   stat.Info.Id.SpaceId,
+		}),
+		Path:     utils.MakeRelativePath(path),
+		Type:     uint64(stat.Info.Type),
+		Document: doc,
+	}
+	r.Hidden = strings.HasPrefix(r.Path, ".")
+	r.Extension = Extension(r.Name)
+	// pathological names would bloat the index and the results, the extension is still taken from the full name
+	r.Name = TruncateName(r.Name, s.maxNameLength)
+	r.HasPreview = s.hasPreview(stat.GetInfo())
+	if s.indexLocks {
+		setLock(&r, stat.GetInfo().GetLock())
+	}
+
+	r.Owner = stat.GetInfo().GetOwner().GetOpaqueId()
+	r.CreatedBy = utils.ReadPlainFromOpaque(stat.GetInfo().GetOpaque(), "creator")
+	r.Ctime = creationTime(stat.GetInfo())
+
+	if parentID := stat.GetInfo().GetParentId(); parentID != nil {
+		r.ParentID = storagespace.FormatResourceID(parentID)
+	}
+
+	if spaceName != nil {
+		r.SpaceName = *spaceName
+	} else {
+		r.SpaceName = s.spaceName(ctx, &provider.ResourceId{
+			StorageId: stat.GetInfo().GetId().GetStorageId(),
+			SpaceId:   stat.GetInfo().GetId().GetSpaceId(),
+			OpaqueId:  stat.GetInfo().GetId().GetSpaceId(),
+		})
+	}
+
+	if s.indexSharedWith {
+		r.SharedWith = s.sharedWith(ctx, stat.GetInfo().GetId())
+	}
+
+	if len(s.extendedAttributes) > 0 {
+		r.Attributes = ExtendedAttributes(stat.GetInfo(), s.extendedAttributes)
+	}
+
+	if s.classificationKey != "" {
+		r.Classification = strings.TrimSpace(stat.GetInfo().GetArbitraryMetadata().GetMetadata()[s.classificationKey])
+	}
+
+	return &pendingUpsert{
+		ctx:          ctx,
+		ref:          ref,
+		info:         stat.GetInfo(),
+		doc:          doc,
+		metadataOnly: metadataOnly,
+		r:            r,
+	}, true
+}
+
+// upsert adds the resource to the batch or the index, what has been added to the indexed document is kept.
+// The document is nil if the resource is not indexed yet.
+func (s *Service) upsert(u *pendingUpsert, indexed *Resource, batch BatchOperator) error {
+	r := u.r
+	// the metadata of external systems is not part of the storage, keep what has been added to the index
+	if indexed != nil {
+		r.Metadata = indexed.Metadata
+		r.LastEventTS = indexed.LastEventTS
+		r.SeqNo, r.PrimaryTerm = indexed.SeqNo, indexed.PrimaryTerm
+	}
+
+	if s.indexVersions > 0 && !u.metadataOnly && u.info.GetType() == provider.ResourceType_RESOURCE_TYPE_FILE {
+		r.Versions = s.versions(u.ctx, u.info, indexed)
+	}
+
+	if batch != nil {
+		return batch.Upsert(r.ID, r.WithoutSequence())
+	}
+	return s.engine.Upsert(r.ID, r)
+}
+
+// finishUpsert logs the result of the upsert and stores the extracted metadata in the storage
+func (s *Service) finishUpsert(u *pendingUpsert, err error) {
+	if err != nil {
+		s.logger.Error().Err(err).Msg("error adding updating the resource in the index")
+	} else {
+		logDocCount(s.engine, s.logger)
+	}
+
+	// determine if metadata needs to be stored in storage as well
+	metadata := map[string]string{}
+	addAudioMetadata(metadata, u.doc.Audio)
+	addImageMetadata(metadata, u.doc.Image)
+	addLocationMetadata(metadata, u.doc.Location)
+	addPhotoMetadata(metadata, u.doc.Photo)
+	if len(metadata) == 0 {
+		return
+	}
+
+	s.logger.Trace().Str("name", u.doc.Name).Interface("metadata", metadata).Msg("Storing metadata")
+
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not retrieve client to store metadata")
+		return
+	}
+
+	resp, err := gatewayClient.SetArbitraryMetadata(u.ctx, &provider.SetArbitraryMetadataRequest{
+		Ref: u.ref,
+		ArbitraryMetadata: &provider.ArbitraryMetadata{
+			Metadata: metadata,
+		},
+	})
+	if err != nil || resp.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Int32("status", int32(resp.GetStatus().GetCode())).Msg("error storing metadata")
+		return
+	}
+}
+
+// UpdateSharedWith updates the users and groups an indexed resource is shared with,
+// the other fields of the resource are kept as they are.
+func (s *Service) UpdateSharedWith(rID *provider.ResourceId) {
+	if !s.indexSharedWith {
+		return
+	}
+
+	s.applyChange(rID, func() {
+		s.updateSharedWith(rID)
+	})
+}
+
+func (s *Service) updateSharedWith(rID *provider.ResourceId) {
+	id := storagespace.FormatResourceID(rID)
+	r, err := s.engine.GetDocument(id)
+	if err != nil {
+		// resources which are not indexed yet get their shares with the indexing
+		s.logger.Debug().Err(err).Interface("resourceID", rID).Msg("failed to get the shared resource from the index")
+		return
+	}
+
+	ctx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+	if err != nil {
+		return
+	}
+
+	sharedWith := s.sharedWith(ctx, rID)
+	err = RetryOnConflict(func() error {
+		if r == nil {
+			// the resource was changed concurrently, the shares are applied to its current state
+			if r, err = s.engine.GetDocument(id); err != nil {
+				return err
+			}
+		}
+
+		r.SharedWith = sharedWith
+		err := s.engine.Upsert(r.ID, *r)
+		r = nil
+		return err
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Interface("resourceID", rID).Msg("failed to update the shares of the resource in the index")
+	}
+}
+
+// UpdateLock updates the lock of an indexed resource after it was locked or unlocked,
+// the other fields of the resource are kept as they are.
+func (s *Service) UpdateLock(ref *provider.Reference) {
+	if !s.indexLocks {
+		return
+	}
+
+	s.applyChange(ref.GetResourceId(), func() {
+		s.updateLock(ref)
+	})
+}
+
+func (s *Service) updateLock(ref *provider.Reference) {
+	ctx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
+	if err != nil {
+		return
+	}
+
+	stat, err := statResource(ctx, ref, s.indexingGatewaySelector, s.logger)
+	if err != nil || stat == nil {
+		return
+	}
+
+	id := storagespace.FormatResourceID(stat.GetInfo().GetId())
+	err = RetryOnConflict(func() error {
+		r, err := s.engine.GetDocument(id)
+		if err != nil {
+			return err
+		}
+
+		setLock(r, stat.GetInfo().GetLock())
+		return s.engine.Upsert(r.ID, *r)
+	})
+	switch err.(type) {
+	case nil:
+	case errtypes.NotFound:
+		// resources which are not indexed yet get their lock with the indexing
+		s.logger.Debug().Err(err).Str("resourceID", id).Msg("failed to get the locked resource from the index")
+	default:
+		s.logger.Error().Err(err).Str("resourceID", id).Msg("failed to update the lock of the resource in the index")
+	}
+}
+
+// creationTime returns the creation time the storage reports as 'ctime' in the opaque of the resource info,
+// formatted as RFC3339 in UTC like the modification time. It is empty if the storage does not report a valid time.
+func creationTime(ri *provider.ResourceInfo) string {
+	ctime, err := time.Parse(time.RFC3339Nano, utils.ReadPlainFromOpaque(ri.GetOpaque(), "ctime"))
+	if err != nil {
+		return ""
+	}
+
+	return ctime.UTC().Format(time.RFC3339Nano)
+}
+
+// setLock sets the lock fields of the resource to the given lock, the resource is unlocked if there is none
+// or if it has expired already.
+func setLock(r *Resource, lock *provider.Lock) {
+	r.Locked, r.LockedBy, r.LockExpiration = false, "", ""
+	if lock == nil {
+		return
+	}
+
+	if expiration := lock.GetExpiration(); expiration != nil {
+		expiresAt := utils.TSToTime(expiration)
+		if !expiresAt.After(time.Now()) {
+			return
+		}
+		r.LockExpiration = expiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	r.Locked = true
+	r.LockedBy = lock.GetUser().GetOpaqueId()
+}
+
+// UpdateMetadata applies the metadata patch of an external system to an indexed resource,
+// the other fields of the resource are kept as they are. See PatchMetadata for the patch semantics.
+func (s *Service) UpdateMetadata(id string, patch map[string]string) error {
+	if _, err := storagespace.ParseID(id); err != nil {
+		return errtypes.BadRequest(fmt.Sprintf("invalid resource id '%s'", id))
+	}
+
+	return RetryOnConflict(func() error {
+		r, err := s.engine.GetDocument(id)
+		if err != nil {
+			return err
+		}
+
+		r.Metadata, err = PatchMetadata(r.Metadata, patch)
+		if err != nil {
+			return errtypes.BadRequest(err.Error())
+		}
 
-// IndexSpace (re)indexes all resources of a given space.
-func (s *Service) IndexSpace(spaceID *provider.StorageSpaceId) error {
-	ownerCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
-	if err != nil {
+		err = s.engine.Upsert(r.ID, *r)
+		if err != nil && !errors.Is(err, ErrConflict) {
+			s.logger.Error().Err(err).Str("resourceID", id).Msg("failed to update the metadata of the resource in the index")
+		}
 		return err
-	}
+	})
+}
 
-	rootID, err := storagespace.ParseID(spaceID.OpaqueId)
+// UpdateSpaceName sets the new name of a renamed space on its indexed resources.
+// Trashed resources keep the old name.
+func (s *Service) UpdateSpaceName(spaceID *provider.StorageSpaceId, name string) error {
+	rootID, err := storagespace.ParseID(spaceID.GetOpaqueId())
 	if err != nil {
-		s.logger.Error().Err(err).Msg("invalid space id")
 		return err
 	}
-	if rootID.StorageId == "" || rootID.SpaceId == "" {
-		s.logger.Error().Err(err).Msg("invalid space id")
-		return fmt.Errorf("invalid space id")
-	}
 	rootID.OpaqueId = rootID.SpaceId
+	rID := storagespace.FormatResourceID(&rootID)
 
-	// Collect metrics
-	startTime := time.Now()
-	success := false
-	defer func() {
-		if s.metrics == nil {
-			return
-		}
-		status := "success"
-		if !success {
-			status = "error"
+	queued := s.reindexing.enqueue(spaceOf(&rootID), func() {
+		if err := s.updateSpaceName(rID, name); err != nil {
+			s.logger.Error().Err(err).Str("spaceID", rID).Msg("failed to update the name of the space in the index")
 		}
-		s.metrics.IndexDuration.WithLabelValues(status).Observe(time.Since(startTime).Seconds())
-	}()
+	})
+	if queued {
+		return nil
+	}
 
-	w := walker.NewWalker(s.gatewaySelector)
-	batch, err := s.engine.NewBatch(s.batchSize)
+	return s.updateSpaceName(rID, name)
+}
+
+func (s *Service) updateSpaceName(rID, name string) error {
+	res, err := searchWithTimeout(context.Background(), s.engine, &searchsvc.SearchIndexRequest{
+		Query:    "rootid:" + rID,
+		PageSize: -1,
+	}, s.indexTimeout)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to search the resources of space %s: %w", rID, err)
 	}
-	defer func() {
-		if err := batch.Push(); err != nil {
-			s.logger.Error().Err(err).Msg("failed to end batch")
-		}
-		logDocCount(s.engine, s.logger)
-	}()
-	err = w.Walk(ownerCtx, &rootID, func(wd string, info *provider.ResourceInfo, err error) error {
+
+	// the resources which got the new name already are skipped when the renaming is retried after a conflict
+	return RetryOnConflict(func() error {
+		batch, err := s.engine.NewBatch(s.batchSize)
 		if err != nil {
-			s.logger.Error().Err(err).Msg("error walking the tree")
 			return err
 		}
 
-		if info == nil {
-			return nil
-		}
-
-		ref := &provider.Reference{
-			Path:       utils.MakeRelativePath(filepath.Join(wd, info.Path)),
-			ResourceId: &rootID,
-		}
-		s.logger.Debug().Str("path", ref.Path).Msg("Walking tree")
-
-		searchRes, err := s.engine.Search(ownerCtx, &searchsvc.SearchIndexRequest{
-			Query: "id:" + storagespace.FormatResourceID(info.Id) + ` mtime>=` + utils.TSToTime(info.Mtime).Format(time.RFC3339Nano),
-		})
+		for _, match := range res.GetMatches() {
+			id := storagespace.FormatResourceID(&provider.ResourceId{
+				StorageId: match.GetEntity().GetId().GetStorageId(),
+				SpaceId:   match.GetEntity().GetId().GetSpaceId(),
+				OpaqueId:  match.GetEntity().GetId().GetOpaqueId(),
+			})
+
+			r, err := s.engine.GetDocument(id)
+			if err != nil {
+				s.logger.Error().Err(err).Str("resourceID", id).Msg("failed to get the resource of the renamed space")
+				continue
+			}
+			if r.SpaceName == name {
+				continue
+			}
 
-		if err == nil && len(searchRes.Matches) >= 1 {
-			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
-				s.logger.Debug().Str("path", ref.Path).Msg("subtree hasn't changed. Skipping.")
-				return filepath.SkipDir
+			r.SpaceName = name
+			if err := batch.Upsert(r.ID, *r); err != nil {
+				return fmt.Errorf("failed to update the space name of %s: %w", id, err)
 			}
-			s.logger.Debug().Str("path", ref.Path).Msg("element hasn't changed. Skipping.")
-			return nil
 		}
 
-		s.doUpsertItem(ref, batch)
-
-		return nil
+		return batch.Push()
 	})
+}
 
+// spaceName returns the name of the space with the given root, it is empty if the space can not be listed.
+func (s *Service) spaceName(ctx context.Context, rootID *provider.ResourceId) string {
+	gatewayClient, err := s.gatewaySelector.Next()
 	if err != nil {
-		return err
+		s.logger.Error().Err(err).Msg("could not retrieve client to list the space")
+		return ""
 	}
-	success = true
-
-	return nil
-}
 
-// TrashItem marks the item as deleted.
-func (s *Service) TrashItem(rID *provider.ResourceId) {
-	if err := s.engine.Delete(storagespace.FormatResourceID(rID)); err != nil {
-		s.logger.Error().Err(err).Interface("Id", rID).Msg("failed to remove item from index")
+	res, err := gatewayClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Filters: []*provider.ListStorageSpacesRequest_Filter{{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+			Term: &provider.ListStorageSpacesRequest_Filter_Id{Id: &provider.StorageSpaceId{OpaqueId: storagespace.FormatResourceID(rootID)}},
+		}},
+	})
+	if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK || len(res.GetStorageSpaces()) == 0 {
+		s.logger.Error().Err(err).Int32("status", int32(res.GetStatus().GetCode())).Interface("rootID", rootID).Msg("failed to list the space of the resource")
+		return ""
 	}
+
+	return res.GetStorageSpaces()[0].GetName()
 }
 
-func (s *Service) PurgeItem(ref *provider.Reference) {
-	if ref.Path != "" && ref.Path != "." {
-		s.logger.Warn().Str("path", ref.Path).Msg("purging an item with a path is not supported")
-		return
+// sharedWith returns the ids of the users and groups the resource is shared with.
+// Failing lookups are logged only, the resource is indexed without shares then.
+func (s *Service) sharedWith(ctx context.Context, rID *provider.ResourceId) []string {
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not retrieve client to list the shares")
+		return nil
 	}
 
-	err := s.engine.Purge(storagespace.FormatResourceID(ref.ResourceId), false)
-	if err != nil {
-		s.logger.Error().Err(err).Interface("Id", ref.ResourceId).Msg("failed to purge item from index")
-		return
+	res, err := gatewayClient.ListShares(ctx, &collaborationv1beta1.ListSharesRequest{
+		Filters: []*collaborationv1beta1.Filter{share.ResourceIDFilter(rID)},
+	})
+	if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Int32("status", int32(res.GetStatus().GetCode())).Interface("resourceID", rID).Msg("failed to list the shares of the resource")
+		return nil
 	}
-	s.logger.Info().Interface("Id", ref.ResourceId).Msg("purged item from index")
-	logDocCount(s.engine, s.logger)
-}
 
-func (s *Service) PurgeDeleted(spaceID *provider.StorageSpaceId) error {
-	if spaceID == nil {
-		return fmt.Errorf("spaceID must not be nil")
+	var ids []string
+	for _, sh := range res.GetShares() {
+		switch {
+		case sh.GetGrantee().GetUserId() != nil:
+			ids = append(ids, sh.GetGrantee().GetUserId().GetOpaqueId())
+		case sh.GetGrantee().GetGroupId() != nil:
+			ids = append(ids, sh.GetGrantee().GetGroupId().GetOpaqueId())
+		}
 	}
 
-	rootID, err := storagespace.ParseID(spaceID.OpaqueId)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// versions extracts the content of the latest previous versions of the file. Versions never change,
+// the content of versions which are already indexed is taken from the index.
+func (s *Service) versions(ctx context.Context, ri *provider.ResourceInfo, indexed *Resource) []Version {
+	gatewayClient, err := s.gatewaySelector.Next()
 	if err != nil {
-		s.logger.Error().Err(err).Msg("invalid space id")
-		return err
+		s.logger.Error().Err(err).Msg("could not retrieve client to list the versions")
+		return nil
 	}
-	if rootID.StorageId == "" || rootID.SpaceId == "" {
-		s.logger.Error().Err(err).Msg("invalid space id")
-		return fmt.Errorf("invalid space id")
+
+	res, err := gatewayClient.ListFileVersions(ctx, &provider.ListFileVersionsRequest{
+		Ref: &provider.Reference{ResourceId: ri.GetId()},
+	})
+	if err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Str("status", res.GetStatus().GetMessage()).Str("id", storagespace.FormatResourceID(ri.GetId())).Msg("failed to list the versions")
+		return nil
 	}
-	rootID.OpaqueId = rootID.SpaceId
 
-	if err := s.engine.Purge(storagespace.FormatResourceID(&rootID), true); err != nil {
-		s.logger.Error().Err(err).Interface("Id", &rootID).Msg("failed to purge deleted items from index")
-		return err
+	fileVersions := res.GetVersions()
+	slices.SortFunc(fileVersions, func(a, b *provider.FileVersion) int {
+		return cmp.Compare(b.GetMtime(), a.GetMtime())
+	})
+	fileVersions = fileVersions[:min(len(fileVersions), s.indexVersions)]
+
+	extracted := make(map[string]string)
+	if indexed != nil {
+		for _, v := range indexed.Versions {
+			extracted[v.Key] = v.Content
+		}
 	}
 
-	logDocCount(s.engine, s.logger)
+	versions := make([]Version, 0, len(fileVersions))
+	for _, fv := range fileVersions {
+		versionContent, ok := extracted[fv.GetKey()]
+		if !ok {
+			// the versions are downloaded by using the version key as opaque id of the resource
+			vi := proto.Clone(ri).(*provider.ResourceInfo)
+			vi.Id.OpaqueId = fv.GetKey()
+			vi.Size = fv.GetSize()
+			vi.Etag = fv.GetEtag()
+			vi.Mtime = &types.Timestamp{Seconds: fv.GetMtime()}
+
+			doc, err := s.extract(ctx, s.extractor, vi)
+			if err != nil {
+				s.logger.Error().Err(err).Str("key", fv.GetKey()).Msg("failed to extract the version content")
+				continue
+			}
+			versionContent = content.Sanitize(doc.Content)
+		}
 
-	return nil
+		if versionContent == "" {
+			continue
+		}
+
+		versions = append(versions, Version{
+			Key:     fv.GetKey(),
+			Mtime:   time.Unix(int64(fv.GetMtime()), 0).UTC().Format(time.RFC3339),
+			Content: versionContent,
+		})
+	}
+
+	return versions
 }
 
-// UpsertItem indexes or stores Resource data fields.
-func (s *Service) UpsertItem(ref *provider.Reference) {
-	s.doUpsertItem(ref, nil)
+// isIndexable reports whether resources of the given type are indexed. Files and folders are always indexed,
+// symlinks and references unless skipped by the configuration. Invalid and internal resources are never indexed.
+func (s *Service) isIndexable(ri *provider.ResourceInfo) bool {
+	switch ri.GetType() {
+	case provider.ResourceType_RESOURCE_TYPE_FILE, provider.ResourceType_RESOURCE_TYPE_CONTAINER:
+		return true
+	case provider.ResourceType_RESOURCE_TYPE_SYMLINK:
+		return !s.skipSymlinks
+	case provider.ResourceType_RESOURCE_TYPE_REFERENCE:
+		return !s.skipReferences
+	default:
+		return false
+	}
 }
 
-// doUpsertItem indexes or stores Resource data fields.
-func (s *Service) doUpsertItem(ref *provider.Reference, batch BatchOperator) {
-	ctx, stat, path := s.resInfo(ref)
-	if ctx == nil || stat == nil || path == "" {
-		return
+// isExcluded reports whether the resource at the given path relative to the space root or one of its parents
+// matches an excluded path pattern. Patterns without a slash are matched against the names, patterns with a
+// slash against the paths.
+func (s *Service) isExcluded(p string) bool {
+	if len(s.excludedPaths) == 0 {
+		return false
 	}
 
-	doc, err := s.extractor.Extract(ctx, stat.Info)
-	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to extract resource content")
-		return
+	p = path.Clean(p)
+	if p == "." {
+		return false
 	}
 
-	r := Resource{
-		ID: storagespace.FormatResourceID(stat.Info.Id),
-		RootID: storagespace.FormatResourceID(&provider.ResourceId{
-			StorageId: stat.Info.Id.StorageId,
-			OpaqueId:  stat.Info.Id.SpaceId,
-			SpaceId:   stat.Info.Id.SpaceId,
-		}),
-		Path:     utils.MakeRelativePath(path),
-		Type:     uint64(stat.Info.Type),
-		Document: doc,
+	elems := strings.Split(p, "/")
+	for i, name := range elems {
+		for _, pattern := range s.excludedPaths {
+			target := name
+			if strings.Contains(pattern, "/") {
+				target = strings.Join(elems[:i+1], "/")
+			}
+			if ok, _ := path.Match(pattern, target); ok {
+				return true
+			}
+		}
 	}
-	r.Hidden = strings.HasPrefix(r.Path, ".")
 
-	if parentID := stat.GetInfo().GetParentId(); parentID != nil {
-		r.ParentID = storagespace.FormatResourceID(parentID)
+	return false
+}
+
+// removeExcluded removes an excluded resource and its descendants from the index,
+// they are still indexed if they have been indexed before the exclusion was configured.
+func (s *Service) removeExcluded(id string, batch BatchOperator) {
+	if _, err := s.engine.GetDocument(id); err != nil {
+		return
 	}
 
+	var err error
 	if batch != nil {
-		err = batch.Upsert(r.ID, r)
+		err = batch.Purge(id, false)
 	} else {
-		err = s.engine.Upsert(r.ID, r)
+		err = s.engine.Purge(id, false)
 	}
 	if err != nil {
-		s.logger.Error().Err(err).Msg("error adding updating the resource in the index")
-	} else {
-		logDocCount(s.engine, s.logger)
+		s.logger.Error().Err(err).Str("resourceID", id).Msg("failed to remove the excluded resource from the index")
 	}
+}
 
-	// determine if metadata needs to be stored in storage as well
-	metadata := map[string]string{}
-	addAudioMetadata(metadata, doc.Audio)
-	addImageMetadata(metadata, doc.Image)
-	addLocationMetadata(metadata, doc.Location)
-	addPhotoMetadata(metadata, doc.Photo)
-	if len(metadata) == 0 {
-		return
+// isMetadataOnly reports whether the content extraction is disabled for the given resource,
+// either because its space or its mime type is configured to be indexed without content.
+func (s *Service) isMetadataOnly(ri *provider.ResourceInfo) bool {
+	if _, ok := s.metadataOnlySpaces[ri.GetId().GetSpaceId()]; ok {
+		return true
+	}
+
+	for _, pattern := range s.metadataOnlyMimeTypes {
+		if ok, _ := path.Match(pattern, ri.GetMimeType()); ok {
+			return true
+		}
 	}
 
-	s.logger.Trace().Str("name", doc.Name).Interface("metadata", metadata).Msg("Storing metadata")
+	return false
+}
 
-	gatewayClient, err := s.gatewaySelector.Next()
-	if err != nil {
-		s.logger.Error().Err(err).Msg("could not retrieve client to store metadata")
-		return
+// hasPreview reports whether the thumbnails service renders a preview of the resource
+func (s *Service) hasPreview(ri *provider.ResourceInfo) bool {
+	if ri.GetType() != provider.ResourceType_RESOURCE_TYPE_FILE {
+		return false
 	}
 
-	resp, err := gatewayClient.SetArbitraryMetadata(ctx, &provider.SetArbitraryMetadataRequest{
-		Ref: ref,
-		ArbitraryMetadata: &provider.ArbitraryMetadata{
-			Metadata: metadata,
-		},
-	})
-	if err != nil || resp.GetStatus().GetCode() != rpc.Code_CODE_OK {
-		s.logger.Error().Err(err).Int32("status", int32(resp.GetStatus().GetCode())).Msg("error storing metadata")
-		return
+	for _, pattern := range s.previewMimeTypes {
+		if ok, _ := path.Match(pattern, ri.GetMimeType()); ok {
+			return true
+		}
 	}
+
+	return false
 }
 
 func addAudioMetadata(metadata map[string]string, audio *libregraph.Audio) {
@@ -708,26 +2138,204 @@ func valueToString(value interface{}) string {
 }
 
 // RestoreItem makes the item available again.
-func (s *Service) RestoreItem(ref *provider.Reference) {
+func (s *Service) RestoreItem(ref *provider.Reference, eventTime time.Time) {
+	s.applyChange(ref.GetResourceId(), func() {
+		s.restoreItem(ref, eventTime)
+	})
+}
+
+func (s *Service) restoreItem(ref *provider.Reference, eventTime time.Time) {
 	ctx, stat, path := s.resInfo(ref)
 	if ctx == nil || stat == nil || path == "" {
 		return
 	}
 
-	if err := s.engine.Restore(storagespace.FormatResourceID(stat.Info.Id)); err != nil {
+	id := storagespace.FormatResourceID(stat.Info.Id)
+	err := s.engine.Restore(id)
+	var notFound errtypes.NotFound
+	switch {
+	case errors.Is(err, ErrTooManyDescendants), errors.As(err, &notFound):
+		// the descendants of a resource which is not indexed are not indexed either, e.g. after its deletion fell back to the reindex
+		s.reindexInstead(id, err)
+	case err != nil:
 		s.logger.Error().Err(err).Msg("failed to restore the changed resource in the index")
+	default:
+		s.recordEvent(id, eventTime)
 	}
 }
 
-// MoveItem updates the resource location and all of its necessary fields.
-func (s *Service) MoveItem(ref *provider.Reference) {
+// MoveItem updates the resource location and all of its necessary fields, unless the move event is stale.
+func (s *Service) MoveItem(ref *provider.Reference, eventTime time.Time) {
+	s.applyChange(ref.GetResourceId(), func() {
+		s.moveItem(ref, eventTime)
+	})
+}
+
+func (s *Service) moveItem(ref *provider.Reference, eventTime time.Time) {
 	ctx, stat, path := s.resInfo(ref)
 	if ctx == nil || stat == nil || path == "" {
 		return
 	}
 
-	if err := s.engine.Move(storagespace.FormatResourceID(stat.GetInfo().GetId()), storagespace.FormatResourceID(stat.GetInfo().GetParentId()), path); err != nil {
+	id := storagespace.FormatResourceID(stat.GetInfo().GetId())
+	if s.isStaleEvent(id, eventTime) {
+		return
+	}
+
+	if s.isExcluded(path) {
+		s.logger.Debug().Str("path", path).Msg("resource has been moved to an excluded path")
+		s.removeExcluded(id, nil)
+		return
+	}
+
+	err := s.engine.Move(id, storagespace.FormatResourceID(stat.GetInfo().GetParentId()), path)
+	var notFound errtypes.NotFound
+	switch {
+	case errors.As(err, &notFound):
+		// storages which assign new ids on moves across spaces, index the resource at its new location
+		s.logger.Debug().Str("id", id).Msg("moved resource is not indexed under its id, indexing it")
+		s.doUpsertItem(ref)
+		s.recordEvent(id, eventTime)
+	case errors.Is(err, ErrTooManyDescendants):
+		s.reindexInstead(id, err)
+	case err != nil:
 		s.logger.Error().Err(err).Msg("failed to move the changed resource in the index")
+	default:
+		if TruncateName(stat.GetInfo().GetName(), s.maxNameLength) != stat.GetInfo().GetName() {
+			// the engines take the new name from the path as it is, index the resource again to truncate it
+			s.doUpsertItem(ref)
+		}
+		s.recordEvent(id, eventTime)
+	}
+}
+
+// reindexInstead is the fallback for changes the engine can not apply to the descendants of a resource,
+// like moving a folder with more descendants than the engine updates. The resource and its descendants are removed
+// from the index and the space is indexed completely, which indexes the ones that still exist at their current location.
+func (s *Service) reindexInstead(id string, cause error) {
+	s.logger.Warn().Err(cause).Str("id", id).Msg("failed to update the descendants of the resource, indexing its space completely instead")
+
+	var notFound errtypes.NotFound
+	if err := s.engine.Purge(id, false); err != nil && !errors.As(err, &notFound) {
+		s.logger.Error().Err(err).Str("id", id).Msg("failed to remove the resource and its descendants from the index")
+		return
+	}
+
+	rID, err := storagespace.ParseID(id)
+	if err != nil {
+		s.logger.Error().Err(err).Str("id", id).Msg("invalid resource id")
+		return
+	}
+	spaceID := &provider.StorageSpaceId{OpaqueId: storagespace.FormatStorageID(rID.GetStorageId(), rID.GetSpaceId())}
+
+	// an incremental indexing would skip the folders which did not change since the last indexing
+	if s.watermarks != nil {
+		if err := s.watermarks.Set(spaceID.GetOpaqueId(), time.Time{}); err != nil {
+			s.logger.Warn().Err(err).Str("spaceID", spaceID.GetOpaqueId()).Msg("failed to reset the index watermark")
+		}
+	}
+
+	if err := s.IndexSpace(spaceID); err != nil {
+		s.logger.Error().Err(err).Str("spaceID", spaceID.GetOpaqueId()).Msg("failed to index the space")
+	}
+}
+
+// AfterQueuedChanges calls f once the changes of the space which are queued while the space is indexed completely
+// are applied, right away if there are none.
+func (s *Service) AfterQueuedChanges(spaceID *provider.StorageSpaceId, f func()) {
+	rID, err := storagespace.ParseID(spaceID.GetOpaqueId())
+	if err != nil || !s.reindexing.after(spaceOf(&rID), f) {
+		f()
+	}
+}
+
+// applyChange applies the change of a resource right away, or after the indexing if its space is indexed completely
+// right now. The indexing might have read the resource before the change and would write its old state again.
+func (s *Service) applyChange(rID *provider.ResourceId, change func()) {
+	if !s.reindexing.enqueue(spaceOf(rID), change) {
+		change()
+	}
+}
+
+// extract extracts the resource with the extractor and gives up once the extraction timeout is exceeded.
+// Extractors which don't stop on the cancellation of the context keep running in the background until they return.
+func (s *Service) extract(ctx context.Context, extractor content.Extractor, ri *provider.ResourceInfo) (content.Document, error) {
+	if s.extractionTimeout <= 0 {
+		return extractor.Extract(ctx, ri)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.extractionTimeout)
+	defer cancel()
+
+	type result struct {
+		doc content.Document
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		doc, err := extractor.Extract(ctx, ri)
+		done <- result{doc: doc, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil || ctx.Err() == nil {
+			return r.doc, r.err
+		}
+	case <-ctx.Done():
+	}
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return content.Document{}, ctx.Err()
+	}
+	if s.metrics != nil {
+		s.metrics.ExtractionTimeouts.Inc()
+	}
+	return content.Document{}, fmt.Errorf("%w after %s", errExtractionTimeout, s.extractionTimeout)
+}
+
+// isStaleEvent reports whether an event which is newer than the event has been applied to the indexed resource,
+// taking the grace period into account. Events without a time and resources which are not indexed are never stale.
+func (s *Service) isStaleEvent(id string, eventTime time.Time) bool {
+	if !s.skipStaleEvents || eventTime.IsZero() {
+		return false
+	}
+
+	r, err := s.engine.GetDocument(id)
+	if err != nil {
+		return false
+	}
+
+	lastEvent, err := time.Parse(time.RFC3339Nano, r.LastEventTS)
+	if err != nil || lastEvent.Sub(eventTime) <= s.staleEventGracePeriod {
+		return false
+	}
+
+	s.logger.Info().Str("id", id).Time("eventTime", eventTime).Time("lastEventTime", lastEvent).Msg("the resource has been changed after the event, skipping the stale event")
+	return true
+}
+
+// recordEvent stores the time of the event which has been applied to the indexed resource, events older than it are stale.
+// The time is only stored if stale events are skipped.
+func (s *Service) recordEvent(id string, eventTime time.Time) {
+	if !s.skipStaleEvents || eventTime.IsZero() {
+		return
+	}
+
+	err := RetryOnConflict(func() error {
+		r, err := s.engine.GetDocument(id)
+		if err != nil {
+			return err
+		}
+		if lastEvent, err := time.Parse(time.RFC3339Nano, r.LastEventTS); err == nil && !eventTime.After(lastEvent) {
+			return nil
+		}
+
+		r.LastEventTS = eventTime.UTC().Format(time.RFC3339Nano)
+		return s.engine.Upsert(r.ID, *r)
+	})
+	if err != nil {
+		s.logger.Warn().Err(err).Str("id", id).Msg("failed to store the time of the event in the index")
 	}
 }
 
@@ -737,7 +2345,7 @@ func (s *Service) resInfo(ref *provider.Reference) (context.Context, *provider.S
 		return nil, nil, ""
 	}
 
-	statRes, err := statResource(ownerCtx, ref, s.gatewaySelector, s.logger)
+	statRes, err := statResource(ownerCtx, ref, s.indexingGatewaySelector, s.logger)
 	if err != nil {
 		return nil, nil, ""
 	}
@@ -1,28 +1,51 @@
 package search_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	bleveSearch "github.com/blevesearch/bleve/v2"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpcv1beta1 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaborationv1beta1 "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	libregraph "github.com/opencloud-eu/libre-graph-api-go"
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config/defaults"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
 	contentMocks "github.com/opencloud-eu/opencloud/services/search/pkg/content/mocks"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
 )
@@ -116,7 +139,281 @@ var _ = Describe("Searchprovider", func() {
 		})
 	})
 
+	Describe("GetDocuments", func() {
+		It("returns the indexed documents in the requested order and the missing ids", func() {
+			indexClient.On("GetDocuments", []string{"1$2!3", "1$2!unknown", "1$2!4"}).Return([]*search.Resource{{ID: "1$2!4"}, {ID: "1$2!3"}}, nil)
+
+			resources, missing, err := s.GetDocuments([]string{"1$2!3", "1$2!unknown", "1$2!4", "1$2!3"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+			Expect(resources[0].ID).To(Equal("1$2!3"))
+			Expect(resources[1].ID).To(Equal("1$2!4"))
+			Expect(missing).To(Equal([]string{"1$2!unknown"}))
+		})
+	})
+
+	Describe("GetDocument", func() {
+		It("returns the indexed document", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!opaqueid").Return(&search.Resource{ID: "storageid$spaceid!opaqueid", Hidden: true}, nil)
+
+			resource, err := s.GetDocument("storageid$spaceid!opaqueid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource.ID).To(Equal("storageid$spaceid!opaqueid"))
+			Expect(resource.Hidden).To(BeTrue())
+		})
+
+		It("passes the not found error of the engine", func() {
+			indexClient.On("GetDocument", "unknown").Return(nil, errtypes.NotFound("document with id unknown not found"))
+
+			_, err := s.GetDocument("unknown")
+			Expect(err).To(MatchError(errtypes.NotFound("document with id unknown not found")))
+		})
+	})
+
+	Describe("RebuildIndex", func() {
+		It("fails if the engine can not rebuild its index", func() {
+			Expect(s.RebuildIndex()).To(BeAssignableToTypeOf(errtypes.NotSupported("")))
+		})
+
+		It("indexes all spaces into the rebuilt index regardless of the watermarks", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			infos := map[string]*sprovider.ResourceInfo{
+				".": {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./file.txt": {
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "file"},
+					ParentId: rootID,
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     "file.txt",
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 1000},
+				},
+			}
+
+			var upserted []string
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				upserted = append(upserted, args.String(0))
+			}).Return(nil)
+			target := &engineMocks.Engine{}
+			target.On("DocCount").Return(uint64(0), nil)
+			target.On("NewBatch", mock.Anything).Return(batch, nil)
+			target.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			indexClient.On("GetDocuments", mock.Anything).Return([]*search.Resource{}, nil)
+
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status: status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{{
+					Id:   &sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"},
+					Root: rootID,
+					Name: "Marketing",
+				}},
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./file.txt"]},
+			}, nil)
+
+			// the space has not changed since the last indexing of the current index
+			watermarks := search.NewMemoryWatermarkStore()
+			Expect(watermarks.Set("storageid$spaceid!spaceid", time.Unix(5000, 0))).To(Succeed())
+
+			svc := search.NewService(gatewaySelector, &rebuildingEngine{Engine: indexClient, target: target}, extractor, nil, logger, &config.Config{})
+			svc.SetWatermarkStore(watermarks)
+
+			Expect(svc.RebuildIndex()).To(Succeed())
+			Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!file"))
+			indexClient.AssertNotCalled(GinkgoT(), "NewBatch", mock.Anything)
+			Expect(watermarks.Get("storageid$spaceid!spaceid")).To(Equal(time.Unix(5000, 0)))
+		})
+	})
+
+	Describe("IndexAllSpaces", func() {
+		It("indexes the spaces concurrently up to the configured limit and isolates failing spaces", func() {
+			var spaces []*sprovider.StorageSpace
+			for i := 1; i <= 6; i++ {
+				spaceID := fmt.Sprintf("space%d", i)
+				spaces = append(spaces, &sprovider.StorageSpace{
+					Id:   &sprovider.StorageSpaceId{OpaqueId: "storageid$" + spaceID + "!" + spaceID},
+					Root: &sprovider.ResourceId{StorageId: "storageid", SpaceId: spaceID, OpaqueId: spaceID},
+				})
+			}
+			spaces = append(spaces, &sprovider.StorageSpace{Id: &sprovider.StorageSpaceId{OpaqueId: "invalid"}})
+
+			var (
+				mu       sync.Mutex
+				active   int
+				maxCount int
+				upserted []string
+			)
+			batch := &engineMocks.BatchOperator{}
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				mu.Lock()
+				defer mu.Unlock()
+				upserted = append(upserted, args.String(0))
+			}).Return(nil)
+			batch.On("Push").Run(func(mock.Arguments) {
+				mu.Lock()
+				defer mu.Unlock()
+				active--
+			}).Return(nil)
+			indexClient.On("NewBatch", mock.Anything).Run(func(mock.Arguments) {
+				mu.Lock()
+				active++
+				maxCount = max(maxCount, active)
+				mu.Unlock()
+				// keep the space busy, so the other workers start in the meantime
+				time.Sleep(20 * time.Millisecond)
+			}).Return(batch, nil)
+			indexClient.On("DocCount").Return(uint64(0), nil)
+			indexClient.On("GetDocuments", mock.Anything).Return([]*search.Resource{}, nil)
+			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(context.Background()),
+				StorageSpaces: spaces,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info: &sprovider.ResourceInfo{
+						Id:    req.GetRef().GetResourceId(),
+						Type:  sprovider.ResourceType_RESOURCE_TYPE_CONTAINER,
+						Path:  ".",
+						Mtime: &typesv1beta1.Timestamp{Seconds: 1000},
+					},
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+			}, nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{IndexConcurrency: 2})
+			err := s.IndexAllSpaces()
+
+			Expect(err).To(MatchError(ContainSubstring("failed to index space invalid")))
+			Expect(upserted).To(ConsistOf(
+				"storageid$space1!space1", "storageid$space2!space2", "storageid$space3!space3",
+				"storageid$space4!space4", "storageid$space5!space5", "storageid$space6!space6",
+			))
+			Expect(maxCount).To(Equal(2))
+		})
+
+		It("catches up the engine which was not connected yet once it is connected", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			fileID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "fileid"}
+			infos := map[string]*sprovider.ResourceInfo{
+				".":      {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./file": {Id: fileID, ParentId: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_FILE, Path: "file", Name: "file", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+			}
+			newBleveEngine := func() search.Engine {
+				mapping, err := bleve.NewMapping()
+				Expect(err).ToNot(HaveOccurred())
+				idx, err := bleveSearch.NewMemOnly(mapping)
+				Expect(err).ToNot(HaveOccurred())
+				return bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			}
+
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status: status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{
+					{Id: &sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}, Root: rootID},
+				},
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./file"]},
+			}, nil)
+
+			var (
+				reachable  atomic.Bool
+				caughtUp   = make(chan error, 1)
+				s          *search.Service
+				primary    = newBleveEngine()
+				fallback   = newBleveEngine()
+				connecting = search.NewConnectingEngine(func() (search.Engine, error) {
+					if !reachable.Load() {
+						return nil, errors.New("cluster is not healthy")
+					}
+					return primary, nil
+				}, 10*time.Millisecond, func() {
+					caughtUp <- s.IndexAllSpaces()
+				}, log.NopLogger())
+			)
+			s = search.NewService(gatewaySelector, search.NewFallbackEngine(connecting, fallback, nil, log.NopLogger()), extractor, nil, logger, &config.Config{BatchSize: 10})
+
+			// the file is only indexed by the fallback engine while the engine is not connected
+			Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+			_, err := fallback.GetDocument("storageid$spaceid!fileid")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = primary.GetDocument("storageid$spaceid!fileid")
+			Expect(err).To(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+			reachable.Store(true)
+			go connecting.Run(ctx)
+
+			Eventually(caughtUp, "2s").Should(Receive(BeNil()))
+			r, err := primary.GetDocument("storageid$spaceid!fileid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.ID).To(Equal("storageid$spaceid!fileid"))
+		})
+	})
+
+	Describe("ResetIndex", func() {
+		It("fails if the engine can not reset its index", func() {
+			_, err := s.ResetIndex()
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotSupported("")))
+		})
+
+		It("resets the index and clears the watermarks", func() {
+			watermarks := search.NewMemoryWatermarkStore()
+			Expect(watermarks.Set("storageid$spaceid!spaceid", time.Unix(5000, 0))).To(Succeed())
+
+			svc := search.NewService(gatewaySelector, &resettingEngine{Engine: indexClient, count: 3}, extractor, nil, logger, &config.Config{})
+			svc.SetWatermarkStore(watermarks)
+
+			Expect(svc.ResetIndex()).To(Equal(uint64(3)))
+			Expect(watermarks.Get("storageid$spaceid!spaceid")).To(BeZero())
+		})
+	})
+
 	Describe("IndexSpace", func() {
+		BeforeEach(func() {
+			indexClient.On("GetDocument", mock.Anything).Return(nil, errtypes.NotFound("not indexed")).Maybe()
+			indexClient.On("GetDocuments", mock.Anything).Return([]*search.Resource{}, nil).Maybe()
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{{Name: "Marketing"}},
+			}, nil).Maybe()
+		})
+
 		It("walks the space and indexes all files", func() {
 			batch := &engineMocks.BatchOperator{}
 			batch.EXPECT().Push().Return(nil)
@@ -136,75 +433,1979 @@ var _ = Describe("Searchprovider", func() {
 			err := s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})
 			Expect(err).ShouldNot(HaveOccurred())
 		})
-	})
 
-	Describe("Search", func() {
-		It("fails when an empty query is given", func() {
-			res, err := s.Search(ctx, &searchsvc.SearchRequest{
-				Query: "",
+		It("skips the resource types excluded by the policy", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			child := func(opaqueID string, resourceType sprovider.ResourceType) *sprovider.ResourceInfo {
+				return &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+					ParentId: rootID,
+					Type:     resourceType,
+					Path:     opaqueID,
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 1000},
+				}
+			}
+			infos := map[string]*sprovider.ResourceInfo{
+				".":       {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./file":  child("file", sprovider.ResourceType_RESOURCE_TYPE_FILE),
+				"./link":  child("link", sprovider.ResourceType_RESOURCE_TYPE_SYMLINK),
+				"./share": child("share", sprovider.ResourceType_RESOURCE_TYPE_REFERENCE),
+			}
+
+			var upserted []string
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				upserted = append(upserted, args.String(0))
+			}).Return(nil)
+			indexClient.On("NewBatch", mock.Anything).Return(batch, nil)
+			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./file"], infos["./link"], infos["./share"]},
+			}, nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+				ResourceTypes: config.ResourceTypes{SkipSymlinks: true},
+			})
+			Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+			Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!file", "storageid$spaceid!share"))
+		})
+
+		It("fetches the indexed documents in batches and looks up the space name once", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			child := func(opaqueID string) *sprovider.ResourceInfo {
+				return &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+					ParentId: rootID,
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     opaqueID,
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 1000},
+				}
+			}
+			infos := map[string]*sprovider.ResourceInfo{
+				".":   {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./a": child("a"),
+				"./b": child("b"),
+				"./c": child("c"),
+			}
+
+			upserted := map[string]search.Resource{}
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				upserted[args.String(0)] = args.Get(1).(search.Resource)
+			}).Return(nil)
+			eng := &engineMocks.Engine{}
+			eng.On("DocCount").Return(uint64(0), nil)
+			eng.On("NewBatch", mock.Anything).Return(batch, nil)
+			eng.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			eng.On("GetDocuments", []string{"storageid$spaceid!spaceid", "storageid$spaceid!a", "storageid$spaceid!b"}).Return([]*search.Resource{
+				{ID: "storageid$spaceid!a", Metadata: []string{"classification=internal"}},
+			}, nil).Once()
+			eng.On("GetDocuments", []string{"storageid$spaceid!c"}).Return([]*search.Resource{}, nil).Once()
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./a"], infos["./b"], infos["./c"]},
+			}, nil)
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{BatchSize: 3})
+			Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+
+			Expect(upserted).To(HaveLen(4))
+			Expect(upserted["storageid$spaceid!a"].Metadata).To(Equal([]string{"classification=internal"}))
+			Expect(upserted["storageid$spaceid!c"].SpaceName).To(Equal("Marketing"))
+			eng.AssertNumberOfCalls(GinkgoT(), "GetDocuments", 2)
+			eng.AssertNotCalled(GinkgoT(), "GetDocument", mock.Anything)
+			gatewayClient.AssertNumberOfCalls(GinkgoT(), "ListStorageSpaces", 1)
+		})
+
+		It("gets the indexed documents one by one if they can't be fetched at once", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			child := func(opaqueID string) *sprovider.ResourceInfo {
+				return &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+					ParentId: rootID,
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     opaqueID,
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 1000},
+				}
+			}
+			infos := map[string]*sprovider.ResourceInfo{
+				".":   {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./a": child("a"),
+				"./b": child("b"),
+			}
+
+			upserted := map[string]search.Resource{}
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				upserted[args.String(0)] = args.Get(1).(search.Resource)
+			}).Return(nil)
+			eng := &engineMocks.Engine{}
+			eng.On("DocCount").Return(uint64(0), nil)
+			eng.On("NewBatch", mock.Anything).Return(batch, nil)
+			eng.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			eng.On("GetDocuments", mock.Anything).Return(nil, errors.New("unavailable"))
+			eng.On("GetDocument", mock.Anything).Return(func(id string) (*search.Resource, error) {
+				return &search.Resource{ID: id, Metadata: []string{"classification=internal"}, LastEventTS: "2024-01-01T00:00:00Z", SeqNo: 7, PrimaryTerm: 1}, nil
+			})
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./a"], infos["./b"]},
+			}, nil)
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{BatchSize: 3})
+			Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+
+			Expect(upserted).To(HaveLen(3))
+			for id, r := range upserted {
+				Expect(r.Metadata).To(Equal([]string{"classification=internal"}), id)
+				Expect(r.LastEventTS).To(Equal("2024-01-01T00:00:00Z"), id)
+			}
+			eng.AssertNumberOfCalls(GinkgoT(), "GetDocument", 3)
+		})
+
+		DescribeTable("handles the changes which arrive while the space is indexed",
+			func(duringReindex string, maxQueued int, deleted bool) {
+				rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+				fileID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "fileid"}
+				infos := map[string]*sprovider.ResourceInfo{
+					".":      {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+					"./file": {Id: fileID, ParentId: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_FILE, Path: "file", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				}
+
+				mapping, err := bleve.NewMapping()
+				Expect(err).ToNot(HaveOccurred())
+				idx, err := bleveSearch.NewMemOnly(mapping)
+				Expect(err).ToNot(HaveOccurred())
+				eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+				s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{
+					BatchSize: 10,
+					Events:    config.Events{DuringReindex: duringReindex, MaxQueuedDuringReindex: maxQueued},
+				})
+
+				// the file is trashed after the indexing read it, but before the indexing wrote it, after a change of another resource
+				trashWhileIndexing := false
+				var applied atomic.Bool
+				extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(context.Background()),
+					User:   user,
+				}, nil)
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+					if req.GetRef().GetPath() == "./file" && trashWhileIndexing {
+						trashWhileIndexing = false
+						s.TrashItem(&sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "otherid"}, time.Now())
+						s.TrashItem(fileID, time.Now())
+						s.AfterQueuedChanges(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid"}, func() {
+							applied.Store(true)
+						})
+						Expect(applied.Load()).To(Equal(duringReindex == search.EventsDuringReindexApply))
+					}
+					return &sprovider.StatResponse{
+						Status: status.NewOK(context.Background()),
+						Info:   infos[req.GetRef().GetPath()],
+					}, nil
+				})
+				gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(func(_ context.Context, _ *sprovider.ListContainerRequest, _ ...grpc.CallOption) (*sprovider.ListContainerResponse, error) {
+					return &sprovider.ListContainerResponse{
+						Status: status.NewOK(context.Background()),
+						Infos:  []*sprovider.ResourceInfo{infos["./file"]},
+					}, nil
+				})
+
+				Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+
+				infos["./file"].Mtime = &typesv1beta1.Timestamp{Seconds: 2000}
+				trashWhileIndexing = true
+				Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+				Expect(trashWhileIndexing).To(BeFalse())
+
+				// the queued changes are applied in the background
+				Eventually(applied.Load).Should(BeTrue())
+				r, err := eng.GetDocument("storageid$spaceid!fileid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(r.Deleted).To(Equal(deleted))
+			},
+			Entry("queues them until the indexing is done", search.EventsDuringReindexQueue, 0, true),
+			Entry("applies them right away once the queue is full, the indexing overwrites them", search.EventsDuringReindexQueue, 1, false),
+			Entry("applies them right away, the indexing overwrites them", search.EventsDuringReindexApply, 0, false),
+		)
+
+		Context("with a flaky gateway", func() {
+			var (
+				rootID   *sprovider.ResourceId
+				infos    map[string]*sprovider.ResourceInfo
+				upserted []string
+			)
+
+			BeforeEach(func() {
+				rootID = &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+				infos = map[string]*sprovider.ResourceInfo{
+					".":      {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+					"./file": {Id: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "fileid"}, ParentId: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_FILE, Path: "file", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				}
+
+				upserted = nil
+				batch := &engineMocks.BatchOperator{}
+				batch.EXPECT().Push().Return(nil)
+				batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					upserted = append(upserted, args.String(0))
+				}).Return(nil)
+				indexClient.On("NewBatch", mock.Anything).Return(batch, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+				extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(context.Background()),
+					User:   user,
+				}, nil)
+			})
+
+			stat := func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			}
+
+			It("retries the transient errors and indexes the space", func() {
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+					Status: &rpcv1beta1.Status{Code: rpcv1beta1.Code_CODE_UNAVAILABLE},
+				}, nil).Once()
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(stat)
+				gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(nil, grpcstatus.Error(codes.Unavailable, "connection refused")).Twice()
+				gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+					Status: status.NewOK(context.Background()),
+					Infos:  []*sprovider.ResourceInfo{infos["./file"]},
+				}, nil)
+
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+					BatchSize:      10,
+					GatewayRetries: config.GatewayRetries{Attempts: 2, Backoff: time.Millisecond},
+				})
+				Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+				Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!fileid"))
+				gatewayClient.AssertNumberOfCalls(GinkgoT(), "ListContainer", 3)
+			})
+
+			It("does not retry the permanent errors", func() {
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+					Status: status.NewPermissionDenied(context.Background(), nil, "denied"),
+				}, nil)
+
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+					BatchSize:      10,
+					GatewayRetries: config.GatewayRetries{Attempts: 2, Backoff: time.Millisecond},
+				})
+				Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).ToNot(Succeed())
+				gatewayClient.AssertNumberOfCalls(GinkgoT(), "Stat", 1)
+			})
+
+			It("skips the containers which are removed during the indexing", func() {
+				infos["./folder"] = &sprovider.ResourceInfo{Id: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "folderid"}, ParentId: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: "folder", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}}
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(stat)
+				gatewayClient.On("ListContainer", mock.Anything, mock.MatchedBy(func(req *sprovider.ListContainerRequest) bool {
+					return req.GetRef().GetResourceId().GetOpaqueId() == "folderid"
+				})).Return(&sprovider.ListContainerResponse{
+					Status: status.NewNotFound(context.Background(), "removed"),
+				}, nil)
+				gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+					Status: status.NewOK(context.Background()),
+					Infos:  []*sprovider.ResourceInfo{infos["./folder"], infos["./file"]},
+				}, nil)
+
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{BatchSize: 10})
+				Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+				Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!fileid"))
+			})
+		})
+
+		It("skips the excluded resources and removes them from the index", func() {
+			rootID := &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+			child := func(opaqueID, name string) *sprovider.ResourceInfo {
+				return &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+					ParentId: rootID,
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     name,
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 1000},
+				}
+			}
+			infos := map[string]*sprovider.ResourceInfo{
+				".":            {Id: rootID, Type: sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: ".", Mtime: &typesv1beta1.Timestamp{Seconds: 1000}},
+				"./report.pdf": child("report", "report.pdf"),
+				"./.DS_Store":  child("dsstore", ".DS_Store"),
+				"./draft.tmp":  child("draft", "draft.tmp"),
+			}
+
+			var upserted, purged []string
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				upserted = append(upserted, args.String(0))
+			}).Return(nil)
+			batch.On("Purge", mock.Anything, false).Run(func(args mock.Arguments) {
+				purged = append(purged, args.String(0))
+			}).Return(nil)
+
+			// the .DS_Store file has been indexed before it was excluded
+			eng := &engineMocks.Engine{}
+			eng.On("DocCount").Return(uint64(1), nil)
+			eng.On("NewBatch", mock.Anything).Return(batch, nil)
+			eng.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			eng.On("GetDocument", "storageid$spaceid!dsstore").Return(&search.Resource{ID: "storageid$spaceid!dsstore"}, nil)
+			eng.On("GetDocument", mock.Anything).Return(nil, errtypes.NotFound("not indexed"))
+			eng.On("GetDocuments", mock.Anything).Return([]*search.Resource{}, nil)
+
+			extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				return &sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   infos[req.GetRef().GetPath()],
+				}, nil
+			})
+			gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&sprovider.ListContainerResponse{
+				Status: status.NewOK(context.Background()),
+				Infos:  []*sprovider.ResourceInfo{infos["./report.pdf"], infos["./.DS_Store"], infos["./draft.tmp"]},
+			}, nil)
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{
+				ExcludedPaths: []string{".DS_Store", "*.tmp"},
+			})
+			Expect(s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})).To(Succeed())
+			Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!report"))
+			Expect(purged).To(ConsistOf("storageid$spaceid!dsstore"))
+		})
+
+		Context("with a bulk indexing engine", func() {
+			var (
+				eng *bulkIndexingEngine
+				s   search.Searcher
+			)
+
+			BeforeEach(func() {
+				eng = &bulkIndexingEngine{Engine: indexClient}
+				s = search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{})
+
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(context.Background()),
+					User:   user,
+				}, nil)
+			})
+
+			It("ends the bulk indexing after the last batch was pushed", func() {
+				batch := &engineMocks.BatchOperator{}
+				batch.EXPECT().Push().Run(func() { eng.events = append(eng.events, "push") }).Return(nil)
+				batch.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+				extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+				indexClient.On("NewBatch", mock.Anything).Return(batch, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   ri,
+				}, nil)
+
+				err := s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(eng.events).To(Equal([]string{"start", "push", "end"}))
+			})
+
+			It("ends the bulk indexing if the indexing fails", func() {
+				indexClient.On("NewBatch", mock.Anything).Return(nil, errors.New("no batch"))
+
+				err := s.IndexSpace(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"})
+				Expect(err).To(HaveOccurred())
+				Expect(eng.events).To(Equal([]string{"start", "end"}))
+			})
+		})
+
+		Context("with incremental indexing", func() {
+			var (
+				svc      *search.Service
+				batch    *engineMocks.BatchOperator
+				upserted []string
+				indexed  []*searchmsg.Match
+				infos    map[string]*sprovider.ResourceInfo
+				rootID   = &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}
+				spaceID  = &sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid!spaceid"}
+			)
+
+			file := func(opaqueID string, mtime uint64) *sprovider.ResourceInfo {
+				return &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+					ParentId: rootID,
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     opaqueID + ".txt",
+					Mtime:    &typesv1beta1.Timestamp{Seconds: mtime},
+				}
+			}
+			match := func(opaqueID string) *searchmsg.Match {
+				return &searchmsg.Match{Entity: &searchmsg.Entity{
+					Id: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: opaqueID},
+				}}
+			}
+
+			BeforeEach(func() {
+				svc = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{})
+				svc.SetWatermarkStore(search.NewMemoryWatermarkStore())
+
+				upserted = nil
+				indexed = []*searchmsg.Match{match("a"), match("b")}
+				infos = map[string]*sprovider.ResourceInfo{
+					".": {
+						Id:    rootID,
+						Type:  sprovider.ResourceType_RESOURCE_TYPE_CONTAINER,
+						Path:  ".",
+						Mtime: &typesv1beta1.Timestamp{Seconds: 2000},
+					},
+					"./a.txt": file("a", 1000),
+					"./b.txt": file("b", 2000),
+				}
+
+				batch = &engineMocks.BatchOperator{}
+				batch.EXPECT().Push().Return(nil)
+				batch.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					upserted = append(upserted, args.String(0))
+				}).Return(nil)
+				indexClient.On("NewBatch", mock.Anything).Return(batch, nil)
+				indexClient.On("Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "parentid:storageid$spaceid!spaceid"
+				})).Return(func(context.Context, *searchsvc.SearchIndexRequest) (*searchsvc.SearchIndexResponse, error) {
+					return &searchsvc.SearchIndexResponse{Matches: indexed}, nil
+				})
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+				extractor.On("Extract", mock.Anything, mock.Anything, mock.Anything).Return(content.Document{}, nil)
+
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(context.Background()),
+					User:   user,
+				}, nil)
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(_ context.Context, req *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+					return &sprovider.StatResponse{
+						Status: status.NewOK(context.Background()),
+						Info:   infos[req.GetRef().GetPath()],
+					}, nil
+				})
+				gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(func(context.Context, *sprovider.ListContainerRequest, ...grpc.CallOption) (*sprovider.ListContainerResponse, error) {
+					var children []*sprovider.ResourceInfo
+					for _, p := range []string{"./a.txt", "./b.txt", "./c.txt"} {
+						if info, ok := infos[p]; ok {
+							children = append(children, info)
+						}
+					}
+					return &sprovider.ListContainerResponse{
+						Status: status.NewOK(context.Background()),
+						Infos:  children,
+					}, nil
+				})
+
+				Expect(svc.IndexSpace(spaceID)).To(Succeed())
+				Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!a", "storageid$spaceid!b"))
+				upserted = nil
+			})
+
+			It("only reindexes the resources changed since the last indexing", func() {
+				infos["."].Mtime = &typesv1beta1.Timestamp{Seconds: 3000}
+				infos["./b.txt"] = file("b", 3000)
+
+				Expect(svc.IndexSpace(spaceID)).To(Succeed())
+				Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!b"))
+				batch.AssertNotCalled(GinkgoT(), "Delete", mock.Anything)
+			})
+
+			It("skips the space if nothing changed since the last indexing", func() {
+				Expect(svc.IndexSpace(spaceID)).To(Succeed())
+				Expect(upserted).To(BeEmpty())
+			})
+
+			It("deletes the resources removed since the last indexing", func() {
+				batch.On("Delete", mock.Anything).Return(nil)
+				infos["."].Mtime = &typesv1beta1.Timestamp{Seconds: 3000}
+				delete(infos, "./b.txt")
+				infos["./c.txt"] = file("c", 3000)
+
+				Expect(svc.IndexSpace(spaceID)).To(Succeed())
+				Expect(upserted).To(ConsistOf("storageid$spaceid!spaceid", "storageid$spaceid!c"))
+				batch.AssertCalled(GinkgoT(), "Delete", "storageid$spaceid!b")
+				batch.AssertNumberOfCalls(GinkgoT(), "Delete", 1)
+			})
+		})
+	})
+
+	Describe("UpsertItem", func() {
+		var (
+			ref = &sprovider.Reference{
+				ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"},
+				Path:       "./movie.mp4",
+			}
+			movie = &sprovider.ResourceInfo{
+				Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "movieid"},
+				Name:     "movie.mp4",
+				MimeType: "video/mp4",
+				Size:     12345,
+				Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+				Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+				Owner:    &userv1beta1.UserId{OpaqueId: "ownerid"},
+				Opaque:   utils.AppendPlainToOpaque(utils.AppendPlainToOpaque(nil, "creator", "creatorid"), "ctime", "2024-01-10T10:00:00.5+01:00"),
+			}
+		)
+
+		BeforeEach(func() {
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+				Status: status.NewOK(context.Background()),
+				Info:   movie,
+			}, nil)
+			indexClient.On("GetDocument", mock.Anything).Return(nil, errtypes.NotFound("not indexed")).Maybe()
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{{Name: "Marketing"}},
+			}, nil).Maybe()
+		})
+
+		It("extracts the content", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4", Content: "credits"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			extractor.AssertNumberOfCalls(GinkgoT(), "Extract", 1)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Content == "credits"
+			}))
+		})
+
+		It("sanitizes the name and the content", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{})
+
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{
+				Name:    "movie\x00.mp4",
+				Content: "opening\xff\xfecredits\x1b[0m roll",
+			}, nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			r, err := eng.GetDocument("storageid$spaceid!movieid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Name).To(Equal("movie .mp4"))
+			Expect(r.Content).To(Equal("opening credits [0m roll"))
+
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "Content:credits"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetMatches()).To(HaveLen(1))
+			Expect(res.GetMatches()[0].GetEntity().GetHighlights()).To(Equal("opening <mark>credits</mark> [0m roll"))
+		})
+
+		It("truncates pathological names, which are still found by their prefix", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{MaxNameLength: 255})
+
+			name := "budget" + strings.Repeat("a", 3990) + ".mp4"
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: name}, nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			r, err := eng.GetDocument("storageid$spaceid!movieid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Name).To(HaveLen(255 - 1 + len(search.TruncatedNameIndicator)))
+			Expect(r.Name).To(HavePrefix("budgetaaaa"))
+			Expect(r.Name).To(HaveSuffix(search.TruncatedNameIndicator))
+			Expect(r.Extension).To(Equal("mp4"))
+
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "name:budget*"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetMatches()).To(HaveLen(1))
+			Expect(res.GetMatches()[0].GetEntity().GetName()).To(Equal(r.Name))
+		})
+
+		It("keeps long names without a maximum name length", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, defaults.DefaultConfig())
+
+			name := "budget" + strings.Repeat("a", 300) + ".mp4"
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: name}, nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			r, err := eng.GetDocument("storageid$spaceid!movieid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Name).To(Equal(name))
+		})
+
+		It("indexes the content of the extractor registered for the mime type", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			registry := content.NewRegistry(extractor)
+			Expect(registry.Register("video/*", content.ExtractorFunc(func(_ context.Context, ri *sprovider.ResourceInfo) (content.Document, error) {
+				return content.Document{Name: ri.GetName(), MimeType: ri.GetMimeType(), Content: "subtitles of the movie"}, nil
+			}))).To(Succeed())
+			s := search.NewService(gatewaySelector, eng, registry, nil, logger, &config.Config{})
+
+			s.UpsertItem(ref, time.Time{})
+
+			extractor.AssertNotCalled(GinkgoT(), "Extract", mock.Anything, mock.Anything)
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "Content:subtitles"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetMatches()).To(HaveLen(1))
+			Expect(res.GetMatches()[0].GetEntity().GetName()).To(Equal("movie.mp4"))
+			Expect(res.GetMatches()[0].GetEntity().GetMimeType()).To(Equal("video/mp4"))
+		})
+
+		It("indexes the metadata only if the content extraction times out", func() {
+			release := make(chan struct{})
+			defer close(release)
+			extractor.On("Extract", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+				<-release // the extractor hangs on a malformed file
+			}).Return(content.Document{Name: "movie.mp4", Content: "credits"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			m := metrics.New()
+			timeouts := func() float64 {
+				metric := &dto.Metric{}
+				Expect(m.ExtractionTimeouts.Write(metric)).To(Succeed())
+				return metric.GetCounter().GetValue()
+			}
+			before := timeouts()
+
+			cfg := &config.Config{}
+			cfg.Extractor.Timeout = 50 * time.Millisecond
+			s := search.NewService(gatewaySelector, indexClient, extractor, m, logger, cfg)
+
+			done := make(chan struct{})
+			go func() {
+				s.UpsertItem(ref, time.Time{})
+				close(done)
+			}()
+			Eventually(done, "2s").Should(BeClosed())
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Name == "movie.mp4" && r.Size == 12345 && r.Content == ""
+			}))
+			Expect(timeouts()).To(Equal(before + 1))
+		})
+
+		It("marks the resources the thumbnails service renders previews for", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{PreviewMimeTypes: []string{"image/*"}})
+			DeferCleanup(func() { movie.MimeType = "video/mp4" })
+
+			hasPreview := func(mimeType string) bool {
+				movie.MimeType = mimeType
+				extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4", MimeType: mimeType}, nil).Once()
+				s.UpsertItem(ref, time.Time{})
+
+				res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "Name:movie.mp4"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.GetMatches()).To(HaveLen(1))
+				return res.GetMatches()[0].GetEntity().GetHasPreview()
+			}
+
+			Expect(hasPreview("image/png")).To(BeTrue())
+			Expect(hasPreview("text/plain")).To(BeFalse())
+		})
+
+		It("omits the disabled fields", func() {
+			doc := content.Document{
+				Name:     "movie.mp4",
+				Image:    &libregraph.Image{Width: libregraph.PtrInt32(1920), Height: libregraph.PtrInt32(1080)},
+				Location: &libregraph.GeoCoordinates{Latitude: libregraph.PtrFloat64(49.48675890884328), Longitude: libregraph.PtrFloat64(11.103870357204285)},
+			}
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(doc, nil)
+			gatewayClient.On("SetArbitraryMetadata", mock.Anything, mock.Anything).Return(&sprovider.SetArbitraryMetadataResponse{
+				Status: status.NewOK(context.Background()),
+			}, nil)
+			var indexed []search.Resource
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				indexed = append(indexed, args.Get(1).(search.Resource))
+			}).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+			cfg := &config.Config{}
+			cfg.Extractor.DisabledFields = []string{"location"}
+			search.NewService(gatewaySelector, indexClient, extractor, nil, logger, cfg).UpsertItem(ref, time.Time{})
+
+			Expect(indexed).To(HaveLen(2))
+			Expect(indexed[0].Location).ToNot(BeNil())
+			Expect(indexed[1].Location).To(BeNil())
+			Expect(indexed[1].Image).To(Equal(doc.Image))
+
+			complete, err := json.Marshal(indexed[0])
+			Expect(err).ToNot(HaveOccurred())
+			reduced, err := json.Marshal(indexed[1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(reduced)).To(BeNumerically("<", len(complete)))
+		})
+
+		It("indexes the owner and the creator", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Owner == "ownerid" && r.CreatedBy == "creatorid"
+			}))
+		})
+
+		It("does not index the owner as creator if the storage does not report one", func() {
+			opaque := movie.Opaque
+			DeferCleanup(func() { movie.Opaque = opaque })
+			movie.Opaque = nil
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Owner == "ownerid" && r.CreatedBy == ""
+			}))
+		})
+
+		It("indexes the creation time the storage reports", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4", Mtime: "2024-06-01T09:00:00Z"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Ctime == "2024-01-10T09:00:00.5Z" && r.Mtime == "2024-06-01T09:00:00Z"
+			}))
+		})
+
+		It("indexes the users and groups the resource is shared with", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+			gatewayClient.On("ListShares", mock.Anything, mock.MatchedBy(func(req *collaborationv1beta1.ListSharesRequest) bool {
+				return req.GetFilters()[0].GetResourceId().GetOpaqueId() == "movieid"
+			})).Return(&collaborationv1beta1.ListSharesResponse{
+				Status: status.NewOK(ctx),
+				Shares: []*collaborationv1beta1.Share{
+					{Grantee: &sprovider.Grantee{Id: &sprovider.Grantee_UserId{UserId: &userv1beta1.UserId{OpaqueId: "user"}}}},
+					{Grantee: &sprovider.Grantee{Id: &sprovider.Grantee_GroupId{GroupId: &grouppb.GroupId{OpaqueId: "group"}}}},
+				},
+			}, nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{IndexSharedWith: true})
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return slices.Equal(r.SharedWith, []string{"group", "user"})
+			}))
+		})
+
+		It("indexes the name of the space", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.SpaceName == "Marketing"
+			}))
+		})
+
+		It("indexes the configured extended attributes", func() {
+			movie.ArbitraryMetadata = &sprovider.ArbitraryMetadata{Metadata: map[string]string{
+				"libre.graph.video.codec":  "H264",
+				"libre.graph.video.width":  "1920",
+				"libre.graph.video.height": "1080",
+				"tags":                     "holiday",
+			}}
+			DeferCleanup(func() { movie.ArbitraryMetadata = nil })
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+				ExtendedAttributes: []string{"video.width", "libre.graph.video.codec", "video.duration"},
+			})
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return slices.Equal(r.Attributes, []string{"video.codec=H264", "video.width=1920"})
+			}))
+		})
+
+		It("indexes the classification from the configured metadata key", func() {
+			movie.ArbitraryMetadata = &sprovider.ArbitraryMetadata{Metadata: map[string]string{
+				"classification": " Confidential ",
+			}}
+			DeferCleanup(func() { movie.ArbitraryMetadata = nil })
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+				ClassificationAttribute: "classification",
+			})
+			s.UpsertItem(ref, time.Time{})
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Classification == "Confidential"
+			}))
+		})
+
+		It("keeps the metadata added by external systems", func() {
+			eng := &engineMocks.Engine{}
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{})
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			eng.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{
+				ID:       "storageid$spaceid!movieid",
+				Metadata: []string{"classification=confidential"},
+			}, nil)
+			eng.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+			eng.On("DocCount").Return(uint64(1), nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			eng.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Name == "movie.mp4" && len(r.Metadata) == 1 && r.Metadata[0] == "classification=confidential"
+			}))
+		})
+
+		Describe("versions", func() {
+			var (
+				eng *engineMocks.Engine
+				s   *search.Service
+			)
+
+			BeforeEach(func() {
+				eng = &engineMocks.Engine{}
+				s = search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{IndexVersions: 2})
+				gatewayClient.On("ListFileVersions", mock.Anything, mock.Anything).Return(&sprovider.ListFileVersionsResponse{
+					Status: status.NewOK(context.Background()),
+					Versions: []*sprovider.FileVersion{
+						{Key: "movieid.REV.1", Mtime: 1000},
+						{Key: "movieid.REV.3", Mtime: 3000},
+						{Key: "movieid.REV.2", Mtime: 2000},
+					},
+				}, nil)
+				extractor.On("Extract", mock.Anything, mock.MatchedBy(func(ri *sprovider.ResourceInfo) bool {
+					return ri.GetId().GetOpaqueId() == "movieid"
+				})).Return(content.Document{Name: "movie.mp4", Content: "final cut"}, nil)
+				extractor.On("Extract", mock.Anything, mock.Anything).Return(func(_ context.Context, ri *sprovider.ResourceInfo) (content.Document, error) {
+					return content.Document{Content: "content of " + ri.GetId().GetOpaqueId()}, nil
+				}, nil)
+				eng.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+				eng.On("DocCount").Return(uint64(1), nil)
+			})
+
+			It("indexes the content of the latest previous versions", func() {
+				eng.On("GetDocument", mock.Anything).Return(nil, errtypes.NotFound("not indexed"))
+
+				s.UpsertItem(ref, time.Time{})
+
+				eng.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+					return r.Content == "final cut" && slices.Equal(r.Versions, []search.Version{
+						{Key: "movieid.REV.3", Mtime: "1970-01-01T00:50:00Z", Content: "content of movieid.REV.3"},
+						{Key: "movieid.REV.2", Mtime: "1970-01-01T00:33:20Z", Content: "content of movieid.REV.2"},
+					})
+				}))
+				extractor.AssertNumberOfCalls(GinkgoT(), "Extract", 3)
+			})
+
+			It("does not extract the versions which are already indexed", func() {
+				eng.On("GetDocument", mock.Anything).Return(&search.Resource{
+					Versions: []search.Version{{Key: "movieid.REV.2", Content: "indexed content"}},
+				}, nil)
+
+				s.UpsertItem(ref, time.Time{})
+
+				eng.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+					return len(r.Versions) == 2 && r.Versions[1].Content == "indexed content"
+				}))
+				extractor.AssertNumberOfCalls(GinkgoT(), "Extract", 2)
+			})
+		})
+
+		It("does not list the shares if disabled", func() {
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(ref, time.Time{})
+
+			gatewayClient.AssertNotCalled(GinkgoT(), "ListShares", mock.Anything, mock.Anything)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.SharedWith == nil
+			}))
+		})
+
+		DescribeTable("indexes only metadata for content excluded resources",
+			func(cfg *config.Config) {
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, cfg)
+				indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+				s.UpsertItem(ref, time.Time{})
+
+				extractor.AssertNotCalled(GinkgoT(), "Extract", mock.Anything, mock.Anything)
+				indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+					return r.Name == "movie.mp4" && r.Size == 12345 && r.Mtime != "" && r.Content == ""
+				}))
+			},
+			Entry("by space", &config.Config{Extractor: config.Extractor{MetadataOnlySpaces: []string{"storageid$spaceid"}}}),
+			Entry("by mime type", &config.Config{Extractor: config.Extractor{MetadataOnlyMimeTypes: []string{"video/*"}}}),
+		)
+
+		DescribeTable("indexes the resources according to the excluded paths",
+			func(resourcePath string, excludedPaths []string, indexed bool) {
+				extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+				indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{ExcludedPaths: excludedPaths})
+				s.UpsertItem(&sprovider.Reference{ResourceId: ref.GetResourceId(), Path: resourcePath}, time.Time{})
+
+				if !indexed {
+					indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+					indexClient.AssertNotCalled(GinkgoT(), "Purge", mock.Anything, mock.Anything)
+					return
+				}
+				indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.Anything)
+			},
+			Entry("without exclusions", "./movie.mp4", nil, true),
+			Entry("not matching", "./movie.mp4", []string{".DS_Store", "*.tmp"}, true),
+			Entry("by name", "./movie.mp4", []string{".DS_Store", "*.mp4"}, false),
+			Entry("by parent name", "./Videos/movie.mp4", []string{"Videos"}, false),
+			Entry("by path", "./Videos/movie.mp4", []string{"/Videos/*"}, false),
+			Entry("by parent path", "./Archive/Videos/movie.mp4", []string{"Archive/Videos"}, false),
+			Entry("not matching the path", "./Videos/movie.mp4", []string{"Archive/Videos"}, true),
+		)
+
+		It("removes excluded resources which are already indexed", func() {
+			eng := &engineMocks.Engine{}
+			eng.On("DocCount").Return(uint64(1), nil)
+			eng.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{ID: "storageid$spaceid!movieid"}, nil)
+			eng.On("Purge", "storageid$spaceid!movieid", false).Return(nil)
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{ExcludedPaths: []string{"*.mp4"}})
+			s.UpsertItem(ref, time.Time{})
+
+			eng.AssertCalled(GinkgoT(), "Purge", "storageid$spaceid!movieid", false)
+			eng.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+			extractor.AssertNotCalled(GinkgoT(), "Extract", mock.Anything, mock.Anything)
+		})
+
+		DescribeTable("indexes the resource types according to the policy",
+			func(resourceType sprovider.ResourceType, cfg config.ResourceTypes, indexed bool) {
+				DeferCleanup(func(t sprovider.ResourceType) { movie.Type = t }, movie.Type)
+				movie.Type = resourceType
+				extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+				indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{ResourceTypes: cfg})
+				s.UpsertItem(ref, time.Time{})
+
+				if !indexed {
+					indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+					return
+				}
+				indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+					return r.Type == uint64(resourceType)
+				}))
+			},
+			Entry("file", sprovider.ResourceType_RESOURCE_TYPE_FILE, config.ResourceTypes{SkipSymlinks: true, SkipReferences: true}, true),
+			Entry("folder", sprovider.ResourceType_RESOURCE_TYPE_CONTAINER, config.ResourceTypes{SkipSymlinks: true, SkipReferences: true}, true),
+			Entry("symlink", sprovider.ResourceType_RESOURCE_TYPE_SYMLINK, config.ResourceTypes{}, true),
+			Entry("skipped symlink", sprovider.ResourceType_RESOURCE_TYPE_SYMLINK, config.ResourceTypes{SkipSymlinks: true}, false),
+			Entry("reference", sprovider.ResourceType_RESOURCE_TYPE_REFERENCE, config.ResourceTypes{}, true),
+			Entry("skipped reference", sprovider.ResourceType_RESOURCE_TYPE_REFERENCE, config.ResourceTypes{SkipReferences: true}, false),
+			Entry("internal", sprovider.ResourceType_RESOURCE_TYPE_INTERNAL, config.ResourceTypes{}, false),
+			Entry("invalid", sprovider.ResourceType_RESOURCE_TYPE_INVALID, config.ResourceTypes{}, false),
+		)
+	})
+
+	Describe("UpdateMetadata", func() {
+		It("patches the metadata of the indexed resource", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{
+				ID:       "storageid$spaceid!movieid",
+				Document: content.Document{Name: "movie.mp4"},
+				Metadata: []string{"classification=internal", "project=apollo"},
+			}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			err := s.UpdateMetadata("storageid$spaceid!movieid", map[string]string{"classification": "confidential", "project": "", "retention": "10y"})
+			Expect(err).ToNot(HaveOccurred())
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Name == "movie.mp4" && slices.Equal(r.Metadata, []string{"classification=confidential", "retention=10y"})
+			}))
+		})
+
+		It("retries the patch with the fresh resource if the resource was changed concurrently", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{
+				ID:          "storageid$spaceid!movieid",
+				Metadata:    []string{"project=apollo"},
+				SeqNo:       1,
+				PrimaryTerm: 1,
+			}, nil).Once()
+			indexClient.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{
+				ID:          "storageid$spaceid!movieid",
+				Metadata:    []string{"classification=internal", "project=apollo"},
+				SeqNo:       2,
+				PrimaryTerm: 1,
+			}, nil).Once()
+			indexClient.On("Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool { return r.SeqNo == 1 })).Return(search.ErrConflict)
+			indexClient.On("Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool { return r.SeqNo == 2 })).Return(nil)
+
+			err := s.UpdateMetadata("storageid$spaceid!movieid", map[string]string{"retention": "10y"})
+			Expect(err).ToNot(HaveOccurred())
+
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Upsert", 2)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.SeqNo == 2 && slices.Equal(r.Metadata, []string{"classification=internal", "project=apollo", "retention=10y"})
+			}))
+		})
+
+		It("gives up on resources which keep changing concurrently", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{ID: "storageid$spaceid!movieid", SeqNo: 1, PrimaryTerm: 1}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(search.ErrConflict)
+
+			err := s.UpdateMetadata("storageid$spaceid!movieid", map[string]string{"retention": "10y"})
+			Expect(err).To(MatchError(search.ErrConflict))
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Upsert", 4)
+		})
+
+		It("fails for resources which are not indexed", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!unknown").Return(nil, errtypes.NotFound("storageid$spaceid!unknown"))
+
+			err := s.UpdateMetadata("storageid$spaceid!unknown", map[string]string{"classification": "confidential"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+			indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+		})
+
+		It("rejects invalid metadata keys", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!movieid").Return(&search.Resource{ID: "storageid$spaceid!movieid"}, nil)
+
+			err := s.UpdateMetadata("storageid$spaceid!movieid", map[string]string{"a=b": "c"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+			indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("UpdateSpaceName", func() {
+		It("updates the space name of the indexed resources", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng := bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			for _, r := range []search.Resource{
+				{ID: "storageid$spaceid!spaceid", RootID: "storageid$spaceid!spaceid", Path: ".", SpaceName: "Marketing"},
+				{ID: "storageid$spaceid!movieid", RootID: "storageid$spaceid!spaceid", Path: "./movie.mp4", SpaceName: "Marketing", Document: content.Document{Name: "movie.mp4"}},
+				{ID: "storageid$otherspaceid!otherspaceid", RootID: "storageid$otherspaceid!otherspaceid", Path: ".", SpaceName: "Sales"},
+			} {
+				Expect(eng.Upsert(r.ID, r)).To(Succeed())
+			}
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{BatchSize: 10})
+			Expect(s.UpdateSpaceName(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid"}, "Campaigns")).To(Succeed())
+
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{Query: "name:movie.mp4"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetMatches()).To(HaveLen(1))
+			Expect(res.GetMatches()[0].GetEntity().GetSpaceName()).To(Equal("Campaigns"))
+
+			other, err := eng.GetDocument("storageid$otherspaceid!otherspaceid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(other.SpaceName).To(Equal("Sales"))
+		})
+	})
+
+	Describe("MoveItem", func() {
+		var (
+			ref = &sprovider.Reference{
+				ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "otherspaceid", OpaqueId: "otherspaceid"},
+				Path:       "./moved/movie.mp4",
+			}
+			movie = &sprovider.ResourceInfo{
+				Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "otherspaceid", OpaqueId: "movieid"},
+				ParentId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "otherspaceid", OpaqueId: "movedid"},
+				Name:     "movie.mp4",
+				Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+			}
+		)
+
+		BeforeEach(func() {
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+				Status: status.NewOK(context.Background()),
+				Info:   movie,
+			}, nil)
+			indexClient.On("GetDocument", mock.Anything).Return(nil, errtypes.NotFound("not indexed")).Maybe()
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{{Name: "Marketing"}},
+			}, nil).Maybe()
+		})
+
+		It("moves the resource in the index", func() {
+			indexClient.On("Move", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			s.MoveItem(ref, time.Now())
+
+			indexClient.AssertCalled(GinkgoT(), "Move", "storageid$otherspaceid!movieid", "storageid$otherspaceid!movedid", "./moved/movie.mp4")
+			indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+		})
+
+		It("indexes the resource again if the new name has to be truncated", func() {
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{MaxNameLength: 5})
+			indexClient.On("Move", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.MoveItem(ref, time.Now())
+
+			indexClient.AssertCalled(GinkgoT(), "Move", "storageid$otherspaceid!movieid", "storageid$otherspaceid!movedid", "./moved/movie.mp4")
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$otherspaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.Name == "movi…" && r.Extension == "mp4"
+			}))
+		})
+
+		It("indexes the resource at its new location if it is not indexed under its id", func() {
+			indexClient.On("Move", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("failed to get resource: %w", errtypes.NotFound("storageid$otherspaceid!movieid")))
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.MoveItem(ref, time.Now())
+
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$otherspaceid!movieid", mock.MatchedBy(func(r search.Resource) bool {
+				return r.RootID == "storageid$otherspaceid!otherspaceid" && r.Path == "./moved/movie.mp4"
+			}))
+		})
+
+		It("indexes the space completely if the resource has too many descendants to move", func() {
+			batch := &engineMocks.BatchOperator{}
+			batch.EXPECT().Push().Return(nil)
+			batch.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+			indexClient.On("Move", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("%w: movieid has 3 descendants", search.ErrTooManyDescendants))
+			indexClient.On("Purge", mock.Anything, mock.Anything).Return(nil)
+			indexClient.On("NewBatch", mock.Anything).Return(batch, nil)
+			indexClient.On("GetDocuments", mock.Anything).Return([]*search.Resource{}, nil)
+			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "movie.mp4"}, nil)
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+
+			s.MoveItem(ref, time.Now())
+
+			indexClient.AssertCalled(GinkgoT(), "Purge", "storageid$otherspaceid!movieid", false)
+			indexClient.AssertCalled(GinkgoT(), "NewBatch", mock.Anything)
+			batch.AssertCalled(GinkgoT(), "Upsert", "storageid$otherspaceid!movieid", mock.Anything)
+		})
+	})
+
+	Describe("TrashItem", func() {
+		var (
+			ref = &sprovider.Reference{
+				ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"},
+				Path:       "./report.pdf",
+			}
+			report = &sprovider.ResourceInfo{
+				Id:    &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "reportid"},
+				Name:  "report.pdf",
+				Type:  sprovider.ResourceType_RESOURCE_TYPE_FILE,
+				Mtime: &typesv1beta1.Timestamp{Seconds: 4000},
+			}
+			eng   *bleve.Backend
+			cfg   *config.Config
+			mtime time.Time
+
+			isDeleted = func() bool {
+				r, err := eng.GetDocument("storageid$spaceid!reportid")
+				Expect(err).ToNot(HaveOccurred())
+				return r.Deleted
+			}
+		)
+
+		BeforeEach(func() {
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+				Status: status.NewOK(context.Background()),
+				Info:   report,
+			}, nil)
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(context.Background()),
+				StorageSpaces: []*sprovider.StorageSpace{{Name: "Marketing"}},
+			}, nil).Maybe()
+			mtime = time.Unix(4000, 0)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(func(context.Context, *sprovider.ResourceInfo) (content.Document, error) {
+				return content.Document{
+					Name:  "report.pdf",
+					Mtime: mtime.UTC().Format(time.RFC3339Nano),
+				}, nil
+			})
+
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+			cfg = &config.Config{}
+			cfg.Events.SkipStaleEvents = true
+			cfg.Events.StaleEventGracePeriod = time.Second
+		})
+
+		It("ignores a trash event which is older than the last applied event", func() {
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, cfg)
+			s.UpsertItem(ref, time.Unix(4000, 0))
+
+			r, err := eng.GetDocument("storageid$spaceid!reportid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.LastEventTS).To(Equal("1970-01-01T01:06:40Z"))
+
+			s.TrashItem(report.Id, time.Unix(3000, 0))
+			Expect(isDeleted()).To(BeFalse())
+
+			s.TrashItem(report.Id, time.Unix(5000, 0))
+			Expect(isDeleted()).To(BeTrue())
+		})
+
+		It("applies the trash events within the grace period", func() {
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, cfg)
+			s.UpsertItem(ref, time.Unix(4000, 0))
+
+			s.TrashItem(report.Id, time.Unix(4000, 0).Add(-500*time.Millisecond))
+			Expect(isDeleted()).To(BeTrue())
+		})
+
+		It("compares the events with the last applied event and not with the modification time of the resource", func() {
+			// the clock of the storage is ahead of the clock of the events
+			mtime = time.Unix(4000, 0).Add(time.Hour)
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, cfg)
+			s.UpsertItem(ref, time.Time{})
+
+			r, err := eng.GetDocument("storageid$spaceid!reportid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.LastEventTS).To(BeEmpty())
+
+			s.MoveItem(ref, time.Unix(4000, 0))
+			r, err = eng.GetDocument("storageid$spaceid!reportid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.LastEventTS).To(Equal("1970-01-01T01:06:40Z"))
+
+			s.TrashItem(report.Id, time.Unix(3000, 0))
+			Expect(isDeleted()).To(BeFalse())
+
+			s.TrashItem(report.Id, time.Unix(5000, 0))
+			Expect(isDeleted()).To(BeTrue())
+		})
+
+		It("removes folders with too many descendants to delete and indexes their space completely", func() {
+			mapping, err := bleve.NewMapping()
+			Expect(err).ToNot(HaveOccurred())
+			idx, err := bleveSearch.NewMemOnly(mapping)
+			Expect(err).ToNot(HaveOccurred())
+			eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{}, bleve.WithDescendantLimits(10, 1))
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+
+			folder := search.Resource{
+				ID:       "storageid$spaceid!folderid",
+				RootID:   "storageid$spaceid!spaceid",
+				ParentID: "storageid$spaceid!spaceid",
+				Path:     "./folder",
+				Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER),
+			}
+			resources := map[string]search.Resource{folder.ID: folder}
+			for _, name := range []string{"a.pdf", "b.pdf"} {
+				resources["storageid$spaceid!"+name] = search.Resource{
+					ID:       "storageid$spaceid!" + name,
+					RootID:   folder.RootID,
+					ParentID: folder.ID,
+					Path:     "./folder/" + name,
+				}
+			}
+			Expect(eng.UpsertMany(resources)).To(Succeed())
+
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{BatchSize: 10})
+			s.TrashItem(&sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "folderid"}, time.Now())
+
+			for id := range resources {
+				_, err := eng.GetDocument(id)
+				Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")), id)
+			}
+			// the space was indexed again, the trashed folder is not part of it anymore
+			Expect(isDeleted()).To(BeFalse())
+		})
+
+		It("applies all trash events if stale events are not skipped", func() {
+			s := search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{})
+			s.UpsertItem(ref, time.Time{})
+
+			s.TrashItem(report.Id, time.Unix(3000, 0))
+			Expect(isDeleted()).To(BeTrue())
+		})
+	})
+
+	Describe("UpdateSharedWith", func() {
+		var rID = &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"}
+
+		BeforeEach(func() {
+			s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{IndexSharedWith: true})
+		})
+
+		It("updates the shares of the indexed resource", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!opaqueid").Return(&search.Resource{
+				ID:         "storageid$spaceid!opaqueid",
+				Document:   content.Document{Name: "foo.pdf", Content: "content"},
+				SharedWith: []string{"removed"},
+			}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+			gatewayClient.On("ListShares", mock.Anything, mock.Anything).Return(&collaborationv1beta1.ListSharesResponse{
+				Status: status.NewOK(ctx),
+				Shares: []*collaborationv1beta1.Share{
+					{Grantee: &sprovider.Grantee{Id: &sprovider.Grantee_UserId{UserId: &userv1beta1.UserId{OpaqueId: "added"}}}},
+				},
+			}, nil)
+
+			s.UpdateSharedWith(rID)
+
+			extractor.AssertNotCalled(GinkgoT(), "Extract", mock.Anything, mock.Anything)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", "storageid$spaceid!opaqueid", mock.MatchedBy(func(r search.Resource) bool {
+				return slices.Equal(r.SharedWith, []string{"added"}) && r.Content == "content"
+			}))
+		})
+
+		It("ignores resources which are not indexed", func() {
+			indexClient.On("GetDocument", "storageid$spaceid!opaqueid").Return(nil, errtypes.NotFound("not found"))
+
+			s.UpdateSharedWith(rID)
+
+			gatewayClient.AssertNotCalled(GinkgoT(), "ListShares", mock.Anything, mock.Anything)
+			indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("UpdateLock", func() {
+		var (
+			ref     = &sprovider.Reference{ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"}}
+			indexed search.Resource
+			stat    = func(lock *sprovider.Lock) *mock.Call {
+				return gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+					Status: status.NewOK(ctx),
+					Info:   &sprovider.ResourceInfo{Id: ref.ResourceId, Lock: lock},
+				}, nil)
+			}
+		)
+
+		BeforeEach(func() {
+			s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{IndexLocks: true})
+
+			indexed = search.Resource{
+				ID:       "storageid$spaceid!opaqueid",
+				Document: content.Document{Name: "foo.odt", Content: "content"},
+			}
+			indexClient.On("GetDocument", "storageid$spaceid!opaqueid").Return(func(string) (*search.Resource, error) {
+				r := indexed
+				return &r, nil
+			})
+			indexClient.On("Upsert", "storageid$spaceid!opaqueid", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				indexed = args.Get(1).(search.Resource)
+			})
+		})
+
+		It("locks and unlocks the indexed resource", func() {
+			expiration := time.Now().Add(time.Hour)
+			locked := stat(&sprovider.Lock{User: &userv1beta1.UserId{OpaqueId: "einstein"}, Expiration: utils.TimeToTS(expiration)})
+
+			s.UpdateLock(ref)
+
+			Expect(indexed.Locked).To(BeTrue())
+			Expect(indexed.LockedBy).To(Equal("einstein"))
+			lockExpiration, err := time.Parse(time.RFC3339Nano, indexed.LockExpiration)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lockExpiration).To(BeTemporally("~", expiration, time.Second))
+			Expect(indexed.Content).To(Equal("content"))
+			extractor.AssertNotCalled(GinkgoT(), "Extract", mock.Anything, mock.Anything)
+
+			locked.Unset()
+			stat(nil)
+
+			s.UpdateLock(ref)
+
+			Expect(indexed.Locked).To(BeFalse())
+			Expect(indexed.LockedBy).To(BeEmpty())
+			Expect(indexed.LockExpiration).To(BeEmpty())
+			Expect(indexed.Content).To(Equal("content"))
+		})
+
+		It("does not index expired locks", func() {
+			stat(&sprovider.Lock{User: &userv1beta1.UserId{OpaqueId: "einstein"}, Expiration: utils.TimeToTS(time.Now().Add(-time.Minute))})
+
+			s.UpdateLock(ref)
+
+			Expect(indexed.Locked).To(BeFalse())
+		})
+
+		It("ignores the locks if disabled", func() {
+			s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{})
+
+			s.UpdateLock(ref)
+
+			gatewayClient.AssertNotCalled(GinkgoT(), "Stat", mock.Anything, mock.Anything)
+			indexClient.AssertNotCalled(GinkgoT(), "Upsert", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("Search", func() {
+		It("rejects an empty query without a scope", func() {
+			for _, query := range []string{"", "  "} {
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: query,
+				})
+				Expect(err).To(MatchError(search.ErrEmptyQueryWithoutScope))
+				Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+				Expect(res).To(BeNil())
+			}
+			indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+		})
+
+		Context("with a personal space", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+					TotalMatches: 1,
+					Matches: []*searchmsg.Match{
+						{
+							Score: 1,
+							Entity: &searchmsg.Entity{
+								Ref: &searchmsg.Reference{
+									ResourceId: &searchmsg.ResourceID{
+										StorageId: personalSpace.Root.StorageId,
+										SpaceId:   personalSpace.Root.SpaceId,
+										OpaqueId:  personalSpace.Root.OpaqueId,
+									},
+									Path: "./path/to/Foo.pdf",
+								},
+								Id: &searchmsg.ResourceID{
+									StorageId: personalSpace.Root.StorageId,
+									OpaqueId:  "foo-id",
+								},
+								Name: "Foo.pdf",
+							},
+						},
+					},
+				}, nil)
+				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+					Status: status.NewOK(context.Background()),
+					Info:   ri,
+				}, nil)
+			})
+
+			It("resolves usernames of owner and creator conditions", func() {
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.MatchedBy(func(req *userv1beta1.GetUserByClaimRequest) bool {
+					return req.Claim == "username" && req.Value == "alice"
+				})).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(ctx),
+					User:   &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "alice-id"}},
+				}, nil)
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewNotFound(ctx, "user not found"),
+				}, nil)
+
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "owner:alice AND creator:bob-id",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "owner:alice-id AND creator:bob-id"
+				}))
+			})
+
+			It("resolves only the owner and creator conditions of the query", func() {
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.MatchedBy(func(req *userv1beta1.GetUserByClaimRequest) bool {
+					return req.Claim == "username" && req.Value == "alice"
+				})).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewOK(ctx),
+					User:   &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "alice-id"}},
+				}, nil)
+				gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+					Status: status.NewNotFound(ctx, "user not found"),
+				}, nil)
+
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: `name:"owner:alice" name:ärger owner:(alice OR bob-id) Creator:"alice"`,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == `name:"owner:alice" name:ärger owner:(alice-id OR bob-id) Creator:alice-id`
+				}))
+			})
+
+			It("resolves sharedwith conditions of the current user", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "sharedwith:me OR sharedwith:user",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "sharedwith:user OR sharedwith:user"
+				}))
+			})
+
+			It("rejects sharedwith conditions of other users", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "sharedwith:otheruser",
+				})
+				Expect(err).To(MatchError(errtypes.BadRequest("sharedwith only supports the current user and their groups")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("does not restrict terms containing a sharedwith condition", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: `name:"sharedwith:otheruser" OR sharedwith:(me)`,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == `name:"sharedwith:otheruser" OR sharedwith:(user)`
+				}))
+			})
+
+			It("does not mess with field-based searches", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "Size:<10",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "Size:<10"
+				}))
+			})
+
+			It("searches the personal user space", func() {
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "foo",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res).ToNot(BeNil())
+				Expect(res.TotalMatches).To(Equal(int32(1)))
+				Expect(len(res.Matches)).To(Equal(1))
+				match := res.Matches[0]
+				Expect(match.Entity.Id.OpaqueId).To(Equal("foo-id"))
+				Expect(match.Entity.Name).To(Equal("Foo.pdf"))
+				Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal(personalSpace.Root.OpaqueId))
+				Expect(match.Entity.Ref.Path).To(Equal("./path/to/Foo.pdf"))
+			})
+
+			Context("with breadcrumbs", func() {
+				BeforeEach(func() {
+					indexClient.On("Search", mock.Anything, mock.Anything).Unset()
+					indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+						TotalMatches: 2,
+						Matches: []*searchmsg.Match{
+							{
+								Score: 2,
+								Entity: &searchmsg.Entity{
+									Ref:      &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}, Path: "./path/to/Foo.pdf"},
+									Id:       &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "foo-id"},
+									ParentId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "to-id"},
+									Name:     "Foo.pdf",
+								},
+							},
+							{
+								Score: 1,
+								Entity: &searchmsg.Entity{
+									Ref:      &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}, Path: "./Bar.pdf"},
+									Id:       &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "bar-id"},
+									ParentId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"},
+									Name:     "Bar.pdf",
+								},
+							},
+						},
+					}, nil)
+				})
+
+				It("returns the folders on the path of the matches with their ids", func() {
+					indexClient.On("GetDocuments", []string{"storageid$spaceid!to-id"}).Return([]*search.Resource{
+						{ID: "storageid$spaceid!to-id", ParentID: "storageid$spaceid!path-id", Document: content.Document{Name: "to"}},
+					}, nil).Once()
+					indexClient.On("GetDocuments", []string{"storageid$spaceid!path-id"}).Return([]*search.Resource{
+						{ID: "storageid$spaceid!path-id", ParentID: "storageid$spaceid!spaceid", Document: content.Document{Name: "path"}},
+					}, nil).Once()
+
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo", WithBreadcrumbs: true})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches).To(HaveLen(2))
+					Expect(res.Matches[0].GetBreadcrumbs()).To(HaveExactElements(
+						And(HaveField("Name", "path"), HaveField("Id.OpaqueId", "path-id"), HaveField("Id.SpaceId", "spaceid")),
+						And(HaveField("Name", "to"), HaveField("Id.OpaqueId", "to-id"), HaveField("Id.SpaceId", "spaceid")),
+					))
+					// the resources in the root have no folders on their path
+					Expect(res.Matches[1].GetBreadcrumbs()).To(BeEmpty())
+					// the space root is not looked up
+					indexClient.AssertNumberOfCalls(GinkgoT(), "GetDocuments", 2)
+				})
+
+				It("returns the folders which are not indexed without ids", func() {
+					indexClient.On("GetDocuments", []string{"storageid$spaceid!to-id"}).Return([]*search.Resource{}, nil).Once()
+
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo", WithBreadcrumbs: true})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches[0].GetBreadcrumbs()).To(HaveExactElements(
+						And(HaveField("Name", "path"), HaveField("Id", BeNil())),
+						And(HaveField("Name", "to"), HaveField("Id", BeNil())),
+					))
+				})
+
+				It("does not resolve the breadcrumbs unless requested", func() {
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo"})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Matches[0].GetBreadcrumbs()).To(BeEmpty())
+					indexClient.AssertNotCalled(GinkgoT(), "GetDocuments", mock.Anything)
+				})
+			})
+
+			It("passes the count only flag to the index", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query:     "foo",
+					CountOnly: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.CountOnly
+				}))
+			})
+
+			It("cuts off a search which exceeds the timeout", func() {
+				cfg := &config.Config{}
+				cfg.Engine.SearchTimeout = 50 * time.Millisecond
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, cfg)
+
+				indexClient.On("Search", mock.Anything, mock.Anything).Unset()
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(func(ctx context.Context, _ *searchsvc.SearchIndexRequest) (*searchsvc.SearchIndexResponse, error) {
+					// a stuck engine only returns once the search is canceled
+					<-ctx.Done()
+					return nil, ctx.Err()
+				})
+
+				start := time.Now()
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo"})
+				Expect(err).To(MatchError(search.ErrTimeout))
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+				Expect(res).To(BeNil())
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+
+		Context("without matches", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+			})
+
+			It("suggests similar terms", func() {
+				indexClient.On("Suggest", mock.Anything, "invoce", mock.MatchedBy(func(refs []*searchmsg.Reference) bool {
+					return len(refs) == 1 && refs[0].GetResourceId().GetOpaqueId() == "personalspace" && refs[0].GetPath() == ""
+				})).Return([]string{"invoice"}, nil)
+
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "invoce",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(0)))
+				Expect(res.Suggestions).To(Equal([]string{"invoice"}))
+			})
+
+			It("does not suggest terms for field-based searches", func() {
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "Size:<10",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Suggestions).To(BeEmpty())
+				indexClient.AssertNotCalled(GinkgoT(), "Suggest", mock.Anything, mock.Anything)
+			})
+
+			It("does not suggest terms when only counting", func() {
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query:     "invoce",
+					CountOnly: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Suggestions).To(BeEmpty())
+				indexClient.AssertNotCalled(GinkgoT(), "Suggest", mock.Anything, mock.Anything)
 			})
-			Expect(err).To(HaveOccurred())
-			Expect(res).To(BeNil())
 		})
 
-		Context("with a personal space", func() {
+		Context("with a slow engine", func() {
+			var (
+				logs         *bytes.Buffer
+				m            *metrics.Metrics
+				slowSearches = func() float64 {
+					metric := &dto.Metric{}
+					Expect(m.SlowSearches.Write(metric)).To(Succeed())
+					return metric.GetCounter().GetValue()
+				}
+			)
+
 			BeforeEach(func() {
 				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
 					Status:        status.NewOK(ctx),
 					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
 				}, nil)
-				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
-					TotalMatches: 1,
-					Matches: []*searchmsg.Match{
-						{
-							Score: 1,
-							Entity: &searchmsg.Entity{
-								Ref: &searchmsg.Reference{
-									ResourceId: &searchmsg.ResourceID{
-										StorageId: personalSpace.Root.StorageId,
-										SpaceId:   personalSpace.Root.SpaceId,
-										OpaqueId:  personalSpace.Root.OpaqueId,
-									},
-									Path: "./path/to/Foo.pdf",
-								},
-								Id: &searchmsg.ResourceID{
-									StorageId: personalSpace.Root.StorageId,
-									OpaqueId:  "foo-id",
-								},
-								Name: "Foo.pdf",
-							},
-						},
-					},
+				indexClient.On("Search", mock.Anything, mock.Anything).After(50*time.Millisecond).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				logs = &bytes.Buffer{}
+				m = metrics.New()
+			})
+
+			It("logs and counts searches exceeding the threshold", func() {
+				s := search.NewService(gatewaySelector, indexClient, extractor, m, log.Logger{Logger: zerolog.New(logs)}, &config.Config{
+					Engine:              config.Engine{Type: "bleve"},
+					SlowSearchThreshold: 10 * time.Millisecond,
+				})
+				before := slowSearches()
+
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo\nbar", PageSize: 10})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(slowSearches()).To(Equal(before + 1))
+				Expect(logs.String()).To(ContainSubstring(`"level":"warn"`))
+				Expect(logs.String()).To(ContainSubstring(`"query":"foo bar"`))
+				Expect(logs.String()).To(ContainSubstring(`"pageSize":10`))
+				Expect(logs.String()).To(ContainSubstring(`"userID":"user"`))
+				Expect(logs.String()).To(ContainSubstring(`"engine":"bleve"`))
+				Expect(logs.String()).To(ContainSubstring(`"message":"slow search"`))
+			})
+
+			It("ignores searches below the threshold", func() {
+				s := search.NewService(gatewaySelector, indexClient, extractor, m, log.Logger{Logger: zerolog.New(logs)}, &config.Config{
+					SlowSearchThreshold: time.Minute,
+				})
+				before := slowSearches()
+
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(slowSearches()).To(Equal(before))
+				Expect(logs.String()).ToNot(ContainSubstring("slow search"))
+			})
+		})
+
+		Context("with query limits", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
 				}, nil)
-				gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
-					Status: status.NewOK(context.Background()),
-					Info:   ri,
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+					MaxQueryLength: 16,
+					MaxQueryTerms:  3,
+				})
+			})
+
+			It("accepts queries at the maximum length", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "äbcdefghijklmnop"})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("rejects queries exceeding the maximum length", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "abcdefghijklmnopq"})
+				Expect(err).To(MatchError(errtypes.BadRequest("query exceeds the maximum length of 16 characters")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("accepts queries with the maximum number of terms", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "a OR (b c)"})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("rejects queries exceeding the maximum number of terms", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "a OR (b (c d))"})
+				Expect(err).To(MatchError(errtypes.BadRequest("query exceeds the maximum number of 3 terms")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+		})
+
+		Context("with searchable fields", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
 				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+					QueryFields: []string{"Name", "mediatype"},
+				})
 			})
 
-			It("does not mess with field-based searches", func() {
-				_, err := s.Search(ctx, &searchsvc.SearchRequest{
-					Query: "Size:<10",
+			It("accepts queries using the searchable fields", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo name:bar* (MediaType:pdf OR mediatype:document)"})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("rejects queries using other fields", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "name:foo (deleted:true OR mediatype:pdf)"})
+				Expect(err).To(MatchError(errtypes.BadRequest("the property 'deleted' is not searchable")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("rejects queries using the internal fields of the index", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "RootID:1$2!2"})
+				Expect(err).To(MatchError(errtypes.BadRequest("the property 'rootid' is not searchable")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+
+			It("rejects queries which can't be parsed", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "AND name:foo"})
+				Expect(err).To(MatchError(errtypes.BadRequest("invalid query: the expression can't begin from a binary operator: 'AND'")))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+		})
+
+		Context("with a maximum page size", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{
+					MaxPageSize: 50,
 				})
+			})
+
+			DescribeTable("limits the requested page size",
+				func(pageSize, expected int32) {
+					_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo", PageSize: pageSize})
+					Expect(err).ToNot(HaveOccurred())
+					indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+						return req.PageSize == expected
+					}))
+				},
+				Entry("smaller page size", int32(10), int32(10)),
+				Entry("larger page size", int32(100), int32(50)),
+				Entry("default page size", int32(0), int32(50)),
+				Entry("all matches", int32(-1), int32(50)),
+			)
+		})
+
+		Context("with disabled highlights", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				cfg := &config.Config{}
+				cfg.Engine.Highlights.Disabled = true
+				s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, cfg)
+			})
+
+			It("skips the highlighting of all searches", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "foo"})
 				Expect(err).ToNot(HaveOccurred())
 				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == "Size:<10"
+					return req.NoHighlight
 				}))
 			})
+		})
 
-			It("searches the personal user space", func() {
-				res, err := s.Search(ctx, &searchsvc.SearchRequest{
-					Query: "foo",
-				})
+		Context("with restricted content", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{TotalMatches: 1}, nil)
+
+				cfg := &config.Config{}
+				cfg.ContentAccess.RestrictedFields = []string{search.FieldContent, search.FieldTags}
+				cfg.ContentAccess.Roles = []string{"content-read"}
+				s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, cfg)
+			})
+
+			It("omits the restricted fields for users without a content role", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "Content:foo"})
 				Expect(err).ToNot(HaveOccurred())
-				Expect(res).ToNot(BeNil())
-				Expect(res.TotalMatches).To(Equal(int32(1)))
-				Expect(len(res.Matches)).To(Equal(1))
-				match := res.Matches[0]
-				Expect(match.Entity.Id.OpaqueId).To(Equal("foo-id"))
-				Expect(match.Entity.Name).To(Equal("Foo.pdf"))
-				Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal(personalSpace.Root.OpaqueId))
-				Expect(match.Entity.Ref.Path).To(Equal("./path/to/Foo.pdf"))
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "Content:foo" && req.NoHighlight &&
+						slices.Equal(req.OmitFields, []string{search.FieldContent, search.FieldTags})
+				}))
+			})
+
+			It("returns all fields to users with a content role", func() {
+				reader := &userv1beta1.User{
+					Id:     user.GetId(),
+					Opaque: utils.AppendJSONToOpaque(nil, "roles", []string{"user", "content-read"}),
+				}
+
+				_, err := s.Search(revactx.ContextSetUser(context.Background(), reader), &searchsvc.SearchRequest{Query: "Content:foo"})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return !req.NoHighlight && len(req.OmitFields) == 0
+				}))
 			})
 		})
 
@@ -274,6 +2475,31 @@ var _ = Describe("Searchprovider", func() {
 				Expect(match.Entity.Name).To(Equal("Foo.pdf"))
 				Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal(personalSpace.Root.OpaqueId))
 				Expect(match.Entity.Ref.Path).To(Equal("./path/to/Foo.pdf"))
+				gatewayClient.AssertCalled(GinkgoT(), "Stat", mock.Anything, mock.MatchedBy(func(req *sprovider.StatRequest) bool {
+					return req.GetRef().GetResourceId().GetOpaqueId() == "personalspace" && req.GetRef().GetPath() == "./path"
+				}))
+			})
+
+			It("lists all resources of the scope for an empty query", func() {
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: " scope:storageid$personalspace!personalspace/path ",
+				})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(1)))
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.GetQuery() == "" && req.GetRef().GetPath() == "/path"
+				}))
+			})
+
+			It("rejects scopes leaving the scoped resource", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "foo scope:storageid$personalspace!personalspace/../../other",
+				})
+
+				Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+				gatewayClient.AssertNotCalled(GinkgoT(), "Stat", mock.Anything, mock.Anything)
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
 			})
 		})
 
@@ -368,6 +2594,70 @@ var _ = Describe("Searchprovider", func() {
 				}))
 			})
 
+			It("only suggests the terms of the shared resources", func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{grantSpace, mountpointSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+				var suggestRefs []*searchmsg.Reference
+				indexClient.On("Suggest", mock.Anything, "invoce", mock.Anything).Run(func(args mock.Arguments) {
+					suggestRefs = args.Get(2).([]*searchmsg.Reference)
+				}).Return([]string{"invoice"}, nil)
+
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "invoce",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Suggestions).To(Equal([]string{"invoice"}))
+				Expect(suggestRefs).To(HaveLen(1))
+				Expect(suggestRefs[0].GetResourceId().GetOpaqueId()).To(Equal(grantSpace.Root.SpaceId))
+				Expect(suggestRefs[0].GetPath()).To(Equal("./grant/path"))
+			})
+
+			It("orders equal-scored matches by their id", func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexResponse := func(ids ...string) *searchsvc.SearchIndexResponse {
+					res := &searchsvc.SearchIndexResponse{TotalMatches: int32(len(ids))}
+					for _, id := range ids {
+						res.Matches = append(res.Matches, &searchmsg.Match{
+							Score: 1,
+							Entity: &searchmsg.Entity{
+								Ref: &searchmsg.Reference{
+									ResourceId: &searchmsg.ResourceID{
+										StorageId: personalSpace.Root.StorageId,
+										SpaceId:   personalSpace.Root.SpaceId,
+										OpaqueId:  personalSpace.Root.OpaqueId,
+									},
+									Path: "./path/to/" + id + ".pdf",
+								},
+								Id: &searchmsg.ResourceID{
+									StorageId: personalSpace.Root.StorageId,
+									OpaqueId:  id,
+								},
+								Name: id + ".pdf",
+							},
+						})
+					}
+					return res
+				}
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(indexResponse("b-id", "c-id", "a-id"), nil).Once()
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(indexResponse("c-id", "a-id", "b-id"), nil).Once()
+
+				for range 2 {
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{
+						Query: "foo",
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(len(res.Matches)).To(Equal(3))
+					ids := []string{res.Matches[0].Entity.Id.OpaqueId, res.Matches[1].Entity.Id.OpaqueId, res.Matches[2].Entity.Id.OpaqueId}
+					Expect(ids).To(Equal([]string{"a-id", "b-id", "c-id"}))
+				}
+			})
+
 			Context("when searching both spaces", func() {
 				BeforeEach(func() {
 					gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
@@ -488,11 +2778,88 @@ var _ = Describe("Searchprovider", func() {
 			})
 		})
 	})
+
+	Describe("SumField", func() {
+		var (
+			eng          *aggregatingEngine
+			projectSpace = &sprovider.StorageSpace{
+				Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$projectspace!projectspace"},
+				Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "projectspace", OpaqueId: "projectspace"},
+				Name:      "projectspace",
+				SpaceType: "project",
+			}
+			// a share within the project space, which is already covered by the project space
+			grantSpace = &sprovider.StorageSpace{
+				Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$projectspace!folder"},
+				Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "projectspace", OpaqueId: "folder"},
+				SpaceType: "grant",
+			}
+		)
+
+		BeforeEach(func() {
+			eng = &aggregatingEngine{Engine: indexClient, sums: map[string]float64{
+				"personalspace": 10,
+				"projectspace":  32,
+			}}
+			s = search.NewService(gatewaySelector, eng, extractor, nil, logger, &config.Config{})
+
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(ctx),
+				StorageSpaces: []*sprovider.StorageSpace{personalSpace, projectSpace, grantSpace},
+			}, nil)
+		})
+
+		It("sums the field up over the spaces of the user", func() {
+			res, err := s.SumField(ctx, &searchsvc.SumFieldRequest{Query: "type:file", Field: "size"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetSum()).To(Equal(float64(42)))
+
+			Expect(eng.requests).To(HaveLen(2))
+			for _, req := range eng.requests {
+				Expect(req.GetQuery()).To(Equal("type:file"))
+			}
+			Expect(eng.fields).To(HaveEach("Size"))
+		})
+
+		It("sums the field up within the scope", func() {
+			res, err := s.SumField(ctx, &searchsvc.SumFieldRequest{
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "projectspace", OpaqueId: "projectspace"},
+					Path:       "./folder",
+				},
+				Field: "size",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.GetSum()).To(Equal(float64(32)))
+
+			Expect(eng.requests).To(HaveLen(1))
+			Expect(eng.requests[0].GetRef().GetPath()).To(Equal("./folder"))
+		})
+
+		It("rejects an empty query without a scope", func() {
+			_, err := s.SumField(ctx, &searchsvc.SumFieldRequest{Field: "size"})
+			Expect(err).To(MatchError(search.ErrEmptyQueryWithoutScope))
+			Expect(eng.requests).To(BeEmpty())
+		})
+
+		It("rejects the fields which can not be summed up", func() {
+			_, err := s.SumField(ctx, &searchsvc.SumFieldRequest{Query: "foo", Field: "name"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+		})
+
+		It("fails if the engine can not aggregate fields", func() {
+			s = search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{})
+
+			_, err := s.SumField(ctx, &searchsvc.SumFieldRequest{Query: "foo", Field: "size"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotSupported("")))
+		})
+	})
 })
 
 var _ = DescribeTable("Parse Scope",
 	func(pattern, wantSearch, wantScope string) {
-		gotSearch, gotScope := search.ParseScope(pattern)
+		gotSearch, gotScope, err := search.ParseScope(pattern)
+		Expect(err).ToNot(HaveOccurred())
 		Expect(gotSearch).To(Equal(wantSearch))
 		Expect(gotScope).To(Equal(wantScope))
 	},
@@ -536,4 +2903,80 @@ var _ = DescribeTable("Parse Scope",
 		`+Name:*file* +Tags:&quot;foo&quot;`,
 		``,
 	),
+	Entry("When scope is a resource id",
+		`file scope:storageid$spaceid!opaqueid`,
+		`file`,
+		`storageid$spaceid!opaqueid`,
+	),
+	Entry("When scope has redundant slashes",
+		`file scope:<uuid>//folder/./subfolder/`,
+		`file`,
+		`<uuid>/folder/subfolder`,
+	),
+	Entry("When scope has a trailing slash only",
+		`file scope:<uuid>/`,
+		`file`,
+		`<uuid>`,
+	),
+)
+
+var _ = DescribeTable("Parse invalid Scope",
+	func(pattern string) {
+		_, _, err := search.ParseScope(pattern)
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+	},
+	Entry("When scope leaves the resource", `file scope:<uuid>/../other`),
+	Entry("When scope leaves the resource in the middle", `file scope:<uuid>/folder/../../other`),
+	Entry("When scope is a parent", `file scope:../<uuid>`),
+	Entry("When scope has no resource id", `file scope:/folder`),
+	Entry("When scope is empty", `file scope:`),
 )
+
+// rebuildingEngine passes the target engine to the rebuild
+type rebuildingEngine struct {
+	*engineMocks.Engine
+	target search.Engine
+}
+
+func (e *rebuildingEngine) Rebuild(build func(search.Engine) error) error {
+	return build(e.target)
+}
+
+// resettingEngine reports the given number of documents when the index is reset
+type resettingEngine struct {
+	*engineMocks.Engine
+	count uint64
+}
+
+func (e *resettingEngine) ResetIndex() (uint64, error) {
+	return e.count, nil
+}
+
+// aggregatingEngine returns the given sums by space id and records the requests
+type aggregatingEngine struct {
+	*engineMocks.Engine
+	sums     map[string]float64
+	requests []*searchsvc.SearchIndexRequest
+	fields   []string
+}
+
+func (e *aggregatingEngine) SumField(_ context.Context, req *searchsvc.SearchIndexRequest, field string) (float64, error) {
+	e.requests = append(e.requests, req)
+	e.fields = append(e.fields, field)
+	return e.sums[req.GetRef().GetResourceId().GetSpaceId()], nil
+}
+
+// bulkIndexingEngine records the start and the end of the bulk indexing
+type bulkIndexingEngine struct {
+	*engineMocks.Engine
+	events []string
+}
+
+func (e *bulkIndexingEngine) StartBulkIndexing() (func() error, error) {
+	e.events = append(e.events, "start")
+	return func() error {
+		e.events = append(e.events, "end")
+		return nil
+	}, nil
+}
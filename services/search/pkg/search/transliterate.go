@@ -0,0 +1,47 @@
+package search
+
+import (
+	"maps"
+	"strings"
+	"unicode"
+)
+
+// latinTransliterations maps the lowercase cyrillic and greek letters to their latin transliteration.
+// Cyrillic covers the russian, ukrainian, belarusian, bulgarian, serbian and macedonian alphabets,
+// greek covers the modern greek alphabet including the accented vowels.
+var latinTransliterations = map[rune]string{
+	// cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ґ': "g", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "u", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+	// greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o", 'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o", 'ύ': "y", 'ώ': "o",
+	'ϊ': "i", 'ϋ': "y", 'ΐ': "i", 'ΰ': "y",
+}
+
+// Transliterate replaces the cyrillic and greek letters of the given string with their lowercase latin transliteration,
+// all other characters are kept as they are.
+func Transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if latin, ok := latinTransliterations[unicode.ToLower(r)]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// LatinTransliterations returns the lowercase cyrillic and greek letters which are transliterated and their latin transliteration.
+func LatinTransliterations() map[rune]string {
+	return maps.Clone(latinTransliterations)
+}
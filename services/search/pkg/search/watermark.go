@@ -0,0 +1,137 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WatermarkStore keeps the modification time up to which the resources of a space are indexed.
+type WatermarkStore interface {
+	// Get returns the watermark of the space, the zero time if the space was not indexed yet.
+	Get(spaceID string) (time.Time, error)
+	// Set records the watermark of the space.
+	Set(spaceID string, watermark time.Time) error
+	// Clear removes the watermarks of all spaces, so they are indexed completely the next time.
+	Clear() error
+}
+
+type memoryWatermarkStore struct {
+	mu         sync.RWMutex
+	watermarks map[string]time.Time
+}
+
+// NewMemoryWatermarkStore returns a WatermarkStore which forgets the watermarks on restart.
+func NewMemoryWatermarkStore() WatermarkStore {
+	return &memoryWatermarkStore{
+		watermarks: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryWatermarkStore) Get(spaceID string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.watermarks[spaceID], nil
+}
+
+func (s *memoryWatermarkStore) Set(spaceID string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watermarks[spaceID] = watermark
+	return nil
+}
+
+func (s *memoryWatermarkStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watermarks = make(map[string]time.Time)
+	return nil
+}
+
+type fileWatermarkStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileWatermarkStore returns a WatermarkStore which keeps the watermarks of all spaces in a json file.
+func NewFileWatermarkStore(path string) WatermarkStore {
+	return &fileWatermarkStore{
+		path: path,
+	}
+}
+
+func (s *fileWatermarkStore) Get(spaceID string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watermarks, err := s.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return watermarks[spaceID], nil
+}
+
+func (s *fileWatermarkStore) Set(spaceID string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watermarks, err := s.read()
+	if err != nil {
+		return err
+	}
+	watermarks[spaceID] = watermark
+
+	data, err := json.Marshal(watermarks)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash never leaves a truncated file behind
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileWatermarkStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *fileWatermarkStore) read() (map[string]time.Time, error) {
+	watermarks := make(map[string]time.Time)
+
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return watermarks, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &watermarks); err != nil {
+		return nil, err
+	}
+
+	return watermarks, nil
+}
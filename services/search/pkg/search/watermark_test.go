@@ -0,0 +1,43 @@
+package search_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+var _ = Describe("FileWatermarkStore", func() {
+	It("keeps the watermarks of the spaces across instances", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "search", "watermarks.json")
+		watermark := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+
+		store := search.NewFileWatermarkStore(path)
+		Expect(store.Set("space1", watermark)).To(Succeed())
+
+		store = search.NewFileWatermarkStore(path)
+		got, err := store.Get("space1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got.Equal(watermark)).To(BeTrue())
+
+		got, err = store.Get("space2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got.IsZero()).To(BeTrue())
+	})
+
+	It("clears the watermarks of all spaces", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "search", "watermarks.json")
+
+		store := search.NewFileWatermarkStore(path)
+		Expect(store.Clear()).To(Succeed())
+		Expect(store.Set("space1", time.Now())).To(Succeed())
+		Expect(store.Clear()).To(Succeed())
+
+		got, err := store.Get("space1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got.IsZero()).To(BeTrue())
+	})
+})
@@ -15,6 +15,8 @@ type Options struct {
 	Logger  log.Logger
 	Context context.Context
 	Config  *config.Config
+	// EngineHealth reports whether the search engine is able to serve searches
+	EngineHealth func(ctx context.Context) error
 }
 
 // newOptions initializes the available default options.
@@ -48,3 +50,10 @@ func Config(val *config.Config) Option {
 		o.Config = val
 	}
 }
+
+// EngineHealth provides a function to set the engine health check option.
+func EngineHealth(val func(ctx context.Context) error) Option {
+	return func(o *Options) {
+		o.EngineHealth = val
+	}
+}
@@ -32,6 +32,10 @@ func Server(opts ...Option) (*http.Server, error) {
 			return nil
 		})
 
+	if options.EngineHealth != nil {
+		readyHandlerConfiguration = readyHandlerConfiguration.WithCheck("search engine health", options.EngineHealth)
+	}
+
 	return debug.NewService(
 		debug.Logger(options.Logger),
 		debug.Name(options.Config.Service.Name),
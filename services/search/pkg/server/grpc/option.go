@@ -19,16 +19,18 @@ type Option func(o *Options)
 
 // Options defines the available options for this package.
 type Options struct {
-	Name            string
-	Logger          log.Logger
-	Context         context.Context
-	Config          *config.Config
-	Metrics         *metrics.Metrics
-	Handler         *svc.Service
-	JWTSecret       string
-	TraceProvider   trace.TracerProvider
-	GatewaySelector *pool.Selector[gateway.GatewayAPIClient]
-	Searcher        search.Searcher
+	Name               string
+	Logger             log.Logger
+	Context            context.Context
+	Config             *config.Config
+	Metrics            *metrics.Metrics
+	Handler            *svc.Service
+	JWTSecret          string
+	PreviousJWTSecrets []string
+	JWTSecretSource    svc.SecretSource
+	TraceProvider      trace.TracerProvider
+	GatewaySelector    *pool.Selector[gateway.GatewayAPIClient]
+	Searcher           search.Searcher
 }
 
 // newOptions initializes the available default options.
@@ -91,6 +93,20 @@ func JWTSecret(val string) Option {
 	}
 }
 
+// PreviousJWTSecrets provides a function to set the PreviousJWTSecrets option.
+func PreviousJWTSecrets(val []string) Option {
+	return func(o *Options) {
+		o.PreviousJWTSecrets = val
+	}
+}
+
+// JWTSecretSource provides a function to set the JWTSecretSource option.
+func JWTSecretSource(val svc.SecretSource) Option {
+	return func(o *Options) {
+		o.JWTSecretSource = val
+	}
+}
+
 // TraceProvider provides a function to set the trace provider option.
 func TraceProvider(val trace.TracerProvider) Option {
 	return func(o *Options) {
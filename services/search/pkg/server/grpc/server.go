@@ -35,6 +35,8 @@ func Server(opts ...Option) (grpc.Service, error) {
 		svc.Config(options.Config),
 		svc.Logger(options.Logger),
 		svc.JWTSecret(options.JWTSecret),
+		svc.PreviousJWTSecrets(options.PreviousJWTSecrets),
+		svc.JWTSecretSource(options.JWTSecretSource),
 		svc.TracerProvider(options.TraceProvider),
 		svc.Metrics(options.Metrics),
 		svc.GatewaySelector(options.GatewaySelector),
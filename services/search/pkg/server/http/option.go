@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+// MetadataUpdater applies the metadata patches of external systems to the indexed resources.
+type MetadataUpdater interface {
+	UpdateMetadata(id string, patch map[string]string) error
+}
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for this package.
+type Options struct {
+	Logger          log.Logger
+	Config          *config.Config
+	MetadataUpdater MetadataUpdater
+}
+
+// newOptions initializes the available default options.
+func newOptions(opts ...Option) Options {
+	opt := Options{}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Logger provides a function to set the logger option.
+func Logger(val log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = val
+	}
+}
+
+// Config provides a function to set the config option.
+func Config(val *config.Config) Option {
+	return func(o *Options) {
+		o.Config = val
+	}
+}
+
+// Updater provides a function to set the metadata updater option.
+func Updater(val MetadataUpdater) Option {
+	return func(o *Options) {
+		o.MetadataUpdater = val
+	}
+}
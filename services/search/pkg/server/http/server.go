@@ -0,0 +1,81 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// maxMetadataPatchSize limits the size of the request bodies of the metadata webhook
+const maxMetadataPatchSize = 1 << 20
+
+// MetadataPatch is the request body of the metadata webhook
+type MetadataPatch struct {
+	// ID is the id of the resource, like "storage$space!opaque"
+	ID string `json:"id"`
+	// Metadata sets the values of its keys, keys with an empty value are removed
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Server initializes the http server of the metadata webhook.
+func Server(opts ...Option) (*http.Server, error) {
+	options := newOptions(opts...)
+
+	mux := chi.NewMux()
+	mux.Post("/metadata", MetadataHandler(options.MetadataUpdater, options.Config.MetadataWebhook.Secret, options.Logger))
+
+	return &http.Server{
+		Addr:              options.Config.MetadataWebhook.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}, nil
+}
+
+// MetadataHandler applies the metadata patches posted by external systems to the indexed resources.
+// The callers have to authenticate with the secret as bearer token.
+func MetadataHandler(updater MetadataUpdater, secret string, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		var patch MetadataPatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMetadataPatchSize)).Decode(&patch); err != nil {
+			http.Error(w, "invalid metadata patch", http.StatusBadRequest)
+			return
+		}
+		if patch.ID == "" || len(patch.Metadata) == 0 {
+			http.Error(w, "the resource id and metadata must not be empty", http.StatusBadRequest)
+			return
+		}
+
+		err := updater.UpdateMetadata(patch.ID, patch.Metadata)
+		var badRequest errtypes.BadRequest
+		var notFound errtypes.NotFound
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case errors.As(err, &badRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &notFound):
+			http.Error(w, "resource not found", http.StatusNotFound)
+		case errors.Is(err, search.ErrConflict):
+			// the resource kept changing while the patch was applied, the caller can send it again
+			http.Error(w, "the resource was changed concurrently", http.StatusConflict)
+		default:
+			logger.Error().Err(err).Str("resourceID", patch.ID).Msg("failed to apply the metadata patch")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	bleveSearch "github.com/blevesearch/bleve/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	bleveQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	searchhttp "github.com/opencloud-eu/opencloud/services/search/pkg/server/http"
+)
+
+var _ = Describe("MetadataHandler", func() {
+	var (
+		eng     *bleve.Backend
+		handler http.HandlerFunc
+
+		post = func(secret, body string) int {
+			req := httptest.NewRequest(http.MethodPost, "/metadata", strings.NewReader(body))
+			if secret != "" {
+				req.Header.Set("Authorization", "Bearer "+secret)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			return rec.Code
+		}
+		find = func(query string) []*searchmsg.Match {
+			res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+				Query: query,
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return res.Matches
+		}
+	)
+
+	BeforeEach(func() {
+		mapping, err := bleve.NewMapping()
+		Expect(err).ToNot(HaveOccurred())
+		idx, err := bleveSearch.NewMemOnly(mapping)
+		Expect(err).ToNot(HaveOccurred())
+		eng = bleve.NewBackend(idx, bleveQuery.DefaultCreator, log.Logger{})
+
+		Expect(eng.Upsert("1$2!3", search.Resource{
+			ID:       "1$2!3",
+			RootID:   "1$2!2",
+			Path:     "./report.pdf",
+			Document: content.Document{Name: "report.pdf"},
+		})).To(Succeed())
+
+		svc := search.NewService(nil, eng, nil, nil, log.Logger{}, &config.Config{})
+		handler = searchhttp.MetadataHandler(svc, "secret", log.Logger{})
+	})
+
+	It("adds the metadata to the resource", func() {
+		Expect(find("metadata:classification=confidential")).To(BeEmpty())
+
+		Expect(post("secret", `{"id": "1$2!3", "metadata": {"classification": "confidential"}}`)).To(Equal(http.StatusNoContent))
+
+		matches := find("metadata:classification=confidential")
+		Expect(matches).To(HaveLen(1))
+		Expect(matches[0].Entity.Name).To(Equal("report.pdf"))
+	})
+
+	It("rejects unauthenticated callers", func() {
+		Expect(post("", `{"id": "1$2!3", "metadata": {"classification": "confidential"}}`)).To(Equal(http.StatusUnauthorized))
+		Expect(post("wrong", `{"id": "1$2!3", "metadata": {"classification": "confidential"}}`)).To(Equal(http.StatusUnauthorized))
+
+		Expect(find("metadata:classification=confidential")).To(BeEmpty())
+	})
+
+	It("rejects unknown resources", func() {
+		Expect(post("secret", `{"id": "1$2!unknown", "metadata": {"classification": "confidential"}}`)).To(Equal(http.StatusNotFound))
+	})
+
+	It("reports resources which kept changing while the patch was applied", func() {
+		handler = searchhttp.MetadataHandler(conflictingUpdater{}, "secret", log.Logger{})
+
+		Expect(post("secret", `{"id": "1$2!3", "metadata": {"classification": "confidential"}}`)).To(Equal(http.StatusConflict))
+	})
+
+	It("rejects invalid patches", func() {
+		Expect(post("secret", `{"id": "1$2!3"}`)).To(Equal(http.StatusBadRequest))
+		Expect(post("secret", `{"id": "1$2!3", "metadata": {"a=b": "c"}}`)).To(Equal(http.StatusBadRequest))
+		Expect(post("secret", `not json`)).To(Equal(http.StatusBadRequest))
+	})
+})
+
+// conflictingUpdater fails all patches because of concurrent changes of the resources
+type conflictingUpdater struct{}
+
+func (conflictingUpdater) UpdateMetadata(id string, _ map[string]string) error {
+	return fmt.Errorf("%w: document %s was written after it was read", search.ErrConflict, id)
+}
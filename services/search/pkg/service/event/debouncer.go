@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -95,3 +96,49 @@ func (d *SpaceDebouncer) Debounce(id *provider.StorageSpaceId, ack AckFunc) {
 	d.pending[id.OpaqueId] = wi
 
 }
+
+// Flush starts all pending operations right away, use Wait to wait for them to finish
+func (d *SpaceDebouncer) Flush() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, wi := range d.pending {
+		// operations whose timer already fired are running already
+		if wi.t.Stop() {
+			go wi.work()
+		}
+	}
+}
+
+// Wait blocks until no operations are pending or in progress anymore
+func (d *SpaceDebouncer) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !d.idle() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+func (d *SpaceDebouncer) idle() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.pending) > 0 {
+		return false
+	}
+
+	idle := true
+	d.inProgress.Range(func(_, _ any) bool {
+		idle = false
+		return false
+	})
+
+	return idle
+}
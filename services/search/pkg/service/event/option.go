@@ -0,0 +1,130 @@
+package event
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+)
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for this package.
+type Options struct {
+	Logger             log.Logger
+	TracerProvider     trace.TracerProvider
+	Metrics            *metrics.Metrics
+	DebounceDuration   int
+	PurgeBatchDuration int
+	NumConsumers       int
+	ConsumerName       string
+	MaxInFlight        int
+	MaxProcessingTime  time.Duration
+	AckWait            time.Duration
+	ShutdownTimeout    time.Duration
+	AsyncUploads       bool
+}
+
+func newOptions(opts ...Option) Options {
+	opt := Options{
+		Logger:       log.NopLogger(),
+		NumConsumers: 1,
+	}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Logger provides a function to set the Logger option.
+func Logger(val log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = val
+	}
+}
+
+// TracerProvider provides a function to set the TracerProvider option.
+func TracerProvider(val trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.TracerProvider = val
+	}
+}
+
+// Metrics provides a function to set the Metrics option.
+func Metrics(val *metrics.Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = val
+	}
+}
+
+// DebounceDuration provides a function to set the DebounceDuration option in milliseconds.
+func DebounceDuration(val int) Option {
+	return func(o *Options) {
+		o.DebounceDuration = val
+	}
+}
+
+// PurgeBatchDuration provides a function to set the PurgeBatchDuration option in milliseconds.
+// Purged items are not batched if it is 0.
+func PurgeBatchDuration(val int) Option {
+	return func(o *Options) {
+		o.PurgeBatchDuration = val
+	}
+}
+
+// NumConsumers provides a function to set the NumConsumers option.
+func NumConsumers(val int) Option {
+	return func(o *Options) {
+		o.NumConsumers = val
+	}
+}
+
+// ConsumerName provides a function to set the ConsumerName option.
+func ConsumerName(val string) Option {
+	return func(o *Options) {
+		o.ConsumerName = val
+	}
+}
+
+// MaxInFlight provides a function to set the MaxInFlight option.
+// It defaults to the number of consumers if it is not positive.
+func MaxInFlight(val int) Option {
+	return func(o *Options) {
+		o.MaxInFlight = val
+	}
+}
+
+// MaxProcessingTime provides a function to set the MaxProcessingTime option.
+// The processing time of an event is not limited if it is 0.
+func MaxProcessingTime(val time.Duration) Option {
+	return func(o *Options) {
+		o.MaxProcessingTime = val
+	}
+}
+
+// AckWait provides a function to set the AckWait option. It has to match the ack wait time of the stream,
+// the events are reported to be in progress in half of it. The default ack wait time of the event system is used if it is 0.
+func AckWait(val time.Duration) Option {
+	return func(o *Options) {
+		o.AckWait = val
+	}
+}
+
+// ShutdownTimeout provides a function to set the ShutdownTimeout option.
+func ShutdownTimeout(val time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = val
+	}
+}
+
+// AsyncUploads provides a function to set the AsyncUploads option.
+func AsyncUploads(val bool) Option {
+	return func(o *Options) {
+		o.AsyncUploads = val
+	}
+}
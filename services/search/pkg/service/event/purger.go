@@ -0,0 +1,133 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// maxPurgeBatchSize limits the number of items which are purged combined
+const maxPurgeBatchSize = 500
+
+// PurgeBatcher collects the purge operations of a space for a configurable amount of time
+// and executes them combined. The events are acknowledged once the combined purge succeeded.
+type PurgeBatcher struct {
+	after        time.Duration
+	purgeItems   func(refs []*provider.Reference) error
+	purgeDeleted func(id *provider.StorageSpaceId) error
+	pending      map[string]*purgeBatch
+
+	mutex sync.Mutex
+	log   log.Logger
+}
+
+type purgeBatch struct {
+	t *time.Timer
+
+	spaceID      *provider.StorageSpaceId
+	refs         []*provider.Reference
+	purgeDeleted bool
+	acks         []AckFunc
+}
+
+// NewPurgeBatcher returns a new PurgeBatcher instance
+func NewPurgeBatcher(d time.Duration, purgeItems func(refs []*provider.Reference) error, purgeDeleted func(id *provider.StorageSpaceId) error, logger log.Logger) *PurgeBatcher {
+	return &PurgeBatcher{
+		after:        d,
+		purgeItems:   purgeItems,
+		purgeDeleted: purgeDeleted,
+		pending:      map[string]*purgeBatch{},
+		log:          logger,
+	}
+}
+
+// PurgeItem schedules purging the referenced item
+func (p *PurgeBatcher) PurgeItem(ref *provider.Reference, ack AckFunc) {
+	p.add(getSpaceID(ref), ack, func(b *purgeBatch) {
+		b.refs = append(b.refs, ref)
+	})
+}
+
+// PurgeDeleted schedules purging all deleted items of the given space
+func (p *PurgeBatcher) PurgeDeleted(id *provider.StorageSpaceId, ack AckFunc) {
+	p.add(id, ack, func(b *purgeBatch) {
+		b.purgeDeleted = true
+	})
+}
+
+func (p *PurgeBatcher) add(id *provider.StorageSpaceId, ack AckFunc, f func(b *purgeBatch)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	b := p.pending[id.GetOpaqueId()]
+	if b == nil {
+		b = &purgeBatch{spaceID: id}
+		b.t = time.AfterFunc(p.after, func() {
+			p.flush(id.GetOpaqueId(), b)
+		})
+		p.pending[id.GetOpaqueId()] = b
+	}
+
+	f(b)
+	if ack != nil {
+		b.acks = append(b.acks, ack)
+	}
+
+	if len(b.refs) >= maxPurgeBatchSize && b.t.Stop() {
+		go p.flush(id.GetOpaqueId(), b)
+	}
+}
+
+// Flush executes all pending purges right away and returns once they are done
+func (p *PurgeBatcher) Flush() {
+	p.mutex.Lock()
+	batches := make(map[string]*purgeBatch, len(p.pending))
+	for key, b := range p.pending {
+		// batches whose timer already fired are flushed by it
+		if b.t.Stop() {
+			batches[key] = b
+		}
+	}
+	p.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for key, b := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.flush(key, b)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *PurgeBatcher) flush(key string, b *purgeBatch) {
+	p.mutex.Lock()
+	if p.pending[key] == b {
+		delete(p.pending, key)
+	}
+	p.mutex.Unlock()
+
+	if b.purgeDeleted {
+		if err := p.purgeDeleted(b.spaceID); err != nil {
+			p.log.Error().Err(err).Interface("spaceID", b.spaceID).Msg("error while purging deleted items, the events will be redelivered")
+			return
+		}
+	}
+
+	if len(b.refs) > 0 {
+		if err := p.purgeItems(b.refs); err != nil {
+			p.log.Error().Err(err).Interface("spaceID", b.spaceID).Int("count", len(b.refs)).Msg("error while purging items, the events will be redelivered")
+			return
+		}
+	}
+
+	for _, ack := range b.acks {
+		if err := ack(); err != nil {
+			p.log.Error().Err(err).Msg("error while acknowledging event")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package event_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/service/event"
+)
+
+var _ = Describe("PurgeBatcher", func() {
+	var (
+		batcher *event.PurgeBatcher
+
+		mutex        sync.Mutex
+		purgedItems  [][]*sprovider.Reference
+		purgedSpaces []string
+		purgeErr     error
+		acks         atomic.Int32
+
+		ack = func() error {
+			acks.Add(1)
+			return nil
+		}
+		ref = func(spaceID, opaqueID string) *sprovider.Reference {
+			return &sprovider.Reference{ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: spaceID, OpaqueId: opaqueID}}
+		}
+		purgeCalls = func() int {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return len(purgedItems) + len(purgedSpaces)
+		}
+	)
+
+	BeforeEach(func() {
+		purgedItems = nil
+		purgedSpaces = nil
+		purgeErr = nil
+		acks = atomic.Int32{}
+
+		batcher = event.NewPurgeBatcher(50*time.Millisecond, func(refs []*sprovider.Reference) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			purgedItems = append(purgedItems, refs)
+			return purgeErr
+		}, func(id *sprovider.StorageSpaceId) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			purgedSpaces = append(purgedSpaces, id.GetOpaqueId())
+			return purgeErr
+		}, log.NewLogger())
+	})
+
+	It("coalesces the purges of a space", func() {
+		batcher.PurgeItem(ref("spaceid", "1"), ack)
+		batcher.PurgeItem(ref("spaceid", "2"), ack)
+		batcher.PurgeItem(ref("spaceid", "3"), ack)
+		batcher.PurgeDeleted(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid"}, ack)
+		batcher.PurgeDeleted(&sprovider.StorageSpaceId{OpaqueId: "storageid$spaceid"}, ack)
+
+		Eventually(func() int32 { return acks.Load() }, "200ms").Should(Equal(int32(5)))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(purgedSpaces).To(Equal([]string{"storageid$spaceid"}))
+		Expect(purgedItems).To(HaveLen(1))
+		Expect(purgedItems[0]).To(HaveLen(3))
+	})
+
+	It("purges different spaces separately", func() {
+		batcher.PurgeItem(ref("spaceid", "1"), ack)
+		batcher.PurgeItem(ref("otherspaceid", "2"), ack)
+
+		Eventually(func() int32 { return acks.Load() }, "200ms").Should(Equal(int32(2)))
+		Expect(purgeCalls()).To(Equal(2))
+	})
+
+	It("does not acknowledge the events if the purge fails", func() {
+		purgeErr = errors.New("failed")
+
+		batcher.PurgeItem(ref("spaceid", "1"), ack)
+		batcher.PurgeItem(ref("spaceid", "2"), ack)
+
+		Eventually(purgeCalls, "200ms").Should(Equal(1))
+		Consistently(func() int32 { return acks.Load() }, "100ms").Should(BeZero())
+	})
+})
@@ -0,0 +1,187 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
+)
+
+const (
+	// replayBatchSize is the number of events fetched at once during a replay
+	replayBatchSize = 100
+	// replayInactiveThreshold is the time after which the event system removes an abandoned replay consumer
+	replayInactiveThreshold = 5 * time.Minute
+)
+
+// ReplayOptions define where a replay starts, it starts with the first retained event if none is set.
+type ReplayOptions struct {
+	StartTime     time.Time
+	StartSequence uint64
+}
+
+// ReplayProgress describes the progress of a replay
+type ReplayProgress struct {
+	// Processed is the number of events which were fed through the index
+	Processed uint64
+	// Skipped is the number of events which are not relevant for the index or could not be decoded
+	Skipped uint64
+	// Sequence is the stream sequence of the last replayed event
+	Sequence uint64
+	// LastSequence is the stream sequence the replay stops at
+	LastSequence uint64
+}
+
+// Replay feeds the events retained by the event stream through the index. It uses an ephemeral consumer,
+// so it neither acknowledges the events nor interferes with the durable consumer of the running service.
+// The replay stops once all events are processed which were retained when it started, progress is reported
+// after every batch of events. Replaying the same events again leads to the same index.
+func (s Service) Replay(ctx context.Context, opts ReplayOptions, progress func(ReplayProgress)) (ReplayProgress, error) {
+	// the replay must not return before all operations are applied
+	s.replaying = true
+	s.purgeBatcher = nil
+
+	var p ReplayProgress
+	report := func() {
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	stream := s.stream.JetStream()
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return p, fmt.Errorf("failed to get the event stream info: %w", err)
+	}
+	p.LastSequence = info.State.LastSeq
+	if p.LastSequence == 0 || opts.StartSequence > p.LastSequence {
+		report()
+		return p, nil
+	}
+
+	cfg := jetstream.ConsumerConfig{
+		DeliverPolicy:     jetstream.DeliverAllPolicy,
+		AckPolicy:         jetstream.AckNonePolicy,
+		InactiveThreshold: replayInactiveThreshold,
+	}
+	switch {
+	case opts.StartSequence > 0:
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = opts.StartSequence
+	case !opts.StartTime.IsZero():
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &opts.StartTime
+	}
+
+	// a consumer without durable name is ephemeral
+	consumer, err := stream.CreateConsumer(ctx, cfg)
+	if err != nil {
+		return p, fmt.Errorf("failed to create the replay consumer: %w", err)
+	}
+	defer func() {
+		if err := stream.DeleteConsumer(context.Background(), consumer.CachedInfo().Name); err != nil {
+			s.log.Debug().Err(err).Msg("failed to delete the replay consumer")
+		}
+	}()
+
+	registeredEvents := make(map[string]events.Unmarshaller, len(s.events))
+	for _, e := range s.events {
+		registeredEvents[reflect.TypeOf(e).String()] = e
+	}
+
+	for p.Sequence < p.LastSequence {
+		fetched, err := fetchReplayBatch(ctx, consumer, func(msg jetstream.Msg) {
+			if meta, err := msg.Metadata(); err == nil {
+				p.Sequence = meta.Sequence.Stream
+			}
+
+			e, ok := decodeReplayEvent(msg.Data(), registeredEvents)
+			if !ok {
+				p.Skipped++
+				return
+			}
+
+			if err := s.processEvent(e, nil); err != nil {
+				s.log.Error().Err(err).Interface("event", e).Msg("failed to replay event")
+			}
+			p.Processed++
+		})
+		if err != nil {
+			return p, err
+		}
+		if fetched == 0 {
+			// no further events are retained, e.g. if the start time is after the last event
+			break
+		}
+
+		report()
+	}
+
+	if err := s.indexSpaceDebouncer.Wait(ctx); err != nil {
+		return p, err
+	}
+	report()
+
+	return p, nil
+}
+
+// fetchReplayBatch hands the next batch of events to f without waiting for new events,
+// it returns the number of fetched events.
+func fetchReplayBatch(ctx context.Context, consumer jetstream.Consumer, f func(msg jetstream.Msg)) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	batch, err := consumer.FetchNoWait(replayBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch events: %w", err)
+	}
+
+	fetched := 0
+	for msg := range batch.Messages() {
+		f(msg)
+		fetched++
+	}
+
+	if err := batch.Error(); err != nil && !errors.Is(err, jetstream.ErrNoMessages) && !errors.Is(err, nats.ErrTimeout) {
+		return fetched, fmt.Errorf("failed to fetch events: %w", err)
+	}
+
+	return fetched, nil
+}
+
+// decodeReplayEvent decodes a retained event the same way the event stream does for consumed events.
+func decodeReplayEvent(data []byte, registeredEvents map[string]events.Unmarshaller) (raw.Event, bool) {
+	var re raw.RawEvent
+	if err := json.Unmarshal(data, &re); err != nil {
+		return raw.Event{}, false
+	}
+
+	eventType := re.Metadata[events.MetadatakeyEventType]
+	u, ok := registeredEvents[eventType]
+	if !ok {
+		return raw.Event{}, false
+	}
+
+	event, err := u.Unmarshal(re.Payload)
+	if err != nil {
+		return raw.Event{}, false
+	}
+
+	return raw.Event{
+		Event: events.Event{
+			Type:        eventType,
+			ID:          re.Metadata[events.MetadatakeyEventID],
+			TraceParent: re.Metadata[events.MetadatakeyTraceParent],
+			InitiatorID: re.Metadata[events.MetadatakeyInitiatorID],
+			Event:       event,
+		},
+	}, true
+}
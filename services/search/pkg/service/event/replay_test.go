@@ -0,0 +1,121 @@
+package event_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
+	"github.com/stretchr/testify/mock"
+
+	searchMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/service/event"
+)
+
+var _ = Describe("Replay", func() {
+	var (
+		ctx      = context.Background()
+		searcher *searchMocks.Searcher
+		svc      event.Service
+
+		ref = func(spaceID, opaqueID string) *provider.Reference {
+			return &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storageid", SpaceId: spaceID, OpaqueId: opaqueID}}
+		}
+		isSpace = func(id string) any {
+			return mock.MatchedBy(func(spaceID *provider.StorageSpaceId) bool {
+				return spaceID.GetOpaqueId() == id
+			})
+		}
+	)
+
+	BeforeEach(func() {
+		server, err := nserver.NewServer(&nserver.Options{
+			Port:      nserver.RANDOM_PORT,
+			JetStream: true,
+			StoreDir:  GinkgoT().TempDir(),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Start()
+		DeferCleanup(server.Shutdown)
+		Expect(server.ReadyForConnections(5 * time.Second)).To(BeTrue())
+
+		conn, err := nats.Connect(server.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+
+		js, err := jetstream.New(conn)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:     events.MainQueueName,
+			Subjects: []string{events.MainQueueName},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, e := range []any{
+			events.ContainerCreated{Ref: ref("space1", "1")},
+			events.ItemTrashed{ID: ref("space1", "2").GetResourceId(), Ref: ref("space1", "2")},
+			events.LinkCreated{},
+			events.ItemPurged{Ref: ref("space2", "3")},
+			events.FileTouched{Ref: ref("space2", "4")},
+		} {
+			payload, err := json.Marshal(e)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := json.Marshal(raw.RawEvent{
+				Topic:    events.MainQueueName,
+				Metadata: map[string]string{events.MetadatakeyEventType: reflect.TypeOf(e).String()},
+				Payload:  payload,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = js.Publish(ctx, events.MainQueueName, data)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		stream, err := raw.FromConfig(ctx, "search-replay-test", raw.Config{Endpoint: server.ClientURL()})
+		Expect(err).ToNot(HaveOccurred())
+
+		searcher = searchMocks.NewSearcher(GinkgoT())
+		svc, err = event.New(ctx, stream, searcher, event.DebounceDuration(50), event.ConsumerName("search-pull"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("replays the retained events into the index", func() {
+		searcher.EXPECT().IndexSpace(isSpace("storageid$space1")).Return(nil).Once()
+		searcher.EXPECT().TrashItem(mock.Anything, mock.Anything).Once()
+		searcher.EXPECT().PurgeItem(mock.Anything).Once()
+		// the resources of the space no longer exist
+		searcher.EXPECT().IndexSpace(isSpace("storageid$space2")).Return(errors.New("not found")).Once()
+
+		var reported []event.ReplayProgress
+		progress, err := svc.Replay(ctx, event.ReplayOptions{}, func(p event.ReplayProgress) {
+			reported = append(reported, p)
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(progress).To(Equal(event.ReplayProgress{Processed: 4, Skipped: 1, Sequence: 5, LastSequence: 5}))
+		Expect(reported).ToNot(BeEmpty())
+		Expect(reported[len(reported)-1]).To(Equal(progress))
+	})
+
+	It("replays the events from the given sequence", func() {
+		searcher.EXPECT().PurgeItem(mock.Anything).Once()
+		searcher.EXPECT().IndexSpace(isSpace("storageid$space2")).Return(nil).Once()
+
+		progress, err := svc.Replay(ctx, event.ReplayOptions{StartSequence: 4}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(progress).To(Equal(event.ReplayProgress{Processed: 2, Skipped: 0, Sequence: 5, LastSequence: 5}))
+	})
+
+	It("does not replay events retained before the given time", func() {
+		progress, err := svc.Replay(ctx, event.ReplayOptions{StartTime: time.Now().Add(time.Hour)}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(progress.Processed).To(BeZero())
+	})
+})
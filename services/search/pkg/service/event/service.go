@@ -2,6 +2,9 @@ package event
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,10 +16,14 @@ import (
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultAckWait is the ack wait time of the event system if none is configured
+const defaultAckWait = 30 * time.Second
+
 var tracer trace.Tracer
 
 func init() {
@@ -26,6 +33,7 @@ func init() {
 // Service defines the service handlers.
 type Service struct {
 	ctx                 context.Context
+	cancel              context.CancelFunc
 	log                 log.Logger
 	tp                  trace.TracerProvider
 	m                   *metrics.Metrics
@@ -33,22 +41,42 @@ type Service struct {
 	events              []events.Unmarshaller
 	stream              raw.Stream
 	indexSpaceDebouncer *SpaceDebouncer
+	purgeBatcher        *PurgeBatcher
 	numConsumers        int
+	consumerName        string
+	inFlight            chan struct{}
+	maxProcessingTime   time.Duration
+	ackWait             time.Duration
+	shutdownTimeout     time.Duration
+	replaying           bool
 	stopCh              chan struct{}
 	stopped             *atomic.Bool
+	// mu guards stopping the service against starting to process further events
+	mu         *sync.RWMutex
+	processing *sync.WaitGroup
 }
 
 // New returns a service implementation for Service.
-func New(ctx context.Context, stream raw.Stream, logger log.Logger, tp trace.TracerProvider, m *metrics.Metrics, index search.Searcher, debounceDuration int, numConsumers int, asyncUploads bool) (Service, error) {
+func New(ctx context.Context, stream raw.Stream, index search.Searcher, opts ...Option) (Service, error) {
+	o := newOptions(opts...)
+	maxInFlight := o.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = max(o.NumConsumers, 1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	svc := Service{
-		ctx:     ctx,
-		log:     logger,
-		tp:      tp,
-		m:       m,
-		index:   index,
-		stream:  stream,
-		stopCh:  make(chan struct{}, 1),
-		stopped: new(atomic.Bool),
+		ctx:        ctx,
+		cancel:     cancel,
+		log:        o.Logger,
+		tp:         o.TracerProvider,
+		m:          o.Metrics,
+		index:      index,
+		stream:     stream,
+		stopCh:     make(chan struct{}, 1),
+		stopped:    new(atomic.Bool),
+		mu:         new(sync.RWMutex),
+		processing: new(sync.WaitGroup),
 		events: []events.Unmarshaller{
 			events.ItemTrashed{},
 			events.ItemPurged{},
@@ -61,34 +89,48 @@ func New(ctx context.Context, stream raw.Stream, logger log.Logger, tp trace.Tra
 			events.TagsAdded{},
 			events.TagsRemoved{},
 			events.SpaceRenamed{},
+			events.ShareCreated{},
+			events.ShareUpdated{},
+			events.ShareRemoved{},
+			events.FileLocked{},
+			events.FileUnlocked{},
 		},
-		numConsumers: numConsumers,
+		numConsumers:      o.NumConsumers,
+		consumerName:      o.ConsumerName,
+		inFlight:          make(chan struct{}, maxInFlight),
+		maxProcessingTime: o.MaxProcessingTime,
+		ackWait:           o.AckWait,
+		shutdownTimeout:   o.ShutdownTimeout,
 	}
 
-	if asyncUploads {
+	if o.AsyncUploads {
 		svc.events = append(svc.events, events.UploadReady{})
 	} else {
 		svc.events = append(svc.events, events.FileUploaded{})
 	}
 
-	svc.indexSpaceDebouncer = NewSpaceDebouncer(time.Duration(debounceDuration)*time.Millisecond, 30*time.Second, func(id *provider.StorageSpaceId) {
+	svc.indexSpaceDebouncer = NewSpaceDebouncer(time.Duration(o.DebounceDuration)*time.Millisecond, 30*time.Second, func(id *provider.StorageSpaceId) {
 		if err := svc.index.IndexSpace(id); err != nil {
 			svc.log.Error().Err(err).Interface("spaceID", id).Msg("error while indexing a space")
 		}
 	}, svc.log)
 
+	if o.PurgeBatchDuration > 0 {
+		svc.purgeBatcher = NewPurgeBatcher(time.Duration(o.PurgeBatchDuration)*time.Millisecond, svc.index.PurgeItems, svc.index.PurgeDeleted, svc.log)
+	}
+
 	return svc, nil
 }
 
 // Run to fulfil Runner interface
 func (s Service) Run() error {
-	ch, err := s.stream.Consume("search-pull", s.events...)
+	ch, err := s.stream.Consume(s.consumerName, s.events...)
 	if err != nil {
 		return err
 	}
 
 	if s.m != nil {
-		monitorMetrics(s.stream, "search-pull", s.m, s.log)
+		monitorMetrics(s.stream, s.consumerName, s.m, s.log)
 	}
 
 	var wg sync.WaitGroup
@@ -96,7 +138,7 @@ func (s Service) Run() error {
 	defer cancel()
 
 	s.log.Debug().Int("worker.count", s.numConsumers).
-		Str("messaging.consumer.group.name", "search-pull").
+		Str("messaging.consumer.group.name", s.consumerName).
 		Str("messaging.system", "nats").
 		Str("messaging.operation.name", "receive").
 		Msg("starting event processing workers")
@@ -114,12 +156,7 @@ func (s Service) Run() error {
 					if !ok {
 						return
 					}
-					if err := s.processEvent(e); err != nil {
-						s.log.Error().Err(err).
-							Int("worker", workerID).
-							Interface("event", e).
-							Msg("failed to process event")
-					}
+					s.handleEvent(ctx, workerID, e)
 				}
 			}
 		}(i)
@@ -134,14 +171,159 @@ func (s Service) Run() error {
 }
 
 // Close will make the service to stop processing, so the `Run`
-// method can finish.
-// TODO: Underlying services can't be stopped. This means that some goroutines
-// will get stuck trying to push events through a channel nobody is reading
-// from, so resources won't be freed and there will be memory leaks. For now,
-// if the service is stopped, you should close the app soon after.
-func (s Service) Close() {
-	if s.stopped.CompareAndSwap(false, true) {
-		close(s.stopCh)
+// method can finish. It waits for the events which are currently processed,
+// runs the pending space indexing and purges right away and waits for them to finish.
+// An error is returned if draining takes longer than the shutdown timeout,
+// the remaining work keeps running in the background in that case.
+// The stream is closed if it supports it, otherwise the event system
+// redelivers the events which are not acknowledged after the ack wait time.
+func (s Service) Close() error {
+	s.mu.Lock()
+	if !s.stopped.CompareAndSwap(false, true) {
+		s.mu.Unlock()
+		return nil
+	}
+	close(s.stopCh)
+	s.cancel()
+	s.mu.Unlock()
+
+	ctx := context.Background()
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	if err := s.drain(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if closer, ok := s.stream.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close the event stream: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// drain waits for the events in flight and runs the pending work right away
+func (s Service) drain(ctx context.Context) error {
+	if err := waitFor(ctx, s.processing.Wait); err != nil {
+		return fmt.Errorf("timeout while waiting for the events in flight to be processed: %w", err)
+	}
+
+	if s.purgeBatcher != nil {
+		if err := waitFor(ctx, s.purgeBatcher.Flush); err != nil {
+			return fmt.Errorf("timeout while waiting for the pending purges: %w", err)
+		}
+	}
+
+	s.indexSpaceDebouncer.Flush()
+	if err := s.indexSpaceDebouncer.Wait(ctx); err != nil {
+		return fmt.Errorf("timeout while waiting for the pending space indexing: %w", err)
+	}
+
+	return nil
+}
+
+// waitFor calls f and waits for it to return until the context is done
+func waitFor(ctx context.Context, f func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleEvent processes the event once an in-flight slot is free and waits at most the
+// maximum processing time for it. Events which take longer keep their slot until they are done.
+// The event is reported to be in progress to the event system while it is processed, so it is not
+// redelivered while the processing is running, and it is acknowledged once it is processed.
+func (s Service) handleEvent(ctx context.Context, workerID int, e raw.Event) {
+	select {
+	case s.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+
+	// the service might have been stopped while waiting for a free slot
+	s.mu.RLock()
+	if s.stopped.Load() {
+		s.mu.RUnlock()
+		<-s.inFlight
+		return
+	}
+	s.processing.Add(1)
+	s.mu.RUnlock()
+
+	if s.m != nil {
+		s.m.EventsInFlight.Inc()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer func() {
+			s.processing.Done()
+			<-s.inFlight
+			if s.m != nil {
+				s.m.EventsInFlight.Dec()
+			}
+			close(done)
+		}()
+
+		if err := s.processEvent(e, e.Ack); err != nil {
+			s.log.Error().Err(err).
+				Int("worker", workerID).
+				Interface("event", e).
+				Msg("failed to process event")
+		}
+	}()
+	go s.keepInProgress(&e, done)
+
+	var timeout <-chan time.Time
+	if s.maxProcessingTime > 0 {
+		timer := time.NewTimer(s.maxProcessingTime)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-timeout:
+		s.log.Warn().
+			Int("worker", workerID).
+			Interface("event", e).
+			Dur("maxProcessingTime", s.maxProcessingTime).
+			Msg("processing the event takes too long, continuing with the next event")
+	}
+}
+
+// keepInProgress reports the event to be in progress right away and then in half of the ack wait time until done is closed
+func (s Service) keepInProgress(e *raw.Event, done <-chan struct{}) {
+	ackWait := s.ackWait
+	if ackWait <= 0 {
+		ackWait = defaultAckWait
+	}
+	ticker := time.NewTicker(ackWait / 2)
+	defer ticker.Stop()
+
+	for {
+		_ = e.InProgress() // let nats know that we are processing this event
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -156,57 +338,149 @@ func getSpaceID(ref *provider.Reference) *provider.StorageSpaceId {
 	}
 }
 
-func (s Service) processEvent(e raw.Event) error {
+// processEvent applies the event to the index, ack acknowledges the event once it is applied
+func (s Service) processEvent(e raw.Event, ack AckFunc) error {
 	ctx := e.GetTraceContext(s.ctx)
 	_, span := tracer.Start(ctx, "processEvent")
 	defer span.End()
 
-	e.InProgress() // let nats know that we are processing this event
+	if s.replaying {
+		// replayed events are read without acknowledgement
+		ack = nil
+	}
 	s.log.Debug().Interface("event", e).Msg("updating index")
 
 	switch ev := e.Event.Event.(type) {
 	case events.ItemTrashed:
-		s.index.TrashItem(ev.ID)
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.index.TrashItem(ev.ID, utils.TSToTime(ev.Timestamp))
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack))
 	case events.ItemPurged:
-		s.index.PurgeItem(ev.Ref)
-		e.Ack()
+		ack := s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack)
+		if s.purgeBatcher != nil {
+			s.purgeBatcher.PurgeItem(ev.Ref, ack)
+		} else {
+			s.index.PurgeItem(ev.Ref)
+			if ack != nil {
+				if err := ack(); err != nil {
+					s.log.Error().Err(err).Msg("error while acknowledging event")
+				}
+			}
+		}
 	case events.TrashbinPurged:
-		s.index.PurgeDeleted(getSpaceID(ev.Ref))
-		e.Ack()
+		if s.purgeBatcher != nil {
+			s.purgeBatcher.PurgeDeleted(getSpaceID(ev.Ref), ack)
+		} else {
+			s.index.PurgeDeleted(getSpaceID(ev.Ref))
+			if ack != nil {
+				if err := ack(); err != nil {
+					s.log.Error().Err(err).Msg("error while acknowledging event")
+				}
+			}
+		}
 	case events.ItemMoved:
-		s.index.MoveItem(ev.Ref)
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.index.MoveItem(ev.Ref, utils.TSToTime(ev.Timestamp))
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack))
 	case events.ItemRestored:
-		s.index.RestoreItem(ev.Ref)
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.index.RestoreItem(ev.Ref, utils.TSToTime(ev.Timestamp))
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack))
 	case events.ContainerCreated:
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), ack)
 	case events.FileTouched:
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), ack)
 	case events.FileVersionRestored:
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), ack)
 	case events.TagsAdded:
-		s.index.UpsertItem(ev.Ref)
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.index.UpsertItem(ev.Ref, utils.TSToTime(ev.Timestamp))
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack))
 	case events.TagsRemoved:
-		s.index.UpsertItem(ev.Ref)
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.index.UpsertItem(ev.Ref, utils.TSToTime(ev.Timestamp))
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), s.ackAfterQueuedChanges(getSpaceID(ev.Ref), ack))
 	case events.FileUploaded:
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), e.Ack)
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.Ref), ack)
 	case events.UploadReady:
-		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.FileRef), e.Ack)
+		s.indexSpaceDebouncer.Debounce(getSpaceID(ev.FileRef), ack)
 	case events.SpaceRenamed:
-		s.indexSpaceDebouncer.Debounce(ev.ID, e.Ack)
+		if err := s.index.UpdateSpaceName(ev.ID, ev.Name); err != nil {
+			s.log.Error().Err(err).Interface("spaceID", ev.ID).Msg("failed to update the space name in the index")
+		}
+		s.indexSpaceDebouncer.Debounce(ev.ID, s.ackAfterQueuedChanges(ev.ID, ack))
+	case events.ShareCreated:
+		s.updateSharedWith(ev.ItemID, ack)
+	case events.ShareUpdated:
+		s.updateSharedWith(ev.ItemID, ack)
+	case events.ShareRemoved:
+		s.updateSharedWith(ev.ItemID, ack)
+	case events.FileLocked:
+		s.updateLock(ev.Ref, ack)
+	case events.FileUnlocked:
+		s.updateLock(ev.Ref, ack)
+	default:
+		// the event type is consumed but not handled, acknowledge it so it is not redelivered forever
+		typ := fmt.Sprintf("%T", ev)
+		s.log.Warn().Str("type", typ).Msg("unhandled event type")
+		if s.m != nil {
+			s.m.UnhandledEvents.WithLabelValues(typ).Inc()
+		}
+		if ack != nil {
+			if err := ack(); err != nil {
+				s.log.Error().Err(err).Msg("error while acknowledging event")
+			}
+		}
 	}
 	return nil
 }
 
+// updateSharedWith updates the users and groups the shared resource is shared with in the index
+func (s Service) updateSharedWith(rID *provider.ResourceId, ack AckFunc) {
+	s.index.UpdateSharedWith(rID)
+	ack = s.ackAfterQueuedChanges(getSpaceID(&provider.Reference{ResourceId: rID}), ack)
+	if ack != nil {
+		if err := ack(); err != nil {
+			s.log.Error().Err(err).Msg("error while acknowledging event")
+		}
+	}
+}
+
+// updateLock updates the lock of the locked or unlocked resource in the index
+func (s Service) updateLock(ref *provider.Reference, ack AckFunc) {
+	s.index.UpdateLock(ref)
+	ack = s.ackAfterQueuedChanges(getSpaceID(ref), ack)
+	if ack != nil {
+		if err := ack(); err != nil {
+			s.log.Error().Err(err).Msg("error while acknowledging event")
+		}
+	}
+}
+
+// ackAfterQueuedChanges returns an AckFunc which acknowledges the event only once the changes of the space which
+// the index queued are applied, so the event is redelivered if the service stops before the queued change is applied.
+func (s Service) ackAfterQueuedChanges(spaceID *provider.StorageSpaceId, ack AckFunc) AckFunc {
+	queue, ok := s.index.(search.ChangeQueue)
+	if !ok || ack == nil {
+		return ack
+	}
+
+	return func() error {
+		queue.AfterQueuedChanges(spaceID, func() {
+			if err := ack(); err != nil {
+				s.log.Error().Err(err).Msg("error while acknowledging event")
+			}
+		})
+		return nil
+	}
+}
+
 func monitorMetrics(stream raw.Stream, name string, m *metrics.Metrics, logger log.Logger) {
+	js := stream.JetStream()
+	if js == nil {
+		return
+	}
+
 	ctx := context.Background()
-	consumer, err := stream.JetStream().Consumer(ctx, name)
+	consumer, err := js.Consumer(ctx, name)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to get consumer")
+		return
 	}
 	ticker := time.NewTicker(5 * time.Second)
 	go func() {
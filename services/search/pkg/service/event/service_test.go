@@ -2,20 +2,96 @@ package event_test
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
+	"runtime"
 	"sync/atomic"
+	"time"
 
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
 	searchMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/service/event"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
 	rawMocks "github.com/opencloud-eu/reva/v2/pkg/events/raw/mocks"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/mock"
 )
 
+// startJetStream starts a nats server with the main event queue and returns a client and an event stream of it
+func startJetStream(ctx context.Context, name string, ackWait time.Duration) (jetstream.JetStream, raw.Stream) {
+	server, err := nserver.NewServer(&nserver.Options{
+		Port:      nserver.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  GinkgoT().TempDir(),
+	})
+	Expect(err).ToNot(HaveOccurred())
+	go server.Start()
+	DeferCleanup(server.Shutdown)
+	Expect(server.ReadyForConnections(5 * time.Second)).To(BeTrue())
+
+	conn, err := nats.Connect(server.ClientURL())
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	Expect(err).ToNot(HaveOccurred())
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     events.MainQueueName,
+		Subjects: []string{events.MainQueueName},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	stream, err := raw.FromConfig(ctx, name, raw.Config{Endpoint: server.ClientURL(), AckWait: ackWait})
+	Expect(err).ToNot(HaveOccurred())
+	return js, stream
+}
+
+// publish publishes the event once the consumer of the service exists, it only delivers the events published after it was created
+func publish(ctx context.Context, js jetstream.JetStream, ev any) {
+	Eventually(func() error {
+		_, err := js.Consumer(ctx, events.MainQueueName, "search-pull")
+		return err
+	}, "2s").Should(Succeed())
+
+	payload, err := json.Marshal(ev)
+	Expect(err).ToNot(HaveOccurred())
+	data, err := json.Marshal(raw.RawEvent{
+		Topic:    events.MainQueueName,
+		Metadata: map[string]string{events.MetadatakeyEventType: reflect.TypeOf(ev).String()},
+		Payload:  payload,
+	})
+	Expect(err).ToNot(HaveOccurred())
+	_, err = js.Publish(ctx, events.MainQueueName, data)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// unhandledStream consumes additional event types the service has no handler for
+type unhandledStream struct {
+	raw.Stream
+	unhandled []events.Unmarshaller
+}
+
+func (s unhandledStream) Consume(group string, evs ...events.Unmarshaller) (<-chan raw.Event, error) {
+	return s.Stream.Consume(group, append(evs, s.unhandled...)...)
+}
+
+// queuingSearcher queues the callbacks which wait for the queued changes of a space
+type queuingSearcher struct {
+	*searchMocks.Searcher
+	queued chan func()
+}
+
+func (s queuingSearcher) AfterQueuedChanges(_ *provider.StorageSpaceId, f func()) {
+	s.queued <- f
+}
+
 var _ = DescribeTable("event",
 	func(mcks []string, e any, asyncUploads bool) {
 		var (
@@ -27,7 +103,7 @@ var _ = DescribeTable("event",
 		ch := make(chan raw.Event, 1)
 		stream.EXPECT().Consume(mock.Anything, mock.Anything).Return((<-chan raw.Event)(ch), nil)
 
-		event, err := event.New(context.Background(), stream, log.NewLogger(), nil, nil, s, 50, 1, asyncUploads)
+		event, err := event.New(context.Background(), stream, s, event.DebounceDuration(50), event.ConsumerName("search-pull"), event.AsyncUploads(asyncUploads))
 		Expect(err).NotTo(HaveOccurred())
 
 		go func() {
@@ -60,4 +136,313 @@ var _ = DescribeTable("event",
 	Entry("TagsRemoved", []string{"UpsertItem", "IndexSpace"}, events.TagsRemoved{}, false),
 	Entry("FileUploaded", []string{"IndexSpace"}, events.FileUploaded{}, false),
 	Entry("UploadReady", []string{"IndexSpace"}, events.UploadReady{ExecutingUser: &userv1beta1.User{}}, true),
+	Entry("SpaceRenamed", []string{"UpdateSpaceName", "IndexSpace"}, events.SpaceRenamed{ID: &provider.StorageSpaceId{OpaqueId: "storageid$spaceid"}, Name: "Campaigns"}, false),
+	Entry("ShareCreated", []string{"UpdateSharedWith"}, events.ShareCreated{}, false),
+	Entry("ShareUpdated", []string{"UpdateSharedWith"}, events.ShareUpdated{}, false),
+	Entry("ShareRemoved", []string{"UpdateSharedWith"}, events.ShareRemoved{}, false),
+	Entry("FileLocked", []string{"UpdateLock"}, events.FileLocked{}, false),
+	Entry("FileUnlocked", []string{"UpdateLock"}, events.FileUnlocked{}, false),
 )
+
+var _ = Describe("Service", func() {
+	It("consumes the events with the configured consumer name", func() {
+		stream := rawMocks.NewStream(GinkgoT())
+		ch := make(chan raw.Event)
+		stream.EXPECT().Consume("blue-search-pull", mock.Anything).Return((<-chan raw.Event)(ch), nil).Once()
+
+		svc, err := event.New(context.Background(), stream, &searchMocks.Searcher{}, event.DebounceDuration(50), event.ConsumerName("blue-search-pull"))
+		Expect(err).NotTo(HaveOccurred())
+
+		done := make(chan error)
+		go func() {
+			done <- svc.Run()
+		}()
+
+		svc.Close()
+		Eventually(done, "2s").Should(Receive(BeNil()))
+	})
+
+	It("acknowledges and counts events without a handler", func() {
+		ctx := context.Background()
+		js, stream := startJetStream(ctx, "search-unhandled-test", 0)
+
+		m := metrics.New()
+		unhandled := func() float64 {
+			metric := &dto.Metric{}
+			Expect(m.UnhandledEvents.WithLabelValues("events.LinkCreated").Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue()
+		}
+		before := unhandled()
+
+		svc, err := event.New(ctx, unhandledStream{Stream: stream, unhandled: []events.Unmarshaller{events.LinkCreated{}}}, searchMocks.NewSearcher(GinkgoT()),
+			event.Metrics(m),
+			event.DebounceDuration(50),
+			event.ConsumerName("search-pull"),
+			event.ShutdownTimeout(time.Second),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			Expect(svc.Run()).To(Succeed())
+		}()
+		DeferCleanup(svc.Close)
+
+		publish(ctx, js, events.LinkCreated{})
+
+		Eventually(unhandled, "2s").Should(Equal(before + 1))
+		Eventually(func() uint64 {
+			info, err := js.Consumer(ctx, events.MainQueueName, "search-pull")
+			Expect(err).ToNot(HaveOccurred())
+			return info.CachedInfo().AckFloor.Consumer
+		}, "2s").Should(Equal(uint64(1)))
+	})
+
+	It("acknowledges the events once the changes queued by the index are applied", func() {
+		ctx := context.Background()
+		js, stream := startJetStream(ctx, "search-queued-test", 0)
+
+		s := queuingSearcher{Searcher: searchMocks.NewSearcher(GinkgoT()), queued: make(chan func(), 1)}
+		s.EXPECT().PurgeItem(mock.Anything).Return()
+
+		svc, err := event.New(ctx, stream, s,
+			event.DebounceDuration(50),
+			event.ConsumerName("search-pull"),
+			event.ShutdownTimeout(time.Second),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			Expect(svc.Run()).To(Succeed())
+		}()
+		DeferCleanup(svc.Close)
+
+		publish(ctx, js, events.ItemPurged{Ref: &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "fileid"}}})
+
+		ackFloor := func() uint64 {
+			info, err := js.Consumer(ctx, events.MainQueueName, "search-pull")
+			Expect(err).ToNot(HaveOccurred())
+			return info.CachedInfo().AckFloor.Consumer
+		}
+		var applied func()
+		Eventually(s.queued, "2s").Should(Receive(&applied))
+		Consistently(ackFloor, "300ms").Should(BeZero())
+
+		applied()
+		Eventually(ackFloor, "2s").Should(Equal(uint64(1)))
+	})
+
+	It("keeps the events which take longer than the maximum processing time in progress until they are processed", func() {
+		ctx := context.Background()
+		js, stream := startJetStream(ctx, "search-redelivery-test", 500*time.Millisecond)
+
+		release := make(chan struct{})
+		purged := make(chan string, 2)
+		var deliveries atomic.Int32
+		s := searchMocks.NewSearcher(GinkgoT())
+		s.EXPECT().PurgeItem(mock.Anything).Run(func(ref *provider.Reference) {
+			deliveries.Add(1)
+			<-release
+			purged <- ref.GetPath()
+		})
+
+		svc, err := event.New(ctx, stream, s,
+			event.DebounceDuration(50),
+			event.ConsumerName("search-pull"),
+			event.MaxInFlight(2),
+			event.MaxProcessingTime(100*time.Millisecond),
+			event.AckWait(500*time.Millisecond),
+			event.ShutdownTimeout(time.Second),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			Expect(svc.Run()).To(Succeed())
+		}()
+		DeferCleanup(svc.Close)
+
+		publish(ctx, js, events.ItemPurged{Ref: &provider.Reference{Path: "slow"}})
+
+		// the slow event is not redelivered while it is processed, even after the ack wait time has passed
+		Eventually(deliveries.Load, "2s").Should(BeEquivalentTo(1))
+		Consistently(deliveries.Load, "1500ms").Should(BeEquivalentTo(1))
+
+		close(release)
+		Eventually(purged, "2s").Should(Receive(Equal("slow")))
+		Eventually(func() uint64 {
+			info, err := js.Consumer(ctx, events.MainQueueName, "search-pull")
+			Expect(err).ToNot(HaveOccurred())
+			return info.CachedInfo().AckFloor.Consumer
+		}, "2s").Should(Equal(uint64(1)))
+		Expect(deliveries.Load()).To(BeEquivalentTo(1))
+	})
+
+	Context("with a slow index", func() {
+		var (
+			s        *searchMocks.Searcher
+			ch       chan raw.Event
+			release  chan struct{}
+			purged   chan string
+			newEvent = func(path string) raw.Event {
+				return raw.Event{Event: events.Event{Event: events.ItemPurged{Ref: &provider.Reference{Path: path}}}}
+			}
+			run = func(maxInFlight int) {
+				stream := rawMocks.NewStream(GinkgoT())
+				stream.EXPECT().Consume(mock.Anything, mock.Anything).Return((<-chan raw.Event)(ch), nil)
+
+				svc, err := event.New(context.Background(), stream, s,
+					event.DebounceDuration(50),
+					event.ConsumerName("search-pull"),
+					event.MaxInFlight(maxInFlight),
+					event.MaxProcessingTime(50*time.Millisecond),
+					event.ShutdownTimeout(time.Second),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				go func() {
+					defer GinkgoRecover()
+					Expect(svc.Run()).To(Succeed())
+				}()
+				DeferCleanup(func() {
+					close(release)
+					Expect(svc.Close()).To(Succeed())
+				})
+			}
+		)
+
+		BeforeEach(func() {
+			s = &searchMocks.Searcher{}
+			ch = make(chan raw.Event)
+			release = make(chan struct{})
+			purged = make(chan string, 2)
+
+			release, purged := release, purged
+			s.EXPECT().PurgeItem(mock.Anything).Run(func(ref *provider.Reference) {
+				if ref.GetPath() == "slow" {
+					<-release
+				}
+				purged <- ref.GetPath()
+			})
+		})
+
+		It("continues with the next event after the maximum processing time", func() {
+			run(2)
+
+			ch <- newEvent("slow")
+			ch <- newEvent("fast")
+
+			Eventually(purged, "2s").Should(Receive(Equal("fast")))
+		})
+
+		It("waits for a free slot once the in-flight limit is reached", func() {
+			run(1)
+
+			ch <- newEvent("slow")
+			ch <- newEvent("fast")
+
+			Consistently(purged, "200ms").ShouldNot(Receive())
+
+			release <- struct{}{}
+			Eventually(purged, "2s").Should(Receive(Equal("slow")))
+			Eventually(purged, "2s").Should(Receive(Equal("fast")))
+		})
+	})
+
+	Describe("Close", func() {
+		var (
+			s       *searchMocks.Searcher
+			ch      chan raw.Event
+			trashed chan struct{}
+			start   = func(debounceDuration int, purgeBatchDuration int, shutdownTimeout time.Duration) (event.Service, <-chan error) {
+				stream := rawMocks.NewStream(GinkgoT())
+				stream.EXPECT().Consume(mock.Anything, mock.Anything).Return((<-chan raw.Event)(ch), nil)
+
+				svc, err := event.New(context.Background(), stream, s,
+					event.DebounceDuration(debounceDuration),
+					event.PurgeBatchDuration(purgeBatchDuration),
+					event.NumConsumers(2),
+					event.ConsumerName("search-pull"),
+					event.MaxInFlight(2),
+					event.MaxProcessingTime(time.Second),
+					event.ShutdownTimeout(shutdownTimeout),
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				done := make(chan error, 1)
+				go func() {
+					done <- svc.Run()
+				}()
+				return svc, done
+			}
+			trashEvent = raw.Event{Event: events.Event{Event: events.ItemTrashed{Ref: &provider.Reference{Path: "trashed"}}}}
+			purgeEvent = raw.Event{Event: events.Event{Event: events.ItemPurged{Ref: &provider.Reference{Path: "purged"}}}}
+		)
+
+		BeforeEach(func() {
+			s = searchMocks.NewSearcher(GinkgoT())
+			ch = make(chan raw.Event)
+			trashed = make(chan struct{}, 1)
+		})
+
+		It("runs the pending space indexing and purges before returning", func() {
+			s.EXPECT().TrashItem(mock.Anything, mock.Anything).Run(func(*provider.ResourceId, time.Time) {
+				trashed <- struct{}{}
+			}).Once()
+			s.EXPECT().PurgeItems(mock.Anything).Return(nil).Once()
+			s.EXPECT().IndexSpace(mock.Anything).Return(nil).Once()
+
+			svc, done := start(10000, 10000, time.Second)
+
+			ch <- purgeEvent
+			ch <- trashEvent
+			Eventually(trashed, "2s").Should(Receive())
+
+			Expect(svc.Close()).To(Succeed())
+			Eventually(done, "2s").Should(Receive(BeNil()))
+		})
+
+		It("returns an error if draining exceeds the shutdown timeout", func() {
+			release := make(chan struct{})
+			indexed := make(chan struct{}, 1)
+			s.EXPECT().TrashItem(mock.Anything, mock.Anything).Run(func(*provider.ResourceId, time.Time) {
+				trashed <- struct{}{}
+				<-release
+			}).Once()
+			s.EXPECT().IndexSpace(mock.Anything).Run(func(*provider.StorageSpaceId) {
+				indexed <- struct{}{}
+			}).Return(nil).Once()
+
+			svc, _ := start(50, 0, 100*time.Millisecond)
+
+			ch <- trashEvent
+			Eventually(trashed, "2s").Should(Receive())
+
+			err := svc.Close()
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(err).To(MatchError(ContainSubstring("events in flight")))
+
+			// the event keeps being processed in the background
+			close(release)
+			Eventually(indexed, "2s").Should(Receive())
+		})
+
+		It("does not leak goroutines", func() {
+			before := runtime.NumGoroutine()
+
+			s.EXPECT().TrashItem(mock.Anything, mock.Anything).Run(func(*provider.ResourceId, time.Time) {
+				trashed <- struct{}{}
+			}).Once()
+			s.EXPECT().PurgeItems(mock.Anything).Return(nil).Once()
+			s.EXPECT().IndexSpace(mock.Anything).Return(nil).Once()
+
+			svc, done := start(50, 50, time.Second)
+
+			ch <- purgeEvent
+			ch <- trashEvent
+			Eventually(trashed, "2s").Should(Receive())
+
+			Expect(svc.Close()).To(Succeed())
+			Eventually(done, "2s").Should(Receive(BeNil()))
+			Eventually(runtime.NumGoroutine, "2s").Should(BeNumerically("<=", before))
+		})
+	})
+})
@@ -16,13 +16,15 @@ type Option func(o *Options)
 
 // Options defines the available options for this package.
 type Options struct {
-	Logger          log.Logger
-	Config          *config.Config
-	JWTSecret       string
-	TracerProvider  trace.TracerProvider
-	Metrics         *metrics.Metrics
-	GatewaySelector *pool.Selector[gateway.GatewayAPIClient]
-	Searcher        search.Searcher
+	Logger             log.Logger
+	Config             *config.Config
+	JWTSecret          string
+	PreviousJWTSecrets []string
+	SecretSource       SecretSource
+	TracerProvider     trace.TracerProvider
+	Metrics            *metrics.Metrics
+	GatewaySelector    *pool.Selector[gateway.GatewayAPIClient]
+	Searcher           search.Searcher
 }
 
 func newOptions(opts ...Option) Options {
@@ -56,6 +58,21 @@ func JWTSecret(val string) Option {
 	}
 }
 
+// PreviousJWTSecrets provides a function to set the PreviousJWTSecrets option.
+func PreviousJWTSecrets(val []string) Option {
+	return func(o *Options) {
+		o.PreviousJWTSecrets = val
+	}
+}
+
+// JWTSecretSource provides a function to set the SecretSource option, it takes precedence over the JWTSecret and
+// PreviousJWTSecrets options.
+func JWTSecretSource(val SecretSource) Option {
+	return func(o *Options) {
+		o.SecretSource = val
+	}
+}
+
 // TracerProvider provides a function to set the TracerProvider option
 func TracerProvider(val trace.TracerProvider) Option {
 	return func(o *Options) {
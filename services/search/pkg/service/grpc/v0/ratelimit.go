@@ -0,0 +1,86 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+)
+
+// userRateLimiter limits the searches of every user with a token bucket per user
+type userRateLimiter struct {
+	limit   rate.Limit
+	burst   int
+	idle    time.Duration
+	metrics *metrics.Metrics
+
+	mu        sync.Mutex
+	users     map[string]*userLimiter
+	lastSweep time.Time
+}
+
+type userLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newUserRateLimiter(searchesPerSecond float64, burst int, m *metrics.Metrics) *userRateLimiter {
+	return &userRateLimiter{
+		limit: rate.Limit(searchesPerSecond),
+		burst: burst,
+		// the bucket of a user is full again after this duration, forgetting the user does not change the limit
+		idle:    max(time.Minute, time.Duration(float64(burst)/searchesPerSecond*float64(time.Second))),
+		metrics: m,
+		users:   make(map[string]*userLimiter),
+	}
+}
+
+// Allow reports whether the user may run another search now
+func (l *userRateLimiter) Allow(userID string) bool {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	u, ok := l.users[userID]
+	if !ok {
+		u = &userLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.users[userID] = u
+	}
+	u.lastSeen = now
+
+	allowed := u.limiter.AllowN(now, 1)
+	if l.metrics != nil {
+		l.metrics.UserSearches.WithLabelValues(userID).Inc()
+		if !allowed {
+			l.metrics.UserSearchesThrottled.WithLabelValues(userID).Inc()
+		}
+	}
+
+	return allowed
+}
+
+// sweep forgets the users which have been idle long enough for their bucket to be full again,
+// this keeps the number of tracked users and their metrics bounded.
+func (l *userRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+
+	for userID, u := range l.users {
+		if now.Sub(u.lastSeen) < l.idle {
+			continue
+		}
+
+		delete(l.users, userID)
+		if l.metrics != nil {
+			l.metrics.UserSearches.DeleteLabelValues(userID)
+			l.metrics.UserSearchesThrottled.DeleteLabelValues(userID)
+		}
+	}
+}
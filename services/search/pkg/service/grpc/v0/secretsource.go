@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// SecretSource provides the jwt secrets the tokens of the searches are validated with. Deployments loading the secrets
+// from a secret store implement it, the secrets are requested for every search, so changed secrets are used right away.
+type SecretSource interface {
+	// Secrets returns the current secret and the previous secrets whose tokens are still accepted
+	Secrets() (current string, previous []string, err error)
+}
+
+// staticSecretSource provides secrets which never change
+type staticSecretSource struct {
+	current  string
+	previous []string
+}
+
+// NewStaticSecretSource returns a secret source always providing the given secrets
+func NewStaticSecretSource(current string, previous []string) SecretSource {
+	return staticSecretSource{current: current, previous: previous}
+}
+
+func (s staticSecretSource) Secrets() (string, []string, error) {
+	return s.current, s.previous, nil
+}
+
+// FileSecretSource reads the secrets from a file, the first non-empty line is the current secret and the following
+// ones are the previous secrets. The file is read again once it changed, which is checked at most once per interval.
+type FileSecretSource struct {
+	path     string
+	interval time.Duration
+	logger   log.Logger
+
+	mu       sync.Mutex
+	checked  time.Time
+	modTime  time.Time
+	size     int64
+	current  string
+	previous []string
+	loaded   bool
+}
+
+// NewFileSecretSource returns a secret source reading the secrets from the file, it fails if the file holds no secret
+func NewFileSecretSource(path string, interval time.Duration, logger log.Logger) (*FileSecretSource, error) {
+	s := &FileSecretSource{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+	if _, _, err := s.Secrets(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Secrets returns the secrets of the file. If the changed file can not be read, the secrets read before are returned,
+// a secret store rewriting the file must not fail the searches in the meantime.
+func (s *FileSecretSource) Secrets() (string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.loaded && now.Sub(s.checked) < s.interval {
+		return s.current, s.previous, nil
+	}
+	s.checked = now
+
+	if err := s.reload(); err != nil {
+		if !s.loaded {
+			return "", nil, err
+		}
+		s.logger.Error().Err(err).Str("path", s.path).Msg("failed to reload the jwt secrets, using the previous ones")
+	}
+
+	return s.current, s.previous, nil
+}
+
+// reload reads the file if it changed since it was read the last time
+func (s *FileSecretSource) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat the jwt secret file: %w", err)
+	}
+	if s.loaded && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read the jwt secret file: %w", err)
+	}
+
+	var secrets []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			secrets = append(secrets, line)
+		}
+	}
+	if len(secrets) == 0 {
+		return errors.New("the jwt secret file does not contain a secret")
+	}
+
+	if s.loaded && secrets[0] != s.current {
+		s.logger.Info().Str("path", s.path).Msg("the jwt secret has changed")
+	}
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.current = secrets[0]
+	s.previous = secrets[1:]
+	s.loaded = true
+	return nil
+}
@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	permissions "github.com/cs3org/go-cs3apis/cs3/permissions/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/jellydator/ttlcache/v2"
@@ -15,8 +18,6 @@ import (
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/token"
-	"github.com/opencloud-eu/reva/v2/pkg/token/manager/jwt"
-	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	merrors "go-micro.dev/v4/errors"
 	"go-micro.dev/v4/metadata"
 	grpcmetadata "google.golang.org/grpc/metadata"
@@ -28,6 +29,12 @@ import (
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
+// _adminPermission is the permission which is required to reset the index and to get the indexed documents
+const _adminPermission = "Settings.ReadWrite"
+
+// _maxDocuments is the maximum number of documents which can be requested at once
+const _maxDocuments = 1000
+
 // NewHandler returns a service implementation for Service.
 func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, error) {
 	options := newOptions(opts...)
@@ -44,22 +51,30 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, error) {
 		return nil, err
 	}
 
-	tokenManager, err := jwt.New(map[string]interface{}{
-		"secret":  options.JWTSecret,
-		"expires": int64(24 * 60 * 60),
-	})
+	secrets := options.SecretSource
+	if secrets == nil {
+		secrets = NewStaticSecretSource(options.JWTSecret, options.PreviousJWTSecrets)
+	}
+	tokenManager, err := newReloadingTokenManager(secrets, options.Logger)
 	if err != nil {
 		return nil, err
 	}
 
+	var rateLimiter *userRateLimiter
+	if cfg.RateLimit.Rate > 0 {
+		rateLimiter = newUserRateLimiter(cfg.RateLimit.Rate, cfg.RateLimit.Burst, options.Metrics)
+	}
+
 	return &Service{
 		id:           cfg.GRPC.Namespace + "." + cfg.Service.Name,
 		log:          &options.Logger,
 		searcher:     options.Searcher,
 		cache:        cache,
+		searches:     newSharedSearches(),
 		tokenManager: tokenManager,
 		gws:          options.GatewaySelector,
 		cfg:          cfg,
+		rateLimiter:  rateLimiter,
 	}, nil
 }
 
@@ -69,90 +84,304 @@ type Service struct {
 	log          *log.Logger
 	searcher     search.Searcher
 	cache        *ttlcache.Cache
+	searches     *sharedSearches
 	tokenManager token.Manager
 	gws          *pool.Selector[gateway.GatewayAPIClient]
 	cfg          *config.Config
+	rateLimiter  *userRateLimiter
 }
 
 // Search handles the search
 func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *searchsvc.SearchResponse) error {
-	// Get token from the context (go-micro) and make it known to the reva client too (grpc)
-	t, ok := metadata.Get(ctx, revactx.TokenHeader)
-	if !ok {
-		s.log.Error().Msg("Could not get token from context")
-		return errors.New("could not get token from context")
-	}
-	ctx = grpcmetadata.AppendToOutgoingContext(ctx, revactx.TokenHeader, t)
-
-	// unpack user
-	u, _, err := s.tokenManager.DismantleToken(ctx, t)
+	ctx, u, err := s.userContext(ctx)
 	if err != nil {
 		return err
 	}
-	ctx = revactx.ContextSetUser(ctx, u)
 
-	key := cacheKey(in.Query, in.PageSize, in.Ref, u)
-	res, ok := s.FromCache(key)
-	if !ok {
-		var err error
-		res, err = s.searcher.Search(ctx, &searchsvc.SearchRequest{
-			Query:    in.Query,
-			PageSize: in.PageSize,
-			Ref:      in.Ref,
+	if s.rateLimiter != nil && !s.isServiceAccount(u) && !s.rateLimiter.Allow(u.GetId().GetOpaqueId()) {
+		return merrors.New(s.id, "too many searches, try again later", http.StatusTooManyRequests)
+	}
+
+	key := cacheKey(in.Query, in.PageSize, in.Ref, in.CountOnly, in.NoHighlight, in.GroupByParent, in.IdsOnly, in.WithBreadcrumbs, u)
+	// identical concurrent searches share one execution, the cache only helps once it has finished
+	v, err := s.searches.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		if res, ok := s.FromCache(key); ok {
+			return res, nil
+		}
+
+		// the execution is shared, it is only canceled once all callers are gone
+		res, err := s.searcher.Search(ctx, &searchsvc.SearchRequest{
+			Query:           in.Query,
+			PageSize:        in.PageSize,
+			Ref:             in.Ref,
+			CountOnly:       in.CountOnly,
+			NoHighlight:     in.NoHighlight,
+			GroupByParent:   in.GroupByParent,
+			IdsOnly:         in.IdsOnly,
+			WithBreadcrumbs: in.WithBreadcrumbs,
 		})
 		if err != nil {
-			switch err.(type) {
-			case errtypes.BadRequest:
-				return merrors.BadRequest(s.id, "%s", err.Error())
-			default:
-				return merrors.InternalServerError(s.id, "%s", err.Error())
-			}
+			return nil, err
 		}
 
 		s.Cache(key, res)
+		return res, nil
+	})
+	switch {
+	case errors.Is(err, search.ErrIndexNotReady):
+		// nothing has been indexed yet, so nothing is found. The empty result is not cached,
+		// the next search already finds the resources indexed in the meantime.
+		return nil
+	case errors.Is(err, search.ErrBackendUnavailable):
+		return merrors.New(s.id, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, search.ErrTimeout):
+		return merrors.New(s.id, err.Error(), http.StatusGatewayTimeout)
+	case err != nil:
+		switch err.(type) {
+		case errtypes.BadRequest:
+			return merrors.BadRequest(s.id, "%s", err.Error())
+		default:
+			return merrors.InternalServerError(s.id, "%s", err.Error())
+		}
 	}
 
+	res := v.(*searchsvc.SearchResponse)
 	out.Matches = res.Matches
 	out.TotalMatches = res.TotalMatches
 	out.NextPageToken = res.NextPageToken
+	out.Suggestions = res.Suggestions
+	out.Parents = res.Parents
+	out.IdMatches = res.IdMatches
+	return nil
+}
+
+// SumField sums a numeric property up over the resources matching the query, like the total size of the matching documents.
+func (s Service) SumField(ctx context.Context, in *searchsvc.SumFieldRequest, out *searchsvc.SumFieldResponse) error {
+	ctx, u, err := s.userContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.rateLimiter != nil && !s.isServiceAccount(u) && !s.rateLimiter.Allow(u.GetId().GetOpaqueId()) {
+		return merrors.New(s.id, "too many searches, try again later", http.StatusTooManyRequests)
+	}
+
+	res, err := s.searcher.SumField(ctx, in)
+	switch {
+	case errors.Is(err, search.ErrIndexNotReady):
+		// nothing has been indexed yet, so nothing is summed up
+		return nil
+	case errors.Is(err, search.ErrBackendUnavailable):
+		return merrors.New(s.id, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, search.ErrTimeout):
+		return merrors.New(s.id, err.Error(), http.StatusGatewayTimeout)
+	case err != nil:
+		switch err.(type) {
+		case errtypes.BadRequest:
+			return merrors.BadRequest(s.id, "%s", err.Error())
+		case errtypes.NotSupported:
+			return merrors.New(s.id, err.Error(), http.StatusNotImplemented)
+		default:
+			return merrors.InternalServerError(s.id, "%s", err.Error())
+		}
+	}
+
+	out.Sum = res.GetSum()
 	return nil
 }
 
 // IndexSpace (re)indexes all resources of a given space.
 func (s Service) IndexSpace(_ context.Context, in *searchsvc.IndexSpaceRequest, _ *searchsvc.IndexSpaceResponse) error {
+	if in.GetRebuild() {
+		if in.GetSpaceId() != "" {
+			return merrors.BadRequest(s.id, "rebuilding the index is only possible for all spaces")
+		}
+		return s.searcher.RebuildIndex()
+	}
+
 	if in.GetSpaceId() != "" {
 		return s.searcher.IndexSpace(&provider.StorageSpaceId{OpaqueId: in.GetSpaceId()})
 	}
 
 	// index all spaces instead
-	gwc, err := s.gws.Next()
-	if err != nil {
+	return s.searcher.IndexAllSpaces()
+}
+
+// GetDocument returns the indexed document of a resource, it is only available if enabled for debugging.
+func (s Service) GetDocument(ctx context.Context, in *searchsvc.GetDocumentRequest, out *searchsvc.GetDocumentResponse) error {
+	if !s.cfg.Debug.Documents {
+		return merrors.Forbidden(s.id, "getting indexed documents is disabled")
+	}
+	if err := s.checkAdmin(ctx, "getting indexed documents"); err != nil {
 		return err
 	}
+	if in.GetId() == "" {
+		return merrors.BadRequest(s.id, "no id provided")
+	}
+
+	resource, err := s.searcher.GetDocument(in.GetId())
+	if err != nil {
+		switch err.(type) {
+		case errtypes.NotFound:
+			return merrors.NotFound(s.id, "%s", err.Error())
+		default:
+			return merrors.InternalServerError(s.id, "%s", err.Error())
+		}
+	}
 
-	ctx, err := utils.GetServiceUserContext(s.cfg.ServiceAccount.ServiceAccountID, gwc, s.cfg.ServiceAccount.ServiceAccountSecret)
+	document, err := json.Marshal(resource)
 	if err != nil {
+		return merrors.InternalServerError(s.id, "%s", err.Error())
+	}
+
+	out.Document = string(document)
+	return nil
+}
+
+// GetDocuments returns the indexed documents of several resources and the ids which are not indexed,
+// it is only available if enabled for debugging.
+func (s Service) GetDocuments(ctx context.Context, in *searchsvc.GetDocumentsRequest, out *searchsvc.GetDocumentsResponse) error {
+	if !s.cfg.Debug.Documents {
+		return merrors.Forbidden(s.id, "getting indexed documents is disabled")
+	}
+	if err := s.checkAdmin(ctx, "getting indexed documents"); err != nil {
 		return err
 	}
+	switch {
+	case len(in.GetIds()) == 0:
+		return merrors.BadRequest(s.id, "no ids provided")
+	case len(in.GetIds()) > _maxDocuments:
+		return merrors.BadRequest(s.id, "at most %d ids can be requested at once", _maxDocuments)
+	}
 
-	resp, err := gwc.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{})
+	resources, missing, err := s.searcher.GetDocuments(in.GetIds())
 	if err != nil {
+		return merrors.InternalServerError(s.id, "%s", err.Error())
+	}
+
+	out.Documents = make([]string, 0, len(resources))
+	for _, resource := range resources {
+		document, err := json.Marshal(resource)
+		if err != nil {
+			return merrors.InternalServerError(s.id, "%s", err.Error())
+		}
+		out.Documents = append(out.Documents, string(document))
+	}
+	out.MissingIds = missing
+
+	return nil
+}
+
+// ResetIndex deletes all documents by replacing the index with a new and empty one and returns the number of
+// deleted documents, it is only available if enabled and has to be confirmed.
+func (s Service) ResetIndex(ctx context.Context, in *searchsvc.ResetIndexRequest, out *searchsvc.ResetIndexResponse) error {
+	if !s.cfg.AllowIndexReset {
+		return merrors.Forbidden(s.id, "resetting the index is disabled")
+	}
+	if err := s.checkAdmin(ctx, "resetting the index"); err != nil {
 		return err
 	}
+	if !in.GetConfirm() {
+		return merrors.BadRequest(s.id, "resetting the index deletes all documents and has to be confirmed")
+	}
 
-	if resp.GetStatus().GetCode() != rpc.Code_CODE_OK {
-		return errors.New(resp.GetStatus().GetMessage())
+	count, err := s.searcher.ResetIndex()
+	if err != nil {
+		switch err.(type) {
+		case errtypes.NotSupported:
+			return merrors.New(s.id, err.Error(), http.StatusNotImplemented)
+		default:
+			return merrors.InternalServerError(s.id, "%s", err.Error())
+		}
 	}
 
-	for _, space := range resp.GetStorageSpaces() {
-		if err := s.searcher.IndexSpace(space.GetId()); err != nil {
-			return err
+	out.DocumentCount = count
+	return nil
+}
+
+// Capabilities returns the features, query fields and limits of the search service, clients use them
+// to only offer what the deployment supports.
+func (s Service) Capabilities(_ context.Context, _ *searchsvc.CapabilitiesRequest, out *searchsvc.CapabilitiesResponse) error {
+	out.Engine = s.cfg.Engine.Type
+	out.Features = []string{}
+	if s.cfg.Extractor.Type == "tika" {
+		out.Features = append(out.Features, "content")
+		if s.cfg.IndexVersions > 0 {
+			out.Features = append(out.Features, "versions")
 		}
 	}
+	if s.cfg.IndexSharedWith {
+		out.Features = append(out.Features, "sharedwith")
+	}
+	if s.cfg.MetadataWebhook.Addr != "" {
+		out.Features = append(out.Features, "metadata")
+	}
+	if s.cfg.Engine.Transliteration {
+		out.Features = append(out.Features, "transliteration")
+	}
+	if s.cfg.Engine.Phonetic {
+		out.Features = append(out.Features, "phonetic")
+	}
+	out.QueryFields = s.cfg.QueryFields
+	out.MaxPageSize = s.cfg.MaxPageSize
+	return nil
+}
+
+// userContext returns the context with the user of the token of the request, the token is passed on to the reva clients
+func (s Service) userContext(ctx context.Context) (context.Context, *user.User, error) {
+	// Get token from the context (go-micro) and make it known to the reva client too (grpc)
+	t, ok := metadata.Get(ctx, revactx.TokenHeader)
+	if !ok {
+		s.log.Error().Msg("Could not get token from context")
+		return nil, nil, errors.New("could not get token from context")
+	}
+	ctx = grpcmetadata.AppendToOutgoingContext(ctx, revactx.TokenHeader, t)
+
+	// unpack user
+	u, _, err := s.tokenManager.DismantleToken(ctx, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return revactx.ContextSetUser(ctx, u), u, nil
+}
+
+// checkAdmin returns a forbidden error unless the user of the request has the permission to manage the settings,
+// by default only admins have it
+func (s Service) checkAdmin(ctx context.Context, action string) error {
+	ctx, u, err := s.userContext(ctx)
+	if err != nil {
+		return merrors.Unauthorized(s.id, "%s", err.Error())
+	}
 
+	gatewayClient, err := s.gws.Next()
+	if err != nil {
+		return merrors.InternalServerError(s.id, "%s", err.Error())
+	}
+	res, err := gatewayClient.CheckPermission(ctx, &permissions.CheckPermissionRequest{
+		Permission: _adminPermission,
+		SubjectRef: &permissions.SubjectReference{
+			Spec: &permissions.SubjectReference_UserId{
+				UserId: u.GetId(),
+			},
+		},
+	})
+	switch {
+	case err != nil:
+		return merrors.InternalServerError(s.id, "%s", err.Error())
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		s.log.Info().Str("userid", u.GetId().GetOpaqueId()).Str("action", action).Msg("denied an admin action to a user without the permission")
+		return merrors.Forbidden(s.id, "%s is only allowed to admins", action)
+	}
 	return nil
 }
 
+// isServiceAccount reports whether the user is a service account, service accounts are not rate limited
+func (s Service) isServiceAccount(u *user.User) bool {
+	return u.GetId().GetType() == user.UserType_USER_TYPE_SERVICE ||
+		(s.cfg.ServiceAccount.ServiceAccountID != "" && u.GetId().GetOpaqueId() == s.cfg.ServiceAccount.ServiceAccountID)
+}
+
 // FromCache pulls a search result from cache
 func (s Service) FromCache(key string) (*searchsvc.SearchResponse, bool) {
 	v, err := s.cache.Get(key)
@@ -170,6 +399,6 @@ func (s Service) Cache(key string, res *searchsvc.SearchResponse) {
 	_ = s.cache.Set(key, res)
 }
 
-func cacheKey(query string, pagesize int32, ref *v0.Reference, user *user.User) string {
-	return fmt.Sprintf("%s|%d|%s$%s!%s/%s|%s", query, pagesize, ref.GetResourceId().GetStorageId(), ref.GetResourceId().GetSpaceId(), ref.GetResourceId().GetOpaqueId(), ref.GetPath(), user.GetId().GetOpaqueId())
+func cacheKey(query string, pagesize int32, ref *v0.Reference, countOnly, noHighlight, groupByParent, idsOnly, withBreadcrumbs bool, user *user.User) string {
+	return fmt.Sprintf("%s|%d|%s$%s!%s/%s|%t|%t|%t|%t|%t|%s", query, pagesize, ref.GetResourceId().GetStorageId(), ref.GetResourceId().GetSpaceId(), ref.GetResourceId().GetOpaqueId(), ref.GetPath(), countOnly, noHighlight, groupByParent, idsOnly, withBreadcrumbs, user.GetId().GetOpaqueId())
 }
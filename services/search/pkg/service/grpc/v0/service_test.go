@@ -0,0 +1,445 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	permissions "github.com/cs3org/go-cs3apis/cs3/permissions/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/token/manager/jwt"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/mock"
+	merrors "go-micro.dev/v4/errors"
+	"go-micro.dev/v4/metadata"
+	"google.golang.org/grpc"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+	searchMocks "github.com/opencloud-eu/opencloud/services/search/pkg/search/mocks"
+	service "github.com/opencloud-eu/opencloud/services/search/pkg/service/grpc/v0"
+)
+
+var _ = Describe("Searchprovider", func() {
+	const (
+		jwtSecret         = "secret"
+		previousJWTSecret = "previous-secret"
+	)
+
+	var (
+		handler       searchsvc.SearchProviderHandler
+		searcher      *searchMocks.Searcher
+		gatewayClient *cs3mocks.GatewayAPIClient
+		m             *metrics.Metrics
+		cfg           *config.Config
+
+		signedContext = func(secret string, u *userv1beta1.User) context.Context {
+			tokenManager, err := jwt.New(map[string]interface{}{"secret": secret})
+			Expect(err).ToNot(HaveOccurred())
+			t, err := tokenManager.MintToken(context.Background(), u, nil)
+			Expect(err).ToNot(HaveOccurred())
+			return metadata.Set(context.Background(), revactx.TokenHeader, t)
+		}
+		userContext = func(u *userv1beta1.User) context.Context {
+			return signedContext(jwtSecret, u)
+		}
+		adminContext = func() context.Context {
+			return userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "admin"}})
+		}
+		doSearch = func(ctx context.Context, query string) error {
+			return handler.Search(ctx, &searchsvc.SearchRequest{Query: query}, &searchsvc.SearchResponse{})
+		}
+	)
+
+	BeforeEach(func() {
+		searcher = &searchMocks.Searcher{}
+		searcher.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchResponse{}, nil)
+		m = metrics.New()
+
+		cfg = &config.Config{}
+		cfg.Engine.Type = "bleve"
+		cfg.Extractor.Type = "basic"
+		cfg.QueryFields = []string{"name", "content", "versions"}
+		cfg.RateLimit = config.RateLimit{Rate: 0.001, Burst: 2}
+		cfg.ServiceAccount.ServiceAccountID = "service-account-id"
+
+		pool.RemoveSelector("GatewaySelector" + "eu.opencloud.api.gateway")
+		gatewayClient = &cs3mocks.GatewayAPIClient{}
+		gatewayClient.On("CheckPermission", mock.Anything, mock.Anything).Return(func(_ context.Context, req *permissions.CheckPermissionRequest, _ ...grpc.CallOption) (*permissions.CheckPermissionResponse, error) {
+			if req.GetPermission() == "Settings.ReadWrite" && req.GetSubjectRef().GetUserId().GetOpaqueId() == "admin" {
+				return &permissions.CheckPermissionResponse{Status: status.NewOK(context.Background())}, nil
+			}
+			return &permissions.CheckPermissionResponse{Status: status.NewPermissionDenied(context.Background(), nil, "permission denied")}, nil
+		})
+
+		var err error
+		handler, err = service.NewHandler(
+			service.Config(cfg),
+			service.JWTSecret(jwtSecret),
+			service.PreviousJWTSecrets([]string{previousJWTSecret}),
+			service.Metrics(m),
+			service.Searcher(searcher),
+			service.GatewaySelector(pool.GetSelector[gateway.GatewayAPIClient](
+				"GatewaySelector",
+				"eu.opencloud.api.gateway",
+				func(cc grpc.ClientConnInterface) gateway.GatewayAPIClient { return gatewayClient },
+			)),
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects the searches of users exceeding the rate limit", func() {
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}})
+		throttled := &dto.Metric{}
+		Expect(m.UserSearchesThrottled.WithLabelValues("einstein").Write(throttled)).To(Succeed())
+
+		Expect(doSearch(ctx, "first")).To(Succeed())
+		Expect(doSearch(ctx, "second")).To(Succeed())
+
+		err := doSearch(ctx, "third")
+		Expect(err).To(HaveOccurred())
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusTooManyRequests))
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 2)
+
+		metric := &dto.Metric{}
+		Expect(m.UserSearchesThrottled.WithLabelValues("einstein").Write(metric)).To(Succeed())
+		Expect(metric.GetCounter().GetValue()).To(Equal(throttled.GetCounter().GetValue() + 1))
+
+		// other users have their own limit
+		Expect(doSearch(userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "marie"}}), "third")).To(Succeed())
+	})
+
+	It("does not limit service accounts", func() {
+		for _, u := range []*userv1beta1.User{
+			{Id: &userv1beta1.UserId{OpaqueId: "service-account-id"}},
+			{Id: &userv1beta1.UserId{OpaqueId: "other-service", Type: userv1beta1.UserType_USER_TYPE_SERVICE}},
+		} {
+			ctx := userContext(u)
+			for _, query := range []string{"first", "second", "third"} {
+				Expect(doSearch(ctx, query)).To(Succeed())
+			}
+		}
+	})
+
+	It("accepts the tokens signed with a previous secret", func() {
+		u := &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}}
+
+		Expect(doSearch(signedContext(previousJWTSecret, u), "first")).To(Succeed())
+		Expect(doSearch(signedContext(jwtSecret, u), "second")).To(Succeed())
+		Expect(doSearch(signedContext("unknown-secret", u), "third")).ToNot(Succeed())
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 2)
+	})
+
+	It("picks up a changed jwt secret file without restarting", func() {
+		u := &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}}
+		path := filepath.Join(GinkgoT().TempDir(), "jwt-secret")
+		Expect(os.WriteFile(path, []byte("first-secret\n"), 0600)).To(Succeed())
+
+		cfg.RateLimit.Rate = 0
+
+		secrets, err := service.NewFileSecretSource(path, 0, log.NopLogger())
+		Expect(err).ToNot(HaveOccurred())
+		handler, err = service.NewHandler(
+			service.Config(cfg),
+			service.JWTSecretSource(secrets),
+			service.Searcher(searcher),
+			service.GatewaySelector(pool.GetSelector[gateway.GatewayAPIClient](
+				"GatewaySelector",
+				"eu.opencloud.api.gateway",
+				func(cc grpc.ClientConnInterface) gateway.GatewayAPIClient { return gatewayClient },
+			)),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doSearch(signedContext("first-secret", u), "first")).To(Succeed())
+
+		// the rotated secret is accepted right away, the tokens of the first secret during the overlap
+		Expect(os.WriteFile(path, []byte("second-secret\nfirst-secret\n"), 0600)).To(Succeed())
+		Expect(doSearch(signedContext("second-secret", u), "second")).To(Succeed())
+		Expect(doSearch(signedContext("first-secret", u), "third")).To(Succeed())
+
+		// once the overlap has ended, the tokens of the first secret are rejected
+		Expect(os.WriteFile(path, []byte("second-secret"), 0600)).To(Succeed())
+		Expect(doSearch(signedContext("first-secret", u), "fourth")).ToNot(Succeed())
+
+		// a file without a secret keeps the secrets read before
+		Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+		Expect(doSearch(signedContext("second-secret", u), "fifth")).To(Succeed())
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 4)
+	})
+
+	It("shares one search of identical concurrent queries", func() {
+		release := make(chan struct{})
+		searcher.On("Search", mock.Anything, mock.Anything).Unset()
+		searcher.On("Search", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { <-release }).
+			Return(&searchsvc.SearchResponse{TotalMatches: 1}, nil)
+
+		// service accounts are not rate limited
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "service-account-id"}})
+
+		const n = 10
+		var wg sync.WaitGroup
+		results := make([]*searchsvc.SearchResponse, n)
+		errs := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = &searchsvc.SearchResponse{}
+				errs[i] = handler.Search(ctx, &searchsvc.SearchRequest{Query: "foo"}, results[i])
+			}()
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for i := range n {
+			Expect(errs[i]).ToNot(HaveOccurred())
+			Expect(results[i].GetTotalMatches()).To(BeEquivalentTo(1))
+		}
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 1)
+
+		// other queries are searched on their own
+		Expect(doSearch(ctx, "bar")).To(Succeed())
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 2)
+	})
+
+	It("cancels a shared search once all callers are gone", func() {
+		release := make(chan struct{})
+		canceled := make(chan struct{})
+		searcher.On("Search", mock.Anything, mock.Anything).Unset()
+		searcher.On("Search", mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, _ *searchsvc.SearchRequest) (*searchsvc.SearchResponse, error) {
+				select {
+				case <-release:
+					return &searchsvc.SearchResponse{TotalMatches: 1}, nil
+				case <-ctx.Done():
+					close(canceled)
+					return nil, ctx.Err()
+				}
+			})
+
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "service-account-id"}})
+		search := func(ctx context.Context) <-chan error {
+			done := make(chan error, 1)
+			go func() {
+				done <- handler.Search(ctx, &searchsvc.SearchRequest{Query: "foo"}, &searchsvc.SearchResponse{})
+			}()
+			return done
+		}
+
+		// a caller which is gone does not abort the search for the others
+		firstCtx, cancelFirst := context.WithCancel(ctx)
+		first := search(firstCtx)
+		time.Sleep(50 * time.Millisecond)
+		secondCtx, cancelSecond := context.WithCancel(ctx)
+		second := search(secondCtx)
+		time.Sleep(50 * time.Millisecond)
+
+		cancelFirst()
+		Eventually(first).Should(Receive(MatchError(ContainSubstring(context.Canceled.Error()))))
+		Consistently(canceled, 100*time.Millisecond).ShouldNot(BeClosed())
+
+		// the search is canceled once the last caller is gone
+		cancelSecond()
+		Eventually(second).Should(Receive(MatchError(ContainSubstring(context.Canceled.Error()))))
+		Eventually(canceled).Should(BeClosed())
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 1)
+
+		// the following searches are not joined to the canceled one
+		close(release)
+		Expect(doSearch(ctx, "foo")).To(Succeed())
+		searcher.AssertNumberOfCalls(GinkgoT(), "Search", 2)
+	})
+
+	Context("with a failing search engine", func() {
+		var ctx context.Context
+
+		BeforeEach(func() {
+			searcher.On("Search", mock.Anything, mock.Anything).Unset()
+			ctx = userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}})
+		})
+
+		It("finds nothing if the index is not ready", func() {
+			searcher.On("Search", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to search: %w", search.ErrIndexNotReady))
+
+			res := &searchsvc.SearchResponse{}
+			Expect(handler.Search(ctx, &searchsvc.SearchRequest{Query: "foo"}, res)).To(Succeed())
+			Expect(res.GetMatches()).To(BeEmpty())
+			Expect(res.GetTotalMatches()).To(BeZero())
+
+			// the empty result is not cached
+			Expect(doSearch(ctx, "foo")).To(Succeed())
+			searcher.AssertNumberOfCalls(GinkgoT(), "Search", 2)
+		})
+
+		It("reports an unavailable backend", func() {
+			searcher.On("Search", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to search: %w", search.ErrBackendUnavailable))
+
+			err := doSearch(ctx, "foo")
+			Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusServiceUnavailable))
+		})
+
+		It("reports a search which timed out", func() {
+			searcher.On("Search", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w after 30s: %w", search.ErrTimeout, context.DeadlineExceeded))
+
+			err := doSearch(ctx, "foo")
+			Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusGatewayTimeout))
+		})
+
+		It("reports other errors as internal errors", func() {
+			searcher.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("unexpected"))
+
+			err := doSearch(ctx, "foo")
+			Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusInternalServerError))
+		})
+	})
+
+	It("sums a field up over the matching resources", func() {
+		req := &searchsvc.SumFieldRequest{Query: "type:file", Field: "size"}
+		searcher.On("SumField", mock.Anything, req).Return(&searchsvc.SumFieldResponse{Sum: 42}, nil)
+
+		res := &searchsvc.SumFieldResponse{}
+		Expect(handler.SumField(userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}}), req, res)).To(Succeed())
+		Expect(res.GetSum()).To(BeEquivalentTo(42))
+
+		err := handler.SumField(context.Background(), req, &searchsvc.SumFieldResponse{})
+		Expect(err).To(HaveOccurred())
+		searcher.AssertNumberOfCalls(GinkgoT(), "SumField", 1)
+	})
+
+	It("reports the fields which can not be summed up and unsupported engines", func() {
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}})
+		searcher.On("SumField", mock.Anything, mock.Anything).Return(nil, errtypes.BadRequest("the property 'name' can not be summed up")).Once()
+		searcher.On("SumField", mock.Anything, mock.Anything).Return(nil, errtypes.NotSupported("the search engine can not aggregate fields")).Once()
+
+		err := handler.SumField(ctx, &searchsvc.SumFieldRequest{Query: "foo", Field: "name"}, &searchsvc.SumFieldResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusBadRequest))
+
+		err = handler.SumField(ctx, &searchsvc.SumFieldRequest{Query: "foo", Field: "size"}, &searchsvc.SumFieldResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusNotImplemented))
+	})
+
+	It("rebuilds the index of all spaces", func() {
+		searcher.On("RebuildIndex").Return(nil)
+
+		Expect(handler.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{Rebuild: true}, &searchsvc.IndexSpaceResponse{})).To(Succeed())
+		searcher.AssertCalled(GinkgoT(), "RebuildIndex")
+
+		err := handler.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{SpaceId: "storageid$spaceid!spaceid", Rebuild: true}, &searchsvc.IndexSpaceResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusBadRequest))
+		searcher.AssertNumberOfCalls(GinkgoT(), "RebuildIndex", 1)
+	})
+
+	It("returns the indexed documents and the missing ids", func() {
+		searcher.On("GetDocuments", []string{"1$2!3", "1$2!4"}).Return([]*search.Resource{{ID: "1$2!3"}}, []string{"1$2!4"}, nil)
+		req := &searchsvc.GetDocumentsRequest{Ids: []string{"1$2!3", "1$2!4"}}
+
+		err := handler.GetDocuments(adminContext(), req, &searchsvc.GetDocumentsResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusForbidden))
+
+		cfg.Debug.Documents = true
+		res := &searchsvc.GetDocumentsResponse{}
+		Expect(handler.GetDocuments(adminContext(), req, res)).To(Succeed())
+		Expect(res.GetDocuments()).To(HaveLen(1))
+		Expect(res.GetDocuments()[0]).To(ContainSubstring(`"ID":"1$2!3"`))
+		Expect(res.GetMissingIds()).To(Equal([]string{"1$2!4"}))
+	})
+
+	It("returns the indexed documents only to admins", func() {
+		cfg.Debug.Documents = true
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}})
+
+		err := handler.GetDocument(ctx, &searchsvc.GetDocumentRequest{Id: "1$2!3"}, &searchsvc.GetDocumentResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusForbidden))
+		err = handler.GetDocument(context.Background(), &searchsvc.GetDocumentRequest{Id: "1$2!3"}, &searchsvc.GetDocumentResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusUnauthorized))
+		err = handler.GetDocuments(ctx, &searchsvc.GetDocumentsRequest{Ids: []string{"1$2!3"}}, &searchsvc.GetDocumentsResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusForbidden))
+		err = handler.GetDocuments(context.Background(), &searchsvc.GetDocumentsRequest{Ids: []string{"1$2!3"}}, &searchsvc.GetDocumentsResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusUnauthorized))
+
+		searcher.AssertNotCalled(GinkgoT(), "GetDocument", mock.Anything)
+		searcher.AssertNotCalled(GinkgoT(), "GetDocuments", mock.Anything)
+	})
+
+	It("resets the index once enabled and confirmed", func() {
+		searcher.On("ResetIndex").Return(uint64(42), nil)
+		req := &searchsvc.ResetIndexRequest{Confirm: true}
+
+		err := handler.ResetIndex(adminContext(), req, &searchsvc.ResetIndexResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusForbidden))
+
+		cfg.AllowIndexReset = true
+		err = handler.ResetIndex(adminContext(), &searchsvc.ResetIndexRequest{}, &searchsvc.ResetIndexResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusBadRequest))
+		searcher.AssertNotCalled(GinkgoT(), "ResetIndex")
+
+		res := &searchsvc.ResetIndexResponse{}
+		Expect(handler.ResetIndex(adminContext(), req, res)).To(Succeed())
+		Expect(res.GetDocumentCount()).To(BeEquivalentTo(42))
+	})
+
+	It("resets the index only for admins", func() {
+		searcher.On("ResetIndex").Return(uint64(42), nil)
+		cfg.AllowIndexReset = true
+		req := &searchsvc.ResetIndexRequest{Confirm: true}
+
+		ctx := userContext(&userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein"}})
+		err := handler.ResetIndex(ctx, req, &searchsvc.ResetIndexResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusForbidden))
+
+		err = handler.ResetIndex(context.Background(), req, &searchsvc.ResetIndexResponse{})
+		Expect(merrors.FromError(err).Code).To(BeEquivalentTo(http.StatusUnauthorized))
+
+		searcher.AssertNotCalled(GinkgoT(), "ResetIndex")
+	})
+
+	It("advertises the capabilities of the configuration", func() {
+		capabilities := func() *searchsvc.CapabilitiesResponse {
+			res := &searchsvc.CapabilitiesResponse{}
+			Expect(handler.Capabilities(context.Background(), &searchsvc.CapabilitiesRequest{}, res)).To(Succeed())
+			return res
+		}
+
+		res := capabilities()
+		Expect(res.GetEngine()).To(Equal("bleve"))
+		Expect(res.GetFeatures()).To(BeEmpty())
+		Expect(res.GetQueryFields()).To(Equal([]string{"name", "content", "versions"}))
+		Expect(res.GetMaxPageSize()).To(BeZero())
+
+		cfg.Engine.Type = "open-search"
+		cfg.Extractor.Type = "tika"
+		cfg.IndexVersions = 3
+		cfg.IndexSharedWith = true
+		cfg.Engine.Transliteration = true
+		cfg.Engine.Phonetic = true
+		cfg.MaxPageSize = 50
+
+		res = capabilities()
+		Expect(res.GetEngine()).To(Equal("open-search"))
+		Expect(res.GetFeatures()).To(ConsistOf("content", "versions", "sharedwith", "transliteration", "phonetic"))
+		Expect(res.GetMaxPageSize()).To(BeEquivalentTo(50))
+
+		// the versions are only indexed together with the content
+		cfg.Extractor.Type = "basic"
+		Expect(capabilities().GetFeatures()).To(ConsistOf("sharedwith", "transliteration", "phonetic"))
+	})
+})
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedSearches runs identical concurrent searches once. The shared search is canceled once the last of its callers
+// is gone, a single canceled caller neither aborts it for the others nor keeps it running for nobody.
+type sharedSearches struct {
+	group singleflight.Group
+
+	mu    sync.Mutex
+	calls map[string]*sharedSearch
+}
+
+// sharedSearch is the context of a shared search and the number of callers waiting for it
+type sharedSearch struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+func newSharedSearches() *sharedSearches {
+	return &sharedSearches{
+		calls: make(map[string]*sharedSearch),
+	}
+}
+
+// Do runs fn once for all concurrent callers of the same key and returns its result, or the error of the context
+// if the caller is gone before. The context passed to fn carries the values of the context of the caller starting
+// the search and is canceled once all callers are gone.
+func (s *sharedSearches) Do(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	s.mu.Lock()
+	call, ok := s.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &sharedSearch{ctx: callCtx, cancel: cancel}
+		s.calls[key] = call
+	}
+	call.waiters++
+	// the search is joined while the lock is held, so the call can't be forgotten in between
+	results := s.group.DoChan(key, func() (interface{}, error) {
+		defer s.finish(key, call)
+		return fn(call.ctx)
+	})
+	s.mu.Unlock()
+
+	defer s.leave(key, call)
+
+	select {
+	case res := <-results:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// finish removes the call once its search is done, the following callers start a new search
+func (s *sharedSearches) finish(key string, call *sharedSearch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.calls[key] == call {
+		delete(s.calls, key)
+	}
+}
+
+// leave cancels the search of the call once its last caller is gone
+func (s *sharedSearches) leave(key string, call *sharedSearch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	call.cancel()
+	if s.calls[key] == call {
+		// the search is still running and canceled now, the following callers must not join it
+		delete(s.calls, key)
+		s.group.Forget(key)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	auth "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/token"
+	"github.com/opencloud-eu/reva/v2/pkg/token/manager/jwt"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+// newTokenManager returns a token manager which mints tokens with the secret and accepts the tokens signed with the
+// secret or one of the previous secrets, so the tokens minted before a secret rotation stay valid during the overlap.
+func newTokenManager(secret string, previousSecrets []string) (token.Manager, error) {
+	managers := make([]token.Manager, 0, len(previousSecrets)+1)
+	for _, s := range append([]string{secret}, previousSecrets...) {
+		if s == "" {
+			continue
+		}
+
+		manager, err := jwt.New(map[string]interface{}{
+			"secret":  s,
+			"expires": int64(24 * 60 * 60),
+		})
+		if err != nil {
+			return nil, err
+		}
+		managers = append(managers, manager)
+	}
+
+	if len(managers) == 1 {
+		return managers[0], nil
+	}
+
+	return rotatingTokenManager{managers: managers}, nil
+}
+
+// rotatingTokenManager mints tokens with the first manager and dismantles them with the first manager accepting them
+type rotatingTokenManager struct {
+	managers []token.Manager
+}
+
+func (m rotatingTokenManager) MintToken(ctx context.Context, u *user.User, scope map[string]*auth.Scope) (string, error) {
+	return m.managers[0].MintToken(ctx, u, scope)
+}
+
+func (m rotatingTokenManager) DismantleToken(ctx context.Context, t string) (*user.User, map[string]*auth.Scope, error) {
+	var firstErr error
+	for _, manager := range m.managers {
+		u, scope, err := manager.DismantleToken(ctx, t)
+		if err == nil {
+			return u, scope, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// the error of the current secret is the most meaningful one
+	return nil, nil, firstErr
+}
+
+// reloadingTokenManager validates the tokens with the secrets of the source, the token manager is replaced once the
+// secrets change, so rotated secrets are used without restarting the service.
+type reloadingTokenManager struct {
+	source SecretSource
+	logger log.Logger
+
+	mu      sync.Mutex
+	secrets []string
+	manager token.Manager
+}
+
+// newReloadingTokenManager returns a token manager using the secrets of the source, it fails if the source does not
+// provide a valid secret
+func newReloadingTokenManager(source SecretSource, logger log.Logger) (*reloadingTokenManager, error) {
+	m := &reloadingTokenManager{source: source, logger: logger}
+	if _, err := m.current(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// current returns the token manager of the current secrets. If the source fails after it provided secrets before,
+// the token manager of these secrets is used, an unavailable secret store must not fail every search.
+func (m *reloadingTokenManager) current() (token.Manager, error) {
+	current, previous, err := m.source.Secrets()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		if m.manager == nil {
+			return nil, fmt.Errorf("failed to get the jwt secrets: %w", err)
+		}
+		m.logger.Error().Err(err).Msg("failed to get the jwt secrets, using the previous ones")
+		return m.manager, nil
+	}
+	if current == "" {
+		return nil, errors.New("the jwt secret must not be empty")
+	}
+
+	secrets := append([]string{current}, previous...)
+	if m.manager != nil && slices.Equal(secrets, m.secrets) {
+		return m.manager, nil
+	}
+
+	manager, err := newTokenManager(current, previous)
+	if err != nil {
+		return nil, err
+	}
+	m.secrets = secrets
+	m.manager = manager
+	return manager, nil
+}
+
+func (m *reloadingTokenManager) MintToken(ctx context.Context, u *user.User, scope map[string]*auth.Scope) (string, error) {
+	manager, err := m.current()
+	if err != nil {
+		return "", err
+	}
+	return manager.MintToken(ctx, u, scope)
+}
+
+func (m *reloadingTokenManager) DismantleToken(ctx context.Context, t string) (*user.User, map[string]*auth.Scope, error) {
+	manager, err := m.current()
+	if err != nil {
+		return nil, nil, err
+	}
+	return manager.DismantleToken(ctx, t)
+}
@@ -82,6 +82,9 @@ func (g Webdav) Search(w http.ResponseWriter, r *http.Request) {
 		switch e.Code {
 		case http.StatusBadRequest:
 			renderError(w, r, errBadRequest(e.Detail))
+		case http.StatusTooManyRequests:
+			addRetryAfterHeader(w)
+			renderError(w, r, errTooManyRequests(e.Detail))
 		default:
 			renderError(w, r, errInternalError(err.Error()))
 		}
@@ -198,6 +201,12 @@ func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getlastmodified", match.Entity.LastModifiedTime.AsTime().Format(constants.RFC1123)))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:permissions", match.Entity.Permissions))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:highlights", match.Entity.Highlights))
+	if match.Entity.SpaceName != "" {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:space-name", match.Entity.SpaceName))
+	}
+	if match.Entity.MatchedVersion != "" {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:matched-version", match.Entity.MatchedVersion))
+	}
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getcontenttype", match.Entity.MimeType))
 	_, isSupportedMimeType := thumbnail.SupportedMimeTypes[match.Entity.MimeType]
 	if isSupportedMimeType {